@@ -2,9 +2,15 @@ package main
 
 import (
 	"context"
+	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"os"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
 	"twitterxmediabatchdownloader/backend"
 
 	"github.com/wailsapp/wails/v2/pkg/runtime"
@@ -15,6 +21,32 @@ type App struct {
 	ctx            context.Context
 	downloadCtx    context.Context
 	downloadCancel context.CancelFunc
+	downloadJobID  string // Queue ID of the running download, for PauseDownload
+
+	// Cancels the running ExtractTimelines
+	timelinesCancel context.CancelFunc
+
+	// Pending conflict question, answered by ResolveConflict
+	conflictMu     sync.Mutex
+	conflictID     string
+	conflictAnswer chan backend.ConflictDecision
+
+	// Pending large download confirmation, answered by ConfirmDownload
+	confirmMu     sync.Mutex
+	confirmID     string
+	confirmAnswer chan bool
+
+	// Clipboard watcher, on while the setting is enabled
+	clipboardMu sync.Mutex
+	clipboard   *backend.ClipboardWatcher
+
+	// Account watcher, on while the setting is enabled
+	watchMu sync.Mutex
+	watcher *backend.AccountWatcher
+
+	// LAN media server, on while the setting is enabled
+	serverMu    sync.Mutex
+	mediaServer *backend.MediaServer
 }
 
 // NewApp creates a new App application struct
@@ -34,6 +66,9 @@ func (a *App) startup(ctx context.Context) {
 
 // shutdown is called when the app is closing
 func (a *App) shutdown(ctx context.Context) {
+	a.SetClipboardWatch(false)
+	a.SetAccountWatch(false, 0, "")
+	a.SetMediaServer(false, 0, "")
 	backend.CloseDB()
 	// Kill any running extractor processes
 	backend.KillAllExtractorProcesses()
@@ -47,24 +82,56 @@ func (a *App) CleanupExtractorProcesses() {
 
 // TimelineRequest represents the request structure for timeline extraction
 type TimelineRequest struct {
-	Username     string `json:"username"`
-	AuthToken    string `json:"auth_token"`
-	TimelineType string `json:"timeline_type"`
-	BatchSize    int    `json:"batch_size"`
-	Page         int    `json:"page"`
-	MediaType    string `json:"media_type"`
-	Retweets     bool   `json:"retweets"`
-	Cursor       string `json:"cursor,omitempty"` // Resume from this cursor position
+	Username     string                 `json:"username"`
+	AuthToken    string                 `json:"auth_token"`
+	TimelineType string                 `json:"timeline_type"`
+	BatchSize    int                    `json:"batch_size"`
+	Page         int                    `json:"page"`
+	MediaType    string                 `json:"media_type"`
+	Retweets     bool                   `json:"retweets"`
+	Cursor       string                 `json:"cursor,omitempty"`    // Resume from this cursor position
+	Filter       backend.TimelineFilter `json:"filter,omitempty"`    // Optional filters applied during conversion
+	DateZone     string                 `json:"date_zone,omitempty"` // Convert dates to utc or local ("" = as reported)
+
+	// Auth token of an account that can see age-restricted media, used to fetch them again
+	RestrictedAuthToken string `json:"restricted_auth_token,omitempty"`
+
+	// Submit every fetched tweet to the Wayback Machine in the background
+	SaveToWayback bool `json:"save_to_wayback,omitempty"`
+
+	// Return the timeline grouped by tweet in "tweets" instead of one entry per media
+	Grouped bool `json:"grouped,omitempty"`
 }
 
 // DateRangeRequest represents the request structure for date range extraction
 type DateRangeRequest struct {
-	Username    string `json:"username"`
-	AuthToken   string `json:"auth_token"`
-	StartDate   string `json:"start_date"`
-	EndDate     string `json:"end_date"`
-	MediaFilter string `json:"media_filter"`
-	Retweets    bool   `json:"retweets"`
+	Username    string                 `json:"username"`
+	AuthToken   string                 `json:"auth_token"`
+	StartDate   string                 `json:"start_date"`
+	EndDate     string                 `json:"end_date"`
+	MediaFilter string                 `json:"media_filter"`
+	Retweets    bool                   `json:"retweets"`
+	Filter      backend.TimelineFilter `json:"filter,omitempty"`    // Optional filters applied during conversion
+	DateZone    string                 `json:"date_zone,omitempty"` // Convert dates to utc or local ("" = as reported)
+
+	// Auth token of an account that can see age-restricted media, used to fetch them again
+	RestrictedAuthToken string `json:"restricted_auth_token,omitempty"`
+
+	// Submit every fetched tweet to the Wayback Machine in the background
+	SaveToWayback bool `json:"save_to_wayback,omitempty"`
+
+	// Return the timeline grouped by tweet in "tweets" instead of one entry per media
+	Grouped bool `json:"grouped,omitempty"`
+}
+
+// GetTimelineTypes returns the timeline types that can be fetched
+func (a *App) GetTimelineTypes() []backend.TimelineTypeInfo {
+	return backend.GetTimelineTypes()
+}
+
+// EstimateJob estimates the requests, duration and rate limit waits of a fetch before starting it
+func (a *App) EstimateJob(req backend.JobEstimateRequest) (*backend.JobEstimate, error) {
+	return backend.EstimateJob(req)
 }
 
 // ExtractTimeline extracts media from user timeline
@@ -77,7 +144,30 @@ func (a *App) ExtractTimeline(req TimelineRequest) (string, error) {
 		return "", fmt.Errorf("auth token is required")
 	}
 
-	backendReq := backend.TimelineRequest{
+	started := time.Now()
+	response, err := backend.ExtractTimeline(a.toBackendTimelineRequest(req))
+	backend.RecordExtraction(req.Username, started, response, err)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract timeline: %v", err)
+	}
+	if req.SaveToWayback {
+		backend.QueueWaybackSaves(response.Timeline, req.Username)
+	}
+	if req.Grouped {
+		response.GroupByTweet()
+	}
+
+	jsonData, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode response: %v", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// toBackendTimelineRequest builds the backend request of a timeline request
+func (a *App) toBackendTimelineRequest(req TimelineRequest) backend.TimelineRequest {
+	return backend.TimelineRequest{
 		Username:     req.Username,
 		AuthToken:    req.AuthToken,
 		TimelineType: req.TimelineType,
@@ -86,11 +176,46 @@ func (a *App) ExtractTimeline(req TimelineRequest) (string, error) {
 		MediaType:    req.MediaType,
 		Retweets:     req.Retweets,
 		Cursor:       req.Cursor,
+		Filter:       req.Filter,
+		DateZone:     req.DateZone,
+
+		RestrictedAuthToken: req.RestrictedAuthToken,
+		OnProgress:          a.emitExtractProgress,
+	}
+}
+
+// ExtractTimelines fetches the timelines of several accounts in one run, the accounts taking
+// turns batch by batch; the state of an account after each turn is sent as a
+// "timelines-progress" event. StopTimelines ends the run after the batch being fetched
+func (a *App) ExtractTimelines(reqs []TimelineRequest) (string, error) {
+	backendReqs := make([]backend.TimelineRequest, len(reqs))
+	for i, req := range reqs {
+		if req.AuthToken == "" {
+			return "", fmt.Errorf("auth token is required")
+		}
+		backendReqs[i] = a.toBackendTimelineRequest(req)
 	}
 
-	response, err := backend.ExtractTimeline(backendReq)
+	var ctx context.Context
+	ctx, a.timelinesCancel = context.WithCancel(context.Background())
+	defer func() { a.timelinesCancel = nil }()
+
+	response, err := backend.ExtractTimelines(ctx, backendReqs, func(result backend.TimelinesResult) {
+		runtime.EventsEmit(a.ctx, "timelines-progress", result)
+	})
 	if err != nil {
-		return "", fmt.Errorf("failed to extract timeline: %v", err)
+		return "", fmt.Errorf("failed to extract timelines: %v", err)
+	}
+	for i, result := range response.Results {
+		if result.Response == nil {
+			continue
+		}
+		if reqs[i].SaveToWayback {
+			backend.QueueWaybackSaves(result.Response.Timeline, result.Username)
+		}
+		if reqs[i].Grouped {
+			result.Response.GroupByTweet()
+		}
 	}
 
 	jsonData, err := json.MarshalIndent(response, "", "  ")
@@ -101,6 +226,22 @@ func (a *App) ExtractTimeline(req TimelineRequest) (string, error) {
 	return string(jsonData), nil
 }
 
+// StopTimelines stops a running ExtractTimelines after the batch being fetched
+func (a *App) StopTimelines() bool {
+	if a.timelinesCancel != nil {
+		a.timelinesCancel()
+		a.timelinesCancel = nil
+		return true
+	}
+	return false
+}
+
+// emitExtractProgress sends the progress of a running extraction to the frontend as
+// "extract-progress" events
+func (a *App) emitExtractProgress(progress backend.ExtractProgress) {
+	runtime.EventsEmit(a.ctx, "extract-progress", progress)
+}
+
 // ExtractDateRange extracts media based on date range
 func (a *App) ExtractDateRange(req DateRangeRequest) (string, error) {
 	if req.Username == "" {
@@ -123,12 +264,85 @@ func (a *App) ExtractDateRange(req DateRangeRequest) (string, error) {
 		EndDate:     req.EndDate,
 		MediaFilter: req.MediaFilter,
 		Retweets:    req.Retweets,
+		Filter:      req.Filter,
+		DateZone:    req.DateZone,
+
+		RestrictedAuthToken: req.RestrictedAuthToken,
+		OnProgress:          a.emitExtractProgress,
 	}
 
+	started := time.Now()
 	response, err := backend.ExtractDateRange(backendReq)
+	backend.RecordExtraction(req.Username, started, response, err)
 	if err != nil {
 		return "", fmt.Errorf("failed to extract date range: %v", err)
 	}
+	if req.SaveToWayback {
+		backend.QueueWaybackSaves(response.Timeline, "")
+	}
+	if req.Grouped {
+		response.GroupByTweet()
+	}
+
+	jsonData, err := json.MarshalIndent(response, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to encode response: %v", err)
+	}
+
+	return string(jsonData), nil
+}
+
+// ParallelRequest represents the request structure for sharded (parallel) extraction
+type ParallelRequest struct {
+	Username    string                 `json:"username"`
+	AuthToken   string                 `json:"auth_token"`
+	StartDate   string                 `json:"start_date,omitempty"` // Optional, defaults to the whole account history
+	EndDate     string                 `json:"end_date,omitempty"`
+	MediaFilter string                 `json:"media_filter"`
+	Retweets    bool                   `json:"retweets"`
+	Shards      int                    `json:"shards"`
+	Workers     int                    `json:"workers"`
+	Filter      backend.TimelineFilter `json:"filter,omitempty"`    // Optional filters applied during conversion
+	DateZone    string                 `json:"date_zone,omitempty"` // Convert dates to utc or local ("" = as reported)
+
+	// Auth token of an account that can see age-restricted media, used to fetch them again
+	RestrictedAuthToken string `json:"restricted_auth_token,omitempty"`
+}
+
+// ExtractParallel extracts a large account by fetching date shards concurrently
+func (a *App) ExtractParallel(req ParallelRequest) (string, error) {
+	if req.Username == "" {
+		return "", fmt.Errorf("username is required")
+	}
+	if req.AuthToken == "" {
+		return "", fmt.Errorf("auth token is required")
+	}
+
+	backendReq := backend.ParallelRequest{
+		Username:    req.Username,
+		AuthToken:   req.AuthToken,
+		StartDate:   req.StartDate,
+		EndDate:     req.EndDate,
+		MediaFilter: req.MediaFilter,
+		Retweets:    req.Retweets,
+		Shards:      req.Shards,
+		Workers:     req.Workers,
+		Filter:      req.Filter,
+		DateZone:    req.DateZone,
+
+		RestrictedAuthToken: req.RestrictedAuthToken,
+	}
+
+	started := time.Now()
+	response, err := backend.ExtractParallel(backendReq)
+	var merged *backend.TwitterResponse
+	if response != nil {
+		merged = response.TwitterResponse
+	}
+	backend.RecordExtraction(req.Username, started, merged, err)
+	if err != nil {
+		return "", fmt.Errorf("failed to extract timeline: %v", err)
+	}
 
 	jsonData, err := json.MarshalIndent(response, "", "  ")
 	if err != nil {
@@ -189,14 +403,59 @@ type MediaItemRequest struct {
 	Content          string                `json:"content,omitempty"`           // Tweet text content (for text-only tweets)
 	OriginalFilename string                `json:"original_filename,omitempty"` // Original filename from API
 	AuthorUsername   string                `json:"author_username,omitempty"`   // Username of tweet author (for bookmarks and likes)
+	Width            int                   `json:"width,omitempty"`
+	Height           int                   `json:"height,omitempty"`
+	Engagement       int                   `json:"engagement,omitempty"` // Likes + retweets
+	FavoriteCount    int                   `json:"favorite_count,omitempty"`
+	RetweetCount     int                   `json:"retweet_count,omitempty"`
+	ReplyCount       int                   `json:"reply_count,omitempty"`
+	ViewCount        int                   `json:"view_count,omitempty"`
+	BookmarkCount    int                   `json:"bookmark_count,omitempty"`
+	Card             *backend.TweetCard    `json:"card,omitempty"`        // Poll or link preview
+	MediaIndex       int                   `json:"media_index,omitempty"` // 1-based position of the media in its tweet
 }
 
 // DownloadMediaWithMetadataRequest represents the request for downloading media with metadata
 type DownloadMediaWithMetadataRequest struct {
-	Items     []MediaItemRequest `json:"items"`
-	OutputDir string             `json:"output_dir"`
-	Username  string             `json:"username"`
-	Proxy     string             `json:"proxy,omitempty"` // Optional proxy URL (e.g., http://proxy:port or socks5://proxy:port)
+	Items             []MediaItemRequest  `json:"items"`
+	OutputDir         string              `json:"output_dir"`
+	Username          string              `json:"username"`
+	Proxy             string              `json:"proxy,omitempty"`              // Optional proxy URL (e.g., http://proxy:port or socks5://proxy:port)
+	Orientation       string              `json:"orientation,omitempty"`        // Optional orientation filter: portrait, landscape, square
+	MinAspectRatio    float64             `json:"min_aspect_ratio,omitempty"`   // Optional minimum aspect ratio (long side / short side)
+	Notify            bool                `json:"notify,omitempty"`             // Show a desktop notification when the batch completes or fails
+	SanitizePaths     bool                `json:"sanitize_paths,omitempty"`     // Automatically apply preflight path fixes
+	ConflictPolicy    string              `json:"conflict_policy,omitempty"`    // Existing files with different content: skip, overwrite, keep_both, ask
+	MaxArchiveGB      float64             `json:"max_archive_gb,omitempty"`     // Optional cap on the account archive size (0 = no cap)
+	ArchiveCap        string              `json:"archive_cap,omitempty"`        // When over the cap: stop, prune_oldest, prune_engagement
+	Order             string              `json:"order,omitempty"`              // Download order: "" (as listed) or newest_first
+	GraceMinutes      int                 `json:"grace_minutes,omitempty"`      // Defer tweets younger than this and re-resolve their media before downloading
+	AuthToken         string              `json:"auth_token,omitempty"`         // Used to re-resolve media of very new tweets
+	ConfirmAboveGB    float64             `json:"confirm_above_gb,omitempty"`   // Ask before downloading if the dry-run estimate is above this (0 = never)
+	MinFreeGB         float64             `json:"min_free_gb,omitempty"`        // Pause the download when the output volume has less free space (0 = small reserve only)
+	VideoPreview      string              `json:"video_preview,omitempty"`      // Save a poster or contact_sheet of each downloaded video ("" = off)
+	SFTP              *backend.SFTPConfig `json:"sftp,omitempty"`               // Stream files to this server instead of the output folder
+	ConvertWebP       string              `json:"convert_webp,omitempty"`       // Convert WebP images to jpg or png ("" = keep WebP)
+	WebPQuality       int                 `json:"webp_quality,omitempty"`       // JPEG quality for converted WebP images (1-100)
+	ValidateMedia     bool                `json:"validate_media,omitempty"`     // Probe downloads with ffprobe and count corrupt files as failed
+	WaybackFallback   bool                `json:"wayback_fallback,omitempty"`   // Download dead media links from the Wayback Machine if archived
+	DateZone          string              `json:"date_zone,omitempty"`          // Time zone of filename timestamps and embedded dates: utc or local ("" = UTC, no embedded date)
+	FilenameTemplate  string              `json:"filename_template,omitempty"`  // File name without extension, e.g. {sort_index}_{index} ("" = {username}_{timestamp}_{tweet_id}_{index})
+	CollisionSuffix   string              `json:"collision_suffix,omitempty"`   // Suffix for files that would take a used name: counter (_2, _3, ...) or hash (short content hash)
+	ProtectedFallback bool                `json:"protected_fallback,omitempty"` // Download to the Downloads folder if Controlled Folder Access blocks the output folder
+	TweetsJSONL       bool                `json:"tweets_jsonl,omitempty"`       // Also write the tweets of the media to tweets.jsonl in each account folder
+	TweetsTXT         bool                `json:"tweets_txt,omitempty"`         // Also write each tweet of the media to a .txt file in the tweets folder of its account
+	MaxFileMB         float64             `json:"max_file_mb,omitempty"`        // Skip files larger than this (0 = no limit)
+	MaxJobGB          float64             `json:"max_job_gb,omitempty"`         // Stop the download after saving this much, the rest stays queued (0 = no limit)
+	Archive           string              `json:"archive,omitempty"`            // Write one zip or tar archive per account instead of loose files ("" = loose files)
+
+	// Upload files to an S3-compatible bucket or a WebDAV server instead of the output folder
+	S3     *backend.S3Config     `json:"s3,omitempty"`
+	WebDAV *backend.WebDAVConfig `json:"webdav,omitempty"`
+
+	// Shell commands run for every saved file and once per batch (details in TXMBD_* variables)
+	FileHook  string `json:"file_hook,omitempty"`
+	BatchHook string `json:"batch_hook,omitempty"`
 }
 
 // DownloadMediaResponse represents the response for download operation
@@ -205,7 +464,12 @@ type DownloadMediaResponse struct {
 	Downloaded int    `json:"downloaded"`
 	Skipped    int    `json:"skipped"`
 	Failed     int    `json:"failed"`
+	Missing    int    `json:"missing,omitempty"`   // Dead links (deleted tweets, suspended accounts), listed in missing.csv
+	Recovered  int    `json:"recovered,omitempty"` // Dead links downloaded from the Wayback Machine (included in Downloaded)
 	Message    string `json:"message"`
+	QueueID    string `json:"queue_id,omitempty"`   // Queue holding pending items if the download was stopped
+	Declined   bool   `json:"declined,omitempty"`   // The user declined the download after the dry-run diff
+	OutputDir  string `json:"output_dir,omitempty"` // Set if the files went to a fallback folder instead of the requested one
 }
 
 // DownloadMedia downloads media files from URLs (legacy)
@@ -258,25 +522,20 @@ type DownloadProgress struct {
 type DownloadItemStatus struct {
 	TweetID int64  `json:"tweet_id"`
 	Index   int    `json:"index"`
-	Status  string `json:"status"` // "success", "failed", "skipped"
+	Status  string `json:"status"` // "success", "failed", "skipped", "missing", "recovered"
 }
 
-// DownloadMediaWithMetadata downloads media files with proper naming and categorization
-func (a *App) DownloadMediaWithMetadata(req DownloadMediaWithMetadataRequest) (DownloadMediaResponse, error) {
-	if len(req.Items) == 0 {
-		return DownloadMediaResponse{
-			Success: false,
-			Message: "No items provided",
-		}, fmt.Errorf("no items provided")
-	}
-
-	outputDir := req.OutputDir
-	if outputDir == "" {
-		outputDir = backend.GetDefaultDownloadPath()
+// downloadOutputDir returns the request output directory or the default download path
+func downloadOutputDir(req DownloadMediaWithMetadataRequest) string {
+	if req.OutputDir == "" {
+		return backend.GetDefaultDownloadPath()
 	}
+	return req.OutputDir
+}
 
-	// Convert request items to backend items
-	// For bookmarks and likes, use author_username from each item if available
+// toBackendMediaItems converts request items to backend items
+// For bookmarks and likes, use author_username from each item if available
+func toBackendMediaItems(req DownloadMediaWithMetadataRequest) []backend.MediaItem {
 	items := make([]backend.MediaItem, len(req.Items))
 	for i, item := range req.Items {
 		// Use original filename from API if available, otherwise extract from URL
@@ -300,11 +559,151 @@ func (a *App) DownloadMediaWithMetadata(req DownloadMediaWithMetadataRequest) (D
 			Username:         username,
 			Content:          item.Content,
 			OriginalFilename: originalFilename,
+			Width:            item.Width,
+			Height:           item.Height,
+			Engagement:       item.Engagement,
+			FavoriteCount:    item.FavoriteCount,
+			RetweetCount:     item.RetweetCount,
+			ReplyCount:       item.ReplyCount,
+			ViewCount:        item.ViewCount,
+			BookmarkCount:    item.BookmarkCount,
+			Card:             item.Card,
+			MediaIndex:       item.MediaIndex,
+		}
+	}
+	return items
+}
+
+// toDownloadOptions builds the backend download options from the request
+func toDownloadOptions(req DownloadMediaWithMetadataRequest) backend.DownloadOptions {
+	return backend.DownloadOptions{
+		Filter: backend.MediaFilter{
+			Orientation:    req.Orientation,
+			MinAspectRatio: req.MinAspectRatio,
+		},
+		SanitizePaths:     req.SanitizePaths,
+		Conflict:          req.ConflictPolicy,
+		MaxArchiveBytes:   int64(req.MaxArchiveGB * 1024 * 1024 * 1024),
+		ArchiveCapPolicy:  req.ArchiveCap,
+		Order:             req.Order,
+		GraceMinutes:      req.GraceMinutes,
+		AuthToken:         req.AuthToken,
+		ConfirmAboveBytes: int64(req.ConfirmAboveGB * 1024 * 1024 * 1024),
+		MinFreeBytes:      int64(req.MinFreeGB * 1024 * 1024 * 1024),
+		VideoPreview:      req.VideoPreview,
+		SFTP:              req.SFTP,
+		ConvertWebP:       req.ConvertWebP,
+		WebPQuality:       req.WebPQuality,
+		ValidateMedia:     req.ValidateMedia,
+		WaybackFallback:   req.WaybackFallback,
+		DateZone:          req.DateZone,
+		FilenameTemplate:  req.FilenameTemplate,
+		CollisionSuffix:   req.CollisionSuffix,
+		TweetsJSONL:       req.TweetsJSONL,
+		TweetsTXT:         req.TweetsTXT,
+		ProtectedFallback: req.ProtectedFallback,
+		MaxFileBytes:      int64(req.MaxFileMB * 1024 * 1024),
+		MaxJobBytes:       int64(req.MaxJobGB * 1024 * 1024 * 1024),
+		Archive:           req.Archive,
+		S3:                req.S3,
+		WebDAV:            req.WebDAV,
+		FileHook:          req.FileHook,
+		BatchHook:         req.BatchHook,
+	}
+}
+
+// PreflightDownload simulates all target paths of a download job and reports
+// length/character/case collision problems with proposed fixes, without downloading anything
+func (a *App) PreflightDownload(req DownloadMediaWithMetadataRequest) (backend.PreflightReport, error) {
+	if len(req.Items) == 0 {
+		return backend.PreflightReport{}, fmt.Errorf("no items provided")
+	}
+	return backend.PreflightDownload(toBackendMediaItems(req), downloadOutputDir(req), req.Username, toDownloadOptions(req)), nil
+}
+
+// DryRunDownload reports what a download job would add to the archive (new tweets and files,
+// estimated size, authors involved) without downloading anything
+func (a *App) DryRunDownload(req DownloadMediaWithMetadataRequest) (backend.SyncDiff, error) {
+	if len(req.Items) == 0 {
+		return backend.SyncDiff{}, fmt.Errorf("no items provided")
+	}
+	return backend.DryRunDownload(context.Background(), toBackendMediaItems(req), downloadOutputDir(req), req.Username, req.Proxy, toDownloadOptions(req)), nil
+}
+
+// DownloadMediaWithMetadata downloads media files with proper naming and categorization
+func (a *App) DownloadMediaWithMetadata(req DownloadMediaWithMetadataRequest) (DownloadMediaResponse, error) {
+	if len(req.Items) == 0 {
+		return DownloadMediaResponse{
+			Success: false,
+			Message: "No items provided",
+		}, fmt.Errorf("no items provided")
+	}
+
+	outputDir := downloadOutputDir(req)
+	items := toBackendMediaItems(req)
+	opts := toDownloadOptions(req)
+	// Pending items are persisted under this ID if the download is stopped
+	opts.QueueID = backend.NewQueueID(req.Username)
+
+	return a.runDownload(items, outputDir, req.Username, req.Proxy, opts, req.Notify)
+}
+
+// DownloadSelection downloads the chosen entries of a fetched timeline with the options of req (its items are ignored)
+func (a *App) DownloadSelection(entries []backend.TimelineEntry, req DownloadMediaWithMetadataRequest) (DownloadMediaResponse, error) {
+	req.Items = make([]MediaItemRequest, 0, len(entries))
+	for _, entry := range entries {
+		req.Items = append(req.Items, MediaItemRequest{
+			URL:              entry.URL,
+			Date:             entry.Date,
+			TweetID:          entry.TweetID,
+			Type:             entry.Type,
+			Content:          entry.Content,
+			OriginalFilename: entry.OriginalFilename,
+			AuthorUsername:   entry.AuthorUsername,
+			Width:            entry.Width,
+			Height:           entry.Height,
+			Engagement:       entry.FavoriteCount + entry.RetweetCount,
+			FavoriteCount:    entry.FavoriteCount,
+			RetweetCount:     entry.RetweetCount,
+			ReplyCount:       entry.ReplyCount,
+			ViewCount:        entry.ViewCount,
+			BookmarkCount:    entry.BookmarkCount,
+			Card:             entry.Card,
+			MediaIndex:       entry.MediaIndex,
+		})
+	}
+	return a.DownloadMediaWithMetadata(req)
+}
+
+// runDownload runs a download batch, emitting progress and per-item status events to the frontend
+func (a *App) runDownload(items []backend.MediaItem, outputDir, username, proxy string, opts backend.DownloadOptions, notify bool) (DownloadMediaResponse, error) {
+	// Windows may block the output folder (Controlled Folder Access)
+	fallbackDir := ""
+	if !opts.RemoteOutput() {
+		if err := backend.CheckOutputWritable(outputDir); err != nil {
+			var protected *backend.ProtectedFolderError
+			if !errors.As(err, &protected) || !opts.ProtectedFallback || protected.Fallback == "" {
+				return DownloadMediaResponse{Success: false, Message: err.Error()}, err
+			}
+			fmt.Printf("Warning: %v - downloading to %s instead\n", err, protected.Fallback)
+			outputDir = protected.Fallback
+			fallbackDir = protected.Fallback
 		}
 	}
 
 	// Create cancellable context
 	a.downloadCtx, a.downloadCancel = context.WithCancel(context.Background())
+	a.downloadJobID = opts.QueueID
+
+	if opts.Conflict == backend.ConflictAsk {
+		opts.Resolver = a.askConflict
+	}
+	opts.OnPrune = func(path string) {
+		runtime.EventsEmit(a.ctx, "archive-pruned", path)
+	}
+	if opts.ConfirmAboveBytes > 0 {
+		opts.Confirm = a.askDownloadConfirm
+	}
 
 	// Progress callback
 	progressCallback := func(current, total int) {
@@ -320,7 +719,14 @@ func (a *App) DownloadMediaWithMetadata(req DownloadMediaWithMetadataRequest) (D
 	}
 
 	// Per-item status callback
+	var missing, recovered int64
 	itemStatusCallback := func(tweetID int64, index int, status string) {
+		switch status {
+		case "missing":
+			atomic.AddInt64(&missing, 1)
+		case "recovered":
+			atomic.AddInt64(&recovered, 1)
+		}
 		runtime.EventsEmit(a.ctx, "download-item-status", DownloadItemStatus{
 			TweetID: tweetID,
 			Index:   index,
@@ -328,173 +734,1220 @@ func (a *App) DownloadMediaWithMetadata(req DownloadMediaWithMetadataRequest) (D
 		})
 	}
 
-	downloaded, skipped, failed, err := backend.DownloadMediaWithMetadataProgressAndStatus(items, outputDir, req.Username, progressCallback, itemStatusCallback, a.downloadCtx, req.Proxy)
+	downloadCtx := a.downloadCtx
+	downloaded, skipped, failed, err := backend.DownloadMediaWithMetadataProgressAndStatus(items, outputDir, username, progressCallback, itemStatusCallback, downloadCtx, proxy, opts)
+	// Stopped with StopDownload: neither a failure nor a completed download
+	stopped := errors.Is(err, context.Canceled) || downloadCtx.Err() != nil
+	if err == backend.ErrDownloadDeclined {
+		a.downloadCancel = nil
+		return DownloadMediaResponse{
+			Success:  false,
+			Message:  "Download declined",
+			Declined: true,
+		}, nil
+	}
 	if err != nil {
+		if notify && stopped {
+			a.notifyDesktop("Download stopped", fmt.Sprintf("@%s: %d downloaded before it was stopped", username, downloaded))
+		} else if notify {
+			a.notifyDesktop("Download failed", fmt.Sprintf("@%s: %v", username, err))
+		}
 		return DownloadMediaResponse{
 			Success:    false,
 			Downloaded: downloaded,
 			Skipped:    skipped,
 			Failed:     failed,
+			Missing:    int(missing),
+			Recovered:  int(recovered),
 			Message:    err.Error(),
+			QueueID:    opts.QueueID,
 		}, err
 	}
 
 	// Clear cancel function
 	a.downloadCancel = nil
 
+	// Remember where the account's archive lives so it can be moved later
+	if !opts.RemoteOutput() && username != "" {
+		backend.SetArchivePath(username, filepath.Join(outputDir, username))
+	}
+
+	if notify && stopped {
+		a.notifyDesktop("Download stopped", fmt.Sprintf("@%s: %d downloaded before it was stopped", username, downloaded))
+	} else if notify {
+		a.notifyDesktop("Download complete", fmt.Sprintf("@%s: %d downloaded, %d skipped, %d failed", username, downloaded, skipped, failed))
+	}
+
+	message := fmt.Sprintf("Downloaded %d files, %d skipped, %d failed", downloaded, skipped, failed)
+	if recovered > 0 {
+		message += fmt.Sprintf(", %d recovered from the Wayback Machine", recovered)
+	}
+	if missing > 0 {
+		message += fmt.Sprintf(", %d gone (listed in missing.csv)", missing)
+	}
+	if fallbackDir != "" {
+		message += fmt.Sprintf(" (saved to %s, the download folder is protected by Controlled Folder Access)", fallbackDir)
+	}
 	return DownloadMediaResponse{
 		Success:    true,
 		Downloaded: downloaded,
 		Skipped:    skipped,
 		Failed:     failed,
-		Message:    fmt.Sprintf("Downloaded %d files, %d skipped, %d failed", downloaded, skipped, failed),
+		Missing:    int(missing),
+		Recovered:  int(recovered),
+		Message:    message,
+		QueueID:    opts.QueueID,
+		OutputDir:  fallbackDir,
 	}, nil
 }
 
-// StopDownload cancels the current download operation
-func (a *App) StopDownload() bool {
-	if a.downloadCancel != nil {
-		a.downloadCancel()
-		a.downloadCancel = nil
-		return true
+// askConflict emits a "download-conflict" event and waits for the frontend to call ResolveConflict
+func (a *App) askConflict(ctx context.Context, conflict backend.FileConflict) backend.ConflictDecision {
+	answer := make(chan backend.ConflictDecision, 1)
+	a.conflictMu.Lock()
+	a.conflictID = conflict.ID
+	a.conflictAnswer = answer
+	a.conflictMu.Unlock()
+
+	defer func() {
+		a.conflictMu.Lock()
+		a.conflictID = ""
+		a.conflictAnswer = nil
+		a.conflictMu.Unlock()
+	}()
+
+	runtime.EventsEmit(a.ctx, "download-conflict", conflict)
+
+	select {
+	case decision := <-answer:
+		return decision
+	case <-ctx.Done():
+		return backend.ConflictDecision{Action: backend.ConflictSkip}
 	}
-	return false
 }
 
-// Database functions
+// ResolveConflict answers the pending download conflict
+// action: skip, overwrite, keep_both. Returns false if no conflict with this ID is pending
+func (a *App) ResolveConflict(id string, action string, applyToAll bool) bool {
+	a.conflictMu.Lock()
+	defer a.conflictMu.Unlock()
+	if a.conflictAnswer == nil || a.conflictID != id {
+		return false
+	}
+	a.conflictAnswer <- backend.ConflictDecision{Action: action, ApplyToAll: applyToAll}
+	a.conflictAnswer = nil
+	return true
+}
 
-// SaveAccountToDB saves account data to database
-func (a *App) SaveAccountToDB(username, name, profileImage string, totalMedia int, responseJSON string, mediaType string) error {
-	return backend.SaveAccount(username, name, profileImage, totalMedia, responseJSON, mediaType)
+// askDownloadConfirm emits a "download-confirm" event with the dry-run diff and waits for the frontend to call ConfirmDownload
+func (a *App) askDownloadConfirm(ctx context.Context, diff backend.SyncDiff) bool {
+	answer := make(chan bool, 1)
+	a.confirmMu.Lock()
+	a.confirmID = diff.ID
+	a.confirmAnswer = answer
+	a.confirmMu.Unlock()
+
+	defer func() {
+		a.confirmMu.Lock()
+		a.confirmID = ""
+		a.confirmAnswer = nil
+		a.confirmMu.Unlock()
+	}()
+
+	runtime.EventsEmit(a.ctx, "download-confirm", diff)
+
+	select {
+	case ok := <-answer:
+		return ok
+	case <-ctx.Done():
+		return false
+	}
 }
 
-// SaveAccountToDBWithStatus saves account data with cursor and completion status for resume capability
-func (a *App) SaveAccountToDBWithStatus(username, name, profileImage string, totalMedia int, responseJSON string, mediaType string, cursor string, completed bool) error {
-	return backend.SaveAccountWithStatus(username, name, profileImage, totalMedia, responseJSON, mediaType, cursor, completed)
+// ConfirmDownload answers the pending large download confirmation
+// Returns false if no confirmation with this ID is pending
+func (a *App) ConfirmDownload(id string, proceed bool) bool {
+	a.confirmMu.Lock()
+	defer a.confirmMu.Unlock()
+	if a.confirmAnswer == nil || a.confirmID != id {
+		return false
+	}
+	a.confirmAnswer <- proceed
+	a.confirmAnswer = nil
+	return true
 }
 
-// GetAllAccountsFromDB returns all saved accounts
-func (a *App) GetAllAccountsFromDB() ([]backend.AccountListItem, error) {
-	return backend.GetAllAccounts()
+// ResumeQueueResponse is the result of resuming a persisted queue
+type ResumeQueueResponse struct {
+	DownloadMediaResponse
+	InvalidLines []backend.QueueLineError `json:"invalid_lines"` // Lines of the queue file that were skipped
 }
 
-// GetAccountFromDB returns account data by ID
-func (a *App) GetAccountFromDB(id int64) (string, error) {
-	acc, err := backend.GetAccountByID(id)
-	if err != nil {
-		return "", err
-	}
-	return acc.ResponseJSON, nil
+// ListQueues returns the persisted queues of stopped downloads
+func (a *App) ListQueues() ([]backend.QueueJob, error) {
+	return backend.ListQueues()
 }
 
-// DeleteAccountFromDB deletes an account from database
-func (a *App) DeleteAccountFromDB(id int64) error {
-	return backend.DeleteAccount(id)
+// GetQueuePath returns the path of a queue's editable .jsonl file
+func (a *App) GetQueuePath(id string) (string, error) {
+	return backend.GetQueuePath(id)
 }
 
-// ClearAllAccountsFromDB deletes all accounts from database
-func (a *App) ClearAllAccountsFromDB() error {
-	return backend.ClearAllAccounts()
+// DeleteQueue removes a persisted queue
+func (a *App) DeleteQueue(id string) error {
+	return backend.DeleteQueue(id)
 }
 
-// ExportAccountJSON exports account to JSON file in specified directory
-func (a *App) ExportAccountJSON(id int64, outputDir string) (string, error) {
-	return backend.ExportAccountToFile(id, outputDir)
+// GetQueueItems returns a page of a stopped queue's pending items and its authors
+func (a *App) GetQueueItems(id string, offset, limit int) (backend.QueuePage, error) {
+	return backend.GetQueueItems(id, offset, limit)
 }
 
-// ExportAccountsTXT exports selected accounts to TXT file in specified directory
-func (a *App) ExportAccountsTXT(ids []int64, outputDir string) (string, error) {
-	return backend.ExportAccountsToTXT(ids, outputDir)
+// MoveQueueItems moves queue items to another position (0 = download next)
+func (a *App) MoveQueueItems(id string, positions []int, to int) error {
+	return backend.MoveQueueItems(id, positions, to)
 }
 
-// UpdateAccountGroup updates the group for an account
-func (a *App) UpdateAccountGroup(id int64, groupName, groupColor string) error {
-	return backend.UpdateAccountGroup(id, groupName, groupColor)
+// RemoveQueueItems drops items from a stopped queue
+func (a *App) RemoveQueueItems(id string, positions []int) error {
+	return backend.RemoveQueueItems(id, positions)
 }
 
-// GetAllGroups returns all unique groups
-func (a *App) GetAllGroups() ([]map[string]string, error) {
-	return backend.GetAllGroups()
+// MoveQueueAuthors moves all items of the given authors to the front or the end of a queue
+func (a *App) MoveQueueAuthors(id string, usernames []string, toFront bool) error {
+	return backend.MoveQueueAuthors(id, usernames, toFront)
 }
 
-// FFmpeg functions
+// RemoveQueueAuthors drops all items of the given authors from a stopped queue
+func (a *App) RemoveQueueAuthors(id string, usernames []string) error {
+	return backend.RemoveQueueAuthors(id, usernames)
+}
 
-// IsFFmpegInstalled checks if ffmpeg is available
-func (a *App) IsFFmpegInstalled() bool {
-	return backend.IsFFmpegInstalled()
+// ResumeQueue resumes a stopped download from its persisted (and possibly hand-edited) queue
+// Invalid lines are skipped and reported in the response
+func (a *App) ResumeQueue(id string) (ResumeQueueResponse, error) {
+	job, items, lineErrors, err := backend.LoadQueue(id)
+	if err != nil {
+		return ResumeQueueResponse{DownloadMediaResponse: DownloadMediaResponse{Message: err.Error()}}, err
+	}
+	if len(items) == 0 {
+		// Nothing left to download (all lines removed or invalid)
+		backend.DeleteQueue(id)
+		return ResumeQueueResponse{
+			DownloadMediaResponse: DownloadMediaResponse{Success: true, Message: "Queue is empty", QueueID: id},
+			InvalidLines:          lineErrors,
+		}, nil
+	}
+
+	job.Options.QueueID = job.ID
+	resp, err := a.runDownload(items, job.OutputDir, job.Username, job.Proxy, job.Options, false)
+	return ResumeQueueResponse{DownloadMediaResponse: resp, InvalidLines: lineErrors}, err
 }
 
-// DownloadFFmpeg downloads ffmpeg binary
-func (a *App) DownloadFFmpeg() error {
-	return backend.DownloadFFmpeg(nil)
+// GetWaybackSaveStatus returns the progress of the Wayback Machine save queue
+func (a *App) GetWaybackSaveStatus() backend.WaybackSaveStatus {
+	return backend.GetWaybackSaveStatus()
 }
 
-// IsExifToolInstalled checks if exiftool is available
-func (a *App) IsExifToolInstalled() bool {
-	return backend.IsExifToolInstalled()
+// GetJobHistory returns the most recent fetch and download runs, newest first
+func (a *App) GetJobHistory(limit int) ([]backend.JobRecord, error) {
+	return backend.GetJobHistory(limit)
 }
 
-// DownloadExifTool downloads exiftool binary
-func (a *App) DownloadExifTool() error {
-	return backend.DownloadExifTool(nil)
+// GetStats returns the aggregates of the job history for the statistics view
+func (a *App) GetStats() (backend.JobStats, error) {
+	return backend.GetStats()
 }
 
-// ConvertGIFsRequest represents request for converting GIFs
-type ConvertGIFsRequest struct {
-	FolderPath     string `json:"folder_path"`
-	Quality        string `json:"quality"`    // "fast" or "better"
-	Resolution     string `json:"resolution"` // "original", "high", "medium", "low"
-	DeleteOriginal bool   `json:"delete_original"`
+// GetMessageCatalog returns the translations of backend error and hint codes by language
+func (a *App) GetMessageCatalog() map[string]map[string]string {
+	return backend.GetMessageCatalog()
 }
 
-// ConvertGIFsResponse represents response for GIF conversion
-type ConvertGIFsResponse struct {
-	Success   bool   `json:"success"`
-	Converted int    `json:"converted"`
-	Failed    int    `json:"failed"`
-	Message   string `json:"message"`
+// GetSettings returns the backend settings
+func (a *App) GetSettings() backend.Settings {
+	return backend.GetSettings()
 }
 
-// ConvertGIFs converts MP4 files in gifs folder to actual GIF format
-func (a *App) ConvertGIFs(req ConvertGIFsRequest) (ConvertGIFsResponse, error) {
-	if !backend.IsFFmpegInstalled() {
-		return ConvertGIFsResponse{
-			Success: false,
-			Message: "FFmpeg not installed. Please download it first.",
-		}, nil
-	}
+// SetSettings validates and saves the backend settings
+func (a *App) SetSettings(settings backend.Settings) (backend.Settings, error) {
+	return backend.SetSettings(settings)
+}
 
-	// Default values if not provided
-	quality := req.Quality
-	if quality == "" {
-		quality = "fast"
-	}
-	resolution := req.Resolution
-	if resolution == "" {
-		resolution = "high"
-	}
+// GetAuditSummary totals the network audit log by host
+func (a *App) GetAuditSummary() ([]backend.AuditHostSummary, error) {
+	return backend.GetAuditSummary()
+}
+
+// GetAuditLogPath returns the path of the network audit log
+func (a *App) GetAuditLogPath() string {
+	return backend.GetAuditLogPath()
+}
+
+// ClearAuditLog removes the network audit log
+func (a *App) ClearAuditLog() error {
+	return backend.ClearAuditLog()
+}
+
+// GetRecoveredMedia returns the files of an account recovered from the Wayback Machine ("" = all accounts)
+func (a *App) GetRecoveredMedia(username string) ([]backend.RecoveredMedia, error) {
+	return backend.GetRecoveredMedia(username)
+}
+
+// RetryFailedResponse is the result of retrying the failed items of a job
+type RetryFailedResponse struct {
+	DownloadMediaResponse
+	Excluded int `json:"excluded"` // Permanent failures (403 and other client errors) that weren't retried
+}
 
-	converted, failed, err := backend.ConvertGIFsInFolder(req.FolderPath, quality, resolution, req.DeleteOriginal)
+// RetryFailed downloads the failed items of a finished job again, except permanent failures
+func (a *App) RetryFailed(jobID string) (RetryFailedResponse, error) {
+	job, items, excluded, err := backend.RetryFailed(jobID)
 	if err != nil {
-		return ConvertGIFsResponse{
-			Success: false,
-			Message: err.Error(),
-		}, err
+		return RetryFailedResponse{DownloadMediaResponse: DownloadMediaResponse{Message: err.Error()}}, err
+	}
+	if len(items) == 0 {
+		return RetryFailedResponse{
+			DownloadMediaResponse: DownloadMediaResponse{Success: true, Message: "Nothing to retry", QueueID: jobID},
+			Excluded:              excluded,
+		}, nil
 	}
 
-	return ConvertGIFsResponse{
-		Success:   true,
-		Converted: converted,
-		Failed:    failed,
-		Message:   fmt.Sprintf("Converted %d GIFs, %d failed", converted, failed),
-	}, nil
+	job.Options.QueueID = job.ID
+	resp, err := a.runDownload(items, job.OutputDir, job.Username, job.Proxy, job.Options, false)
+	return RetryFailedResponse{DownloadMediaResponse: resp, Excluded: excluded}, err
 }
 
-// ImportAccountResponse represents the response for import operation
-type ImportAccountResponse struct {
-	Success  bool   `json:"success"`
-	Username string `json:"username"`
-	Message  string `json:"message"`
+// SyncAllRequest represents the request structure for syncing all stored accounts
+type SyncAllRequest struct {
+	AuthToken string                           `json:"auth_token"`
+	Usernames []string                         `json:"usernames,omitempty"` // Only sync these accounts, all if empty
+	Download  DownloadMediaWithMetadataRequest `json:"download"`            // Download settings (items and username are ignored)
+}
+
+// SyncAll fetches every stored account again and downloads what's missing from its archive,
+// emitting "sync-all-progress" after each account; StopDownload stops the run
+func (a *App) SyncAll(req SyncAllRequest) (*backend.SyncAllReport, error) {
+	return a.runSync(req.Download, func(ctx context.Context, opts backend.DownloadOptions, onAccount backend.SyncAccountCallback, progress backend.ProgressCallback, itemStatus backend.ItemStatusCallback) (*backend.SyncAllReport, error) {
+		return backend.SyncAll(ctx, backend.SyncAllRequest{
+			AuthToken: req.AuthToken,
+			OutputDir: downloadOutputDir(req.Download),
+			Proxy:     req.Download.Proxy,
+			Usernames: req.Usernames,
+			Options:   opts,
+		}, onAccount, progress, itemStatus)
+	})
+}
+
+// ArchiveFollowingRequest represents the request structure for archiving a following list or List
+type ArchiveFollowingRequest struct {
+	AuthToken          string                           `json:"auth_token"`
+	Username           string                           `json:"username,omitempty"` // Account whose following list is archived
+	ListID             string                           `json:"list_id,omitempty"`  // Archive the members of this List instead
+	Limit              int                              `json:"limit,omitempty"`    // Accounts archived at most, 0 = all
+	MaxMediaPerAccount int                              `json:"max_media_per_account,omitempty"`
+	Download           DownloadMediaWithMetadataRequest `json:"download"` // Download settings (items and username are ignored)
+}
+
+// ArchiveFollowing syncs every account a user follows, or the members of a List, adding the
+// ones that aren't saved yet; it reports like SyncAll and StopDownload stops it
+func (a *App) ArchiveFollowing(req ArchiveFollowingRequest) (*backend.SyncAllReport, error) {
+	return a.runSync(req.Download, func(ctx context.Context, opts backend.DownloadOptions, onAccount backend.SyncAccountCallback, progress backend.ProgressCallback, itemStatus backend.ItemStatusCallback) (*backend.SyncAllReport, error) {
+		return backend.ArchiveFollowing(ctx, backend.FollowingArchiveRequest{
+			Following: backend.FollowingRequest{
+				AuthToken: req.AuthToken,
+				Username:  req.Username,
+				ListID:    req.ListID,
+				Limit:     req.Limit,
+			},
+			OutputDir:          downloadOutputDir(req.Download),
+			Proxy:              req.Download.Proxy,
+			MaxMediaPerAccount: req.MaxMediaPerAccount,
+			Options:            opts,
+		}, onAccount, progress, itemStatus)
+	})
+}
+
+// syncRun runs a sync with the given download options and callbacks
+type syncRun func(ctx context.Context, opts backend.DownloadOptions, onAccount backend.SyncAccountCallback, progress backend.ProgressCallback, itemStatus backend.ItemStatusCallback) (*backend.SyncAllReport, error)
+
+// runSync runs a sync with the download settings and the progress events of SyncAll
+func (a *App) runSync(download DownloadMediaWithMetadataRequest, sync syncRun) (*backend.SyncAllReport, error) {
+	a.downloadCtx, a.downloadCancel = context.WithCancel(context.Background())
+	defer func() { a.downloadCancel = nil }()
+
+	opts := toDownloadOptions(download)
+	if opts.Conflict == backend.ConflictAsk {
+		opts.Resolver = a.askConflict
+	}
+	opts.OnPrune = func(path string) {
+		runtime.EventsEmit(a.ctx, "archive-pruned", path)
+	}
+	if opts.ConfirmAboveBytes > 0 {
+		opts.Confirm = a.askDownloadConfirm
+	}
+
+	onAccount := func(index, total int, result backend.SyncAccountResult) {
+		runtime.EventsEmit(a.ctx, "sync-all-progress", map[string]interface{}{
+			"current": index + 1,
+			"total":   total,
+			"result":  result,
+		})
+	}
+	progressCallback := func(current, total int) {
+		percent := 0
+		if total > 0 {
+			percent = (current * 100) / total
+		}
+		runtime.EventsEmit(a.ctx, "download-progress", DownloadProgress{Current: current, Total: total, Percent: percent})
+	}
+	itemStatusCallback := func(tweetID int64, index int, status string) {
+		runtime.EventsEmit(a.ctx, "download-item-status", DownloadItemStatus{TweetID: tweetID, Index: index, Status: status})
+	}
+
+	report, err := sync(a.downloadCtx, opts, onAccount, progressCallback, itemStatusCallback)
+	if err != nil {
+		return nil, err
+	}
+
+	if download.Notify {
+		a.notifyDesktop("Sync complete", fmt.Sprintf("%d accounts synced, %d new media, %d downloaded, %d failed",
+			report.Synced+report.Partial, report.NewMedia, report.Downloaded, report.FailedFiles))
+	}
+	return report, nil
+}
+
+// notifyDesktop shows a desktop notification; a failure doesn't fail the job, it's reported to the
+// frontend as a "notification-failed" event
+func (a *App) notifyDesktop(title, message string) {
+	if err := backend.SendNotification(title, message); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+		runtime.EventsEmit(a.ctx, "notification-failed", err.Error())
+	}
+}
+
+// StopDownload cancels the current download operation
+func (a *App) StopDownload() bool {
+	if a.downloadCancel != nil {
+		a.downloadCancel()
+		a.downloadCancel = nil
+		return true
+	}
+	return false
+}
+
+// PauseDownload pauses a download job ("" = the running download)
+// With abort the running transfers are cut off too and continue where they stopped on resume
+func (a *App) PauseDownload(jobID string, abort bool) error {
+	if jobID == "" {
+		jobID = a.downloadJobID
+	}
+	return backend.PauseJob(jobID, abort)
+}
+
+// ResumeDownload resumes a paused download job ("" = the running download)
+func (a *App) ResumeDownload(jobID string) error {
+	if jobID == "" {
+		jobID = a.downloadJobID
+	}
+	return backend.ResumeJob(jobID)
+}
+
+// Database functions
+
+// SaveAccountToDB saves account data to database
+func (a *App) SaveAccountToDB(username, name, profileImage string, totalMedia int, responseJSON string, mediaType string) error {
+	if err := backend.SaveAccount(username, name, profileImage, totalMedia, responseJSON, mediaType); err != nil {
+		return err
+	}
+	go a.runCollections()
+	return nil
+}
+
+// SaveAccountToDBWithStatus saves account data with cursor and completion status for resume capability
+func (a *App) SaveAccountToDBWithStatus(username, name, profileImage string, totalMedia int, responseJSON string, mediaType string, cursor string, completed bool) error {
+	if err := backend.SaveAccountWithStatus(username, name, profileImage, totalMedia, responseJSON, mediaType, cursor, completed); err != nil {
+		return err
+	}
+	go a.runCollections()
+	return nil
+}
+
+// runCollections acts on new matches of smart collections after the library changed, emitting
+// "collection-matches" for every collection with new matches
+func (a *App) runCollections() {
+	err := backend.RunCollections(context.Background(), backend.DownloadOptions{}, "", func(result backend.CollectionResult) {
+		runtime.EventsEmit(a.ctx, "collection-matches", map[string]interface{}{
+			"id":         result.Collection.ID,
+			"name":       result.Collection.Name,
+			"new":        result.New,
+			"exported":   result.Exported,
+			"downloaded": result.Downloaded,
+		})
+	})
+	if err != nil {
+		fmt.Printf("Warning: smart collections failed: %v\n", err)
+	}
+}
+
+// SaveCollection creates or updates a smart collection (a saved search over the library)
+func (a *App) SaveCollection(c backend.SmartCollection) (backend.SmartCollection, error) {
+	return backend.SaveCollection(c)
+}
+
+// DeleteCollection deletes a smart collection
+func (a *App) DeleteCollection(id string) error {
+	return backend.DeleteCollection(id)
+}
+
+// ListCollections returns the smart collections
+func (a *App) ListCollections() ([]backend.SmartCollection, error) {
+	return backend.ListCollections()
+}
+
+// EvaluateCollection returns the media a smart collection matches now
+func (a *App) EvaluateCollection(id string) (*backend.CollectionResult, error) {
+	return backend.EvaluateCollection(id)
+}
+
+// GetAllAccountsFromDB returns all saved accounts
+func (a *App) GetAllAccountsFromDB() ([]backend.AccountListItem, error) {
+	return backend.GetAllAccounts()
+}
+
+// GetAccountFromDB returns account data by ID
+func (a *App) GetAccountFromDB(id int64) (string, error) {
+	acc, err := backend.GetAccountByID(id)
+	if err != nil {
+		return "", err
+	}
+	return acc.ResponseJSON, nil
+}
+
+// DeleteAccountFromDB deletes an account from database
+func (a *App) DeleteAccountFromDB(id int64) error {
+	return backend.DeleteAccount(id)
+}
+
+// SetAccountSensitive flags an account as sensitive (hidden while the content lock is locked)
+func (a *App) SetAccountSensitive(id int64, sensitive bool) error {
+	return backend.SetAccountSensitive(id, sensitive)
+}
+
+// MoveArchive moves an account archive to another download folder, verifying every copied file
+// The source is only removed after all copies are verified and the stored paths are updated
+func (a *App) MoveArchive(username, oldRoot, newRoot string) (backend.MoveArchiveResult, error) {
+	return backend.MoveArchive(username, oldRoot, newRoot)
+}
+
+// MergeArchives merges another copy of an account's archive folder into its archive folder,
+// skipping files it already has and unioning tweets.jsonl and missing.csv
+func (a *App) MergeArchives(src, dst string) (*backend.MergeArchivesResult, error) {
+	return backend.MergeArchives(src, dst)
+}
+
+// ReorganizeArchive renames the files of an account's archive to a new file name template
+// With DryRun set it only returns the planned renames
+func (a *App) ReorganizeArchive(req backend.ReorganizeRequest) (*backend.ReorganizeResult, error) {
+	return backend.ReorganizeArchive(req)
+}
+
+// UndoReorganize renames the files of a reorganization back
+func (a *App) UndoReorganize(journalID string) (*backend.ReorganizeResult, error) {
+	return backend.UndoReorganize(journalID)
+}
+
+// ListReorganizeJournals returns the reorganizations that can be undone, newest first
+func (a *App) ListReorganizeJournals() ([]backend.ReorganizeJournal, error) {
+	return backend.ListReorganizeJournals()
+}
+
+// CompareArchives diffs two snapshots of an account: archive folders, database copies (.db) or exported JSON
+func (a *App) CompareArchives(username, oldSource, newSource string) (*backend.ArchiveDiff, error) {
+	return backend.CompareArchives(username, oldSource, newSource)
+}
+
+// GetLockStatus returns whether a content lock passphrase is set and whether the app is locked
+func (a *App) GetLockStatus() backend.LockStatus {
+	return backend.GetLockStatus()
+}
+
+// UnlockContent unlocks sensitive accounts with the passphrase
+func (a *App) UnlockContent(passphrase string) error {
+	return backend.Unlock(passphrase)
+}
+
+// LockContent hides sensitive accounts again
+func (a *App) LockContent() {
+	backend.Lock()
+}
+
+// SetLockPassphrase sets, changes or (with an empty passphrase) removes the content lock
+func (a *App) SetLockPassphrase(current, passphrase string) error {
+	return backend.SetLockPassphrase(current, passphrase)
+}
+
+// ClearAllAccountsFromDB deletes all accounts from database
+func (a *App) ClearAllAccountsFromDB() error {
+	return backend.ClearAllAccounts()
+}
+
+// ExportAccountJSON exports account to JSON file in specified directory
+func (a *App) ExportAccountJSON(id int64, outputDir string) (string, error) {
+	return backend.ExportAccountToFile(id, outputDir)
+}
+
+// ExportAccountsTXT exports selected accounts to TXT file in specified directory
+func (a *App) ExportAccountsTXT(ids []int64, outputDir string) (string, error) {
+	return backend.ExportAccountsToTXT(ids, outputDir)
+}
+
+// UpdateAccountGroup updates the group for an account
+func (a *App) UpdateAccountGroup(id int64, groupName, groupColor string) error {
+	return backend.UpdateAccountGroup(id, groupName, groupColor)
+}
+
+// GetAllGroups returns all unique groups
+func (a *App) GetAllGroups() ([]map[string]string, error) {
+	return backend.GetAllGroups()
+}
+
+// FFmpeg functions
+
+// IsFFmpegInstalled checks if ffmpeg is available
+func (a *App) IsFFmpegInstalled() bool {
+	return backend.IsFFmpegInstalled()
+}
+
+// DownloadFFmpeg downloads ffmpeg binary, trying the custom mirror first if set
+func (a *App) DownloadFFmpeg(mirror string) error {
+	return backend.DownloadFFmpeg(nil, mirror)
+}
+
+// ToolUpdateProgress represents tool update progress event data
+type ToolUpdateProgress struct {
+	Tool       string `json:"tool"`
+	Downloaded int64  `json:"downloaded"`
+	Total      int64  `json:"total"` // -1 if the server doesn't report a size
+}
+
+// CheckToolUpdates compares the bundled ffmpeg and exiftool against the tool manifest
+func (a *App) CheckToolUpdates(mirror string) ([]backend.ToolUpdate, error) {
+	return backend.CheckToolUpdates(mirror)
+}
+
+// UpdateTool downloads the current version of ffmpeg or exiftool, emitting tool-update-progress events
+func (a *App) UpdateTool(tool string, mirror string) error {
+	var lastEmitted int64
+	return backend.UpdateTool(tool, func(downloaded, total int64) {
+		// The callback runs for every chunk, emit about once per megabyte
+		if downloaded-lastEmitted < 1<<20 && downloaded != total {
+			return
+		}
+		lastEmitted = downloaded
+		runtime.EventsEmit(a.ctx, "tool-update-progress", ToolUpdateProgress{
+			Tool:       tool,
+			Downloaded: downloaded,
+			Total:      total,
+		})
+	}, mirror)
+}
+
+// GetDataDir returns the folder holding tools, database and caches
+func (a *App) GetDataDir() backend.DataDirInfo {
+	return backend.GetDataDirInfo()
+}
+
+// SetDataDir moves the tools, database and caches to dir ("" for the default folder)
+func (a *App) SetDataDir(dir string) (backend.DataDirInfo, error) {
+	if a.downloadCancel != nil {
+		return backend.GetDataDirInfo(), fmt.Errorf("stop the running download first")
+	}
+	backend.KillAllExtractorProcesses()
+	return backend.SetAppDataDir(dir)
+}
+
+// RollbackTool restores the version of ffmpeg or exiftool replaced by the last update or import
+func (a *App) RollbackTool(tool string) error {
+	return backend.RollbackTool(tool)
+}
+
+// ExportDebugBundle zips logs, diagnostics, redacted settings and the last extractor output for a bug report
+func (a *App) ExportDebugBundle(req backend.DebugBundleRequest) (string, error) {
+	return backend.ExportDebugBundle(req)
+}
+
+// Diagnostics checks the tools, the download folder and network access to Twitter's media servers
+func (a *App) Diagnostics(outputDir string, proxy string) backend.DiagnosticsReport {
+	return backend.Diagnostics(outputDir, proxy)
+}
+
+// ImportToolResponse represents the response for an offline tool installation
+type ImportToolResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
+// ImportTool installs ffmpeg or exiftool from an archive or binary the user picks,
+// for machines that can't download them
+func (a *App) ImportTool(tool string) (ImportToolResponse, error) {
+	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: fmt.Sprintf("Select downloaded %s archive or binary", tool),
+	})
+	if err != nil {
+		return ImportToolResponse{Success: false, Message: err.Error()}, err
+	}
+
+	// User cancelled
+	if filePath == "" {
+		return ImportToolResponse{Success: false, Message: "Cancelled"}, nil
+	}
+
+	if err := backend.ImportTool(filePath, tool); err != nil {
+		return ImportToolResponse{Success: false, Message: err.Error()}, err
+	}
+	return ImportToolResponse{Success: true, Message: fmt.Sprintf("Installed %s from %s", tool, filepath.Base(filePath))}, nil
+}
+
+// ImportTwitterArchive imports the tweets and likes of an X data export ZIP the user picks
+// Returns nil if the user cancelled
+func (a *App) ImportTwitterArchive(authToken string) (*backend.TwitterArchiveImport, error) {
+	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import X Data Export",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "ZIP Archives", Pattern: "*.zip"},
+		},
+	})
+	if err != nil || filePath == "" {
+		return nil, err
+	}
+	return backend.ImportTwitterArchive(filePath, authToken)
+}
+
+// BatchImport reads a text or CSV file of tweet, profile and search URLs the user picks
+// Returns nil if the user cancelled
+func (a *App) BatchImport() (*backend.BatchImportResult, error) {
+	filePath, err := runtime.OpenFileDialog(a.ctx, runtime.OpenDialogOptions{
+		Title: "Import URL List",
+		Filters: []runtime.FileFilter{
+			{DisplayName: "Text and CSV Files", Pattern: "*.txt;*.csv"},
+		},
+	})
+	if err != nil || filePath == "" {
+		return nil, err
+	}
+	return backend.BatchImport(filePath)
+}
+
+// FetchBatchEntry fetches a single tweet or search from a batch import into the saved accounts
+func (a *App) FetchBatchEntry(entry backend.BatchImportEntry, authToken, dateZone string) (*backend.BatchFetchResult, error) {
+	return backend.FetchBatchEntry(entry, authToken, dateZone)
+}
+
+// SetClipboardWatch turns the clipboard watcher on or off; copied tweet links are sent to the
+// frontend as "clipboard-tweets" events
+func (a *App) SetClipboardWatch(enabled bool) {
+	a.clipboardMu.Lock()
+	defer a.clipboardMu.Unlock()
+	if a.clipboard != nil {
+		a.clipboard.Stop()
+		a.clipboard = nil
+	}
+	if !enabled {
+		return
+	}
+	a.clipboard = backend.StartClipboardWatcher(func() (string, error) {
+		return runtime.ClipboardGetText(a.ctx)
+	}, func(entries []backend.BatchImportEntry) {
+		runtime.EventsEmit(a.ctx, "clipboard-tweets", entries)
+	})
+}
+
+// SetAccountWatch turns the account watcher on or off, checking the saved accounts every
+// intervalHours; changes are sent to the frontend as "account-watch" events and shown as desktop
+// notifications
+func (a *App) SetAccountWatch(enabled bool, intervalHours int, authToken string) {
+	a.watchMu.Lock()
+	defer a.watchMu.Unlock()
+	if a.watcher != nil {
+		a.watcher.Stop()
+		a.watcher = nil
+	}
+	if !enabled {
+		return
+	}
+	if intervalHours < 1 {
+		intervalHours = 24
+	}
+	a.watcher = backend.StartAccountWatcher(time.Duration(intervalHours)*time.Hour, authToken, func(result backend.AccountWatchResult) {
+		title := "Account changed"
+		if result.UrgentArchive {
+			title = "Account at risk - archive it now"
+		}
+		a.notifyDesktop(title, result.Message)
+		runtime.EventsEmit(a.ctx, "account-watch", result)
+	})
+}
+
+// SetMediaServer starts or stops serving the download folder on the LAN
+// Returns the server's URLs when it's running, nil when it's stopped
+func (a *App) SetMediaServer(enabled bool, port int, root string) (*backend.MediaServerInfo, error) {
+	a.serverMu.Lock()
+	defer a.serverMu.Unlock()
+	if a.mediaServer != nil {
+		a.mediaServer.Stop()
+		a.mediaServer = nil
+	}
+	if !enabled {
+		return nil, nil
+	}
+	if root == "" {
+		root = backend.GetDefaultDownloadPath()
+	}
+	server, err := backend.StartMediaServer(port, root)
+	if err != nil {
+		return nil, err
+	}
+	a.mediaServer = server
+	info := server.Info()
+	return &info, nil
+}
+
+// GetEncryptionStatus returns whether sensitive archives are encrypted and unlocked
+func (a *App) GetEncryptionStatus() backend.EncryptionStatus {
+	return backend.GetEncryptionStatus()
+}
+
+// EnableEncryption encrypts the downloads and stored timelines of sensitive accounts from now on
+func (a *App) EnableEncryption(passphrase string) error {
+	return backend.EnableEncryption(passphrase)
+}
+
+// DisableEncryption decrypts every encrypted archive and turns encryption off
+func (a *App) DisableEncryption(passphrase, downloadDir string) ([]backend.EncryptionResult, error) {
+	return backend.DisableEncryption(passphrase, downloadDir)
+}
+
+// EncryptArchive encrypts the existing files of an account folder
+func (a *App) EncryptArchive(folder string) (*backend.EncryptionResult, error) {
+	return backend.EncryptArchive(folder)
+}
+
+// DecryptArchive decrypts the encrypted files of an account folder
+func (a *App) DecryptArchive(folder string) (*backend.EncryptionResult, error) {
+	return backend.DecryptArchive(folder)
+}
+
+// GetSecret returns a stored auth token, "" if it isn't set
+func (a *App) GetSecret(name string) (string, error) {
+	return backend.GetSecret(name)
+}
+
+// SetSecret stores an auth token in the OS keychain, or the encrypted fallback file
+func (a *App) SetSecret(name, value string) error {
+	return backend.SetSecret(name, value)
+}
+
+// GetSecretStorage returns where auth tokens are stored
+func (a *App) GetSecretStorage() string {
+	return backend.GetSecretStorage()
+}
+
+// CheckAccount checks a saved account for changes since the last check, nil if nothing changed
+func (a *App) CheckAccount(username, authToken string) (*backend.AccountWatchResult, error) {
+	return backend.CheckAccount(username, authToken)
+}
+
+// ResolveAccountRename follows a saved account to its new username through its user ID
+func (a *App) ResolveAccountRename(username, authToken string) (*backend.AccountRename, error) {
+	return backend.ResolveRename(username, authToken)
+}
+
+// GetAccountRenames returns the username changes of saved accounts, newest first
+func (a *App) GetAccountRenames() ([]backend.AccountRename, error) {
+	return backend.GetAccountRenames()
+}
+
+// IsFFprobeInstalled checks if ffprobe is available
+func (a *App) IsFFprobeInstalled() bool {
+	return backend.IsFFprobeInstalled()
+}
+
+// ProbeMedia returns the duration, codecs, resolution and corruption status of a media file
+func (a *App) ProbeMedia(path string) (backend.MediaProbe, error) {
+	return backend.ProbeMedia(path)
+}
+
+// IsExifToolInstalled checks if exiftool is available
+func (a *App) IsExifToolInstalled() bool {
+	return backend.IsExifToolInstalled()
+}
+
+// GetExifToolStatus returns whether exiftool works, and why not if Perl is missing
+func (a *App) GetExifToolStatus() backend.ExifToolStatus {
+	return backend.GetExifToolStatus()
+}
+
+// DownloadExifTool downloads exiftool binary, trying the custom mirror first if set
+func (a *App) DownloadExifTool(mirror string) error {
+	return backend.DownloadExifTool(nil, mirror)
+}
+
+// ConvertGIFsRequest represents request for converting GIFs
+type ConvertGIFsRequest struct {
+	FolderPath     string `json:"folder_path"`
+	Quality        string `json:"quality"`    // "fast" or "better"
+	Resolution     string `json:"resolution"` // "original", "high", "medium", "low"
+	DeleteOriginal bool   `json:"delete_original"`
+	Workers        int    `json:"workers,omitempty"` // Conversions run at once, 0 = one per CPU core
+}
+
+// ConvertGIFsResponse represents response for GIF conversion
+type ConvertGIFsResponse struct {
+	Success   bool   `json:"success"`
+	Converted int    `json:"converted"`
+	Failed    int    `json:"failed"`
+	Message   string `json:"message"`
+}
+
+// ConvertGIFs converts MP4 files in gifs folder to actual GIF format
+func (a *App) ConvertGIFs(req ConvertGIFsRequest) (ConvertGIFsResponse, error) {
+	if !backend.IsFFmpegInstalled() {
+		return ConvertGIFsResponse{
+			Success: false,
+			Message: "FFmpeg not installed. Please download it first.",
+		}, nil
+	}
+
+	// Default values if not provided
+	quality := req.Quality
+	if quality == "" {
+		quality = "fast"
+	}
+	resolution := req.Resolution
+	if resolution == "" {
+		resolution = "high"
+	}
+
+	converted, failed, err := backend.ConvertGIFsInFolder(req.FolderPath, quality, resolution, req.DeleteOriginal, req.Workers)
+	if err != nil {
+		return ConvertGIFsResponse{
+			Success: false,
+			Message: err.Error(),
+		}, err
+	}
+
+	return ConvertGIFsResponse{
+		Success:   true,
+		Converted: converted,
+		Failed:    failed,
+		Message:   fmt.Sprintf("Converted %d GIFs, %d failed", converted, failed),
+	}, nil
+}
+
+// ConvertFilesToGIFRequest represents request for converting selected videos to GIF
+type ConvertFilesToGIFRequest struct {
+	Paths          []string `json:"paths"`      // MP4 files, or folders searched recursively for MP4 files
+	Quality        string   `json:"quality"`    // "fast" or "better"
+	Resolution     string   `json:"resolution"` // "original", "high", "medium", "low"
+	DeleteOriginal bool     `json:"delete_original"`
+	Workers        int      `json:"workers,omitempty"` // Conversions run at once, 0 = one per CPU core
+}
+
+// ConvertFilesToGIF converts any selected MP4 files (or all MP4 files in selected folders) to GIF
+func (a *App) ConvertFilesToGIF(req ConvertFilesToGIFRequest) (ConvertGIFsResponse, error) {
+	if !backend.IsFFmpegInstalled() {
+		return ConvertGIFsResponse{
+			Success: false,
+			Message: "FFmpeg not installed. Please download it first.",
+		}, nil
+	}
+	if len(req.Paths) == 0 {
+		return ConvertGIFsResponse{
+			Success: false,
+			Message: "No files provided",
+		}, fmt.Errorf("no files provided")
+	}
+
+	// Default values if not provided
+	quality := req.Quality
+	if quality == "" {
+		quality = "fast"
+	}
+	resolution := req.Resolution
+	if resolution == "" {
+		resolution = "high"
+	}
+
+	converted, failed, err := backend.ConvertFilesToGIF(req.Paths, quality, resolution, req.DeleteOriginal, req.Workers)
+	if err != nil {
+		return ConvertGIFsResponse{
+			Success: false,
+			Message: err.Error(),
+		}, err
+	}
+
+	return ConvertGIFsResponse{
+		Success:   true,
+		Converted: converted,
+		Failed:    failed,
+		Message:   fmt.Sprintf("Converted %d GIFs, %d failed", converted, failed),
+	}, nil
+}
+
+// ConvertVideosRequest represents request for converting videos with a preset
+type ConvertVideosRequest struct {
+	Paths           []string `json:"paths"`            // MP4 files, or folders searched recursively for MP4 files
+	Preset          string   `json:"preset"`           // "hevc", "webm", "strip_audio", "remux"
+	ReplaceOriginal bool     `json:"replace_original"` // Replace the originals instead of saving next to them
+	Workers         int      `json:"workers,omitempty"`
+}
+
+// ConvertVideosResponse represents response for video conversion
+type ConvertVideosResponse struct {
+	Success    bool   `json:"success"`
+	Converted  int    `json:"converted"`
+	Failed     int    `json:"failed"`
+	SavedBytes int64  `json:"saved_bytes"` // Space saved by the conversion (negative if the files grew)
+	Message    string `json:"message"`
+}
+
+// ConvertVideos converts MP4 files (or all MP4 files in selected folders) with a preset
+func (a *App) ConvertVideos(req ConvertVideosRequest) (ConvertVideosResponse, error) {
+	if !backend.IsFFmpegInstalled() {
+		return ConvertVideosResponse{
+			Success: false,
+			Message: "FFmpeg not installed. Please download it first.",
+		}, nil
+	}
+	if len(req.Paths) == 0 {
+		return ConvertVideosResponse{
+			Success: false,
+			Message: "No files provided",
+		}, fmt.Errorf("no files provided")
+	}
+
+	converted, failed, saved, err := backend.ConvertVideos(req.Paths, req.Preset, req.ReplaceOriginal, req.Workers)
+	if err != nil {
+		return ConvertVideosResponse{
+			Success: false,
+			Message: err.Error(),
+		}, err
+	}
+
+	return ConvertVideosResponse{
+		Success:    true,
+		Converted:  converted,
+		Failed:     failed,
+		SavedBytes: saved,
+		Message:    fmt.Sprintf("Converted %d videos, %d failed", converted, failed),
+	}, nil
+}
+
+// GenerateVideoPreviewsRequest represents request for generating video posters or contact sheets
+type GenerateVideoPreviewsRequest struct {
+	Paths     []string `json:"paths"`     // MP4 files, or folders searched recursively for MP4 files
+	Kind      string   `json:"kind"`      // "poster" or "contact_sheet"
+	Location  string   `json:"location"`  // "thumbs" (.thumbs folder) or "alongside"
+	Overwrite bool     `json:"overwrite"` // Regenerate existing previews
+	Workers   int      `json:"workers,omitempty"`
+}
+
+// GenerateVideoPreviewsResponse represents response for video preview generation
+type GenerateVideoPreviewsResponse struct {
+	Success   bool   `json:"success"`
+	Generated int    `json:"generated"`
+	Skipped   int    `json:"skipped"`
+	Failed    int    `json:"failed"`
+	Message   string `json:"message"`
+}
+
+// GenerateVideoPreviews saves a poster frame or 3x3 contact sheet for each video
+func (a *App) GenerateVideoPreviews(req GenerateVideoPreviewsRequest) (GenerateVideoPreviewsResponse, error) {
+	if !backend.IsFFmpegInstalled() {
+		return GenerateVideoPreviewsResponse{
+			Success: false,
+			Message: "FFmpeg not installed. Please download it first.",
+		}, nil
+	}
+	if len(req.Paths) == 0 {
+		return GenerateVideoPreviewsResponse{
+			Success: false,
+			Message: "No files provided",
+		}, fmt.Errorf("no files provided")
+	}
+
+	kind := req.Kind
+	if kind == "" {
+		kind = backend.PreviewPoster
+	}
+	generated, skipped, failed, err := backend.GenerateVideoPreviews(req.Paths, kind, req.Location, req.Overwrite, req.Workers)
+	if err != nil {
+		return GenerateVideoPreviewsResponse{
+			Success: false,
+			Message: err.Error(),
+		}, err
+	}
+
+	return GenerateVideoPreviewsResponse{
+		Success:   true,
+		Generated: generated,
+		Skipped:   skipped,
+		Failed:    failed,
+		Message:   fmt.Sprintf("Generated %d previews, %d skipped, %d failed", generated, skipped, failed),
+	}, nil
+}
+
+// ConvertWebPImagesRequest represents request for converting WebP images
+type ConvertWebPImagesRequest struct {
+	Paths        []string `json:"paths"`         // WebP files, or folders searched recursively for WebP files
+	Format       string   `json:"format"`        // "jpg" or "png"
+	Quality      int      `json:"quality"`       // JPEG quality (1-100), 0 = default
+	KeepOriginal bool     `json:"keep_original"` // Keep the WebP files next to the converted ones
+	Workers      int      `json:"workers,omitempty"`
+}
+
+// ConvertWebPImages converts WebP images in an existing archive to JPEG or PNG
+func (a *App) ConvertWebPImages(req ConvertWebPImagesRequest) (ConvertGIFsResponse, error) {
+	if !backend.IsFFmpegInstalled() {
+		return ConvertGIFsResponse{
+			Success: false,
+			Message: "FFmpeg not installed. Please download it first.",
+		}, nil
+	}
+	if len(req.Paths) == 0 {
+		return ConvertGIFsResponse{
+			Success: false,
+			Message: "No files provided",
+		}, fmt.Errorf("no files provided")
+	}
+
+	format := req.Format
+	if format == "" {
+		format = backend.ImageFormatJPEG
+	}
+	converted, failed, err := backend.ConvertWebPImages(req.Paths, format, req.Quality, req.KeepOriginal, req.Workers)
+	if err != nil {
+		return ConvertGIFsResponse{
+			Success: false,
+			Message: err.Error(),
+		}, err
+	}
+
+	return ConvertGIFsResponse{
+		Success:   true,
+		Converted: converted,
+		Failed:    failed,
+		Message:   fmt.Sprintf("Converted %d images, %d failed", converted, failed),
+	}, nil
+}
+
+// TestSFTPConnection checks that the SFTP target is reachable and its remote folder is writable
+func (a *App) TestSFTPConnection(cfg backend.SFTPConfig) error {
+	return backend.TestSFTPConnection(cfg)
+}
+
+// TestS3Connection checks that the S3 bucket is reachable and writable
+func (a *App) TestS3Connection(cfg backend.S3Config) error {
+	return backend.TestS3Connection(cfg)
+}
+
+// TestWebDAVConnection checks that the WebDAV folder exists and is writable
+func (a *App) TestWebDAVConnection(cfg backend.WebDAVConfig) error {
+	return backend.TestWebDAVConnection(cfg)
+}
+
+// SelectVideoFiles opens a dialog to pick MP4 files, e.g. for GIF conversion
+func (a *App) SelectVideoFiles(defaultPath string) ([]string, error) {
+	return backend.SelectVideoFilesDialog(a.ctx, defaultPath)
+}
+
+// ImportAccountResponse represents the response for import operation
+type ImportAccountResponse struct {
+	Success  bool   `json:"success"`
+	Username string `json:"username"`
+	Message  string `json:"message"`
+}
+
+// ListGallery lists the downloaded files of an account folder for the local gallery (memoized)
+func (a *App) ListGallery(folder string, offset, limit int) (backend.GalleryPage, error) {
+	return backend.ListGallery(folder, offset, limit)
+}
+
+// GetThumbnail returns a thumbnail of a downloaded file as a data URL, generating it on first view
+func (a *App) GetThumbnail(path string, size int) (string, error) {
+	thumbPath, err := backend.GetThumbnail(path, size)
+	if err != nil {
+		return "", err
+	}
+	data, err := os.ReadFile(thumbPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to read thumbnail: %v", err)
+	}
+	return "data:image/jpeg;base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// ExportHTMLGallery writes a static HTML gallery of an account folder that opens in any browser
+func (a *App) ExportHTMLGallery(folder string) (backend.HTMLGalleryResult, error) {
+	return backend.ExportHTMLGallery(folder)
+}
+
+// ExportMarkdownNotes writes a Markdown note per archived tweet of an account folder to a vault folder
+func (a *App) ExportMarkdownNotes(folder, vaultDir string) (backend.MarkdownExportResult, error) {
+	return backend.ExportMarkdownNotes(folder, vaultDir)
+}
+
+// ExportHydrus exports the media of an account folder with Hydrus tag sidecars to an export folder
+func (a *App) ExportHydrus(folder, exportDir string) (backend.HydrusExportResult, error) {
+	return backend.ExportHydrus(folder, exportDir)
+}
+
+// BackfillMetadata embeds tweet metadata into the files of an account folder downloaded without it
+// Progress is emitted as "metadata-backfill-progress" events
+func (a *App) BackfillMetadata(folder, dateZone string) (*backend.BackfillMetadataResult, error) {
+	return backend.BackfillMetadata(folder, dateZone, func(done, total int) {
+		runtime.EventsEmit(a.ctx, "metadata-backfill-progress", map[string]int{"done": done, "total": total})
+	})
+}
+
+// SetMediaStarred stars or unstars a media of the library
+func (a *App) SetMediaStarred(ref backend.MediaRef, starred bool) error {
+	return backend.SetMediaStarred(ref, starred)
+}
+
+// SetMediaTags replaces the tags of a media of the library
+func (a *App) SetMediaTags(ref backend.MediaRef, tags []string) error {
+	return backend.SetMediaTags(ref, tags)
+}
+
+// GetMediaLabels returns the starred and tagged media of an account
+func (a *App) GetMediaLabels(username string) ([]backend.MediaLabels, error) {
+	return backend.GetMediaLabels(username)
+}
+
+// FindLabeledMedia returns the labeled media matching a filter
+func (a *App) FindLabeledMedia(filter backend.LabelFilter) ([]backend.MediaLabels, error) {
+	return backend.FindLabeledMedia(filter)
+}
+
+// ListMediaTags returns the tags in use with their number of media ("" = every account)
+func (a *App) ListMediaTags(username string) ([]backend.TagCount, error) {
+	return backend.ListMediaTags(username)
+}
+
+// ExportLabeledMedia exports the downloaded files of the labeled media matching a filter
+func (a *App) ExportLabeledMedia(filter backend.LabelFilter, downloadDir, exportDir string) (backend.LabelExportResult, error) {
+	return backend.ExportLabeledMedia(filter, downloadDir, exportDir)
+}
+
+// GetGalleryCacheStats returns the size of the thumbnail and listing caches
+func (a *App) GetGalleryCacheStats() backend.GalleryCacheStats {
+	return backend.GetGalleryCacheStats()
+}
+
+// ClearGalleryCache deletes all cached thumbnails and listings
+func (a *App) ClearGalleryCache() {
+	backend.ClearGalleryCache()
 }
 
 // CheckFolderExists checks if a folder exists for the given username
@@ -0,0 +1,276 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Account watcher
+//
+// Accounts disappear without warning: suspended, deleted, locked, or wiped tweet by tweet. With
+// the account watcher on, every saved account is looked up again every few hours (one tweet is
+// enough to get its profile and counts) and compared with the last check. A change is reported
+// with a message: a renamed account is followed to its new name (see renames.go), one that was
+// suspended, deleted or turned protected can't be archived any further, while an account that's
+// still public but deleting tweets or changing its display name is flagged for an urgent full
+// archive before more is lost. Rate limits end a round early, the rest is checked in the next one.
+
+// Status of a watched account
+const (
+	AccountStatusActive    = "active"
+	AccountStatusProtected = "protected"
+	AccountStatusNotFound  = "not_found" // Suspended or deleted, or renamed before its user ID was saved
+)
+
+const (
+	// accountWatchPause is the wait between two accounts of a round
+	accountWatchPause = 5 * time.Second
+	// Tweet deletions are reported from this many tweets or media, and 2% of them
+	minDeletedTweets = 10
+)
+
+// AccountWatchResult is a change found by the account watcher
+type AccountWatchResult struct {
+	Username       string `json:"username"`
+	RenamedFrom    string `json:"renamed_from,omitempty"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previous_status"`
+	Nick           string `json:"nick,omitempty"`
+	PreviousNick   string `json:"previous_nick,omitempty"`
+	DeletedTweets  int    `json:"deleted_tweets,omitempty"` // Drop of the tweet count since the last check
+	DeletedMedia   int    `json:"deleted_media,omitempty"`  // Drop of the media count since the last check
+	Message        string `json:"message"`
+	UrgentArchive  bool   `json:"urgent_archive"` // Still reachable but at risk, archive it now
+	CheckedAt      string `json:"checked_at"`
+}
+
+// accountState is what the last check of an account found
+type accountState struct {
+	status     string
+	nick       string
+	statuses   int
+	mediaCount int
+}
+
+// AccountWatcher checks the saved accounts periodically and reports changes
+type AccountWatcher struct {
+	interval  time.Duration
+	authToken string
+	onChange  func(AccountWatchResult)
+	stop      chan struct{}
+	stopOnce  sync.Once
+}
+
+// StartAccountWatcher checks the saved accounts now and then every interval, calling onChange for
+// every account that changed. authToken may be empty (guest) or a list, see rotation.go
+func StartAccountWatcher(interval time.Duration, authToken string, onChange func(AccountWatchResult)) *AccountWatcher {
+	w := &AccountWatcher{interval: interval, authToken: authToken, onChange: onChange, stop: make(chan struct{})}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			w.round()
+			select {
+			case <-ticker.C:
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+	return w
+}
+
+// Stop stops checking, a check in progress finishes first
+func (w *AccountWatcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// round checks every saved account once
+func (w *AccountWatcher) round() {
+	for i, username := range watchedUsernames() {
+		if i > 0 {
+			select {
+			case <-time.After(accountWatchPause):
+			case <-w.stop:
+				return
+			}
+		}
+		result, err := CheckAccount(username, w.authToken)
+		if err != nil {
+			if !RetryAtFromMessage(err.Error()).IsZero() || strings.Contains(err.Error(), "[hint="+HintRateLimited) {
+				fmt.Printf("Account watcher rate limited, continuing in the next round\n")
+				return
+			}
+			fmt.Printf("Account watcher could not check @%s: %v\n", username, err)
+			continue
+		}
+		if result != nil {
+			w.onChange(*result)
+		}
+	}
+}
+
+// watchedUsernames returns the saved accounts, each once, without bookmarks and likes
+func watchedUsernames() []string {
+	accounts, err := GetAllAccounts()
+	if err != nil {
+		return nil
+	}
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, acc := range accounts {
+		key := strings.ToLower(acc.Username)
+		if acc.Username == "bookmarks" || acc.Username == "likes" || seen[key] {
+			continue
+		}
+		seen[key] = true
+		usernames = append(usernames, acc.Username)
+	}
+	return usernames
+}
+
+// CheckAccount looks an account up and compares it with the last check
+// Returns the change, nil if nothing changed or it's the first check, and an error if the account
+// couldn't be checked (rate limits, network errors)
+func CheckAccount(username, authToken string) (*AccountWatchResult, error) {
+	username = cleanUsername(username)
+	var state accountState
+	response, err := ExtractTimeline(TimelineRequest{Username: username, AuthToken: authToken, TimelineType: "media", BatchSize: 1})
+	switch {
+	case err == nil && response != nil:
+		state = accountState{
+			status:     AccountStatusActive,
+			nick:       response.AccountInfo.Nick,
+			statuses:   response.AccountInfo.StatusesCount,
+			mediaCount: response.AccountInfo.MediaCount,
+		}
+	case err != nil && strings.Contains(err.Error(), "[hint="+HintAccountNotFound):
+		// A renamed account is still there, see renames.go
+		if rename, _ := ResolveRename(username, authToken); rename != nil {
+			return &AccountWatchResult{
+				Username:       rename.NewUsername,
+				RenamedFrom:    username,
+				Status:         AccountStatusActive,
+				PreviousStatus: AccountStatusActive,
+				Message:        fmt.Sprintf("@%s was renamed to @%s, its archive follows the new name", username, rename.NewUsername),
+				UrgentArchive:  true,
+				CheckedAt:      time.Now().Format(time.RFC3339),
+			}, nil
+		}
+		state = accountState{status: AccountStatusNotFound}
+	case err != nil && strings.Contains(err.Error(), "[hint="+HintProtected+"]"):
+		state = accountState{status: AccountStatusProtected}
+	default:
+		return nil, err
+	}
+
+	previous, found, err := loadAccountState(username)
+	if err != nil {
+		return nil, err
+	}
+	if err := saveAccountState(username, state, previous, found); err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, nil
+	}
+	return compareAccountState(username, previous, state), nil
+}
+
+// compareAccountState returns what changed between two checks of an account, nil for nothing
+func compareAccountState(username string, previous, current accountState) *AccountWatchResult {
+	result := &AccountWatchResult{
+		Username:       username,
+		Status:         current.status,
+		PreviousStatus: previous.status,
+		Nick:           current.nick,
+		PreviousNick:   previous.nick,
+		CheckedAt:      time.Now().Format(time.RFC3339),
+	}
+	switch {
+	case current.status == AccountStatusNotFound && previous.status != AccountStatusNotFound:
+		result.Message = fmt.Sprintf("@%s was suspended, deleted or renamed", username)
+	case current.status == AccountStatusProtected && previous.status != AccountStatusProtected:
+		result.Message = fmt.Sprintf("@%s is protected now", username)
+	case current.status == AccountStatusActive && previous.status != AccountStatusActive:
+		result.Message = fmt.Sprintf("@%s can be seen again", username)
+		result.UrgentArchive = true
+	case current.status == AccountStatusActive:
+		if previous.statuses > 0 && significantDrop(previous.statuses, current.statuses) {
+			result.DeletedTweets = previous.statuses - current.statuses
+		}
+		if previous.mediaCount > 0 && significantDrop(previous.mediaCount, current.mediaCount) {
+			result.DeletedMedia = previous.mediaCount - current.mediaCount
+		}
+		var changes []string
+		if result.DeletedTweets > 0 {
+			changes = append(changes, fmt.Sprintf("deleted %d tweets", result.DeletedTweets))
+		}
+		if result.DeletedMedia > 0 {
+			changes = append(changes, fmt.Sprintf("deleted %d media", result.DeletedMedia))
+		}
+		if previous.nick != "" && current.nick != previous.nick {
+			changes = append(changes, fmt.Sprintf("changed the display name from %q to %q", previous.nick, current.nick))
+		}
+		if len(changes) == 0 {
+			return nil
+		}
+		result.Message = fmt.Sprintf("@%s %s", username, strings.Join(changes, " and "))
+		result.UrgentArchive = true
+	default:
+		return nil
+	}
+	return result
+}
+
+// significantDrop reports whether a count fell enough to be deletions rather than noise
+func significantDrop(previous, current int) bool {
+	drop := previous - current
+	return drop >= minDeletedTweets && drop*50 >= previous
+}
+
+// loadAccountState returns the last check of an account, found is false if there's none
+func loadAccountState(username string) (state accountState, found bool, err error) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return state, false, err
+		}
+	}
+	err = db.QueryRow(`SELECT status, nick, statuses_count, media_count FROM account_watch WHERE username = ?`,
+		strings.ToLower(username)).Scan(&state.status, &state.nick, &state.statuses, &state.mediaCount)
+	if err == sql.ErrNoRows {
+		return state, false, nil
+	}
+	if err != nil {
+		return state, false, fmt.Errorf("failed to load account state: %v", err)
+	}
+	return state, true, nil
+}
+
+// saveAccountState records a check of an account
+// Counts and name of an account that can't be seen are kept, to compare with once it's back
+func saveAccountState(username string, state, previous accountState, found bool) error {
+	if state.status != AccountStatusActive && found {
+		state.nick, state.statuses, state.mediaCount = previous.nick, previous.statuses, previous.mediaCount
+	}
+	now := time.Now().Format(time.RFC3339)
+	changedAt := now
+	if found && state.status == previous.status {
+		changedAt = ""
+	}
+	_, err := db.Exec(`
+		INSERT INTO account_watch (username, status, nick, statuses_count, media_count, checked_at, changed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(username) DO UPDATE SET
+			status = excluded.status, nick = excluded.nick, statuses_count = excluded.statuses_count,
+			media_count = excluded.media_count, checked_at = excluded.checked_at,
+			changed_at = CASE WHEN excluded.changed_at = '' THEN account_watch.changed_at ELSE excluded.changed_at END
+	`, strings.ToLower(username), state.status, state.nick, state.statuses, state.mediaCount, now, changedAt)
+	if err != nil {
+		return fmt.Errorf("failed to save account state: %v", err)
+	}
+	return nil
+}
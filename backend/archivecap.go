@@ -0,0 +1,191 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sync"
+)
+
+// Policies when an account archive exceeds its size cap
+const (
+	ArchiveCapStop            = "stop"             // Skip remaining downloads (default)
+	ArchiveCapPruneOldest     = "prune_oldest"     // Move the oldest files to the trash
+	ArchiveCapPruneEngagement = "prune_engagement" // Move the lowest-engagement files to the trash
+)
+
+// ErrArchivePruneFailed is wrapped by the error of jobs stopped because files couldn't be moved
+// to the trash to keep an archive within its cap
+var ErrArchivePruneFailed = errors.New("archive_prune_failed")
+
+// archiveFilePattern matches files named by the downloader: {username}_{timestamp}_{tweet_id}_{index}.{ext},
+// also when encrypted (EncryptedExt appended)
+// Only these are pruned - anything else the user put in the folder is left alone
+var archiveFilePattern = regexp.MustCompile(`_\d{8}_\d{6}_(\d+)_\d{2,}(?:_\d+)?\.[A-Za-z0-9]+(?:\.xenc)?$`)
+
+// archiveFile is a prunable file of an account archive
+type archiveFile struct {
+	path    string
+	size    int64
+	tweetID int64
+}
+
+// archiveUsage is the current size of an account archive
+type archiveUsage struct {
+	used  int64
+	files []archiveFile
+}
+
+// archiveCap enforces the archive size cap of a download batch
+// A nil *archiveCap means no cap
+type archiveCap struct {
+	limit      int64
+	policy     string
+	engagement map[int64]int // tweet_id -> engagement, from the batch items
+	onPrune    func(path string)
+	files      *regexp.Regexp // Names of prunable files: the job's file name template, see filenamePattern
+
+	mu       sync.Mutex
+	accounts map[string]*archiveUsage // account folder -> usage
+}
+
+// newArchiveCap returns the cap for a batch, or nil if no cap is set
+func newArchiveCap(opts DownloadOptions, items []MediaItem) *archiveCap {
+	// Caps measure and prune the account folders on disk
+	if opts.MaxArchiveBytes <= 0 || !isLocalStorage(opts.storage()) {
+		return nil
+	}
+	c := &archiveCap{
+		limit:      opts.MaxArchiveBytes,
+		policy:     opts.ArchiveCapPolicy,
+		engagement: make(map[int64]int),
+		onPrune:    opts.OnPrune,
+		files:      filenamePattern(opts.FilenameTemplate),
+		accounts:   make(map[string]*archiveUsage),
+	}
+	if c.policy == "" {
+		c.policy = ArchiveCapStop
+	}
+	for _, item := range items {
+		if item.Engagement > c.engagement[item.TweetID] {
+			c.engagement[item.TweetID] = item.Engagement
+		}
+	}
+	return c
+}
+
+// accountDir returns the account folder of a target path ({output}/{username}/{type}/{file})
+func accountDir(outputPath string) string {
+	return filepath.Dir(filepath.Dir(outputPath))
+}
+
+// usage returns the usage of an account folder, scanning it on first use (mu must be held)
+func (c *archiveCap) usage(dir string) *archiveUsage {
+	if u, ok := c.accounts[dir]; ok {
+		return u
+	}
+	u := &archiveUsage{}
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		u.used += info.Size()
+		if tweetID, ok := filenameTweetID(c.files, d.Name()); ok {
+			u.files = append(u.files, archiveFile{path: path, size: info.Size(), tweetID: tweetID})
+		}
+		return nil
+	})
+	c.accounts[dir] = u
+	return u
+}
+
+// full reports whether a task must be skipped because its archive reached the cap
+func (c *archiveCap) full(task downloadTask) bool {
+	if c == nil || c.policy != ArchiveCapStop {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.usage(accountDir(task.outputPath)).used >= c.limit
+}
+
+// added records a downloaded file and prunes the archive if it's now over the cap
+// Returns an error wrapping ErrArchivePruneFailed if the archive stays over the cap because files
+// couldn't be moved to the trash
+func (c *archiveCap) added(tweetID int64, path string) error {
+	if c == nil {
+		return nil
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	u := c.usage(accountDir(path))
+	u.used += info.Size()
+	u.files = append(u.files, archiveFile{path: path, size: info.Size(), tweetID: tweetID})
+
+	if c.policy == ArchiveCapPruneOldest || c.policy == ArchiveCapPruneEngagement {
+		return c.prune(u, path)
+	}
+	return nil
+}
+
+// prune moves files to the trash until the archive fits the cap, never touching keep
+// Files that can't be moved are left in place and the next candidates tried; the last error is
+// returned if the archive still doesn't fit
+func (c *archiveCap) prune(u *archiveUsage, keep string) error {
+	var trashErr error
+	for u.used > c.limit {
+		victim := -1
+		for i, f := range u.files {
+			if f.path == keep {
+				continue
+			}
+			if victim == -1 || c.prunesBefore(f, u.files[victim]) {
+				victim = i
+			}
+		}
+		if victim == -1 {
+			break // Nothing left to prune
+		}
+
+		f := u.files[victim]
+		u.files = append(u.files[:victim], u.files[victim+1:]...)
+		if err := MoveToTrash(f.path); err != nil {
+			trashErr = err // Leave it in place, try the next candidate
+			continue
+		}
+		u.used -= f.size
+		if c.onPrune != nil {
+			c.onPrune(f.path)
+		}
+	}
+	if u.used > c.limit && trashErr != nil {
+		return fmt.Errorf("%w: %s is %s over its cap, files couldn't be moved to the trash: %v",
+			ErrArchivePruneFailed, filepath.Base(accountDir(keep)), formatBytes(u.used-c.limit), trashErr)
+	}
+	return nil
+}
+
+// prunesBefore reports whether a should be pruned before b
+func (c *archiveCap) prunesBefore(a, b archiveFile) bool {
+	if c.policy == ArchiveCapPruneEngagement {
+		// Files of tweets not in this batch have unknown (zero) engagement and go first
+		ea, eb := c.engagement[a.tweetID], c.engagement[b.tweetID]
+		if ea != eb {
+			return ea < eb
+		}
+	}
+	// Tweet IDs are time-ordered, so a lower ID is an older tweet
+	return a.tweetID < b.tweetID
+}
@@ -0,0 +1,339 @@
+package backend
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Archive comparison
+//
+// Two snapshots of the same account can be compared to see what changed in between, e.g. around
+// the account going private or a wave of takedowns. A snapshot is an archive folder, a copy of
+// the library database (accounts.db) or an exported account JSON file. Media are matched by tweet
+// ID and position in the tweet, so a folder can be compared with a database snapshot. Engagement
+// changes need stored timelines on both sides, folders only tell which files exist.
+
+// ArchiveSnapshotInfo describes one side of a comparison
+type ArchiveSnapshotInfo struct {
+	Source         string `json:"source"` // Path of the folder, database or JSON file
+	Kind           string `json:"kind"`   // folder, database or json
+	Media          int    `json:"media"`
+	Tweets         int    `json:"tweets"`
+	FollowersCount int    `json:"followers_count,omitempty"` // Stored timelines only
+	StatusesCount  int    `json:"statuses_count,omitempty"`
+}
+
+// ArchiveDiffMedia is a media item found in only one of the snapshots
+type ArchiveDiffMedia struct {
+	TweetID string `json:"tweet_id"`
+	Index   int    `json:"index"` // 1-based position in the tweet
+	Date    string `json:"date,omitempty"`
+	Type    string `json:"type,omitempty"`
+	URL     string `json:"url,omitempty"`  // Stored timelines
+	Path    string `json:"path,omitempty"` // Folders
+}
+
+// Engagement is the engagement counts of a tweet
+type Engagement struct {
+	Views     int `json:"views"`
+	Likes     int `json:"likes"`
+	Retweets  int `json:"retweets"`
+	Replies   int `json:"replies"`
+	Bookmarks int `json:"bookmarks"`
+}
+
+// EngagementChange is a tweet whose engagement changed between the snapshots
+type EngagementChange struct {
+	TweetID string     `json:"tweet_id"`
+	Date    string     `json:"date,omitempty"`
+	Before  Engagement `json:"before"`
+	After   Engagement `json:"after"`
+}
+
+// ArchiveDiff is the difference between two snapshots of an account
+type ArchiveDiff struct {
+	Username           string              `json:"username"`
+	Old                ArchiveSnapshotInfo `json:"old"`
+	New                ArchiveSnapshotInfo `json:"new"`
+	Added              []ArchiveDiffMedia  `json:"added"`   // Only in the new snapshot
+	Removed            []ArchiveDiffMedia  `json:"removed"` // Only in the old snapshot, deleted upstream since
+	EngagementChanges  []EngagementChange  `json:"engagement_changes"`
+	EngagementCompared bool                `json:"engagement_compared"` // Both snapshots have stored timelines
+}
+
+// archiveIndexPattern matches the media index in downloader file names
+var archiveIndexPattern = regexp.MustCompile(`_\d{8}_\d{6}_\d+_(\d{2,})(?:_\d+)?\.[A-Za-z0-9]+(?:\.xenc)?$`)
+
+// archiveSnapshot is a loaded snapshot
+type archiveSnapshot struct {
+	info       ArchiveSnapshotInfo
+	media      map[string]ArchiveDiffMedia // tweetID_index -> media
+	engagement map[string]Engagement       // tweetID -> counts, nil for folders
+}
+
+// CompareArchives compares two snapshots of an account (folders, database copies or exported JSON)
+func CompareArchives(username, oldSource, newSource string) (*ArchiveDiff, error) {
+	username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+	if username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+	oldSnap, err := loadArchiveSnapshot(oldSource, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", oldSource, err)
+	}
+	newSnap, err := loadArchiveSnapshot(newSource, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %v", newSource, err)
+	}
+
+	diff := &ArchiveDiff{
+		Username:          username,
+		Old:               oldSnap.info,
+		New:               newSnap.info,
+		Added:             []ArchiveDiffMedia{},
+		Removed:           []ArchiveDiffMedia{},
+		EngagementChanges: []EngagementChange{},
+	}
+	for key, media := range newSnap.media {
+		if _, ok := oldSnap.media[key]; !ok {
+			diff.Added = append(diff.Added, media)
+		}
+	}
+	for key, media := range oldSnap.media {
+		if _, ok := newSnap.media[key]; !ok {
+			diff.Removed = append(diff.Removed, media)
+		}
+	}
+	sortDiffMedia(diff.Added)
+	sortDiffMedia(diff.Removed)
+
+	if oldSnap.engagement != nil && newSnap.engagement != nil {
+		diff.EngagementCompared = true
+		dates := make(map[string]string)
+		for _, media := range newSnap.media {
+			dates[media.TweetID] = media.Date
+		}
+		for tweetID, before := range oldSnap.engagement {
+			if after, ok := newSnap.engagement[tweetID]; ok && after != before {
+				diff.EngagementChanges = append(diff.EngagementChanges, EngagementChange{
+					TweetID: tweetID,
+					Date:    dates[tweetID],
+					Before:  before,
+					After:   after,
+				})
+			}
+		}
+		sort.Slice(diff.EngagementChanges, func(i, j int) bool {
+			return tweetIDLess(diff.EngagementChanges[i].TweetID, diff.EngagementChanges[j].TweetID)
+		})
+	}
+	return diff, nil
+}
+
+// loadArchiveSnapshot reads a snapshot by kind: a folder, a .db file or a .json file
+func loadArchiveSnapshot(source, username string) (*archiveSnapshot, error) {
+	info, err := os.Stat(source)
+	if err != nil {
+		return nil, err
+	}
+	if info.IsDir() {
+		return loadFolderSnapshot(source, username)
+	}
+	switch strings.ToLower(filepath.Ext(source)) {
+	case ".db", ".sqlite":
+		return loadDatabaseSnapshot(source, username)
+	case ".json":
+		data, err := os.ReadFile(source)
+		if err != nil {
+			return nil, err
+		}
+		snap, err := timelineSnapshot([]string{string(data)})
+		if err != nil {
+			return nil, err
+		}
+		snap.info.Source = source
+		snap.info.Kind = "json"
+		return snap, nil
+	}
+	return nil, fmt.Errorf("not an archive folder, database or JSON export")
+}
+
+// loadFolderSnapshot lists the downloaded files of an account folder (or of the account in an output folder)
+func loadFolderSnapshot(dir, username string) (*archiveSnapshot, error) {
+	if info, err := os.Stat(filepath.Join(dir, username)); err == nil && info.IsDir() {
+		dir = filepath.Join(dir, username)
+	}
+
+	snap := &archiveSnapshot{
+		info:  ArchiveSnapshotInfo{Source: dir, Kind: "folder"},
+		media: make(map[string]ArchiveDiffMedia),
+	}
+	tweets := make(map[string]bool)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != dir && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir // .thumbs, .trash
+			}
+			return nil
+		}
+		m := archiveFilePattern.FindStringSubmatch(d.Name())
+		if m == nil {
+			return nil
+		}
+		mediaType := gallerySubfolders[filepath.Base(filepath.Dir(path))]
+		if mediaType == "text" {
+			return nil
+		}
+		index := archiveFileIndex(d.Name())
+		media := ArchiveDiffMedia{
+			TweetID: m[1],
+			Index:   index,
+			Type:    mediaType,
+			Path:    path,
+		}
+		if ts := galleryTimestampPattern.FindStringSubmatch(d.Name()); ts != nil {
+			if t, err := time.Parse("20060102_150405", ts[1]); err == nil {
+				media.Date = t.Format("2006-01-02T15:04:05")
+			}
+		}
+		snap.media[media.TweetID+"_"+strconv.Itoa(index)] = media
+		tweets[media.TweetID] = true
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	snap.info.Media = len(snap.media)
+	snap.info.Tweets = len(tweets)
+	return snap, nil
+}
+
+// loadDatabaseSnapshot reads the stored timelines of an account from a copy of the library database
+func loadDatabaseSnapshot(path, username string) (*archiveSnapshot, error) {
+	snapDB, err := sql.Open("sqlite3", "file:"+filepath.ToSlash(path)+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	defer snapDB.Close()
+
+	rows, err := snapDB.Query(`SELECT response_json FROM accounts WHERE LOWER(username) = LOWER(?)`, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var timelines []string
+	for rows.Next() {
+		var responseJSON string
+		if err := rows.Scan(&responseJSON); err != nil {
+			return nil, err
+		}
+		if responseJSON, err = openResponseJSON(responseJSON); err != nil {
+			return nil, err
+		}
+		timelines = append(timelines, responseJSON)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(timelines) == 0 {
+		return nil, fmt.Errorf("@%s is not in this database", username)
+	}
+
+	snap, err := timelineSnapshot(timelines)
+	if err != nil {
+		return nil, err
+	}
+	snap.info.Source = path
+	snap.info.Kind = "database"
+	return snap, nil
+}
+
+// timelineSnapshot builds a snapshot from stored timelines (one per fetched media type)
+func timelineSnapshot(timelines []string) (*archiveSnapshot, error) {
+	snap := &archiveSnapshot{
+		media:      make(map[string]ArchiveDiffMedia),
+		engagement: make(map[string]Engagement),
+	}
+	for _, data := range timelines {
+		var response TwitterResponse
+		if err := json.Unmarshal([]byte(data), &response); err != nil {
+			return nil, fmt.Errorf("failed to parse timeline: %v", err)
+		}
+		if response.AccountInfo.FollowersCount > snap.info.FollowersCount {
+			snap.info.FollowersCount = response.AccountInfo.FollowersCount
+			snap.info.StatusesCount = response.AccountInfo.StatusesCount
+		}
+
+		// Number media within each tweet like the downloader does
+		seen := make(map[string]bool)
+		count := make(map[string]int)
+		for _, entry := range response.Timeline {
+			if entry.Type == "text" || seen[entry.URL] {
+				continue
+			}
+			seen[entry.URL] = true
+			tweetID := strconv.FormatInt(int64(entry.TweetID), 10)
+			count[tweetID]++
+			snap.media[tweetID+"_"+strconv.Itoa(count[tweetID])] = ArchiveDiffMedia{
+				TweetID: tweetID,
+				Index:   count[tweetID],
+				Date:    entry.Date,
+				Type:    entry.Type,
+				URL:     entry.URL,
+			}
+			snap.engagement[tweetID] = Engagement{
+				Views:     entry.ViewCount,
+				Likes:     entry.FavoriteCount,
+				Retweets:  entry.RetweetCount,
+				Replies:   entry.ReplyCount,
+				Bookmarks: entry.BookmarkCount,
+			}
+		}
+	}
+	snap.info.Media = len(snap.media)
+	snap.info.Tweets = len(snap.engagement)
+	return snap, nil
+}
+
+// archiveFileIndex returns the media index of a downloaded file name, 1 if it can't be read
+func archiveFileIndex(name string) int {
+	m := archiveIndexPattern.FindStringSubmatch(name)
+	if m == nil {
+		return 1
+	}
+	index, err := strconv.Atoi(m[1])
+	if err != nil {
+		return 1
+	}
+	return index
+}
+
+// sortDiffMedia sorts media by tweet ID and index
+func sortDiffMedia(media []ArchiveDiffMedia) {
+	sort.Slice(media, func(i, j int) bool {
+		if media[i].TweetID != media[j].TweetID {
+			return tweetIDLess(media[i].TweetID, media[j].TweetID)
+		}
+		return media[i].Index < media[j].Index
+	})
+}
+
+// tweetIDLess compares tweet IDs numerically
+func tweetIDLess(a, b string) bool {
+	if len(a) != len(b) {
+		return len(a) < len(b)
+	}
+	return a < b
+}
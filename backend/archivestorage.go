@@ -0,0 +1,677 @@
+package backend
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Archive output: writes one ZIP or tar per account (outputDir/username.zip) with a manifest.json,
+// built next to the existing archive and swapped in when the job ends.
+
+// Archive formats
+const (
+	ArchiveFormatZip = "zip"
+	ArchiveFormatTar = "tar"
+)
+
+// archiveManifestName is the manifest entry of each archive
+const archiveManifestName = "manifest.json"
+
+// ArchiveManifestEntry is a file listed in an archive manifest
+type ArchiveManifestEntry struct {
+	Name     string    `json:"name"`
+	Size     int64     `json:"size"`
+	SHA256   string    `json:"sha256,omitempty"` // Unknown for files of archives written without a manifest
+	Modified time.Time `json:"modified"`
+}
+
+// ArchiveManifest is the manifest.json of an archive
+type ArchiveManifest struct {
+	Account string                 `json:"account"`
+	Updated time.Time              `json:"updated"`
+	Files   []ArchiveManifestEntry `json:"files"`
+}
+
+// ArchiveStorage writes the files of each account into one archive in another Storage
+type ArchiveStorage struct {
+	inner     Storage
+	outputDir string
+	format    string
+
+	mu       sync.Mutex
+	archives map[string]*accountArchive // Account folder name -> archive
+}
+
+// accountArchive is the archive of one account being written
+type accountArchive struct {
+	store   *ArchiveStorage
+	account string
+	path    string // Archive path in the inner storage
+
+	mu       sync.Mutex
+	loaded   bool
+	previous string                          // Local copy of the existing archive, "" if there is none
+	copied   bool                            // previous is a temporary copy
+	old      map[string]ArchiveManifestEntry // Files of the existing archive
+	removed  map[string]bool                 // Files of the existing archive not to carry over
+	out      io.WriteCloser                  // New archive, nil until the first file
+	zw       *zip.Writer
+	tw       *tar.Writer
+	entries  map[string]ArchiveManifestEntry // Files written by this job
+	pending  map[string]spooledFile          // Temporary files (.part, .tmp) waiting for a rename
+}
+
+// spooledFile is a finished file waiting in a local temporary file
+type spooledFile struct {
+	path string
+	size int64
+	hash string
+}
+
+// NewArchiveStorage returns a storage that writes into one archive per account in inner
+func NewArchiveStorage(inner Storage, outputDir, format string) (*ArchiveStorage, error) {
+	if format != ArchiveFormatZip && format != ArchiveFormatTar {
+		return nil, fmt.Errorf("unknown archive format: %s", format)
+	}
+	return &ArchiveStorage{
+		inner:     inner,
+		outputDir: outputDir,
+		format:    format,
+		archives:  make(map[string]*accountArchive),
+	}, nil
+}
+
+// locate returns the archive and entry name of a path, loading the existing archive on first use
+func (s *ArchiveStorage) locate(p string) (*accountArchive, string, error) {
+	rel, err := filepath.Rel(s.outputDir, p)
+	if err != nil || rel == "." || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return nil, "", fmt.Errorf("%s is outside the output folder", p)
+	}
+	parts := strings.SplitN(filepath.ToSlash(rel), "/", 2)
+	name := ""
+	if len(parts) == 2 {
+		name = parts[1]
+	}
+
+	s.mu.Lock()
+	a, ok := s.archives[parts[0]]
+	if !ok {
+		a = &accountArchive{
+			store:   s,
+			account: parts[0],
+			path:    filepath.Join(s.outputDir, parts[0]+"."+s.format),
+			removed: make(map[string]bool),
+			entries: make(map[string]ArchiveManifestEntry),
+			pending: make(map[string]spooledFile),
+		}
+		s.archives[parts[0]] = a
+	}
+	s.mu.Unlock()
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.load(); err != nil {
+		return nil, "", err
+	}
+	return a, name, nil
+}
+
+// Stat implements Storage
+func (s *ArchiveStorage) Stat(p string) (os.FileInfo, error) {
+	a, name, err := s.locate(p)
+	if err != nil {
+		return nil, &fs.PathError{Op: "stat", Path: p, Err: fs.ErrNotExist}
+	}
+	if name == "" {
+		return &archiveFileInfo{name: a.account, dir: true}, nil
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if spooled, ok := a.pending[name]; ok {
+		return &archiveFileInfo{name: path.Base(name), size: spooled.size, modTime: time.Now()}, nil
+	}
+	if entry, ok := a.lookup(name); ok {
+		return &archiveFileInfo{name: path.Base(name), size: entry.Size, modTime: entry.Modified}, nil
+	}
+	return nil, &fs.PathError{Op: "stat", Path: p, Err: fs.ErrNotExist}
+}
+
+// MkdirAll implements Storage, folders exist implicitly in archives
+func (s *ArchiveStorage) MkdirAll(p string) error {
+	return nil
+}
+
+// Create implements Storage, the file is added to the archive when it's closed
+func (s *ArchiveStorage) Create(p string) (io.WriteCloser, error) {
+	a, name, err := s.locate(p)
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		return nil, fmt.Errorf("%s is not a file in an account folder", p)
+	}
+	spool, err := os.CreateTemp("", "txmbd-archive-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	return &archiveFileWriter{archive: a, name: name, spool: spool, hash: sha256.New()}, nil
+}
+
+// Open implements Storage for temporary files and files of the existing archive
+// Files written by this job can only be read back once the archive is finished
+func (s *ArchiveStorage) Open(p string) (io.ReadCloser, error) {
+	a, name, err := s.locate(p)
+	if err != nil {
+		return nil, err
+	}
+	a.mu.Lock()
+	spooled, isPending := a.pending[name]
+	_, isOld := a.old[name]
+	isOld = isOld && !a.removed[name]
+	_, isNew := a.entries[name]
+	previous := a.previous
+	a.mu.Unlock()
+
+	switch {
+	case isPending:
+		return os.Open(spooled.path)
+	case isNew:
+		return nil, fmt.Errorf("%s can't be read before the archive is finished", name)
+	case isOld:
+		return openArchiveEntry(previous, s.format, name)
+	default:
+		return nil, &fs.PathError{Op: "open", Path: p, Err: fs.ErrNotExist}
+	}
+}
+
+// Rename implements Storage for temporary files, which are added to the archive under the new name
+func (s *ArchiveStorage) Rename(oldPath, newPath string) error {
+	a, oldName, err := s.locate(oldPath)
+	if err != nil {
+		return err
+	}
+	b, newName, err := s.locate(newPath)
+	if err != nil {
+		return err
+	}
+	if a != b {
+		return fmt.Errorf("files can't be moved between archives")
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	spooled, ok := a.pending[oldName]
+	if !ok {
+		return fmt.Errorf("%s can't be renamed inside the archive", oldName)
+	}
+	delete(a.pending, oldName)
+	defer os.Remove(spooled.path)
+	return a.add(newName, spooled)
+}
+
+// Remove implements Storage for temporary files and files of the existing archive
+func (s *ArchiveStorage) Remove(p string) error {
+	a, name, err := s.locate(p)
+	if err != nil {
+		return err
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if spooled, ok := a.pending[name]; ok {
+		delete(a.pending, name)
+		return os.Remove(spooled.path)
+	}
+	if _, ok := a.entries[name]; ok {
+		return fmt.Errorf("%s can't be removed from the archive", name)
+	}
+	if _, ok := a.old[name]; ok {
+		a.removed[name] = true
+		return nil
+	}
+	return &fs.PathError{Op: "remove", Path: p, Err: fs.ErrNotExist}
+}
+
+// Close finishes every archive written to and replaces the previous ones
+func (s *ArchiveStorage) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var firstErr error
+	for _, a := range s.archives {
+		a.mu.Lock()
+		if err := a.finish(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("failed to write %s: %v", filepath.Base(a.path), err)
+		}
+		a.mu.Unlock()
+	}
+	return firstErr
+}
+
+// lookup returns a file of the archive, written by this job or carried over (mu must be held)
+func (a *accountArchive) lookup(name string) (ArchiveManifestEntry, bool) {
+	if entry, ok := a.entries[name]; ok {
+		return entry, true
+	}
+	if entry, ok := a.old[name]; ok && !a.removed[name] {
+		return entry, true
+	}
+	return ArchiveManifestEntry{}, false
+}
+
+// load lists the files of the existing archive (mu must be held)
+func (a *accountArchive) load() error {
+	if a.loaded {
+		return nil
+	}
+	a.old = make(map[string]ArchiveManifestEntry)
+	if _, err := a.store.inner.Stat(a.path); err != nil {
+		a.loaded = true
+		return nil
+	}
+
+	// Archives are read by offset or scanned, a remote one is copied locally first
+	a.previous = a.path
+	if !isLocalStorage(a.store.inner) {
+		local, err := copyToTemp(a.store.inner, a.path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %v", filepath.Base(a.path), err)
+		}
+		a.previous = local
+		a.copied = true
+	}
+	entries, err := listArchive(a.previous, a.store.format)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %v", filepath.Base(a.path), err)
+	}
+	a.old = entries
+	a.loaded = true
+	return nil
+}
+
+// add appends a spooled file to the new archive (mu must be held)
+func (a *accountArchive) add(name string, spooled spooledFile) error {
+	if _, ok := a.entries[name]; ok {
+		return fmt.Errorf("%s is already in the archive", name)
+	}
+	if err := a.open(); err != nil {
+		return err
+	}
+	in, err := os.Open(spooled.path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	entry := ArchiveManifestEntry{Name: name, Size: spooled.size, SHA256: spooled.hash, Modified: time.Now().UTC()}
+	if err := a.write(entry, in); err != nil {
+		return err
+	}
+	a.entries[name] = entry
+	return nil
+}
+
+// open starts the new archive next to the existing one (mu must be held)
+func (a *accountArchive) open() error {
+	if a.out != nil {
+		return nil
+	}
+	out, err := a.store.inner.Create(a.path + ".tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create %s: %v", filepath.Base(a.path), err)
+	}
+	a.out = out
+	if a.store.format == ArchiveFormatZip {
+		a.zw = zip.NewWriter(out)
+	} else {
+		a.tw = tar.NewWriter(out)
+	}
+	return nil
+}
+
+// write adds one file to the new archive (mu must be held)
+func (a *accountArchive) write(entry ArchiveManifestEntry, r io.Reader) error {
+	if a.zw != nil {
+		// Media is already compressed, only text is worth deflating
+		method := zip.Store
+		switch strings.ToLower(path.Ext(entry.Name)) {
+		case ".txt", ".json", ".jsonl", ".csv", ".html", ".md":
+			method = zip.Deflate
+		}
+		w, err := a.zw.CreateHeader(&zip.FileHeader{Name: entry.Name, Method: method, Modified: entry.Modified})
+		if err != nil {
+			return err
+		}
+		_, err = io.Copy(w, r)
+		return err
+	}
+	header := &tar.Header{Typeflag: tar.TypeReg, Name: entry.Name, Mode: 0644, Size: entry.Size, ModTime: entry.Modified}
+	if err := a.tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := io.Copy(a.tw, r)
+	return err
+}
+
+// finish copies the files of the existing archive, adds the manifest and swaps the archives (mu must be held)
+func (a *accountArchive) finish() error {
+	for _, spooled := range a.pending {
+		os.Remove(spooled.path)
+	}
+	a.pending = make(map[string]spooledFile)
+	defer func() {
+		if a.copied {
+			os.Remove(a.previous)
+		}
+	}()
+	if a.out == nil {
+		return nil // Nothing new, the existing archive stays as it is
+	}
+
+	if a.previous != "" {
+		if err := a.carryOver(); err != nil {
+			a.abort()
+			return err
+		}
+	}
+
+	manifest := ArchiveManifest{Account: a.account, Updated: time.Now().UTC()}
+	for _, entry := range a.entries {
+		manifest.Files = append(manifest.Files, entry)
+	}
+	for name, entry := range a.old {
+		if _, replaced := a.entries[name]; !replaced && !a.removed[name] {
+			manifest.Files = append(manifest.Files, entry)
+		}
+	}
+	sort.Slice(manifest.Files, func(i, j int) bool {
+		return manifest.Files[i].Name < manifest.Files[j].Name
+	})
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		a.abort()
+		return err
+	}
+	if err := a.write(ArchiveManifestEntry{Name: archiveManifestName, Size: int64(len(data)), Modified: manifest.Updated}, bytes.NewReader(data)); err != nil {
+		a.abort()
+		return err
+	}
+
+	var closeErr error
+	if a.zw != nil {
+		closeErr = a.zw.Close()
+	} else {
+		closeErr = a.tw.Close()
+	}
+	if err := a.out.Close(); err != nil && closeErr == nil {
+		closeErr = err
+	}
+	a.out = nil
+	if closeErr != nil {
+		a.store.inner.Remove(a.path + ".tmp")
+		return closeErr
+	}
+	return a.store.inner.Rename(a.path+".tmp", a.path)
+}
+
+// carryOver copies the files of the existing archive that weren't replaced (mu must be held)
+func (a *accountArchive) carryOver() error {
+	keep := func(name string) bool {
+		_, replaced := a.entries[name]
+		return name != archiveManifestName && !replaced && !a.removed[name]
+	}
+
+	if a.zw != nil {
+		r, err := zip.OpenReader(a.previous)
+		if err != nil {
+			return err
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if keep(f.Name) {
+				// Copied as is, without decompressing
+				if err := a.zw.Copy(f); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	file, err := os.Open(a.previous)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	tr := tar.NewReader(file)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag != tar.TypeReg || !keep(header.Name) {
+			continue
+		}
+		if err := a.tw.WriteHeader(header); err != nil {
+			return err
+		}
+		if _, err := io.Copy(a.tw, tr); err != nil {
+			return err
+		}
+	}
+}
+
+// abort discards the new archive, the existing one stays in place (mu must be held)
+func (a *accountArchive) abort() {
+	a.out.Close()
+	a.out = nil
+	a.store.inner.Remove(a.path + ".tmp")
+}
+
+// archiveFileWriter spools a file and adds it to the archive when closed
+type archiveFileWriter struct {
+	archive *accountArchive
+	name    string
+	spool   *os.File
+	hash    hash.Hash
+	size    int64
+}
+
+func (w *archiveFileWriter) Write(p []byte) (int, error) {
+	n, err := w.spool.Write(p)
+	w.hash.Write(p[:n])
+	w.size += int64(n)
+	return n, err
+}
+
+func (w *archiveFileWriter) Close() error {
+	if err := w.spool.Close(); err != nil {
+		os.Remove(w.spool.Name())
+		return err
+	}
+	spooled := spooledFile{path: w.spool.Name(), size: w.size, hash: hex.EncodeToString(w.hash.Sum(nil))}
+
+	a := w.archive
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	// Temporary files wait for the rename that names them
+	if strings.HasSuffix(w.name, ".part") || strings.HasSuffix(w.name, ".tmp") {
+		if previous, ok := a.pending[w.name]; ok {
+			os.Remove(previous.path)
+		}
+		a.pending[w.name] = spooled
+		return nil
+	}
+	defer os.Remove(spooled.path)
+	return a.add(w.name, spooled)
+}
+
+// archiveFileInfo implements os.FileInfo for files in an archive
+type archiveFileInfo struct {
+	name    string
+	size    int64
+	modTime time.Time
+	dir     bool
+}
+
+func (i *archiveFileInfo) Name() string       { return i.name }
+func (i *archiveFileInfo) Size() int64        { return i.size }
+func (i *archiveFileInfo) ModTime() time.Time { return i.modTime }
+func (i *archiveFileInfo) IsDir() bool        { return i.dir }
+func (i *archiveFileInfo) Sys() any           { return nil }
+
+func (i *archiveFileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0755
+	}
+	return 0644
+}
+
+// copyToTemp copies a file of a storage to a local temporary file
+func copyToTemp(store Storage, p string) (string, error) {
+	in, err := store.Open(p)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	out, err := os.CreateTemp("", "txmbd-archive-*")
+	if err != nil {
+		return "", err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(out.Name())
+		return "", err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(out.Name())
+		return "", err
+	}
+	return out.Name(), nil
+}
+
+// listArchive lists the files of a local archive, with the hashes of its manifest
+func listArchive(archivePath, format string) (map[string]ArchiveManifestEntry, error) {
+	entries := make(map[string]ArchiveManifestEntry)
+	if format == ArchiveFormatZip {
+		r, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		defer r.Close()
+		for _, f := range r.File {
+			if !f.FileInfo().IsDir() {
+				entries[f.Name] = ArchiveManifestEntry{Name: f.Name, Size: int64(f.UncompressedSize64), Modified: f.Modified}
+			}
+		}
+	} else {
+		file, err := os.Open(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		defer file.Close()
+		tr := tar.NewReader(file)
+		for {
+			header, err := tr.Next()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				return nil, err
+			}
+			if header.Typeflag == tar.TypeReg {
+				entries[header.Name] = ArchiveManifestEntry{Name: header.Name, Size: header.Size, Modified: header.ModTime}
+			}
+		}
+	}
+
+	if r, err := openArchiveEntry(archivePath, format, archiveManifestName); err == nil {
+		var manifest ArchiveManifest
+		if json.NewDecoder(r).Decode(&manifest) == nil {
+			for _, listed := range manifest.Files {
+				if entry, ok := entries[listed.Name]; ok && entry.Size == listed.Size {
+					entry.SHA256 = listed.SHA256
+					entries[listed.Name] = entry
+				}
+			}
+		}
+		r.Close()
+	}
+	delete(entries, archiveManifestName)
+	return entries, nil
+}
+
+// archiveEntryReader reads one file of an archive and closes the archive with it
+type archiveEntryReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+func (r *archiveEntryReader) Close() error {
+	var firstErr error
+	for _, c := range r.closers {
+		if err := c.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// openArchiveEntry opens one file of a local archive
+func openArchiveEntry(archivePath, format, name string) (io.ReadCloser, error) {
+	if format == ArchiveFormatZip {
+		r, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range r.File {
+			if f.Name == name {
+				rc, err := f.Open()
+				if err != nil {
+					r.Close()
+					return nil, err
+				}
+				return &archiveEntryReader{Reader: rc, closers: []io.Closer{rc, r}}, nil
+			}
+		}
+		r.Close()
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	file, err := os.Open(archivePath)
+	if err != nil {
+		return nil, err
+	}
+	tr := tar.NewReader(file)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+		if header.Name == name && header.Typeflag == tar.TypeReg {
+			return &archiveEntryReader{Reader: tr, closers: []io.Closer{file}}, nil
+		}
+	}
+	file.Close()
+	return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+}
@@ -0,0 +1,333 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Network audit log
+//
+// With the audit log on in the settings, every outbound request is appended to audit.log in the
+// data folder as one JSON line: the host, why the app contacted it, the method, the response
+// status and the body bytes sent and received. Each redirect is its own line, so a download that
+// ends up on another host shows both. The log lets security-conscious users check that the app
+// only talks to X/Twitter, its media CDN, the tool mirrors and the storage and archive services
+// they set up themselves. HTTP requests are logged by the transport of every client the app
+// creates; the extractor and ssh run as separate processes, so they get one line per run with
+// the bytes exchanged with the process. The log is rotated to audit.log.1 when it grows past
+// auditMaxSize.
+
+const (
+	auditLogFile = "audit.log"
+	auditMaxSize = 10 * 1024 * 1024
+)
+
+// Purposes of network operations
+const (
+	AuditPurposeTwitter     = "twitter"         // Timelines and tweets through the extractor
+	AuditPurposeMedia       = "media"           // Media files from the CDN
+	AuditPurposeTool        = "tool download"   // FFmpeg, ExifTool and the tool manifest
+	AuditPurposeWayback     = "wayback machine" // Recovering deleted media and saving tweets
+	AuditPurposeUpload      = "upload"          // S3, WebDAV and SFTP archive targets
+	AuditPurposeDiagnostics = "diagnostics"     // Reachability checks
+	AuditPurposeOther       = "other"
+)
+
+// auditHostPurposes are the purposes of requests to known hosts, for clients used for several
+// (subdomains included)
+var auditHostPurposes = map[string]string{
+	"x.com":                         AuditPurposeTwitter,
+	"twitter.com":                   AuditPurposeTwitter,
+	"pbs.twimg.com":                 AuditPurposeMedia,
+	"video.twimg.com":               AuditPurposeMedia,
+	"web.archive.org":               AuditPurposeWayback,
+	"archive.org":                   AuditPurposeWayback,
+	"github.com":                    AuditPurposeTool,
+	"evermeet.cx":                   AuditPurposeTool,
+	"exiftool.org":                  AuditPurposeTool,
+	"sourceforge.net":               AuditPurposeTool,
+	"objects.githubusercontent.com": AuditPurposeTool,
+}
+
+// AuditEntry is one line of the audit log
+type AuditEntry struct {
+	Time     string `json:"time"`
+	Host     string `json:"host"`
+	Purpose  string `json:"purpose"`
+	Method   string `json:"method,omitempty"` // "" for a process
+	Status   int    `json:"status,omitempty"`
+	Sent     int64  `json:"sent"`     // Body bytes
+	Received int64  `json:"received"` // Body bytes
+	Error    string `json:"error,omitempty"`
+}
+
+// AuditHostSummary totals the audit log for one host
+type AuditHostSummary struct {
+	Host      string   `json:"host"`
+	Purposes  []string `json:"purposes"`
+	Requests  int      `json:"requests"`
+	Errors    int      `json:"errors"`
+	Sent      int64    `json:"sent"`
+	Received  int64    `json:"received"`
+	FirstSeen string   `json:"first_seen"`
+	LastSeen  string   `json:"last_seen"`
+}
+
+var auditMu sync.Mutex
+
+// auditEnabled reports whether the audit log is on
+func auditEnabled() bool {
+	return GetSettings().AuditLog
+}
+
+// GetAuditLogPath returns the path of the audit log
+func GetAuditLogPath() string {
+	return filepath.Join(GetAppDataDir(), auditLogFile)
+}
+
+// writeAuditEntry appends an entry to the audit log
+func writeAuditEntry(entry AuditEntry) {
+	entry.Time = time.Now().UTC().Format(time.RFC3339)
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	path := GetAuditLogPath()
+	if info, err := os.Stat(path); err == nil && info.Size() >= auditMaxSize {
+		os.Rename(path, path+".1")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		fmt.Printf("Warning: failed to write audit log: %v\n", err)
+		return
+	}
+	defer f.Close()
+	f.Write(append(line, '\n'))
+}
+
+// auditHostPurpose returns the purpose of a request to a host
+func auditHostPurpose(host string) string {
+	for h := host; h != ""; {
+		if purpose, ok := auditHostPurposes[h]; ok {
+			return purpose
+		}
+		_, parent, found := strings.Cut(h, ".")
+		if !found {
+			break
+		}
+		h = parent
+	}
+	return AuditPurposeOther
+}
+
+// auditProcess logs a run of an external program that talked to host
+func auditProcess(host, purpose string, sent, received int64, runErr error) {
+	if !auditEnabled() {
+		return
+	}
+	entry := AuditEntry{Host: host, Purpose: purpose, Sent: sent, Received: received}
+	if runErr != nil {
+		entry.Error = runErr.Error()
+	}
+	writeAuditEntry(entry)
+}
+
+// auditPurposeKey is the context key of a request's purpose, see withAuditPurpose
+type auditPurposeKey struct{}
+
+// withAuditPurpose sets the purpose logged for requests made with ctx
+func withAuditPurpose(ctx context.Context, purpose string) context.Context {
+	return context.WithValue(ctx, auditPurposeKey{}, purpose)
+}
+
+// audited returns a transport that logs its requests with a purpose ("" = by host)
+func audited(next http.RoundTripper, purpose string) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return &auditTransport{next: next, purpose: purpose}
+}
+
+// auditTransport logs the requests of a transport while the audit log is on
+type auditTransport struct {
+	next    http.RoundTripper
+	purpose string
+}
+
+func (t *auditTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !auditEnabled() {
+		return t.next.RoundTrip(req)
+	}
+	entry := AuditEntry{Host: req.URL.Hostname(), Purpose: t.purpose, Method: req.Method}
+	if purpose, ok := req.Context().Value(auditPurposeKey{}).(string); ok {
+		entry.Purpose = purpose
+	}
+	if entry.Purpose == "" {
+		entry.Purpose = auditHostPurpose(entry.Host)
+	}
+	var sent *countingReader
+	if req.Body != nil && req.Body != http.NoBody {
+		sent = &countingReader{ReadCloser: req.Body}
+		req = req.Clone(req.Context())
+		req.Body = sent
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if sent != nil {
+		entry.Sent = sent.n.Load()
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		writeAuditEntry(entry)
+		return resp, err
+	}
+	entry.Status = resp.StatusCode
+	resp.Body = &auditBody{countingReader: countingReader{ReadCloser: resp.Body}, entry: entry, sent: sent}
+	return resp, nil
+}
+
+// countingReader counts the bytes read through it
+type countingReader struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// countingWriter counts the bytes written through it
+type countingWriter struct {
+	io.WriteCloser
+	n atomic.Int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.WriteCloser.Write(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
+// auditBody logs its request once the response body is read or closed
+type auditBody struct {
+	countingReader
+	entry AuditEntry
+	sent  *countingReader // Request body, still read by the transport while the response arrives
+	once  sync.Once
+}
+
+func (b *auditBody) Read(p []byte) (int, error) {
+	n, err := b.countingReader.Read(p)
+	if err == io.EOF {
+		b.log()
+	}
+	return n, err
+}
+
+func (b *auditBody) Close() error {
+	err := b.countingReader.Close()
+	b.log()
+	return err
+}
+
+func (b *auditBody) log() {
+	b.once.Do(func() {
+		b.entry.Received = b.n.Load()
+		if b.sent != nil {
+			b.entry.Sent = b.sent.n.Load()
+		}
+		writeAuditEntry(b.entry)
+	})
+}
+
+// GetAuditSummary totals the audit log by host, the most used first
+func GetAuditSummary() ([]AuditHostSummary, error) {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+
+	byHost := make(map[string]*AuditHostSummary)
+	purposes := make(map[string]map[string]bool)
+	path := GetAuditLogPath()
+	for _, p := range []string{path + ".1", path} {
+		f, err := os.Open(p)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %v", err)
+		}
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			var entry AuditEntry
+			if json.Unmarshal(scanner.Bytes(), &entry) != nil {
+				continue // Cut off by a crash
+			}
+			s := byHost[entry.Host]
+			if s == nil {
+				s = &AuditHostSummary{Host: entry.Host, FirstSeen: entry.Time}
+				byHost[entry.Host] = s
+				purposes[entry.Host] = make(map[string]bool)
+			}
+			s.Requests++
+			if entry.Error != "" {
+				s.Errors++
+			}
+			s.Sent += entry.Sent
+			s.Received += entry.Received
+			s.LastSeen = entry.Time
+			if !purposes[entry.Host][entry.Purpose] {
+				purposes[entry.Host][entry.Purpose] = true
+				s.Purposes = append(s.Purposes, entry.Purpose)
+			}
+		}
+		err = scanner.Err()
+		f.Close()
+		if err != nil {
+			return nil, fmt.Errorf("failed to read audit log: %v", err)
+		}
+	}
+
+	summary := make([]AuditHostSummary, 0, len(byHost))
+	for _, s := range byHost {
+		summary = append(summary, *s)
+	}
+	sort.Slice(summary, func(i, j int) bool {
+		if summary[i].Requests != summary[j].Requests {
+			return summary[i].Requests > summary[j].Requests
+		}
+		return summary[i].Host < summary[j].Host
+	})
+	return summary, nil
+}
+
+// ClearAuditLog removes the audit log
+func ClearAuditLog() error {
+	auditMu.Lock()
+	defer auditMu.Unlock()
+	path := GetAuditLogPath()
+	for _, p := range []string{path, path + ".1"} {
+		if err := os.Remove(p); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to clear audit log: %v", err)
+		}
+	}
+	return nil
+}
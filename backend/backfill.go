@@ -0,0 +1,148 @@
+package backend
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Metadata backfill
+//
+// New downloads get the tweet URL, the original file name and the posting date embedded (see
+// metadata.go), but files downloaded before that have none. BackfillMetadata walks an account
+// folder and embeds the same metadata into them afterwards. Files are matched to the stored
+// tweets (the library, or else tweets.jsonl) by the tweet ID and index in downloader file names,
+// by the original file name for templates with {original_filename}, or by a known tweet ID
+// anywhere in the name. Files that already carry their tweet URL are skipped, so a backfill can be
+// run again after an interruption.
+
+// backfillScanBytes is how much of the start and the end of a file is searched for embedded metadata
+const backfillScanBytes = 256 * 1024
+
+// backfillTweetIDPattern finds digit runs long enough to be tweet IDs in file names
+var backfillTweetIDPattern = regexp.MustCompile(`\d{8,20}`)
+
+// BackfillMetadataResult describes a metadata backfill of an account folder
+type BackfillMetadataResult struct {
+	Folder    string `json:"folder"`
+	Files     int    `json:"files"`     // JPG and MP4 files, the formats metadata is embedded in
+	Embedded  int    `json:"embedded"`  // Files that got their metadata
+	Skipped   int    `json:"skipped"`   // Files that already had it
+	Unmatched int    `json:"unmatched"` // Files without a stored tweet
+	Failed    int    `json:"failed"`
+}
+
+// BackfillMetadata embeds tweet metadata into the files of an account folder downloaded without it
+// dateZone is the zone of the embedded posting date, see DownloadOptions.DateZone.
+// onProgress, if set, is called after every file
+func BackfillMetadata(folder, dateZone string, onProgress func(done, total int)) (*BackfillMetadataResult, error) {
+	folder = filepath.Clean(folder)
+	username := filepath.Base(folder)
+	if IsUsernameSensitive(username) && IsLocked() {
+		return nil, ErrContentLocked
+	}
+	page, err := ListGallery(folder, 0, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tweets := archivedTweets(folder, username)
+	if len(tweets) == 0 {
+		return nil, fmt.Errorf("no stored tweets for @%s, fetch the account first", username)
+	}
+	byOriginal := make(map[string]string) // Original file name -> tweet ID
+	for tweetID, tweet := range tweets {
+		for _, url := range tweet.MediaURLs {
+			if name := ExtractOriginalFilename(url); name != "" {
+				byOriginal[name] = tweetID
+			}
+		}
+	}
+
+	var files []GalleryItem
+	for _, item := range page.Items {
+		switch strings.ToLower(filepath.Ext(item.Name)) {
+		case ".jpg", ".jpeg", ".mp4":
+			files = append(files, item)
+		}
+	}
+	result := &BackfillMetadataResult{Folder: folder, Files: len(files)}
+	for i, item := range files {
+		if onProgress != nil && i > 0 {
+			onProgress(i, len(files))
+		}
+		tweetID, original := matchBackfillFile(item, tweets, byOriginal)
+		if tweetID == "" {
+			result.Unmatched++
+			continue
+		}
+		tweetURL := "https://x.com/i/status/" + tweetID
+		if hasEmbeddedText(item.Path, tweetURL) {
+			result.Skipped++
+			continue
+		}
+		tweet := tweets[tweetID]
+		if err := EmbedMetadata(item.Path, tweet.Text, tweetURL, original, postedTime(tweet.Date, dateZone)); err != nil {
+			fmt.Printf("Warning: failed to embed metadata into %s: %v\n", item.Name, err)
+			result.Failed++
+			continue
+		}
+		result.Embedded++
+	}
+	if onProgress != nil {
+		onProgress(len(files), len(files))
+	}
+	return result, nil
+}
+
+// matchBackfillFile returns the tweet ID and original file name of a downloaded file, "" if no
+// stored tweet matches. The original file name is "" when the file's media can't be told apart
+// from the other media of its tweet
+func matchBackfillFile(item GalleryItem, tweets map[string]*archivedTweet, byOriginal map[string]string) (string, string) {
+	// Downloader file names: {username}_{timestamp}_{tweet_id}_{index}
+	if tweet := tweets[item.TweetID]; tweet != nil {
+		return item.TweetID, ExtractOriginalFilename(tweet.MediaURLs[archiveFileIndex(item.Name)])
+	}
+	stem := strings.TrimSuffix(item.Name, filepath.Ext(item.Name))
+	for original, tweetID := range byOriginal {
+		if strings.Contains(stem, original) {
+			return tweetID, original
+		}
+	}
+	for _, candidate := range backfillTweetIDPattern.FindAllString(stem, -1) {
+		tweet := tweets[candidate]
+		if tweet == nil {
+			continue
+		}
+		if len(tweet.MediaURLs) == 1 {
+			return candidate, ExtractOriginalFilename(tweet.MediaURLs[1])
+		}
+		return candidate, ""
+	}
+	return "", ""
+}
+
+// hasEmbeddedText reports whether text is in the first or last backfillScanBytes of a file, where
+// JPEG comments and MP4 metadata are written
+func hasEmbeddedText(path, text string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	buf := make([]byte, backfillScanBytes)
+	n, _ := io.ReadFull(f, buf)
+	if bytes.Contains(buf[:n], []byte(text)) {
+		return true
+	}
+	info, err := f.Stat()
+	if err != nil || info.Size() <= backfillScanBytes {
+		return false
+	}
+	n, _ = f.ReadAt(buf, max(info.Size()-backfillScanBytes, backfillScanBytes-int64(len(text))))
+	return bytes.Contains(buf[:n], []byte(text))
+}
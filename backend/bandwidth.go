@@ -0,0 +1,103 @@
+package backend
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Bandwidth limit
+//
+// With a bandwidth limit in the settings, all downloads share one budget of bytes per second, so
+// several jobs running at once together stay under the limit. Response bodies are read through a
+// limiter that paces the reads: each read reserves its bytes and sleeps until the budget allows
+// them. Without a limit the client is used as is.
+
+// bandwidthLimiter paces reads to a number of bytes per second
+type bandwidthLimiter struct {
+	mu   sync.Mutex
+	rate int64     // Bytes per second, 0 = unlimited
+	next time.Time // When the bytes reserved so far have been paid for
+}
+
+var bandwidth = &bandwidthLimiter{}
+
+// setRate changes the limit, 0 removes it
+func (l *bandwidthLimiter) setRate(bytesPerSecond int64) {
+	l.mu.Lock()
+	l.rate = bytesPerSecond
+	l.next = time.Time{}
+	l.mu.Unlock()
+}
+
+// limited reports whether a limit is set
+func (l *bandwidthLimiter) limited() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.rate > 0
+}
+
+// take reserves n bytes and waits until the limit allows them
+func (l *bandwidthLimiter) take(n int) {
+	l.mu.Lock()
+	if l.rate <= 0 || n <= 0 {
+		l.mu.Unlock()
+		return
+	}
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	l.next = l.next.Add(time.Duration(int64(n) * int64(time.Second) / l.rate))
+	wait := l.next.Sub(now)
+	l.mu.Unlock()
+	time.Sleep(wait)
+}
+
+// wrap returns a client whose response bodies are read at the bandwidth limit
+func (l *bandwidthLimiter) wrap(client *http.Client) *http.Client {
+	if !l.limited() {
+		return client
+	}
+	wrapped := *client
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	wrapped.Transport = &bandwidthTransport{next: transport, limiter: l}
+	return &wrapped
+}
+
+// bandwidthTransport paces the response bodies of a transport
+type bandwidthTransport struct {
+	next    http.RoundTripper
+	limiter *bandwidthLimiter
+}
+
+func (t *bandwidthTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	resp.Body = &bandwidthBody{ReadCloser: resp.Body, limiter: t.limiter}
+	return resp, nil
+}
+
+// bandwidthBody is a response body read at the bandwidth limit
+type bandwidthBody struct {
+	io.ReadCloser
+	limiter *bandwidthLimiter
+}
+
+// bandwidthChunk keeps single reads small, so the pacing stays smooth
+const bandwidthChunk = 32 * 1024
+
+func (b *bandwidthBody) Read(p []byte) (int, error) {
+	if len(p) > bandwidthChunk {
+		p = p[:bandwidthChunk]
+	}
+	n, err := b.ReadCloser.Read(p)
+	b.limiter.take(n)
+	return n, err
+}
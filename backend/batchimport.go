@@ -0,0 +1,323 @@
+package backend
+
+import (
+	"bufio"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Batch import
+//
+// BatchImport reads a plain text file (one entry per line, # starts a comment) or a CSV file (the
+// first cell of each row that's a usable entry, so a header row or extra columns don't matter)
+// and turns every entry into a fetch: profile URLs and bare usernames fetch the timeline their
+// path names (/media, /likes, ...), tweet URLs and bare tweet IDs fetch that single tweet, and
+// search and hashtag URLs run the search. Lines that are none of these are reported with their
+// line number, duplicates are dropped. Single tweets and searches are fetched with
+// FetchBatchEntry and merged into the saved account of each author, so they never replace an
+// account's saved timeline; profiles go through the regular multiple-account fetch.
+
+// Batch import modes
+const (
+	BatchModeProfile = "profile"
+	BatchModeTweet   = "tweet"
+	BatchModeSearch  = "search"
+)
+
+var (
+	batchUsernamePattern = regexp.MustCompile(`^@?([A-Za-z0-9_]{1,15})$`)
+	batchTweetIDPattern  = regexp.MustCompile(`^\d{16,20}$`) // Longer than any username can be
+)
+
+// batchHosts are the hosts whose URLs can be imported (embed fixers mirror x.com paths)
+var batchHosts = map[string]bool{
+	"x.com":              true,
+	"twitter.com":        true,
+	"mobile.twitter.com": true,
+	"mobile.x.com":       true,
+	"fxtwitter.com":      true,
+	"vxtwitter.com":      true,
+	"fixupx.com":         true,
+	"fixvx.com":          true,
+}
+
+// BatchImportEntry is an imported fetch
+type BatchImportEntry struct {
+	Line         int    `json:"line"`
+	Input        string `json:"input"`
+	Mode         string `json:"mode"`                    // profile, tweet or search
+	Username     string `json:"username,omitempty"`      // Profile, or the author of a tweet if the URL names it
+	TimelineType string `json:"timeline_type,omitempty"` // Profile timeline, see GetTimelineTypes ("" = the default one)
+	TweetID      string `json:"tweet_id,omitempty"`
+	URL          string `json:"url,omitempty"` // Search URL
+}
+
+// BatchImportResult is the outcome of reading a batch file
+type BatchImportResult struct {
+	Entries    []BatchImportEntry `json:"entries"`
+	Errors     []QueueLineError   `json:"errors"`
+	Duplicates int                `json:"duplicates"`
+}
+
+// BatchFetchResult is the outcome of fetching a single tweet or search entry
+type BatchFetchResult struct {
+	Accounts []string `json:"accounts"` // Saved accounts the media were merged into
+	Media    int      `json:"media"`
+	Partial  bool     `json:"partial,omitempty"`
+	Error    string   `json:"error,omitempty"` // Why a search stopped early
+}
+
+// BatchImport reads a text or CSV file of tweet, profile and search URLs
+func BatchImport(path string) (*BatchImportResult, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open file: %v", err)
+	}
+	defer f.Close()
+	if strings.EqualFold(filepath.Ext(path), ".csv") {
+		return parseBatchCSV(f)
+	}
+	return parseBatchText(f)
+}
+
+// parseBatchText reads one entry per line
+func parseBatchText(r io.Reader) (*BatchImportResult, error) {
+	result := &BatchImportResult{Entries: []BatchImportEntry{}, Errors: []QueueLineError{}}
+	seen := make(map[string]bool)
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(strings.TrimPrefix(scanner.Text(), "\ufeff"))
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		entry, err := parseBatchEntry(text)
+		if err != nil {
+			result.Errors = append(result.Errors, QueueLineError{Line: line, Message: err.Error()})
+			continue
+		}
+		entry.Line = line
+		result.add(entry, seen)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+	return result, nil
+}
+
+// parseBatchCSV reads the first usable cell of each row
+func parseBatchCSV(r io.Reader) (*BatchImportResult, error) {
+	result := &BatchImportResult{Entries: []BatchImportEntry{}, Errors: []QueueLineError{}}
+	seen := make(map[string]bool)
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+	reader.TrimLeadingSpace = true
+	for row := 1; ; row++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid CSV: %v", err)
+		}
+		var found bool
+		var firstErr error
+		for _, cell := range record {
+			cell = strings.TrimSpace(strings.TrimPrefix(cell, "\ufeff"))
+			if cell == "" {
+				continue
+			}
+			entry, err := parseBatchEntry(cell)
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				continue
+			}
+			// A header like "username" reads as a bare username, the first row needs URLs or @names
+			if row == 1 && entry.Mode == BatchModeProfile && !strings.ContainsAny(cell, "@/") {
+				continue
+			}
+			line, _ := reader.FieldPos(0)
+			entry.Line = line
+			result.add(entry, seen)
+			found = true
+			break
+		}
+		// The first row may be a header
+		if !found && firstErr != nil && row > 1 {
+			line, _ := reader.FieldPos(0)
+			result.Errors = append(result.Errors, QueueLineError{Line: line, Message: firstErr.Error()})
+		}
+	}
+	return result, nil
+}
+
+// add appends an entry unless the same fetch was imported already
+func (r *BatchImportResult) add(entry BatchImportEntry, seen map[string]bool) {
+	key := entry.Mode + "|" + strings.ToLower(entry.Username) + "|" + entry.TimelineType + "|" + entry.TweetID + "|" + entry.URL
+	if entry.Mode == BatchModeTweet {
+		key = entry.Mode + "|" + entry.TweetID
+	}
+	if seen[key] {
+		r.Duplicates++
+		return
+	}
+	seen[key] = true
+	r.Entries = append(r.Entries, entry)
+}
+
+// parseBatchEntry maps an input to its fetch
+func parseBatchEntry(input string) (BatchImportEntry, error) {
+	entry := BatchImportEntry{Input: input}
+	if batchTweetIDPattern.MatchString(input) {
+		entry.Mode = BatchModeTweet
+		entry.TweetID = input
+		return entry, nil
+	}
+	if m := batchUsernamePattern.FindStringSubmatch(input); m != nil {
+		entry.Mode = BatchModeProfile
+		entry.Username = m[1]
+		return entry, nil
+	}
+
+	u, err := url.Parse(ensureURLScheme(input))
+	if err != nil || !batchHosts[strings.TrimPrefix(strings.ToLower(u.Hostname()), "www.")] {
+		return entry, fmt.Errorf("not a tweet, profile or search URL: %s", input)
+	}
+	segments := strings.Split(strings.Trim(u.Path, "/"), "/")
+	first := strings.ToLower(segments[0])
+
+	// /<user>/status/<id>, /i/status/<id>, /i/web/status/<id>
+	for i := 0; i+1 < len(segments); i++ {
+		if segments[i] == "status" || segments[i] == "statuses" {
+			id := segments[i+1]
+			if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+				return entry, fmt.Errorf("invalid tweet ID in %s", input)
+			}
+			entry.Mode = BatchModeTweet
+			entry.TweetID = id
+			if i == 1 && first != "i" {
+				entry.Username = segments[0]
+			}
+			return entry, nil
+		}
+	}
+
+	switch first {
+	case "search":
+		if u.Query().Get("q") == "" {
+			return entry, fmt.Errorf("search URL without a query: %s", input)
+		}
+		entry.Mode = BatchModeSearch
+		entry.URL = "https://x.com/search?" + u.RawQuery
+		return entry, nil
+	case "hashtag":
+		if len(segments) < 2 || segments[1] == "" {
+			return entry, fmt.Errorf("hashtag URL without a tag: %s", input)
+		}
+		entry.Mode = BatchModeSearch
+		entry.URL = "https://x.com/search?" + url.Values{"q": {"#" + segments[1]}, "f": {"live"}}.Encode()
+		return entry, nil
+	case "", "i", "home", "explore", "settings", "messages", "notifications":
+		return entry, fmt.Errorf("not a tweet, profile or search URL: %s", input)
+	}
+
+	if !batchUsernamePattern.MatchString(segments[0]) {
+		return entry, fmt.Errorf("invalid username in %s", input)
+	}
+	entry.Mode = BatchModeProfile
+	entry.Username = segments[0]
+	if len(segments) > 1 {
+		timelineType := ""
+		for _, t := range timelineTypes {
+			if t.NeedsUsername && strings.EqualFold(t.Path, "/"+segments[1]) {
+				timelineType = t.ID
+			}
+		}
+		if timelineType == "" {
+			return entry, fmt.Errorf("unsupported profile page /%s: %s", segments[1], input)
+		}
+		entry.TimelineType = timelineType
+	}
+	return entry, nil
+}
+
+// FetchBatchEntry fetches a single tweet or search entry and merges its media into the saved
+// account of each author
+func FetchBatchEntry(entry BatchImportEntry, authToken, dateZone string) (*BatchFetchResult, error) {
+	result := &BatchFetchResult{Accounts: []string{}}
+	var timeline []TimelineEntry
+	nicks := make(map[string]string)
+	switch entry.Mode {
+	case BatchModeTweet:
+		id, err := strconv.ParseInt(entry.TweetID, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tweet ID: %s", entry.TweetID)
+		}
+		media, err := ExtractTweetMedia(id, authToken)
+		if err != nil {
+			return nil, err
+		}
+		if len(media) == 0 {
+			return nil, fmt.Errorf("tweet %s has no media (deleted, protected or text only)", entry.TweetID)
+		}
+		for _, item := range media {
+			timeline = append(timeline, convertToTimelineEntry(item))
+			nicks[item.Author.Name] = item.Author.Nick
+		}
+		NormalizeTimelineDates(timeline, dateZone)
+	case BatchModeSearch:
+		response, err := ExtractDateRange(DateRangeRequest{Username: entry.URL, AuthToken: authToken, DateZone: dateZone})
+		if err != nil {
+			return nil, err
+		}
+		timeline = response.Timeline
+		result.Partial = response.Partial
+		result.Error = response.Error
+		nicks[response.AccountInfo.Name] = response.AccountInfo.Nick
+	default:
+		return nil, fmt.Errorf("profiles are fetched as accounts: %s", entry.Input)
+	}
+
+	// One saved account per author
+	byAuthor := make(map[string][]TimelineEntry)
+	var authors []string
+	for _, e := range timeline {
+		author := e.AuthorUsername
+		if author == "" {
+			author = entry.Username
+		}
+		if author == "" {
+			continue
+		}
+		if _, ok := byAuthor[author]; !ok {
+			authors = append(authors, author)
+		}
+		byAuthor[author] = append(byAuthor[author], e)
+	}
+	for _, author := range authors {
+		if checkFetchAllowed(author) != nil {
+			continue
+		}
+		nick := nicks[author]
+		if nick == "" {
+			nick = author
+		}
+		if err := mergeAccountTimeline(author, nick, byAuthor[author]); err != nil {
+			return result, fmt.Errorf("failed to save @%s: %v", author, err)
+		}
+		result.Accounts = append(result.Accounts, author)
+		result.Media += len(byAuthor[author])
+	}
+	return result, nil
+}
@@ -0,0 +1,196 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Polls and cards
+//
+// Tweets with a poll or a link preview carry a card: a name saying what kind it is and a list of
+// binding values (key, typed value). When the extractor includes the card of a tweet it's parsed
+// into a TweetCard on the timeline entry, with the options and vote counts of a poll or the
+// title, description, domain and image of a link preview, and written along with the tweet text
+// in text downloads and the tweet archives. The binding values come as a list (GraphQL, possibly
+// wrapped in "legacy") or as a map keyed by name (older API); both are read.
+
+// TweetCard is the poll or link preview of a tweet
+type TweetCard struct {
+	Name        string     `json:"name"`          // Card type, e.g. summary_large_image or poll2choice_text_only
+	URL         string     `json:"url,omitempty"` // Link the card points to
+	Title       string     `json:"title,omitempty"`
+	Description string     `json:"description,omitempty"`
+	Domain      string     `json:"domain,omitempty"`
+	Image       string     `json:"image,omitempty"` // Preview image URL
+	Poll        *TweetPoll `json:"poll,omitempty"`
+}
+
+// TweetPoll is the state of a poll when the tweet was fetched
+type TweetPoll struct {
+	Choices    []PollChoice `json:"choices"`
+	TotalVotes int          `json:"total_votes"`
+	EndsAt     string       `json:"ends_at,omitempty"`
+	Final      bool         `json:"final"` // The poll ended and the counts won't change
+}
+
+// PollChoice is an option of a poll
+type PollChoice struct {
+	Label string `json:"label"`
+	Votes int    `json:"votes"`
+}
+
+// cardValue is a binding value of a card
+type cardValue struct {
+	Type         string `json:"type"`
+	StringValue  string `json:"string_value"`
+	BooleanValue bool   `json:"boolean_value"`
+	ImageValue   struct {
+		URL string `json:"url"`
+	} `json:"image_value"`
+}
+
+// cardImageKeys are the binding values holding the preview image, best first
+var cardImageKeys = []string{
+	"photo_image_full_size_original",
+	"summary_photo_image_original",
+	"thumbnail_image_original",
+	"photo_image_full_size_large",
+	"summary_photo_image_large",
+	"thumbnail_image_large",
+}
+
+// parseCard parses the card of a tweet as the extractor reports it, nil if there is none
+func parseCard(raw json.RawMessage) *TweetCard {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var card struct {
+		Name          string          `json:"name"`
+		URL           string          `json:"url"`
+		BindingValues json.RawMessage `json:"binding_values"`
+		Legacy        json.RawMessage `json:"legacy"`
+	}
+	if err := json.Unmarshal(raw, &card); err != nil {
+		return nil
+	}
+	if len(card.Legacy) > 0 && card.Name == "" {
+		return parseCard(card.Legacy)
+	}
+	if card.Name == "" {
+		return nil
+	}
+
+	values := cardBindingValues(card.BindingValues)
+	result := &TweetCard{
+		// Names can be prefixed with the ID of the card's owner, e.g. 123456:live_event
+		Name:        card.Name[strings.LastIndex(card.Name, ":")+1:],
+		URL:         values["card_url"].StringValue,
+		Title:       values["title"].StringValue,
+		Description: values["description"].StringValue,
+		Domain:      values["domain"].StringValue,
+	}
+	if result.URL == "" {
+		result.URL = card.URL
+	}
+	if result.Domain == "" {
+		result.Domain = values["vanity_url"].StringValue
+	}
+	for _, key := range cardImageKeys {
+		if image := values[key].ImageValue.URL; image != "" {
+			result.Image = image
+			break
+		}
+	}
+
+	if strings.HasPrefix(result.Name, "poll") {
+		poll := &TweetPoll{
+			Choices: []PollChoice{},
+			EndsAt:  values["end_datetime_utc"].StringValue,
+			Final:   values["counts_are_final"].BooleanValue,
+		}
+		for i := 1; ; i++ {
+			label, ok := values[fmt.Sprintf("choice%d_label", i)]
+			if !ok {
+				break
+			}
+			votes, _ := strconv.Atoi(values[fmt.Sprintf("choice%d_count", i)].StringValue)
+			poll.Choices = append(poll.Choices, PollChoice{Label: label.StringValue, Votes: votes})
+			poll.TotalVotes += votes
+		}
+		result.Poll = poll
+	}
+	return result
+}
+
+// cardBindingValues reads the binding values of a card, given as a list or a map
+func cardBindingValues(raw json.RawMessage) map[string]cardValue {
+	values := make(map[string]cardValue)
+	var list []struct {
+		Key   string    `json:"key"`
+		Value cardValue `json:"value"`
+	}
+	if json.Unmarshal(raw, &list) == nil {
+		for _, v := range list {
+			values[v.Key] = v.Value
+		}
+		return values
+	}
+	json.Unmarshal(raw, &values)
+	return values
+}
+
+// formatCard formats a card as text for text downloads and tweet archives, "" for none
+func formatCard(card *TweetCard) string {
+	if card == nil {
+		return ""
+	}
+	var b strings.Builder
+	if card.Poll != nil {
+		b.WriteString("Poll")
+		if card.Poll.Final {
+			b.WriteString(" (final results)")
+		} else if card.Poll.EndsAt != "" {
+			fmt.Fprintf(&b, " (ends %s)", card.Poll.EndsAt)
+		}
+		b.WriteString(":\n")
+		for _, choice := range card.Poll.Choices {
+			percent := 0.0
+			if card.Poll.TotalVotes > 0 {
+				percent = float64(choice.Votes) * 100 / float64(card.Poll.TotalVotes)
+			}
+			fmt.Fprintf(&b, "- %s: %d votes (%.1f%%)\n", choice.Label, choice.Votes, percent)
+		}
+		fmt.Fprintf(&b, "Total votes: %d\n", card.Poll.TotalVotes)
+		return b.String()
+	}
+	if card.Title == "" && card.URL == "" {
+		return ""
+	}
+	b.WriteString("Link:")
+	for _, part := range []string{card.Title, card.Domain, card.URL} {
+		if part != "" {
+			b.WriteString(" ")
+			b.WriteString(part)
+		}
+	}
+	b.WriteString("\n")
+	if card.Description != "" {
+		b.WriteString(card.Description)
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// tweetText returns the text of a text download: the tweet text, then its poll or link preview
+func tweetText(item MediaItem) string {
+	card := formatCard(item.Card)
+	if card == "" {
+		return item.Content
+	}
+	if item.Content == "" {
+		return card
+	}
+	return item.Content + "\n\n" + card
+}
@@ -0,0 +1,102 @@
+package backend
+
+import (
+	"regexp"
+	"sync"
+	"time"
+)
+
+// Clipboard watcher
+//
+// With the clipboard watcher on, tweet links copied anywhere (x.com, twitter.com and embed fixers
+// like fxtwitter) are offered for the download queue, so a tweet can be queued without switching
+// to the app and pasting. The clipboard is polled about once a second; only text copied after the
+// watcher started counts, and every tweet is offered once per session, however often it's copied
+// again. The watcher only reads the clipboard through the function it's given, so the app decides
+// how (the Wails runtime on desktop).
+
+const clipboardPollInterval = time.Second
+
+// clipboardLinkPattern finds tweet links in copied text
+var clipboardLinkPattern = regexp.MustCompile(`(?i)(?:https?://)?(?:www\.|mobile\.)?(?:x|twitter|fxtwitter|vxtwitter|fixupx|fixvx)\.com/[A-Za-z0-9_]+(?:/web)?/status(?:es)?/\d+`)
+
+// maxClipboardText ignores huge clipboard contents (copied files, documents)
+const maxClipboardText = 64 << 10
+
+// ClipboardWatcher reports tweet links copied to the clipboard
+type ClipboardWatcher struct {
+	read    func() (string, error)
+	onLinks func([]BatchImportEntry)
+
+	mu   sync.Mutex
+	last string
+	seen map[string]bool // Tweet IDs offered already
+	stop chan struct{}
+}
+
+// StartClipboardWatcher polls the clipboard with read and calls onLinks with the tweets of newly copied text
+func StartClipboardWatcher(read func() (string, error), onLinks func([]BatchImportEntry)) *ClipboardWatcher {
+	w := &ClipboardWatcher{read: read, onLinks: onLinks, seen: make(map[string]bool), stop: make(chan struct{})}
+	// What's on the clipboard already was copied before the watcher started
+	w.last, _ = read()
+
+	go func() {
+		ticker := time.NewTicker(clipboardPollInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				w.poll()
+			case <-w.stop:
+				return
+			}
+		}
+	}()
+	return w
+}
+
+// Stop stops polling the clipboard
+func (w *ClipboardWatcher) Stop() {
+	close(w.stop)
+}
+
+// poll reads the clipboard once and reports new tweet links
+func (w *ClipboardWatcher) poll() {
+	text, err := w.read()
+	if err != nil || len(text) > maxClipboardText {
+		return
+	}
+	w.mu.Lock()
+	if text == w.last {
+		w.mu.Unlock()
+		return
+	}
+	w.last = text
+	var entries []BatchImportEntry
+	for _, entry := range clipboardTweetLinks(text) {
+		if !w.seen[entry.TweetID] {
+			w.seen[entry.TweetID] = true
+			entries = append(entries, entry)
+		}
+	}
+	w.mu.Unlock()
+
+	if len(entries) > 0 {
+		w.onLinks(entries)
+	}
+}
+
+// clipboardTweetLinks returns the tweets linked in a text, each once
+func clipboardTweetLinks(text string) []BatchImportEntry {
+	var entries []BatchImportEntry
+	found := make(map[string]bool)
+	for _, link := range clipboardLinkPattern.FindAllString(text, -1) {
+		entry, err := parseBatchEntry(link)
+		if err != nil || entry.Mode != BatchModeTweet || found[entry.TweetID] {
+			continue
+		}
+		found[entry.TweetID] = true
+		entries = append(entries, entry)
+	}
+	return entries
+}
@@ -0,0 +1,492 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Smart collections are saved searches over the library, evaluated again when opened or when the
+// library changes. New matches can be exported to a folder or downloaded automatically.
+
+// collectionRun keeps automatic runs from overlapping, see RunCollections
+var collectionRun sync.Mutex
+
+// CollectionQuery selects media of the library; all set fields must match
+type CollectionQuery struct {
+	Usernames   []string `json:"usernames,omitempty"` // Library accounts, all if empty
+	Types       []string `json:"types,omitempty"`     // photo, video, gif, text; all if empty
+	MinLikes    int      `json:"min_likes,omitempty"`
+	MinRetweets int      `json:"min_retweets,omitempty"`
+	MinViews    int      `json:"min_views,omitempty"`
+	Since       string   `json:"since,omitempty"`    // First day, YYYY-MM-DD
+	Until       string   `json:"until,omitempty"`    // Last day, YYYY-MM-DD
+	Text        string   `json:"text,omitempty"`     // Words the tweet must contain, case-insensitive
+	Retweets    string   `json:"retweets,omitempty"` // "", "only" or "exclude"
+	Tag         string   `json:"tag,omitempty"`      // Media tagged with this, see tags.go
+	Starred     bool     `json:"starred,omitempty"`  // Only starred media
+}
+
+// SmartCollection is a saved query and what to do with new matches
+type SmartCollection struct {
+	ID           string          `json:"id"`
+	Name         string          `json:"name"`
+	Query        CollectionQuery `json:"query"`
+	AutoExport   string          `json:"auto_export"`   // Folder new matches are exported to, "" = off
+	AutoDownload bool            `json:"auto_download"` // Download new matches that aren't downloaded yet
+	DownloadDir  string          `json:"download_dir"`  // Download folder for exports and downloads
+	CreatedAt    string          `json:"created_at"`
+	LastRun      string          `json:"last_run,omitempty"`
+	LastMatches  int             `json:"last_matches"`
+}
+
+// CollectionMatch is a media matched by a collection
+type CollectionMatch struct {
+	MediaKey  string `json:"media_key"`
+	Username  string `json:"username"` // Library account
+	Author    string `json:"author,omitempty"`
+	TweetID   string `json:"tweet_id"`
+	URL       string `json:"url"`
+	Type      string `json:"type"`
+	Date      string `json:"date"`
+	Content   string `json:"content,omitempty"`
+	Likes     int    `json:"likes"`
+	Retweets  int    `json:"retweets"`
+	Views     int    `json:"views"`
+	IsRetweet bool   `json:"is_retweet"`
+	New       bool   `json:"new"` // Not matched by an earlier evaluation
+}
+
+// CollectionResult is an evaluation of a collection
+type CollectionResult struct {
+	Collection SmartCollection   `json:"collection"`
+	Matches    []CollectionMatch `json:"matches"`
+	New        int               `json:"new"`
+	Exported   int               `json:"exported"`
+	Downloaded int               `json:"downloaded"`
+}
+
+// SaveCollection creates a collection, or updates it if its ID is set
+func SaveCollection(c SmartCollection) (SmartCollection, error) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return c, err
+		}
+	}
+	c.Name = strings.TrimSpace(c.Name)
+	if c.Name == "" {
+		return c, fmt.Errorf("collection name is required")
+	}
+	if err := validateCollectionQuery(c.Query); err != nil {
+		return c, err
+	}
+	if c.ID == "" {
+		c.ID = NewQueueID("collection")
+		c.CreatedAt = time.Now().Format(time.RFC3339)
+	}
+	query, err := json.Marshal(c.Query)
+	if err != nil {
+		return c, err
+	}
+	_, err = db.Exec(`
+		INSERT INTO collections (id, name, query_json, auto_export, auto_download, download_dir, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name, query_json = excluded.query_json, auto_export = excluded.auto_export,
+			auto_download = excluded.auto_download, download_dir = excluded.download_dir
+	`, c.ID, c.Name, string(query), c.AutoExport, boolToInt(c.AutoDownload), c.DownloadDir, c.CreatedAt)
+	if err != nil {
+		return c, fmt.Errorf("failed to save collection: %v", err)
+	}
+	// Another query matches other media
+	db.Exec("DELETE FROM collection_matches WHERE collection_id = ?", c.ID)
+	return c, nil
+}
+
+// validateCollectionQuery checks the dates and options of a query
+func validateCollectionQuery(q CollectionQuery) error {
+	for _, day := range []string{q.Since, q.Until} {
+		if day == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", day); err != nil {
+			return fmt.Errorf("invalid date %q, use YYYY-MM-DD", day)
+		}
+	}
+	switch q.Retweets {
+	case "", "only", "exclude":
+	default:
+		return fmt.Errorf("invalid retweets option: %s", q.Retweets)
+	}
+	return nil
+}
+
+// DeleteCollection deletes a collection
+func DeleteCollection(id string) error {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return err
+		}
+	}
+	db.Exec("DELETE FROM collection_matches WHERE collection_id = ?", id)
+	if _, err := db.Exec("DELETE FROM collections WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to delete collection: %v", err)
+	}
+	return nil
+}
+
+// ListCollections returns the collections, oldest first
+func ListCollections() ([]SmartCollection, error) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return nil, err
+		}
+	}
+	rows, err := db.Query(`
+		SELECT id, name, query_json, COALESCE(auto_export, ''), COALESCE(auto_download, 0), COALESCE(download_dir, ''),
+		       created_at, COALESCE(last_run, ''), COALESCE(last_matches, 0)
+		FROM collections ORDER BY created_at, name
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list collections: %v", err)
+	}
+	defer rows.Close()
+	collections := make([]SmartCollection, 0)
+	for rows.Next() {
+		var c SmartCollection
+		var query string
+		var autoDownload int
+		if err := rows.Scan(&c.ID, &c.Name, &query, &c.AutoExport, &autoDownload, &c.DownloadDir, &c.CreatedAt, &c.LastRun, &c.LastMatches); err != nil {
+			continue
+		}
+		json.Unmarshal([]byte(query), &c.Query)
+		c.AutoDownload = autoDownload == 1
+		collections = append(collections, c)
+	}
+	return collections, nil
+}
+
+// loadCollection returns a collection by ID
+func loadCollection(id string) (SmartCollection, error) {
+	collections, err := ListCollections()
+	if err != nil {
+		return SmartCollection{}, err
+	}
+	for _, c := range collections {
+		if c.ID == id {
+			return c, nil
+		}
+	}
+	return SmartCollection{}, fmt.Errorf("collection %s not found", id)
+}
+
+// EvaluateCollection returns the media a collection matches now, marking the new ones
+// Matches of collections with automatic actions stay new until RunCollections acts on them
+func EvaluateCollection(id string) (*CollectionResult, error) {
+	c, err := loadCollection(id)
+	if err != nil {
+		return nil, err
+	}
+	return evaluateCollection(c, c.AutoExport == "" && !c.AutoDownload)
+}
+
+// evaluateCollection matches a collection against the library; with record its matches are
+// remembered, so they aren't new in the next evaluation
+func evaluateCollection(c SmartCollection, record bool) (*CollectionResult, error) {
+	matches, err := findCollectionMatches(c.Query)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	rows, err := db.Query("SELECT media_key FROM collection_matches WHERE collection_id = ?", c.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load collection matches: %v", err)
+	}
+	for rows.Next() {
+		var key string
+		if rows.Scan(&key) == nil {
+			seen[key] = true
+		}
+	}
+	rows.Close()
+
+	result := &CollectionResult{Collection: c, Matches: matches}
+	for i := range matches {
+		if !seen[matches[i].MediaKey] {
+			matches[i].New = true
+			result.New++
+		}
+	}
+	if record {
+		if err := recordCollectionMatches(result); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+// recordCollectionMatches remembers the matches of an evaluation
+func recordCollectionMatches(result *CollectionResult) error {
+	c := result.Collection
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to save collection matches: %v", err)
+	}
+	for _, m := range result.Matches {
+		if m.New {
+			tx.Exec("INSERT OR IGNORE INTO collection_matches (collection_id, media_key) VALUES (?, ?)", c.ID, m.MediaKey)
+		}
+	}
+	c.LastRun = time.Now().Format(time.RFC3339)
+	c.LastMatches = len(result.Matches)
+	tx.Exec("UPDATE collections SET last_run = ?, last_matches = ? WHERE id = ?", c.LastRun, c.LastMatches, c.ID)
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to save collection matches: %v", err)
+	}
+	result.Collection = c
+	return nil
+}
+
+// findCollectionMatches returns the media of the library matching a query, newest tweets first
+func findCollectionMatches(q CollectionQuery) ([]CollectionMatch, error) {
+	accounts, err := GetAllAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %v", err)
+	}
+	var labeled map[string]bool
+	if q.Tag != "" || q.Starred {
+		labels, err := FindLabeledMedia(LabelFilter{Tag: q.Tag, Starred: q.Starred})
+		if err != nil {
+			return nil, err
+		}
+		labeled = make(map[string]bool, len(labels))
+		for _, l := range labels {
+			labeled[l.MediaKey] = true
+		}
+	}
+	since, until := collectionDateRange(q)
+	text := strings.ToLower(strings.TrimSpace(q.Text))
+
+	matches := make([]CollectionMatch, 0)
+	seenUser := make(map[string]bool)
+	seenMedia := make(map[string]bool)
+	for _, acc := range accounts {
+		username := strings.ToLower(acc.Username)
+		if seenUser[username] || (len(q.Usernames) > 0 && !containsHandle(q.Usernames, username)) {
+			continue
+		}
+		seenUser[username] = true
+		for _, data := range libraryTimelines(acc.Username) {
+			var response TwitterResponse
+			if json.Unmarshal([]byte(data), &response) != nil {
+				continue
+			}
+			for _, entry := range response.Timeline {
+				key := mediaKey(entry.URL)
+				if seenMedia[key] || !matchCollectionEntry(q, entry, since, until, text) {
+					continue
+				}
+				if labeled != nil && !labeled[key] {
+					continue
+				}
+				seenMedia[key] = true
+				matches = append(matches, CollectionMatch{
+					MediaKey:  key,
+					Username:  acc.Username,
+					Author:    entry.AuthorUsername,
+					TweetID:   strconv.FormatInt(int64(entry.TweetID), 10),
+					URL:       entry.URL,
+					Type:      entry.Type,
+					Date:      entry.Date,
+					Content:   entry.Content,
+					Likes:     entry.FavoriteCount,
+					Retweets:  entry.RetweetCount,
+					Views:     entry.ViewCount,
+					IsRetweet: entry.IsRetweet,
+				})
+			}
+		}
+	}
+	sortCollectionMatches(matches)
+	return matches, nil
+}
+
+// collectionDateRange returns the time range of a query, zero for open ends
+func collectionDateRange(q CollectionQuery) (since, until time.Time) {
+	if q.Since != "" {
+		since, _ = time.Parse("2006-01-02", q.Since)
+	}
+	if q.Until != "" {
+		if t, err := time.Parse("2006-01-02", q.Until); err == nil {
+			until = t.AddDate(0, 0, 1) // The last day is included
+		}
+	}
+	return since, until
+}
+
+// matchCollectionEntry reports whether a timeline entry passes a query (without tags and stars)
+func matchCollectionEntry(q CollectionQuery, entry TimelineEntry, since, until time.Time, text string) bool {
+	if len(q.Types) > 0 {
+		entryType := entry.Type
+		if entryType == "animated_gif" {
+			entryType = "gif"
+		}
+		if !containsString(q.Types, entryType) {
+			return false
+		}
+	}
+	if entry.FavoriteCount < q.MinLikes || entry.RetweetCount < q.MinRetweets || entry.ViewCount < q.MinViews {
+		return false
+	}
+	if (q.Retweets == "only" && !entry.IsRetweet) || (q.Retweets == "exclude" && entry.IsRetweet) {
+		return false
+	}
+	if !since.IsZero() || !until.IsZero() {
+		posted, err := ParseTweetDate(entry.Date)
+		if err != nil {
+			return false
+		}
+		if (!since.IsZero() && posted.Before(since)) || (!until.IsZero() && !posted.Before(until)) {
+			return false
+		}
+	}
+	if text != "" {
+		content := strings.ToLower(entry.Content)
+		for _, word := range strings.Fields(text) {
+			if !strings.Contains(content, word) {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// sortCollectionMatches sorts matches by tweet, newest first
+func sortCollectionMatches(matches []CollectionMatch) {
+	id := func(m CollectionMatch) int64 {
+		n, _ := strconv.ParseInt(m.TweetID, 10, 64)
+		return n
+	}
+	sort.SliceStable(matches, func(i, j int) bool {
+		return id(matches[i]) > id(matches[j])
+	})
+}
+
+// RunCollections evaluates the collections with automatic actions and exports and downloads their
+// new matches. Runs don't overlap: while one is running, another returns right away with nothing.
+// onResult, if set, is called for every collection with new matches
+func RunCollections(ctx context.Context, opts DownloadOptions, proxy string, onResult func(CollectionResult)) error {
+	if !collectionRun.TryLock() {
+		return nil
+	}
+	defer collectionRun.Unlock()
+
+	collections, err := ListCollections()
+	if err != nil {
+		return err
+	}
+	for _, c := range collections {
+		if c.AutoExport == "" && !c.AutoDownload {
+			continue
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		result, err := runCollection(ctx, c, opts, proxy)
+		if err != nil {
+			fmt.Printf("Warning: collection %q failed: %v\n", c.Name, err)
+			continue
+		}
+		if result.New > 0 && onResult != nil {
+			onResult(*result)
+		}
+	}
+	return nil
+}
+
+// runCollection evaluates a collection, then downloads and exports its new matches
+// They're only remembered once that's done, so an interrupted run is picked up by the next one
+func runCollection(ctx context.Context, c SmartCollection, opts DownloadOptions, proxy string) (*CollectionResult, error) {
+	result, err := evaluateCollection(c, false)
+	if err != nil || result.New == 0 {
+		return result, err
+	}
+
+	if c.AutoDownload && c.DownloadDir != "" {
+		// Downloads are grouped by library account, like the account's own downloads
+		byAccount := make(map[string][]MediaItem)
+		var order []string
+		for _, m := range result.Matches {
+			if !m.New {
+				continue
+			}
+			tweetID, _ := strconv.ParseInt(m.TweetID, 10, 64)
+			if byAccount[m.Username] == nil {
+				order = append(order, m.Username)
+			}
+			byAccount[m.Username] = append(byAccount[m.Username], MediaItem{
+				URL:      m.URL,
+				Date:     m.Date,
+				TweetID:  tweetID,
+				Type:     m.Type,
+				Content:  m.Content,
+				Username: m.Author,
+			})
+		}
+		for _, username := range order {
+			downloaded, _, _, err := DownloadMediaWithMetadataProgressAndStatus(byAccount[username], c.DownloadDir, username, nil, nil, ctx, proxy, opts)
+			result.Downloaded += downloaded
+			if err != nil {
+				return result, err
+			}
+		}
+	}
+
+	if c.AutoExport != "" {
+		var refs []exportRef
+		for _, m := range result.Matches {
+			if !m.New {
+				continue
+			}
+			author := m.Author
+			if author == "" {
+				author = m.Username
+			}
+			refs = append(refs, exportRef{author: author, key: m.MediaKey})
+		}
+		exported, err := exportMediaFiles(refs, c.DownloadDir, c.AutoExport)
+		result.Exported = exported.Exported
+		if err != nil {
+			return result, err
+		}
+	}
+	return result, recordCollectionMatches(result)
+}
+
+// renameInCollections points the queries of collections at the new username of a renamed account
+func renameInCollections(oldName, newName string) {
+	collections, err := ListCollections()
+	if err != nil {
+		return
+	}
+	for _, c := range collections {
+		if !containsHandle(c.Query.Usernames, normalizeHandle(oldName)) {
+			continue
+		}
+		for i, username := range c.Query.Usernames {
+			if normalizeHandle(username) == normalizeHandle(oldName) {
+				c.Query.Usernames[i] = newName
+			}
+		}
+		// The media stay the same, so the remembered matches are kept
+		if query, err := json.Marshal(c.Query); err == nil {
+			db.Exec("UPDATE collections SET query_json = ? WHERE id = ?", string(query), c.ID)
+		}
+	}
+}
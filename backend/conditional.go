@@ -0,0 +1,130 @@
+package backend
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"time"
+)
+
+// Conditional re-downloads
+//
+// The ETag and Last-Modified headers of every downloaded media URL are kept in the
+// media_validators table of the library. When a job meets a file that already exists and its
+// conflict policy wants the new content (overwrite, keep both, ask), the URL is requested with
+// If-None-Match and If-Modified-Since; a 304 answer means the file didn't change and it's skipped
+// without downloading anything, which makes refresh runs over a large archive cheap. Files
+// downloaded before the validators were kept are compared by their modification time. As not
+// every server answers conditional requests, a HEAD request goes first: the ETag that was kept,
+// or without one a size equal to the existing file's, also counts as unchanged, so the body is
+// only fetched when the file really differs.
+
+// rememberValidators keeps the ETag and Last-Modified of a downloaded URL
+func rememberValidators(url string, header http.Header) {
+	etag, lastModified := header.Get("ETag"), header.Get("Last-Modified")
+	if etag == "" && lastModified == "" {
+		return
+	}
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return
+		}
+	}
+	db.Exec(`
+		INSERT OR REPLACE INTO media_validators (url, etag, last_modified, updated_at)
+		VALUES (?, ?, ?, ?)
+	`, url, etag, lastModified, time.Now().UTC().Format(time.RFC3339))
+}
+
+// loadValidators returns the ETag and Last-Modified a URL was downloaded with
+func loadValidators(url string) (etag, lastModified string) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return "", ""
+		}
+	}
+	db.QueryRow("SELECT etag, last_modified FROM media_validators WHERE url = ?", url).Scan(&etag, &lastModified)
+	return etag, lastModified
+}
+
+// downloadIfModified downloads url to path unless the server says the existing file is still current
+// Returns false without downloading on 304 Not Modified
+func downloadIfModified(ctx context.Context, client *http.Client, store Storage, url, path, existingPath string) (bool, error) {
+	existing, _ := store.Stat(existingPath)
+	if headUnchanged(ctx, client, url, existing) {
+		return false, nil
+	}
+
+	req, err := newConditionalRequest(ctx, "GET", url, existing)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return false, nil
+	case http.StatusOK:
+	default:
+		return false, &HTTPStatusError{Code: resp.StatusCode, Status: resp.Status}
+	}
+	if err := writeStorageFile(store, path, resp.Body); err != nil {
+		return false, err
+	}
+	rememberValidators(url, resp.Header)
+	return true, nil
+}
+
+// newConditionalRequest returns a request for url with the validators of the existing file
+func newConditionalRequest(ctx context.Context, method, url string, existing os.FileInfo) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	etag, lastModified := loadValidators(url)
+	if etag != "" {
+		req.Header.Set("If-None-Match", etag)
+	}
+	if lastModified != "" {
+		req.Header.Set("If-Modified-Since", lastModified)
+	} else if etag == "" && existing != nil && !existing.ModTime().IsZero() {
+		req.Header.Set("If-Modified-Since", existing.ModTime().UTC().Format(http.TimeFormat))
+	}
+	return req, nil
+}
+
+// headUnchanged reports whether a HEAD request shows that the existing file is still current:
+// 304, the ETag that was kept, or without a kept ETag the size of the existing file
+// Errors and servers without HEAD count as changed, the body is fetched then
+func headUnchanged(ctx context.Context, client *http.Client, url string, existing os.FileInfo) bool {
+	req, err := newConditionalRequest(ctx, http.MethodHead, url, existing)
+	if err != nil {
+		return false
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false
+	}
+	resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusNotModified:
+		return true
+	case http.StatusOK:
+	default:
+		return false
+	}
+	etag, _ := loadValidators(url)
+	if etag != "" {
+		return resp.Header.Get("ETag") == etag
+	}
+	if existing != nil && resp.ContentLength > 0 && resp.ContentLength == existing.Size() {
+		rememberValidators(url, resp.Header)
+		return true
+	}
+	return false
+}
@@ -10,6 +10,11 @@ import (
 )
 
 func GetDefaultDownloadPath() string {
+	// Portable mode keeps downloads next to the app as well
+	if root := portableAppDir(); root != "" {
+		return filepath.Join(root, "downloads")
+	}
+
 	// Get user's home directory
 	homeDir, err := os.UserHomeDir()
 	if err != nil {
@@ -33,6 +38,15 @@ func GetProxyURL(customProxy string) (*url.URL, error) {
 		return proxyURL, nil
 	}
 
+	// Then the default proxy from the settings
+	if proxy := GetSettings().Proxy; proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil {
+			return nil, fmt.Errorf("invalid proxy URL in settings: %v", err)
+		}
+		return proxyURL, nil
+	}
+
 	// Check environment variables (case-insensitive on Windows)
 	proxyEnv := os.Getenv("HTTPS_PROXY")
 	if proxyEnv == "" {
@@ -57,18 +71,15 @@ func GetProxyURL(customProxy string) (*url.URL, error) {
 }
 
 // CreateHTTPClient creates an HTTP client with proxy support
+// Clients share their connections, see transport.go
 func CreateHTTPClient(customProxy string, timeout time.Duration) (*http.Client, error) {
 	proxyURL, err := GetProxyURL(customProxy)
 	if err != nil {
 		return nil, err
 	}
 
-	transport := &http.Transport{
-		Proxy: http.ProxyURL(proxyURL),
-	}
-
 	client := &http.Client{
-		Transport: transport,
+		Transport: audited(sharedTransport(proxyURL), ""),
 		Timeout:   timeout,
 	}
 
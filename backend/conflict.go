@@ -0,0 +1,206 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Conflict policies for target files that already exist
+const (
+	ConflictSkip      = "skip"      // Keep the existing file without downloading (default)
+	ConflictOverwrite = "overwrite" // Replace the existing file if the content differs
+	ConflictKeepBoth  = "keep_both" // Save the new file next to the existing one if the content differs
+	ConflictAsk       = "ask"       // Ask the user through the resolver if the content differs
+)
+
+// Suffixes for files that would take a name that's already used
+const (
+	CollisionCounter = "counter" // _2, _3, ... in the order the files come in (default)
+	CollisionHash    = "hash"    // _ + a short hash of the content, the same on every run (for rsync-style backups)
+)
+
+// collisionHashLength is the number of hex digits of a hash suffix
+const collisionHashLength = 8
+
+// FileConflict describes an existing file whose content differs from the downloaded one
+type FileConflict struct {
+	ID              string    `json:"id"`
+	TweetID         int64     `json:"tweet_id"`
+	Index           int       `json:"index"` // Index of the item in the request
+	Path            string    `json:"path"`
+	ExistingSize    int64     `json:"existing_size"`
+	ExistingModTime time.Time `json:"existing_mod_time"`
+	NewSize         int64     `json:"new_size"`
+}
+
+// ConflictDecision is the answer to a FileConflict
+type ConflictDecision struct {
+	Action     string `json:"action"`       // skip, overwrite, keep_both
+	ApplyToAll bool   `json:"apply_to_all"` // Use this action for the remaining conflicts of the batch
+}
+
+// ConflictResolver asks for a decision on a conflict, blocking until one is made
+// It should return a skip decision if ctx is cancelled
+type ConflictResolver func(ctx context.Context, conflict FileConflict) ConflictDecision
+
+// conflictHandler applies the conflict policy of a single download batch
+type conflictHandler struct {
+	policy    string
+	resolver  ConflictResolver
+	collision string     // Suffix style for keep_both
+	mu        sync.Mutex // Only one question at a time
+	applyAll  string     // Action chosen with "apply to all"
+	nextID    int64
+}
+
+// newConflictHandler returns a handler for the batch's policy
+func newConflictHandler(policy string, resolver ConflictResolver, collision string) *conflictHandler {
+	if policy == ConflictAsk && resolver == nil {
+		policy = ConflictSkip // Nobody to ask
+	}
+	return &conflictHandler{policy: policy, resolver: resolver, collision: collision}
+}
+
+// keepsExisting reports whether existing files are kept without downloading anything
+func (h *conflictHandler) keepsExisting() bool {
+	return h.policy == "" || h.policy == ConflictSkip
+}
+
+// decide returns the action for a conflict, asking the resolver if the policy is "ask"
+func (h *conflictHandler) decide(ctx context.Context, conflict FileConflict) string {
+	if h.policy != ConflictAsk {
+		return h.policy
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.applyAll != "" {
+		return h.applyAll
+	}
+	if ctx.Err() != nil {
+		return ConflictSkip
+	}
+
+	conflict.ID = fmt.Sprintf("%d", atomic.AddInt64(&h.nextID, 1))
+	decision := h.resolver(ctx, conflict)
+	switch decision.Action {
+	case ConflictOverwrite, ConflictKeepBoth:
+	default:
+		decision.Action = ConflictSkip
+	}
+	if decision.ApplyToAll {
+		h.applyAll = decision.Action
+	}
+	return decision.Action
+}
+
+// resolve downloads an item whose target file already exists and applies the policy
+// Identical content is never a conflict. Returns the path the new file was saved to,
+// or "" if the existing file was kept
+func (h *conflictHandler) resolve(ctx context.Context, client *http.Client, store Storage, task downloadTask) (string, error) {
+	tmpPath := task.outputPath + ".part"
+	if task.item.Type == "text" {
+		if err := writeStorageFile(store, tmpPath, strings.NewReader(tweetText(task.item))); err != nil {
+			return "", err
+		}
+	} else if modified, err := downloadIfModified(ctx, client, store, task.item.URL, tmpPath, task.outputPath); err != nil {
+		store.Remove(tmpPath)
+		return "", err
+	} else if !modified {
+		// Unchanged on the server since it was downloaded, see conditional.go
+		return "", nil
+	}
+
+	existing, err := store.Stat(task.outputPath)
+	if err != nil {
+		// Removed in the meantime - no conflict anymore
+		return task.outputPath, store.Rename(tmpPath, task.outputPath)
+	}
+	downloaded, err := store.Stat(tmpPath)
+	if err != nil {
+		return "", err
+	}
+
+	if existing.Size() == downloaded.Size() {
+		existingHash, err1 := storageSHA256(store, task.outputPath)
+		newHash, err2 := storageSHA256(store, tmpPath)
+		if err1 == nil && err2 == nil && existingHash == newHash {
+			store.Remove(tmpPath)
+			return "", nil
+		}
+	}
+
+	action := h.decide(ctx, FileConflict{
+		TweetID:         task.item.TweetID,
+		Index:           task.index,
+		Path:            task.outputPath,
+		ExistingSize:    existing.Size(),
+		ExistingModTime: existing.ModTime(),
+		NewSize:         downloaded.Size(),
+	})
+
+	switch action {
+	case ConflictOverwrite:
+		if err := store.Rename(tmpPath, task.outputPath); err != nil {
+			store.Remove(tmpPath)
+			return "", err
+		}
+		return task.outputPath, nil
+	case ConflictKeepBoth:
+		path := h.keepBothPath(store, task.outputPath, tmpPath)
+		if path == "" {
+			// Kept on an earlier run under its hash name
+			store.Remove(tmpPath)
+			return "", nil
+		}
+		if err := store.Rename(tmpPath, path); err != nil {
+			store.Remove(tmpPath)
+			return "", err
+		}
+		return path, nil
+	default:
+		store.Remove(tmpPath)
+		return "", nil
+	}
+}
+
+// keepBothPath returns where to save a new file next to the existing one at path
+// With hash suffixes, "" means a file with the same content is already saved there
+func (h *conflictHandler) keepBothPath(store Storage, path, tmpPath string) string {
+	if h.collision != CollisionHash {
+		return freeStoragePath(store, path)
+	}
+	hash, err := storageSHA256(store, tmpPath)
+	if err != nil {
+		return freeStoragePath(store, path)
+	}
+	candidate := hashSuffixPath(path, hash)
+	if _, err := store.Stat(candidate); err != nil {
+		return candidate
+	}
+	if existing, err := storageSHA256(store, candidate); err == nil && existing == hash {
+		return ""
+	}
+	// Different content with the same short hash, practically never happens
+	return freeStoragePath(store, candidate)
+}
+
+// hashSuffixPath inserts _ and the first digits of a hex hash before the extension
+func hashSuffixPath(path, hash string) string {
+	if len(hash) > collisionHashLength {
+		hash = hash[:collisionHashLength]
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_" + hash + ext
+}
+
+// freeFilePath appends _2, _3, ... before the extension until no file exists at the path
+func freeFilePath(path string) string {
+	return freeStoragePath(LocalStorage{}, path)
+}
@@ -0,0 +1,201 @@
+package backend
+
+import (
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Content lock
+//
+// A passphrase lock for shared machines: while locked, accounts flagged sensitive are hidden
+// from the account queries and their timelines can't be fetched. The checks live in the
+// backend query and fetch functions, so they can't be bypassed by calling the API directly.
+// The app starts locked whenever a passphrase is set.
+
+const (
+	lockIterations = 600000
+	lockKeyLength  = 32
+)
+
+// ErrContentLocked is returned when a sensitive account is accessed while the app is locked
+var ErrContentLocked = errors.New("content_locked: Unlock the app to access this account")
+
+// lockConfig is the stored passphrase hash
+type lockConfig struct {
+	Salt       string `json:"salt"`
+	Hash       string `json:"hash"`
+	Iterations int    `json:"iterations"`
+}
+
+// LockStatus describes the content lock state
+type LockStatus struct {
+	Enabled bool `json:"enabled"` // A passphrase is set
+	Locked  bool `json:"locked"`  // Sensitive accounts are currently hidden
+}
+
+var contentLock struct {
+	sync.Mutex
+	loaded   bool
+	config   *lockConfig
+	unlocked bool
+}
+
+// getLockPath returns the path of the passphrase file
+func getLockPath() string {
+	return filepath.Join(GetAppDataDir(), "lock.json")
+}
+
+// loadLock reads the passphrase file once (contentLock must be held)
+func loadLock() {
+	if contentLock.loaded {
+		return
+	}
+	contentLock.loaded = true
+	data, err := os.ReadFile(getLockPath())
+	if err != nil {
+		return
+	}
+	var cfg lockConfig
+	if err := json.Unmarshal(data, &cfg); err != nil || cfg.Hash == "" {
+		return
+	}
+	contentLock.config = &cfg
+}
+
+// GetLockStatus returns whether a passphrase is set and whether the app is locked
+func GetLockStatus() LockStatus {
+	contentLock.Lock()
+	defer contentLock.Unlock()
+	loadLock()
+	enabled := contentLock.config != nil
+	return LockStatus{Enabled: enabled, Locked: enabled && !contentLock.unlocked}
+}
+
+// IsLocked reports whether sensitive accounts are currently hidden
+func IsLocked() bool {
+	return GetLockStatus().Locked
+}
+
+// Unlock unlocks the app with the passphrase
+func Unlock(passphrase string) error {
+	if err := unlockContent(passphrase); err != nil {
+		return err
+	}
+	// The passphrase also unwraps the key of encrypted archives, see encryption.go
+	return unlockEncryption(passphrase)
+}
+
+// unlockContent checks the passphrase and shows sensitive accounts again
+func unlockContent(passphrase string) error {
+	contentLock.Lock()
+	defer contentLock.Unlock()
+	loadLock()
+	if contentLock.config == nil {
+		return nil
+	}
+	if !checkPassphrase(contentLock.config, passphrase) {
+		return fmt.Errorf("wrong passphrase")
+	}
+	contentLock.unlocked = true
+	return nil
+}
+
+// Lock locks the app again (no-op without a passphrase)
+func Lock() {
+	contentLock.Lock()
+	contentLock.unlocked = false
+	contentLock.Unlock()
+	lockEncryption()
+}
+
+// verifyLockPassphrase reports whether passphrase is the lock passphrase
+func verifyLockPassphrase(passphrase string) bool {
+	contentLock.Lock()
+	defer contentLock.Unlock()
+	loadLock()
+	return contentLock.config != nil && checkPassphrase(contentLock.config, passphrase)
+}
+
+// SetLockPassphrase sets, changes or (with an empty passphrase) removes the lock passphrase
+// current must be the existing passphrase if one is set
+func SetLockPassphrase(current, passphrase string) error {
+	contentLock.Lock()
+	defer contentLock.Unlock()
+	loadLock()
+	if contentLock.config != nil && !checkPassphrase(contentLock.config, current) {
+		return fmt.Errorf("wrong passphrase")
+	}
+	if err := rewrapEncryptionKey(current, passphrase); err != nil {
+		return err
+	}
+
+	if passphrase == "" {
+		if err := os.Remove(getLockPath()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove passphrase: %v", err)
+		}
+		contentLock.config = nil
+		contentLock.unlocked = false
+		return nil
+	}
+
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return fmt.Errorf("failed to generate salt: %v", err)
+	}
+	key, err := pbkdf2.Key(sha256.New, passphrase, salt, lockIterations, lockKeyLength)
+	if err != nil {
+		return fmt.Errorf("failed to hash passphrase: %v", err)
+	}
+	cfg := &lockConfig{Salt: hex.EncodeToString(salt), Hash: hex.EncodeToString(key), Iterations: lockIterations}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(GetAppDataDir(), 0755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(getLockPath(), data, 0600); err != nil {
+		return fmt.Errorf("failed to save passphrase: %v", err)
+	}
+	contentLock.config = cfg
+	contentLock.unlocked = true // Whoever just set it doesn't need to unlock
+	return nil
+}
+
+// checkPassphrase compares a passphrase with the stored hash
+func checkPassphrase(cfg *lockConfig, passphrase string) bool {
+	salt, err := hex.DecodeString(cfg.Salt)
+	if err != nil {
+		return false
+	}
+	want, err := hex.DecodeString(cfg.Hash)
+	if err != nil {
+		return false
+	}
+	got, err := pbkdf2.Key(sha256.New, passphrase, salt, cfg.Iterations, len(want))
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare(got, want) == 1
+}
+
+// checkFetchAllowed returns ErrContentLocked if the account is flagged sensitive and the app is locked
+func checkFetchAllowed(username string) error {
+	if !IsLocked() {
+		return nil
+	}
+	if IsUsernameSensitive(username) {
+		return ErrContentLocked
+	}
+	return nil
+}
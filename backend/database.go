@@ -23,6 +23,7 @@ type AccountDB struct {
 	MediaType    string    `json:"media_type"`
 	Cursor       string    `json:"cursor"`
 	Completed    bool      `json:"completed"`
+	Sensitive    bool      `json:"sensitive"`
 }
 
 // AccountListItem represents a simplified account for listing
@@ -40,17 +41,16 @@ type AccountListItem struct {
 	Completed      bool   `json:"completed"`
 	FollowersCount int    `json:"followers_count"`
 	StatusesCount  int    `json:"statuses_count"`
+	Sensitive      bool   `json:"sensitive"`    // Hidden while the content lock is locked
+	ArchivePath    string `json:"archive_path"` // Folder the account was last downloaded to, "" if unknown
+	UserID         string `json:"user_id"`      // Numeric user ID, "" until fetched with this version
 }
 
 var db *sql.DB
 
 // GetDBPath returns the database file path
 func GetDBPath() string {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		homeDir = "."
-	}
-	return filepath.Join(homeDir, ".twitterxmediabatchdownloader", "accounts.db")
+	return filepath.Join(GetAppDataDir(), "accounts.db")
 }
 
 // InitDB initializes the database connection
@@ -97,11 +97,176 @@ func InitDB() error {
 	db.Exec("ALTER TABLE accounts ADD COLUMN media_type TEXT DEFAULT 'all'")
 	db.Exec("ALTER TABLE accounts ADD COLUMN cursor TEXT DEFAULT ''")
 	db.Exec("ALTER TABLE accounts ADD COLUMN completed INTEGER DEFAULT 1")
+	db.Exec("ALTER TABLE accounts ADD COLUMN sensitive INTEGER DEFAULT 0")
+	db.Exec("ALTER TABLE accounts ADD COLUMN archive_path TEXT DEFAULT ''")
+	db.Exec("ALTER TABLE accounts ADD COLUMN user_id TEXT DEFAULT ''")
 
 	// Migration: Update unique constraint for existing databases
 	// This allows same username with different media types
 	db.Exec("CREATE UNIQUE INDEX IF NOT EXISTS idx_username_media_type ON accounts(username, media_type)")
 
+	// Probed media files, see ProbeMedia
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS media_probes (
+			path TEXT PRIMARY KEY,
+			size INTEGER NOT NULL,
+			mod_time INTEGER NOT NULL,
+			format TEXT DEFAULT '',
+			duration REAL DEFAULT 0,
+			video_codec TEXT DEFAULT '',
+			audio_codec TEXT DEFAULT '',
+			width INTEGER DEFAULT 0,
+			height INTEGER DEFAULT 0,
+			corrupt INTEGER DEFAULT 0,
+			problem TEXT DEFAULT '',
+			probed_at TEXT DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Media recovered from the Wayback Machine, see wayback.go
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS recovered_media (
+			media_url TEXT PRIMARY KEY,
+			tweet_id TEXT NOT NULL,
+			username TEXT DEFAULT '',
+			path TEXT DEFAULT '',
+			snapshot_url TEXT DEFAULT '',
+			recovered_at TEXT DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// ETag and Last-Modified of downloaded media, see conditional.go
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS media_validators (
+			url TEXT PRIMARY KEY,
+			etag TEXT DEFAULT '',
+			last_modified TEXT DEFAULT '',
+			updated_at TEXT DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Fetch and download runs, see history.go
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS job_history (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			kind TEXT NOT NULL,
+			username TEXT DEFAULT '',
+			started_at TEXT NOT NULL,
+			duration_ms INTEGER DEFAULT 0,
+			items INTEGER DEFAULT 0,
+			bytes INTEGER DEFAULT 0,
+			failed INTEGER DEFAULT 0,
+			error TEXT DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_job_history_started_at ON job_history(started_at)")
+
+	// Last check of every watched account, see accountwatch.go
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS account_watch (
+			username TEXT PRIMARY KEY,
+			status TEXT NOT NULL,
+			nick TEXT DEFAULT '',
+			statuses_count INTEGER DEFAULT 0,
+			media_count INTEGER DEFAULT 0,
+			checked_at TEXT DEFAULT '',
+			changed_at TEXT DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Username changes of saved accounts, see renames.go
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS account_renames (
+			id INTEGER PRIMARY KEY AUTOINCREMENT,
+			user_id TEXT NOT NULL,
+			old_username TEXT NOT NULL,
+			new_username TEXT NOT NULL,
+			archive_path TEXT DEFAULT '',
+			renamed_files INTEGER DEFAULT 0,
+			renamed_at TEXT NOT NULL
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Stars and tags of library media, see tags.go
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS media_labels (
+			media_key TEXT PRIMARY KEY,
+			username TEXT NOT NULL,
+			author TEXT DEFAULT '',
+			tweet_id TEXT NOT NULL,
+			url TEXT NOT NULL,
+			starred INTEGER DEFAULT 0,
+			updated_at TEXT DEFAULT ''
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_media_labels_username ON media_labels(username)")
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS media_tags (
+			media_key TEXT NOT NULL,
+			tag TEXT NOT NULL,
+			PRIMARY KEY (media_key, tag)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	db.Exec("CREATE INDEX IF NOT EXISTS idx_media_tags_tag ON media_tags(tag)")
+
+	// Saved searches and the media they matched, see collections.go
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS collections (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			query_json TEXT NOT NULL,
+			auto_export TEXT DEFAULT '',
+			auto_download INTEGER DEFAULT 0,
+			download_dir TEXT DEFAULT '',
+			created_at TEXT NOT NULL,
+			last_run TEXT DEFAULT '',
+			last_matches INTEGER DEFAULT 0
+		)
+	`)
+	if err != nil {
+		return err
+	}
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS collection_matches (
+			collection_id TEXT NOT NULL,
+			media_key TEXT NOT NULL,
+			PRIMARY KEY (collection_id, media_key)
+		)
+	`)
+	if err != nil {
+		return err
+	}
+
+	// Record the schema version, older versions ignore what they don't know
+	var version int
+	if db.QueryRow("PRAGMA user_version").Scan(&version) == nil && version < dbSchemaVersion {
+		db.Exec(fmt.Sprintf("PRAGMA user_version = %d", dbSchemaVersion))
+	}
+
 	return nil
 }
 
@@ -109,6 +274,7 @@ func InitDB() error {
 func CloseDB() {
 	if db != nil {
 		db.Close()
+		db = nil
 	}
 }
 
@@ -135,9 +301,23 @@ func SaveAccountWithStatus(username, name, profileImage string, totalMedia int,
 		completedInt = 1
 	}
 
-	_, err := db.Exec(`
-		INSERT INTO accounts (username, name, profile_image, total_media, last_fetched, response_json, media_type, cursor, completed)
-		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	// An account saved before under another name was renamed, continue its archive
+	userID := responseUserID(responseJSON)
+	if userID != "" {
+		if _, err := followRename(userID, username); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}
+
+	// Timelines of sensitive accounts are encrypted while encryption is on
+	storedJSON, err := sealResponseJSON(username, responseJSON)
+	if err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`
+		INSERT INTO accounts (username, name, profile_image, total_media, last_fetched, response_json, media_type, cursor, completed, user_id)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(username, media_type) DO UPDATE SET
 			name = excluded.name,
 			profile_image = excluded.profile_image,
@@ -145,8 +325,9 @@ func SaveAccountWithStatus(username, name, profileImage string, totalMedia int,
 			last_fetched = excluded.last_fetched,
 			response_json = excluded.response_json,
 			cursor = excluded.cursor,
-			completed = excluded.completed
-	`, username, name, profileImage, totalMedia, time.Now(), responseJSON, mediaType, cursor, completedInt)
+			completed = excluded.completed,
+			user_id = CASE WHEN excluded.user_id != '' THEN excluded.user_id ELSE accounts.user_id END
+	`, username, name, profileImage, totalMedia, time.Now(), storedJSON, mediaType, cursor, completedInt, userID)
 
 	return err
 }
@@ -164,10 +345,12 @@ func GetAllAccounts() ([]AccountListItem, error) {
 		       COALESCE(group_name, '') as group_name, COALESCE(group_color, '') as group_color,
 		       COALESCE(media_type, 'all') as media_type,
 		       COALESCE(cursor, '') as cursor, COALESCE(completed, 1) as completed,
-		       COALESCE(response_json, '') as response_json
+		       COALESCE(response_json, '') as response_json, COALESCE(sensitive, 0) as sensitive,
+		       COALESCE(archive_path, '') as archive_path, COALESCE(user_id, '') as user_id
 		FROM accounts
+		WHERE ? = 0 OR COALESCE(sensitive, 0) = 0
 		ORDER BY group_name ASC, last_fetched DESC
-	`)
+	`, boolToInt(IsLocked()))
 	if err != nil {
 		return nil, err
 	}
@@ -179,14 +362,16 @@ func GetAllAccounts() ([]AccountListItem, error) {
 		var lastFetched time.Time
 		var completedInt int
 		var responseJSON string
-		if err := rows.Scan(&acc.ID, &acc.Username, &acc.Name, &acc.ProfileImage, &acc.TotalMedia, &lastFetched, &acc.GroupName, &acc.GroupColor, &acc.MediaType, &acc.Cursor, &completedInt, &responseJSON); err != nil {
+		var sensitiveInt int
+		if err := rows.Scan(&acc.ID, &acc.Username, &acc.Name, &acc.ProfileImage, &acc.TotalMedia, &lastFetched, &acc.GroupName, &acc.GroupColor, &acc.MediaType, &acc.Cursor, &completedInt, &responseJSON, &sensitiveInt, &acc.ArchivePath, &acc.UserID); err != nil {
 			continue
 		}
 		acc.LastFetched = lastFetched.Format("2006-01-02 15:04")
 		acc.Completed = completedInt == 1
+		acc.Sensitive = sensitiveInt == 1
 
 		// Extract followers_count and statuses_count from response_json
-		if responseJSON != "" {
+		if responseJSON, _ = openResponseJSON(responseJSON); responseJSON != "" {
 			var parsed map[string]interface{}
 			if err := json.Unmarshal([]byte(responseJSON), &parsed); err == nil {
 				if accountInfo, ok := parsed["account_info"].(map[string]interface{}); ok {
@@ -257,7 +442,8 @@ func ClearAllAccounts() error {
 		}
 	}
 
-	_, err := db.Exec("DELETE FROM accounts")
+	// Sensitive accounts are invisible while locked, so they're not part of "all"
+	_, err := db.Exec("DELETE FROM accounts WHERE ? = 0 OR COALESCE(sensitive, 0) = 0", boolToInt(IsLocked()))
 	return err
 }
 
@@ -271,18 +457,26 @@ func GetAccountByID(id int64) (*AccountDB, error) {
 
 	var acc AccountDB
 	var lastFetched time.Time
-	var completedInt int
+	var completedInt, sensitiveInt int
 	err := db.QueryRow(`
 		SELECT id, username, name, profile_image, total_media, last_fetched, response_json,
-		       COALESCE(cursor, '') as cursor, COALESCE(completed, 1) as completed
+		       COALESCE(cursor, '') as cursor, COALESCE(completed, 1) as completed,
+		       COALESCE(sensitive, 0) as sensitive
 		FROM accounts WHERE id = ?
-	`, id).Scan(&acc.ID, &acc.Username, &acc.Name, &acc.ProfileImage, &acc.TotalMedia, &lastFetched, &acc.ResponseJSON, &acc.Cursor, &completedInt)
+	`, id).Scan(&acc.ID, &acc.Username, &acc.Name, &acc.ProfileImage, &acc.TotalMedia, &lastFetched, &acc.ResponseJSON, &acc.Cursor, &completedInt, &sensitiveInt)
 
 	if err != nil {
 		return nil, err
 	}
 	acc.LastFetched = lastFetched
 	acc.Completed = completedInt == 1
+	acc.Sensitive = sensitiveInt == 1
+	if acc.Sensitive && IsLocked() {
+		return nil, ErrContentLocked
+	}
+	if acc.ResponseJSON, err = openResponseJSON(acc.ResponseJSON); err != nil {
+		return nil, err
+	}
 
 	// Convert legacy format if needed
 	if converted, err := ConvertLegacyToNewFormat(acc.ResponseJSON); err == nil {
@@ -300,10 +494,65 @@ func DeleteAccount(id int64) error {
 		}
 	}
 
-	_, err := db.Exec("DELETE FROM accounts WHERE id = ?", id)
+	_, err := db.Exec("DELETE FROM accounts WHERE id = ? AND (? = 0 OR COALESCE(sensitive, 0) = 0)", id, boolToInt(IsLocked()))
+	return err
+}
+
+// SetAccountSensitive flags or unflags an account as sensitive
+// Unflagging requires the app to be unlocked
+func SetAccountSensitive(id int64, sensitive bool) error {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return err
+		}
+	}
+	if !sensitive && IsLocked() {
+		return ErrContentLocked
+	}
+
+	if _, err := db.Exec("UPDATE accounts SET sensitive = ? WHERE id = ?", boolToInt(sensitive), id); err != nil {
+		return err
+	}
+	// While locked the timeline is encrypted on the next unlock
+	if GetEncryptionStatus().Unlocked {
+		return syncEncryptedRows(false)
+	}
+	return nil
+}
+
+// IsUsernameSensitive reports whether any saved account with this username is flagged sensitive
+func IsUsernameSensitive(username string) bool {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return false
+		}
+	}
+
+	var count int
+	err := db.QueryRow("SELECT COUNT(*) FROM accounts WHERE LOWER(username) = LOWER(?) AND COALESCE(sensitive, 0) = 1", username).Scan(&count)
+	return err == nil && count > 0
+}
+
+// SetArchivePath records the folder an account's media was downloaded to
+func SetArchivePath(username, path string) error {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return err
+		}
+	}
+
+	_, err := db.Exec("UPDATE accounts SET archive_path = ? WHERE LOWER(username) = LOWER(?)", path, username)
 	return err
 }
 
+// boolToInt converts a bool to a SQLite integer
+func boolToInt(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
 // LegacyMediaEntry represents media entry in old format
 type LegacyMediaEntry struct {
 	TweetID string `json:"tweet_id"`
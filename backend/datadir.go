@@ -0,0 +1,297 @@
+package backend
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Data folder
+//
+// Tools, the database, queues and caches live in ~/.twitterxmediabatchdownloader by default. On
+// machines with a small system drive they can be kept elsewhere: the TXMBD_DATA_DIR environment
+// variable wins, otherwise a folder chosen in the settings is remembered in datadir.txt in the
+// default folder (it has to be known before the database is opened, so it can't live in it).
+// SetAppDataDir moves the existing contents along - a rename on the same disk, a verified copy
+// otherwise - and only switches over once everything arrived.
+//
+// Portable mode (a portable.txt file next to the executable, or the --portable flag) keeps
+// everything in a data folder in the application directory instead (on Windows also the webview
+// profile that holds the settings), so the app can run from a USB stick without leaving anything
+// in the user profile. Downloads default to a downloads folder there too.
+
+// dataDirEnv overrides the data folder
+const dataDirEnv = "TXMBD_DATA_DIR"
+
+// dataDirPointerFile holds the folder chosen in the settings, in the default data folder
+const dataDirPointerFile = "datadir.txt"
+
+// Portable mode switches, next to the executable or on the command line
+const (
+	portableMarkerFile = "portable.txt"
+	portableFlag       = "--portable"
+)
+
+// Where the data folder location comes from
+const (
+	DataDirDefault  = "default"
+	DataDirSetting  = "setting"
+	DataDirEnv      = "env"
+	DataDirPortable = "portable"
+)
+
+// DataDirInfo describes the data folder in use
+type DataDirInfo struct {
+	Path    string `json:"path"`
+	Default string `json:"default"`
+	Source  string `json:"source"` // default, setting, env or portable
+}
+
+var (
+	dataDirMu       sync.Mutex
+	dataDirLoaded   bool
+	dataDirOverride string // Folder from the pointer file, "" for the default
+
+	portableOnce sync.Once
+	portableRoot string // Application directory in portable mode, "" otherwise
+)
+
+// appDir returns the folder holding the executable, or the .app bundle on macOS
+func appDir() string {
+	exe, err := os.Executable()
+	if err != nil {
+		return ""
+	}
+	if resolved, err := filepath.EvalSymlinks(exe); err == nil {
+		exe = resolved
+	}
+	dir := filepath.Dir(exe)
+	if bundle := strings.Index(dir, ".app"+string(filepath.Separator)+"Contents"); bundle >= 0 {
+		dir = filepath.Dir(dir[:bundle+len(".app")])
+	}
+	return dir
+}
+
+// portableAppDir returns the application directory if the app runs in portable mode, "" otherwise
+func portableAppDir() string {
+	portableOnce.Do(func() {
+		dir := appDir()
+		if dir == "" {
+			return
+		}
+		for _, arg := range os.Args[1:] {
+			if arg == portableFlag {
+				portableRoot = dir
+				return
+			}
+		}
+		if _, err := os.Stat(filepath.Join(dir, portableMarkerFile)); err == nil {
+			portableRoot = dir
+		}
+	})
+	return portableRoot
+}
+
+// IsPortable reports whether the app keeps its data in the application directory
+func IsPortable() bool {
+	return portableAppDir() != ""
+}
+
+// WebviewDataDir returns the folder for the webview profile in portable mode, "" to use the system default
+func WebviewDataDir() string {
+	if !IsPortable() {
+		return ""
+	}
+	return filepath.Join(GetAppDataDir(), "webview")
+}
+
+// defaultAppDataDir returns ~/.twitterxmediabatchdownloader
+func defaultAppDataDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+	return filepath.Join(homeDir, ".twitterxmediabatchdownloader")
+}
+
+// GetAppDataDir returns the directory holding tools, database and app state
+func GetAppDataDir() string {
+	return GetDataDirInfo().Path
+}
+
+// GetDataDirInfo returns the data folder in use and where its location comes from
+func GetDataDirInfo() DataDirInfo {
+	info := DataDirInfo{Default: defaultAppDataDir(), Source: DataDirDefault}
+	if env := strings.TrimSpace(os.Getenv(dataDirEnv)); env != "" {
+		info.Path = env
+		info.Source = DataDirEnv
+		return info
+	}
+	if root := portableAppDir(); root != "" {
+		info.Path = filepath.Join(root, "data")
+		info.Source = DataDirPortable
+		return info
+	}
+
+	dataDirMu.Lock()
+	if !dataDirLoaded {
+		if data, err := os.ReadFile(filepath.Join(info.Default, dataDirPointerFile)); err == nil {
+			dataDirOverride = strings.TrimSpace(string(data))
+		}
+		dataDirLoaded = true
+	}
+	override := dataDirOverride
+	dataDirMu.Unlock()
+
+	if override != "" {
+		info.Path = override
+		info.Source = DataDirSetting
+		return info
+	}
+	info.Path = info.Default
+	return info
+}
+
+// SetAppDataDir moves the data folder to dir ("" for the default) and remembers the new location
+// Nothing may be using the database or the tools while this runs
+func SetAppDataDir(dir string) (DataDirInfo, error) {
+	current := GetDataDirInfo()
+	if current.Source == DataDirEnv {
+		return current, fmt.Errorf("the data folder is set by the %s environment variable", dataDirEnv)
+	}
+	if current.Source == DataDirPortable {
+		return current, fmt.Errorf("the data folder can't be moved in portable mode")
+	}
+
+	target := current.Default
+	if strings.TrimSpace(dir) != "" {
+		abs, err := filepath.Abs(strings.TrimSpace(dir))
+		if err != nil {
+			return current, fmt.Errorf("invalid folder: %v", err)
+		}
+		target = abs
+	}
+	if samePath(target, current.Path) {
+		return current, nil
+	}
+	if isWithin(target, current.Path) || isWithin(current.Path, target) {
+		return current, fmt.Errorf("the data folder can't be moved into itself")
+	}
+	if err := os.MkdirAll(target, 0755); err != nil {
+		return current, fmt.Errorf("failed to create %s: %v", target, err)
+	}
+	if err := CheckOutputWritable(target); err != nil {
+		return current, err
+	}
+
+	// The database is reopened from the new location on next use
+	CloseDB()
+	if err := moveDataDir(current.Path, target, current.Default); err != nil {
+		return current, fmt.Errorf("failed to move the data folder (still using %s): %v", current.Path, err)
+	}
+
+	pointer := filepath.Join(current.Default, dataDirPointerFile)
+	override := target
+	if samePath(target, current.Default) {
+		override = ""
+		os.Remove(pointer)
+	} else {
+		if err := os.MkdirAll(current.Default, 0755); err != nil {
+			return current, fmt.Errorf("failed to save the data folder location: %v", err)
+		}
+		if err := os.WriteFile(pointer, []byte(target), 0644); err != nil {
+			return current, fmt.Errorf("failed to save the data folder location: %v", err)
+		}
+	}
+
+	dataDirMu.Lock()
+	dataDirOverride = override
+	dataDirLoaded = true
+	dataDirMu.Unlock()
+	return GetDataDirInfo(), nil
+}
+
+// moveDataDir moves the contents of src into dst, keeping the pointer file in the default folder
+func moveDataDir(src, dst, defaultDir string) error {
+	entries, err := os.ReadDir(src)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var renamed, copied []string
+	for _, entry := range entries {
+		if entry.Name() == dataDirPointerFile && samePath(src, defaultDir) {
+			continue
+		}
+		from := filepath.Join(src, entry.Name())
+		to := filepath.Join(dst, entry.Name())
+		if _, err := os.Lstat(to); os.IsNotExist(err) {
+			if err := os.Rename(from, to); err == nil {
+				renamed = append(renamed, entry.Name())
+				continue
+			}
+		}
+		// Another disk or already there: copy, verify, remove the source only at the end
+		if err := copyDataTree(from, to); err != nil {
+			// Put back what was renamed, the old folder stays complete
+			for _, name := range renamed {
+				os.Rename(filepath.Join(dst, name), filepath.Join(src, name))
+			}
+			return fmt.Errorf("%s: %v", entry.Name(), err)
+		}
+		copied = append(copied, from)
+	}
+
+	for _, path := range copied {
+		os.RemoveAll(path)
+	}
+	if !samePath(src, defaultDir) {
+		removeEmptyDirs(src)
+	}
+	return nil
+}
+
+// copyDataTree copies a file or folder with verified copies, keeping file permissions (tools are executables)
+func copyDataTree(src, dst string) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		if _, err := copyVerified(path, target); err != nil {
+			return err
+		}
+		return os.Chmod(target, info.Mode().Perm())
+	})
+}
+
+// samePath reports whether two paths name the same folder
+func samePath(a, b string) bool {
+	return filepath.Clean(a) == filepath.Clean(b)
+}
+
+// isWithin reports whether path is inside dir
+func isWithin(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
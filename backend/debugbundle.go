@@ -0,0 +1,212 @@
+package backend
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Debug bundle
+//
+// ExportDebugBundle zips what a bug report needs into one file in <app dir>/debug: the recent
+// log lines of the app, the diagnostics report, the frontend and backend settings, the recent
+// job history and the error output of the last extractor run. Settings that hold credentials
+// (auth tokens, passwords, secret keys, proxy logins) are redacted, and their values and the
+// stored auth tokens are also masked wherever else they appear in the bundle, so it can be
+// attached to a public issue.
+
+// maxExtractorStderr is how much of the last extractor error output is kept
+const maxExtractorStderr = 64 * 1024
+
+// debugHistoryLimit is the number of recent runs in a debug bundle
+const debugHistoryLimit = 50
+
+// redacted replaces secrets in a debug bundle
+const redacted = "[redacted]"
+
+// secretSettingPattern matches the names of settings that hold credentials
+var secretSettingPattern = regexp.MustCompile(`(?i)token|password|passphrase|secret|cookie|access_?key`)
+
+// DebugBundleRequest holds the parts of a debug bundle only the frontend has
+type DebugBundleRequest struct {
+	Logs      []string       `json:"logs"`       // Recent log lines
+	Settings  map[string]any `json:"settings"`   // Frontend settings, redacted before they're written
+	OutputDir string         `json:"output_dir"` // For the diagnostics report
+	Proxy     string         `json:"proxy"`
+}
+
+// lastExtractorRun is the last extractor run, for debug bundles
+var lastExtractorRun struct {
+	sync.Mutex
+	at     time.Time
+	args   []string
+	stderr string
+}
+
+// rememberExtractorRun keeps the arguments and error output of an extractor run
+func rememberExtractorRun(args []string, stderr string) {
+	safeArgs := append([]string(nil), args...)
+	for i := 0; i+1 < len(safeArgs); i++ {
+		if strings.HasPrefix(safeArgs[i], "--") && secretSettingPattern.MatchString(safeArgs[i]) {
+			safeArgs[i+1] = redacted
+		}
+	}
+	if len(stderr) > maxExtractorStderr {
+		stderr = stderr[len(stderr)-maxExtractorStderr:]
+	}
+	lastExtractorRun.Lock()
+	lastExtractorRun.at = time.Now()
+	lastExtractorRun.args = safeArgs
+	lastExtractorRun.stderr = stderr
+	lastExtractorRun.Unlock()
+}
+
+// GetDebugDir returns the directory debug bundles are saved to
+func GetDebugDir() string {
+	return filepath.Join(GetAppDataDir(), "debug")
+}
+
+// ExportDebugBundle writes a debug bundle and returns its path
+func ExportDebugBundle(req DebugBundleRequest) (string, error) {
+	var secrets []string
+	frontendSettings := redactSettings(req.Settings, &secrets)
+	backendSettings := GetSettings()
+	backendSettings.Proxy = redactProxy(backendSettings.Proxy, &secrets)
+	redactProxy(req.Proxy, &secrets)
+	secrets = append(secrets, storedSecrets()...) // Auth tokens, see keychain.go
+
+	lastExtractorRun.Lock()
+	extractor := fmt.Sprintf("Time: %s\nArgs: %s\n\n%s", formatDebugTime(lastExtractorRun.at), strings.Join(lastExtractorRun.args, " "), lastExtractorRun.stderr)
+	lastExtractorRun.Unlock()
+
+	history, err := GetJobHistory(debugHistoryLimit)
+	if err != nil {
+		history = []JobRecord{}
+	}
+
+	files := []struct {
+		name string
+		data any
+	}{
+		{"info.json", map[string]string{
+			"os":           runtime.GOOS,
+			"arch":         runtime.GOARCH,
+			"go":           runtime.Version(),
+			"app_data_dir": GetAppDataDir(),
+			"generated_at": time.Now().UTC().Format(time.RFC3339),
+		}},
+		{"diagnostics.json", Diagnostics(req.OutputDir, req.Proxy)},
+		{"settings.json", map[string]any{"frontend": frontendSettings, "backend": backendSettings}},
+		{"history.json", history},
+		{"logs.txt", strings.Join(req.Logs, "\n")},
+		{"extractor-stderr.txt", extractor},
+	}
+
+	// Longest first, so a secret containing another is masked whole
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	mask := func(text string) string {
+		for _, secret := range secrets {
+			if len(secret) < 4 {
+				continue // Too short to mask without garbling the bundle
+			}
+			text = strings.ReplaceAll(text, secret, redacted)
+		}
+		return text
+	}
+
+	if err := os.MkdirAll(GetDebugDir(), 0755); err != nil {
+		return "", fmt.Errorf("failed to create debug folder: %v", err)
+	}
+	path := filepath.Join(GetDebugDir(), "debug-"+time.Now().Format("20060102-150405")+".zip")
+	out, err := os.Create(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to create debug bundle: %v", err)
+	}
+	zw := zip.NewWriter(out)
+	for _, file := range files {
+		text, ok := file.data.(string)
+		if !ok {
+			data, err := json.MarshalIndent(file.data, "", "  ")
+			if err != nil {
+				continue
+			}
+			text = string(data)
+		}
+		w, err := zw.Create(file.name)
+		if err == nil {
+			_, err = w.Write([]byte(mask(text)))
+		}
+		if err != nil {
+			zw.Close()
+			out.Close()
+			os.Remove(path)
+			return "", fmt.Errorf("failed to write debug bundle: %v", err)
+		}
+	}
+	if err := zw.Close(); err != nil {
+		out.Close()
+		os.Remove(path)
+		return "", fmt.Errorf("failed to write debug bundle: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		return "", fmt.Errorf("failed to write debug bundle: %v", err)
+	}
+	return path, nil
+}
+
+// redactSettings returns a copy of the settings with credentials replaced, collecting their values
+func redactSettings(settings map[string]any, secrets *[]string) map[string]any {
+	result := make(map[string]any, len(settings))
+	for key, value := range settings {
+		switch v := value.(type) {
+		case map[string]any:
+			result[key] = redactSettings(v, secrets)
+		case string:
+			switch {
+			case v == "":
+				result[key] = v
+			case secretSettingPattern.MatchString(key):
+				*secrets = append(*secrets, v)
+				result[key] = redacted
+			case strings.Contains(strings.ToLower(key), "proxy"):
+				result[key] = redactProxy(v, secrets)
+			default:
+				result[key] = v
+			}
+		default:
+			result[key] = value
+		}
+	}
+	return result
+}
+
+// redactProxy removes the password from a proxy URL, collecting it
+func redactProxy(proxy string, secrets *[]string) string {
+	u, err := url.Parse(proxy)
+	if err != nil || u.User == nil {
+		return proxy
+	}
+	if password, ok := u.User.Password(); ok && password != "" {
+		*secrets = append(*secrets, password)
+		u.User = url.UserPassword(u.User.Username(), "xxxxx")
+		return strings.Replace(u.String(), "xxxxx", redacted, 1)
+	}
+	return proxy
+}
+
+// formatDebugTime formats a time for a debug bundle, "never" if it's zero
+func formatDebugTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return t.UTC().Format(time.RFC3339)
+}
@@ -0,0 +1,48 @@
+package backend
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Duplicate media
+//
+// The same media often shows up more than once in a response: a retweet next to the original
+// tweet, a quoted tweet, or a tweet repeated across pages of the timeline. Before a job starts,
+// items pointing at the same media file are collapsed so it's fetched only once; the first one
+// is kept, so the file is named after the first tweet it appeared under. The size variant of an
+// image URL (name=orig, name=large) and the tags of a video URL don't make it a different file.
+
+// mediaURLKey returns what identifies the media file behind a URL, "" if it can't be parsed
+func mediaURLKey(rawURL string) string {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return ""
+	}
+	key := strings.ToLower(u.Host) + u.Path
+	// Images of the same media in another format are another file
+	if format := u.Query().Get("format"); format != "" {
+		key += "." + strings.ToLower(format)
+	}
+	return key
+}
+
+// duplicateMedia tracks the media files already planned in a job
+type duplicateMedia map[string]bool
+
+// seen reports whether the media of an item was planned before, and marks it planned otherwise
+// Text items are never duplicates
+func (d duplicateMedia) seen(item MediaItem) bool {
+	if item.Type == "text" {
+		return false
+	}
+	key := mediaURLKey(item.URL)
+	if key == "" {
+		return false
+	}
+	if d[key] {
+		return true
+	}
+	d[key] = true
+	return false
+}
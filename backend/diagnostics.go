@@ -0,0 +1,273 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Diagnostics
+//
+// Most support issues come down to the environment: a missing or blocked tool, Perl missing for
+// exiftool, a full disk, a download folder the app can't write to, or twimg.com being blocked.
+// Diagnostics checks all of them at once and returns one structured report that can be copied
+// into an issue.
+
+// Diagnostic check statuses
+const (
+	DiagnosticOK      = "ok"
+	DiagnosticWarning = "warning" // Works, but something may go wrong
+	DiagnosticError   = "error"   // Broken, some features can't work
+)
+
+// lowDiskSpace is the free space below which the download folder gets a warning
+const lowDiskSpace = 1 << 30
+
+// DiagnosticCheck is the result of one environment check
+type DiagnosticCheck struct {
+	Name   string `json:"name"`           // extractor, ffmpeg, ffprobe, exiftool, perl, disk_space, write_access, state_compat, network
+	Status string `json:"status"`         // ok, warning, error
+	Detail string `json:"detail"`         // Version, path, size or what went wrong
+	Hint   string `json:"hint,omitempty"` // How to fix it
+}
+
+// DiagnosticsReport is the result of all environment checks
+type DiagnosticsReport struct {
+	OS          string            `json:"os"`
+	Arch        string            `json:"arch"`
+	AppDataDir  string            `json:"app_data_dir"`
+	OutputDir   string            `json:"output_dir"`
+	GeneratedAt string            `json:"generated_at"`
+	Checks      []DiagnosticCheck `json:"checks"`
+}
+
+// Diagnostics checks the tools, the download folder and network access to Twitter's media servers
+func Diagnostics(outputDir string, customProxy string) DiagnosticsReport {
+	if outputDir == "" {
+		outputDir = GetDefaultDownloadPath()
+	}
+	report := DiagnosticsReport{
+		OS:          runtime.GOOS,
+		Arch:        runtime.GOARCH,
+		AppDataDir:  GetAppDataDir(),
+		OutputDir:   outputDir,
+		GeneratedAt: time.Now().Format(time.RFC3339),
+	}
+
+	// The network checks take the longest, run them while the local checks run
+	var network []DiagnosticCheck
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		network = checkNetwork(customProxy)
+	}()
+
+	report.Checks = append(report.Checks,
+		checkExtractor(),
+		checkTool("ffmpeg", IsFFmpegInstalled(), ffmpegBinary(), "-version",
+			"Download FFmpeg in Settings, it's needed for GIF conversion and video previews"),
+		checkTool("ffprobe", IsFFprobeInstalled(), GetFFprobePath(), "-version",
+			"Download FFmpeg again to get FFprobe, it's needed to check downloads for corruption"),
+		checkTool("exiftool", IsExifToolInstalled(), findExifTool(), "-ver",
+			"Download ExifTool in Settings, it's needed to embed metadata"),
+		checkPerl(),
+		checkDiskSpace(outputDir),
+		checkWriteAccess(outputDir),
+		checkStateCompat(),
+	)
+	wg.Wait()
+	report.Checks = append(report.Checks, network...)
+	return report
+}
+
+// checkExtractor makes sure the embedded extractor can be unpacked into the app data folder
+func checkExtractor() DiagnosticCheck {
+	check := DiagnosticCheck{Name: "extractor"}
+	path, err := ensureExtractor()
+	if err != nil {
+		check.Status = DiagnosticError
+		check.Detail = err.Error()
+		check.Hint = "Make sure the app data folder is writable and not blocked by antivirus software"
+		return check
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		check.Status = DiagnosticError
+		check.Detail = fmt.Sprintf("%s was removed after it was unpacked: %v", path, err)
+		check.Hint = "Antivirus software may be quarantining the extractor, add an exception for the app data folder"
+		return check
+	}
+	check.Status = DiagnosticOK
+	check.Detail = fmt.Sprintf("%s (%s)", path, formatBytes(info.Size()))
+	return check
+}
+
+// checkTool reports whether an external tool runs and which version is used
+func checkTool(name string, installed bool, bundledPath, versionFlag, hint string) DiagnosticCheck {
+	check := DiagnosticCheck{Name: name}
+	if !installed {
+		check.Status = DiagnosticWarning
+		check.Detail = "not installed"
+		check.Hint = hint
+		return check
+	}
+	path := bundledPath
+	if systemPath, err := exec.LookPath(name); err == nil {
+		path = systemPath
+	}
+	check.Status = DiagnosticOK
+	check.Detail = strings.TrimSpace(fmt.Sprintf("%s %s", toolVersion(path, versionFlag), path))
+	return check
+}
+
+// checkPerl checks for Perl, which the Unix exiftool script needs (the Windows executable includes it)
+func checkPerl() DiagnosticCheck {
+	check := DiagnosticCheck{Name: "perl"}
+	if runtime.GOOS == "windows" {
+		check.Status = DiagnosticOK
+		check.Detail = "not needed (included in exiftool.exe)"
+		return check
+	}
+	path, err := exec.LookPath("perl")
+	if err != nil {
+		check.Status = DiagnosticWarning
+		check.Detail = "not found"
+		check.Hint = "Install Perl (e.g. with your package manager) to use the downloaded ExifTool"
+		return check
+	}
+	cmd := exec.Command(path, "-e", "print $^V")
+	hideWindow(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		check.Status = DiagnosticWarning
+		check.Detail = fmt.Sprintf("%s doesn't run: %v", path, err)
+		check.Hint = "Reinstall Perl"
+		return check
+	}
+	check.Status = DiagnosticOK
+	check.Detail = fmt.Sprintf("%s %s", strings.TrimSpace(string(output)), path)
+	return check
+}
+
+// checkDiskSpace reports the free space on the volume of the download folder
+func checkDiskSpace(outputDir string) DiagnosticCheck {
+	check := DiagnosticCheck{Name: "disk_space"}
+	free, err := freeDiskSpace(outputDir)
+	if err != nil {
+		check.Status = DiagnosticWarning
+		check.Detail = fmt.Sprintf("unknown: %v", err)
+		return check
+	}
+	check.Detail = fmt.Sprintf("%s free", formatBytes(free))
+	if free < lowDiskSpace {
+		check.Status = DiagnosticWarning
+		check.Hint = "Free up space or choose a download folder on another drive"
+		return check
+	}
+	check.Status = DiagnosticOK
+	return check
+}
+
+// checkWriteAccess makes sure files can be created in the download folder
+func checkWriteAccess(outputDir string) DiagnosticCheck {
+	check := DiagnosticCheck{Name: "write_access"}
+	err := CheckOutputWritable(outputDir)
+	if err == nil {
+		check.Status = DiagnosticOK
+		check.Detail = outputDir
+		return check
+	}
+	check.Status = DiagnosticError
+	check.Detail = err.Error()
+	if errors.Is(err, ErrFolderProtected) {
+		check.Hint = "Allow the app through Controlled Folder Access or enable the Downloads fallback"
+	} else {
+		check.Hint = "Choose a download folder you have write permission for"
+	}
+	return check
+}
+
+// diagnosticHosts are the media servers downloads come from
+var diagnosticHosts = []string{"https://pbs.twimg.com/", "https://video.twimg.com/"}
+
+// checkNetwork checks that Twitter's media servers can be reached (through the proxy, if set)
+func checkNetwork(customProxy string) []DiagnosticCheck {
+	client, err := CreateHTTPClient(customProxy, 10*time.Second)
+	if err != nil {
+		return []DiagnosticCheck{{Name: "network", Status: DiagnosticError, Detail: err.Error(), Hint: "Fix the proxy URL in Settings"}}
+	}
+
+	checks := make([]DiagnosticCheck, len(diagnosticHosts))
+	var wg sync.WaitGroup
+	for i, host := range diagnosticHosts {
+		wg.Add(1)
+		go func(i int, host string) {
+			defer wg.Done()
+			checks[i] = checkHost(client, host)
+		}(i, host)
+	}
+	wg.Wait()
+	return checks
+}
+
+// checkHost sends a HEAD request to a host; any HTTP response means it's reachable
+func checkHost(client *http.Client, url string) DiagnosticCheck {
+	check := DiagnosticCheck{Name: "network"}
+	host := strings.TrimSuffix(strings.TrimPrefix(url, "https://"), "/")
+
+	req, err := http.NewRequestWithContext(withAuditPurpose(context.Background(), AuditPurposeDiagnostics), http.MethodHead, url, nil)
+	if err != nil {
+		check.Status = DiagnosticError
+		check.Detail = err.Error()
+		return check
+	}
+	start := time.Now()
+	resp, err := client.Do(req)
+	if err != nil {
+		check.Status = DiagnosticError
+		check.Detail = fmt.Sprintf("%s unreachable: %v", host, err)
+		check.Hint = "Check your connection, firewall or proxy - media is downloaded from twimg.com"
+		return check
+	}
+	resp.Body.Close()
+	check.Status = DiagnosticOK
+	check.Detail = fmt.Sprintf("%s reachable (HTTP %d, %d ms)", host, resp.StatusCode, time.Since(start).Milliseconds())
+	return check
+}
+
+// existingParent returns path or its closest existing parent folder
+func existingParent(path string) string {
+	for {
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+		parent := filepath.Dir(path)
+		if parent == path {
+			return path
+		}
+		path = parent
+	}
+}
+
+// formatBytes formats a size for messages, e.g. "1.5 GB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,111 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Free space monitoring
+//
+// A job that fills the disk used to fail every remaining file, one write at a time. While a job
+// writes to the local filesystem, the free space (and on Linux the free inodes) of the output
+// volume is checked every few seconds and after every failed file. Once it drops below the
+// threshold, the job is paused like a stopped job: files that weren't saved stay in the queue,
+// so it can be resumed after making room.
+
+// ErrLowDiskSpace is wrapped by the error of jobs paused for lack of disk space
+var ErrLowDiskSpace = errors.New("disk_space_low")
+
+const (
+	diskCheckInterval = 5 * time.Second
+	diskFullReserve   = 16 << 20 // Always keep this much free, even without a threshold
+	minFreeInodes     = 1000
+)
+
+// DiskSpaceError is returned when a job was paused because the output volume is almost full
+type DiskSpaceError struct {
+	Dir        string
+	FreeBytes  int64
+	FreeInodes int64 // -1 if not reported
+}
+
+func (e *DiskSpaceError) Error() string {
+	free := formatBytes(e.FreeBytes) + " free"
+	if e.FreeInodes >= 0 && e.FreeInodes < minFreeInodes {
+		free = fmt.Sprintf("%d free inodes", e.FreeInodes)
+	}
+	return fmt.Sprintf("%v: only %s on the volume of %s - the download was paused, free up space and resume it from the queue",
+		ErrLowDiskSpace, free, e.Dir)
+}
+
+func (e *DiskSpaceError) Unwrap() error {
+	return ErrLowDiskSpace
+}
+
+// diskMonitor pauses a job when the output volume runs out of space
+type diskMonitor struct {
+	dir     string
+	minFree int64
+	pause   context.CancelCauseFunc
+	mu      sync.Mutex
+	paused  bool
+}
+
+// startDiskMonitor checks the output volume now and every few seconds until stop is called
+// minFree is the free space to keep in bytes, 0 = only keep a small reserve
+func startDiskMonitor(ctx context.Context, dir string, minFree int64, pause context.CancelCauseFunc) (*diskMonitor, func()) {
+	if minFree < diskFullReserve {
+		minFree = diskFullReserve
+	}
+	m := &diskMonitor{dir: dir, minFree: minFree, pause: pause}
+	m.check()
+
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(diskCheckInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				m.check()
+			case <-ctx.Done():
+				return
+			case <-done:
+				return
+			}
+		}
+	}()
+	return m, func() { close(done) }
+}
+
+// check pauses the job if the volume is below the thresholds; returns true if the job is paused
+func (m *diskMonitor) check() bool {
+	if m == nil {
+		return false
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.paused {
+		return true
+	}
+
+	free, err := freeDiskSpace(m.dir)
+	if err != nil {
+		// Unknown free space never pauses a job
+		return false
+	}
+	inodes, err := freeInodes(m.dir)
+	if err != nil {
+		inodes = -1
+	}
+	if free >= m.minFree && (inodes < 0 || inodes >= minFreeInodes) {
+		return false
+	}
+
+	m.paused = true
+	m.pause(&DiskSpaceError{Dir: m.dir, FreeBytes: free, FreeInodes: inodes})
+	return true
+}
@@ -0,0 +1,27 @@
+//go:build !windows
+
+package backend
+
+import "syscall"
+
+// freeDiskSpace returns the bytes available to the user on the volume of path
+func freeDiskSpace(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(existingParent(path), &stat); err != nil {
+		return 0, err
+	}
+	return int64(stat.Bavail) * int64(stat.Bsize), nil
+}
+
+// freeInodes returns the free inodes on the volume of path, -1 if the filesystem doesn't report them
+func freeInodes(path string) (int64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(existingParent(path), &stat); err != nil {
+		return 0, err
+	}
+	if stat.Files == 0 {
+		// btrfs and others allocate inodes dynamically
+		return -1, nil
+	}
+	return int64(stat.Ffree), nil
+}
@@ -0,0 +1,28 @@
+//go:build windows
+
+package backend
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+var procGetDiskFreeSpaceEx = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDiskFreeSpaceExW")
+
+// freeDiskSpace returns the bytes available to the user on the volume of path
+func freeDiskSpace(path string) (int64, error) {
+	dir, err := syscall.UTF16PtrFromString(existingParent(path))
+	if err != nil {
+		return 0, err
+	}
+	var available uint64
+	if r, _, err := procGetDiskFreeSpaceEx.Call(uintptr(unsafe.Pointer(dir)), uintptr(unsafe.Pointer(&available)), 0, 0); r == 0 {
+		return 0, err
+	}
+	return int64(available), nil
+}
+
+// freeInodes is always -1, NTFS has no fixed inode count
+func freeInodes(path string) (int64, error) {
+	return -1, nil
+}
@@ -2,12 +2,14 @@ package backend
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"sort"
 	"strings"
 	"sync"
 	"sync/atomic"
@@ -15,19 +17,104 @@ import (
 )
 
 const (
-	// MaxConcurrentDownloads is the number of parallel downloads
+	// MaxConcurrentDownloads is the default number of parallel downloads (see Settings)
 	MaxConcurrentDownloads = 10
+
+	// OrderNewestFirst downloads the most recent tweets first (tweet IDs are time-ordered)
+	OrderNewestFirst = "newest_first"
 )
 
 // MediaItem represents a media item with metadata for download
 type MediaItem struct {
-	URL             string `json:"url"`
-	Date            string `json:"date"`
-	TweetID         int64  `json:"tweet_id"`
-	Type            string `json:"type"`
-	Username        string `json:"username"`
-	Content         string `json:"content,omitempty"` // Tweet text content (for text-only tweets)
-	OriginalFilename string `json:"original_filename,omitempty"` // Original Twitter media filename (15 char alphanumeric)
+	URL              string     `json:"url"`
+	Date             string     `json:"date"`
+	TweetID          int64      `json:"tweet_id"`
+	Type             string     `json:"type"`
+	Username         string     `json:"username"`
+	Content          string     `json:"content,omitempty"`           // Tweet text content (for text-only tweets)
+	OriginalFilename string     `json:"original_filename,omitempty"` // Original Twitter media filename (15 char alphanumeric)
+	Width            int        `json:"width,omitempty"`
+	Height           int        `json:"height,omitempty"`
+	Engagement       int        `json:"engagement,omitempty"` // Likes + retweets, used to prune the least popular files first
+	FavoriteCount    int        `json:"favorite_count,omitempty"`
+	RetweetCount     int        `json:"retweet_count,omitempty"`
+	ReplyCount       int        `json:"reply_count,omitempty"`
+	ViewCount        int        `json:"view_count,omitempty"`
+	BookmarkCount    int        `json:"bookmark_count,omitempty"`
+	Card             *TweetCard `json:"card,omitempty"`        // Poll or link preview, written with the text of text tweets
+	MediaIndex       int        `json:"media_index,omitempty"` // 1-based position of the media in its tweet as posted, 0 = unknown
+}
+
+// DownloadOptions holds optional per-batch settings for the download manager
+type DownloadOptions struct {
+	Filter        MediaFilter `json:"filter"`         // Orientation/aspect ratio filter applied when building the queue
+	SanitizePaths bool        `json:"sanitize_paths"` // Apply preflight path fixes (length, characters, case collisions) automatically
+	QueueID       string      `json:"queue_id"`       // If set, pending items are persisted to this queue when the job is stopped
+	Conflict      string      `json:"conflict"`       // Policy for existing files with different content: skip (default), overwrite, keep_both, ask
+	Order         string      `json:"order"`          // Download order: "" (as requested) or newest_first
+	GraceMinutes  int         `json:"grace_minutes"`  // Defer tweets younger than this to the end of the job and re-resolve their URLs
+	VideoPreview  string      `json:"video_preview"`  // Save a poster or contact_sheet of each downloaded video in .thumbs ("" = off)
+	ConvertWebP   string      `json:"convert_webp"`   // Convert WebP images to jpg or png after download ("" = keep WebP)
+	WebPQuality   int         `json:"webp_quality"`   // JPEG quality (1-100) for converted WebP images, 0 = default
+	ValidateMedia bool        `json:"validate_media"` // Probe downloaded media with ffprobe and count corrupt files as failed
+	DateZone      string      `json:"date_zone"`      // Time zone of filename timestamps and embedded dates: "" (UTC, no embedded date), utc, local
+
+	// Download dead media links (404) from the Wayback Machine if it has a copy, see wayback.go
+	WaybackFallback bool `json:"wayback_fallback"`
+
+	// Pause the job when free space on the output volume drops below this many bytes, 0 = keep a small reserve
+	MinFreeBytes int64 `json:"min_free_bytes"`
+
+	// Download to the Downloads folder if Windows Controlled Folder Access blocks the output folder
+	ProtectedFallback bool `json:"protected_fallback"`
+
+	// Suffix for files that would take a used name: counter (default, _2, _3, ...) or hash (short content hash)
+	CollisionSuffix string `json:"collision_suffix"`
+
+	// Also write the tweets of the job's media to tweets.jsonl in each account folder
+	TweetsJSONL bool `json:"tweets_jsonl"`
+
+	// Also write each tweet of the job's media to a .txt file in the tweets folder of its account
+	TweetsTXT bool `json:"tweets_txt"`
+
+	// File name template without extension ("" = {username}_{timestamp}_{tweet_id}_{index}), see renderFilename
+	FilenameTemplate string `json:"filename_template"`
+
+	// Ask Confirm before downloading if the dry-run estimate of the job is above this size, 0 = never
+	ConfirmAboveBytes int64 `json:"confirm_above_bytes"`
+
+	// Stream files to this server instead of the local output folder (same relative layout)
+	SFTP *SFTPConfig `json:"sftp,omitempty"`
+
+	// Upload files to an S3-compatible bucket or a WebDAV server instead (same relative layout)
+	S3     *S3Config     `json:"s3,omitempty"`
+	WebDAV *WebDAVConfig `json:"webdav,omitempty"`
+
+	// Write one zip or tar archive per account instead of loose files ("" = loose files)
+	Archive string `json:"archive"`
+
+	// Shell commands run for every saved file and once when the job ends, see hooks.go
+	FileHook  string `json:"file_hook"`
+	BatchHook string `json:"batch_hook"`
+
+	// Optional cap on each account archive's total size: stop (default), prune_oldest, prune_engagement
+	MaxArchiveBytes  int64  `json:"max_archive_bytes"`
+	ArchiveCapPolicy string `json:"archive_cap_policy"`
+
+	// Skip files larger than MaxFileBytes and stop the job after saving MaxJobBytes, 0 = no limit
+	MaxFileBytes int64 `json:"max_file_bytes"`
+	MaxJobBytes  int64 `json:"max_job_bytes"`
+
+	// AuthToken is used to re-resolve media URLs (not persisted)
+	AuthToken string `json:"-"`
+	// Resolver is asked about conflicts when Conflict is "ask"
+	Resolver ConflictResolver `json:"-"`
+	// OnPrune is called for every file moved to the trash to keep an archive under its cap
+	OnPrune func(path string) `json:"-"`
+	// Confirm is asked about jobs above ConfirmAboveBytes
+	Confirm DownloadConfirmer `json:"-"`
+	// Storage receives the downloaded files, the local filesystem (or the remote target if set) if nil
+	Storage Storage `json:"-"`
 }
 
 // DownloadMediaFiles downloads media files from URLs to the output directory (legacy)
@@ -42,7 +129,8 @@ func DownloadMediaFiles(urls []string, outputDir string, customProxy string) (do
 	if err != nil {
 		// If proxy setup fails, use default client without proxy
 		client = &http.Client{
-			Timeout: 60 * time.Second,
+			Transport: audited(nil, ""),
+			Timeout:   60 * time.Second,
 		}
 	}
 
@@ -70,33 +158,41 @@ func DownloadMediaFiles(urls []string, outputDir string, customProxy string) (do
 type ProgressCallback func(current, total int)
 
 // ItemStatusCallback is a function type for per-item status updates
-type ItemStatusCallback func(tweetID int64, index int, status string) // status: "success", "failed", "skipped"
+type ItemStatusCallback func(tweetID int64, index int, status string) // status: "success", "failed", "skipped", "missing", "recovered"
 
 // downloadTask represents a single download task
 type downloadTask struct {
 	item       MediaItem
 	outputPath string
-	index      int
+	index      int // Index of the item in the request (for status events)
+	seq        int // Position in the task list (for tracking pending tasks)
+	mediaIndex int // 1-based position of the media in its tweet
+	recheck    bool
+	webp       bool // Downloaded as WebP, then converted to the format of outputPath
 }
 
-// DownloadMediaWithMetadataProgressAndStatus downloads media files with progress and per-item status callbacks
-// Returns: downloaded count, skipped count, failed count, error
-func DownloadMediaWithMetadataProgressAndStatus(items []MediaItem, outputDir string, username string, progress ProgressCallback, itemStatus ItemStatusCallback, ctx context.Context, customProxy string) (downloaded int, skipped int, failed int, err error) {
-	if ctx == nil {
-		ctx = context.Background()
-	}
-
-	total := len(items)
-	if total == 0 {
-		return 0, 0, 0, nil
-	}
-
-	// Prepare all tasks first (sequential to handle tweet media count)
+// planDownloadTasks computes the target path of every item without touching the filesystem
+// Returns the tasks and the number of items dropped by the media filter or as duplicates (see dedup.go)
+func planDownloadTasks(items []MediaItem, outputDir string, username string, opts DownloadOptions) ([]downloadTask, int) {
+	opts = applySettingsDefaults(opts)
 	// For bookmarks and likes, each item may have different username, so we track per username
 	tweetMediaCount := make(map[string]map[int64]int) // username -> tweet_id -> count
-	tasks := make([]downloadTask, 0, total)
+	tasks := make([]downloadTask, 0, len(items))
+	filtered := 0
+	duplicates := duplicateMedia{}
 
 	for i, item := range items {
+		// Drop items that don't pass the media filter (keep original index for status events)
+		if !opts.Filter.Match(item) {
+			filtered++
+			continue
+		}
+		// The same media under a retweet and its original is fetched once
+		if duplicates.seen(item) {
+			filtered++
+			continue
+		}
+
 		// Use item.Username if available (for bookmarks/likes with different authors), otherwise use provided username
 		itemUsername := item.Username
 		if itemUsername == "" {
@@ -108,66 +204,285 @@ func DownloadMediaWithMetadataProgressAndStatus(items []MediaItem, outputDir str
 			tweetMediaCount[itemUsername] = make(map[int64]int)
 		}
 
-		// Determine subfolder based on type
-		var subfolder string
-		switch item.Type {
-		case "photo":
-			subfolder = "images"
-		case "video":
-			subfolder = "videos"
-		case "gif", "animated_gif":
-			subfolder = "gifs"
-		case "text":
-			subfolder = "texts"
-		default:
-			subfolder = "other"
-		}
-
-		// Create base directory for this username
-		baseDir := filepath.Join(outputDir, itemUsername)
-		if err := os.MkdirAll(baseDir, 0755); err != nil {
-			continue
-		}
-
-		// Create type subfolder
-		typeDir := filepath.Join(baseDir, subfolder)
-		if err := os.MkdirAll(typeDir, 0755); err != nil {
-			continue
-		}
-
-		// Format timestamp from date
-		timestamp := formatTimestamp(item.Date)
+		// Type subfolder inside the username folder
+		typeDir := filepath.Join(outputDir, itemUsername, mediaSubfolder(item.Type))
 
 		// Get file extension
 		ext := getExtension(item.URL, item.Type)
+		webp := false
+		if opts.ConvertWebP != "" && strings.EqualFold(ext, ".webp") && opts.localOutput() {
+			// Plan the converted file so existing conversions are recognized
+			ext = "." + opts.ConvertWebP
+			webp = true
+		}
 
-		// Increment counter for this username and tweet_id
+		// Position of the media in the tweet, so the name doesn't depend on which media are in the
+		// batch; items from before media were numbered count in the order they come
 		tweetMediaCount[itemUsername][item.TweetID]++
-		mediaIndex := tweetMediaCount[itemUsername][item.TweetID]
+		mediaIndex := item.MediaIndex
+		if mediaIndex <= 0 {
+			mediaIndex = tweetMediaCount[itemUsername][item.TweetID]
+		}
 
-		// Create filename: {username}_{timestamp}_{tweet_id}_{index}.{ext}
-		filename := fmt.Sprintf("%s_%s_%d_%02d%s", itemUsername, timestamp, item.TweetID, mediaIndex, ext)
+		// Create filename from the template, by default {username}_{timestamp}_{tweet_id}_{index}.{ext}
+		filename := renderFilename(opts.FilenameTemplate, item, itemUsername, mediaIndex, opts.DateZone) + ext
 		outputPath := filepath.Join(typeDir, filename)
 
 		tasks = append(tasks, downloadTask{
 			item:       item,
 			outputPath: outputPath,
 			index:      i,
+			mediaIndex: mediaIndex,
+			webp:       webp,
 		})
 	}
 
+	// Rewrite problematic paths using the preflight fixes if requested
+	if opts.SanitizePaths {
+		applyPathFixes(tasks, outputDir, opts.CollisionSuffix)
+	}
+
+	if opts.Order == OrderNewestFirst {
+		// Newest tweets are the most likely to be deleted soon - get them first, then backfill
+		sort.SliceStable(tasks, func(i, j int) bool {
+			return tasks[i].item.TweetID > tasks[j].item.TweetID
+		})
+	}
+
+	return tasks, filtered
+}
+
+// mediaSubfolder returns the type subfolder for a media type
+func mediaSubfolder(mediaType string) string {
+	switch mediaType {
+	case "photo":
+		return "images"
+	case "video":
+		return "videos"
+	case "gif", "animated_gif":
+		return "gifs"
+	case "text":
+		return "texts"
+	default:
+		return "other"
+	}
+}
+
+// DownloadMediaWithMetadataProgressAndStatus downloads media files with progress and per-item status callbacks
+// Returns: downloaded count, skipped count, failed count, error
+func DownloadMediaWithMetadataProgressAndStatus(items []MediaItem, outputDir string, username string, progress ProgressCallback, itemStatus ItemStatusCallback, ctx context.Context, customProxy string, opts DownloadOptions) (downloaded int, skipped int, failed int, err error) {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	total := len(items)
+	if total == 0 {
+		return 0, 0, 0, nil
+	}
+	opts = applySettingsDefaults(opts)
+
+	// Record the run in the job history
+	started := time.Now()
+	var savedBytes int64
+	defer func() {
+		if err == ErrDownloadDeclined {
+			return
+		}
+		record := newJobRecord(JobKindDownload, username, started)
+		record.Items, record.Bytes, record.Failed = downloaded, atomic.LoadInt64(&savedBytes), failed
+		if err != nil {
+			record.Error = err.Error()
+		}
+		recordJob(record)
+	}()
+
+	// The queue is rewritten when the job stops, so it can't be edited meanwhile
+	// The job can be paused and resumed through its queue ID
+	control := newJobControl()
+	if opts.QueueID != "" {
+		runningQueues.Store(opts.QueueID, control)
+		defer runningQueues.Delete(opts.QueueID)
+	}
+
+	// Connect to a remote target once for the whole job
+	opts, closeStorage, err := openStorage(opts, outputDir)
+	if err != nil {
+		return 0, 0, total, err
+	}
+	defer closeStorage()
+
+	// Prepare all tasks first (sequential to handle tweet media count)
+	tasks, filtered := planDownloadTasks(items, outputDir, username, opts)
+
+	// Diff the job against the archive first so a huge sync doesn't start unnoticed
+	if opts.ConfirmAboveBytes > 0 && opts.Confirm != nil {
+		diff := diffTasks(ctx, tasks, opts.storage(), customProxy, opts.ConfirmAboveBytes)
+		diff.Username = username
+		diff.OutputDir = outputDir
+		diff.FilteredFiles = filtered
+		if diff.NeedsConfirmation && !opts.Confirm(ctx, diff) {
+			return 0, 0, 0, ErrDownloadDeclined
+		}
+	}
+
+	// Items dropped by the filter or as duplicates are reported as skipped
+	if itemStatus != nil && filtered > 0 {
+		planned := make(map[int]bool, len(tasks))
+		for _, task := range tasks {
+			planned[task.index] = true
+		}
+		for i, item := range items {
+			if !planned[i] {
+				itemStatus(item.TweetID, i, "skipped")
+			}
+		}
+	}
+
+	// Very new tweets go last so their media has time to finish processing
+	var resolver *tweetResolver
+	if opts.GraceMinutes > 0 {
+		grace := time.Duration(opts.GraceMinutes) * time.Minute
+		tasks = deferYoungTasks(tasks, grace, time.Now())
+		resolver = newTweetResolver(opts.AuthToken, grace)
+	}
+
+	// Create the username/type folders up front, dropping tasks whose folder can't be created
+	store := opts.storage()
+	embed := isLocalStorage(store) // Metadata is embedded in place, which needs a local file
+	validate := opts.ValidateMedia && embed && IsFFprobeInstalled()
+	// Files of sensitive accounts are encrypted as they finish while encryption is on, which needs
+	// them on a local disk; they're never sent to a remote target in plaintext
+	encrypt := encryptsAccount(username)
+	if encrypt && !embed {
+		return 0, 0, total, fmt.Errorf("@%s is encrypted at rest, which needs a local output folder (not SFTP, S3, WebDAV or an archive)", username)
+	}
+	if encrypt {
+		if _, err := encryptionKey(); err != nil {
+			return 0, 0, total, err
+		}
+	}
+	ready := tasks[:0]
+	for _, task := range tasks {
+		if err := store.MkdirAll(filepath.Dir(task.outputPath)); err != nil {
+			continue
+		}
+		task.seq = len(ready)
+		ready = append(ready, task)
+	}
+	tasks = ready
+
+	// Filtered items are reported as skipped and don't count towards progress
+	total = len(tasks)
+	if total == 0 {
+		return 0, filtered, 0, nil
+	}
+
+	// Run the batch hook last, after the tweet archive is written
+	hooks := startHooks(opts, outputDir, username, len(tasks))
+	defer func() {
+		hooks.finish(downloaded, skipped, failed, err)
+	}()
+
+	// Archive the tweets of the job's media, also when it's stopped
+	// Encrypted accounts get no tweet archives, their text would be readable
+	if opts.TweetsJSONL && !encrypt {
+		defer func() {
+			if err := writeTweetsJSONL(store, tasks, outputDir); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}()
+	}
+	if opts.TweetsTXT && !encrypt {
+		defer func() {
+			if err := writeTweetTexts(store, tasks, outputDir); err != nil {
+				fmt.Printf("Warning: %v\n", err)
+			}
+		}()
+	}
+
+	// List media that's gone for good in missing.csv
+	missing := &missingLog{}
+	defer func() {
+		if err := missing.write(store); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+	}()
+
+	// Pause the job before the output volume fills up
+	var disk *diskMonitor
+	if embed {
+		var pause context.CancelCauseFunc
+		ctx, pause = context.WithCancelCause(ctx)
+		defer pause(nil)
+		var stopMonitor func()
+		disk, stopMonitor = startDiskMonitor(ctx, outputDir, opts.MinFreeBytes, pause)
+		defer stopMonitor()
+	}
+
+	// Skip oversized files and stop the job at its quota, or when its archive can't be pruned to the cap
+	var stopJob context.CancelCauseFunc
+	ctx, stopJob = context.WithCancelCause(ctx)
+	defer stopJob(nil)
+	limits := newSizeLimits(opts, stopJob)
+
 	// Counters for parallel downloads
 	var downloadedCount int64
-	var skippedCount int64
+	skippedCount := int64(filtered)
 	var failedCount int64
 	var completedCount int64
+	attempted := make([]int32, len(tasks)) // 1 once a worker picked up the task
+
+	// persistPending saves tasks no worker picked up so a stopped job can be resumed
+	persistPending := func() {
+		if opts.QueueID == "" {
+			return
+		}
+		var pending []MediaItem
+		for _, task := range tasks {
+			if atomic.LoadInt32(&attempted[task.seq]) == 0 {
+				pending = append(pending, task.item)
+			}
+		}
+		if len(pending) == 0 {
+			DeleteQueue(opts.QueueID)
+			return
+		}
+		job := QueueJob{ID: opts.QueueID, Username: username, OutputDir: outputDir, Proxy: customProxy, Options: opts}
+		if existing, _, _, err := LoadQueue(opts.QueueID); err == nil {
+			job.CreatedAt = existing.CreatedAt
+		}
+		if err := SaveQueue(job, pending); err != nil {
+			fmt.Printf("Warning: failed to save pending queue: %v\n", err)
+		}
+	}
+
+	// Failed items are saved so RetryFailed can try just those again
+	failures := &failureLog{}
+	if opts.QueueID != "" {
+		defer func() {
+			var ran []MediaItem
+			for _, task := range tasks {
+				if atomic.LoadInt32(&attempted[task.seq]) == 1 {
+					ran = append(ran, task.item)
+				}
+			}
+			failures.save(QueueJob{ID: opts.QueueID, Username: username, OutputDir: outputDir, Proxy: customProxy, Options: opts}, ran)
+		}()
+	}
+
+	// Downloaded videos, for the preview step
+	var videos []string
+	var videosMu sync.Mutex
+
+	conflicts := newConflictHandler(opts.Conflict, opts.Resolver, opts.CollisionSuffix)
+	archive := newArchiveCap(opts, items)
 
 	// Create worker pool
 	taskChan := make(chan downloadTask, len(tasks))
 	var wg sync.WaitGroup
 
 	// Start workers
-	numWorkers := MaxConcurrentDownloads
+	numWorkers := GetSettings().MaxConcurrentDownloads
 	if numWorkers > len(tasks) {
 		numWorkers = len(tasks)
 	}
@@ -178,11 +493,13 @@ func DownloadMediaWithMetadataProgressAndStatus(items []MediaItem, outputDir str
 	if err != nil {
 		// If proxy setup fails, use default client without proxy
 		sharedClient = &http.Client{
-			Timeout: 60 * time.Second,
+			Transport: audited(sharedTransport(nil), ""),
+			Timeout:   60 * time.Second,
 		}
 	} else {
 		sharedClient = client
 	}
+	sharedClient = bandwidth.wrap(limits.wrap(sharedClient))
 
 	for i := 0; i < numWorkers; i++ {
 		wg.Add(1)
@@ -190,6 +507,22 @@ func DownloadMediaWithMetadataProgressAndStatus(items []MediaItem, outputDir str
 			defer wg.Done()
 			client := sharedClient
 
+			// failedDownload counts a download error, files above the size limit as skipped and
+			// dead links as missing
+			var failErr error
+			failedDownload := func(task downloadTask, err error) string {
+				if errors.Is(err, ErrFileTooLarge) {
+					atomic.AddInt64(&skippedCount, 1)
+					return "skipped"
+				}
+				failErr = err
+				if _, dead := isDeadLink(err); dead {
+					return "missing"
+				}
+				atomic.AddInt64(&failedCount, 1)
+				return "failed"
+			}
+
 			for task := range taskChan {
 				// Check for cancellation
 				select {
@@ -197,10 +530,29 @@ func DownloadMediaWithMetadataProgressAndStatus(items []MediaItem, outputDir str
 					return
 				default:
 				}
+				// Don't start new files while the job is paused
+				if !control.wait(ctx) {
+					return
+				}
+				atomic.StoreInt32(&attempted[task.seq], 1)
+				if task.recheck {
+					task = resolver.refresh(ctx, task)
+				}
 
 				var status string
+				failErr = nil
+				savedPath := task.outputPath
+				// Encrypted files count as downloaded
+				if _, err := store.Stat(task.outputPath + EncryptedExt); err == nil && encrypt && conflicts.keepsExisting() {
+					if itemStatus != nil {
+						itemStatus(task.item.TweetID, task.index, "skipped")
+					}
+					atomic.AddInt64(&skippedCount, 1)
+					continue
+				}
 				// Skip if file already exists
-				if _, err := os.Stat(task.outputPath); err == nil {
+				// Converted images can't be compared with a new download, so they're always kept
+				if _, err := store.Stat(task.outputPath); err == nil && (conflicts.keepsExisting() || task.webp) {
 					status = "skipped"
 					// Emit status immediately for skipped files
 					if itemStatus != nil {
@@ -208,18 +560,68 @@ func DownloadMediaWithMetadataProgressAndStatus(items []MediaItem, outputDir str
 					}
 					atomic.AddInt64(&skippedCount, 1)
 					continue // Skip to next task
+				} else if archive.full(task) {
+					// Account archive reached its size cap
+					status = "skipped"
+					atomic.AddInt64(&skippedCount, 1)
+				} else if err == nil {
+					// Existing file - download and compare, then apply the conflict policy
+					var err error
+					err = control.transfer(ctx, func(ctx context.Context) (err error) {
+						savedPath, err = conflicts.resolve(ctx, client, store, task)
+						return err
+					})
+					if err != nil {
+						status = failedDownload(task, err)
+					} else if savedPath == "" {
+						status = "skipped"
+						if itemStatus != nil {
+							itemStatus(task.item.TweetID, task.index, status)
+						}
+						atomic.AddInt64(&skippedCount, 1)
+						continue
+					} else {
+						if embed && task.item.Type != "text" {
+							tweetURL := fmt.Sprintf("https://x.com/i/status/%d", task.item.TweetID)
+							EmbedMetadata(savedPath, task.item.Content, tweetURL, ExtractOriginalFilename(task.item.URL), postedTime(task.item.Date, opts.DateZone))
+						}
+						atomic.AddInt64(&downloadedCount, 1)
+						status = "success"
+					}
 				} else if task.item.Type == "text" {
 					// For text tweets, write content to file
-					if err := os.WriteFile(task.outputPath, []byte(task.item.Content), 0644); err != nil {
+					if err := writeStorageFile(store, task.outputPath, strings.NewReader(tweetText(task.item))); err != nil {
 						atomic.AddInt64(&failedCount, 1)
+						failErr = err
 						status = "failed"
 					} else {
 						atomic.AddInt64(&downloadedCount, 1)
 						status = "success"
 					}
-				} else if err := downloadFileWithContext(ctx, client, task.item.URL, task.outputPath); err != nil {
+				} else if task.webp {
+					if err = control.transfer(ctx, func(ctx context.Context) (err error) {
+						savedPath, err = downloadWebP(ctx, client, task, opts.WebPQuality)
+						return err
+					}); err != nil {
+						status = failedDownload(task, err)
+					} else {
+						tweetURL := fmt.Sprintf("https://x.com/i/status/%d", task.item.TweetID)
+						EmbedMetadata(savedPath, task.item.Content, tweetURL, ExtractOriginalFilename(task.item.URL), postedTime(task.item.Date, opts.DateZone))
+						atomic.AddInt64(&downloadedCount, 1)
+						status = "success"
+					}
+				} else if err := control.transfer(ctx, func(ctx context.Context) error {
+					return downloadFileWithContext(ctx, client, store, task.item.URL, task.outputPath)
+				}); err != nil {
+					status = failedDownload(task, err)
+				} else if validate && !validateDownload(task.outputPath) {
+					// Removed, so the next run downloads it again
 					atomic.AddInt64(&failedCount, 1)
+					failErr = errors.New("downloaded file is invalid")
 					status = "failed"
+				} else if !embed {
+					atomic.AddInt64(&downloadedCount, 1)
+					status = "success"
 				} else {
 					// Embed metadata after successful download
 					tweetURL := fmt.Sprintf("https://x.com/i/status/%d", task.item.TweetID)
@@ -230,7 +632,7 @@ func DownloadMediaWithMetadataProgressAndStatus(items []MediaItem, outputDir str
 					// This is acceptable - video URLs from Twitter may not contain original filename
 					
 					// Embed metadata (non-fatal: if it fails, file is still downloaded)
-					if err := EmbedMetadata(task.outputPath, task.item.Content, tweetURL, originalFilename); err != nil {
+					if err := EmbedMetadata(task.outputPath, task.item.Content, tweetURL, originalFilename, postedTime(task.item.Date, opts.DateZone)); err != nil {
 						// Log error but don't fail the download
 						// Metadata embedding is optional
 					}
@@ -239,6 +641,56 @@ func DownloadMediaWithMetadataProgressAndStatus(items []MediaItem, outputDir str
 					status = "success"
 				}
 
+				// Deleted media may still be in the Wayback Machine
+				if status == "missing" {
+					if !opts.WaybackFallback {
+						missing.add(task, failErr, outputDir)
+					} else if path, snapshot, err := recoverFromWayback(ctx, client, store, task, opts.WebPQuality); err != nil {
+						missing.add(task, failErr, outputDir)
+					} else {
+						markRecovered(task.item, path, snapshot)
+						atomic.AddInt64(&downloadedCount, 1)
+						savedPath = path
+						status = "recovered"
+					}
+				}
+
+				if encrypt && (status == "success" || status == "recovered") {
+					if path, err := EncryptFile(savedPath); err != nil {
+						// Never leave a plaintext copy behind
+						os.Remove(savedPath)
+						atomic.AddInt64(&downloadedCount, -1)
+						atomic.AddInt64(&failedCount, 1)
+						failErr = err
+						status = "failed"
+					} else {
+						savedPath = path
+					}
+				}
+
+				if status == "success" || status == "recovered" {
+					if err := archive.added(task.item.TweetID, savedPath); err != nil {
+						stopJob(err)
+					}
+					hooks.file(task.item, task.index, savedPath)
+					if info, err := store.Stat(savedPath); err == nil {
+						limits.added(info.Size())
+						atomic.AddInt64(&savedBytes, info.Size())
+					}
+					if task.item.Type == "video" && !encrypt {
+						videosMu.Lock()
+						videos = append(videos, savedPath)
+						videosMu.Unlock()
+					}
+				}
+
+				// Files that failed because the job was stopped or the disk filled up stay in the queue
+				if status == "failed" && (disk.check() || ctx.Err() != nil) {
+					atomic.StoreInt32(&attempted[task.seq], 0)
+				} else if status == "failed" {
+					failures.add(task.item, failErr)
+				}
+
 				// Emit per-item status
 				if itemStatus != nil {
 					itemStatus(task.item.TweetID, task.index, status)
@@ -259,7 +711,8 @@ func DownloadMediaWithMetadataProgressAndStatus(items []MediaItem, outputDir str
 		case <-ctx.Done():
 			close(taskChan)
 			wg.Wait()
-			return int(downloadedCount), int(skippedCount), int(failedCount) + (total - int(completedCount)), ctx.Err()
+			persistPending()
+			return int(downloadedCount), int(skippedCount), int(failedCount) + (total - int(completedCount)), context.Cause(ctx)
 		case taskChan <- task:
 		}
 	}
@@ -268,11 +721,32 @@ func DownloadMediaWithMetadataProgressAndStatus(items []MediaItem, outputDir str
 	// Wait for all workers to finish
 	wg.Wait()
 
+	if opts.VideoPreview != "" && embed && len(videos) > 0 && IsFFmpegInstalled() {
+		runFFmpegPool(videos, 0, func(videoPath string) error {
+			_, err := GenerateVideoPreview(videoPath, opts.VideoPreview, PreviewInThumbsFolder, false)
+			return err
+		})
+	}
+
+	// Workers stop early when cancelled - keep what's left for resume, otherwise the queue is done
+	if ctx.Err() != nil {
+		persistPending()
+		if cause := context.Cause(ctx); errors.Is(cause, ErrLowDiskSpace) || errors.Is(cause, ErrJobQuotaReached) || errors.Is(cause, ErrArchivePruneFailed) {
+			return int(downloadedCount), int(skippedCount), int(failedCount) + (total - int(completedCount)), cause
+		}
+	} else if opts.QueueID != "" {
+		DeleteQueue(opts.QueueID)
+	}
+
 	return int(downloadedCount), int(skippedCount), int(failedCount), nil
 }
 
-// downloadFileWithContext downloads a single file with context support for cancellation
-func downloadFileWithContext(ctx context.Context, client *http.Client, url, outputPath string) error {
+// downloadFileWithContext downloads a single file to store with context support for cancellation
+func downloadFileWithContext(ctx context.Context, client *http.Client, store Storage, url, outputPath string) error {
+	if resumable, ok := store.(ResumableStorage); ok {
+		return resumeDownload(ctx, client, resumable, url, outputPath, true)
+	}
+
 	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
 	if err != nil {
 		return err
@@ -285,41 +759,28 @@ func downloadFileWithContext(ctx context.Context, client *http.Client, url, outp
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("bad status: %s", resp.Status)
+		return &HTTPStatusError{Code: resp.StatusCode, Status: resp.Status}
 	}
 
-	out, err := os.Create(outputPath)
-	if err != nil {
+	if err := writeStorageFile(store, outputPath, resp.Body); err != nil {
 		return err
 	}
-	defer out.Close()
-
-	_, err = io.Copy(out, resp.Body)
-	return err
+	rememberValidators(url, resp.Header)
+	return nil
 }
 
-// formatTimestamp converts date string to timestamp format
-func formatTimestamp(dateStr string) string {
-	// Try parsing various date formats
-	formats := []string{
-		"2006-01-02T15:04:05",       // ISO 8601 without timezone (from extractor)
-		"2006-01-02T15:04:05+00:00", // ISO 8601 with timezone
-		"2006-01-02T15:04:05-07:00", // ISO 8601 with timezone offset
-		time.RFC3339,                // Standard RFC3339
-		"2006-01-02T15:04:05.000Z",
-		"2006-01-02T15:04:05Z",
-		"2006-01-02 15:04:05",
-		"Mon Jan 02 15:04:05 -0700 2006",
-	}
-
-	for _, format := range formats {
-		if t, err := time.Parse(format, dateStr); err == nil {
-			return t.Format("20060102_150405")
-		}
+// downloadWebP downloads a WebP image and converts it to the planned format
+// Returns the saved path: the converted file, or the WebP file if the conversion failed
+func downloadWebP(ctx context.Context, client *http.Client, task downloadTask, quality int) (string, error) {
+	webpPath := strings.TrimSuffix(task.outputPath, filepath.Ext(task.outputPath)) + ".webp"
+	if err := downloadFileWithContext(ctx, client, LocalStorage{}, task.item.URL, webpPath); err != nil {
+		return "", err
 	}
-
-	// Fallback: use empty string to indicate parsing failed
-	return "00000000_000000"
+	if err := ConvertImage(webpPath, task.outputPath, quality); err != nil {
+		return webpPath, nil // Keep the original rather than failing the download
+	}
+	os.Remove(webpPath)
+	return task.outputPath, nil
 }
 
 // getExtension determines file extension from URL and type
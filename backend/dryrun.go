@@ -0,0 +1,181 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Dry-run diff
+//
+// An incremental sync of an account only downloads what isn't in the archive yet, which is
+// usually little - but a reset archive or a prolific account can turn it into tens of GB.
+// Before anything is downloaded, the job is diffed against the archive: new tweets, new files,
+// an estimated size from HEAD requests on a sample of the new files, and the authors involved
+// (for bookmarks/likes). Above a threshold, the user is asked to confirm first.
+
+// dryRunSampleSize is the number of new files per media type whose size is requested from the server
+const dryRunSampleSize = 25
+
+// ErrDownloadDeclined is returned when the user declines a download after seeing its diff
+var ErrDownloadDeclined = errors.New("download declined after dry run")
+
+// DownloadConfirmer is asked whether to go ahead with a download whose estimate is above the threshold
+type DownloadConfirmer func(ctx context.Context, diff SyncDiff) bool
+
+// SyncAuthor is an author with new files in a sync
+type SyncAuthor struct {
+	Username string `json:"username"`
+	NewFiles int    `json:"new_files"`
+}
+
+// SyncDiff is the dry-run report of what a download job would add to the archive
+type SyncDiff struct {
+	ID                string         `json:"id"`
+	Username          string         `json:"username"`
+	OutputDir         string         `json:"output_dir"`
+	NewTweets         int            `json:"new_tweets"`
+	NewFiles          int            `json:"new_files"`
+	ExistingFiles     int            `json:"existing_files"`
+	FilteredFiles     int            `json:"filtered_files"`
+	NewFilesByType    map[string]int `json:"new_files_by_type"`
+	EstimatedBytes    int64          `json:"estimated_bytes"`
+	MeasuredFiles     int            `json:"measured_files"` // New files whose size came from the server, the rest is extrapolated
+	Authors           []SyncAuthor   `json:"authors,omitempty"`
+	ThresholdBytes    int64          `json:"threshold_bytes,omitempty"`
+	NeedsConfirmation bool           `json:"needs_confirmation"`
+}
+
+// DryRunDownload reports what a download job would add to the archive without downloading anything
+func DryRunDownload(ctx context.Context, items []MediaItem, outputDir string, username string, customProxy string, opts DownloadOptions) SyncDiff {
+	opts, closeStorage, err := openStorage(opts, outputDir)
+	if err != nil {
+		opts.Storage = LocalStorage{} // Compare with the local folder rather than failing the preview
+	} else {
+		defer closeStorage()
+	}
+	tasks, filtered := planDownloadTasks(items, outputDir, username, opts)
+	diff := diffTasks(ctx, tasks, opts.storage(), customProxy, opts.ConfirmAboveBytes)
+	diff.Username = username
+	diff.OutputDir = outputDir
+	diff.FilteredFiles = filtered
+	return diff
+}
+
+// diffTasks compares planned tasks with the archive on disk and estimates the download size
+func diffTasks(ctx context.Context, tasks []downloadTask, store Storage, customProxy string, threshold int64) SyncDiff {
+	diff := SyncDiff{
+		ID:             fmt.Sprintf("%d", time.Now().UnixNano()),
+		NewFilesByType: make(map[string]int),
+		ThresholdBytes: threshold,
+	}
+
+	newTweets := make(map[int64]bool)
+	authorFiles := make(map[string]int)
+	byType := make(map[string][]downloadTask)
+	for _, task := range tasks {
+		if storageExists(store, task.outputPath) {
+			diff.ExistingFiles++
+			continue
+		}
+		diff.NewFiles++
+		diff.NewFilesByType[task.item.Type]++
+		newTweets[task.item.TweetID] = true
+		authorFiles[filepath.Base(accountDir(task.outputPath))]++
+		byType[task.item.Type] = append(byType[task.item.Type], task)
+	}
+	diff.NewTweets = len(newTweets)
+
+	// Authors only matter when the job mixes them (bookmarks, likes)
+	if len(authorFiles) > 1 {
+		for name, files := range authorFiles {
+			diff.Authors = append(diff.Authors, SyncAuthor{Username: name, NewFiles: files})
+		}
+		sort.Slice(diff.Authors, func(i, j int) bool {
+			if diff.Authors[i].NewFiles != diff.Authors[j].NewFiles {
+				return diff.Authors[i].NewFiles > diff.Authors[j].NewFiles
+			}
+			return diff.Authors[i].Username < diff.Authors[j].Username
+		})
+	}
+
+	client, err := CreateHTTPClient(customProxy, 15*time.Second)
+	if err != nil {
+		client = &http.Client{Transport: audited(nil, ""), Timeout: 15 * time.Second}
+	}
+	for mediaType, typeTasks := range byType {
+		if mediaType == "text" {
+			for _, task := range typeTasks {
+				diff.EstimatedBytes += int64(len(tweetText(task.item)))
+			}
+			continue
+		}
+		measured, bytes := sampleSizes(ctx, client, typeTasks)
+		diff.MeasuredFiles += measured
+		if measured > 0 {
+			// Extrapolate the sample average to the files that weren't measured
+			diff.EstimatedBytes += bytes + bytes/int64(measured)*int64(len(typeTasks)-measured)
+		}
+	}
+
+	diff.NeedsConfirmation = threshold > 0 && diff.EstimatedBytes > threshold
+	return diff
+}
+
+// sampleSizes requests the size of up to dryRunSampleSize files spread over tasks
+// Returns the number of files with a known size and their total size
+func sampleSizes(ctx context.Context, client *http.Client, tasks []downloadTask) (int, int64) {
+	n := len(tasks)
+	if n > dryRunSampleSize {
+		n = dryRunSampleSize
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var measured int
+	var total int64
+	sem := make(chan struct{}, GetSettings().MaxConcurrentDownloads)
+	for i := 0; i < n; i++ {
+		// Spread the sample over the whole job - old and new media differ in size
+		task := tasks[i*len(tasks)/n]
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			size, err := remoteSize(ctx, client, task.item.URL)
+			if err != nil || size <= 0 {
+				return
+			}
+			mu.Lock()
+			measured++
+			total += size
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return measured, total
+}
+
+// remoteSize returns the Content-Length of a URL from a HEAD request
+func remoteSize(ctx context.Context, client *http.Client, url string) (int64, error) {
+	req, err := http.NewRequestWithContext(ctx, "HEAD", url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return 0, errors.New(resp.Status)
+	}
+	return resp.ContentLength, nil
+}
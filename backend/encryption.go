@@ -0,0 +1,725 @@
+package backend
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Encryption at rest: files and stored timelines of sensitive accounts are encrypted with
+// AES-256-GCM under a random data key, which is wrapped with the content lock passphrase in
+// encryption.json. Files get EncryptedExt and are sealed in 64 KB chunks so they can be streamed.
+
+const (
+	// EncryptedExt is appended to the name of encrypted files
+	EncryptedExt = ".xenc"
+
+	encryptionMagic      = "XMDENC01"
+	encryptionNonceSize  = 8 // Random per file, followed by a 4-byte chunk counter
+	encryptionHeaderSize = len(encryptionMagic) + encryptionNonceSize
+	encryptionChunkSize  = 64 * 1024
+	encryptionSealedSize = encryptionChunkSize + 16 // Chunk plus GCM tag
+
+	encryptedValuePrefix = "enc:v1:"
+)
+
+// EncryptionStatus describes the encryption state
+type EncryptionStatus struct {
+	Enabled  bool `json:"enabled"`
+	Unlocked bool `json:"unlocked"` // The data key is available
+}
+
+// EncryptionResult describes the files of an account folder encrypted or decrypted in one go
+type EncryptionResult struct {
+	Folder string `json:"folder"`
+	Files  int    `json:"files"`
+	Failed int    `json:"failed"`
+}
+
+// encryptionConfig is the stored, wrapped data key
+type encryptionConfig struct {
+	Salt       string `json:"salt"`
+	Iterations int    `json:"iterations"`
+	WrappedKey string `json:"wrapped_key"`
+}
+
+var encryption struct {
+	sync.Mutex
+	loaded bool
+	config *encryptionConfig
+	key    []byte // Data key, nil while locked
+}
+
+// getEncryptionPath returns the path of the wrapped data key
+func getEncryptionPath() string {
+	return filepath.Join(GetAppDataDir(), "encryption.json")
+}
+
+// loadEncryption reads the wrapped data key once (encryption must be held)
+func loadEncryption() {
+	if encryption.loaded {
+		return
+	}
+	encryption.loaded = true
+	data, err := os.ReadFile(getEncryptionPath())
+	if err != nil {
+		return
+	}
+	var cfg encryptionConfig
+	if err := json.Unmarshal(data, &cfg); err != nil || cfg.WrappedKey == "" {
+		return
+	}
+	encryption.config = &cfg
+}
+
+// GetEncryptionStatus returns whether encryption is on and whether the data key is available
+func GetEncryptionStatus() EncryptionStatus {
+	encryption.Lock()
+	defer encryption.Unlock()
+	loadEncryption()
+	return EncryptionStatus{Enabled: encryption.config != nil, Unlocked: encryption.key != nil}
+}
+
+// EncryptionEnabled reports whether sensitive accounts are encrypted
+func EncryptionEnabled() bool {
+	return GetEncryptionStatus().Enabled
+}
+
+// encryptsAccount reports whether the downloads and timelines of an account are encrypted
+func encryptsAccount(username string) bool {
+	return EncryptionEnabled() && IsUsernameSensitive(username)
+}
+
+// encryptionKey returns the data key, ErrContentLocked while the app is locked
+func encryptionKey() ([]byte, error) {
+	encryption.Lock()
+	defer encryption.Unlock()
+	loadEncryption()
+	if encryption.config == nil {
+		return nil, fmt.Errorf("encryption is off")
+	}
+	if encryption.key == nil {
+		return nil, ErrContentLocked
+	}
+	return encryption.key, nil
+}
+
+// EnableEncryption turns encryption on and encrypts the stored timelines of sensitive accounts
+// passphrase is the content lock passphrase, which must be set first
+func EnableEncryption(passphrase string) error {
+	if !GetLockStatus().Enabled {
+		return fmt.Errorf("set a lock passphrase first, it protects the encryption key")
+	}
+	if !verifyLockPassphrase(passphrase) {
+		return fmt.Errorf("wrong passphrase")
+	}
+
+	encryption.Lock()
+	loadEncryption()
+	if encryption.config != nil {
+		encryption.Unlock()
+		return nil
+	}
+	key := make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		encryption.Unlock()
+		return fmt.Errorf("failed to generate encryption key: %v", err)
+	}
+	cfg, err := wrapEncryptionKey(key, passphrase)
+	if err == nil {
+		err = saveEncryptionConfig(cfg)
+	}
+	if err != nil {
+		encryption.Unlock()
+		return err
+	}
+	encryption.config = cfg
+	encryption.key = key
+	encryption.Unlock()
+
+	if err := syncEncryptedRows(false); err != nil {
+		return err
+	}
+	// Plaintext timelines stay in free pages until they're reused
+	if _, err := db.Exec("VACUUM"); err != nil {
+		fmt.Printf("Warning: failed to compact the database: %v\n", err)
+	}
+	return nil
+}
+
+// DisableEncryption decrypts the stored timelines and the account folders of saved accounts and
+// turns encryption off. Folders outside downloadDir are found through their recorded archive path
+func DisableEncryption(passphrase, downloadDir string) ([]EncryptionResult, error) {
+	if IsLocked() {
+		return nil, ErrContentLocked
+	}
+	if !verifyLockPassphrase(passphrase) {
+		return nil, fmt.Errorf("wrong passphrase")
+	}
+	if err := unlockEncryption(passphrase); err != nil {
+		return nil, err
+	}
+	if !EncryptionEnabled() {
+		return nil, nil
+	}
+
+	accounts, err := GetAllAccounts()
+	if err != nil {
+		return nil, err
+	}
+	var results []EncryptionResult
+	seen := make(map[string]bool)
+	for _, acc := range accounts {
+		folder := accountArchiveFolder(acc.Username, downloadDir)
+		if seen[folder] {
+			continue
+		}
+		seen[folder] = true
+		if _, err := os.Stat(folder); err != nil {
+			continue
+		}
+		result, err := DecryptArchive(folder)
+		if err != nil {
+			return results, err
+		}
+		if result.Files > 0 {
+			results = append(results, *result)
+		}
+		if result.Failed > 0 {
+			return results, fmt.Errorf("failed to decrypt %d files in %s, encryption stays on", result.Failed, folder)
+		}
+	}
+	if err := syncEncryptedRows(true); err != nil {
+		return results, err
+	}
+
+	encryption.Lock()
+	defer encryption.Unlock()
+	if err := os.Remove(getEncryptionPath()); err != nil && !os.IsNotExist(err) {
+		return results, fmt.Errorf("failed to remove encryption key: %v", err)
+	}
+	encryption.config = nil
+	encryption.key = nil
+	return results, nil
+}
+
+// unlockEncryption unwraps the data key with the lock passphrase and encrypts timelines of accounts
+// flagged sensitive while it was locked
+func unlockEncryption(passphrase string) error {
+	encryption.Lock()
+	loadEncryption()
+	if encryption.config == nil || encryption.key != nil {
+		encryption.Unlock()
+		return nil
+	}
+	key, err := unwrapEncryptionKey(encryption.config, passphrase)
+	if err != nil {
+		encryption.Unlock()
+		return err
+	}
+	encryption.key = key
+	encryption.Unlock()
+	return syncEncryptedRows(false)
+}
+
+// lockEncryption forgets the data key
+func lockEncryption() {
+	encryption.Lock()
+	defer encryption.Unlock()
+	encryption.key = nil
+}
+
+// rewrapEncryptionKey wraps the data key with a new lock passphrase
+func rewrapEncryptionKey(current, passphrase string) error {
+	encryption.Lock()
+	defer encryption.Unlock()
+	loadEncryption()
+	if encryption.config == nil {
+		return nil
+	}
+	if passphrase == "" {
+		return fmt.Errorf("turn off encryption before removing the passphrase")
+	}
+	key, err := unwrapEncryptionKey(encryption.config, current)
+	if err != nil {
+		return err
+	}
+	cfg, err := wrapEncryptionKey(key, passphrase)
+	if err != nil {
+		return err
+	}
+	if err := saveEncryptionConfig(cfg); err != nil {
+		return err
+	}
+	encryption.config = cfg
+	encryption.key = key
+	return nil
+}
+
+// wrapEncryptionKey encrypts the data key with a key derived from the passphrase
+func wrapEncryptionKey(key []byte, passphrase string) (*encryptionConfig, error) {
+	salt := make([]byte, 16)
+	if _, err := rand.Read(salt); err != nil {
+		return nil, fmt.Errorf("failed to generate salt: %v", err)
+	}
+	kek, err := pbkdf2.Key(sha256.New, passphrase, salt, lockIterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+	sealed, err := sealBytes(kek, key)
+	if err != nil {
+		return nil, err
+	}
+	return &encryptionConfig{Salt: hex.EncodeToString(salt), Iterations: lockIterations, WrappedKey: hex.EncodeToString(sealed)}, nil
+}
+
+// unwrapEncryptionKey decrypts the data key with the passphrase
+func unwrapEncryptionKey(cfg *encryptionConfig, passphrase string) ([]byte, error) {
+	salt, err := hex.DecodeString(cfg.Salt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key file: %v", err)
+	}
+	sealed, err := hex.DecodeString(cfg.WrappedKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid encryption key file: %v", err)
+	}
+	kek, err := pbkdf2.Key(sha256.New, passphrase, salt, cfg.Iterations, 32)
+	if err != nil {
+		return nil, fmt.Errorf("failed to derive key: %v", err)
+	}
+	key, err := openBytes(kek, sealed)
+	if err != nil {
+		return nil, fmt.Errorf("wrong passphrase")
+	}
+	return key, nil
+}
+
+func saveEncryptionConfig(cfg *encryptionConfig) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(GetAppDataDir(), 0755); err != nil {
+		return err
+	}
+	tmpPath := getEncryptionPath() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to save encryption key: %v", err)
+	}
+	if err := os.Rename(tmpPath, getEncryptionPath()); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("failed to save encryption key: %v", err)
+	}
+	return nil
+}
+
+// sealBytes encrypts data with a random nonce, which is prepended
+func sealBytes(key, data []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	return aead.Seal(nonce, nonce, data, nil), nil
+}
+
+// openBytes decrypts data sealed by sealBytes
+func openBytes(key, sealed []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(sealed) < aead.NonceSize() {
+		return nil, errors.New("ciphertext too short")
+	}
+	return aead.Open(nil, sealed[:aead.NonceSize()], sealed[aead.NonceSize():], nil)
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// sealResponseJSON encrypts the stored timeline of a sensitive account while encryption is on
+func sealResponseJSON(username, responseJSON string) (string, error) {
+	if responseJSON == "" || strings.HasPrefix(responseJSON, encryptedValuePrefix) || !encryptsAccount(username) {
+		return responseJSON, nil
+	}
+	key, err := encryptionKey()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := sealBytes(key, []byte(responseJSON))
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt timeline: %v", err)
+	}
+	return encryptedValuePrefix + base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// openResponseJSON decrypts a stored timeline, returning plaintext ones as they are
+func openResponseJSON(responseJSON string) (string, error) {
+	encoded, ok := strings.CutPrefix(responseJSON, encryptedValuePrefix)
+	if !ok {
+		return responseJSON, nil
+	}
+	key, err := encryptionKey()
+	if err != nil {
+		return "", err
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt timeline: %v", err)
+	}
+	data, err := openBytes(key, sealed)
+	if err != nil {
+		return "", fmt.Errorf("failed to decrypt timeline: %v", err)
+	}
+	return string(data), nil
+}
+
+// syncEncryptedRows encrypts the stored timelines of sensitive accounts and decrypts the others,
+// or with decryptAll decrypts every timeline. Needs the data key
+func syncEncryptedRows(decryptAll bool) error {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return err
+		}
+	}
+	rows, err := db.Query(`SELECT id, username, COALESCE(response_json, ''), COALESCE(sensitive, 0) FROM accounts`)
+	if err != nil {
+		return err
+	}
+	type update struct {
+		id           int64
+		responseJSON string
+	}
+	var updates []update
+	for rows.Next() {
+		var id int64
+		var username, responseJSON string
+		var sensitive int
+		if err := rows.Scan(&id, &username, &responseJSON, &sensitive); err != nil {
+			continue
+		}
+		encrypted := strings.HasPrefix(responseJSON, encryptedValuePrefix)
+		if want := sensitive == 1 && !decryptAll; want == encrypted || responseJSON == "" {
+			continue
+		}
+		updates = append(updates, update{id, responseJSON})
+	}
+	rows.Close()
+
+	key, err := encryptionKey()
+	if err != nil {
+		return err
+	}
+	for _, u := range updates {
+		var value string
+		if strings.HasPrefix(u.responseJSON, encryptedValuePrefix) {
+			value, err = openResponseJSON(u.responseJSON)
+		} else {
+			var sealed []byte
+			sealed, err = sealBytes(key, []byte(u.responseJSON))
+			value = encryptedValuePrefix + base64.StdEncoding.EncodeToString(sealed)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to convert stored timeline: %v", err)
+		}
+		if _, err := db.Exec("UPDATE accounts SET response_json = ? WHERE id = ?", value, u.id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// EncryptArchive encrypts the downloaded files of an account folder
+func EncryptArchive(folder string) (*EncryptionResult, error) {
+	return convertArchive(folder, true)
+}
+
+// DecryptArchive decrypts the encrypted files of an account folder
+func DecryptArchive(folder string) (*EncryptionResult, error) {
+	return convertArchive(folder, false)
+}
+
+func convertArchive(folder string, encrypt bool) (*EncryptionResult, error) {
+	folder = filepath.Clean(folder)
+	if IsUsernameSensitive(filepath.Base(folder)) && IsLocked() {
+		return nil, ErrContentLocked
+	}
+	if _, err := encryptionKey(); err != nil {
+		return nil, err
+	}
+	result := &EncryptionResult{Folder: folder}
+	for sub := range gallerySubfolders {
+		entries, err := os.ReadDir(filepath.Join(folder, sub))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || strings.HasSuffix(name, ".part") || strings.HasSuffix(name, ".tmp") {
+				continue
+			}
+			if strings.HasSuffix(name, EncryptedExt) == encrypt {
+				continue
+			}
+			path := filepath.Join(folder, sub, name)
+			if encrypt {
+				_, err = EncryptFile(path)
+			} else {
+				_, err = DecryptFile(path)
+			}
+			if err != nil {
+				fmt.Printf("Warning: %v\n", err)
+				result.Failed++
+				continue
+			}
+			result.Files++
+		}
+	}
+	return result, nil
+}
+
+// EncryptFile replaces a file with its encrypted copy and returns the new path
+func EncryptFile(path string) (string, error) {
+	key, err := encryptionKey()
+	if err != nil {
+		return "", err
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to encrypt %s: %v", filepath.Base(path), err)
+	}
+	defer in.Close()
+
+	outPath := path + EncryptedExt
+	if err := writeAtomically(outPath, func(w io.Writer) error { return encryptStream(key, in, w) }); err != nil {
+		return "", fmt.Errorf("failed to encrypt %s: %v", filepath.Base(path), err)
+	}
+	in.Close()
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to remove %s after encrypting it: %v", filepath.Base(path), err)
+	}
+	return outPath, nil
+}
+
+// DecryptFile replaces an encrypted file with its plaintext and returns the new path
+func DecryptFile(path string) (string, error) {
+	f, err := OpenEncryptedFile(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	outPath := strings.TrimSuffix(path, EncryptedExt)
+	if err := writeAtomically(outPath, func(w io.Writer) error {
+		_, err := io.Copy(w, f)
+		return err
+	}); err != nil {
+		return "", fmt.Errorf("failed to decrypt %s: %v", filepath.Base(path), err)
+	}
+	f.Close()
+	if err := os.Remove(path); err != nil {
+		return "", fmt.Errorf("failed to remove %s after decrypting it: %v", filepath.Base(path), err)
+	}
+	return outPath, nil
+}
+
+// writeAtomically writes path through a temporary file, so it never exists half written
+func writeAtomically(path string, write func(io.Writer) error) error {
+	tmpPath := path + ".tmp"
+	out, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	if err := write(out); err != nil {
+		out.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// encryptStream writes the encrypted form of r to w: the magic, the file nonce and the sealed
+// chunks. The last chunk is shorter than a full one (possibly empty) and sealed as final, so a
+// truncated file fails to decrypt
+func encryptStream(key []byte, r io.Reader, w io.Writer) error {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return err
+	}
+	header := make([]byte, encryptionHeaderSize)
+	copy(header, encryptionMagic)
+	if _, err := rand.Read(header[len(encryptionMagic):]); err != nil {
+		return err
+	}
+	if _, err := w.Write(header); err != nil {
+		return err
+	}
+
+	buf := make([]byte, encryptionChunkSize)
+	sealed := make([]byte, 0, encryptionSealedSize)
+	for index := uint32(0); ; index++ {
+		n, err := io.ReadFull(r, buf)
+		final := err == io.EOF || err == io.ErrUnexpectedEOF
+		if err != nil && !final {
+			return err
+		}
+		sealed = aead.Seal(sealed[:0], chunkNonce(header, index), buf[:n], chunkAAD(final))
+		if _, err := w.Write(sealed); err != nil {
+			return err
+		}
+		if final {
+			return nil
+		}
+	}
+}
+
+func chunkNonce(header []byte, index uint32) []byte {
+	nonce := make([]byte, 12)
+	copy(nonce, header[len(encryptionMagic):])
+	binary.BigEndian.PutUint32(nonce[encryptionNonceSize:], index)
+	return nonce
+}
+
+func chunkAAD(final bool) []byte {
+	if final {
+		return []byte{1}
+	}
+	return []byte{0}
+}
+
+// EncryptedFile reads the plaintext of an encrypted file, with seeking
+type EncryptedFile struct {
+	f      *os.File
+	aead   cipher.AEAD
+	header []byte
+	chunks int64 // Sealed chunks in the file, the last one final
+	size   int64 // Plaintext size
+	offset int64
+
+	chunk      []byte // Last decrypted chunk
+	chunkIndex int64
+}
+
+// OpenEncryptedFile opens an encrypted file for reading its plaintext
+func OpenEncryptedFile(path string) (*EncryptedFile, error) {
+	key, err := encryptionKey()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	header := make([]byte, encryptionHeaderSize)
+	if _, err := io.ReadFull(f, header); err != nil || string(header[:len(encryptionMagic)]) != encryptionMagic {
+		f.Close()
+		return nil, fmt.Errorf("%s is not an encrypted file", filepath.Base(path))
+	}
+	body := info.Size() - int64(encryptionHeaderSize)
+	chunks := body/encryptionSealedSize + 1 // The final chunk is always shorter than a full one
+	last := body - (chunks-1)*encryptionSealedSize
+	if last < 16 {
+		f.Close()
+		return nil, fmt.Errorf("%s is truncated", filepath.Base(path))
+	}
+	size := (chunks-1)*encryptionChunkSize + last - 16
+	return &EncryptedFile{f: f, aead: aead, header: header, chunks: chunks, size: size, chunkIndex: -1}, nil
+}
+
+// Size returns the plaintext size
+func (e *EncryptedFile) Size() int64 {
+	return e.size
+}
+
+// readChunk decrypts a chunk, keeping the last one for sequential reads
+func (e *EncryptedFile) readChunk(index int64) ([]byte, error) {
+	if index == e.chunkIndex {
+		return e.chunk, nil
+	}
+	sealed := make([]byte, encryptionSealedSize)
+	n, err := e.f.ReadAt(sealed, int64(encryptionHeaderSize)+index*encryptionSealedSize)
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	final := index == e.chunks-1
+	plain, err := e.aead.Open(e.chunk[:0], chunkNonce(e.header, uint32(index)), sealed[:n], chunkAAD(final))
+	if err != nil {
+		e.chunkIndex = -1
+		return nil, fmt.Errorf("encrypted file is damaged or was tampered with")
+	}
+	e.chunk, e.chunkIndex = plain, index
+	return plain, nil
+}
+
+// Read reads plaintext from the current offset
+func (e *EncryptedFile) Read(p []byte) (int, error) {
+	if e.offset >= e.size {
+		return 0, io.EOF
+	}
+	chunk, err := e.readChunk(e.offset / encryptionChunkSize)
+	if err != nil {
+		return 0, err
+	}
+	n := copy(p, chunk[e.offset%encryptionChunkSize:])
+	e.offset += int64(n)
+	return n, nil
+}
+
+// Seek sets the plaintext offset of the next Read
+func (e *EncryptedFile) Seek(offset int64, whence int) (int64, error) {
+	switch whence {
+	case io.SeekCurrent:
+		offset += e.offset
+	case io.SeekEnd:
+		offset += e.size
+	}
+	if offset < 0 {
+		return 0, errors.New("negative offset")
+	}
+	e.offset = offset
+	return offset, nil
+}
+
+// Close closes the file
+func (e *EncryptedFile) Close() error {
+	return e.f.Close()
+}
@@ -0,0 +1,220 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Job estimates
+//
+// Before starting a big fetch it helps to know whether it's a coffee break or an overnight job.
+// EstimateJob takes the account size (given, from the stored timeline, or looked up with a
+// one-item fetch), turns it into API requests of about tweetsPerRequest tweets each and prices
+// them with the average request latency measured on earlier fetches of the same kind. Timelines
+// are limited to timelineRateBudget requests per rate limit window and searches (date ranges) to
+// far fewer; jobs above the budget wait for the window to reset, which usually dominates.
+
+// Approximate API limits used by the estimate
+const (
+	tweetsPerRequest     = 20                      // Tweets per timeline or search page
+	timelineRateBudget   = 500                     // Timeline requests per window
+	searchRateBudget     = 50                      // Search requests per window
+	rateLimitWindow      = 15 * time.Minute        // Length of a rate limit window
+	defaultRequestTime   = 1500 * time.Millisecond // Latency used before anything was measured
+	fetchStatsSmoothing  = 0.3                     // Weight of the newest fetch in the average latency
+	fetchStatsSearchKind = "search"                // Stats kind of date range fetches
+)
+
+// Where the size and latency of an estimate come from
+const (
+	EstimateSourceGiven   = "given"
+	EstimateSourceStored  = "stored"
+	EstimateSourceFetched = "fetched"
+	EstimateSourceHistory = "history"
+	EstimateSourceDefault = "default"
+)
+
+// JobEstimateRequest describes a fetch to estimate
+type JobEstimateRequest struct {
+	Username     string `json:"username"`
+	AuthToken    string `json:"auth_token,omitempty"`  // Used to look the account up if its size isn't known
+	TimelineType string `json:"timeline_type"`         // As in TimelineRequest, "search" for date range fetches
+	MediaType    string `json:"media_type"`            // all, image, video, gif or text
+	BatchSize    int    `json:"batch_size"`            // Items per batch, 0 = single fetch
+	MediaCount   int    `json:"media_count,omitempty"` // Known account size, 0 = stored or looked up
+}
+
+// JobEstimate is the approximate cost of a fetch
+type JobEstimate struct {
+	Username          string  `json:"username"`
+	Items             int     `json:"items"`        // Media (tweets for text) to fetch
+	ItemsSource       string  `json:"items_source"` // given, stored or fetched
+	Pages             int     `json:"pages"`        // Batches of BatchSize items
+	Requests          int     `json:"requests"`     // API requests
+	SecondsPerRequest float64 `json:"seconds_per_request"`
+	LatencySource     string  `json:"latency_source"` // history or default
+	RateBudget        int     `json:"rate_budget"`    // Requests allowed per rate limit window
+	RateWindows       int     `json:"rate_windows"`   // Rate limit windows the job spans
+	DurationSeconds   int     `json:"duration_seconds"`
+	ExceedsRateLimit  bool    `json:"exceeds_rate_limit"` // The job has to wait for the rate limit at least once
+}
+
+// fetchStat is the measured request latency of one kind of fetch
+type fetchStat struct {
+	SecondsPerRequest float64 `json:"seconds_per_request"`
+	Samples           int     `json:"samples"`
+}
+
+var fetchStatsMu sync.Mutex
+
+// getFetchStatsPath returns the path of the latency history
+func getFetchStatsPath() string {
+	return filepath.Join(GetAppDataDir(), "fetch_stats.json")
+}
+
+// loadFetchStats reads the latency history (fetchStatsMu must be held)
+func loadFetchStats() map[string]fetchStat {
+	stats := make(map[string]fetchStat)
+	if data, err := os.ReadFile(getFetchStatsPath()); err == nil {
+		json.Unmarshal(data, &stats)
+	}
+	return stats
+}
+
+// fetchStatsKind returns the latency history key of a timeline type
+func fetchStatsKind(timelineType string) string {
+	if timelineType == fetchStatsSearchKind {
+		return fetchStatsSearchKind
+	}
+	return getTimelineType(timelineType).ID
+}
+
+// recordFetchLatency adds a finished fetch to the latency history
+func recordFetchLatency(timelineType string, elapsed time.Duration, tweets int) {
+	// Small fetches are mostly process startup
+	if tweets < tweetsPerRequest || elapsed <= 0 {
+		return
+	}
+	requests := math.Ceil(float64(tweets) / tweetsPerRequest)
+	perRequest := elapsed.Seconds() / requests
+
+	fetchStatsMu.Lock()
+	defer fetchStatsMu.Unlock()
+	stats := loadFetchStats()
+	kind := fetchStatsKind(timelineType)
+	stat := stats[kind]
+	if stat.Samples == 0 {
+		stat.SecondsPerRequest = perRequest
+	} else {
+		stat.SecondsPerRequest = fetchStatsSmoothing*perRequest + (1-fetchStatsSmoothing)*stat.SecondsPerRequest
+	}
+	stat.Samples++
+	stats[kind] = stat
+
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(GetAppDataDir(), 0755); err != nil {
+		return
+	}
+	os.WriteFile(getFetchStatsPath(), data, 0644)
+}
+
+// requestLatency returns the average request latency of a kind of fetch and where it comes from
+func requestLatency(timelineType string) (float64, string) {
+	fetchStatsMu.Lock()
+	defer fetchStatsMu.Unlock()
+	if stat, ok := loadFetchStats()[fetchStatsKind(timelineType)]; ok && stat.Samples > 0 {
+		return stat.SecondsPerRequest, EstimateSourceHistory
+	}
+	return defaultRequestTime.Seconds(), EstimateSourceDefault
+}
+
+// storedAccountSize returns the size of an account from its stored timelines, 0 if unknown
+func storedAccountSize(username string, textOnly bool) int {
+	accounts, err := GetAllAccounts()
+	if err != nil {
+		return 0
+	}
+	size := 0
+	for _, acc := range accounts {
+		if !strings.EqualFold(acc.Username, username) {
+			continue
+		}
+		stored, err := GetAccountByID(acc.ID)
+		if err != nil {
+			continue
+		}
+		var response TwitterResponse
+		if json.Unmarshal([]byte(stored.ResponseJSON), &response) != nil {
+			continue
+		}
+		count := response.AccountInfo.MediaCount
+		if textOnly {
+			count = response.AccountInfo.StatusesCount
+		}
+		if count == 0 {
+			count = acc.TotalMedia
+		}
+		size = max(size, count)
+	}
+	return size
+}
+
+// EstimateJob estimates the requests, duration and rate limit waits of a fetch
+func EstimateJob(req JobEstimateRequest) (*JobEstimate, error) {
+	username := cleanUsername(req.Username)
+	textOnly := req.MediaType == "text"
+	estimate := &JobEstimate{Username: username, Items: req.MediaCount, ItemsSource: EstimateSourceGiven}
+
+	if estimate.Items <= 0 && username != "" {
+		estimate.Items = storedAccountSize(username, textOnly)
+		estimate.ItemsSource = EstimateSourceStored
+	}
+	if estimate.Items <= 0 {
+		if username == "" || req.AuthToken == "" {
+			return nil, fmt.Errorf("account size unknown: fetch the account once or pass media_count")
+		}
+		// One item is enough to get the account's counts
+		response, err := ExtractTimeline(TimelineRequest{Username: username, AuthToken: req.AuthToken, TimelineType: "media", BatchSize: 1})
+		if err != nil {
+			return nil, fmt.Errorf("failed to look up @%s: %v", username, err)
+		}
+		estimate.Items = response.AccountInfo.MediaCount
+		if textOnly {
+			estimate.Items = response.AccountInfo.StatusesCount
+		}
+		estimate.ItemsSource = EstimateSourceFetched
+	}
+
+	estimate.Requests = int(math.Ceil(float64(estimate.Items) / tweetsPerRequest))
+	estimate.Pages = 1
+	if req.BatchSize > 0 {
+		estimate.Pages = max(1, int(math.Ceil(float64(estimate.Items)/float64(req.BatchSize))))
+	}
+	estimate.SecondsPerRequest, estimate.LatencySource = requestLatency(req.TimelineType)
+
+	estimate.RateBudget = timelineRateBudget
+	if req.TimelineType == fetchStatsSearchKind {
+		estimate.RateBudget = searchRateBudget
+	}
+	estimate.RateWindows = max(1, int(math.Ceil(float64(estimate.Requests)/float64(estimate.RateBudget))))
+	estimate.ExceedsRateLimit = estimate.Requests > estimate.RateBudget
+
+	// Requests run back to back, a full budget waits for the next window
+	duration := float64(estimate.Requests) * estimate.SecondsPerRequest
+	if estimate.ExceedsRateLimit {
+		waited := float64(estimate.RateWindows-1) * rateLimitWindow.Seconds()
+		last := float64(estimate.Requests-(estimate.RateWindows-1)*estimate.RateBudget) * estimate.SecondsPerRequest
+		duration = math.Max(duration, waited+last)
+	}
+	estimate.DurationSeconds = int(math.Ceil(duration))
+	return estimate, nil
+}
@@ -1,12 +1,13 @@
 package backend
 
 import (
+	"archive/tar"
 	"archive/zip"
+	"compress/gzip"
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
 	"io"
-	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -24,19 +25,9 @@ const (
 	exiftoolUnixURL = "https://sourceforge.net/projects/exiftool/files/Image-ExifTool-13.43.tar.gz/download"
 )
 
-// ExifTool SHA256 hashes for verification
-// Note: Hashes will be calculated during download if not provided
-// For now, we'll skip hash verification for version 13.43 (can be added later if needed)
-const (
-	exiftoolWindows64Hash = "" // Hash not provided, will skip verification
-	exiftoolWindows32Hash = "" // Hash not provided, will skip verification
-	exiftoolUnixHash      = "" // Hash not provided, will skip verification
-)
-
 // GetExifToolPath returns the path to exiftool binary
 func GetExifToolPath() string {
-	homeDir, _ := os.UserHomeDir()
-	baseDir := filepath.Join(homeDir, ".twitterxmediabatchdownloader")
+	baseDir := GetAppDataDir()
 
 	switch runtime.GOOS {
 	case "windows":
@@ -60,6 +51,12 @@ func GetExifToolPath() string {
 
 // IsExifToolInstalled checks if exiftool is available (either system-installed or bundled)
 func IsExifToolInstalled() bool {
+	// 0. A path set in the settings wins
+	if path := GetSettings().ExifToolPath; path != "" {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
 	// 1. Check if exiftool is available in system PATH
 	if path, err := exec.LookPath("exiftool"); err == nil && path != "" {
 		// Verify it's executable
@@ -98,6 +95,10 @@ func IsExifToolInstalled() bool {
 	// 3. Check our bundled exiftool
 	exiftoolPath := GetExifToolPath()
 	if _, err := os.Stat(exiftoolPath); err == nil {
+		if err := verifyToolBinary(exiftoolPath); err != nil {
+			fmt.Printf("Warning: not running exiftool: %v\n", err)
+			return false
+		}
 		// Test if it's executable by running version command
 		cmd := exec.Command(exiftoolPath, "-ver")
 		hideWindow(cmd)
@@ -144,97 +145,52 @@ func is64Bit() bool {
 }
 
 // DownloadExifTool downloads exiftool binary for current platform
-func DownloadExifTool(progressCallback func(downloaded, total int64)) error {
-	var downloadURL string
-	var expectedHash string
+// mirror is an optional custom mirror tried before the built-in download locations
+func DownloadExifTool(progressCallback func(downloaded, total int64), mirror string) error {
+	var src toolSource
 
 	switch runtime.GOOS {
 	case "windows":
 		if is64Bit() {
-			downloadURL = exiftoolWindows64URL
-			expectedHash = exiftoolWindows64Hash
+			src = exiftoolWindows64Source
 		} else {
-			downloadURL = exiftoolWindows32URL
-			expectedHash = exiftoolWindows32Hash
+			src = exiftoolWindows32Source
 		}
 	case "linux", "darwin":
-		// Linux and macOS use the same tar.gz archive
-		downloadURL = exiftoolUnixURL
-		expectedHash = exiftoolUnixHash
+		// Linux and macOS use the same tar.gz archive, a Perl script
+		if !IsPerlAvailable() {
+			return errPerlMissing
+		}
+		src = exiftoolUnixSource
 	default:
 		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
 	}
 
-	// Create temp file for download
-	tempFile, err := os.CreateTemp("", "exiftool-*")
+	// Only pinned archives listed in the signed tool manifest are installed (verified before extraction)
+	tempPath, pinned, err := downloadTool("exiftool", src, mirror, progressCallback)
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %v", err)
+		return err
 	}
-	tempPath := tempFile.Name()
 	defer os.Remove(tempPath)
-	defer tempFile.Close()
 
-	// Download file
-	resp, err := http.Get(downloadURL)
+	// Extract into a staging folder, the installed exiftool is only replaced once the new one runs
+	staging, err := newToolStaging(".staging")
 	if err != nil {
-		return fmt.Errorf("failed to download exiftool: %v", err)
-	}
-	defer resp.Body.Close()
-
-	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download exiftool: status %d", resp.StatusCode)
-	}
-
-	// Copy with progress
-	total := resp.ContentLength
-	var downloaded int64
-	buf := make([]byte, 32*1024)
-
-	for {
-		n, err := resp.Body.Read(buf)
-		if n > 0 {
-			_, writeErr := tempFile.Write(buf[:n])
-			if writeErr != nil {
-				return fmt.Errorf("failed to write temp file: %v", writeErr)
-			}
-			downloaded += int64(n)
-			if progressCallback != nil {
-				progressCallback(downloaded, total)
-			}
-		}
-		if err == io.EOF {
-			break
-		}
-		if err != nil {
-			return fmt.Errorf("failed to download: %v", err)
-		}
-	}
-	tempFile.Close()
-
-	// Verify hash before extraction
-	if expectedHash != "" {
-		if err := verifyHash(tempPath, expectedHash); err != nil {
-			return fmt.Errorf("hash verification failed: %v", err)
-		}
-	}
-
-	// Extract exiftool binary
-	exiftoolPath := GetExifToolPath()
-	baseDir := filepath.Dir(exiftoolPath)
-	if err := os.MkdirAll(baseDir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %v", err)
+		return err
 	}
+	defer os.RemoveAll(staging)
 
 	switch runtime.GOOS {
 	case "windows":
-		return extractExifToolFromZip(tempPath, exiftoolPath)
+		err = extractExifToolFromZip(tempPath, filepath.Join(staging, "exiftool.exe"))
 	case "linux", "darwin":
-		// For Linux/macOS, we need to extract and build
-		// For simplicity, we'll extract the exiftool script from tar.gz
-		return extractExifToolFromTarGz(tempPath, exiftoolPath)
+		// For Linux/macOS, the whole Image-ExifTool-VERSION folder is needed
+		err = extractExifToolFromTarGz(tempPath, filepath.Join(staging, "exiftool"))
 	}
-
-	return nil
+	if err != nil {
+		return err
+	}
+	return installStagedExifTool(staging, pinned)
 }
 
 // extractExifToolFromZip extracts exiftool from Windows zip archive
@@ -329,16 +285,12 @@ func extractExifToolFromZip(zipPath, destPath string) error {
 func extractExifToolFromTarGz(tarGzPath, destPath string) error {
 	// For Linux/macOS, ExifTool is a Perl script that requires the lib directory
 	// We'll extract the entire folder and use exiftool directly from Image-ExifTool-VERSION/exiftool
-	// This is simpler than implementing tar.gz extraction in Go
 
 	baseDir := filepath.Dir(destPath)
 
-	// Use system tar command to extract
-	cmd := exec.Command("tar", "-xzf", tarGzPath, "-C", baseDir)
-	hideWindow(cmd)
-	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return fmt.Errorf("failed to extract tar.gz: %v, output: %s", err, string(output))
+	// Extracted in Go, a tar command isn't available everywhere (containers, Windows shells)
+	if err := extractTarGz(tarGzPath, baseDir); err != nil {
+		return err
 	}
 
 	// Find the extracted exiftool script
@@ -356,7 +308,7 @@ func extractExifToolFromTarGz(tarGzPath, destPath string) error {
 		exiftoolScript = filepath.Join(extractedDir, "exiftool")
 	} else {
 		// Fallback: try common version or alternative location
-		extractedDir = filepath.Join(baseDir, "Image-ExifTool-13.43")
+		extractedDir = filepath.Join(baseDir, "Image-ExifTool-"+exiftoolVersion)
 		exiftoolScript = filepath.Join(extractedDir, "exiftool")
 	}
 
@@ -381,3 +333,58 @@ func extractExifToolFromTarGz(tarGzPath, destPath string) error {
 
 	return nil
 }
+
+// extractTarGz extracts the directories and regular files of a tar.gz archive into destDir
+func extractTarGz(tarGzPath, destDir string) error {
+	file, err := os.Open(tarGzPath)
+	if err != nil {
+		return fmt.Errorf("failed to open tar.gz: %v", err)
+	}
+	defer file.Close()
+
+	gzReader, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to create gzip reader: %v", err)
+	}
+	defer gzReader.Close()
+
+	tarReader := tar.NewReader(gzReader)
+	for {
+		header, err := tarReader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar: %v", err)
+		}
+
+		// Entries can't be written outside destDir
+		targetPath := filepath.Join(destDir, filepath.FromSlash(header.Name))
+		rel, err := filepath.Rel(destDir, targetPath)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return fmt.Errorf("invalid path in archive: %s", header.Name)
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(targetPath, 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %v", err)
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(targetPath), 0755); err != nil {
+				return fmt.Errorf("failed to create directory: %v", err)
+			}
+			out, err := os.OpenFile(targetPath, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.FileMode(header.Mode).Perm()|0600)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %v", err)
+			}
+			_, err = io.Copy(out, tarReader)
+			out.Close()
+			if err != nil {
+				return fmt.Errorf("failed to extract %s: %v", header.Name, err)
+			}
+		default:
+			// Links and special files aren't needed by exiftool
+		}
+	}
+}
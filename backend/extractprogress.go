@@ -0,0 +1,199 @@
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+// Extraction progress: pages, received entries, resume cursor and an estimate of the rate limit
+// budget left (counted against estimate.go), reported at most every extractProgressInterval.
+// Profile timelines also report the expected tweet count from the account's counts.
+
+// extractProgressInterval is the shortest time between two reports of received items
+const extractProgressInterval = 500 * time.Millisecond
+
+// ExtractProgress is a progress report of a running extraction
+type ExtractProgress struct {
+	Username       string  `json:"username"`
+	Pages          int     `json:"pages"`            // Pages (API requests) fetched
+	Items          int     `json:"items"`            // Media and tweets received
+	Media          int     `json:"media"`            // Of those, media
+	Tweets         int     `json:"tweets"`           // and tweets
+	Expected       int     `json:"expected"`         // Tweets the timeline holds according to the account's counts, 0 = unknown
+	Cursor         string  `json:"cursor,omitempty"` // Cursor to resume from if the fetch is stopped now
+	RateBudget     int     `json:"rate_budget"`      // Requests allowed per rate limit window
+	RateRemaining  int     `json:"rate_remaining"`   // Estimated requests left in the current window
+	RateResetAt    string  `json:"rate_reset_at,omitempty"`
+	ElapsedSeconds float64 `json:"elapsed_seconds"`
+	Done           bool    `json:"done"`
+}
+
+// rateWindow counts the requests of one kind of fetch in the current rate limit window
+type rateWindow struct {
+	start    time.Time
+	requests int
+}
+
+var (
+	rateWindowsMu sync.Mutex
+	rateWindows   = make(map[string]*rateWindow)
+)
+
+// rateBudget returns the requests allowed per window for a kind of fetch (see fetchStatsKind)
+func rateBudget(kind string) int {
+	if kind == fetchStatsSearchKind {
+		return searchRateBudget
+	}
+	return timelineRateBudget
+}
+
+// currentRateWindow returns the window of a kind of fetch, starting a new one if it has reset
+// (rateWindowsMu must be held)
+func currentRateWindow(kind string, now time.Time) *rateWindow {
+	w := rateWindows[kind]
+	if w == nil || !now.Before(w.start.Add(rateLimitWindow)) {
+		w = &rateWindow{start: now}
+		rateWindows[kind] = w
+	}
+	return w
+}
+
+// countRateRequests adds requests to the window of a kind of fetch and returns the estimated
+// requests left and when the window resets
+func countRateRequests(kind string, requests int) (int, time.Time) {
+	rateWindowsMu.Lock()
+	defer rateWindowsMu.Unlock()
+	w := currentRateWindow(kind, time.Now())
+	w.requests += requests
+	return max(0, rateBudget(kind)-w.requests), w.start.Add(rateLimitWindow)
+}
+
+// exhaustRateWindow marks the window of a kind of fetch as used up until retryAt
+func exhaustRateWindow(kind string, retryAt time.Time) {
+	rateWindowsMu.Lock()
+	defer rateWindowsMu.Unlock()
+	rateWindows[kind] = &rateWindow{start: retryAt.Add(-rateLimitWindow), requests: rateBudget(kind)}
+}
+
+// timelineSizeCount returns the count of an account that tells the number of tweets of a
+// timeline type, nil if none does
+func timelineSizeCount(timelineType string) func(UserInfo) int {
+	switch timelineType {
+	case "media":
+		return func(u UserInfo) int { return u.MediaCount }
+	case "timeline", "tweets", "with_replies":
+		return func(u UserInfo) int { return u.StatusesCount }
+	}
+	return nil
+}
+
+// extractProgress tracks the progress of one extraction and reports it to a callback
+type extractProgress struct {
+	kind       string             // Rate limit kind, see fetchStatsKind
+	sizeCount  func(UserInfo) int // Expected size from the account, see timelineSizeCount
+	report     func(ExtractProgress)
+	started    time.Time
+	mu         sync.Mutex
+	state      ExtractProgress
+	lastReport time.Time
+}
+
+// newExtractProgress returns the tracker of an extraction; report may be nil, the requests are
+// still counted against the rate limit window
+func newExtractProgress(username, kind string, report func(ExtractProgress)) *extractProgress {
+	remaining, resetAt := countRateRequests(kind, 0)
+	return &extractProgress{
+		kind:    kind,
+		report:  report,
+		started: time.Now(),
+		state: ExtractProgress{
+			Username:      username,
+			RateBudget:    rateBudget(kind),
+			RateRemaining: remaining,
+			RateResetAt:   resetAt.UTC().Format(time.RFC3339),
+		},
+	}
+}
+
+// handler wraps a stream handler, tracking what passes through it
+func (p *extractProgress) handler(h cliStreamHandler) cliStreamHandler {
+	return cliStreamHandler{
+		onMedia: func(item CLIMediaItem) {
+			p.addItem(&item.User)
+			if h.onMedia != nil {
+				h.onMedia(item)
+			}
+		},
+		onMetadata: func(meta TweetMetadata) {
+			p.addItem(nil)
+			if h.onMetadata != nil {
+				h.onMetadata(meta)
+			}
+		},
+		onCursor: func(cursor string) {
+			p.addPage(cursor)
+			if h.onCursor != nil {
+				h.onCursor(cursor)
+			}
+		},
+	}
+}
+
+// addItem counts a received media of the account user, or a tweet with a nil user
+func (p *extractProgress) addItem(user *UserInfo) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.Items++
+	if user == nil {
+		p.state.Tweets++
+	} else {
+		p.state.Media++
+		if p.state.Expected == 0 && p.sizeCount != nil {
+			p.state.Expected = p.sizeCount(*user)
+		}
+	}
+	if time.Since(p.lastReport) >= extractProgressInterval {
+		p.send()
+	}
+}
+
+// addPage counts a fetched page, the extractor reports the cursor of the next one
+func (p *extractProgress) addPage(cursor string) {
+	remaining, resetAt := countRateRequests(p.kind, 1)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.state.Pages++
+	p.state.Cursor = cursor
+	p.state.RateRemaining = remaining
+	p.state.RateResetAt = resetAt.UTC().Format(time.RFC3339)
+	p.send()
+}
+
+// finish sends the last report of a run that ended with err
+func (p *extractProgress) finish(err error) {
+	var retryAt time.Time
+	if err != nil {
+		retryAt = RetryAtFromMessage(err.Error())
+	}
+	if !retryAt.IsZero() {
+		exhaustRateWindow(p.kind, retryAt)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !retryAt.IsZero() {
+		p.state.RateRemaining = 0
+		p.state.RateResetAt = retryAt.UTC().Format(time.RFC3339)
+	}
+	p.state.Done = true
+	p.send()
+}
+
+// send reports the current state (p.mu must be held)
+func (p *extractProgress) send() {
+	p.lastReport = time.Now()
+	if p.report == nil {
+		return
+	}
+	p.state.ElapsedSeconds = time.Since(p.started).Seconds()
+	p.report(p.state)
+}
@@ -5,12 +5,16 @@ import (
 	"archive/zip"
 	"fmt"
 	"io"
+	"io/fs"
 	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/ulikunitz/xz"
 )
@@ -20,12 +24,12 @@ const (
 	ffmpegWindowsURL = "https://github.com/BtbN/FFmpeg-Builds/releases/download/latest/ffmpeg-master-latest-win64-gpl.zip"
 	ffmpegLinuxURL   = "https://github.com/BtbN/FFmpeg-Builds/releases/download/latest/ffmpeg-master-latest-linux64-gpl.tar.xz"
 	ffmpegMacOSURL   = "https://evermeet.cx/ffmpeg/getrelease/ffmpeg/zip"
+	ffprobeMacOSURL  = "https://evermeet.cx/ffmpeg/getrelease/ffprobe/zip" // The other builds include ffprobe
 )
 
 // GetFFmpegPath returns the path to ffmpeg binary
 func GetFFmpegPath() string {
-	homeDir, _ := os.UserHomeDir()
-	baseDir := filepath.Join(homeDir, ".twitterxmediabatchdownloader")
+	baseDir := GetAppDataDir()
 
 	switch runtime.GOOS {
 	case "windows":
@@ -35,8 +39,22 @@ func GetFFmpegPath() string {
 	}
 }
 
+// ffmpegBinary returns the ffmpeg to run: the one set in the settings, otherwise the bundled one
+func ffmpegBinary() string {
+	if path := GetSettings().FFmpegPath; path != "" {
+		return path
+	}
+	return GetFFmpegPath()
+}
+
 // IsFFmpegInstalled checks if ffmpeg is available (either system-installed or bundled)
 func IsFFmpegInstalled() bool {
+	// 0. A path set in the settings wins
+	if path := GetSettings().FFmpegPath; path != "" {
+		_, err := os.Stat(path)
+		return err == nil
+	}
+
 	// 1. Check if ffmpeg is available in system PATH
 	if path, err := exec.LookPath("ffmpeg"); err == nil && path != "" {
 		cmd := exec.Command("ffmpeg", "-version")
@@ -75,45 +93,115 @@ func IsFFmpegInstalled() bool {
 	// 3. Check our bundled ffmpeg
 	ffmpegPath := GetFFmpegPath()
 	if _, err := os.Stat(ffmpegPath); err == nil {
+		if err := verifyToolBinary(ffmpegPath); err != nil {
+			fmt.Printf("Warning: not running ffmpeg: %v\n", err)
+			return false
+		}
 		return true
 	}
 	return false
 }
 
 // DownloadFFmpeg downloads ffmpeg binary for current platform
-func DownloadFFmpeg(progressCallback func(downloaded, total int64)) error {
-	var downloadURL string
+// mirror is an optional custom mirror tried before the built-in download locations
+func DownloadFFmpeg(progressCallback func(downloaded, total int64), mirror string) error {
+	var src toolSource
 
 	switch runtime.GOOS {
 	case "windows":
-		downloadURL = ffmpegWindowsURL
+		src = ffmpegWindowsSource
 	case "linux":
-		downloadURL = ffmpegLinuxURL
+		src = ffmpegLinuxSource
 	case "darwin":
-		downloadURL = ffmpegMacOSURL
+		src = ffmpegMacOSSource
 	default:
 		return fmt.Errorf("unsupported platform: %s", runtime.GOOS)
 	}
 
-	// Create temp file for download
-	tempFile, err := os.CreateTemp("", "ffmpeg-*")
+	// Only pinned archives listed in the signed tool manifest are installed
+	tempPath, pinned, err := downloadTool("ffmpeg", src, mirror, progressCallback)
 	if err != nil {
-		return fmt.Errorf("failed to create temp file: %v", err)
+		return err
 	}
-	tempPath := tempFile.Name()
 	defer os.Remove(tempPath)
-	defer tempFile.Close()
 
-	// Download file
-	resp, err := http.Get(downloadURL)
+	// Extract into a staging folder, the installed binaries are only replaced once the new ones run
+	staging, err := newToolStaging(".staging")
 	if err != nil {
-		return fmt.Errorf("failed to download ffmpeg: %v", err)
+		return err
+	}
+	defer os.RemoveAll(staging)
+	ffmpegPath := GetFFmpegPath()
+	stagedFFmpeg := filepath.Join(staging, filepath.Base(ffmpegPath))
+	stagedFFprobe := filepath.Join(staging, filepath.Base(bundledFFprobePath()))
+
+	probePinned := pinned
+	var probeErr error
+	switch runtime.GOOS {
+	case "windows":
+		if err := extractFromZip(tempPath, stagedFFmpeg); err != nil {
+			return err
+		}
+		probeErr = extractFromZip(tempPath, stagedFFprobe)
+	case "darwin":
+		if err := extractFromZip(tempPath, stagedFFmpeg); err != nil {
+			return err
+		}
+		var probePath string
+		probePath, probePinned, probeErr = downloadTool("ffprobe", ffprobeMacOSSource, mirror, nil)
+		if probeErr == nil {
+			defer os.Remove(probePath)
+			probeErr = extractFromZip(probePath, stagedFFprobe)
+		}
+	case "linux":
+		if err := extractFromTarXz(tempPath, stagedFFmpeg); err != nil {
+			return err
+		}
+		probeErr = extractFromTarXz(tempPath, stagedFFprobe)
+	}
+
+	if err := installStaged(stagedFFmpeg, ffmpegPath, pinned, "-version"); err != nil {
+		return err
+	}
+	installFFprobe(probeErr, stagedFFprobe, probePinned)
+	return nil
+}
+
+// installFFprobe installs the extracted ffprobe, or logs a failed extraction
+// ffmpeg works without it, only media probing is unavailable
+func installFFprobe(err error, staged string, pinned ToolManifestEntry) {
+	if err == nil {
+		err = installStaged(staged, bundledFFprobePath(), pinned, "-version")
+	}
+	if err != nil {
+		fmt.Printf("Warning: failed to install ffprobe: %v\n", err)
+	}
+}
+
+// downloadToTemp downloads url to a temporary file and returns its path
+func downloadToTemp(url string, progressCallback func(downloaded, total int64)) (string, error) {
+	// Unresponsive mirrors give up early so the next one can be tried
+	client := &http.Client{Transport: audited(&http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ResponseHeaderTimeout: 30 * time.Second,
+	}, AuditPurposeTool)}
+	resp, err := client.Get(url)
+	if err != nil {
+		return "", err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return fmt.Errorf("failed to download ffmpeg: status %d", resp.StatusCode)
+		return "", fmt.Errorf("status %d", resp.StatusCode)
+	}
+
+	// Create temp file for download
+	tempFile, err := os.CreateTemp("", "ffmpeg-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp file: %v", err)
 	}
+	tempPath := tempFile.Name()
+	defer tempFile.Close()
 
 	// Copy with progress
 	total := resp.ContentLength
@@ -125,7 +213,8 @@ func DownloadFFmpeg(progressCallback func(downloaded, total int64)) error {
 		if n > 0 {
 			_, writeErr := tempFile.Write(buf[:n])
 			if writeErr != nil {
-				return fmt.Errorf("failed to write temp file: %v", writeErr)
+				os.Remove(tempPath)
+				return "", fmt.Errorf("failed to write temp file: %v", writeErr)
 			}
 			downloaded += int64(n)
 			if progressCallback != nil {
@@ -136,30 +225,16 @@ func DownloadFFmpeg(progressCallback func(downloaded, total int64)) error {
 			break
 		}
 		if err != nil {
-			return fmt.Errorf("failed to download: %v", err)
+			os.Remove(tempPath)
+			return "", fmt.Errorf("failed to download: %v", err)
 		}
 	}
-	tempFile.Close()
-
-	// Extract ffmpeg binary
-	ffmpegPath := GetFFmpegPath()
-	baseDir := filepath.Dir(ffmpegPath)
-	if err := os.MkdirAll(baseDir, 0755); err != nil {
-		return fmt.Errorf("failed to create directory: %v", err)
-	}
-
-	switch runtime.GOOS {
-	case "windows", "darwin":
-		return extractFromZip(tempPath, ffmpegPath)
-	case "linux":
-		return extractFromTarXz(tempPath, ffmpegPath)
-	}
-
-	return nil
+	return tempPath, nil
 }
 
-// extractFromZip extracts ffmpeg from zip archive
+// extractFromZip extracts the binary named like destPath (ffmpeg, ffprobe) from zip archive
 func extractFromZip(zipPath, destPath string) error {
+	binary := filepath.Base(destPath)
 	r, err := zip.OpenReader(zipPath)
 	if err != nil {
 		return fmt.Errorf("failed to open zip: %v", err)
@@ -167,9 +242,9 @@ func extractFromZip(zipPath, destPath string) error {
 	defer r.Close()
 
 	for _, f := range r.File {
-		// Look for ffmpeg binary
+		// Look for the binary
 		name := filepath.Base(f.Name)
-		if name == "ffmpeg" || name == "ffmpeg.exe" {
+		if name == binary {
 			rc, err := f.Open()
 			if err != nil {
 				return fmt.Errorf("failed to open file in zip: %v", err)
@@ -195,11 +270,12 @@ func extractFromZip(zipPath, destPath string) error {
 		}
 	}
 
-	return fmt.Errorf("ffmpeg binary not found in archive")
+	return fmt.Errorf("%s binary not found in archive", binary)
 }
 
-// extractFromTarXz extracts ffmpeg from tar.xz archive
+// extractFromTarXz extracts the binary named like destPath (ffmpeg, ffprobe) from tar.xz archive
 func extractFromTarXz(tarXzPath, destPath string) error {
+	binary := filepath.Base(destPath)
 	file, err := os.Open(tarXzPath)
 	if err != nil {
 		return fmt.Errorf("failed to open tar.xz: %v", err)
@@ -222,9 +298,9 @@ func extractFromTarXz(tarXzPath, destPath string) error {
 			return fmt.Errorf("failed to read tar: %v", err)
 		}
 
-		// Look for ffmpeg binary
+		// Look for the binary
 		name := filepath.Base(header.Name)
-		if name == "ffmpeg" && header.Typeflag == tar.TypeReg {
+		if name == binary && header.Typeflag == tar.TypeReg {
 			out, err := os.Create(destPath)
 			if err != nil {
 				return fmt.Errorf("failed to create output file: %v", err)
@@ -240,14 +316,14 @@ func extractFromTarXz(tarXzPath, destPath string) error {
 		}
 	}
 
-	return fmt.Errorf("ffmpeg binary not found in archive")
+	return fmt.Errorf("%s binary not found in archive", binary)
 }
 
 // ConvertMP4ToGIF converts an MP4 file to GIF using ffmpeg
 // quality: "fast" for simple conversion, "better" for optimized palette
 // resolution: "original", "high" (800px), "medium" (600px), "low" (400px)
 func ConvertMP4ToGIF(inputPath, outputPath, quality, resolution string) error {
-	ffmpegPath := GetFFmpegPath()
+	ffmpegPath := ffmpegBinary()
 
 	if !IsFFmpegInstalled() {
 		return fmt.Errorf("ffmpeg not installed")
@@ -329,7 +405,8 @@ func ConvertMP4ToGIF(inputPath, outputPath, quality, resolution string) error {
 }
 
 // ConvertGIFsInFolder converts all MP4 files in gifs folder to actual GIF format
-func ConvertGIFsInFolder(folderPath, quality, resolution string, deleteOriginal bool) (converted int, failed int, err error) {
+// workers is the number of ffmpeg processes run at once, 0 = one per CPU core
+func ConvertGIFsInFolder(folderPath, quality, resolution string, deleteOriginal bool, workers int) (converted int, failed int, err error) {
 	if !IsFFmpegInstalled() {
 		return 0, 0, fmt.Errorf("ffmpeg not installed")
 	}
@@ -346,6 +423,7 @@ func ConvertGIFsInFolder(folderPath, quality, resolution string, deleteOriginal
 		return 0, 0, fmt.Errorf("failed to read gifs folder: %v", err)
 	}
 
+	var inputs []string
 	for _, file := range files {
 		if file.IsDir() {
 			continue
@@ -355,21 +433,121 @@ func ConvertGIFsInFolder(folderPath, quality, resolution string, deleteOriginal
 		if !strings.HasSuffix(strings.ToLower(name), ".mp4") {
 			continue
 		}
+		inputs = append(inputs, filepath.Join(gifsFolder, name))
+	}
 
-		inputPath := filepath.Join(gifsFolder, name)
-		outputPath := filepath.Join(gifsFolder, strings.TrimSuffix(name, filepath.Ext(name))+".gif")
+	converted, failed = convertMP4sToGIF(inputs, quality, resolution, deleteOriginal, workers)
+	return converted, failed, nil
+}
 
-		if err := ConvertMP4ToGIF(inputPath, outputPath, quality, resolution); err != nil {
-			failed++
+// ConvertFilesToGIF converts the given MP4 files to GIF next to the originals
+// Folders in paths are searched recursively for MP4 files (e.g. a whole account folder)
+func ConvertFilesToGIF(paths []string, quality, resolution string, deleteOriginal bool, workers int) (converted int, failed int, err error) {
+	if !IsFFmpegInstalled() {
+		return 0, 0, fmt.Errorf("ffmpeg not installed")
+	}
+
+	inputs, err := collectMP4Files(paths)
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(inputs) == 0 {
+		return 0, 0, fmt.Errorf("no MP4 files found")
+	}
+
+	converted, failed = convertMP4sToGIF(inputs, quality, resolution, deleteOriginal, workers)
+	return converted, failed, nil
+}
+
+// collectMP4Files returns the MP4 files in paths, searching folders recursively
+func collectMP4Files(paths []string) ([]string, error) {
+	seen := make(map[string]bool)
+	var inputs []string
+	addInput := func(path string) {
+		if !strings.HasSuffix(strings.ToLower(path), ".mp4") || seen[path] {
+			return
+		}
+		seen[path] = true
+		inputs = append(inputs, path)
+	}
+
+	for _, path := range paths {
+		cleanPath := filepath.Clean(path)
+		info, err := os.Stat(cleanPath)
+		if err != nil {
+			return nil, fmt.Errorf("file not found: %s", cleanPath)
+		}
+		if !info.IsDir() {
+			if !strings.HasSuffix(strings.ToLower(cleanPath), ".mp4") {
+				return nil, fmt.Errorf("not an MP4 file: %s", cleanPath)
+			}
+			addInput(cleanPath)
 			continue
 		}
+		err = filepath.WalkDir(cleanPath, func(p string, d fs.DirEntry, err error) error {
+			if err != nil {
+				return nil // Skip unreadable entries
+			}
+			if !d.IsDir() {
+				addInput(p)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read folder: %v", err)
+		}
+	}
+	return inputs, nil
+}
 
+// convertMP4sToGIF converts MP4 files to GIFs next to them with a pool of ffmpeg processes
+// workers is the number of conversions run at once, 0 = one per CPU core
+func convertMP4sToGIF(inputs []string, quality, resolution string, deleteOriginal bool, workers int) (converted int, failed int) {
+	return runFFmpegPool(inputs, workers, func(inputPath string) error {
+		outputPath := strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + ".gif"
+		if err := ConvertMP4ToGIF(inputPath, outputPath, quality, resolution); err != nil {
+			return err
+		}
 		if deleteOriginal {
 			os.Remove(inputPath)
 		}
+		return nil
+	})
+}
 
-		converted++
+// runFFmpegPool runs convert on every input with a pool of workers
+// workers is the number of conversions run at once, 0 = one per CPU core
+func runFFmpegPool(inputs []string, workers int, convert func(inputPath string) error) (converted int, failed int) {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(inputs) {
+		workers = len(inputs)
 	}
 
-	return converted, failed, nil
+	// Each conversion is a separate ffmpeg process, so run several at once
+	inputChan := make(chan string, len(inputs))
+	for _, inputPath := range inputs {
+		inputChan <- inputPath
+	}
+	close(inputChan)
+
+	var convertedCount, failedCount int64
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for inputPath := range inputChan {
+				if err := convert(inputPath); err != nil {
+					atomic.AddInt64(&failedCount, 1)
+					continue
+				}
+				atomic.AddInt64(&convertedCount, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	return int(convertedCount), int(failedCount)
 }
@@ -0,0 +1,260 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Media probing
+//
+// ffprobe reads a file's container and streams without decoding them: duration, codecs and
+// resolution, and whether the file is truncated or otherwise damaged. Downloads can be checked
+// right after they finish (a cut-off video is removed and counted as failed, so the next run
+// fetches it again), and every result is kept in the library database by path, size and
+// modification time so unchanged files aren't probed twice.
+
+// MediaProbe describes a probed media file
+type MediaProbe struct {
+	Path       string  `json:"path"`
+	Size       int64   `json:"size"`
+	Format     string  `json:"format"`   // Container as reported by ffprobe, e.g. "mov,mp4,m4a,3gp,3g2,mj2"
+	Duration   float64 `json:"duration"` // Seconds, 0 for still images
+	VideoCodec string  `json:"video_codec,omitempty"`
+	AudioCodec string  `json:"audio_codec,omitempty"`
+	Width      int     `json:"width"`
+	Height     int     `json:"height"`
+	Corrupt    bool    `json:"corrupt"`
+	Problem    string  `json:"problem,omitempty"` // Why the file is considered corrupt
+	ProbedAt   string  `json:"probed_at"`
+}
+
+// ffprobeOutput is the part of ffprobe's JSON output that's used
+type ffprobeOutput struct {
+	Format struct {
+		FormatName string `json:"format_name"`
+		Duration   string `json:"duration"`
+	} `json:"format"`
+	Streams []struct {
+		CodecType     string `json:"codec_type"`
+		CodecName     string `json:"codec_name"`
+		Width         int    `json:"width"`
+		Height        int    `json:"height"`
+		NbReadPackets string `json:"nb_read_packets"`
+	} `json:"streams"`
+}
+
+// GetFFprobePath returns the path to the ffprobe binary: the one set in the settings, the bundled
+// one if it was downloaded with ffmpeg, otherwise the one in PATH
+func GetFFprobePath() string {
+	if path := GetSettings().FFprobePath; path != "" {
+		return path
+	}
+	bundled := bundledFFprobePath()
+	if _, err := os.Stat(bundled); err == nil {
+		return bundled
+	}
+	if path, err := exec.LookPath("ffprobe"); err == nil {
+		return path
+	}
+	return bundled
+}
+
+// bundledFFprobePath returns where DownloadFFmpeg installs ffprobe, next to ffmpeg
+func bundledFFprobePath() string {
+	if runtime.GOOS == "windows" {
+		return filepath.Join(filepath.Dir(GetFFmpegPath()), "ffprobe.exe")
+	}
+	return filepath.Join(filepath.Dir(GetFFmpegPath()), "ffprobe")
+}
+
+// IsFFprobeInstalled checks if ffprobe is available (either system-installed or bundled)
+func IsFFprobeInstalled() bool {
+	// Also adds common Unix locations to PATH, where ffprobe is installed alongside ffmpeg
+	IsFFmpegInstalled()
+
+	path := GetFFprobePath()
+	if path == bundledFFprobePath() {
+		if err := verifyToolBinary(path); err != nil {
+			if !os.IsNotExist(err) {
+				fmt.Printf("Warning: not running ffprobe: %v\n", err)
+			}
+			return false
+		}
+	}
+	cmd := exec.Command(path, "-version")
+	hideWindow(cmd)
+	return cmd.Run() == nil
+}
+
+// ProbeMedia returns the duration, codecs, resolution and corruption status of a media file
+// Results are cached in the database until the file's size or modification time changes
+func ProbeMedia(path string) (MediaProbe, error) {
+	if !IsFFprobeInstalled() {
+		return MediaProbe{}, fmt.Errorf("ffprobe not installed")
+	}
+	return probeMedia(path)
+}
+
+// probeMedia is ProbeMedia without the ffprobe check, for callers that checked once up front
+func probeMedia(path string) (MediaProbe, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return MediaProbe{}, fmt.Errorf("file not found: %s", path)
+	}
+	if info.IsDir() {
+		return MediaProbe{}, fmt.Errorf("not a file: %s", path)
+	}
+	if probe, ok := loadMediaProbe(path, info); ok {
+		return probe, nil
+	}
+
+	probe, err := runFFprobe(path)
+	if err != nil {
+		return MediaProbe{}, err
+	}
+	probe.Size = info.Size()
+	probe.ProbedAt = time.Now().Format(time.RFC3339)
+	if err := saveMediaProbe(probe, info); err != nil {
+		fmt.Printf("Warning: failed to save media probe: %v\n", err)
+	}
+	return probe, nil
+}
+
+// runFFprobe probes a file; damage ffprobe can detect is reported in the result, not as an error
+func runFFprobe(path string) (MediaProbe, error) {
+	// -count_packets demuxes the whole file (without decoding), which catches truncated downloads
+	cmd := exec.Command(GetFFprobePath(),
+		"-v", "error",
+		"-count_packets",
+		"-show_entries", "format=format_name,duration:stream=codec_type,codec_name,width,height,nb_read_packets",
+		"-of", "json",
+		path,
+	)
+	hideWindow(cmd)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+
+	probe := MediaProbe{Path: path}
+	var output ffprobeOutput
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		if runErr == nil {
+			return probe, fmt.Errorf("failed to parse ffprobe output: %v", err)
+		}
+		// ffprobe couldn't open the file as media at all
+		probe.Corrupt = true
+		probe.Problem = ffprobeProblem(stderr.String(), runErr)
+		return probe, nil
+	}
+
+	probe.Format = output.Format.FormatName
+	probe.Duration, _ = strconv.ParseFloat(output.Format.Duration, 64)
+	emptyVideo := false
+	for _, stream := range output.Streams {
+		switch stream.CodecType {
+		case "video":
+			if probe.VideoCodec == "" {
+				probe.VideoCodec = stream.CodecName
+				probe.Width = stream.Width
+				probe.Height = stream.Height
+				emptyVideo = stream.NbReadPackets == "0"
+			}
+		case "audio":
+			if probe.AudioCodec == "" {
+				probe.AudioCodec = stream.CodecName
+			}
+		}
+	}
+
+	switch {
+	case runErr != nil || stderr.Len() > 0:
+		probe.Corrupt = true
+		probe.Problem = ffprobeProblem(stderr.String(), runErr)
+	case probe.VideoCodec == "":
+		probe.Corrupt = true
+		probe.Problem = "no video or image stream"
+	case emptyVideo:
+		probe.Corrupt = true
+		probe.Problem = "video stream has no frames"
+	}
+	return probe, nil
+}
+
+// ffprobeProblem returns the first error ffprobe logged
+func ffprobeProblem(stderr string, runErr error) string {
+	for _, line := range strings.Split(stderr, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			return line
+		}
+	}
+	if runErr != nil {
+		return runErr.Error()
+	}
+	return "unreadable"
+}
+
+// validateDownload probes a downloaded file and removes it if it's corrupt
+// Returns false for removed files; files that can't be probed are kept
+func validateDownload(path string) bool {
+	probe, err := probeMedia(path)
+	if err != nil || !probe.Corrupt {
+		return true
+	}
+	fmt.Printf("Removed corrupt download %s: %s\n", filepath.Base(path), probe.Problem)
+	os.Remove(path)
+	return false
+}
+
+// loadMediaProbe returns the stored probe of a file if the file hasn't changed since
+func loadMediaProbe(path string, info os.FileInfo) (MediaProbe, bool) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return MediaProbe{}, false
+		}
+	}
+
+	probe := MediaProbe{Path: path}
+	var corrupt int
+	err := db.QueryRow(`
+		SELECT size, format, duration, video_codec, audio_codec, width, height, corrupt, problem, probed_at
+		FROM media_probes WHERE path = ? AND size = ? AND mod_time = ?
+	`, path, info.Size(), info.ModTime().UnixNano()).Scan(
+		&probe.Size, &probe.Format, &probe.Duration, &probe.VideoCodec, &probe.AudioCodec,
+		&probe.Width, &probe.Height, &corrupt, &probe.Problem, &probe.ProbedAt,
+	)
+	if err != nil {
+		return MediaProbe{}, false
+	}
+	probe.Corrupt = corrupt == 1
+	return probe, true
+}
+
+// saveMediaProbe stores the probe of a file
+func saveMediaProbe(probe MediaProbe, info os.FileInfo) error {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return err
+		}
+	}
+
+	corrupt := 0
+	if probe.Corrupt {
+		corrupt = 1
+	}
+	_, err := db.Exec(`
+		INSERT OR REPLACE INTO media_probes
+			(path, size, mod_time, format, duration, video_codec, audio_codec, width, height, corrupt, problem, probed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`, probe.Path, info.Size(), info.ModTime().UnixNano(), probe.Format, probe.Duration, probe.VideoCodec,
+		probe.AudioCodec, probe.Width, probe.Height, corrupt, probe.Problem, probe.ProbedAt)
+	return err
+}
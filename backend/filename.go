@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filename templates
+//
+// Files are named {username}_{timestamp}_{tweet_id}_{index} by default. A template can reorder
+// these parts or use others. {sort_index} is the tweet's posting time in milliseconds followed
+// by the snowflake sequence bits, zero-padded to a fixed width: unlike the timestamp (whole
+// seconds) it differs for every tweet, and unlike the tweet ID it always has the same number of
+// digits, so files sort strictly chronologically by name. Useful for comic and photo-set accounts
+// that post several tweets within the same second. {index} ({num} without zero padding) is the
+// position of the media in its tweet as posted, 1 to 4 for photos, so a file gets the same name
+// when it is downloaded again on its own or with a filter that skips the other media of its tweet.
+
+// DefaultFilenameTemplate is the file name (without extension) used when no template is set
+const DefaultFilenameTemplate = "{username}_{timestamp}_{tweet_id}_{index}"
+
+// snowflakeSequenceMask covers the worker and sequence bits below the timestamp of a snowflake ID
+const snowflakeSequenceMask = 1<<22 - 1
+
+// SortIndex returns a fixed-width index that increases with the posting time of a tweet
+// Tweets from before snowflake IDs (2010) use their date, with the tweet ID as tie-breaker
+func SortIndex(tweetID int64, dateStr string) string {
+	created := TweetTime(tweetID)
+	sequence := tweetID & snowflakeSequenceMask
+	if created.IsZero() {
+		if t, err := ParseTweetDate(dateStr); err == nil {
+			created = t
+		} else {
+			created = time.Unix(0, 0)
+		}
+		sequence = tweetID % 10000000
+	}
+	return fmt.Sprintf("%013d%07d", created.UnixMilli(), sequence)
+}
+
+// renderFilename builds the file name (without extension) of a media item from a template
+func renderFilename(template string, item MediaItem, username string, mediaIndex int, zone string) string {
+	if strings.TrimSpace(template) == "" {
+		template = DefaultFilenameTemplate
+	}
+
+	timestamp := formatTimestamp(item.Date, zone)
+	replacer := strings.NewReplacer(
+		"{username}", username,
+		"{timestamp}", timestamp,
+		"{date}", timestamp[:8],
+		"{tweet_id}", fmt.Sprintf("%d", item.TweetID),
+		"{index}", fmt.Sprintf("%02d", mediaIndex),
+		"{num}", fmt.Sprintf("%d", mediaIndex),
+		"{type}", item.Type,
+		"{sort_index}", SortIndex(item.TweetID, item.Date),
+	)
+	// Templates can't create folders or escape the account folder
+	return replaceInvalidChars(replacer.Replace(template))
+}
+
+// filenamePatternTokens are the regexps of the template variables in file names
+var filenamePatternTokens = map[string]string{
+	"{username}":   `.*?`,
+	"{timestamp}":  `\d{8}_\d{6}`,
+	"{date}":       `\d{8}`,
+	"{tweet_id}":   `\d+`,
+	"{index}":      `\d{2,}`,
+	"{num}":        `\d+`,
+	"{type}":       `\w+`,
+	"{sort_index}": `\d{20}`,
+}
+
+// filenamePattern returns a regexp matching the file names of a template, with their collision
+// suffix, extension and EncryptedExt; nil if the names don't tell the tweet (no {tweet_id} or
+// {sort_index}). The first {tweet_id} and {sort_index} are captured under their names.
+func filenamePattern(template string) *regexp.Regexp {
+	if strings.TrimSpace(template) == "" {
+		template = DefaultFilenameTemplate
+	}
+	var b strings.Builder
+	b.WriteString("^")
+	captured := make(map[string]bool)
+	rest := template
+	for rest != "" {
+		start := strings.Index(rest, "{")
+		end := strings.Index(rest[max(start, 0):], "}")
+		if start < 0 || end < 0 {
+			b.WriteString(regexp.QuoteMeta(replaceInvalidChars(rest)))
+			break
+		}
+		b.WriteString(regexp.QuoteMeta(replaceInvalidChars(rest[:start])))
+		token := rest[start : start+end+1]
+		rest = rest[start+end+1:]
+
+		expr, ok := filenamePatternTokens[token]
+		if !ok {
+			b.WriteString(regexp.QuoteMeta(replaceInvalidChars(token)))
+			continue
+		}
+		if name := strings.Trim(token, "{}"); (name == "tweet_id" || name == "sort_index") && !captured[name] {
+			captured[name] = true
+			expr = "(?P<" + name + ">" + expr + ")"
+		}
+		b.WriteString(expr)
+	}
+	if !captured["tweet_id"] && !captured["sort_index"] {
+		return nil
+	}
+	fmt.Fprintf(&b, `(?:_\d+|_[0-9a-f]{%d})?\.[A-Za-z0-9]+(?:%s)?$`, collisionHashLength, regexp.QuoteMeta(EncryptedExt))
+	return regexp.MustCompile(b.String())
+}
+
+// filenameTweetID returns the tweet ID of a file name matched by a filenamePattern, false if it
+// doesn't match. Names with only a {sort_index} give the snowflake ID it was made from, 0 for
+// tweets from before snowflake IDs.
+func filenameTweetID(pattern *regexp.Regexp, name string) (int64, bool) {
+	if pattern == nil {
+		return 0, false
+	}
+	m := pattern.FindStringSubmatch(name)
+	if m == nil {
+		return 0, false
+	}
+	if i := pattern.SubexpIndex("tweet_id"); i > 0 {
+		tweetID, _ := strconv.ParseInt(m[i], 10, 64)
+		return tweetID, true
+	}
+	sortIndex := m[pattern.SubexpIndex("sort_index")]
+	millis, _ := strconv.ParseInt(sortIndex[:13], 10, 64)
+	sequence, _ := strconv.ParseInt(sortIndex[13:], 10, 64)
+	if millis < twitterEpochMillis || sequence > snowflakeSequenceMask {
+		return 0, true
+	}
+	return (millis-twitterEpochMillis)<<22 | sequence, true
+}
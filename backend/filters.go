@@ -0,0 +1,177 @@
+package backend
+
+import (
+	"strings"
+)
+
+// MediaFilter holds optional filters applied when building the download queue
+type MediaFilter struct {
+	Orientation    string  `json:"orientation,omitempty"`      // "", "all", "portrait", "landscape", "square"
+	MinAspectRatio float64 `json:"min_aspect_ratio,omitempty"` // Long side / short side (e.g. 1.77 for 16:9), 0 = no limit
+}
+
+// squareTolerance is how far width/height may differ (relative) and still count as square
+const squareTolerance = 0.02
+
+// IsEmpty returns true if no filter is configured
+func (f MediaFilter) IsEmpty() bool {
+	orientation := strings.ToLower(strings.TrimSpace(f.Orientation))
+	return (orientation == "" || orientation == "all") && f.MinAspectRatio <= 0
+}
+
+// Match checks if a media item passes the filter
+// Text tweets always pass; visual media without known dimensions are rejected
+// when a filter is active since we can't tell their shape
+func (f MediaFilter) Match(item MediaItem) bool {
+	if f.IsEmpty() || item.Type == "text" {
+		return true
+	}
+	if item.Width <= 0 || item.Height <= 0 {
+		return false
+	}
+
+	switch strings.ToLower(strings.TrimSpace(f.Orientation)) {
+	case "portrait":
+		if mediaOrientation(item.Width, item.Height) != "portrait" {
+			return false
+		}
+	case "landscape":
+		if mediaOrientation(item.Width, item.Height) != "landscape" {
+			return false
+		}
+	case "square":
+		if mediaOrientation(item.Width, item.Height) != "square" {
+			return false
+		}
+	}
+
+	if f.MinAspectRatio > 0 && aspectRatio(item.Width, item.Height) < f.MinAspectRatio {
+		return false
+	}
+
+	return true
+}
+
+// mediaOrientation classifies dimensions as "portrait", "landscape" or "square"
+func mediaOrientation(width, height int) string {
+	w, h := float64(width), float64(height)
+	if w >= h*(1-squareTolerance) && w <= h*(1+squareTolerance) {
+		return "square"
+	}
+	if w > h {
+		return "landscape"
+	}
+	return "portrait"
+}
+
+// aspectRatio returns the long side divided by the short side (always >= 1)
+func aspectRatio(width, height int) float64 {
+	w, h := float64(width), float64(height)
+	if w < h {
+		w, h = h, w
+	}
+	return w / h
+}
+
+// TimelineFilter holds optional filters applied when converting extractor results to timeline entries
+type TimelineFilter struct {
+	SourceInclude []string `json:"source_include,omitempty"` // Only keep tweets posted from these apps (e.g. "Twitter for iPhone")
+	SourceExclude []string `json:"source_exclude,omitempty"` // Drop tweets posted from these apps (e.g. "dlvr.it")
+	Verified      string   `json:"verified,omitempty"`       // "", "only" (verified authors only), "exclude" (non-verified only)
+	AuthorInclude []string `json:"author_include,omitempty"` // Only keep tweets by these handles (bookmarks, likes)
+	AuthorExclude []string `json:"author_exclude,omitempty"` // Drop tweets by these handles
+}
+
+// MatchMedia checks if a media item from the extractor passes the filter
+func (f TimelineFilter) MatchMedia(media CLIMediaItem) bool {
+	author := media.Author.Name
+	if author == "" {
+		author = media.User.Name
+	}
+	return f.matchSource(media.Source) && f.matchVerified(media.Author.Verified) && f.matchAuthor(author)
+}
+
+// MatchMetadata checks if a metadata-only (text) tweet passes the filter
+func (f TimelineFilter) MatchMetadata(meta TweetMetadata) bool {
+	return f.matchSource(meta.Source) && f.matchVerified(meta.Author.Verified) && f.matchAuthor(meta.Author.Name)
+}
+
+// matchVerified checks the author's verified badge against the verified filter
+// Mostly useful for bookmarks and likes where media comes from many different authors
+func (f TimelineFilter) matchVerified(verified bool) bool {
+	switch strings.ToLower(strings.TrimSpace(f.Verified)) {
+	case "only":
+		return verified
+	case "exclude":
+		return !verified
+	default:
+		return true
+	}
+}
+
+// matchSource checks the posting app against include/exclude lists (case-insensitive substring match)
+// Tweets with unknown source fail an include list but pass an exclude list
+func (f TimelineFilter) matchSource(source string) bool {
+	source = strings.ToLower(strings.TrimSpace(source))
+
+	if containsAnyFold(source, f.SourceExclude) {
+		return false
+	}
+	if hasNonEmpty(f.SourceInclude) && !containsAnyFold(source, f.SourceInclude) {
+		return false
+	}
+	return true
+}
+
+// matchAuthor checks the author's handle against the allow/deny lists (case-insensitive, "@" optional)
+// Tweets with unknown author fail an allow list but pass a deny list
+func (f TimelineFilter) matchAuthor(handle string) bool {
+	handle = normalizeHandle(handle)
+
+	if handle != "" && containsHandle(f.AuthorExclude, handle) {
+		return false
+	}
+	if hasNonEmpty(f.AuthorInclude) && (handle == "" || !containsHandle(f.AuthorInclude, handle)) {
+		return false
+	}
+	return true
+}
+
+// normalizeHandle lowercases a handle and strips the leading "@"
+func normalizeHandle(handle string) string {
+	return strings.ToLower(strings.TrimPrefix(strings.TrimSpace(handle), "@"))
+}
+
+// containsHandle reports whether handles contains the (normalized) handle
+func containsHandle(handles []string, handle string) bool {
+	for _, h := range handles {
+		if normalizeHandle(h) == handle {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAnyFold reports whether s (already lowercased) contains any non-empty pattern
+func containsAnyFold(s string, patterns []string) bool {
+	if s == "" {
+		return false
+	}
+	for _, p := range patterns {
+		p = strings.ToLower(strings.TrimSpace(p))
+		if p != "" && strings.Contains(s, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasNonEmpty reports whether the list has at least one non-blank value
+func hasNonEmpty(values []string) bool {
+	for _, v := range values {
+		if strings.TrimSpace(v) != "" {
+			return true
+		}
+	}
+	return false
+}
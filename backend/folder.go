@@ -53,6 +53,24 @@ func SelectFolderDialog(ctx context.Context, defaultPath string) (string, error)
 	return selectedPath, nil
 }
 
+// SelectVideoFilesDialog opens a multi-file selection dialog for MP4 videos
+func SelectVideoFilesDialog(ctx context.Context, defaultPath string) ([]string, error) {
+	if defaultPath == "" {
+		defaultPath = GetDefaultDownloadPath()
+	}
+
+	options := wailsRuntime.OpenDialogOptions{
+		Title:            "Select Videos",
+		DefaultDirectory: defaultPath,
+		Filters: []wailsRuntime.FileFilter{
+			{DisplayName: "MP4 Videos (*.mp4)", Pattern: "*.mp4"},
+		},
+	}
+
+	// Empty if the user cancelled
+	return wailsRuntime.OpenMultipleFilesDialog(ctx, options)
+}
+
 // CheckFolderExists checks if a folder exists at the given path
 func CheckFolderExists(basePath, username string) bool {
 	folderPath := filepath.Join(basePath, username)
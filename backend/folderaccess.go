@@ -0,0 +1,81 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Controlled Folder Access
+//
+// Windows Defender's ransomware protection ("Controlled Folder Access") stops unknown apps from
+// writing to Documents, Pictures, Videos and the other protected folders - Pictures being the
+// default download folder. Every file then fails with "access denied". The output folder gets a
+// test write before a job starts; when Controlled Folder Access is what blocks it, the job fails
+// with a folder_protected error explaining how to allow the app, or downloads to a folder outside
+// the protected ones if the fallback is enabled.
+
+// ErrFolderProtected is wrapped by errors for output folders blocked by Controlled Folder Access
+var ErrFolderProtected = errors.New("folder_protected")
+
+// ProtectedFolderError is returned when Controlled Folder Access blocks writing to the output folder
+type ProtectedFolderError struct {
+	Dir      string // Blocked output folder
+	Fallback string // Writable folder outside the protected folders, "" if none was found
+}
+
+func (e *ProtectedFolderError) Error() string {
+	msg := fmt.Sprintf("%v: Windows Controlled Folder Access is blocking downloads to %s. "+
+		"Allow the app in Windows Security > Virus & threat protection > Ransomware protection > "+
+		"Allow an app through Controlled folder access, or choose another download folder", ErrFolderProtected, e.Dir)
+	if e.Fallback != "" {
+		msg += fmt.Sprintf(" (e.g. %s)", e.Fallback)
+	}
+	return msg
+}
+
+func (e *ProtectedFolderError) Unwrap() error {
+	return ErrFolderProtected
+}
+
+// CheckOutputWritable makes sure files can be created in the output folder with a test write
+// Returns a *ProtectedFolderError if Controlled Folder Access blocks the folder
+func CheckOutputWritable(dir string) error {
+	err := testWrite(dir)
+	if err == nil {
+		return nil
+	}
+	if controlledFolderAccessBlocked(dir, err) {
+		return &ProtectedFolderError{Dir: dir, Fallback: protectedFallbackDir()}
+	}
+	return fmt.Errorf("can't write to %s: %v", dir, err)
+}
+
+// testWrite creates and removes a file in dir
+func testWrite(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".write-test-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	f.Close()
+	return os.Remove(name)
+}
+
+// protectedFallbackDir returns the user's Downloads folder if it can be written to
+// Downloads isn't one of the folders Controlled Folder Access protects by default
+func protectedFallbackDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	dir := filepath.Join(homeDir, "Downloads")
+	if testWrite(dir) != nil {
+		return ""
+	}
+	return dir
+}
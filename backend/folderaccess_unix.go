@@ -0,0 +1,8 @@
+//go:build !windows
+
+package backend
+
+// controlledFolderAccessBlocked is always false, Controlled Folder Access only exists on Windows
+func controlledFolderAccessBlocked(dir string, err error) bool {
+	return false
+}
@@ -0,0 +1,71 @@
+//go:build windows
+
+package backend
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"syscall"
+)
+
+// controlledFolderAccessBlocked reports whether a failed write to dir was blocked by Controlled Folder Access:
+// the write was denied, the feature is enabled (not just auditing) and dir is in a protected folder
+func controlledFolderAccessBlocked(dir string, err error) bool {
+	var errno syscall.Errno
+	if !errors.As(err, &errno) || (errno != syscall.ERROR_ACCESS_DENIED && errno != syscall.ERROR_FILE_NOT_FOUND) {
+		return false
+	}
+
+	enabled, folders := controlledFolderAccessSettings()
+	if !enabled {
+		return false
+	}
+	for _, folder := range folders {
+		if isWithinFolder(dir, folder) {
+			return true
+		}
+	}
+	return false
+}
+
+// controlledFolderAccessSettings returns whether Controlled Folder Access is enabled and the folders it protects
+func controlledFolderAccessSettings() (bool, []string) {
+	// EnableControlledFolderAccess: 0 = off, 1 = on, 2 = audit only
+	cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command",
+		"$p = Get-MpPreference; $p.EnableControlledFolderAccess; $p.ControlledFolderAccessProtectedFolders")
+	hideWindow(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return false, nil
+	}
+	lines := strings.Split(strings.ReplaceAll(string(output), "\r", ""), "\n")
+	if strings.TrimSpace(lines[0]) != "1" {
+		return false, nil
+	}
+
+	// Folders protected by default, plus the ones the user added
+	var folders []string
+	for _, root := range []string{os.Getenv("USERPROFILE"), os.Getenv("PUBLIC")} {
+		if root == "" {
+			continue
+		}
+		for _, name := range []string{"Documents", "Pictures", "Videos", "Music", "Desktop", "Favorites"} {
+			folders = append(folders, filepath.Join(root, name))
+		}
+	}
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); line != "" {
+			folders = append(folders, line)
+		}
+	}
+	return true, folders
+}
+
+// isWithinFolder reports whether path is folder or inside it (case-insensitive, like Windows paths)
+func isWithinFolder(path, folder string) bool {
+	rel, err := filepath.Rel(strings.ToLower(filepath.Clean(folder)), strings.ToLower(filepath.Clean(path)))
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
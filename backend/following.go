@@ -0,0 +1,131 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Following archive
+//
+// Archives every account a user follows, or the members of a List, in one run. The extractor
+// lists the accounts (user records, see stream.go) and they go through a sync run (see
+// syncall.go) that adds the ones not saved yet: new accounts are fetched from the start, saved
+// ones continue incrementally like in any other sync. MaxMediaPerAccount caps the media fetched
+// per account, so a few huge accounts don't use up the run's rate limit budget; what a capped
+// account didn't get is fetched by the next runs from its saved cursor. X only shows the
+// following list of the auth token's own account and of public accounts.
+
+// FollowingRequest selects an account list
+type FollowingRequest struct {
+	AuthToken string `json:"auth_token"`
+	Username  string `json:"username,omitempty"` // Account whose following list is read, usually the auth token's own
+	ListID    string `json:"list_id,omitempty"`  // Read the members of this List instead (ID or URL)
+	Limit     int    `json:"limit,omitempty"`    // Accounts listed at most, 0 = all
+}
+
+// FollowingArchiveRequest holds the settings of a following archive run
+type FollowingArchiveRequest struct {
+	Following          FollowingRequest `json:"following"`
+	OutputDir          string           `json:"output_dir"` // For accounts without a known archive folder
+	Proxy              string           `json:"proxy,omitempty"`
+	MaxMediaPerAccount int              `json:"max_media_per_account,omitempty"` // 0 = no cap
+	Options            DownloadOptions  `json:"options"`
+}
+
+// followingURL returns the URL of an account list
+func followingURL(req FollowingRequest) (string, error) {
+	if req.ListID != "" {
+		id := req.ListID
+		if i := strings.Index(id, "/lists/"); i >= 0 {
+			id = strings.SplitN(id[i+len("/lists/"):], "/", 2)[0]
+		}
+		id = strings.TrimSpace(id)
+		if id == "" || strings.Trim(id, "0123456789") != "" {
+			return "", fmt.Errorf("invalid list ID: %s", req.ListID)
+		}
+		return "https://x.com/i/lists/" + id + "/members", nil
+	}
+	username := cleanUsername(req.Username)
+	if username == "" {
+		return "", fmt.Errorf("username or list ID is required")
+	}
+	return "https://x.com/" + username + "/following", nil
+}
+
+// followingSource describes an account list for messages
+func followingSource(req FollowingRequest) string {
+	if req.ListID != "" {
+		return "list " + req.ListID
+	}
+	return "@" + cleanUsername(req.Username)
+}
+
+// ExtractFollowing lists the accounts a user follows or the members of a List
+// If the extractor fails midway, the accounts listed so far are returned with a *PartialResultError
+func ExtractFollowing(req FollowingRequest) ([]UserInfo, error) {
+	tokens := pickAuthTokens(req.AuthToken)
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("auth token is required")
+	}
+	url, err := followingURL(req)
+	if err != nil {
+		return nil, err
+	}
+	exePath, err := ensureExtractor()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{url, "--auth-token", tokens[0], "--ndjson"}
+	if req.Limit > 0 {
+		args = append(args, "--limit", fmt.Sprintf("%d", req.Limit))
+	}
+
+	users := make([]UserInfo, 0)
+	seen := make(map[string]bool)
+	_, err = runExtractorStream(exePath, args, req.Username, cliStreamHandler{
+		onUser: func(user UserInfo) {
+			key := strings.ToLower(user.Name)
+			if key == "" || seen[key] {
+				return
+			}
+			seen[key] = true
+			users = append(users, user)
+		},
+	})
+	var partial *PartialResultError
+	if err != nil && !errors.As(err, &partial) {
+		return nil, fmt.Errorf("failed to list accounts: %v", err)
+	}
+	return users, err
+}
+
+// ArchiveFollowing lists the accounts of a following list or List and syncs each of them,
+// adding the ones that aren't saved yet. A list cut short by an extractor failure is archived as
+// far as it was listed.
+func ArchiveFollowing(ctx context.Context, req FollowingArchiveRequest, onAccount SyncAccountCallback, progress ProgressCallback, itemStatus ItemStatusCallback) (*SyncAllReport, error) {
+	users, err := ExtractFollowing(req.Following)
+	if err != nil && len(users) == 0 {
+		return nil, err
+	}
+	// No usernames would make SyncAll sync every saved account
+	if len(users) == 0 {
+		return nil, fmt.Errorf("no accounts listed for %s", followingSource(req.Following))
+	}
+	usernames := make([]string, 0, len(users))
+	for _, user := range users {
+		usernames = append(usernames, user.Name)
+	}
+
+	return SyncAll(ctx, SyncAllRequest{
+		AuthToken: req.Following.AuthToken,
+		OutputDir: req.OutputDir,
+		Proxy:     req.Proxy,
+		Usernames: usernames,
+		AddNew:    true,
+		MaxMedia:  req.MaxMediaPerAccount,
+		Options:   req.Options,
+	}, onAccount, progress, itemStatus)
+}
@@ -0,0 +1,409 @@
+package backend
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif" // Register decoders for thumbnails
+	"image/jpeg"
+	_ "image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Gallery cache
+//
+// The local gallery browses downloaded archives that can hold hundreds of thousands of files.
+// Thumbnails are generated lazily the first time they're viewed and kept on disk, bounded in
+// size with the least recently viewed evicted first. Folder listings are memoized in memory
+// and rebuilt only when a media folder changes.
+
+const (
+	defaultThumbnailSize   = 320
+	maxThumbnailSize       = 1024
+	maxThumbnailCacheBytes = 1 << 30 // 1 GB
+	maxGalleryListings     = 64      // Memoized folder listings
+	thumbnailJPEGQuality   = 80
+)
+
+// gallerySubfolders are the media folders inside an account folder, with their item type
+var gallerySubfolders = map[string]string{
+	"images": "photo",
+	"videos": "video",
+	"gifs":   "gif",
+	"texts":  "text",
+	"other":  "other",
+}
+
+// galleryTimestampPattern matches the timestamp in downloader file names ({username}_{timestamp}_{tweet_id}_{index}.{ext})
+var galleryTimestampPattern = regexp.MustCompile(`_(\d{8}_\d{6})_\d+_\d{2,}`)
+
+// GalleryItem is a downloaded file of an account folder
+type GalleryItem struct {
+	Path    string `json:"path"`
+	Name    string `json:"name"`
+	Type    string `json:"type"`
+	TweetID string `json:"tweet_id,omitempty"`
+	Date    string `json:"date,omitempty"`
+	Size    int64  `json:"size"`
+}
+
+// GalleryPage is a page of a folder listing
+type GalleryPage struct {
+	Folder string        `json:"folder"`
+	Total  int           `json:"total"`
+	Items  []GalleryItem `json:"items"`
+}
+
+// GalleryCacheStats describes the gallery caches
+type GalleryCacheStats struct {
+	Thumbnails     int   `json:"thumbnails"`
+	ThumbnailBytes int64 `json:"thumbnail_bytes"`
+	Listings       int   `json:"listings"`
+}
+
+// galleryListing is a memoized folder listing, valid as long as the folder stamp is unchanged
+type galleryListing struct {
+	stamp string
+	items []GalleryItem
+}
+
+var (
+	galleryListings = newLRUCache[string, galleryListing](maxGalleryListings, nil)
+
+	thumbnailsOnce sync.Once
+	thumbnails     *lruCache[string, string] // cache key -> thumbnail path
+)
+
+// getThumbnailDir returns the directory holding cached thumbnails
+func getThumbnailDir() string {
+	return filepath.Join(GetAppDataDir(), "cache", "thumbnails")
+}
+
+// thumbnailCache returns the thumbnail index, loading it from disk on first use
+func thumbnailCache() *lruCache[string, string] {
+	thumbnailsOnce.Do(func() {
+		thumbnails = newLRUCache(maxThumbnailCacheBytes, func(_ string, path string) {
+			os.Remove(path)
+		})
+
+		entries, err := os.ReadDir(getThumbnailDir())
+		if err != nil {
+			return
+		}
+		type cached struct {
+			path    string
+			size    int64
+			modTime time.Time
+		}
+		var files []cached
+		for _, entry := range entries {
+			if entry.IsDir() || filepath.Ext(entry.Name()) != ".jpg" {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+			files = append(files, cached{filepath.Join(getThumbnailDir(), entry.Name()), info.Size(), info.ModTime()})
+		}
+		// Thumbnails are touched when viewed, so the oldest modification time is the least recently used
+		sort.Slice(files, func(i, j int) bool { return files[i].modTime.Before(files[j].modTime) })
+		for _, f := range files {
+			thumbnails.add(strings.TrimSuffix(filepath.Base(f.path), ".jpg"), f.path, f.size)
+		}
+	})
+	return thumbnails
+}
+
+// ListGallery lists the downloaded files of an account folder, newest tweets first
+// limit <= 0 returns everything from offset
+func ListGallery(folder string, offset, limit int) (GalleryPage, error) {
+	folder = filepath.Clean(folder)
+	if IsUsernameSensitive(filepath.Base(folder)) && IsLocked() {
+		return GalleryPage{}, ErrContentLocked
+	}
+	if info, err := os.Stat(folder); err != nil || !info.IsDir() {
+		return GalleryPage{}, fmt.Errorf("folder not found: %s", folder)
+	}
+
+	stamp := galleryFolderStamp(folder)
+	listing, ok := galleryListings.get(folder)
+	if !ok || listing.stamp != stamp {
+		items, err := scanGalleryFolder(folder)
+		if err != nil {
+			return GalleryPage{}, err
+		}
+		listing = galleryListing{stamp: stamp, items: items}
+		galleryListings.add(folder, listing, 1)
+	}
+
+	page := GalleryPage{Folder: folder, Total: len(listing.items), Items: []GalleryItem{}}
+	if offset < 0 {
+		offset = 0
+	}
+	if offset >= len(listing.items) {
+		return page, nil
+	}
+	end := len(listing.items)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	page.Items = listing.items[offset:end]
+	return page, nil
+}
+
+// galleryFolderStamp identifies the state of an account folder by the modification times
+// of its media folders, which change whenever a file is added, removed or renamed
+func galleryFolderStamp(folder string) string {
+	var b strings.Builder
+	for _, sub := range []string{"", "images", "videos", "gifs", "texts", "other"} {
+		if info, err := os.Stat(filepath.Join(folder, sub)); err == nil {
+			fmt.Fprintf(&b, "%s:%d;", sub, info.ModTime().UnixNano())
+		}
+	}
+	return b.String()
+}
+
+// scanGalleryFolder reads the media folders of an account folder
+func scanGalleryFolder(folder string) ([]GalleryItem, error) {
+	var items []GalleryItem
+	var tweetIDs []int64
+	for sub, itemType := range gallerySubfolders {
+		entries, err := os.ReadDir(filepath.Join(folder, sub))
+		if err != nil {
+			continue
+		}
+		for _, entry := range entries {
+			name := entry.Name()
+			if entry.IsDir() || strings.HasPrefix(name, ".") || strings.HasSuffix(name, ".part") {
+				continue
+			}
+			info, err := entry.Info()
+			if err != nil {
+				continue
+			}
+
+			item := GalleryItem{
+				Path: filepath.Join(folder, sub, name),
+				Name: name,
+				Type: itemType,
+				Size: info.Size(),
+			}
+			var tweetID int64
+			if m := archiveFilePattern.FindStringSubmatch(name); m != nil {
+				tweetID, _ = strconv.ParseInt(m[1], 10, 64)
+				item.TweetID = m[1]
+			}
+			if m := galleryTimestampPattern.FindStringSubmatch(name); m != nil {
+				if t, err := time.Parse("20060102_150405", m[1]); err == nil {
+					item.Date = t.Format("2006-01-02T15:04:05")
+				}
+			}
+			items = append(items, item)
+			tweetIDs = append(tweetIDs, tweetID)
+		}
+	}
+
+	order := make([]int, len(items))
+	for i := range order {
+		order[i] = i
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := order[i], order[j]
+		if tweetIDs[a] != tweetIDs[b] {
+			return tweetIDs[a] > tweetIDs[b]
+		}
+		return items[a].Name < items[b].Name
+	})
+	sorted := make([]GalleryItem, len(items))
+	for i, idx := range order {
+		sorted[i] = items[idx]
+	}
+	return sorted, nil
+}
+
+// GetThumbnail returns the path of a JPEG thumbnail of a downloaded file, generating it on first use
+// size is the longest side in pixels (0 = default). Videos need FFmpeg
+func GetThumbnail(path string, size int) (string, error) {
+	if size <= 0 {
+		size = defaultThumbnailSize
+	}
+	if size > maxThumbnailSize {
+		size = maxThumbnailSize
+	}
+	if IsUsernameSensitive(galleryPathUsername(path)) && IsLocked() {
+		return "", ErrContentLocked
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", fmt.Errorf("file not found: %v", err)
+	}
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	// The key changes with the file, so replaced files never get a stale thumbnail
+	sum := sha1.Sum([]byte(fmt.Sprintf("%s|%d|%d|%d", absPath, info.Size(), info.ModTime().UnixNano(), size)))
+	key := hex.EncodeToString(sum[:])
+
+	cache := thumbnailCache()
+	if thumbPath, ok := cache.get(key); ok {
+		if _, err := os.Stat(thumbPath); err == nil {
+			now := time.Now()
+			os.Chtimes(thumbPath, now, now) // Keep the LRU order across restarts
+			return thumbPath, nil
+		}
+		cache.remove(key)
+	}
+
+	if err := os.MkdirAll(getThumbnailDir(), 0755); err != nil {
+		return "", fmt.Errorf("failed to create thumbnail cache: %v", err)
+	}
+	thumbPath := filepath.Join(getThumbnailDir(), key+".jpg")
+	tmpPath := thumbPath + ".part"
+	if err := generateThumbnail(absPath, tmpPath, size); err != nil {
+		os.Remove(tmpPath)
+		return "", err
+	}
+	if err := os.Rename(tmpPath, thumbPath); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to save thumbnail: %v", err)
+	}
+
+	thumbInfo, err := os.Stat(thumbPath)
+	if err != nil {
+		return "", err
+	}
+	cache.add(key, thumbPath, thumbInfo.Size())
+	return thumbPath, nil
+}
+
+// galleryPathUsername returns the account of a downloaded file: the account folder above its
+// media folder, or the folder it's in
+func galleryPathUsername(path string) string {
+	dir := filepath.Dir(filepath.Clean(path))
+	if gallerySubfolders[filepath.Base(dir)] != "" {
+		return filepath.Base(filepath.Dir(dir))
+	}
+	return filepath.Base(dir)
+}
+
+// generateThumbnail writes a JPEG thumbnail of inputPath to outputPath
+func generateThumbnail(inputPath, outputPath string, size int) error {
+	switch strings.ToLower(filepath.Ext(inputPath)) {
+	case ".jpg", ".jpeg", ".png", ".gif":
+		return generateImageThumbnail(inputPath, outputPath, size)
+	case ".mp4", ".mov", ".webm", ".m4v", ".webp":
+		return generateFFmpegThumbnail(inputPath, outputPath, size)
+	default:
+		return fmt.Errorf("no thumbnail for file type: %s", filepath.Ext(inputPath))
+	}
+}
+
+// generateImageThumbnail decodes and downscales an image
+func generateImageThumbnail(inputPath, outputPath string, size int) error {
+	f, err := os.Open(inputPath)
+	if err != nil {
+		return err
+	}
+	img, _, err := image.Decode(f)
+	f.Close()
+	if err != nil {
+		// Some files have the wrong extension - let ffmpeg try
+		if IsFFmpegInstalled() {
+			return generateFFmpegThumbnail(inputPath, outputPath, size)
+		}
+		return fmt.Errorf("failed to decode image: %v", err)
+	}
+
+	out, err := os.Create(outputPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return jpeg.Encode(out, downscaleImage(img, size), &jpeg.Options{Quality: thumbnailJPEGQuality})
+}
+
+// generateFFmpegThumbnail grabs a frame with ffmpeg
+func generateFFmpegThumbnail(inputPath, outputPath string, size int) error {
+	if !IsFFmpegInstalled() {
+		return fmt.Errorf("ffmpeg not installed")
+	}
+	args := []string{
+		"-ss", "0.5",
+		"-i", inputPath,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease", size, size),
+		"-f", "image2",
+		"-c:v", "mjpeg",
+		"-y",
+		outputPath,
+	}
+	cmd := exec.Command(ffmpegBinary(), args...)
+	hideWindow(cmd)
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("ffmpeg error: %v, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// downscaleImage scales img to fit size x size with a box filter (sampling at most 4x4 pixels per box)
+func downscaleImage(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= size && h <= size {
+		return img
+	}
+	nw, nh := size, size
+	if w > h {
+		nh = max(1, h*size/w)
+	} else {
+		nw = max(1, w*size/h)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, nw, nh))
+	for y := 0; y < nh; y++ {
+		y0, y1 := b.Min.Y+y*h/nh, b.Min.Y+(y+1)*h/nh
+		stepY := max(1, (y1-y0)/4)
+		for x := 0; x < nw; x++ {
+			x0, x1 := b.Min.X+x*w/nw, b.Min.X+(x+1)*w/nw
+			stepX := max(1, (x1-x0)/4)
+			var r, g, bl, a, n uint64
+			for sy := y0; sy < max(y1, y0+1); sy += stepY {
+				for sx := x0; sx < max(x1, x0+1); sx += stepX {
+					cr, cg, cb, ca := img.At(sx, sy).RGBA()
+					r, g, bl, a = r+uint64(cr), g+uint64(cg), bl+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{uint8(r / n >> 8), uint8(g / n >> 8), uint8(bl / n >> 8), uint8(a / n >> 8)})
+		}
+	}
+	return dst
+}
+
+// GetGalleryCacheStats returns the size of the gallery caches
+func GetGalleryCacheStats() GalleryCacheStats {
+	thumbs, bytes := thumbnailCache().stats()
+	listings, _ := galleryListings.stats()
+	return GalleryCacheStats{Thumbnails: thumbs, ThumbnailBytes: bytes, Listings: listings}
+}
+
+// ClearGalleryCache deletes all cached thumbnails and memoized listings
+func ClearGalleryCache() {
+	thumbnailCache().clear()
+	galleryListings.clear()
+}
@@ -0,0 +1,155 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Media of tweets that are only a few minutes old sometimes lacks the final variants
+// (e.g. videos still processing, only low bitrates available). With a grace period set,
+// such tweets are moved to the end of the job, and just before downloading them their
+// media URLs are resolved again from the tweet itself.
+
+// twitterEpochMillis is the epoch of Twitter snowflake IDs
+const twitterEpochMillis = 1288834974657
+
+// TweetTime returns the creation time encoded in a snowflake tweet ID
+// Returns the zero time for IDs from before snowflakes (2010)
+func TweetTime(tweetID int64) time.Time {
+	if tweetID < 1<<22 {
+		return time.Time{}
+	}
+	return time.UnixMilli((tweetID >> 22) + twitterEpochMillis)
+}
+
+// deferYoungTasks moves tasks of tweets younger than grace to the end and marks them for re-resolving
+func deferYoungTasks(tasks []downloadTask, grace time.Duration, now time.Time) []downloadTask {
+	ordered := make([]downloadTask, 0, len(tasks))
+	var young []downloadTask
+	for _, task := range tasks {
+		created := TweetTime(task.item.TweetID)
+		if task.item.Type != "text" && !created.IsZero() && now.Sub(created) < grace {
+			task.recheck = true
+			young = append(young, task)
+			continue
+		}
+		ordered = append(ordered, task)
+	}
+	return append(ordered, young...)
+}
+
+// tweetResolver re-resolves media URLs of tweets, fetching each tweet only once per batch
+// Different tweets are fetched in parallel; workers that need a tweet being fetched wait for it
+type tweetResolver struct {
+	authToken string
+	grace     time.Duration
+
+	mu     sync.Mutex
+	tweets map[int64]*tweetResolution
+}
+
+// tweetResolution is the result of fetching a tweet, available once done is closed
+type tweetResolution struct {
+	done  chan struct{}
+	media []CLIMediaItem
+	err   error
+}
+
+// newTweetResolver returns a resolver for a batch
+func newTweetResolver(authToken string, grace time.Duration) *tweetResolver {
+	return &tweetResolver{
+		authToken: authToken,
+		grace:     grace,
+		tweets:    make(map[int64]*tweetResolution),
+	}
+}
+
+// refresh waits until the tweet is past the grace period, then returns the task with its URL
+// resolved again. The original URL is kept if the tweet can't be fetched
+func (r *tweetResolver) refresh(ctx context.Context, task downloadTask) downloadTask {
+	if wait := r.grace - time.Since(TweetTime(task.item.TweetID)); wait > 0 {
+		select {
+		case <-ctx.Done():
+			return task
+		case <-time.After(wait):
+		}
+	}
+
+	media, err := r.resolve(ctx, task.item.TweetID)
+	if err != nil {
+		fmt.Printf("Warning: failed to re-resolve tweet %d, using original URL: %v\n", task.item.TweetID, err)
+		return task
+	}
+
+	// Media are in tweet order, mediaIndex is 1-based
+	if task.mediaIndex >= 1 && task.mediaIndex <= len(media) {
+		fresh := media[task.mediaIndex-1]
+		if fresh.URL != "" {
+			task.item.URL = fresh.URL
+			if fresh.Width > 0 && fresh.Height > 0 {
+				task.item.Width = fresh.Width
+				task.item.Height = fresh.Height
+			}
+		}
+	}
+	return task
+}
+
+// resolve fetches the media of a single tweet (cached)
+// The extractor runs without the lock held, a second caller for the same tweet waits for it
+func (r *tweetResolver) resolve(ctx context.Context, tweetID int64) ([]CLIMediaItem, error) {
+	r.mu.Lock()
+	res, fetching := r.tweets[tweetID]
+	if !fetching {
+		res = &tweetResolution{done: make(chan struct{})}
+		r.tweets[tweetID] = res
+	}
+	r.mu.Unlock()
+
+	if fetching {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-res.done:
+			return res.media, res.err
+		}
+	}
+	res.media, res.err = ExtractTweetMedia(tweetID, r.authToken)
+	close(res.done)
+	return res.media, res.err
+}
+
+// ExtractTweetMedia fetches the media of a single tweet
+func ExtractTweetMedia(tweetID int64, authToken string) ([]CLIMediaItem, error) {
+	exePath, err := ensureExtractor()
+	if err != nil {
+		return nil, err
+	}
+
+	args := []string{fmt.Sprintf("https://x.com/i/status/%d", tweetID)}
+	if token := firstAuthToken(authToken); token != "" {
+		args = append(args, "--auth-token", token)
+	} else {
+		args = append(args, "--guest")
+	}
+	args = append(args, "--ndjson")
+
+	var media []CLIMediaItem
+	_, err = runExtractorStream(exePath, args, "", cliStreamHandler{
+		onMedia: func(item CLIMediaItem) {
+			// Conversation pages may include other tweets
+			if int64(item.TweetID) == tweetID {
+				if item.Num == 0 {
+					item.Num = len(media) + 1
+				}
+				media = append(media, item)
+			}
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return media, nil
+}
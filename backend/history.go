@@ -0,0 +1,196 @@
+package backend
+
+import (
+	"fmt"
+	"time"
+)
+
+// Job history
+//
+// Every fetch and download run is recorded in the job_history table of the library: when it
+// started, how long it took, the account, how many items it fetched or files it saved, the bytes
+// downloaded and what went wrong. GetStats adds the history up for the statistics view: the media
+// archived in total, the growth per month (months in UTC) and the accounts with the most media.
+// Runs stopped before anything was downloaded (a declined confirmation) aren't recorded.
+
+// Job kinds
+const (
+	JobKindExtract  = "extract"
+	JobKindDownload = "download"
+)
+
+// topAccountsLimit is the number of accounts in the top accounts of the statistics
+const topAccountsLimit = 10
+
+// JobRecord is a recorded fetch or download run
+type JobRecord struct {
+	ID         int64  `json:"id"`
+	Kind       string `json:"kind"` // extract or download
+	Username   string `json:"username"`
+	StartedAt  string `json:"started_at"` // RFC 3339, UTC
+	DurationMs int64  `json:"duration_ms"`
+	Items      int    `json:"items"` // Tweets fetched, or files saved
+	Bytes      int64  `json:"bytes"` // Bytes downloaded
+	Failed     int    `json:"failed"`
+	Error      string `json:"error,omitempty"`
+}
+
+// MonthStats is the media archived in a month
+type MonthStats struct {
+	Month string `json:"month"` // YYYY-MM
+	Media int    `json:"media"`
+	Bytes int64  `json:"bytes"`
+	Runs  int    `json:"runs"`
+}
+
+// AccountStats is the media archived of an account
+type AccountStats struct {
+	Username string `json:"username"`
+	Media    int    `json:"media"`
+	Bytes    int64  `json:"bytes"`
+	LastRun  string `json:"last_run"`
+}
+
+// JobStats are the aggregates of the job history
+type JobStats struct {
+	TotalMedia  int            `json:"total_media"` // Files saved by all downloads
+	TotalBytes  int64          `json:"total_bytes"`
+	Extractions int            `json:"extractions"`
+	Downloads   int            `json:"downloads"`
+	FailedFiles int            `json:"failed_files"`
+	FailedRuns  int            `json:"failed_runs"` // Runs that ended with an error
+	Months      []MonthStats   `json:"months"`      // Oldest first
+	TopAccounts []AccountStats `json:"top_accounts"`
+}
+
+// recordJob adds a run to the job history
+func recordJob(record JobRecord) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return
+		}
+	}
+	_, err := db.Exec(`
+		INSERT INTO job_history (kind, username, started_at, duration_ms, items, bytes, failed, error)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`, record.Kind, record.Username, record.StartedAt, record.DurationMs, record.Items, record.Bytes, record.Failed, record.Error)
+	if err != nil {
+		fmt.Printf("Warning: failed to record job: %v\n", err)
+	}
+}
+
+// newJobRecord starts the record of a run
+func newJobRecord(kind, username string, started time.Time) JobRecord {
+	return JobRecord{
+		Kind:       kind,
+		Username:   username,
+		StartedAt:  started.UTC().Format(time.RFC3339),
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+}
+
+// RecordExtraction adds a fetch to the job history
+func RecordExtraction(username string, started time.Time, response *TwitterResponse, err error) {
+	record := newJobRecord(JobKindExtract, username, started)
+	if response != nil {
+		record.Items = len(response.Timeline)
+		record.Error = response.Error
+		if username == "" {
+			record.Username = response.AccountInfo.Name
+		}
+	}
+	if err != nil {
+		record.Error = err.Error()
+	}
+	recordJob(record)
+}
+
+// GetJobHistory returns the most recent runs, newest first
+func GetJobHistory(limit int) ([]JobRecord, error) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return nil, err
+		}
+	}
+	if limit <= 0 {
+		limit = 100
+	}
+	rows, err := db.Query(`
+		SELECT id, kind, username, started_at, duration_ms, items, bytes, failed, error FROM job_history
+		ORDER BY started_at DESC, id DESC LIMIT ?
+	`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	history := []JobRecord{}
+	for rows.Next() {
+		var r JobRecord
+		if err := rows.Scan(&r.ID, &r.Kind, &r.Username, &r.StartedAt, &r.DurationMs, &r.Items, &r.Bytes, &r.Failed, &r.Error); err != nil {
+			return nil, err
+		}
+		history = append(history, r)
+	}
+	return history, rows.Err()
+}
+
+// GetStats returns the aggregates of the job history
+func GetStats() (JobStats, error) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return JobStats{}, err
+		}
+	}
+	stats := JobStats{Months: []MonthStats{}, TopAccounts: []AccountStats{}}
+
+	err := db.QueryRow(`
+		SELECT
+			COALESCE(SUM(CASE WHEN kind = ? THEN items END), 0),
+			COALESCE(SUM(bytes), 0),
+			COUNT(CASE WHEN kind = ? THEN 1 END),
+			COUNT(CASE WHEN kind = ? THEN 1 END),
+			COALESCE(SUM(failed), 0),
+			COUNT(CASE WHEN error != '' THEN 1 END)
+		FROM job_history
+	`, JobKindDownload, JobKindExtract, JobKindDownload).Scan(
+		&stats.TotalMedia, &stats.TotalBytes, &stats.Extractions, &stats.Downloads, &stats.FailedFiles, &stats.FailedRuns)
+	if err != nil {
+		return stats, err
+	}
+
+	rows, err := db.Query(`
+		SELECT substr(started_at, 1, 7) AS month, SUM(items), SUM(bytes), COUNT(*) FROM job_history
+		WHERE kind = ?
+		GROUP BY month ORDER BY month
+	`, JobKindDownload)
+	if err != nil {
+		return stats, err
+	}
+	for rows.Next() {
+		var m MonthStats
+		if err := rows.Scan(&m.Month, &m.Media, &m.Bytes, &m.Runs); err != nil {
+			rows.Close()
+			return stats, err
+		}
+		stats.Months = append(stats.Months, m)
+	}
+	rows.Close()
+
+	rows, err = db.Query(`
+		SELECT username, SUM(items) AS media, SUM(bytes), MAX(started_at) FROM job_history
+		WHERE kind = ? AND username != ''
+		GROUP BY username COLLATE NOCASE ORDER BY media DESC LIMIT ?
+	`, JobKindDownload, topAccountsLimit)
+	if err != nil {
+		return stats, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var a AccountStats
+		if err := rows.Scan(&a.Username, &a.Media, &a.Bytes, &a.LastRun); err != nil {
+			return stats, err
+		}
+		stats.TopAccounts = append(stats.TopAccounts, a)
+	}
+	return stats, rows.Err()
+}
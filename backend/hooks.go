@@ -0,0 +1,148 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Post-download hooks
+//
+// Custom workflows (importing into Hydrus, syncing with rclone, ...) can hook into downloads
+// with a shell command: FileHook runs for every saved file, BatchHook once when a job ends.
+// Commands run through sh -c (cmd /C on Windows) with the details in TXMBD_* environment
+// variables. File hooks run one at a time in the background, in the order files were saved,
+// so a slow hook doesn't hold up downloads; the batch hook runs after the last file hook, with
+// TXMBD_FILES naming a file that lists every saved path, one per line (e.g. for rclone
+// --files-from). A failing hook is logged and never fails the download.
+
+// hookTimeout is how long a hook command may run
+const hookTimeout = 10 * time.Minute
+
+// hookRunner runs the hooks of a download job
+// A nil *hookRunner means no hooks
+type hookRunner struct {
+	fileHook  string
+	batchHook string
+	outputDir string
+	username  string
+
+	files chan []string // Environment of pending file hooks
+	done  chan struct{}
+
+	mu    sync.Mutex
+	saved []string
+}
+
+// startHooks starts the file hook worker of a job, or returns nil if no hooks are set
+func startHooks(opts DownloadOptions, outputDir, username string, tasks int) *hookRunner {
+	fileHook := strings.TrimSpace(opts.FileHook)
+	batchHook := strings.TrimSpace(opts.BatchHook)
+	if fileHook == "" && batchHook == "" {
+		return nil
+	}
+	h := &hookRunner{
+		fileHook:  fileHook,
+		batchHook: batchHook,
+		outputDir: outputDir,
+		username:  username,
+		files:     make(chan []string, tasks),
+		done:      make(chan struct{}),
+	}
+	go func() {
+		defer close(h.done)
+		for env := range h.files {
+			runHook(h.fileHook, env)
+		}
+	}()
+	return h
+}
+
+// file queues the file hook of a saved file
+func (h *hookRunner) file(item MediaItem, index int, path string) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	h.saved = append(h.saved, path)
+	h.mu.Unlock()
+	if h.fileHook == "" {
+		return
+	}
+	h.files <- []string{
+		"TXMBD_EVENT=file",
+		"TXMBD_PATH=" + path,
+		"TXMBD_TWEET_ID=" + strconv.FormatInt(item.TweetID, 10),
+		"TXMBD_AUTHOR=" + item.Username,
+		"TXMBD_TYPE=" + item.Type,
+		"TXMBD_INDEX=" + strconv.Itoa(index),
+		"TXMBD_DATE=" + item.Date,
+		"TXMBD_URL=" + item.URL,
+		"TXMBD_TWEET_URL=" + fmt.Sprintf("https://x.com/%s/status/%d", item.Username, item.TweetID),
+		"TXMBD_OUTPUT_DIR=" + h.outputDir,
+	}
+}
+
+// finish waits for the file hooks and runs the batch hook with the job's result
+func (h *hookRunner) finish(downloaded, skipped, failed int, err error) {
+	if h == nil {
+		return
+	}
+	close(h.files)
+	<-h.done
+	if h.batchHook == "" {
+		return
+	}
+
+	status := "completed"
+	if err != nil {
+		status = "stopped"
+	}
+	env := []string{
+		"TXMBD_EVENT=batch",
+		"TXMBD_STATUS=" + status,
+		"TXMBD_USERNAME=" + h.username,
+		"TXMBD_OUTPUT_DIR=" + h.outputDir,
+		"TXMBD_DOWNLOADED=" + strconv.Itoa(downloaded),
+		"TXMBD_SKIPPED=" + strconv.Itoa(skipped),
+		"TXMBD_FAILED=" + strconv.Itoa(failed),
+	}
+	if err != nil {
+		env = append(env, "TXMBD_ERROR="+err.Error())
+	}
+
+	h.mu.Lock()
+	list := strings.Join(h.saved, "\n")
+	h.mu.Unlock()
+	if f, err := os.CreateTemp("", "txmbd-files-*.txt"); err == nil {
+		f.WriteString(list)
+		f.Close()
+		defer os.Remove(f.Name())
+		env = append(env, "TXMBD_FILES="+f.Name())
+	}
+	runHook(h.batchHook, env)
+}
+
+// runHook runs a hook command with extra environment variables, logging failures
+func runHook(command string, env []string) {
+	ctx, cancel := context.WithTimeout(context.Background(), hookTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	if runtime.GOOS == "windows" {
+		cmd = exec.CommandContext(ctx, "cmd", "/C", command)
+	} else {
+		cmd = exec.CommandContext(ctx, "sh", "-c", command)
+	}
+	hideWindow(cmd)
+	cmd.Env = append(os.Environ(), env...)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		fmt.Printf("Warning: hook %q failed: %v, output: %s\n", command, err, strings.TrimSpace(string(output)))
+	}
+}
@@ -0,0 +1,409 @@
+package backend
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// HTML gallery export
+//
+// An account folder can be exported as a static gallery that opens in any browser, without the
+// app: gallery/index.html in the account folder with a thumbnail grid, a lightbox and the text of
+// each tweet, linking to the downloaded files and to the tweets on X. Everything the page needs is
+// inlined, the thumbnails are written to gallery/thumbs next to it, so the account folder can be
+// copied or zipped as a whole. Tweet texts come from the library database, or from tweets.jsonl
+// when the account isn't in the library. Exporting again only renders thumbnails of new files.
+// Video thumbnails need FFmpeg; without it videos get a placeholder tile.
+
+const (
+	htmlGalleryDir       = "gallery"
+	htmlGalleryThumbSize = 360
+)
+
+// HTMLGalleryResult describes an exported gallery
+type HTMLGalleryResult struct {
+	Path       string `json:"path"` // index.html of the gallery
+	Media      int    `json:"media"`
+	Tweets     int    `json:"tweets"`
+	Thumbnails int    `json:"thumbnails"` // Files with a thumbnail (the rest get a placeholder)
+}
+
+// archivedTweet is what the exports know about a tweet
+type archivedTweet struct {
+	Author    string
+	Text      string
+	Date      string
+	Likes     int
+	Retweets  int
+	Replies   int
+	Views     int
+	Bookmarks int
+	MediaURLs map[int]string // Media index -> original URL
+}
+
+// htmlGalleryItem is a tile of the gallery
+type htmlGalleryItem struct {
+	File     string // Relative to the gallery folder
+	Thumb    string // Relative to the gallery folder, "" = placeholder
+	Type     string
+	Name     string
+	TweetID  string
+	TweetURL string
+	Original string // Media URL on X
+	Date     string
+	Text     string
+	Stats    string
+}
+
+// htmlGalleryPage is the data of the page template
+type htmlGalleryPage struct {
+	Username string
+	Items    []htmlGalleryItem
+	Tweets   int
+}
+
+// ExportHTMLGallery writes a static HTML gallery of an account folder to its gallery subfolder
+func ExportHTMLGallery(folder string) (HTMLGalleryResult, error) {
+	folder = filepath.Clean(folder)
+	username := filepath.Base(folder)
+	if IsUsernameSensitive(username) && IsLocked() {
+		return HTMLGalleryResult{}, ErrContentLocked
+	}
+
+	page, err := ListGallery(folder, 0, 0)
+	if err != nil {
+		return HTMLGalleryResult{}, err
+	}
+	galleryDir := filepath.Join(folder, htmlGalleryDir)
+	thumbDir := filepath.Join(galleryDir, "thumbs")
+	if err := os.MkdirAll(thumbDir, 0755); err != nil {
+		return HTMLGalleryResult{}, fmt.Errorf("failed to create gallery folder: %v", err)
+	}
+
+	tweets := archivedTweets(folder, username)
+	var files []GalleryItem
+	for _, item := range page.Items {
+		if item.Type != "text" {
+			files = append(files, item)
+		}
+	}
+	thumbs := renderGalleryThumbnails(files, thumbDir)
+
+	result := HTMLGalleryResult{Path: filepath.Join(galleryDir, "index.html"), Media: len(files)}
+	data := htmlGalleryPage{Username: username, Items: make([]htmlGalleryItem, 0, len(files))}
+	seenTweets := make(map[string]bool)
+	for i, file := range files {
+		rel, err := filepath.Rel(galleryDir, file.Path)
+		if err != nil {
+			continue
+		}
+		item := htmlGalleryItem{
+			File:    filepath.ToSlash(rel),
+			Type:    file.Type,
+			Name:    file.Name,
+			TweetID: file.TweetID,
+			Date:    file.Date,
+		}
+		if thumbs[i] {
+			item.Thumb = "thumbs/" + file.Name + ".jpg"
+			result.Thumbnails++
+		}
+		if file.TweetID != "" {
+			item.TweetURL = fmt.Sprintf("https://x.com/%s/status/%s", username, file.TweetID)
+			seenTweets[file.TweetID] = true
+		}
+		if tweet, ok := tweets[file.TweetID]; ok {
+			item.TweetURL = fmt.Sprintf("https://x.com/%s/status/%s", tweet.Author, file.TweetID)
+			item.Text = tweet.Text
+			item.Original = tweet.MediaURLs[archiveFileIndex(file.Name)]
+			if tweet.Date != "" {
+				item.Date = tweet.Date
+			}
+			item.Stats = fmt.Sprintf("%d likes · %d retweets · %d views", tweet.Likes, tweet.Retweets, tweet.Views)
+		}
+		data.Items = append(data.Items, item)
+	}
+	data.Tweets = len(seenTweets)
+	result.Tweets = data.Tweets
+
+	tmpPath := result.Path + ".tmp"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return result, fmt.Errorf("failed to write gallery: %v", err)
+	}
+	err = htmlGalleryTemplate.Execute(out, data)
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return result, fmt.Errorf("failed to write gallery: %v", err)
+	}
+	if err := os.Rename(tmpPath, result.Path); err != nil {
+		os.Remove(tmpPath)
+		return result, fmt.Errorf("failed to write gallery: %v", err)
+	}
+	return result, nil
+}
+
+// archivedTweets returns the tweets of an account by ID, from the library or else from tweets.jsonl
+func archivedTweets(folder, username string) map[string]*archivedTweet {
+	tweets := make(map[string]*archivedTweet)
+	for _, data := range libraryTimelines(username) {
+		var response TwitterResponse
+		if err := json.Unmarshal([]byte(data), &response); err != nil {
+			continue
+		}
+		// Number media within each tweet like the downloader does
+		seen := make(map[string]bool)
+		count := make(map[string]int)
+		for _, entry := range response.Timeline {
+			tweetID := strconv.FormatInt(int64(entry.TweetID), 10)
+			tweet := tweets[tweetID]
+			if tweet == nil {
+				tweet = &archivedTweet{Author: username, MediaURLs: make(map[int]string)}
+				tweets[tweetID] = tweet
+			}
+			if entry.AuthorUsername != "" {
+				tweet.Author = entry.AuthorUsername
+			}
+			if tweet.Text == "" {
+				tweet.Text = entry.Content
+			}
+			tweet.Date = entry.Date
+			tweet.Likes = max(tweet.Likes, entry.FavoriteCount)
+			tweet.Retweets = max(tweet.Retweets, entry.RetweetCount)
+			tweet.Replies = max(tweet.Replies, entry.ReplyCount)
+			tweet.Views = max(tweet.Views, entry.ViewCount)
+			tweet.Bookmarks = max(tweet.Bookmarks, entry.BookmarkCount)
+			if entry.Type == "text" || seen[entry.URL] {
+				continue
+			}
+			seen[entry.URL] = true
+			count[tweetID]++
+			tweet.MediaURLs[count[tweetID]] = entry.URL
+		}
+	}
+	if len(tweets) > 0 {
+		return tweets
+	}
+
+	f, err := os.Open(filepath.Join(folder, tweetsJSONLFile))
+	if err != nil {
+		return tweets
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var record TweetRecord
+		if json.Unmarshal(scanner.Bytes(), &record) != nil || record.TweetID == "" {
+			continue
+		}
+		tweet := &archivedTweet{
+			Author:    record.Username,
+			Text:      record.Content,
+			Date:      record.Date,
+			Likes:     record.FavoriteCount,
+			Retweets:  record.RetweetCount,
+			Replies:   record.ReplyCount,
+			Views:     record.ViewCount,
+			Bookmarks: record.BookmarkCount,
+			MediaURLs: make(map[int]string),
+		}
+		if tweet.Author == "" {
+			tweet.Author = username
+		}
+		for i, url := range record.Media {
+			tweet.MediaURLs[i+1] = url
+		}
+		tweets[record.TweetID] = tweet
+	}
+	return tweets
+}
+
+// libraryTimelines returns the stored timelines of an account (one per fetched media type)
+func libraryTimelines(username string) []string {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return nil
+		}
+	}
+	rows, err := db.Query(`SELECT response_json FROM accounts WHERE LOWER(username) = LOWER(?)`, username)
+	if err != nil {
+		return nil
+	}
+	defer rows.Close()
+	var timelines []string
+	for rows.Next() {
+		var responseJSON string
+		if rows.Scan(&responseJSON) != nil {
+			continue
+		}
+		if responseJSON, err = openResponseJSON(responseJSON); err != nil {
+			continue
+		}
+		if converted, err := ConvertLegacyToNewFormat(responseJSON); err == nil {
+			responseJSON = converted
+		}
+		timelines = append(timelines, responseJSON)
+	}
+	return timelines
+}
+
+// renderGalleryThumbnails writes missing or outdated thumbnails of files to thumbDir and reports
+// which files have one
+func renderGalleryThumbnails(files []GalleryItem, thumbDir string) []bool {
+	ok := make([]bool, len(files))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < min(runtime.NumCPU(), 4); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				file := files[i]
+				thumbPath := filepath.Join(thumbDir, file.Name+".jpg")
+				if thumbInfo, err := os.Stat(thumbPath); err == nil {
+					if info, err := os.Stat(file.Path); err == nil && !thumbInfo.ModTime().Before(info.ModTime()) {
+						ok[i] = true
+						continue
+					}
+				}
+				tmpPath := thumbPath + ".part"
+				if err := generateThumbnail(file.Path, tmpPath, htmlGalleryThumbSize); err != nil {
+					os.Remove(tmpPath)
+					continue
+				}
+				if os.Rename(tmpPath, thumbPath) == nil {
+					ok[i] = true
+				}
+			}
+		}()
+	}
+	for i := range files {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return ok
+}
+
+// htmlGalleryTemplate is the gallery page; styles and script are inlined so it works offline
+var htmlGalleryTemplate = template.Must(template.New("gallery").Funcs(template.FuncMap{
+	"isVideo": func(t string) bool { return t == "video" || t == "gif" },
+}).Parse(strings.TrimSpace(`
+<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>@{{.Username}} - media archive</title>
+<style>
+*{box-sizing:border-box}
+body{margin:0;font-family:system-ui,sans-serif;background:#111;color:#eee}
+header{padding:16px 20px;border-bottom:1px solid #333}
+header h1{margin:0;font-size:20px}
+header p{margin:4px 0 0;color:#999;font-size:13px}
+.grid{display:grid;grid-template-columns:repeat(auto-fill,minmax(180px,1fr));gap:6px;padding:12px}
+.tile{position:relative;aspect-ratio:1;background:#222;border-radius:6px;overflow:hidden;cursor:pointer;border:0;padding:0;color:#888}
+.tile img{width:100%;height:100%;object-fit:cover;display:block}
+.tile .badge{position:absolute;right:6px;bottom:6px;background:rgba(0,0,0,.7);color:#fff;font-size:11px;padding:2px 6px;border-radius:4px}
+.tile .placeholder{display:flex;align-items:center;justify-content:center;height:100%;font-size:13px;padding:8px;word-break:break-all}
+#lightbox{position:fixed;inset:0;background:rgba(0,0,0,.92);display:none;flex-direction:column;align-items:center;justify-content:center;padding:16px}
+#lightbox.open{display:flex}
+#lightbox .media{max-width:100%;max-height:72vh;display:flex}
+#lightbox .media img,#lightbox .media video{max-width:100%;max-height:72vh}
+#lightbox .caption{max-width:720px;margin-top:12px;font-size:14px;white-space:pre-wrap;text-align:center}
+#lightbox .meta{margin-top:8px;color:#999;font-size:12px;text-align:center}
+#lightbox .meta a{color:#8ab4f8;margin:0 6px}
+#lightbox .nav{position:fixed;top:50%;background:none;border:0;color:#fff;font-size:40px;cursor:pointer;padding:16px}
+#lightbox .prev{left:4px}
+#lightbox .next{right:4px}
+#lightbox .close{position:fixed;top:8px;right:12px;background:none;border:0;color:#fff;font-size:32px;cursor:pointer}
+</style>
+</head>
+<body>
+<header>
+<h1>@{{.Username}}</h1>
+<p>{{len .Items}} media from {{.Tweets}} tweets</p>
+</header>
+<main class="grid">
+{{- range $i, $item := .Items}}
+<button class="tile" data-index="{{$i}}" title="{{$item.Text}}">
+{{- if $item.Thumb}}<img src="{{$item.Thumb}}" alt="" loading="lazy">{{else}}<span class="placeholder">{{$item.Name}}</span>{{end -}}
+{{- if isVideo $item.Type}}<span class="badge">{{$item.Type}}</span>{{end -}}
+</button>
+{{- end}}
+</main>
+<div id="lightbox">
+<button class="close" aria-label="Close">&times;</button>
+<button class="nav prev" aria-label="Previous">&#8249;</button>
+<div class="media"></div>
+<div class="caption"></div>
+<div class="meta"></div>
+<button class="nav next" aria-label="Next">&#8250;</button>
+</div>
+<script>
+const items = {{.Items}};
+const box = document.getElementById("lightbox");
+const media = box.querySelector(".media");
+const caption = box.querySelector(".caption");
+const meta = box.querySelector(".meta");
+let current = -1;
+function link(href, text) {
+  const a = document.createElement("a");
+  a.href = href;
+  a.textContent = text;
+  a.target = "_blank";
+  a.rel = "noopener";
+  return a;
+}
+function show(index) {
+  current = (index + items.length) % items.length;
+  const item = items[current];
+  media.replaceChildren();
+  const el = document.createElement(item.Type === "video" || item.Type === "gif" ? "video" : "img");
+  el.src = item.File;
+  if (el.tagName === "VIDEO") {
+    el.controls = true;
+    el.autoplay = true;
+    el.loop = item.Type === "gif";
+  }
+  media.appendChild(el);
+  caption.textContent = item.Text || "";
+  meta.replaceChildren(document.createTextNode([item.Date, item.Stats].filter(Boolean).join(" · ")));
+  meta.appendChild(document.createElement("br"));
+  meta.appendChild(link(item.File, "Open file"));
+  if (item.TweetURL) meta.appendChild(link(item.TweetURL, "View tweet"));
+  if (item.Original) meta.appendChild(link(item.Original, "Original"));
+  box.classList.add("open");
+}
+function hide() {
+  box.classList.remove("open");
+  media.replaceChildren();
+  current = -1;
+}
+document.querySelectorAll(".tile").forEach((tile) => tile.addEventListener("click", () => show(Number(tile.dataset.index))));
+box.querySelector(".close").addEventListener("click", hide);
+box.querySelector(".prev").addEventListener("click", () => show(current - 1));
+box.querySelector(".next").addEventListener("click", () => show(current + 1));
+box.addEventListener("click", (e) => { if (e.target === box) hide(); });
+document.addEventListener("keydown", (e) => {
+  if (current < 0) return;
+  if (e.key === "Escape") hide();
+  if (e.key === "ArrowLeft") show(current - 1);
+  if (e.key === "ArrowRight") show(current + 1);
+});
+</script>
+</body>
+</html>
+`)))
@@ -0,0 +1,144 @@
+package backend
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Hydrus export
+//
+// Downloaded media can be exported for Hydrus Network: every file of an account folder is placed
+// in <export folder>/<username> (hard linked when the export is on the same drive, copied
+// otherwise) next to a <file>.txt sidecar with one tag per line, which a Hydrus import folder or
+// the manual import's sidecar option reads as tags. The tags come from the stored metadata:
+// creator:<username>, title:<first line of the tweet>, date:<YYYY-MM-DD>, tweet id:<id> and the
+// tweet's hashtags as plain tags. Files exported before are skipped.
+
+// HydrusExportResult describes a Hydrus export
+type HydrusExportResult struct {
+	Folder   string `json:"folder"`   // Folder of the exported files
+	Exported int    `json:"exported"` // New files with their sidecar
+	Skipped  int    `json:"skipped"`  // Files exported before
+}
+
+// hydrusHashtagPattern finds hashtags in tweet text
+var hydrusHashtagPattern = regexp.MustCompile(`(?:^|[^\p{L}\p{N}_&])#([\p{L}\p{N}_]+)`)
+
+// maxHydrusTitle keeps titles readable in the Hydrus tag list
+const maxHydrusTitle = 200
+
+// ExportHydrus exports the media of an account folder with Hydrus tag sidecars to exportDir/<username>
+func ExportHydrus(folder, exportDir string) (HydrusExportResult, error) {
+	folder = filepath.Clean(folder)
+	username := filepath.Base(folder)
+	if IsUsernameSensitive(username) && IsLocked() {
+		return HydrusExportResult{}, ErrContentLocked
+	}
+
+	page, err := ListGallery(folder, 0, 0)
+	if err != nil {
+		return HydrusExportResult{}, err
+	}
+	outDir := filepath.Join(exportDir, username)
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return HydrusExportResult{}, fmt.Errorf("failed to create export folder: %v", err)
+	}
+
+	tweets := archivedTweets(folder, username)
+	result := HydrusExportResult{Folder: outDir}
+	for _, item := range page.Items {
+		if item.Type == "text" {
+			continue
+		}
+		target := filepath.Join(outDir, item.Name)
+		if _, err := os.Stat(target); err == nil {
+			result.Skipped++
+			continue
+		}
+		if err := linkOrCopyFile(item.Path, target); err != nil {
+			return result, fmt.Errorf("failed to export %s: %v", item.Name, err)
+		}
+		tags := hydrusTags(username, item, tweets[item.TweetID])
+		if err := os.WriteFile(target+".txt", []byte(strings.Join(tags, "\n")+"\n"), 0644); err != nil {
+			return result, fmt.Errorf("failed to write sidecar of %s: %v", item.Name, err)
+		}
+		result.Exported++
+	}
+	return result, nil
+}
+
+// hydrusTags returns the tags of a downloaded file
+func hydrusTags(username string, item GalleryItem, tweet *archivedTweet) []string {
+	creator := username
+	date := item.Date
+	text := ""
+	if tweet != nil {
+		if tweet.Author != "" {
+			creator = tweet.Author
+		}
+		if tweet.Date != "" {
+			date = tweet.Date
+		}
+		text = tweet.Text
+	}
+
+	tags := []string{"creator:" + strings.ToLower(creator)}
+	if title := hydrusTitle(text); title != "" {
+		tags = append(tags, "title:"+title)
+	}
+	if len(date) >= 10 {
+		tags = append(tags, "date:"+date[:10])
+	}
+	if item.TweetID != "" {
+		tags = append(tags, "tweet id:"+item.TweetID)
+	}
+	seen := make(map[string]bool)
+	for _, m := range hydrusHashtagPattern.FindAllStringSubmatch(text, -1) {
+		tag := strings.ToLower(m[1])
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
+// hydrusTitle is the first line of a tweet without its trailing t.co link
+func hydrusTitle(text string) string {
+	line, _, _ := strings.Cut(strings.TrimSpace(text), "\n")
+	fields := strings.Fields(line)
+	if n := len(fields); n > 0 && strings.HasPrefix(fields[n-1], "https://t.co/") {
+		fields = fields[:n-1]
+	}
+	title := strings.Join(fields, " ")
+	if runes := []rune(title); len(runes) > maxHydrusTitle {
+		title = strings.TrimSpace(string(runes[:maxHydrusTitle])) + "…"
+	}
+	return title
+}
+
+// linkOrCopyFile hard links src to dst, or copies it when linking isn't possible (other drive)
+func linkOrCopyFile(src, dst string) error {
+	if err := os.Link(src, dst); err == nil {
+		return nil
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dst)
+		return err
+	}
+	return out.Close()
+}
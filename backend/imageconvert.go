@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Image formats for WebP conversion
+const (
+	ImageFormatJPEG = "jpg"
+	ImageFormatPNG  = "png"
+)
+
+// defaultJPEGQuality is used when no quality is given
+const defaultJPEGQuality = 90
+
+// ConvertImage converts an image (e.g. WebP) to JPEG or PNG with ffmpeg
+// quality (1-100) only applies to JPEG, 0 = default
+func ConvertImage(inputPath, outputPath string, quality int) error {
+	if !IsFFmpegInstalled() {
+		return fmt.Errorf("ffmpeg not installed")
+	}
+
+	args := []string{"-i", inputPath, "-frames:v", "1"}
+	switch strings.ToLower(filepath.Ext(outputPath)) {
+	case ".jpg", ".jpeg":
+		if quality <= 0 || quality > 100 {
+			quality = defaultJPEGQuality
+		}
+		// ffmpeg's JPEG scale runs from 2 (best) to 31 (worst)
+		args = append(args, "-q:v", fmt.Sprintf("%d", 2+(100-quality)*29/99))
+	case ".png":
+	default:
+		return fmt.Errorf("unsupported image format: %s", filepath.Ext(outputPath))
+	}
+
+	// Write to a temporary file first so a failed conversion leaves nothing behind
+	tmpPath := outputPath + ".converting" + filepath.Ext(outputPath)
+	args = append(args, "-y", tmpPath)
+	cmd := exec.Command(ffmpegBinary(), args...)
+	hideWindow(cmd)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("ffmpeg error: %v, output: %s", err, string(output))
+	}
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// ConvertWebPImages converts WebP images (folders are searched recursively) to JPEG or PNG next to them
+// The WebP files are removed after a successful conversion unless keepOriginal is set
+func ConvertWebPImages(paths []string, format string, quality int, keepOriginal bool, workers int) (converted int, failed int, err error) {
+	if !IsFFmpegInstalled() {
+		return 0, 0, fmt.Errorf("ffmpeg not installed")
+	}
+	if format != ImageFormatJPEG && format != ImageFormatPNG {
+		return 0, 0, fmt.Errorf("unsupported image format: %s", format)
+	}
+
+	var inputs []string
+	for _, path := range paths {
+		cleanPath := filepath.Clean(path)
+		info, err := os.Stat(cleanPath)
+		if err != nil {
+			return 0, 0, fmt.Errorf("file not found: %s", cleanPath)
+		}
+		if !info.IsDir() {
+			inputs = append(inputs, cleanPath)
+			continue
+		}
+		filepath.WalkDir(cleanPath, func(p string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() && strings.EqualFold(filepath.Ext(p), ".webp") {
+				inputs = append(inputs, p)
+			}
+			return nil
+		})
+	}
+	if len(inputs) == 0 {
+		return 0, 0, fmt.Errorf("no WebP images found")
+	}
+
+	converted, failed = runFFmpegPool(inputs, workers, func(inputPath string) error {
+		outputPath := strings.TrimSuffix(inputPath, filepath.Ext(inputPath)) + "." + format
+		if err := ConvertImage(inputPath, outputPath, quality); err != nil {
+			return err
+		}
+		if !keepOriginal {
+			os.Remove(inputPath)
+		}
+		return nil
+	})
+	return converted, failed, nil
+}
@@ -0,0 +1,159 @@
+package backend
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Offline tool installation
+//
+// Machines without internet access (or where SourceForge/GitHub are blocked) can't use
+// DownloadFFmpeg or DownloadExifTool. ImportTool installs the same tools from an archive or
+// binary the user downloaded elsewhere: it's extracted into a staging folder, run once to make
+// sure it works on this machine, then moved into place and its checksum recorded like a
+// downloaded tool.
+
+// Tools that can be imported
+const (
+	ToolFFmpeg   = "ffmpeg"
+	ToolExifTool = "exiftool"
+)
+
+// ImportTool installs ffmpeg or exiftool from a local archive (.zip, .tar.xz, .tar.gz) or binary
+func ImportTool(path, tool string) error {
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("file not found: %s", path)
+	}
+	staging, err := newToolStaging(".import")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	switch tool {
+	case ToolFFmpeg:
+		return importFFmpeg(path, staging)
+	case ToolExifTool:
+		return importExifTool(path, staging)
+	default:
+		return fmt.Errorf("unknown tool: %s", tool)
+	}
+}
+
+// importFFmpeg installs ffmpeg (and ffprobe, if the archive has it) next to the downloaded tools
+func importFFmpeg(path, staging string) error {
+	ffmpegPath := GetFFmpegPath()
+	stagedFFmpeg := filepath.Join(staging, filepath.Base(ffmpegPath))
+	stagedFFprobe := filepath.Join(staging, filepath.Base(bundledFFprobePath()))
+
+	var err, probeErr error
+	switch archiveKind(path) {
+	case "zip":
+		err = extractFromZip(path, stagedFFmpeg)
+		probeErr = extractFromZip(path, stagedFFprobe)
+	case "tar.xz":
+		err = extractFromTarXz(path, stagedFFmpeg)
+		probeErr = extractFromTarXz(path, stagedFFprobe)
+	case "":
+		err = copyExecutable(path, stagedFFmpeg)
+		probeErr = fmt.Errorf("not an archive")
+	default:
+		return fmt.Errorf("unsupported archive for ffmpeg: %s (use the .zip or .tar.xz build)", filepath.Base(path))
+	}
+	if err != nil {
+		return err
+	}
+	if err := installStaged(stagedFFmpeg, ffmpegPath, ToolManifestEntry{}, "-version"); err != nil {
+		return err
+	}
+	if probeErr == nil && checkToolRuns(stagedFFprobe, "-version") == nil {
+		if err := installStaged(stagedFFprobe, bundledFFprobePath(), ToolManifestEntry{}, ""); err != nil {
+			fmt.Printf("Warning: failed to install ffprobe: %v\n", err)
+		}
+	}
+	return nil
+}
+
+// importExifTool installs exiftool (the Windows executable or the Perl distribution)
+func importExifTool(path, staging string) error {
+	switch archiveKind(path) {
+	case "zip":
+		if runtime.GOOS != "windows" {
+			return fmt.Errorf("the .zip release of exiftool is for Windows, use the .tar.gz release")
+		}
+		if err := extractExifToolFromZip(path, filepath.Join(staging, "exiftool.exe")); err != nil {
+			return err
+		}
+		return installStagedExifTool(staging, ToolManifestEntry{})
+	case "tar.gz":
+		if runtime.GOOS == "windows" {
+			return fmt.Errorf("the .tar.gz release of exiftool needs Perl, use the Windows .zip release")
+		}
+		if !IsPerlAvailable() {
+			return errPerlMissing
+		}
+		if err := extractExifToolFromTarGz(path, filepath.Join(staging, "exiftool")); err != nil {
+			return err
+		}
+		return installStagedExifTool(staging, ToolManifestEntry{})
+	default:
+		return fmt.Errorf("unsupported archive for exiftool: %s (use the .zip or .tar.gz release)", filepath.Base(path))
+	}
+}
+
+// archiveKind returns "zip", "tar.xz", "tar.gz" or another extension for archives, "" for anything else
+func archiveKind(path string) string {
+	name := strings.ToLower(filepath.Base(path))
+	switch {
+	case strings.HasSuffix(name, ".zip"):
+		return "zip"
+	case strings.HasSuffix(name, ".tar.xz"), strings.HasSuffix(name, ".txz"):
+		return "tar.xz"
+	case strings.HasSuffix(name, ".tar.gz"), strings.HasSuffix(name, ".tgz"):
+		return "tar.gz"
+	case strings.HasSuffix(name, ".7z"), strings.HasSuffix(name, ".rar"), strings.HasSuffix(name, ".tar"), strings.HasSuffix(name, ".dmg"):
+		return strings.TrimPrefix(filepath.Ext(name), ".")
+	}
+	return ""
+}
+
+// copyExecutable copies a bare binary into place and makes it executable
+func copyExecutable(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return fmt.Errorf("failed to create output file: %v", err)
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return fmt.Errorf("failed to copy file: %v", err)
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if runtime.GOOS != "windows" {
+		os.Chmod(dst, 0755)
+	}
+	return nil
+}
+
+// checkToolRuns runs a staged tool with a version flag to make sure it works on this machine
+func checkToolRuns(path string, versionFlag string) error {
+	cmd := exec.Command(path, versionFlag)
+	hideWindow(cmd)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s doesn't run on this machine (wrong platform or damaged file?): %v, output: %s",
+			filepath.Base(path), err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
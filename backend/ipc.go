@@ -0,0 +1,299 @@
+package backend
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// JSON-over-stdio interface for third-party frontends
+//
+// Start the app with --ipc. Each line on stdin is a request:
+//
+//	{"id": 1, "method": "extract_timeline", "params": {...TimelineRequest}}
+//
+// Each line on stdout is a message tagged with the request id:
+//
+//	{"id": 1, "type": "event", "event": "download-progress", "data": {...}}
+//	{"id": 1, "type": "result", "data": {...}}
+//	{"id": 1, "type": "error", "error": "..."}
+//
+// Warnings and other output of the backend go to stderr, stdout carries nothing but messages.
+//
+// Methods: ping, extract_timeline, extract_date_range, extract_parallel, download, cancel, resolve_conflict
+// Event names match the ones emitted to the built-in frontend.
+
+// IPCRequest is a single command read from stdin
+type IPCRequest struct {
+	ID     int64           `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params,omitempty"`
+}
+
+// IPCMessage is a single message written to stdout
+type IPCMessage struct {
+	ID    int64       `json:"id"`
+	Type  string      `json:"type"` // "event", "result", "error"
+	Event string      `json:"event,omitempty"`
+	Data  interface{} `json:"data,omitempty"`
+	Error string      `json:"error,omitempty"`
+}
+
+// IPCDownloadParams are the params of the "download" method
+type IPCDownloadParams struct {
+	Items     []MediaItem     `json:"items"`
+	OutputDir string          `json:"output_dir"`
+	Username  string          `json:"username"`
+	Proxy     string          `json:"proxy,omitempty"`
+	Options   DownloadOptions `json:"options"`
+}
+
+// IPCCancelParams are the params of the "cancel" method
+type IPCCancelParams struct {
+	TargetID int64 `json:"target_id"` // ID of the request to cancel
+}
+
+// IPCResolveConflictParams are the params of the "resolve_conflict" method
+type IPCResolveConflictParams struct {
+	TargetID   int64  `json:"target_id"` // ID of the download request that emitted the conflict
+	ConflictID string `json:"conflict_id"`
+	Action     string `json:"action"` // skip, overwrite, keep_both
+	ApplyToAll bool   `json:"apply_to_all"`
+}
+
+// IPCDownloadResult is the result of the "download" method
+type IPCDownloadResult struct {
+	Downloaded int `json:"downloaded"`
+	Skipped    int `json:"skipped"`
+	Failed     int `json:"failed"`
+}
+
+// ipcServer handles requests from one stdin/stdout pair
+type ipcServer struct {
+	out     *json.Encoder
+	outMu   sync.Mutex
+	cancels map[int64]context.CancelFunc
+	answers map[string]chan ConflictDecision // "{request id}:{conflict id}" -> pending conflict
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+}
+
+// ServeIPC reads requests from r and writes events/results to w until r is closed
+// Requests run concurrently; use "cancel" to stop a running download
+func ServeIPC(r io.Reader, w io.Writer) error {
+	s := &ipcServer{
+		out:     json.NewEncoder(w),
+		cancels: make(map[int64]context.CancelFunc),
+		answers: make(map[string]chan ConflictDecision),
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024) // Download requests can carry many items
+
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+
+		var req IPCRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.send(IPCMessage{Type: "error", Error: fmt.Sprintf("invalid request: %v", err)})
+			continue
+		}
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(req)
+		}()
+	}
+
+	s.wg.Wait()
+	return scanner.Err()
+}
+
+// send writes a single message as one JSON line
+func (s *ipcServer) send(msg IPCMessage) {
+	s.outMu.Lock()
+	defer s.outMu.Unlock()
+	s.out.Encode(msg)
+}
+
+// event sends a progress event for a request
+func (s *ipcServer) event(id int64, name string, data interface{}) {
+	s.send(IPCMessage{ID: id, Type: "event", Event: name, Data: data})
+}
+
+// extractProgress returns a callback sending extraction progress as "extract-progress" events
+func (s *ipcServer) extractProgress(id int64) func(ExtractProgress) {
+	return func(progress ExtractProgress) {
+		s.event(id, "extract-progress", progress)
+	}
+}
+
+// handle dispatches a request and sends its result or error
+func (s *ipcServer) handle(req IPCRequest) {
+	result, err := s.dispatch(req)
+	if err != nil {
+		s.send(IPCMessage{ID: req.ID, Type: "error", Error: err.Error()})
+		return
+	}
+	s.send(IPCMessage{ID: req.ID, Type: "result", Data: result})
+}
+
+// dispatch runs the method named in the request
+func (s *ipcServer) dispatch(req IPCRequest) (interface{}, error) {
+	switch req.Method {
+	case "ping":
+		return "pong", nil
+
+	case "extract_timeline":
+		var params TimelineRequest
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid params: %v", err)
+		}
+		s.event(req.ID, "extract-started", params.Username)
+		params.OnProgress = s.extractProgress(req.ID)
+		started := time.Now()
+		response, err := ExtractTimeline(params)
+		RecordExtraction(params.Username, started, response, err)
+		return response, err
+
+	case "extract_date_range":
+		var params DateRangeRequest
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid params: %v", err)
+		}
+		s.event(req.ID, "extract-started", params.Username)
+		params.OnProgress = s.extractProgress(req.ID)
+		started := time.Now()
+		response, err := ExtractDateRange(params)
+		RecordExtraction(params.Username, started, response, err)
+		return response, err
+
+	case "extract_parallel":
+		var params ParallelRequest
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid params: %v", err)
+		}
+		s.event(req.ID, "extract-started", params.Username)
+		params.OnProgress = s.extractProgress(req.ID)
+		started := time.Now()
+		response, err := ExtractParallel(params)
+		var merged *TwitterResponse
+		if response != nil {
+			merged = response.TwitterResponse
+		}
+		RecordExtraction(params.Username, started, merged, err)
+		return response, err
+
+	case "download":
+		var params IPCDownloadParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid params: %v", err)
+		}
+		return s.download(req.ID, params)
+
+	case "cancel":
+		var params IPCCancelParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid params: %v", err)
+		}
+		s.mu.Lock()
+		cancel, ok := s.cancels[params.TargetID]
+		s.mu.Unlock()
+		if ok {
+			cancel()
+		}
+		return ok, nil
+
+	case "resolve_conflict":
+		var params IPCResolveConflictParams
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, fmt.Errorf("invalid params: %v", err)
+		}
+		key := fmt.Sprintf("%d:%s", params.TargetID, params.ConflictID)
+		s.mu.Lock()
+		answer, ok := s.answers[key]
+		delete(s.answers, key)
+		s.mu.Unlock()
+		if ok {
+			answer <- ConflictDecision{Action: params.Action, ApplyToAll: params.ApplyToAll}
+		}
+		return ok, nil
+
+	default:
+		return nil, fmt.Errorf("unknown method: %s", req.Method)
+	}
+}
+
+// download runs a download batch, streaming progress and per-item status events
+func (s *ipcServer) download(id int64, params IPCDownloadParams) (interface{}, error) {
+	if len(params.Items) == 0 {
+		return nil, fmt.Errorf("no items provided")
+	}
+	outputDir := params.OutputDir
+	if outputDir == "" {
+		outputDir = GetDefaultDownloadPath()
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	s.mu.Lock()
+	s.cancels[id] = cancel
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		delete(s.cancels, id)
+		s.mu.Unlock()
+		cancel()
+	}()
+
+	progress := func(current, total int) {
+		percent := 0
+		if total > 0 {
+			percent = (current * 100) / total
+		}
+		s.event(id, "download-progress", map[string]int{"current": current, "total": total, "percent": percent})
+	}
+	itemStatus := func(tweetID int64, index int, status string) {
+		s.event(id, "download-item-status", map[string]interface{}{"tweet_id": tweetID, "index": index, "status": status})
+	}
+
+	if params.Options.Conflict == ConflictAsk {
+		params.Options.Resolver = func(ctx context.Context, conflict FileConflict) ConflictDecision {
+			return s.askConflict(ctx, id, conflict)
+		}
+	}
+
+	downloaded, skipped, failed, err := DownloadMediaWithMetadataProgressAndStatus(params.Items, outputDir, params.Username, progress, itemStatus, ctx, params.Proxy, params.Options)
+	if err != nil {
+		return nil, err
+	}
+	return IPCDownloadResult{Downloaded: downloaded, Skipped: skipped, Failed: failed}, nil
+}
+
+// askConflict emits a "download-conflict" event and waits for a matching "resolve_conflict" request
+func (s *ipcServer) askConflict(ctx context.Context, id int64, conflict FileConflict) ConflictDecision {
+	key := fmt.Sprintf("%d:%s", id, conflict.ID)
+	answer := make(chan ConflictDecision, 1)
+	s.mu.Lock()
+	s.answers[key] = answer
+	s.mu.Unlock()
+
+	s.event(id, "download-conflict", conflict)
+
+	select {
+	case decision := <-answer:
+		return decision
+	case <-ctx.Done():
+		s.mu.Lock()
+		delete(s.answers, key)
+		s.mu.Unlock()
+		return ConflictDecision{Action: ConflictSkip}
+	}
+}
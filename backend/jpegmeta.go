@@ -0,0 +1,172 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// Native JPEG metadata
+//
+// The bundled exiftool is a Perl script on Linux and macOS, so without Perl nothing gets
+// embedded. For JPEGs the basic tags are simple enough to write directly: the comment (tweet URL
+// and original filename) goes into the JPEG COM segment, like exiftool's -Comment, and the
+// posting date into a minimal Exif segment with DateTimeOriginal and OffsetTimeOriginal. Files
+// that already carry Exif data keep it untouched and only get the comment.
+
+// JPEG markers used when rewriting segments
+const (
+	jpegSOI     = 0xD8
+	jpegSOS     = 0xDA
+	jpegAPP0    = 0xE0
+	jpegAPP1    = 0xE1
+	jpegAPP15   = 0xEF
+	jpegCOM     = 0xFE
+	jpegMaxData = 0xFFFF - 2 // Segment length includes its own two bytes
+)
+
+// jpegSegment is a marker segment before the image data
+type jpegSegment struct {
+	marker byte
+	data   []byte
+}
+
+// embedJPEGMetadataNative writes the comment and posting date of a JPEG without exiftool
+func embedJPEGMetadataNative(filePath, comment string, posted time.Time) error {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return err
+	}
+	segments, imageData, err := splitJPEG(data)
+	if err != nil {
+		return err
+	}
+
+	// Existing comments are replaced, like exiftool -Comment= does
+	var kept []jpegSegment
+	hasExif := false
+	for _, seg := range segments {
+		if seg.marker == jpegCOM {
+			continue
+		}
+		if seg.marker == jpegAPP1 && bytes.HasPrefix(seg.data, []byte("Exif\x00\x00")) {
+			hasExif = true
+		}
+		kept = append(kept, seg)
+	}
+
+	// Exif goes right after the JFIF header, the comment after all application segments
+	if !posted.IsZero() && !hasExif {
+		exif := jpegSegment{marker: jpegAPP1, data: buildExifDate(posted)}
+		at := 0
+		if len(kept) > 0 && kept[0].marker == jpegAPP0 {
+			at = 1
+		}
+		kept = append(kept[:at], append([]jpegSegment{exif}, kept[at:]...)...)
+	}
+	if comment != "" {
+		if len(comment) > jpegMaxData {
+			comment = comment[:jpegMaxData]
+		}
+		at := 0
+		for at < len(kept) && kept[at].marker >= jpegAPP0 && kept[at].marker <= jpegAPP15 {
+			at++
+		}
+		kept = append(kept[:at], append([]jpegSegment{{marker: jpegCOM, data: []byte(comment)}}, kept[at:]...)...)
+	}
+
+	var out bytes.Buffer
+	out.Write([]byte{0xFF, jpegSOI})
+	for _, seg := range kept {
+		out.Write([]byte{0xFF, seg.marker})
+		binary.Write(&out, binary.BigEndian, uint16(len(seg.data)+2))
+		out.Write(seg.data)
+	}
+	out.Write(imageData)
+
+	// Replace the file only once the new one is completely written
+	tmp := filePath + ".meta.tmp"
+	if err := os.WriteFile(tmp, out.Bytes(), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %v", filepath.Base(filePath), err)
+	}
+	if err := os.Rename(tmp, filePath); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("failed to replace %s: %v", filepath.Base(filePath), err)
+	}
+	return nil
+}
+
+// splitJPEG returns the marker segments of a JPEG and everything from the start of scan on
+func splitJPEG(data []byte) ([]jpegSegment, []byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != jpegSOI {
+		return nil, nil, fmt.Errorf("not a JPEG file")
+	}
+	var segments []jpegSegment
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return nil, nil, fmt.Errorf("corrupt JPEG segment at %d", pos)
+		}
+		marker := data[pos+1]
+		if marker == 0xFF {
+			// Fill byte
+			pos++
+			continue
+		}
+		if marker == jpegSOS {
+			return segments, data[pos:], nil
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			return nil, nil, fmt.Errorf("corrupt JPEG segment at %d", pos)
+		}
+		segments = append(segments, jpegSegment{marker: marker, data: data[pos+4 : pos+2+length]})
+		pos += 2 + length
+	}
+	return nil, nil, fmt.Errorf("JPEG has no image data")
+}
+
+// buildExifDate builds an Exif APP1 payload with only DateTimeOriginal and OffsetTimeOriginal
+func buildExifDate(posted time.Time) []byte {
+	dateValue := posted.Format("2006:01:02 15:04:05") + "\x00" // 20 bytes
+	offsetValue := posted.Format("-07:00") + "\x00"            // 7 bytes
+
+	const (
+		ifd0Offset   = 8
+		exifOffset   = ifd0Offset + 2 + 12 + 4 // IFD0: count, one entry, next IFD
+		valuesOffset = exifOffset + 2 + 2*12 + 4
+	)
+
+	var b bytes.Buffer
+	b.WriteString("Exif\x00\x00")
+	// TIFF header, big endian
+	b.WriteString("MM")
+	binary.Write(&b, binary.BigEndian, uint16(42))
+	binary.Write(&b, binary.BigEndian, uint32(ifd0Offset))
+
+	// IFD0 with just the pointer to the Exif IFD
+	binary.Write(&b, binary.BigEndian, uint16(1))
+	writeIFDEntry(&b, 0x8769, 4, 1, exifOffset) // ExifIFDPointer, LONG
+	binary.Write(&b, binary.BigEndian, uint32(0))
+
+	// Exif IFD, values stored after it
+	binary.Write(&b, binary.BigEndian, uint16(2))
+	writeIFDEntry(&b, 0x9003, 2, uint32(len(dateValue)), valuesOffset)                  // DateTimeOriginal, ASCII
+	writeIFDEntry(&b, 0x9011, 2, uint32(len(offsetValue)), valuesOffset+len(dateValue)) // OffsetTimeOriginal, ASCII
+	binary.Write(&b, binary.BigEndian, uint32(0))
+
+	b.WriteString(dateValue)
+	b.WriteString(offsetValue)
+	return b.Bytes()
+}
+
+// writeIFDEntry writes a 12-byte TIFF directory entry whose value is at offset (or is offset, for LONG)
+func writeIFDEntry(b *bytes.Buffer, tag, typ uint16, count uint32, offset int) {
+	binary.Write(b, binary.BigEndian, tag)
+	binary.Write(b, binary.BigEndian, typ)
+	binary.Write(b, binary.BigEndian, count)
+	binary.Write(b, binary.BigEndian, uint32(offset))
+}
@@ -0,0 +1,350 @@
+package backend
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Secret storage
+//
+// Auth tokens used to live in the frontend's local storage, readable by anyone with access to the
+// profile folder. They're now kept in the OS keychain where there is one the app can reach
+// without extra dependencies: the macOS Keychain through `security` and the Secret Service
+// (GNOME Keyring, KWallet) through libsecret's `secret-tool`. Everywhere else, and when the
+// keychain can't be used, they go to secrets.json in the app data folder, encrypted with DPAPI on
+// Windows. On other systems the file is only obfuscated with a key kept next to it (secrets.key),
+// which doesn't protect it from anyone who can read the app data folder. Stored secrets are
+// masked in debug bundles.
+//
+// The same store keeps the S3 secret keys and WebDAV passwords of saved download jobs: queue and
+// failed-items files only name the target, and the credential is looked up again when the job is
+// loaded. A job whose credential is gone asks for it again when it's resumed.
+
+const (
+	keychainService = "XDown"
+	keychainTimeout = 10 * time.Second
+)
+
+// secretNames are the secrets the frontend may store
+var secretNames = map[string]bool{
+	"public_auth_token":     true, // Public mode (timelines of other accounts)
+	"private_auth_token":    true, // Private mode (own bookmarks and likes)
+	"restricted_auth_token": true, // Age-verified account, see restricted.go
+}
+
+// errSecretNotFound is returned by the keychain backends for a missing secret
+var errSecretNotFound = errors.New("secret not found")
+
+var secretsMu sync.Mutex
+
+// GetSecretStorage returns where secrets are stored: "Keychain", "Secret Service" or the file fallback
+func GetSecretStorage() string {
+	if name := keychainName(); name != "" {
+		return name
+	}
+	return secretFileName
+}
+
+// GetSecret returns a stored secret, "" if it isn't set
+func GetSecret(name string) (string, error) {
+	if !secretNames[name] {
+		return "", fmt.Errorf("unknown secret: %s", name)
+	}
+	return getSecret(name)
+}
+
+// getSecret returns a stored secret of any name, "" if it isn't set
+func getSecret(name string) (string, error) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	if keychainName() != "" {
+		value, err := keychainGet(name)
+		if err == nil {
+			return value, nil
+		}
+		if !errors.Is(err, errSecretNotFound) {
+			fmt.Printf("Warning: failed to read %s from the keychain: %v\n", name, err)
+		}
+	}
+	secrets, err := loadSecretFile()
+	if err != nil {
+		return "", err
+	}
+	return secrets[name], nil
+}
+
+// SetSecret stores a secret, removing it with an empty value
+func SetSecret(name, value string) error {
+	if !secretNames[name] {
+		return fmt.Errorf("unknown secret: %s", name)
+	}
+	return setSecret(name, value)
+}
+
+// setSecret stores a secret of any name, removing it with an empty value
+func setSecret(name, value string) error {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+
+	secrets, err := loadSecretFile()
+	if err != nil {
+		secrets = make(map[string]string) // Unreadable, e.g. copied from another machine
+	}
+	if keychainName() != "" {
+		if value == "" {
+			err = keychainDelete(name)
+			if errors.Is(err, errSecretNotFound) {
+				err = nil
+			}
+		} else {
+			err = keychainSet(name, value)
+		}
+		if err == nil {
+			// Don't keep a second copy in the file
+			if _, ok := secrets[name]; !ok {
+				return nil
+			}
+			delete(secrets, name)
+			return saveSecretFile(secrets)
+		}
+		fmt.Printf("Warning: failed to store %s in the keychain, using %s: %v\n", name, secretFileName, err)
+	}
+
+	if value == "" {
+		delete(secrets, name)
+	} else {
+		secrets[name] = value
+	}
+	return saveSecretFile(secrets)
+}
+
+// storageSecretNames returns the secret names of the S3 and WebDAV credentials of download options
+func storageSecretNames(opts DownloadOptions) (s3Name, webdavName string) {
+	if opts.S3 != nil {
+		s3Name = fmt.Sprintf("s3:%s@%s/%s", opts.S3.AccessKey, opts.S3.Endpoint, opts.S3.Bucket)
+	}
+	if opts.WebDAV != nil {
+		webdavName = fmt.Sprintf("webdav:%s@%s", opts.WebDAV.User, opts.WebDAV.URL)
+	}
+	return s3Name, webdavName
+}
+
+// stashStorageSecrets moves the S3 and WebDAV credentials of download options to the secret
+// store, for options that are written to disk
+func stashStorageSecrets(opts DownloadOptions) DownloadOptions {
+	s3Name, webdavName := storageSecretNames(opts)
+	if opts.S3 != nil && opts.S3.SecretKey != "" {
+		if err := setSecret(s3Name, opts.S3.SecretKey); err != nil {
+			fmt.Printf("Warning: failed to store the S3 secret key: %v\n", err)
+		}
+		s3 := *opts.S3
+		s3.SecretKey = ""
+		opts.S3 = &s3
+	}
+	if opts.WebDAV != nil && opts.WebDAV.Password != "" {
+		if err := setSecret(webdavName, opts.WebDAV.Password); err != nil {
+			fmt.Printf("Warning: failed to store the WebDAV password: %v\n", err)
+		}
+		webdav := *opts.WebDAV
+		webdav.Password = ""
+		opts.WebDAV = &webdav
+	}
+	return opts
+}
+
+// restoreStorageSecrets fills in the S3 and WebDAV credentials of download options read from disk
+func restoreStorageSecrets(opts DownloadOptions) DownloadOptions {
+	s3Name, webdavName := storageSecretNames(opts)
+	if opts.S3 != nil && opts.S3.SecretKey == "" {
+		if value, err := getSecret(s3Name); err == nil && value != "" {
+			s3 := *opts.S3
+			s3.SecretKey = value
+			opts.S3 = &s3
+		}
+	}
+	if opts.WebDAV != nil && opts.WebDAV.Password == "" {
+		if value, err := getSecret(webdavName); err == nil && value != "" {
+			webdav := *opts.WebDAV
+			webdav.Password = value
+			opts.WebDAV = &webdav
+		}
+	}
+	return opts
+}
+
+// storedSecrets returns the values of all stored secrets, for masking them
+func storedSecrets() []string {
+	var values []string
+	for name := range secretNames {
+		value, err := GetSecret(name)
+		if err != nil || value == "" {
+			continue
+		}
+		values = append(values, value)
+		values = append(values, splitAuthTokens(value)...) // Lists for token rotation
+	}
+	return values
+}
+
+// getSecretFilePath returns the path of the fallback secret file
+func getSecretFilePath() string {
+	return filepath.Join(GetAppDataDir(), "secrets.json")
+}
+
+// loadSecretFile reads the fallback secret file (secretsMu must be held)
+func loadSecretFile() (map[string]string, error) {
+	secrets := make(map[string]string)
+	data, err := os.ReadFile(getSecretFilePath())
+	if os.IsNotExist(err) {
+		return secrets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read secrets: %v", err)
+	}
+	var stored map[string]string
+	if err := json.Unmarshal(data, &stored); err != nil {
+		return nil, fmt.Errorf("failed to read secrets: %v", err)
+	}
+	for name, encoded := range stored {
+		sealed, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read secrets: %v", err)
+		}
+		value, err := unprotectSecret(sealed)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decrypt secrets: %v", err)
+		}
+		secrets[name] = string(value)
+	}
+	return secrets, nil
+}
+
+// saveSecretFile writes the fallback secret file (secretsMu must be held)
+func saveSecretFile(secrets map[string]string) error {
+	if len(secrets) == 0 {
+		if err := os.Remove(getSecretFilePath()); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to remove secrets: %v", err)
+		}
+		return nil
+	}
+	stored := make(map[string]string, len(secrets))
+	for name, value := range secrets {
+		sealed, err := protectSecret([]byte(value))
+		if err != nil {
+			return fmt.Errorf("failed to encrypt secrets: %v", err)
+		}
+		stored[name] = base64.StdEncoding.EncodeToString(sealed)
+	}
+	data, err := json.MarshalIndent(stored, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(GetAppDataDir(), 0755); err != nil {
+		return err
+	}
+	return writeAtomically(getSecretFilePath(), func(w io.Writer) error {
+		_, err := w.Write(data)
+		return err
+	})
+}
+
+// keychainName returns the name of the OS keychain secrets go to, "" if there is none
+func keychainName() string {
+	switch runtime.GOOS {
+	case "darwin":
+		if _, err := exec.LookPath("security"); err == nil {
+			return "Keychain"
+		}
+	case "linux", "freebsd", "openbsd", "netbsd":
+		// secret-tool needs a session bus to reach the keyring daemon
+		if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+			return ""
+		}
+		if _, err := exec.LookPath("secret-tool"); err == nil {
+			return "Secret Service"
+		}
+	}
+	return ""
+}
+
+// keychainCommand runs a keychain tool with stdin as its input and returns its output
+func keychainCommand(stdin string, name string, args ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keychainTimeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, name, args...)
+	hideWindow(cmd)
+	cmd.Stdin = strings.NewReader(stdin)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			// security: 44 = item not found; secret-tool lookup: 1 with no output
+			if (name == "security" && exitErr.ExitCode() == 44) || (name == "secret-tool" && exitErr.ExitCode() == 1 && stderr.Len() == 0) {
+				return "", errSecretNotFound
+			}
+		}
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %s", name, msg)
+		}
+		return "", err
+	}
+	return string(out), nil
+}
+
+func keychainGet(name string) (string, error) {
+	var out string
+	var err error
+	if runtime.GOOS == "darwin" {
+		out, err = keychainCommand("", "security", "find-generic-password", "-s", keychainService, "-a", name, "-w")
+		out = strings.TrimSuffix(out, "\n")
+	} else {
+		out, err = keychainCommand("", "secret-tool", "lookup", "service", keychainService, "account", name)
+	}
+	if err != nil {
+		return "", err
+	}
+	if out == "" {
+		return "", errSecretNotFound
+	}
+	return out, nil
+}
+
+func keychainSet(name, value string) error {
+	if runtime.GOOS == "darwin" {
+		// Passed through stdin in interactive mode so the secret never shows up in the process list
+		command := fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n", quoteSecurityArg(keychainService), quoteSecurityArg(name), quoteSecurityArg(value))
+		_, err := keychainCommand(command, "security", "-i")
+		return err
+	}
+	_, err := keychainCommand(value, "secret-tool", "store", "--label="+keychainService+" "+name, "service", keychainService, "account", name)
+	return err
+}
+
+func keychainDelete(name string) error {
+	var err error
+	if runtime.GOOS == "darwin" {
+		_, err = keychainCommand("", "security", "delete-generic-password", "-s", keychainService, "-a", name)
+	} else {
+		_, err = keychainCommand("", "secret-tool", "clear", "service", keychainService, "account", name)
+	}
+	return err
+}
+
+// quoteSecurityArg quotes an argument of a `security -i` command
+func quoteSecurityArg(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
@@ -0,0 +1,107 @@
+package backend
+
+import (
+	"container/list"
+	"sync"
+)
+
+// lruCache is a size-bounded cache that evicts the least recently used entries first
+// Each entry has a cost (e.g. bytes); entries are evicted until the total fits the capacity
+type lruCache[K comparable, V any] struct {
+	capacity int64
+	onEvict  func(key K, value V)
+
+	mu      sync.Mutex
+	used    int64
+	order   *list.List // Front is the most recently used
+	entries map[K]*list.Element
+}
+
+// lruEntry is a single cache entry
+type lruEntry[K comparable, V any] struct {
+	key   K
+	value V
+	cost  int64
+}
+
+// newLRUCache returns an empty cache; onEvict (optional) is called for every evicted entry
+func newLRUCache[K comparable, V any](capacity int64, onEvict func(key K, value V)) *lruCache[K, V] {
+	return &lruCache[K, V]{
+		capacity: capacity,
+		onEvict:  onEvict,
+		order:    list.New(),
+		entries:  make(map[K]*list.Element),
+	}
+}
+
+// get returns the value of key and marks it as most recently used
+func (c *lruCache[K, V]) get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		c.order.MoveToFront(el)
+		return el.Value.(*lruEntry[K, V]).value, true
+	}
+	var zero V
+	return zero, false
+}
+
+// add inserts or replaces key as the most recently used entry, evicting others if needed
+func (c *lruCache[K, V]) add(key K, value V, cost int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*lruEntry[K, V])
+		c.used += cost - entry.cost
+		entry.value = value
+		entry.cost = cost
+		c.order.MoveToFront(el)
+	} else {
+		c.entries[key] = c.order.PushFront(&lruEntry[K, V]{key: key, value: value, cost: cost})
+		c.used += cost
+	}
+
+	// Never evict the entry just added, even if it alone exceeds the capacity
+	for c.used > c.capacity && c.order.Len() > 1 {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// remove drops key from the cache without calling onEvict
+func (c *lruCache[K, V]) remove(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.entries[key]; ok {
+		entry := el.Value.(*lruEntry[K, V])
+		c.order.Remove(el)
+		delete(c.entries, key)
+		c.used -= entry.cost
+	}
+}
+
+// clear evicts all entries
+func (c *lruCache[K, V]) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for c.order.Len() > 0 {
+		c.removeElement(c.order.Back())
+	}
+}
+
+// stats returns the number of entries and their total cost
+func (c *lruCache[K, V]) stats() (int, int64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len(), c.used
+}
+
+// removeElement evicts a single entry (mu must be held)
+func (c *lruCache[K, V]) removeElement(el *list.Element) {
+	entry := el.Value.(*lruEntry[K, V])
+	c.order.Remove(el)
+	delete(c.entries, entry.key)
+	c.used -= entry.cost
+	if c.onEvict != nil {
+		c.onEvict(entry.key, entry.value)
+	}
+}
@@ -0,0 +1,127 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Markdown export
+//
+// Archived tweets can be exported as Markdown notes for research vaults (Obsidian, Logseq, ...):
+// one note per tweet named after its ID, in a folder per account inside the chosen vault folder.
+// Each note has YAML front matter with the tweet's metadata, the tweet text, and embeds of the
+// downloaded media, linked relative to the note so they resolve when the archive is inside the
+// vault (a file:// link otherwise). Tweets come from the library database or tweets.jsonl, plus
+// any downloaded file whose tweet isn't known. Exporting again only adds notes of new tweets;
+// existing notes are left alone, as they may have been edited in the vault.
+
+// MarkdownExportResult describes a Markdown export
+type MarkdownExportResult struct {
+	Folder  string `json:"folder"`  // Folder of the account's notes
+	Written int    `json:"written"` // New notes
+	Skipped int    `json:"skipped"` // Notes that already existed
+}
+
+// ExportMarkdownNotes writes a Markdown note per archived tweet of an account folder to vaultDir/<username>
+func ExportMarkdownNotes(folder, vaultDir string) (MarkdownExportResult, error) {
+	folder = filepath.Clean(folder)
+	username := filepath.Base(folder)
+	if IsUsernameSensitive(username) && IsLocked() {
+		return MarkdownExportResult{}, ErrContentLocked
+	}
+
+	page, err := ListGallery(folder, 0, 0)
+	if err != nil {
+		return MarkdownExportResult{}, err
+	}
+	notesDir := filepath.Join(vaultDir, username)
+	if err := os.MkdirAll(notesDir, 0755); err != nil {
+		return MarkdownExportResult{}, fmt.Errorf("failed to create notes folder: %v", err)
+	}
+
+	tweets := archivedTweets(folder, username)
+	files := make(map[string][]GalleryItem) // tweet ID -> downloaded media
+	for _, item := range page.Items {
+		if item.TweetID == "" || item.Type == "text" {
+			continue
+		}
+		files[item.TweetID] = append(files[item.TweetID], item)
+		if _, ok := tweets[item.TweetID]; !ok {
+			tweets[item.TweetID] = &archivedTweet{Author: username, Date: item.Date, MediaURLs: make(map[int]string)}
+		}
+	}
+
+	result := MarkdownExportResult{Folder: notesDir}
+	for tweetID, tweet := range tweets {
+		notePath := filepath.Join(notesDir, tweetID+".md")
+		if _, err := os.Stat(notePath); err == nil {
+			result.Skipped++
+			continue
+		}
+		media := files[tweetID]
+		sort.Slice(media, func(i, j int) bool {
+			return archiveFileIndex(media[i].Name) < archiveFileIndex(media[j].Name)
+		})
+		note := markdownNote(tweetID, tweet, media, notesDir)
+		if err := os.WriteFile(notePath, []byte(note), 0644); err != nil {
+			return result, fmt.Errorf("failed to write note %s: %v", tweetID, err)
+		}
+		result.Written++
+	}
+	return result, nil
+}
+
+// markdownNote renders the note of a tweet
+func markdownNote(tweetID string, tweet *archivedTweet, media []GalleryItem, notesDir string) string {
+	tweetURL := fmt.Sprintf("https://x.com/%s/status/%s", tweet.Author, tweetID)
+
+	var b strings.Builder
+	b.WriteString("---\n")
+	fmt.Fprintf(&b, "tweet_id: %s\n", yamlString(tweetID))
+	fmt.Fprintf(&b, "author: %s\n", yamlString(tweet.Author))
+	if tweet.Date != "" {
+		fmt.Fprintf(&b, "date: %s\n", yamlString(tweet.Date))
+	}
+	fmt.Fprintf(&b, "url: %s\n", yamlString(tweetURL))
+	fmt.Fprintf(&b, "likes: %d\n", tweet.Likes)
+	fmt.Fprintf(&b, "retweets: %d\n", tweet.Retweets)
+	fmt.Fprintf(&b, "replies: %d\n", tweet.Replies)
+	fmt.Fprintf(&b, "views: %d\n", tweet.Views)
+	fmt.Fprintf(&b, "bookmarks: %d\n", tweet.Bookmarks)
+	fmt.Fprintf(&b, "media: %d\n", len(media))
+	fmt.Fprintf(&b, "tags: [twitter, %s]\n", yamlString(tweet.Author))
+	b.WriteString("---\n\n")
+
+	if tweet.Text != "" {
+		b.WriteString(tweet.Text)
+		b.WriteString("\n\n")
+	}
+	for _, item := range media {
+		fmt.Fprintf(&b, "![%s](%s)\n", item.Name, markdownLink(notesDir, item.Path))
+	}
+	if len(media) > 0 {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "[View on X](%s)\n", tweetURL)
+	return b.String()
+}
+
+// markdownLink links a file from a note folder, relative when possible
+func markdownLink(notesDir, path string) string {
+	if rel, err := filepath.Rel(notesDir, path); err == nil {
+		// Escape the path so spaces and parentheses don't end the link
+		return (&url.URL{Path: filepath.ToSlash(rel)}).EscapedPath()
+	}
+	return (&url.URL{Scheme: "file", Path: "/" + strings.TrimPrefix(filepath.ToSlash(path), "/")}).String()
+}
+
+// yamlString quotes a YAML string value (JSON strings are valid YAML)
+func yamlString(s string) string {
+	quoted, _ := json.Marshal(s)
+	return string(quoted)
+}
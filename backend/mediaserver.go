@@ -0,0 +1,398 @@
+package backend
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"html/template"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Media server
+//
+// An optional HTTP server that makes the download folder browsable from other devices on the LAN,
+// a phone or a TV browser. It serves plain HTML pages listing the account folders and their media
+// as a thumbnail grid, thumbnails from the gallery cache, and the media files themselves with
+// range support so videos stream and seek. Every request needs the access key, generated once and
+// kept in the app data folder; the URLs handed out carry it and the first visit stores it in a
+// cookie. Sensitive accounts are left out while the content lock is on.
+
+const (
+	defaultMediaServerPort = 8642
+	mediaServerPageSize    = 120
+	mediaServerCookie      = "xmd_key"
+)
+
+// MediaServerInfo describes a running media server
+type MediaServerInfo struct {
+	Port int      `json:"port"`
+	Key  string   `json:"key"`
+	URLs []string `json:"urls"` // One per LAN address, with the access key
+}
+
+// MediaServer serves the download folder over HTTP
+type MediaServer struct {
+	root     string
+	key      string
+	server   *http.Server
+	listener net.Listener
+	stopOnce sync.Once
+}
+
+// StartMediaServer serves root on every interface at port (0 = default)
+func StartMediaServer(port int, root string) (*MediaServer, error) {
+	if port <= 0 {
+		port = defaultMediaServerPort
+	}
+	root = filepath.Clean(root)
+	if info, err := os.Stat(root); err != nil || !info.IsDir() {
+		return nil, fmt.Errorf("folder not found: %s", root)
+	}
+	key, err := mediaServerKey()
+	if err != nil {
+		return nil, err
+	}
+	listener, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on port %d: %v", port, err)
+	}
+
+	s := &MediaServer{root: root, key: key, listener: listener}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", s.handleAccounts)
+	mux.HandleFunc("GET /a/{user}", s.handleAccount)
+	mux.HandleFunc("GET /thumb/{user}/{sub}/{name}", s.handleThumbnail)
+	mux.HandleFunc("GET /media/{user}/{sub}/{name}", s.handleMedia)
+	s.server = &http.Server{Handler: s.authorize(mux), ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("Media server stopped: %v\n", err)
+		}
+	}()
+	return s, nil
+}
+
+// Info returns the port, access key and LAN URLs of the server
+func (s *MediaServer) Info() MediaServerInfo {
+	port := s.listener.Addr().(*net.TCPAddr).Port
+	info := MediaServerInfo{Port: port, Key: s.key, URLs: []string{}}
+	for _, ip := range lanAddresses() {
+		info.URLs = append(info.URLs, fmt.Sprintf("http://%s:%d/?key=%s", ip, port, s.key))
+	}
+	if len(info.URLs) == 0 {
+		info.URLs = append(info.URLs, fmt.Sprintf("http://localhost:%d/?key=%s", port, s.key))
+	}
+	return info
+}
+
+// Stop shuts the server down, letting open requests finish for a few seconds
+func (s *MediaServer) Stop() {
+	s.stopOnce.Do(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := s.server.Shutdown(ctx); err != nil {
+			s.server.Close()
+		}
+	})
+}
+
+// mediaServerKey returns the access key, generating it on first use
+func mediaServerKey() (string, error) {
+	path := filepath.Join(GetAppDataDir(), "mediaserver.key")
+	if data, err := os.ReadFile(path); err == nil {
+		if key := strings.TrimSpace(string(data)); len(key) >= 16 {
+			return key, nil
+		}
+	}
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate access key: %v", err)
+	}
+	key := hex.EncodeToString(buf)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", fmt.Errorf("failed to save access key: %v", err)
+	}
+	if err := os.WriteFile(path, []byte(key), 0600); err != nil {
+		return "", fmt.Errorf("failed to save access key: %v", err)
+	}
+	return key, nil
+}
+
+// lanAddresses returns the IPv4 addresses of the machine's active non-loopback interfaces
+func lanAddresses() []string {
+	var ips []string
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return ips
+	}
+	for _, iface := range ifaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok {
+				if ip := ipNet.IP.To4(); ip != nil && !ip.IsLinkLocalUnicast() {
+					ips = append(ips, ip.String())
+				}
+			}
+		}
+	}
+	return ips
+}
+
+// authorize rejects requests without the access key. A key in the query string is stored in a
+// cookie, so links between pages don't need to carry it
+func (s *MediaServer) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if key := r.URL.Query().Get("key"); key != "" && s.validKey(key) {
+			http.SetCookie(w, &http.Cookie{Name: mediaServerCookie, Value: key, Path: "/", HttpOnly: true, SameSite: http.SameSiteStrictMode, MaxAge: 365 * 24 * 3600})
+			next.ServeHTTP(w, r)
+			return
+		}
+		if cookie, err := r.Cookie(mediaServerCookie); err == nil && s.validKey(cookie.Value) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		http.Error(w, "Open the link shown in the app settings to browse this library", http.StatusUnauthorized)
+	})
+}
+
+func (s *MediaServer) validKey(key string) bool {
+	return subtle.ConstantTimeCompare([]byte(key), []byte(s.key)) == 1
+}
+
+// accountFolder returns the folder of an account the server may show, "" if there is none
+func (s *MediaServer) accountFolder(username string) string {
+	if username == "" || username != filepath.Base(username) || strings.HasPrefix(username, ".") {
+		return ""
+	}
+	if IsUsernameSensitive(username) && IsLocked() {
+		return ""
+	}
+	folder := filepath.Join(s.root, username)
+	if info, err := os.Stat(folder); err != nil || !info.IsDir() {
+		return ""
+	}
+	return folder
+}
+
+// mediaFile returns the path of a media file the server may serve, "" if there is none
+func (s *MediaServer) mediaFile(r *http.Request) string {
+	folder := s.accountFolder(r.PathValue("user"))
+	sub, name := r.PathValue("sub"), r.PathValue("name")
+	if folder == "" || gallerySubfolders[sub] == "" || name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		return ""
+	}
+	path := filepath.Join(folder, sub, name)
+	if info, err := os.Stat(path); err != nil || !info.Mode().IsRegular() {
+		return ""
+	}
+	return path
+}
+
+// mediaServerAccount is an account folder on the index page
+type mediaServerAccount struct {
+	Username string
+	Files    int
+}
+
+// mediaServerItem is a file on an account page
+type mediaServerItem struct {
+	Name     string
+	Type     string
+	MediaURL string
+	ThumbURL string
+}
+
+func (s *MediaServer) handleAccounts(w http.ResponseWriter, r *http.Request) {
+	entries, err := os.ReadDir(s.root)
+	if err != nil {
+		http.Error(w, "Failed to read the download folder", http.StatusInternalServerError)
+		return
+	}
+	var accounts []mediaServerAccount
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		folder := s.accountFolder(entry.Name())
+		if folder == "" {
+			continue
+		}
+		page, err := ListGallery(folder, 0, 0)
+		if err != nil || page.Total == 0 {
+			continue
+		}
+		accounts = append(accounts, mediaServerAccount{Username: entry.Name(), Files: page.Total})
+	}
+	sort.Slice(accounts, func(i, j int) bool {
+		return strings.ToLower(accounts[i].Username) < strings.ToLower(accounts[j].Username)
+	})
+	renderMediaServerPage(w, "accounts", map[string]any{"Title": "Library", "Accounts": accounts})
+}
+
+func (s *MediaServer) handleAccount(w http.ResponseWriter, r *http.Request) {
+	username := r.PathValue("user")
+	folder := s.accountFolder(username)
+	if folder == "" {
+		http.NotFound(w, r)
+		return
+	}
+	pageNum, _ := strconv.Atoi(r.URL.Query().Get("page"))
+	if pageNum < 1 {
+		pageNum = 1
+	}
+	page, err := ListGallery(folder, (pageNum-1)*mediaServerPageSize, mediaServerPageSize)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	items := make([]mediaServerItem, 0, len(page.Items))
+	for _, item := range page.Items {
+		rel, err := filepath.Rel(folder, item.Path)
+		if err != nil {
+			continue
+		}
+		parts := strings.Split(filepath.ToSlash(rel), "/")
+		if len(parts) != 2 {
+			continue
+		}
+		ref := url.PathEscape(username) + "/" + url.PathEscape(parts[0]) + "/" + url.PathEscape(parts[1])
+		items = append(items, mediaServerItem{Name: item.Name, Type: item.Type, MediaURL: "/media/" + ref, ThumbURL: "/thumb/" + ref})
+	}
+	pages := (page.Total + mediaServerPageSize - 1) / mediaServerPageSize
+	data := map[string]any{
+		"Title":    "@" + username,
+		"Username": username,
+		"Total":    page.Total,
+		"Items":    items,
+		"Page":     pageNum,
+		"Pages":    pages,
+		"Prev":     pageNum - 1,
+		"Next":     0,
+	}
+	if pageNum < pages {
+		data["Next"] = pageNum + 1
+	}
+	renderMediaServerPage(w, "account", data)
+}
+
+func (s *MediaServer) handleThumbnail(w http.ResponseWriter, r *http.Request) {
+	path := s.mediaFile(r)
+	if path == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if strings.HasSuffix(path, EncryptedExt) {
+		// Thumbnails of encrypted files would be plaintext copies in the cache
+		http.NotFound(w, r)
+		return
+	}
+	size, _ := strconv.Atoi(r.URL.Query().Get("size"))
+	if thumbPath, err := GetThumbnail(path, size); err == nil {
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+		http.ServeFile(w, r, thumbPath)
+		return
+	}
+	// Without a thumbnail (no FFmpeg for videos, an undecodable image) images are shown as they are
+	if gallerySubfolders[r.PathValue("sub")] == "photo" || gallerySubfolders[r.PathValue("sub")] == "gif" {
+		http.ServeFile(w, r, path)
+		return
+	}
+	http.NotFound(w, r)
+}
+
+func (s *MediaServer) handleMedia(w http.ResponseWriter, r *http.Request) {
+	path := s.mediaFile(r)
+	if path == "" {
+		http.NotFound(w, r)
+		return
+	}
+	if strings.HasSuffix(path, EncryptedExt) {
+		f, err := OpenEncryptedFile(path)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		defer f.Close()
+		info, err := os.Stat(path)
+		if err != nil {
+			http.NotFound(w, r)
+			return
+		}
+		http.ServeContent(w, r, strings.TrimSuffix(filepath.Base(path), EncryptedExt), info.ModTime(), f)
+		return
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// ServeContent answers Range requests, which video players need to stream and seek
+	http.ServeContent(w, r, info.Name(), info.ModTime(), f)
+}
+
+func renderMediaServerPage(w http.ResponseWriter, name string, data map[string]any) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	if err := mediaServerTemplates.ExecuteTemplate(w, name, data); err != nil {
+		fmt.Printf("Media server: failed to render %s: %v\n", name, err)
+	}
+}
+
+var mediaServerTemplates = template.Must(template.New("").Parse(`
+{{define "head"}}<!DOCTYPE html>
+<html><head><meta charset="utf-8"><meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.Title}}</title>
+<style>
+body{margin:0;font-family:system-ui,sans-serif;background:#111;color:#eee}
+header{padding:12px 16px;background:#1c1c1c;display:flex;gap:12px;align-items:baseline}
+a{color:#8ab4f8;text-decoration:none}
+ul{list-style:none;margin:0;padding:8px 16px}
+li{padding:10px 0;border-bottom:1px solid #222}
+.muted{color:#888;font-size:.9em}
+.grid{display:grid;grid-template-columns:repeat(auto-fill,minmax(150px,1fr));gap:4px;padding:4px}
+.cell{position:relative;aspect-ratio:1;background:#222;overflow:hidden;display:flex;align-items:center;justify-content:center}
+.cell img{width:100%;height:100%;object-fit:cover}
+.badge{position:absolute;bottom:4px;right:4px;background:rgba(0,0,0,.7);padding:1px 6px;border-radius:4px;font-size:.8em}
+.pager{padding:16px;display:flex;gap:16px;justify-content:center}
+</style></head><body>{{end}}
+
+{{define "accounts"}}{{template "head" .}}
+<header><strong>Library</strong></header>
+<ul>{{range .Accounts}}<li><a href="/a/{{.Username}}">@{{.Username}}</a> <span class="muted">{{.Files}} files</span></li>
+{{else}}<li class="muted">No downloaded accounts</li>{{end}}</ul>
+</body></html>{{end}}
+
+{{define "account"}}{{template "head" .}}
+<header><a href="/">Library</a><strong>@{{.Username}}</strong><span class="muted">{{.Total}} files</span></header>
+<div class="grid">{{range .Items}}<a class="cell" href="{{.MediaURL}}" title="{{.Name}}">
+{{if eq .Type "text"}}<span class="muted">{{.Name}}</span>{{else}}<img loading="lazy" src="{{.ThumbURL}}" alt="{{.Name}}">{{end}}
+{{if eq .Type "video"}}<span class="badge">&#9654;</span>{{else if eq .Type "gif"}}<span class="badge">GIF</span>{{end}}</a>
+{{end}}</div>
+{{if gt .Pages 1}}<div class="pager">{{if gt .Prev 0}}<a href="?page={{.Prev}}">&larr; Newer</a>{{end}}
+<span class="muted">{{.Page}} / {{.Pages}}</span>{{if gt .Next 0}}<a href="?page={{.Next}}">Older &rarr;</a>{{end}}</div>{{end}}
+</body></html>{{end}}
+`))
@@ -0,0 +1,106 @@
+package backend
+
+// Timeline statistics
+//
+// A fetched timeline comes with a breakdown of what it holds, so the UI can show what a download
+// will bring before it starts: the number of photos, videos, GIFs and text tweets, the span of
+// their dates and the approximate size of the media files. Videos are sized from the bitrate and
+// duration the extractor reports for the variant that gets downloaded and photos from their
+// pixel count; media without those (GIFs, older stored timelines) count with typical sizes.
+// The estimate of each media entry is kept on the entry, so timelines merged from several
+// fetches add up without the extractor output.
+
+// Size estimates of media files
+const (
+	photoBytesPerPixel  = 0.3             // Full-size JPEGs as served by the media CDN
+	defaultPhotoBytes   = 400 * 1024      // Photo without dimensions
+	defaultVideoBitrate = 2_000_000       // Bits per second of a video without a bitrate
+	defaultVideoSeconds = 30              // Length of a video without a duration
+	defaultGIFBytes     = 1 * 1024 * 1024 // GIFs come without bitrate or duration
+)
+
+// TimelineStats is the breakdown of a fetched timeline by media type
+type TimelineStats struct {
+	Photos         int    `json:"photos"`
+	Videos         int    `json:"videos"`
+	GIFs           int    `json:"gifs"`
+	Text           int    `json:"text"`
+	EstimatedBytes int64  `json:"estimated_bytes"`      // Approximate size of the media files
+	FirstDate      string `json:"first_date,omitempty"` // Date of the oldest entry
+	LastDate       string `json:"last_date,omitempty"`  // Date of the newest entry
+}
+
+// estimateMediaSize returns the approximate file size of a media item of a type from the extractor
+func estimateMediaSize(mediaType string, media CLIMediaItem) int64 {
+	switch mediaType {
+	case "video":
+		bitrate, seconds := float64(media.Bitrate), media.Duration
+		if bitrate <= 0 {
+			bitrate = defaultVideoBitrate
+		}
+		if seconds <= 0 {
+			seconds = defaultVideoSeconds
+		}
+		return int64(bitrate * seconds / 8)
+	case "gif", "animated_gif":
+		return defaultGIFBytes
+	default:
+		return estimatePhotoSize(media.Width, media.Height)
+	}
+}
+
+// estimatePhotoSize returns the approximate file size of a photo
+func estimatePhotoSize(width, height int) int64 {
+	if width <= 0 || height <= 0 {
+		return defaultPhotoBytes
+	}
+	return int64(float64(width*height) * photoBytesPerPixel)
+}
+
+// estimateEntrySize returns the approximate file size of a timeline entry, 0 for text
+func estimateEntrySize(entry TimelineEntry) int64 {
+	if entry.EstimatedSize > 0 {
+		return entry.EstimatedSize
+	}
+	switch entry.Type {
+	case "text":
+		return 0
+	case "video":
+		return defaultVideoBitrate * defaultVideoSeconds / 8
+	case "gif", "animated_gif":
+		return defaultGIFBytes
+	default:
+		return estimatePhotoSize(entry.Width, entry.Height)
+	}
+}
+
+// computeTimelineStats breaks a timeline down by media type
+func computeTimelineStats(timeline []TimelineEntry) *TimelineStats {
+	stats := &TimelineStats{}
+	var first, last int64 // Unix seconds of FirstDate and LastDate
+	for _, entry := range timeline {
+		switch entry.Type {
+		case "text":
+			stats.Text++
+		case "video":
+			stats.Videos++
+		case "gif", "animated_gif":
+			stats.GIFs++
+		default:
+			stats.Photos++
+		}
+		stats.EstimatedBytes += estimateEntrySize(entry)
+
+		t, err := ParseTweetDate(entry.Date)
+		if err != nil {
+			continue
+		}
+		if stats.FirstDate == "" || t.Unix() < first {
+			first, stats.FirstDate = t.Unix(), entry.Date
+		}
+		if stats.LastDate == "" || t.Unix() > last {
+			last, stats.LastDate = t.Unix(), entry.Date
+		}
+	}
+	return stats
+}
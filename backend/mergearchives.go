@@ -0,0 +1,263 @@
+package backend
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Merging archives
+//
+// The same account downloaded on two machines ends up in two folders that mostly overlap.
+// MergeArchives copies what the source folder has and the target doesn't into the target: files
+// with the same name and content are skipped, as are files whose content the target already has
+// under another name (another file name template). A file with the same name but different
+// content is kept next to the target's, with the collision suffix of the settings. The tweet
+// archive (tweets.jsonl) and the dead link list (missing.csv) of both folders are unioned, the
+// highest counts of a tweet winning. Every copy is verified, and the source is left untouched.
+
+// MergeArchivesResult describes a merge of two archive folders
+type MergeArchivesResult struct {
+	Source     string `json:"source"`
+	Target     string `json:"target"`
+	Copied     int    `json:"copied"`      // Files copied into the target
+	Bytes      int64  `json:"bytes"`       // Size of the copied files
+	Duplicates int    `json:"duplicates"`  // Files the target already had, by name or content
+	KeptBoth   int    `json:"kept_both"`   // Files with a name the target had for other content
+	Tweets     int    `json:"tweets"`      // Tweets in the merged tweets.jsonl
+	DeadLinks  int    `json:"dead_links"`  // Dead links in the merged missing.csv
+	FailedCopy int    `json:"failed_copy"` // Files that couldn't be copied
+}
+
+// MergeArchives merges the archive folder src of an account into the archive folder dst
+func MergeArchives(src, dst string) (*MergeArchivesResult, error) {
+	srcAbs, err1 := filepath.Abs(src)
+	dstAbs, err2 := filepath.Abs(dst)
+	if err1 != nil || err2 != nil || src == "" || dst == "" {
+		return nil, fmt.Errorf("invalid archive folder")
+	}
+	result := &MergeArchivesResult{Source: srcAbs, Target: dstAbs}
+	if srcAbs == dstAbs {
+		return result, fmt.Errorf("can't merge an archive into itself")
+	}
+	if strings.HasPrefix(dstAbs+string(filepath.Separator), srcAbs+string(filepath.Separator)) ||
+		strings.HasPrefix(srcAbs+string(filepath.Separator), dstAbs+string(filepath.Separator)) {
+		return result, fmt.Errorf("one archive is inside the other")
+	}
+	if info, err := os.Stat(srcAbs); err != nil || !info.IsDir() {
+		return result, fmt.Errorf("archive folder not found: %s", srcAbs)
+	}
+	if err := os.MkdirAll(dstAbs, 0755); err != nil {
+		return result, fmt.Errorf("failed to create target folder: %v", err)
+	}
+
+	index := newContentIndex(dstAbs)
+	collision := GetSettings().CollisionSuffix
+	err := filepath.WalkDir(srcAbs, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		rel, err := filepath.Rel(srcAbs, path)
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != srcAbs && strings.HasPrefix(d.Name(), ".") {
+				return filepath.SkipDir // .thumbs, .trash
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || isMergeTempFile(d.Name()) {
+			return nil
+		}
+		if path == filepath.Join(srcAbs, tweetsJSONLFile) || path == filepath.Join(srcAbs, missingCSVFile) {
+			return nil // Unioned below
+		}
+
+		target := filepath.Join(dstAbs, rel)
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		hash, err := calculateSHA256(path)
+		if err != nil {
+			result.FailedCopy++
+			return nil
+		}
+		if index.has(info.Size(), hash) {
+			result.Duplicates++
+			return nil
+		}
+		if _, err := os.Lstat(target); err == nil {
+			// Same name, other content: keep both
+			if collision == CollisionHash {
+				target = freeFilePath(hashSuffixPath(target, hash))
+			} else {
+				target = freeFilePath(target)
+			}
+			result.KeptBoth++
+		}
+		if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+			result.FailedCopy++
+			return nil
+		}
+		n, err := copyVerified(path, target)
+		if err != nil {
+			fmt.Printf("Warning: failed to merge %s: %v\n", rel, err)
+			result.FailedCopy++
+			return nil
+		}
+		index.add(target, n, hash)
+		result.Copied++
+		result.Bytes += n
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to merge archive: %v", err)
+	}
+
+	if result.Tweets, err = mergeTweetArchives(filepath.Join(srcAbs, tweetsJSONLFile), filepath.Join(dstAbs, tweetsJSONLFile)); err != nil {
+		return result, err
+	}
+	if result.DeadLinks, err = mergeMissingLists(filepath.Join(srcAbs, missingCSVFile), filepath.Join(dstAbs, missingCSVFile)); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// isMergeTempFile reports whether a file is a leftover of an interrupted write
+func isMergeTempFile(name string) bool {
+	return strings.HasSuffix(name, ".part") || strings.HasSuffix(name, ".tmp")
+}
+
+// contentIndex finds files of a folder by content; files are only hashed when a file of the same
+// size is looked up
+type contentIndex struct {
+	bySize map[int64][]string
+	hashes map[string]string // Path -> SHA-256
+}
+
+// newContentIndex indexes the files of a folder
+func newContentIndex(dir string) *contentIndex {
+	index := &contentIndex{bySize: make(map[int64][]string), hashes: make(map[string]string)}
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			index.bySize[info.Size()] = append(index.bySize[info.Size()], path)
+		}
+		return nil
+	})
+	return index
+}
+
+// has reports whether the folder has a file with this size and hash
+func (ix *contentIndex) has(size int64, hash string) bool {
+	for _, path := range ix.bySize[size] {
+		known, ok := ix.hashes[path]
+		if !ok {
+			known, _ = calculateSHA256(path)
+			ix.hashes[path] = known
+		}
+		if known == hash {
+			return true
+		}
+	}
+	return false
+}
+
+// add records a file added to the folder
+func (ix *contentIndex) add(path string, size int64, hash string) {
+	ix.bySize[size] = append(ix.bySize[size], path)
+	ix.hashes[path] = hash
+}
+
+// mergeTweetArchives unions the tweets.jsonl of src into dst, returning the tweets in dst
+func mergeTweetArchives(src, dst string) (int, error) {
+	incoming := readTweetsJSONL(src)
+	if len(incoming) == 0 {
+		return len(readTweetsJSONL(dst)), nil
+	}
+	existing := make(map[string]TweetRecord)
+	for _, record := range readTweetsJSONL(dst) {
+		existing[record.TweetID] = record
+	}
+	for i, record := range incoming {
+		previous, ok := existing[record.TweetID]
+		if !ok {
+			continue
+		}
+		// The higher counts are usually the more recent ones
+		record.FavoriteCount = max(record.FavoriteCount, previous.FavoriteCount)
+		record.RetweetCount = max(record.RetweetCount, previous.RetweetCount)
+		record.ReplyCount = max(record.ReplyCount, previous.ReplyCount)
+		record.ViewCount = max(record.ViewCount, previous.ViewCount)
+		record.BookmarkCount = max(record.BookmarkCount, previous.BookmarkCount)
+		if record.Content == "" {
+			record.Content = previous.Content
+		}
+		if record.Card == nil {
+			record.Card = previous.Card
+		}
+		incoming[i] = record
+	}
+	if err := mergeTweetsJSONL(LocalStorage{}, dst, incoming); err != nil {
+		return 0, err
+	}
+	return len(readTweetsJSONL(dst)), nil
+}
+
+// readTweetsJSONL reads the records of a tweets.jsonl file, none if it doesn't exist
+func readTweetsJSONL(path string) []TweetRecord {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	var records []TweetRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+	for scanner.Scan() {
+		var record TweetRecord
+		if json.Unmarshal(scanner.Bytes(), &record) == nil && record.TweetID != "" {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+// mergeMissingLists unions the missing.csv of src into dst, returning the dead links in dst
+func mergeMissingLists(src, dst string) (int, error) {
+	// A link keeps the time either side first found it dead
+	if incoming := readMissingCSV(src); len(incoming) > 0 {
+		if err := mergeMissingCSV(LocalStorage{}, dst, incoming); err != nil {
+			return 0, err
+		}
+	}
+	return len(readMissingCSV(dst)), nil
+}
+
+// readMissingCSV reads the rows of a missing.csv file without its header
+func readMissingCSV(path string) [][]string {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+	reader := csv.NewReader(f)
+	reader.FieldsPerRecord = -1
+	records, _ := reader.ReadAll()
+	var rows [][]string
+	for i, record := range records {
+		if i > 0 && len(record) >= len(missingCSVHeader) {
+			rows = append(rows, record)
+		}
+	}
+	return rows
+}
@@ -0,0 +1,116 @@
+package backend
+
+import "strings"
+
+// Message catalog
+//
+// Errors and hints from the backend keep their English text, so logs and bug reports read the
+// same everywhere, and also carry a code the frontend can translate: hints end in a
+// "[hint=<code> key=value ...]" token (like the retry_at token of rate limits), and extractor
+// errors start with "<code>: ". GetMessageCatalog returns the translations of every code by
+// language (en, zh-CN, ja, es); templates use {name} for the values in the token.
+
+// Hint codes
+const (
+	HintRateLimitedUntil = "rate_limited_until"
+	HintTimelineEnd      = "timeline_end"
+	HintRateLimited      = "rate_limited"
+	HintAuthInvalid      = "auth_invalid"
+	HintAccountNotFound  = "account_not_found"
+	HintProtected        = "protected_account"
+)
+
+// Extractor error codes, the prefix of the error message
+const (
+	ErrCodeIncompleteOutput = "incomplete_output"
+	ErrCodeEmptyResponse    = "empty_response"
+	ErrCodeParseError       = "parse_error"
+	ErrCodeJSONError        = "json_error"
+	ErrCodeExtractorTimeout = "extractor_timeout"
+	ErrCodeExtractorStalled = "extractor_stalled"
+)
+
+// messageCatalog holds the text of every code by language
+var messageCatalog = map[string]map[string]string{
+	"en": {
+		HintRateLimitedUntil:    "Rate limited - retry at {time}",
+		HintTimelineEnd:         "End of timeline reached or rate limited - data already fetched has been saved",
+		HintRateLimited:         "Wait 5-15 minutes before retrying",
+		HintAuthInvalid:         "Auth token may be invalid or expired",
+		HintAccountNotFound:     "@{username} may not exist or is suspended",
+		HintProtected:           "Protected account - need to follow and use auth token",
+		ErrCodeIncompleteOutput: "Extractor output ended unexpectedly",
+		ErrCodeEmptyResponse:    "Extractor returned no data. The timeline may be empty or inaccessible",
+		ErrCodeParseError:       "Could not parse extractor output",
+		ErrCodeJSONError:        "Failed to read extractor output",
+		ErrCodeExtractorTimeout: "Extractor run exceeded the time limit and was stopped",
+		ErrCodeExtractorStalled: "Extractor stopped writing output and was stopped",
+	},
+	"zh-CN": {
+		HintRateLimitedUntil:    "请求受限 - 请在 {time} 后重试",
+		HintTimelineEnd:         "已到达时间线末尾或请求受限 - 已获取的数据已保存",
+		HintRateLimited:         "请等待 5-15 分钟后重试",
+		HintAuthInvalid:         "Auth token 可能无效或已过期",
+		HintAccountNotFound:     "@{username} 可能不存在或已被封禁",
+		HintProtected:           "受保护的账号 - 需要关注该账号并使用 auth token",
+		ErrCodeIncompleteOutput: "提取器输出意外中断",
+		ErrCodeEmptyResponse:    "提取器未返回数据。时间线可能为空或无法访问",
+		ErrCodeParseError:       "无法解析提取器输出",
+		ErrCodeJSONError:        "读取提取器输出失败",
+		ErrCodeExtractorTimeout: "提取器运行超过时间限制，已被终止",
+		ErrCodeExtractorStalled: "提取器长时间没有输出，已被终止",
+	},
+	"ja": {
+		HintRateLimitedUntil:    "レート制限中 - {time} 以降に再試行してください",
+		HintTimelineEnd:         "タイムラインの終わりに達したか、レート制限中です - 取得済みのデータは保存されています",
+		HintRateLimited:         "5～15分待ってから再試行してください",
+		HintAuthInvalid:         "Auth token が無効か期限切れの可能性があります",
+		HintAccountNotFound:     "@{username} は存在しないか凍結されている可能性があります",
+		HintProtected:           "非公開アカウントです - フォローして auth token を使用してください",
+		ErrCodeIncompleteOutput: "抽出ツールの出力が途中で終了しました",
+		ErrCodeEmptyResponse:    "抽出ツールがデータを返しませんでした。タイムラインが空か、アクセスできない可能性があります",
+		ErrCodeParseError:       "抽出ツールの出力を解析できませんでした",
+		ErrCodeJSONError:        "抽出ツールの出力を読み取れませんでした",
+		ErrCodeExtractorTimeout: "抽出ツールの実行が制限時間を超えたため停止しました",
+		ErrCodeExtractorStalled: "抽出ツールの出力が途絶えたため停止しました",
+	},
+	"es": {
+		HintRateLimitedUntil:    "Límite de solicitudes - vuelve a intentarlo a las {time}",
+		HintTimelineEnd:         "Se llegó al final de la cronología o hay un límite de solicitudes - los datos obtenidos se han guardado",
+		HintRateLimited:         "Espera de 5 a 15 minutos antes de volver a intentarlo",
+		HintAuthInvalid:         "El auth token puede ser inválido o haber caducado",
+		HintAccountNotFound:     "Puede que @{username} no exista o esté suspendida",
+		HintProtected:           "Cuenta protegida - hay que seguirla y usar un auth token",
+		ErrCodeIncompleteOutput: "La salida del extractor terminó inesperadamente",
+		ErrCodeEmptyResponse:    "El extractor no devolvió datos. La cronología puede estar vacía o no ser accesible",
+		ErrCodeParseError:       "No se pudo analizar la salida del extractor",
+		ErrCodeJSONError:        "No se pudo leer la salida del extractor",
+		ErrCodeExtractorTimeout: "El extractor superó el tiempo límite y se detuvo",
+		ErrCodeExtractorStalled: "El extractor dejó de producir salida y se detuvo",
+	},
+}
+
+// hintText returns an English hint for an error message with its code token
+// params are key, value pairs for the placeholders of the code's template
+func hintText(code string, params ...string) string {
+	text := messageCatalog["en"][code]
+	token := "hint=" + code
+	for i := 0; i+1 < len(params); i += 2 {
+		text = strings.ReplaceAll(text, "{"+params[i]+"}", params[i+1])
+		// Values are single words (usernames), spaces would end the token
+		token += " " + params[i] + "=" + strings.ReplaceAll(params[i+1], " ", "_")
+	}
+	return " [Hint: " + text + "] [" + token + "]"
+}
+
+// GetMessageCatalog returns the text of every message code by language
+func GetMessageCatalog() map[string]map[string]string {
+	catalog := make(map[string]map[string]string, len(messageCatalog))
+	for lang, messages := range messageCatalog {
+		catalog[lang] = make(map[string]string, len(messages))
+		for code, text := range messages {
+			catalog[lang][code] = text
+		}
+	}
+	return catalog
+}
@@ -9,6 +9,7 @@ import (
 	"regexp"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // ExtractOriginalFilename extracts the original filename from Twitter media URL
@@ -81,15 +82,15 @@ func ExtractOriginalFilename(mediaURL string) string {
 }
 
 // EmbedMetadata embeds metadata into a media file
-// Only supports JPG (images) and MP4 (videos)
-func EmbedMetadata(filePath string, tweetContent string, tweetURL string, originalFilename string) error {
+// Only supports JPG (images) and MP4 (videos). posted is written as the capture date unless zero
+func EmbedMetadata(filePath string, tweetContent string, tweetURL string, originalFilename string, posted time.Time) error {
 	ext := strings.ToLower(filepath.Ext(filePath))
 
 	switch ext {
 	case ".jpg", ".jpeg":
-		return embedImageMetadata(filePath, tweetContent, tweetURL, originalFilename)
+		return embedImageMetadata(filePath, tweetContent, tweetURL, originalFilename, posted)
 	case ".mp4":
-		return embedVideoMetadata(filePath, tweetContent, tweetURL, originalFilename)
+		return embedVideoMetadata(filePath, tweetContent, tweetURL, originalFilename, posted)
 	default:
 		// For unsupported formats, skip metadata embedding
 		return nil
@@ -100,24 +101,31 @@ func EmbedMetadata(filePath string, tweetContent string, tweetURL string, origin
 // Since we don't want to add heavy dependencies, we'll use a simple approach:
 // For JPEG: We can use exiftool if available, or skip if not
 // For PNG: Limited support, skip for now
-func embedImageMetadata(filePath string, _ string, tweetURL string, originalFilename string) error {
+func embedImageMetadata(filePath string, _ string, tweetURL string, originalFilename string, posted time.Time) error {
 	// Try to use exiftool if available (common tool for metadata)
 	exiftoolPath := findExifTool()
-	if exiftoolPath == "" {
-		// exiftool not found, skip metadata embedding for images
-		// This is acceptable as it's an optional feature
-		return nil
-	}
 
 	// Build metadata comment
 	metadataComment := buildMetadataComment(tweetURL, originalFilename)
 
-	// Use exiftool to add comment only (URL | filename)
+	if exiftoolPath == "" {
+		// exiftool not found (or no Perl to run it), write the basic tags natively
+		return embedJPEGMetadataNative(filePath, metadataComment, posted)
+	}
+
+	// Use exiftool to add comment (URL | filename) and the posting date
 	args := []string{
 		"-overwrite_original",
 		"-Comment=" + metadataComment,
-		filePath,
 	}
+	if !posted.IsZero() {
+		// EXIF dates are wall-clock times, the offset tags say which zone they're in
+		args = append(args,
+			"-DateTimeOriginal="+posted.Format("2006:01:02 15:04:05"),
+			"-OffsetTimeOriginal="+posted.Format("-07:00"),
+		)
+	}
+	args = append(args, filePath)
 
 	cmd := exec.Command(exiftoolPath, args...)
 	hideWindow(cmd)
@@ -132,28 +140,36 @@ func embedImageMetadata(filePath string, _ string, tweetURL string, originalFile
 }
 
 // embedVideoMetadata embeds metadata into video/GIF files using ExifTool
-func embedVideoMetadata(filePath string, tweetContent string, tweetURL string, originalFilename string) error {
+func embedVideoMetadata(filePath string, tweetContent string, tweetURL string, originalFilename string, posted time.Time) error {
 	// Use ExifTool for video metadata (works well for MP4)
 	exiftoolPath := findExifTool()
 	if exiftoolPath == "" {
 		// ExifTool not available, skip metadata embedding (non-fatal)
+		warnNoExifTool()
 		return nil
 	}
 
-	return embedVideoMetadataWithExifTool(exiftoolPath, filePath, tweetContent, tweetURL, originalFilename)
+	return embedVideoMetadataWithExifTool(exiftoolPath, filePath, tweetContent, tweetURL, originalFilename, posted)
 }
 
 // embedVideoMetadataWithExifTool embeds metadata using ExifTool (preferred for MP4)
-func embedVideoMetadataWithExifTool(exiftoolPath string, filePath string, _ string, tweetURL string, originalFilename string) error {
+func embedVideoMetadataWithExifTool(exiftoolPath string, filePath string, _ string, tweetURL string, originalFilename string, posted time.Time) error {
 	// Build metadata comment
 	metadataComment := buildMetadataComment(tweetURL, originalFilename)
 
-	// Use exiftool to add comment only (URL | filename)
+	// Use exiftool to add comment (URL | filename) and the posting date
 	args := []string{
 		"-overwrite_original",
 		"-Comment=" + metadataComment,
-		filePath,
 	}
+	if !posted.IsZero() {
+		// QuickTime dates are stored in UTC; players convert them to their own zone
+		args = append(args,
+			"-api", "QuickTimeUTC",
+			"-QuickTime:CreateDate="+posted.Format("2006:01:02 15:04:05-07:00"),
+		)
+	}
+	args = append(args, filePath)
 
 	cmd := exec.Command(exiftoolPath, args...)
 	hideWindow(cmd)
@@ -188,6 +204,10 @@ func buildMetadataComment(tweetURL string, originalFilename string) string {
 
 // findExifTool searches for exiftool, prioritizing the installed version in .twitterxmediabatchdownloader
 func findExifTool() string {
+	if path := GetSettings().ExifToolPath; path != "" {
+		return path
+	}
+
 	// First, check if exiftool is installed in .twitterxmediabatchdownloader
 	if IsExifToolInstalled() {
 		return GetExifToolPath()
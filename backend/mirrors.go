@@ -0,0 +1,135 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Download mirrors
+//
+// SourceForge, GitHub and evermeet.cx are slow or blocked in some regions and networks. Every
+// tool archive can come from several places: a custom mirror set by the user (e.g. a company
+// artifact server holding the archives under their usual file names), the mirrors listed in the
+// tool manifest and the built-in ones. They're tried in that order until one download passes the
+// manifest checksum, so a mirror can never serve a different file than the pinned one.
+
+// toolSource is a tool archive and where it can be downloaded
+type toolSource struct {
+	name    string   // Archive file name, looked up on custom mirrors
+	urls    []string // Built-in download URLs, tried in order
+	version string   // Version in the name and URLs, "" for unversioned ("latest") downloads
+}
+
+// exiftoolVersion is the ExifTool version of the built-in URLs, the tool manifest can name a newer one
+const exiftoolVersion = "13.43"
+
+// Built-in tool archives per platform
+var (
+	ffmpegWindowsSource = toolSource{"ffmpeg-master-latest-win64-gpl.zip", []string{ffmpegWindowsURL}, ""}
+	ffmpegLinuxSource   = toolSource{"ffmpeg-master-latest-linux64-gpl.tar.xz", []string{ffmpegLinuxURL}, ""}
+	ffmpegMacOSSource   = toolSource{"ffmpeg-macos.zip", []string{ffmpegMacOSURL}, ""}
+	ffprobeMacOSSource  = toolSource{"ffprobe-macos.zip", []string{ffprobeMacOSURL}, ""}
+
+	exiftoolWindows64Source = toolSource{"exiftool-13.43_64.zip", []string{
+		exiftoolWindows64URL,
+		"https://downloads.sourceforge.net/project/exiftool/exiftool-13.43_64.zip",
+		"https://exiftool.org/exiftool-13.43_64.zip",
+	}, exiftoolVersion}
+	exiftoolWindows32Source = toolSource{"exiftool-13.43_32.zip", []string{
+		exiftoolWindows32URL,
+		"https://downloads.sourceforge.net/project/exiftool/exiftool-13.43_32.zip",
+		"https://exiftool.org/exiftool-13.43_32.zip",
+	}, exiftoolVersion}
+	exiftoolUnixSource = toolSource{"Image-ExifTool-13.43.tar.gz", []string{
+		exiftoolUnixURL,
+		"https://downloads.sourceforge.net/project/exiftool/Image-ExifTool-13.43.tar.gz",
+		"https://exiftool.org/Image-ExifTool-13.43.tar.gz",
+	}, exiftoolVersion}
+)
+
+// mirrorURL returns the URL of a file on a custom mirror ("" without a mirror)
+func mirrorURL(mirror, name string) string {
+	mirror = strings.TrimSpace(mirror)
+	if mirror == "" {
+		return ""
+	}
+	return strings.TrimRight(mirror, "/") + "/" + name
+}
+
+// downloadURLs returns every URL a tool archive can be downloaded from, in the order they're tried
+func downloadURLs(src toolSource, entry ToolManifestEntry, mirror string) []string {
+	var urls []string
+	seen := make(map[string]bool)
+	add := func(u string) {
+		if u != "" && !seen[u] {
+			seen[u] = true
+			urls = append(urls, u)
+		}
+	}
+
+	// The built-in URLs follow the version in the manifest, so a new release needs no app update
+	versioned := func(s string) string {
+		if src.version == "" || entry.Version == "" {
+			return s
+		}
+		return strings.ReplaceAll(s, src.version, entry.Version)
+	}
+
+	name := versioned(src.name)
+	if entry.Name != "" {
+		name = entry.Name
+	}
+	add(mirrorURL(mirror, name))
+	add(entry.URL)
+	for _, u := range entry.Mirrors {
+		add(u)
+	}
+	for _, u := range src.urls {
+		add(versioned(u))
+	}
+	return urls
+}
+
+// downloadVerified downloads a tool archive from the first URL that serves the pinned file
+// Returns the path of the verified temporary file
+func downloadVerified(urls []string, sha256 string, progressCallback func(downloaded, total int64)) (string, error) {
+	var errs []string
+	for _, u := range urls {
+		tempPath, err := downloadToTemp(u, progressCallback)
+		if err == nil {
+			if err = verifyHash(tempPath, sha256); err == nil {
+				return tempPath, nil
+			}
+			os.Remove(tempPath)
+		}
+		fmt.Printf("Warning: download from %s failed: %v\n", u, err)
+		errs = append(errs, fmt.Sprintf("%s: %v", u, err))
+	}
+	if len(errs) == 0 {
+		return "", fmt.Errorf("no download URL for %s/%s", runtime.GOOS, runtime.GOARCH)
+	}
+	return "", fmt.Errorf("all mirrors failed (%s)", strings.Join(errs, "; "))
+}
+
+// downloadTool downloads the pinned archive of a tool, trying all mirrors
+func downloadTool(tool string, src toolSource, mirror string, progressCallback func(downloaded, total int64)) (string, ToolManifestEntry, error) {
+	manifest, err := fetchToolManifest(mirror)
+	if err != nil {
+		return "", ToolManifestEntry{}, err
+	}
+	entry, err := manifest.entry(tool)
+	if err != nil {
+		return "", ToolManifestEntry{}, err
+	}
+	if entry.SHA256 == "" {
+		return "", ToolManifestEntry{}, fmt.Errorf("tool manifest has no checksum for %s", tool)
+	}
+
+	tempPath, err := downloadVerified(downloadURLs(src, entry, mirror), entry.SHA256, progressCallback)
+	if err != nil {
+		return "", ToolManifestEntry{}, fmt.Errorf("failed to download %s: %v", tool, err)
+	}
+	return tempPath, entry, nil
+}
@@ -0,0 +1,140 @@
+package backend
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Dead links
+//
+// Media of deleted tweets and suspended accounts answer 404 (or 410) for good. Such files aren't
+// counted as failed: their item status is "missing" and they're listed in missing.csv in the
+// account folder with the tweet ID, author, date, media URL and tweet text, so an archive shows
+// exactly what is gone (unless the Wayback Machine fallback recovers them, see wayback.go). Later
+// jobs merge into the file; a link keeps the time it was first found dead.
+
+// missingCSVFile lists the dead media links of an account folder
+const missingCSVFile = "missing.csv"
+
+// missingCSVHeader is the header row of missing.csv
+var missingCSVHeader = []string{"tweet_id", "author", "date", "media_url", "status", "detected_at", "text"}
+
+// isDeadLink reports whether a download failed because the media is gone for good
+func isDeadLink(err error) (int, bool) {
+	var statusErr *HTTPStatusError
+	if errors.As(err, &statusErr) && (statusErr.Code == http.StatusNotFound || statusErr.Code == http.StatusGone) {
+		return statusErr.Code, true
+	}
+	return 0, false
+}
+
+// missingLog collects the dead links found by a job, per account folder
+type missingLog struct {
+	mu    sync.Mutex
+	byDir map[string][][]string
+}
+
+// add records the dead link of a task
+func (l *missingLog) add(task downloadTask, err error, outputDir string) {
+	status, _ := isDeadLink(err)
+	// outputDir/username/type/file
+	dir := filepath.Dir(filepath.Dir(task.outputPath))
+	if rel, err := filepath.Rel(outputDir, dir); err != nil || rel == "." {
+		return
+	}
+	item := task.item
+	row := []string{
+		strconv.FormatInt(item.TweetID, 10),
+		item.Username,
+		item.Date,
+		item.URL,
+		strconv.Itoa(status),
+		time.Now().UTC().Format(time.RFC3339),
+		item.Content,
+	}
+	l.mu.Lock()
+	if l.byDir == nil {
+		l.byDir = make(map[string][][]string)
+	}
+	l.byDir[dir] = append(l.byDir[dir], row)
+	l.mu.Unlock()
+}
+
+// write merges the dead links into missing.csv of each account folder
+func (l *missingLog) write(store Storage) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var firstErr error
+	for dir, rows := range l.byDir {
+		if err := mergeMissingCSV(store, filepath.Join(dir, missingCSVFile), rows); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// mergeMissingCSV adds rows to a missing.csv file, newest tweets first
+func mergeMissingCSV(store Storage, path string, rows [][]string) error {
+	merged := make(map[string][]string) // tweet ID + media URL -> row
+	if r, err := store.Open(path); err == nil {
+		reader := csv.NewReader(r)
+		reader.FieldsPerRecord = -1
+		records, _ := reader.ReadAll()
+		r.Close()
+		for i, record := range records {
+			if i == 0 || len(record) < len(missingCSVHeader) {
+				continue // Header
+			}
+			merged[record[0]+"|"+record[3]] = record
+		}
+	}
+	for _, row := range rows {
+		key := row[0] + "|" + row[3]
+		if previous, ok := merged[key]; ok && previous[5] < row[5] {
+			row[5] = previous[5] // Keep when it was first found dead
+		}
+		merged[key] = row
+	}
+
+	keys := make([]string, 0, len(merged))
+	for key := range merged {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		a, b := merged[keys[i]], merged[keys[j]]
+		if a[0] != b[0] {
+			return tweetIDLess(b[0], a[0])
+		}
+		return a[3] < b[3]
+	})
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	w.Write(missingCSVHeader)
+	for _, key := range keys {
+		w.Write(merged[key])
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return fmt.Errorf("failed to encode %s: %v", missingCSVFile, err)
+	}
+
+	// Write next to the file and swap, so an interrupted write keeps the old list
+	tmp := path + ".tmp"
+	if err := writeStorageFile(store, tmp, &buf); err != nil {
+		return fmt.Errorf("failed to write %s: %v", missingCSVFile, err)
+	}
+	if err := store.Rename(tmp, path); err != nil {
+		store.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %v", missingCSVFile, err)
+	}
+	return nil
+}
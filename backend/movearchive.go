@@ -0,0 +1,234 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Moving archives
+//
+// Moving an account folder to another disk by hand is easy to get wrong: an interrupted copy
+// leaves half an archive behind and the app keeps pointing at the old place. MoveArchive copies
+// every file, verifies each copy against the source's SHA-256, records the new location in the
+// database and pending queues, and only then removes the source. Whenever it stops, the stored
+// paths point to a complete, verified archive.
+
+// MoveArchiveResult describes a moved account archive
+type MoveArchiveResult struct {
+	Source  string `json:"source"`
+	Target  string `json:"target"`
+	Files   int    `json:"files"`
+	Bytes   int64  `json:"bytes"`
+	Renamed bool   `json:"renamed"` // Moved with a single rename (same disk), nothing had to be copied
+}
+
+// MoveArchive moves the account folder {oldRoot}/{username} to {newRoot}/{username}
+// An existing target folder is merged into: identical files are kept, different ones abort the move
+func MoveArchive(username, oldRoot, newRoot string) (MoveArchiveResult, error) {
+	if username == "" || strings.ContainsAny(username, `/\`) || username == "." || username == ".." {
+		return MoveArchiveResult{}, fmt.Errorf("invalid username: %s", username)
+	}
+	oldAbs, err1 := filepath.Abs(oldRoot)
+	newAbs, err2 := filepath.Abs(newRoot)
+	if err1 != nil || err2 != nil || oldRoot == "" || newRoot == "" {
+		return MoveArchiveResult{}, fmt.Errorf("invalid archive folder")
+	}
+	src := filepath.Join(oldAbs, username)
+	dst := filepath.Join(newAbs, username)
+	result := MoveArchiveResult{Source: src, Target: dst}
+
+	if src == dst {
+		return result, fmt.Errorf("the archive is already in %s", newAbs)
+	}
+	if strings.HasPrefix(dst+string(filepath.Separator), src+string(filepath.Separator)) {
+		return result, fmt.Errorf("can't move an archive into itself")
+	}
+	if info, err := os.Stat(src); err != nil || !info.IsDir() {
+		return result, fmt.Errorf("archive folder not found: %s", src)
+	}
+	if err := os.MkdirAll(newAbs, 0755); err != nil {
+		return result, fmt.Errorf("failed to create target folder: %v", err)
+	}
+
+	// Same disk and no existing target - a rename is atomic, there's nothing to verify
+	if _, err := os.Stat(dst); os.IsNotExist(err) {
+		if err := os.Rename(src, dst); err == nil {
+			if err := updateArchivePaths(username, oldAbs, newAbs, dst); err != nil {
+				os.Rename(dst, src) // Keep the stored paths valid
+				return result, err
+			}
+			result.Renamed = true
+			result.Files, result.Bytes = countArchiveFiles(dst)
+			return result, nil
+		}
+	}
+
+	// Copy and verify everything before anything is removed
+	var sources []string
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil // Links and special files are left in place
+		}
+		n, err := copyVerified(path, target)
+		if err != nil {
+			return fmt.Errorf("%s: %v", rel, err)
+		}
+		sources = append(sources, path)
+		result.Files++
+		result.Bytes += n
+		return nil
+	})
+	if err != nil {
+		return result, fmt.Errorf("failed to copy archive (source kept): %v", err)
+	}
+
+	if err := updateArchivePaths(username, oldAbs, newAbs, dst); err != nil {
+		return result, err
+	}
+
+	for _, path := range sources {
+		os.Remove(path)
+	}
+	removeEmptyDirs(src)
+	return result, nil
+}
+
+// copyVerified copies a file and checks the copy on disk against the source's SHA-256
+// Returns the file size; an identical file already at the target counts as copied
+func copyVerified(srcPath, dstPath string) (int64, error) {
+	info, err := os.Stat(srcPath)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err := os.Stat(dstPath); err == nil {
+		srcHash, err1 := calculateSHA256(srcPath)
+		dstHash, err2 := calculateSHA256(dstPath)
+		if err1 != nil || err2 != nil || srcHash != dstHash {
+			return 0, fmt.Errorf("a different file already exists at %s", dstPath)
+		}
+		return info.Size(), nil
+	}
+
+	in, err := os.Open(srcPath)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+
+	tmpPath := dstPath + ".part"
+	out, err := os.Create(tmpPath)
+	if err != nil {
+		return 0, err
+	}
+	hash := sha256.New()
+	_, err = io.Copy(io.MultiWriter(out, hash), in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+
+	// Read the copy back rather than trusting the write
+	copyHash, err := calculateSHA256(tmpPath)
+	if err != nil || copyHash != hex.EncodeToString(hash.Sum(nil)) {
+		os.Remove(tmpPath)
+		return 0, fmt.Errorf("checksum mismatch after copy")
+	}
+	if err := os.Rename(tmpPath, dstPath); err != nil {
+		os.Remove(tmpPath)
+		return 0, err
+	}
+	os.Chtimes(dstPath, info.ModTime(), info.ModTime())
+	return info.Size(), nil
+}
+
+// updateArchivePaths points the account and its pending queues at the new archive location
+func updateArchivePaths(username, oldRoot, newRoot, archivePath string) error {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return err
+		}
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to update archive path: %v", err)
+	}
+	if _, err := tx.Exec("UPDATE accounts SET archive_path = ? WHERE LOWER(username) = LOWER(?)", archivePath, username); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to update archive path: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to update archive path: %v", err)
+	}
+
+	// Resumed jobs should continue in the new location
+	jobs, _ := ListQueues()
+	for _, job := range jobs {
+		if !strings.EqualFold(job.Username, username) || filepath.Clean(job.OutputDir) != oldRoot {
+			continue
+		}
+		job, items, _, err := LoadQueue(job.ID)
+		if err != nil {
+			continue
+		}
+		job.OutputDir = newRoot
+		if err := SaveQueue(job, items); err != nil {
+			fmt.Printf("Warning: failed to update queue %s: %v\n", job.ID, err)
+		}
+	}
+	return nil
+}
+
+// countArchiveFiles returns the number and total size of the files in a folder
+func countArchiveFiles(dir string) (int, int64) {
+	var files int
+	var bytes int64
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				files++
+				bytes += info.Size()
+			}
+		}
+		return nil
+	})
+	return files, bytes
+}
+
+// removeEmptyDirs removes dir and its subfolders if they contain no files
+func removeEmptyDirs(dir string) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return
+	}
+	for _, entry := range entries {
+		if entry.IsDir() {
+			removeEmptyDirs(filepath.Join(dir, entry.Name()))
+		}
+	}
+	os.Remove(dir) // Fails if anything is left
+}
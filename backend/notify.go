@@ -0,0 +1,67 @@
+package backend
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// notificationAppName is shown as the notification source where the OS supports it
+const notificationAppName = "XDown"
+
+// notificationAppID is the AppUserModelID toasts are sent as on Windows. Windows drops toasts of
+// IDs it doesn't know, so it's registered for the current user (HKCU, no installer or Start menu
+// shortcut needed) before the first toast.
+const notificationAppID = "afkarxyz.XDown"
+
+// SendNotification shows an OS-native desktop notification
+// Windows: toast via PowerShell, macOS: Notification Center via osascript, Linux: notify-send
+func SendNotification(title, message string) error {
+	var cmd *exec.Cmd
+
+	switch runtime.GOOS {
+	case "windows":
+		script := fmt.Sprintf(`$ErrorActionPreference = 'Stop'
+$key = 'HKCU:\Software\Classes\AppUserModelId\%s'
+if (-not (Test-Path $key)) { New-Item -Path $key -Force | Out-Null }
+New-ItemProperty -Path $key -Name DisplayName -Value '%s' -PropertyType String -Force | Out-Null
+[Windows.UI.Notifications.ToastNotificationManager, Windows.UI.Notifications, ContentType = WindowsRuntime] | Out-Null
+$template = [Windows.UI.Notifications.ToastNotificationManager]::GetTemplateContent([Windows.UI.Notifications.ToastTemplateType]::ToastText02)
+$texts = $template.GetElementsByTagName("text")
+$texts.Item(0).AppendChild($template.CreateTextNode('%s')) | Out-Null
+$texts.Item(1).AppendChild($template.CreateTextNode('%s')) | Out-Null
+$toast = [Windows.UI.Notifications.ToastNotification]::new($template)
+[Windows.UI.Notifications.ToastNotificationManager]::CreateToastNotifier('%s').Show($toast)`,
+			notificationAppID, notificationAppName, escapePowerShell(title), escapePowerShell(message), notificationAppID)
+		cmd = exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+	case "darwin":
+		script := fmt.Sprintf(`display notification "%s" with title "%s"`, escapeAppleScript(message), escapeAppleScript(title))
+		cmd = exec.Command("osascript", "-e", script)
+	case "linux":
+		if _, err := exec.LookPath("notify-send"); err != nil {
+			return fmt.Errorf("notify-send not found")
+		}
+		cmd = exec.Command("notify-send", "--app-name", notificationAppName, title, message)
+	default:
+		return fmt.Errorf("notifications not supported on %s", runtime.GOOS)
+	}
+
+	hideWindow(cmd) // Hide console window on Windows
+	output, err := cmd.CombinedOutput()
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %v, output: %s", err, string(output))
+	}
+	return nil
+}
+
+// escapePowerShell escapes a string for use inside single quotes in PowerShell
+func escapePowerShell(s string) string {
+	return strings.ReplaceAll(s, "'", "''")
+}
+
+// escapeAppleScript escapes a string for use inside double quotes in AppleScript
+func escapeAppleScript(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return strings.ReplaceAll(s, `"`, `\"`)
+}
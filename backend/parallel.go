@@ -0,0 +1,266 @@
+package backend
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Parallel pagination for large accounts
+//
+// A single timeline fetch pages through the account sequentially, which takes hours for
+// accounts with 50k+ media. Instead, the requested period is split into date shards that
+// are fetched concurrently with search since/until windows (each shard runs its own
+// extractor process), then merged and deduplicated.
+
+const (
+	defaultParallelShards  = 8
+	defaultParallelWorkers = 4
+	maxParallelWorkers     = 8
+	parallelDateLayout     = "2006-01-02"
+	// twitterLaunchDate is used as the start date when none is given
+	twitterLaunchDate = "2006-03-21"
+)
+
+// ParallelRequest represents request parameters for a sharded date range extraction
+type ParallelRequest struct {
+	Username    string         `json:"username"`
+	AuthToken   string         `json:"auth_token"`
+	StartDate   string         `json:"start_date,omitempty"` // YYYY-MM-DD, defaults to Twitter's launch
+	EndDate     string         `json:"end_date,omitempty"`   // YYYY-MM-DD (exclusive), defaults to tomorrow
+	MediaFilter string         `json:"media_filter"`
+	Retweets    bool           `json:"retweets"`
+	Shards      int            `json:"shards"`  // Number of date windows, 0 = default
+	Workers     int            `json:"workers"` // Extractor processes running at once, 0 = default
+	Filter      TimelineFilter `json:"filter,omitempty"`
+	DateZone    string         `json:"date_zone,omitempty"`
+
+	RestrictedAuthToken string `json:"restricted_auth_token,omitempty"` // See applyRestricted
+
+	// OnProgress receives the progress reports of every shard, see extractprogress.go (not persisted)
+	OnProgress func(ExtractProgress) `json:"-"`
+}
+
+// DateShard is a single [StartDate, EndDate) search window
+type DateShard struct {
+	StartDate string `json:"start_date"`
+	EndDate   string `json:"end_date"`
+}
+
+// ShardError describes a shard that failed to fetch
+type ShardError struct {
+	DateShard
+	Error string `json:"error"`
+}
+
+// ParallelResponse is the merged response of a sharded extraction
+type ParallelResponse struct {
+	*TwitterResponse
+	Shards       int          `json:"shards"`
+	FailedShards []ShardError `json:"failed_shards,omitempty"` // Shards to retry; results are partial if set
+}
+
+// SplitDateRange splits [startDate, endDate) into at most n windows of whole days
+func SplitDateRange(startDate, endDate string, n int) ([]DateShard, error) {
+	start, err := time.Parse(parallelDateLayout, startDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid start date: %v", err)
+	}
+	end, err := time.Parse(parallelDateLayout, endDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid end date: %v", err)
+	}
+	if !end.After(start) {
+		return nil, fmt.Errorf("end date must be after start date")
+	}
+
+	days := int(end.Sub(start).Hours() / 24)
+	if n <= 0 {
+		n = 1
+	}
+	if n > days {
+		n = days
+	}
+
+	shards := make([]DateShard, 0, n)
+	for i := 0; i < n; i++ {
+		from := start.AddDate(0, 0, days*i/n)
+		to := start.AddDate(0, 0, days*(i+1)/n)
+		shards = append(shards, DateShard{
+			StartDate: from.Format(parallelDateLayout),
+			EndDate:   to.Format(parallelDateLayout),
+		})
+	}
+	return shards, nil
+}
+
+// ExtractParallel fetches the account in date shards concurrently and merges the results
+// Failed shards are reported in the response instead of discarding the shards that succeeded
+func ExtractParallel(req ParallelRequest) (*ParallelResponse, error) {
+	startDate := req.StartDate
+	if startDate == "" {
+		startDate = twitterLaunchDate
+	}
+	endDate := req.EndDate
+	if endDate == "" {
+		endDate = time.Now().AddDate(0, 0, 1).Format(parallelDateLayout)
+	}
+
+	numShards := req.Shards
+	if numShards <= 0 {
+		numShards = defaultParallelShards
+	}
+	shards, err := SplitDateRange(startDate, endDate, numShards)
+	if err != nil {
+		return nil, err
+	}
+
+	workers := req.Workers
+	if workers <= 0 {
+		workers = defaultParallelWorkers
+	}
+	if workers > maxParallelWorkers {
+		workers = maxParallelWorkers
+	}
+
+	// Make sure the binary is extracted once, not by every worker at the same time
+	if _, err := ensureExtractor(); err != nil {
+		return nil, err
+	}
+
+	results := make([]*TwitterResponse, len(shards))
+	errs := make([]error, len(shards))
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+
+	for i, shard := range shards {
+		wg.Add(1)
+		go func(i int, shard DateShard) {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			shardReq := DateRangeRequest{
+				Username:    req.Username,
+				AuthToken:   req.AuthToken,
+				StartDate:   shard.StartDate,
+				EndDate:     shard.EndDate,
+				MediaFilter: req.MediaFilter,
+				Retweets:    req.Retweets,
+				Filter:      req.Filter,
+				DateZone:    req.DateZone,
+
+				RestrictedAuthToken: req.RestrictedAuthToken,
+				OnProgress:          req.OnProgress,
+			}
+			results[i], errs[i] = ExtractDateRange(shardReq)
+
+			// Rate limited: wait for the reset and retry the shard once
+			if wait, ok := rateLimitWait(shardRetryAt(results[i], errs[i])); ok {
+				time.Sleep(wait)
+				results[i], errs[i] = ExtractDateRange(shardReq)
+			}
+		}(i, shard)
+	}
+	wg.Wait()
+
+	var failed []ShardError
+	var succeeded []*TwitterResponse
+	for i, shard := range shards {
+		if errs[i] != nil {
+			failed = append(failed, ShardError{DateShard: shard, Error: errs[i].Error()})
+			continue
+		}
+		if results[i].Partial {
+			// Keep what the shard fetched, but it still needs a retry
+			failed = append(failed, ShardError{DateShard: shard, Error: results[i].Error})
+		}
+		succeeded = append(succeeded, results[i])
+	}
+	if len(succeeded) == 0 {
+		return nil, fmt.Errorf("all %d shards failed: %s", len(shards), failed[0].Error)
+	}
+
+	merged := mergeResponses(req.Username, succeeded)
+	merged.Completed = len(failed) == 0
+	merged.Metadata.Completed = merged.Completed
+
+	return &ParallelResponse{
+		TwitterResponse: merged,
+		Shards:          len(shards),
+		FailedShards:    failed,
+	}, nil
+}
+
+// shardRetryAt returns when a rate-limited shard can be retried, or the zero time
+func shardRetryAt(resp *TwitterResponse, err error) time.Time {
+	if err != nil {
+		return RetryAtFromMessage(err.Error())
+	}
+	if resp != nil && resp.Partial {
+		return RetryAtFromMessage(resp.Error)
+	}
+	return time.Time{}
+}
+
+// mergeResponses combines shard responses, dropping duplicate entries and sorting newest first
+func mergeResponses(username string, responses []*TwitterResponse) *TwitterResponse {
+	accountInfo := AccountInfo{Name: username, Nick: username}
+	seen := make(map[string]bool)
+	timeline := make([]TimelineEntry, 0)
+
+	for _, resp := range responses {
+		// Prefer account info from a shard that had media (has full user info)
+		if accountInfo.ProfileImage == "" && resp.AccountInfo.ProfileImage != "" {
+			accountInfo = resp.AccountInfo
+		}
+		if accountInfo.ID == "" {
+			accountInfo.ID = resp.AccountInfo.ID
+		}
+		if accountInfo.ProfileImageOriginal == "" && accountInfo.ProfileImage != "" {
+			// Stored by a version without full-size avatars
+			accountInfo.ProfileImageOriginal = OriginalProfileImageURL(accountInfo.ProfileImage)
+		}
+		for _, entry := range resp.Timeline {
+			key := fmt.Sprintf("%d|%s", int64(entry.TweetID), entry.URL)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			timeline = append(timeline, entry)
+		}
+	}
+
+	sort.SliceStable(timeline, func(i, j int) bool {
+		return int64(timeline[i].TweetID) > int64(timeline[j].TweetID)
+	})
+
+	merged := &TwitterResponse{
+		AccountInfo: accountInfo,
+		TotalURLs:   len(timeline),
+		Timeline:    timeline,
+		Metadata: ExtractMetadata{
+			NewEntries: len(timeline),
+		},
+	}
+
+	// Restricted tweets stay listed until one of the responses has their media
+	fetched := make(map[string]bool)
+	for _, entry := range timeline {
+		fetched[strconv.FormatInt(int64(entry.TweetID), 10)] = true
+	}
+	for _, resp := range responses {
+		merged.RestrictedRecovered += resp.RestrictedRecovered
+		for _, id := range resp.RestrictedTweetIDs {
+			if !fetched[id] {
+				fetched[id] = true
+				merged.RestrictedTweetIDs = append(merged.RestrictedTweetIDs, id)
+			}
+		}
+	}
+	merged.Restricted = len(merged.RestrictedTweetIDs) + merged.RestrictedRecovered
+	merged.Metadata.Stats = computeTimelineStats(merged.Timeline)
+	return merged
+}
@@ -0,0 +1,144 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// Pause and resume
+//
+// A running download job can be paused: its workers stop picking up files until it's resumed,
+// while the transfers already running finish. Paused with abort, the running transfers are cut
+// off too; they don't count as failed but are started again on resume. Targets that keep partial
+// .part files (see resumeDownload) continue an aborted file with a range request, others start it
+// over. Stopping a paused job works as usual and keeps the rest in the queue.
+
+// errJobPaused cancels the transfers of a job paused with abort
+var errJobPaused = errors.New("download_paused")
+
+// jobControl pauses and resumes a download job
+type jobControl struct {
+	mu          sync.Mutex
+	paused      bool
+	resumed     chan struct{}   // Closed on resume
+	abort       context.Context // Cancelled when paused with abort, replaced on resume
+	cancelAbort context.CancelFunc
+}
+
+// newJobControl returns the control of a running job
+func newJobControl() *jobControl {
+	c := &jobControl{}
+	c.abort, c.cancelAbort = context.WithCancel(context.Background())
+	return c
+}
+
+// pause stops the job from starting new transfers, and with abort cuts off the running ones
+func (c *jobControl) pause(abort bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		c.paused = true
+		c.resumed = make(chan struct{})
+	}
+	if abort {
+		c.cancelAbort()
+	}
+}
+
+// resume lets the job go on; returns false if it wasn't paused
+func (c *jobControl) resume() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		return false
+	}
+	c.paused = false
+	close(c.resumed)
+	c.cancelAbort()
+	c.abort, c.cancelAbort = context.WithCancel(context.Background())
+	return true
+}
+
+// isPaused reports whether the job is paused
+func (c *jobControl) isPaused() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.paused
+}
+
+// wait blocks while the job is paused; returns false if ctx ended
+func (c *jobControl) wait(ctx context.Context) bool {
+	for {
+		c.mu.Lock()
+		paused, resumed := c.paused, c.resumed
+		c.mu.Unlock()
+		if !paused {
+			return ctx.Err() == nil
+		}
+		select {
+		case <-resumed:
+		case <-ctx.Done():
+			return false
+		}
+	}
+}
+
+// transfer runs a download, and runs it again after the resume if a pause cut it off
+func (c *jobControl) transfer(ctx context.Context, download func(ctx context.Context) error) error {
+	for {
+		if !c.wait(ctx) {
+			return ctx.Err()
+		}
+		c.mu.Lock()
+		abort := c.abort
+		c.mu.Unlock()
+
+		transferCtx, cancel := context.WithCancelCause(ctx)
+		stop := context.AfterFunc(abort, func() { cancel(errJobPaused) })
+		err := download(transferCtx)
+		stop()
+		cancel(nil)
+		if err == nil || ctx.Err() != nil || !errors.Is(context.Cause(transferCtx), errJobPaused) {
+			return err
+		}
+	}
+}
+
+// runningJob returns the control of a downloading job
+func runningJob(id string) (*jobControl, error) {
+	value, ok := runningQueues.Load(id)
+	if !ok {
+		return nil, fmt.Errorf("job %s isn't downloading", id)
+	}
+	return value.(*jobControl), nil
+}
+
+// PauseJob pauses a downloading job; with abort the running transfers are cut off and restarted on resume
+func PauseJob(id string, abort bool) error {
+	control, err := runningJob(id)
+	if err != nil {
+		return err
+	}
+	control.pause(abort)
+	return nil
+}
+
+// ResumeJob resumes a paused job
+func ResumeJob(id string) error {
+	control, err := runningJob(id)
+	if err != nil {
+		return err
+	}
+	if !control.resume() {
+		return fmt.Errorf("job %s isn't paused", id)
+	}
+	return nil
+}
+
+// IsJobPaused reports whether a downloading job is paused
+func IsJobPaused(id string) bool {
+	control, err := runningJob(id)
+	return err == nil && control.isPaused()
+}
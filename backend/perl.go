@@ -0,0 +1,76 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"sync"
+)
+
+// Perl for exiftool
+//
+// On Linux and macOS the downloaded exiftool is a Perl script (the Windows executable bundles
+// Perl). Without Perl it can't run, which used to look like a failed download. Perl is now checked
+// up front: downloads and imports of exiftool fail with a clear message, the status tells the UI
+// why exiftool doesn't work, and metadata falls back to the native JPEG writer - videos are
+// saved without metadata, with a warning.
+
+// ExifToolStatus describes whether metadata can be embedded and how
+type ExifToolStatus struct {
+	Installed   bool   `json:"installed"`    // exiftool runs
+	PerlMissing bool   `json:"perl_missing"` // exiftool can't run because Perl isn't installed
+	Fallback    bool   `json:"fallback"`     // JPEG metadata is written natively instead
+	Message     string `json:"message,omitempty"`
+}
+
+// errPerlMissing explains why exiftool can't be installed
+var errPerlMissing = fmt.Errorf("ExifTool needs Perl, which isn't installed - install perl with your package manager " +
+	"(until then, JPEG metadata is written without ExifTool and videos are saved without metadata)")
+
+var perlWarning sync.Once
+
+// IsPerlAvailable checks if Perl is installed (always true on Windows, where exiftool bundles it)
+func IsPerlAvailable() bool {
+	if runtime.GOOS == "windows" {
+		return true
+	}
+	path, err := exec.LookPath("perl")
+	if err != nil {
+		// GUI apps on macOS may not have the full PATH
+		for _, candidate := range []string{"/usr/bin/perl", "/opt/homebrew/bin/perl", "/usr/local/bin/perl"} {
+			if _, err := os.Stat(candidate); err == nil {
+				path = candidate
+				break
+			}
+		}
+	}
+	if path == "" {
+		return false
+	}
+	cmd := exec.Command(path, "-e", "1")
+	hideWindow(cmd)
+	return cmd.Run() == nil
+}
+
+// GetExifToolStatus returns whether exiftool works, and why not if Perl is missing
+func GetExifToolStatus() ExifToolStatus {
+	if IsExifToolInstalled() {
+		return ExifToolStatus{Installed: true}
+	}
+	if !IsPerlAvailable() {
+		return ExifToolStatus{PerlMissing: true, Fallback: true, Message: errPerlMissing.Error()}
+	}
+	return ExifToolStatus{Fallback: true, Message: "ExifTool isn't installed - JPEG metadata is written without it, videos are saved without metadata"}
+}
+
+// warnNoExifTool logs once per run that video metadata is skipped
+func warnNoExifTool() {
+	perlWarning.Do(func() {
+		if !IsPerlAvailable() {
+			fmt.Printf("Warning: %v\n", errPerlMissing)
+		} else {
+			fmt.Println("Warning: ExifTool isn't installed, videos are saved without metadata")
+		}
+	})
+}
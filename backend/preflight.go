@@ -0,0 +1,335 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Path problem codes reported by the preflight check
+const (
+	PathProblemTooLong       = "too_long"
+	PathProblemNameTooLong   = "name_too_long"
+	PathProblemInvalidChars  = "invalid_chars"
+	PathProblemReservedName  = "reserved_name"
+	PathProblemTrailingChars = "trailing_dot_space"
+	PathProblemCaseCollision = "case_collision"
+)
+
+// maxComponentBytes is the file name limit on practically every filesystem (NTFS, ext4, APFS);
+// NTFS counts it in UTF-16 code units
+const maxComponentBytes = 255
+
+// windowsReservedNames are device names that can't be used as file names on Windows
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true, "COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true, "LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// PathIssue describes a problem with a planned download path and the proposed fix
+type PathIssue struct {
+	Index     int      `json:"index"` // Index of the item in the request
+	TweetID   string   `json:"tweet_id"`
+	Path      string   `json:"path"`
+	Problems  []string `json:"problems"`
+	Message   string   `json:"message"`
+	FixedPath string   `json:"fixed_path"`
+}
+
+// PreflightReport is the result of simulating all target paths of a download job
+type PreflightReport struct {
+	OutputDir       string      `json:"output_dir"`
+	TotalPaths      int         `json:"total_paths"`
+	MaxPathLength   int         `json:"max_path_length"`
+	CaseInsensitive bool        `json:"case_insensitive"`
+	Issues          []PathIssue `json:"issues"`
+}
+
+// pathRules describes the constraints of the destination filesystem
+type pathRules struct {
+	maxPath         int
+	windowsNames    bool // Apply Windows character and reserved name rules
+	caseInsensitive bool
+}
+
+// PreflightDownload simulates the full set of target paths for a job and reports
+// problems (length, characters, case collisions) with proposed automatic fixes
+func PreflightDownload(items []MediaItem, outputDir string, username string, opts DownloadOptions) PreflightReport {
+	// Plan the raw paths - fixes are what we're reporting, so don't apply them here
+	opts.SanitizePaths = false
+	tasks, _ := planDownloadTasks(items, outputDir, username, opts)
+
+	rules := detectPathRules(outputDir)
+	issues := checkPlannedPaths(tasks, outputDir, rules, opts.CollisionSuffix)
+
+	return PreflightReport{
+		OutputDir:       outputDir,
+		TotalPaths:      len(tasks),
+		MaxPathLength:   rules.maxPath,
+		CaseInsensitive: rules.caseInsensitive,
+		Issues:          issues,
+	}
+}
+
+// applyPathFixes rewrites task paths in place using the preflight fixes
+func applyPathFixes(tasks []downloadTask, outputDir string, collision string) {
+	rules := detectPathRules(outputDir)
+	fixes := make(map[int]string)
+	for _, issue := range checkPlannedPaths(tasks, outputDir, rules, collision) {
+		fixes[issue.Index] = issue.FixedPath
+	}
+	for i := range tasks {
+		if fixed, ok := fixes[tasks[i].index]; ok {
+			tasks[i].outputPath = fixed
+		}
+	}
+}
+
+// pathLength returns the length of a path or file name as the filesystem limits count it:
+// UTF-16 code units on Windows, bytes elsewhere
+func (r pathRules) pathLength(path string) int {
+	if r.windowsNames {
+		return len(utf16.Encode([]rune(path)))
+	}
+	return len(path)
+}
+
+// detectPathRules returns the path constraints for the destination directory
+func detectPathRules(outputDir string) pathRules {
+	switch runtime.GOOS {
+	case "windows":
+		// MAX_PATH is 260 including the terminating NUL
+		return pathRules{maxPath: 259, windowsNames: true, caseInsensitive: probeCaseInsensitive(outputDir, true)}
+	case "darwin":
+		return pathRules{maxPath: 1023, caseInsensitive: probeCaseInsensitive(outputDir, true)}
+	default:
+		return pathRules{maxPath: 4095, caseInsensitive: probeCaseInsensitive(outputDir, false)}
+	}
+}
+
+// probeCaseInsensitive checks whether the filesystem at dir ignores case by creating a probe file
+// Falls back to the platform default if the directory doesn't exist or isn't writable
+func probeCaseInsensitive(dir string, fallback bool) bool {
+	if info, err := os.Stat(dir); err != nil || !info.IsDir() {
+		return fallback
+	}
+
+	probe, err := os.CreateTemp(dir, ".casecheck-*")
+	if err != nil {
+		return fallback
+	}
+	probePath := probe.Name()
+	probe.Close()
+	defer os.Remove(probePath)
+
+	upper := filepath.Join(dir, strings.ToUpper(filepath.Base(probePath)))
+	if upper == probePath {
+		return fallback
+	}
+	_, err = os.Stat(upper)
+	return err == nil
+}
+
+// checkPlannedPaths validates every planned path and returns one issue per problematic path
+// Colliding paths get a collision suffix: a hash of the media URL (the content isn't known yet) or a counter
+func checkPlannedPaths(tasks []downloadTask, outputDir string, rules pathRules, collision string) []PathIssue {
+	var issues []PathIssue
+	seen := make(map[string]bool)
+
+	for _, task := range tasks {
+		fixed, problems := fixPath(task.outputPath, outputDir, rules)
+
+		// Collisions are checked on the fixed path so the suggested fix is itself unique
+		key := fixed
+		if rules.caseInsensitive {
+			key = strings.ToLower(fixed)
+		}
+		if seen[key] {
+			problems = append(problems, PathProblemCaseCollision)
+			hash := ""
+			if collision == CollisionHash {
+				hash = calculateHash([]byte(task.item.URL + task.item.Content))
+			}
+			fixed = uniquePath(fixed, hash, seen, rules.caseInsensitive)
+			key = fixed
+			if rules.caseInsensitive {
+				key = strings.ToLower(fixed)
+			}
+		}
+		seen[key] = true
+
+		if len(problems) == 0 {
+			continue
+		}
+
+		issues = append(issues, PathIssue{
+			Index:     task.index,
+			TweetID:   fmt.Sprintf("%d", task.item.TweetID),
+			Path:      task.outputPath,
+			Problems:  problems,
+			Message:   describePathProblems(problems, rules),
+			FixedPath: fixed,
+		})
+	}
+
+	return issues
+}
+
+// fixPath sanitizes the components below outputDir and shortens the file name if the path is too long
+func fixPath(path, outputDir string, rules pathRules) (string, []string) {
+	var problems []string
+	addProblem := func(p string) {
+		for _, existing := range problems {
+			if existing == p {
+				return
+			}
+		}
+		problems = append(problems, p)
+	}
+
+	rel, err := filepath.Rel(outputDir, path)
+	if err != nil {
+		rel = filepath.Base(path)
+		outputDir = filepath.Dir(path)
+	}
+
+	parts := strings.Split(rel, string(filepath.Separator))
+	for i, part := range parts {
+		if rules.windowsNames {
+			if cleaned := replaceInvalidChars(part); cleaned != part {
+				addProblem(PathProblemInvalidChars)
+				part = cleaned
+			}
+			if trimmed := strings.TrimRight(part, ". "); trimmed != part {
+				addProblem(PathProblemTrailingChars)
+				part = trimmed
+				if part == "" {
+					part = "_"
+				}
+			}
+			base := strings.ToUpper(strings.SplitN(part, ".", 2)[0])
+			if windowsReservedNames[base] {
+				addProblem(PathProblemReservedName)
+				part = "_" + part
+			}
+		} else if strings.ContainsRune(part, 0) {
+			addProblem(PathProblemInvalidChars)
+			part = strings.ReplaceAll(part, "\x00", "_")
+		}
+
+		for over := rules.pathLength(part) - maxComponentBytes; over > 0; over = rules.pathLength(part) - maxComponentBytes {
+			addProblem(PathProblemNameTooLong)
+			shorter := shortenName(part, len(part)-over)
+			if shorter == part {
+				break
+			}
+			part = shorter
+		}
+		parts[i] = part
+	}
+
+	fixed := filepath.Join(append([]string{outputDir}, parts...)...)
+
+	// Shorten the file name (keeping its tail, which holds the tweet ID and index) to fit the path limit.
+	// A UTF-16 code unit can take up to 3 bytes, so cutting the excess may take a few rounds.
+	for over := rules.pathLength(fixed) - rules.maxPath; over > 0; over = rules.pathLength(fixed) - rules.maxPath {
+		addProblem(PathProblemTooLong)
+		name := parts[len(parts)-1]
+		shorter := shortenName(name, len(name)-over)
+		if shorter == name {
+			break
+		}
+		parts[len(parts)-1] = shorter
+		fixed = filepath.Join(append([]string{outputDir}, parts...)...)
+	}
+
+	return fixed, problems
+}
+
+// replaceInvalidChars replaces characters Windows doesn't allow in file names with underscores
+func replaceInvalidChars(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r < 32 || strings.ContainsRune(`<>:"/\|?*`, r) {
+			return '_'
+		}
+		return r
+	}, name)
+}
+
+// shortenName trims a file name to at most maxBytes, keeping the extension and the end of the base name
+func shortenName(name string, maxBytes int) string {
+	if len(name) <= maxBytes {
+		return name
+	}
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+
+	keep := maxBytes - len(ext)
+	if keep < 1 {
+		keep = 1
+	}
+	if keep > len(base) {
+		keep = len(base)
+	}
+
+	// Cut from the front without splitting a multi-byte character
+	cut := len(base) - keep
+	for cut < len(base) && !utf8.RuneStart(base[cut]) {
+		cut++
+	}
+	return base[cut:] + ext
+}
+
+// uniquePath appends _<hash> (if set) or _2, _3, ... before the extension until the path is unused
+func uniquePath(path, hash string, seen map[string]bool, caseInsensitive bool) string {
+	isSeen := func(candidate string) bool {
+		if caseInsensitive {
+			candidate = strings.ToLower(candidate)
+		}
+		return seen[candidate]
+	}
+	if hash != "" {
+		if candidate := hashSuffixPath(path, hash); !isSeen(candidate) {
+			return candidate
+		}
+	}
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, n, ext)
+		if !isSeen(candidate) {
+			return candidate
+		}
+	}
+}
+
+// describePathProblems builds a human readable summary of the problems
+func describePathProblems(problems []string, rules pathRules) string {
+	var parts []string
+	for _, p := range problems {
+		switch p {
+		case PathProblemTooLong:
+			parts = append(parts, fmt.Sprintf("path exceeds %d characters", rules.maxPath))
+		case PathProblemNameTooLong:
+			unit := "bytes"
+			if rules.windowsNames {
+				unit = "characters"
+			}
+			parts = append(parts, fmt.Sprintf("file name exceeds %d %s", maxComponentBytes, unit))
+		case PathProblemInvalidChars:
+			parts = append(parts, "contains characters not allowed by the filesystem")
+		case PathProblemReservedName:
+			parts = append(parts, "uses a reserved Windows device name")
+		case PathProblemTrailingChars:
+			parts = append(parts, "ends with a dot or space")
+		case PathProblemCaseCollision:
+			parts = append(parts, "collides with another file in this job")
+		}
+	}
+	return strings.Join(parts, "; ")
+}
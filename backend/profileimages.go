@@ -0,0 +1,55 @@
+package backend
+
+import (
+	"net/url"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// Profile images
+//
+// The extractor reports the avatar in its small "_normal" variant (48x48) and the banner in
+// whatever size the API picked. Both are kept as reported for small thumbnails and rewritten to
+// their original uploads: avatars lose the size suffix of the file name
+// (profile_images/<id>/<name>_normal.jpg -> <name>.jpg) and banners the size segment after the
+// upload timestamp (profile_banners/<user>/<timestamp>/1500x500 -> <timestamp>). URLs that
+// don't look like either are returned unchanged.
+
+// avatarSizeSuffix matches the size variant at the end of an avatar file name
+var avatarSizeSuffix = regexp.MustCompile(`_(normal|bigger|mini|reasonably_small|x96|\d+x\d+)$`)
+
+// OriginalProfileImageURL returns the URL of the original upload of an avatar
+func OriginalProfileImageURL(imageURL string) string {
+	u, err := url.Parse(imageURL)
+	if err != nil || !strings.HasSuffix(u.Hostname(), "twimg.com") || !strings.HasPrefix(u.Path, "/profile_images/") {
+		return imageURL
+	}
+	dir, file := path.Split(u.Path)
+	ext := path.Ext(file)
+	u.Path = dir + avatarSizeSuffix.ReplaceAllString(strings.TrimSuffix(file, ext), "") + ext
+	return u.String()
+}
+
+// OriginalProfileBannerURL returns the URL of the original upload of a profile banner
+func OriginalProfileBannerURL(bannerURL string) string {
+	u, err := url.Parse(bannerURL)
+	if err != nil || !strings.HasSuffix(u.Hostname(), "twimg.com") || !strings.HasPrefix(u.Path, "/profile_banners/") {
+		return bannerURL
+	}
+	// profile_banners/<user>/<timestamp>[/<size>]
+	parts := strings.Split(strings.TrimPrefix(u.Path, "/profile_banners/"), "/")
+	if len(parts) < 2 {
+		return bannerURL
+	}
+	u.Path = "/profile_banners/" + parts[0] + "/" + parts[1]
+	return u.String()
+}
+
+// setProfileImages fills in the avatar and banner of an account, as reported and at full size
+func (a *AccountInfo) setProfileImages(user UserInfo) {
+	a.ProfileImage = user.ProfileImage
+	a.ProfileImageOriginal = OriginalProfileImageURL(user.ProfileImage)
+	a.ProfileBanner = user.ProfileBanner
+	a.ProfileBannerOriginal = OriginalProfileBannerURL(user.ProfileBanner)
+}
@@ -0,0 +1,239 @@
+package backend
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Pending download queues are persisted when a job is stopped so it can be resumed later.
+// Each job is stored as two files in <app dir>/queue:
+//
+//	<id>.json  - job settings (QueueJob)
+//	<id>.jsonl - one pending MediaItem per line
+//
+// The .jsonl file is meant to be edited by hand while the job is stopped:
+// delete lines to skip items, or reorder them to change download order.
+// Lines are validated on resume; invalid lines are reported and skipped.
+// Job files from older versions are upgraded when loaded, see stateversion.go.
+
+// QueueJob holds the settings of a persisted download job
+type QueueJob struct {
+	Version   int             `json:"version"` // Format version, see queueFormatVersion
+	ID        string          `json:"id"`
+	Username  string          `json:"username"`
+	OutputDir string          `json:"output_dir"`
+	Proxy     string          `json:"proxy,omitempty"`
+	Options   DownloadOptions `json:"options"`
+	CreatedAt time.Time       `json:"created_at"`
+	UpdatedAt time.Time       `json:"updated_at"`
+	Pending   int             `json:"pending"` // Number of lines in the .jsonl file when it was written
+}
+
+// QueueLineError describes an invalid line found when loading a queue
+type QueueLineError struct {
+	Line    int    `json:"line"`
+	Message string `json:"message"`
+}
+
+// queueIDPattern restricts queue IDs to safe file names
+var queueIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// GetQueueDir returns the directory holding persisted queues
+func GetQueueDir() string {
+	return filepath.Join(GetAppDataDir(), "queue")
+}
+
+// NewQueueID returns a new queue ID for a job: {username}_{unix timestamp}
+func NewQueueID(username string) string {
+	name := strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9') || r == '_' {
+			return r
+		}
+		return '_'
+	}, username)
+	if name == "" {
+		name = "job"
+	}
+	return fmt.Sprintf("%s_%d", name, time.Now().UnixNano())
+}
+
+// GetQueuePath returns the path of the editable .jsonl file of a queue
+func GetQueuePath(id string) (string, error) {
+	if !queueIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid queue id: %s", id)
+	}
+	return filepath.Join(GetQueueDir(), id+".jsonl"), nil
+}
+
+// SaveQueue writes the job settings and pending items of a queue
+func SaveQueue(job QueueJob, items []MediaItem) error {
+	itemsPath, err := GetQueuePath(job.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(GetQueueDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create queue directory: %v", err)
+	}
+
+	now := time.Now()
+	if job.CreatedAt.IsZero() {
+		job.CreatedAt = now
+	}
+	job.UpdatedAt = now
+	job.Pending = len(items)
+	job.Version = queueFormatVersion
+	job.Options = stashStorageSecrets(pinQueueOptions(job.Options))
+
+	// Write items first so a job file never points to missing items
+	f, err := os.Create(itemsPath)
+	if err != nil {
+		return fmt.Errorf("failed to create queue file: %v", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, item := range items {
+		if err := enc.Encode(item); err != nil {
+			f.Close()
+			return fmt.Errorf("failed to write queue item: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		f.Close()
+		return fmt.Errorf("failed to write queue file: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write queue file: %v", err)
+	}
+
+	jobData, err := json.MarshalIndent(job, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(strings.TrimSuffix(itemsPath, ".jsonl")+".json", jobData, 0644)
+}
+
+// LoadQueue reads a queue, validating every line of the .jsonl file
+// Invalid lines are returned as errors and left out of the items
+func LoadQueue(id string) (QueueJob, []MediaItem, []QueueLineError, error) {
+	itemsPath, err := GetQueuePath(id)
+	if err != nil {
+		return QueueJob{}, nil, nil, err
+	}
+	if _, err := os.Stat(strings.TrimSuffix(itemsPath, ".jsonl") + ".json"); err != nil {
+		return QueueJob{}, nil, nil, fmt.Errorf("queue not found: %s", id)
+	}
+	return loadQueueFiles(strings.TrimSuffix(itemsPath, ".jsonl")+".json", itemsPath)
+}
+
+// loadQueueFiles reads a queue from its job and items files, upgrading older job files
+func loadQueueFiles(jobPath, itemsPath string) (QueueJob, []MediaItem, []QueueLineError, error) {
+	jobData, err := os.ReadFile(jobPath)
+	if err != nil {
+		return QueueJob{}, nil, nil, fmt.Errorf("failed to read queue job file: %v", err)
+	}
+	job, err := decodeQueueJob(jobData)
+	if err != nil {
+		return job, nil, nil, err
+	}
+
+	f, err := os.Open(itemsPath)
+	if err != nil {
+		return job, nil, nil, fmt.Errorf("failed to open queue file: %v", err)
+	}
+	defer f.Close()
+
+	var items []MediaItem
+	var lineErrors []QueueLineError
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // Text tweets can be long
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue // Allow blank lines and comments
+		}
+
+		var item MediaItem
+		if err := json.Unmarshal([]byte(line), &item); err != nil {
+			lineErrors = append(lineErrors, QueueLineError{Line: lineNum, Message: fmt.Sprintf("invalid JSON: %v", err)})
+			continue
+		}
+		if msg := validateQueueItem(item); msg != "" {
+			lineErrors = append(lineErrors, QueueLineError{Line: lineNum, Message: msg})
+			continue
+		}
+		items = append(items, item)
+	}
+	if err := scanner.Err(); err != nil {
+		return job, nil, nil, fmt.Errorf("failed to read queue file: %v", err)
+	}
+
+	return job, items, lineErrors, nil
+}
+
+// validateQueueItem returns a description of what's wrong with an item, or "" if valid
+func validateQueueItem(item MediaItem) string {
+	if item.TweetID <= 0 {
+		return "missing or invalid tweet_id"
+	}
+	switch item.Type {
+	case "photo", "video", "gif", "animated_gif":
+		if !strings.HasPrefix(item.URL, "http://") && !strings.HasPrefix(item.URL, "https://") {
+			return "missing or invalid url"
+		}
+	case "text":
+		// Text tweets have no URL, content is written to a file
+	case "":
+		return "missing type"
+	default:
+		return fmt.Sprintf("unknown type: %s", item.Type)
+	}
+	return ""
+}
+
+// ListQueues returns all persisted queues, most recently updated first
+func ListQueues() ([]QueueJob, error) {
+	matches, err := filepath.Glob(filepath.Join(GetQueueDir(), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+
+	jobs := make([]QueueJob, 0, len(matches))
+	for _, path := range matches {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		var job QueueJob
+		if err := json.Unmarshal(data, &job); err != nil {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+
+	sort.Slice(jobs, func(i, j int) bool {
+		return jobs[i].UpdatedAt.After(jobs[j].UpdatedAt)
+	})
+	return jobs, nil
+}
+
+// DeleteQueue removes a persisted queue
+func DeleteQueue(id string) error {
+	itemsPath, err := GetQueuePath(id)
+	if err != nil {
+		return err
+	}
+	os.Remove(itemsPath)
+	if err := os.Remove(strings.TrimSuffix(itemsPath, ".jsonl") + ".json"); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
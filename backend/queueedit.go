@@ -0,0 +1,193 @@
+package backend
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Queue editing
+//
+// A stopped job's queue is the list of what downloads next when it's resumed. Besides editing
+// the .jsonl file by hand, it can be paged through and edited here: move items or whole authors
+// to the front (or back), or drop them. Edits are refused while the job is running, since the
+// running job rewrites the queue when it stops.
+
+// ErrQueueRunning is returned when a queue is edited while its job is downloading
+var ErrQueueRunning = errors.New("queue is being downloaded - stop the download first")
+
+// runningQueues holds the controls of downloading jobs by queue ID, see pause.go
+var runningQueues sync.Map
+
+// QueueItem is a pending item with its position in the queue
+type QueueItem struct {
+	Position int `json:"position"` // 0-based, the next item to download is 0
+	MediaItem
+}
+
+// QueueAuthor is an author with pending items in a queue
+type QueueAuthor struct {
+	Username string `json:"username"`
+	Items    int    `json:"items"`
+}
+
+// QueuePage is a page of a queue's pending items
+type QueuePage struct {
+	Job          QueueJob         `json:"job"`
+	Items        []QueueItem      `json:"items"`
+	Total        int              `json:"total"`
+	Offset       int              `json:"offset"`
+	Authors      []QueueAuthor    `json:"authors"` // All authors in the queue, most items first
+	Running      bool             `json:"running"`
+	InvalidLines []QueueLineError `json:"invalid_lines,omitempty"`
+}
+
+// GetQueueItems returns limit pending items of a queue starting at offset (limit 0 = all)
+func GetQueueItems(id string, offset, limit int) (QueuePage, error) {
+	job, items, lineErrors, err := LoadQueue(id)
+	if err != nil {
+		return QueuePage{}, err
+	}
+	_, running := runningQueues.Load(id)
+	page := QueuePage{Job: job, Total: len(items), Offset: offset, Running: running, InvalidLines: lineErrors}
+
+	counts := make(map[string]int)
+	for _, item := range items {
+		counts[queueItemAuthor(job, item)]++
+	}
+	for name, n := range counts {
+		page.Authors = append(page.Authors, QueueAuthor{Username: name, Items: n})
+	}
+	sort.Slice(page.Authors, func(i, j int) bool {
+		if page.Authors[i].Items != page.Authors[j].Items {
+			return page.Authors[i].Items > page.Authors[j].Items
+		}
+		return page.Authors[i].Username < page.Authors[j].Username
+	})
+
+	if offset < 0 {
+		offset = 0
+	}
+	end := len(items)
+	if limit > 0 && offset+limit < end {
+		end = offset + limit
+	}
+	for i := offset; i < end; i++ {
+		page.Items = append(page.Items, QueueItem{Position: i, MediaItem: items[i]})
+	}
+	return page, nil
+}
+
+// MoveQueueItems moves the items at positions (keeping their order) in front of the item at to
+// to <= 0 moves them to the front, to >= the queue length to the end
+func MoveQueueItems(id string, positions []int, to int) error {
+	return editQueue(id, func(job QueueJob, items []MediaItem) []MediaItem {
+		selected := positionSet(positions, len(items))
+		return moveSelected(items, to, func(i int, _ MediaItem) bool { return selected[i] })
+	})
+}
+
+// RemoveQueueItems drops the items at positions from the queue
+func RemoveQueueItems(id string, positions []int) error {
+	return editQueue(id, func(job QueueJob, items []MediaItem) []MediaItem {
+		selected := positionSet(positions, len(items))
+		kept := items[:0]
+		for i, item := range items {
+			if !selected[i] {
+				kept = append(kept, item)
+			}
+		}
+		return kept
+	})
+}
+
+// MoveQueueAuthors moves all items of the given authors to the front (or the end) of the queue
+func MoveQueueAuthors(id string, usernames []string, toFront bool) error {
+	return editQueue(id, func(job QueueJob, items []MediaItem) []MediaItem {
+		to := len(items)
+		if toFront {
+			to = 0
+		}
+		return moveSelected(items, to, func(_ int, item MediaItem) bool {
+			return containsFold(usernames, queueItemAuthor(job, item))
+		})
+	})
+}
+
+// RemoveQueueAuthors drops all items of the given authors from the queue
+func RemoveQueueAuthors(id string, usernames []string) error {
+	return editQueue(id, func(job QueueJob, items []MediaItem) []MediaItem {
+		kept := items[:0]
+		for _, item := range items {
+			if !containsFold(usernames, queueItemAuthor(job, item)) {
+				kept = append(kept, item)
+			}
+		}
+		return kept
+	})
+}
+
+// editQueue loads a stopped queue, applies edit and saves the result
+func editQueue(id string, edit func(job QueueJob, items []MediaItem) []MediaItem) error {
+	if _, running := runningQueues.Load(id); running {
+		return ErrQueueRunning
+	}
+	job, items, lineErrors, err := LoadQueue(id)
+	if err != nil {
+		return err
+	}
+	if len(lineErrors) > 0 {
+		// Saving would silently drop them
+		return errors.New("the queue file has invalid lines - fix or remove them first")
+	}
+	return SaveQueue(job, edit(job, items))
+}
+
+// moveSelected moves the selected items as a block in front of position to, keeping all other items in order
+func moveSelected(items []MediaItem, to int, selected func(i int, item MediaItem) bool) []MediaItem {
+	var moved, before, after []MediaItem
+	for i, item := range items {
+		switch {
+		case selected(i, item):
+			moved = append(moved, item)
+		case i < to:
+			before = append(before, item)
+		default:
+			after = append(after, item)
+		}
+	}
+	result := make([]MediaItem, 0, len(items))
+	result = append(result, before...)
+	result = append(result, moved...)
+	return append(result, after...)
+}
+
+// positionSet returns the valid positions as a set
+func positionSet(positions []int, n int) map[int]bool {
+	set := make(map[int]bool, len(positions))
+	for _, p := range positions {
+		if p >= 0 && p < n {
+			set[p] = true
+		}
+	}
+	return set
+}
+
+// queueItemAuthor returns the author of a queued item (bookmarks and likes mix authors)
+func queueItemAuthor(job QueueJob, item MediaItem) string {
+	if item.Username != "" {
+		return item.Username
+	}
+	return job.Username
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
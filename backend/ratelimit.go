@@ -0,0 +1,103 @@
+package backend
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Rate limits
+//
+// When the API rate limit is hit, X sends the time the limit resets (x-rate-limit-reset,
+// Retry-After). The extractor reports it on stderr, so instead of a generic "wait a while"
+// hint the error carries a concrete retry time: readable for the user ("retry at 15:04")
+// and as a retry_at=<RFC 3339> token for the UI and automatic retries.
+
+// maxRateLimitWait is the longest automatic retries wait for a rate limit to reset
+const maxRateLimitWait = 20 * time.Minute
+
+var (
+	// rateLimitResetPattern matches "x-rate-limit-reset: 1712345678" and the extractor's "Rate limit reset: 1712345678" (unix seconds)
+	rateLimitResetPattern = regexp.MustCompile(`(?i)rate-?\s?limit-?\s?reset['"]?\s*[:=]\s*['"]?(\d{9,11})`)
+	// retryAfterPattern matches "Retry-After: 120" (seconds)
+	retryAfterPattern = regexp.MustCompile(`(?i)retry-after['"]?\s*[:=]\s*['"]?(\d{1,6})\b`)
+	// waitingUntilPattern matches gallery-dl's "Waiting until 15:04:05 (rate limit)" (local time)
+	waitingUntilPattern = regexp.MustCompile(`(?i)waiting until (\d{1,2}):(\d{2}):(\d{2})`)
+	// retryAtTokenPattern matches the retry time embedded in extractor error messages
+	retryAtTokenPattern = regexp.MustCompile(`retry_at=([0-9T:+\-Z]+)`)
+)
+
+// ParseRetryAt returns when the rate limit reported in the extractor output resets
+// The last reported limit wins, since earlier ones may have been waited out already.
+// Returns the zero time if no limit is reported or it has already reset
+func ParseRetryAt(output string, now time.Time) time.Time {
+	lines := strings.Split(output, "\n")
+	for i := len(lines) - 1; i >= 0; i-- {
+		retryAt := parseRetryAtLine(lines[i], now)
+		if retryAt.IsZero() {
+			continue
+		}
+		if !retryAt.After(now) {
+			return time.Time{}
+		}
+		return retryAt
+	}
+	return time.Time{}
+}
+
+// parseRetryAtLine returns the reset time reported in a single output line, or the zero time
+func parseRetryAtLine(line string, now time.Time) time.Time {
+	if m := rateLimitResetPattern.FindStringSubmatch(line); m != nil {
+		unix, _ := strconv.ParseInt(m[1], 10, 64)
+		return time.Unix(unix, 0)
+	}
+	if m := retryAfterPattern.FindStringSubmatch(line); m != nil {
+		secs, _ := strconv.Atoi(m[1])
+		return now.Add(time.Duration(secs) * time.Second)
+	}
+	if m := waitingUntilPattern.FindStringSubmatch(line); m != nil {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		second, _ := strconv.Atoi(m[3])
+		local := now.Local()
+		t := time.Date(local.Year(), local.Month(), local.Day(), hour, minute, second, 0, time.Local)
+		// Only a time of day is logged - a time far in the past is tomorrow
+		if local.Sub(t) > 12*time.Hour {
+			t = t.AddDate(0, 0, 1)
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// rateLimitHint returns the error hint for a rate limit resetting at retryAt
+func rateLimitHint(retryAt time.Time) string {
+	return hintText(HintRateLimitedUntil, "time", retryAt.Local().Format("15:04")) + " [retry_at=" + retryAt.UTC().Format(time.RFC3339) + "]"
+}
+
+// RetryAtFromMessage returns the retry time embedded in an extractor error message, or the zero time
+func RetryAtFromMessage(msg string) time.Time {
+	m := retryAtTokenPattern.FindStringSubmatch(msg)
+	if m == nil {
+		return time.Time{}
+	}
+	retryAt, err := time.Parse(time.RFC3339, m[1])
+	if err != nil {
+		return time.Time{}
+	}
+	return retryAt
+}
+
+// rateLimitWait returns how long to wait before retrying a rate-limited request
+// ok is false if there's no known reset time or it's too far away to wait for
+func rateLimitWait(retryAt time.Time) (wait time.Duration, ok bool) {
+	if retryAt.IsZero() {
+		return 0, false
+	}
+	wait = time.Until(retryAt) + 5*time.Second // Small margin for clock skew
+	if wait <= 0 || wait > maxRateLimitWait {
+		return 0, false
+	}
+	return wait, true
+}
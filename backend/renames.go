@@ -0,0 +1,305 @@
+package backend
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Username changes
+//
+// Accounts are saved and archived under their username, which the owner can change at any time.
+// The numeric user ID never changes, so it's saved with every fetch: when an account is saved
+// under a new name with the ID of an account saved before, or a sync finds the old name gone and
+// the ID resolves to a new one, the saved account is renamed instead of starting a second archive.
+// Its cursor and timeline carry over, so incremental syncs continue where they were, and pending
+// queues follow it. Depending on the settings, the archive folder and the files named after the
+// account (file name templates starting with {username}) are renamed too, optionally leaving a
+// link with the old name for other tools; or the folder is kept as it is.
+
+// What happens to the archive folder of a renamed account, see Settings.RenamedFolders
+const (
+	RenamedFoldersRename  = "rename"  // Rename the folder and its files to the new username
+	RenamedFoldersSymlink = "symlink" // Rename them and leave a link with the old name
+	RenamedFoldersKeep    = "keep"    // Leave the folder as it is
+)
+
+// AccountRename is a username change of a saved account
+type AccountRename struct {
+	UserID       string `json:"user_id"`
+	OldUsername  string `json:"old_username"`
+	NewUsername  string `json:"new_username"`
+	ArchivePath  string `json:"archive_path,omitempty"` // Archive folder after the rename, "" if unknown
+	RenamedFiles int    `json:"renamed_files"`
+	RenamedAt    string `json:"renamed_at"`
+}
+
+// formatUserID returns a user ID of the extractor as a string, "" if it's missing
+func formatUserID(id int64) string {
+	if id <= 0 {
+		return ""
+	}
+	return strconv.FormatInt(id, 10)
+}
+
+// responseUserID returns the user ID of a saved response, "" if it has none
+func responseUserID(responseJSON string) string {
+	var parsed struct {
+		AccountInfo struct {
+			ID string `json:"id"`
+		} `json:"account_info"`
+	}
+	if json.Unmarshal([]byte(responseJSON), &parsed) != nil {
+		return ""
+	}
+	return parsed.AccountInfo.ID
+}
+
+// storedUserID returns the user ID saved with an account, "" if it isn't known
+func storedUserID(username string) string {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return ""
+		}
+	}
+	var userID string
+	db.QueryRow(`SELECT COALESCE(user_id, '') FROM accounts WHERE LOWER(username) = LOWER(?) AND COALESCE(user_id, '') != '' LIMIT 1`,
+		username).Scan(&userID)
+	return userID
+}
+
+// followRename renames the saved account with a user ID to username, if it's saved under another name
+func followRename(userID, username string) (*AccountRename, error) {
+	var oldName string
+	err := db.QueryRow(`SELECT username FROM accounts WHERE user_id = ? AND LOWER(username) != LOWER(?) ORDER BY last_fetched DESC LIMIT 1`,
+		userID, username).Scan(&oldName)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user %s: %v", userID, err)
+	}
+	return RenameAccount(oldName, username)
+}
+
+// ResolveRename looks a saved account up by its user ID and follows a username change
+// Returns nil if the account still has its name or its user ID isn't known
+func ResolveRename(username, authToken string) (*AccountRename, error) {
+	userID := storedUserID(username)
+	if userID == "" {
+		return nil, nil
+	}
+	// The extractor takes id:<user ID> in place of a username
+	response, err := ExtractTimeline(TimelineRequest{Username: "id:" + userID, AuthToken: authToken, TimelineType: "timeline", BatchSize: 1})
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up user %s: %v", userID, err)
+	}
+	current := response.AccountInfo.Name
+	if current == "" || strings.HasPrefix(current, "id:") || strings.EqualFold(current, username) {
+		return nil, nil
+	}
+	if response.AccountInfo.ID != "" && response.AccountInfo.ID != userID {
+		return nil, fmt.Errorf("user %s resolved to another account (%s)", userID, response.AccountInfo.ID)
+	}
+	return RenameAccount(username, current)
+}
+
+// RenameAccount moves a saved account to a new username: its saved timelines, pending queues and,
+// as the settings say, its archive folder
+func RenameAccount(oldName, newName string) (*AccountRename, error) {
+	oldName, newName = cleanUsername(oldName), cleanUsername(newName)
+	for _, name := range []string{oldName, newName} {
+		if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+			return nil, fmt.Errorf("invalid username: %s", name)
+		}
+	}
+	if oldName == newName {
+		return nil, nil
+	}
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return nil, err
+		}
+	}
+
+	rename := &AccountRename{OldUsername: oldName, NewUsername: newName, RenamedAt: time.Now().Format(time.RFC3339)}
+	var archivePath string
+	err := db.QueryRow(`SELECT COALESCE(user_id, ''), COALESCE(archive_path, '') FROM accounts WHERE LOWER(username) = LOWER(?) ORDER BY archive_path DESC LIMIT 1`,
+		oldName).Scan(&rename.UserID, &archivePath)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("account @%s is not saved", oldName)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename account: %v", err)
+	}
+
+	if archivePath != "" {
+		newPath, files, err := remapArchiveFolder(archivePath, oldName, newName, GetSettings().RenamedFolders)
+		if err != nil {
+			fmt.Printf("Warning: archive of @%s not renamed: %v\n", oldName, err)
+			newPath = archivePath
+		}
+		rename.ArchivePath, rename.RenamedFiles = newPath, files
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to rename account: %v", err)
+	}
+	// Media types already saved under the new name keep their own rows
+	if _, err := tx.Exec(`
+		UPDATE accounts SET username = ?, archive_path = CASE WHEN ? != '' THEN ? ELSE archive_path END
+		WHERE LOWER(username) = LOWER(?)
+		  AND media_type NOT IN (SELECT media_type FROM accounts WHERE LOWER(username) = LOWER(?))
+	`, newName, rename.ArchivePath, rename.ArchivePath, oldName, newName); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to rename account: %v", err)
+	}
+	if _, err := tx.Exec(`
+		INSERT INTO account_renames (user_id, old_username, new_username, archive_path, renamed_files, renamed_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, rename.UserID, oldName, newName, rename.ArchivePath, rename.RenamedFiles, rename.RenamedAt); err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("failed to rename account: %v", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to rename account: %v", err)
+	}
+	db.Exec("UPDATE account_watch SET username = LOWER(?) WHERE username = LOWER(?)", newName, oldName)
+	db.Exec("UPDATE media_labels SET username = ? WHERE LOWER(username) = LOWER(?)", newName, oldName)
+	db.Exec("UPDATE media_labels SET author = ? WHERE LOWER(author) = LOWER(?)", newName, oldName)
+	renameInCollections(oldName, newName)
+
+	renameQueues(oldName, newName)
+	fmt.Printf("Account @%s was renamed to @%s\n", oldName, newName)
+	return rename, nil
+}
+
+// renameQueues points the pending queues of an account at its new username
+func renameQueues(oldName, newName string) {
+	jobs, _ := ListQueues()
+	for _, job := range jobs {
+		if !strings.EqualFold(job.Username, oldName) {
+			continue
+		}
+		job, items, _, err := LoadQueue(job.ID)
+		if err != nil {
+			continue
+		}
+		job.Username = newName
+		for i := range items {
+			if strings.EqualFold(items[i].Username, oldName) {
+				items[i].Username = newName
+			}
+		}
+		if err := SaveQueue(job, items); err != nil {
+			fmt.Printf("Warning: failed to update queue %s: %v\n", job.ID, err)
+		}
+	}
+}
+
+// remapArchiveFolder renames the archive folder of a renamed account and the files named after it
+// Returns the folder's new path and the number of renamed files
+func remapArchiveFolder(archivePath, oldName, newName, mode string) (string, int, error) {
+	if mode == RenamedFoldersKeep {
+		return archivePath, 0, nil
+	}
+	if info, err := os.Stat(archivePath); err != nil || !info.IsDir() {
+		return archivePath, 0, nil // Nothing downloaded yet
+	}
+	// Folders that aren't named after the account were placed by the user
+	if !strings.EqualFold(filepath.Base(archivePath), oldName) {
+		return archivePath, 0, nil
+	}
+	newPath := filepath.Join(filepath.Dir(archivePath), newName)
+	caseOnly := strings.EqualFold(oldName, newName)
+	if _, err := os.Lstat(newPath); err == nil && !caseOnly {
+		return archivePath, 0, fmt.Errorf("folder %s already exists", newPath)
+	}
+	if err := os.Rename(archivePath, newPath); err != nil {
+		return archivePath, 0, fmt.Errorf("failed to rename folder: %v", err)
+	}
+	files := renameArchiveFiles(newPath, oldName, newName)
+	if mode == RenamedFoldersSymlink && !caseOnly {
+		if err := os.Symlink(newPath, archivePath); err != nil {
+			fmt.Printf("Warning: failed to link %s to %s: %v\n", archivePath, newPath, err)
+		}
+	}
+	return newPath, files, nil
+}
+
+// renameArchiveFiles renames the files of an archive that start with the old username
+func renameArchiveFiles(dir, oldName, newName string) int {
+	oldPrefix := usernameFilePrefix(GetSettings().FilenameTemplate, oldName)
+	newPrefix := usernameFilePrefix(GetSettings().FilenameTemplate, newName)
+	if oldPrefix == "" {
+		return 0
+	}
+	renamed := 0
+	filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() || !strings.HasPrefix(d.Name(), oldPrefix) {
+			return nil
+		}
+		target := filepath.Join(filepath.Dir(path), newPrefix+strings.TrimPrefix(d.Name(), oldPrefix))
+		if _, err := os.Lstat(target); err == nil {
+			return nil
+		}
+		if os.Rename(path, target) == nil {
+			renamed++
+		}
+		return nil
+	})
+	return renamed
+}
+
+// usernameFilePrefix returns how file names of a template start for a username, "" if the
+// template doesn't start with the username and a separator
+func usernameFilePrefix(template, username string) string {
+	if strings.TrimSpace(template) == "" {
+		template = DefaultFilenameTemplate
+	}
+	rest, ok := strings.CutPrefix(template, "{username}")
+	if !ok {
+		return ""
+	}
+	if i := strings.Index(rest, "{"); i >= 0 {
+		rest = rest[:i]
+	}
+	if rest == "" {
+		return "" // The username runs into the next field
+	}
+	return replaceInvalidChars(username + rest)
+}
+
+// GetAccountRenames returns the username changes of saved accounts, newest first
+func GetAccountRenames() ([]AccountRename, error) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return nil, err
+		}
+	}
+	rows, err := db.Query(`
+		SELECT user_id, old_username, new_username, COALESCE(archive_path, ''), COALESCE(renamed_files, 0), renamed_at
+		FROM account_renames ORDER BY id DESC
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list renames: %v", err)
+	}
+	defer rows.Close()
+
+	renames := make([]AccountRename, 0)
+	for rows.Next() {
+		var r AccountRename
+		if err := rows.Scan(&r.UserID, &r.OldUsername, &r.NewUsername, &r.ArchivePath, &r.RenamedFiles, &r.RenamedAt); err != nil {
+			continue
+		}
+		renames = append(renames, r)
+	}
+	return renames, nil
+}
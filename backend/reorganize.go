@@ -0,0 +1,358 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReorganizeArchive renames the saved files of an account from one file name template to another.
+// The moves are journaled first, so UndoReorganize can rename them back after an interrupted run.
+
+// reorganizeTempSuffix marks a file between the two steps of a rename
+const reorganizeTempSuffix = ".reorg"
+
+// ReorganizeRequest describes how to rename the files of an account's archive
+type ReorganizeRequest struct {
+	Username     string `json:"username"`
+	OutputDir    string `json:"output_dir"`     // Folder holding the account folder, "" = where it was last downloaded to
+	FromTemplate string `json:"from_template"`  // Template the files were saved with, "" = the settings' template
+	ToTemplate   string `json:"to_template"`    // New template
+	FromDateZone string `json:"from_date_zone"` // Time zone of timestamps in the old names, see DownloadOptions.DateZone
+	ToDateZone   string `json:"to_date_zone"`   // Time zone of timestamps in the new names
+	DryRun       bool   `json:"dry_run"`
+}
+
+// ReorganizeMove is one renamed file
+type ReorganizeMove struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// ReorganizeResult is the plan or the outcome of a reorganization
+type ReorganizeResult struct {
+	JournalID string           `json:"journal_id,omitempty"` // For UndoReorganize, "" for dry runs
+	Moves     []ReorganizeMove `json:"moves"`                // Planned or done renames
+	Conflicts []ReorganizeMove `json:"conflicts"`            // Renames skipped because the new name is taken
+	Unchanged int              `json:"unchanged"`            // Files whose name stays the same
+	NotFound  int              `json:"not_found"`            // Media of the stored timeline without a file under the old name
+	Failed    int              `json:"failed"`
+}
+
+// ReorganizeJournal records the renames of a reorganization for undo
+type ReorganizeJournal struct {
+	ID           string           `json:"id"`
+	Username     string           `json:"username"`
+	FromTemplate string           `json:"from_template"`
+	ToTemplate   string           `json:"to_template"`
+	CreatedAt    time.Time        `json:"created_at"`
+	UndoneAt     *time.Time       `json:"undone_at,omitempty"`
+	Moves        []ReorganizeMove `json:"moves"`
+}
+
+// GetReorganizeDir returns the directory holding reorganization journals
+func GetReorganizeDir() string {
+	return filepath.Join(GetAppDataDir(), "reorganize")
+}
+
+// ReorganizeArchive renames the files of an account's archive to a new file name template
+func ReorganizeArchive(req ReorganizeRequest) (*ReorganizeResult, error) {
+	username := cleanUsername(req.Username)
+	if username == "" {
+		return nil, fmt.Errorf("username is required")
+	}
+	if strings.TrimSpace(req.ToTemplate) == "" {
+		return nil, fmt.Errorf("new file name template is required")
+	}
+	items, outputDir, err := storedArchiveItems(username, req.OutputDir)
+	if err != nil {
+		return nil, err
+	}
+
+	// The template only changes names, so both plans list the same items in the same order
+	fromTasks, _ := planDownloadTasks(items, outputDir, username, DownloadOptions{FilenameTemplate: req.FromTemplate, DateZone: req.FromDateZone})
+	toTasks, _ := planDownloadTasks(items, outputDir, username, DownloadOptions{FilenameTemplate: req.ToTemplate, DateZone: req.ToDateZone})
+	result := &ReorganizeResult{Moves: []ReorganizeMove{}, Conflicts: []ReorganizeMove{}}
+	var moves []ReorganizeMove
+	for i := range fromTasks {
+		from := existingMediaFile(fromTasks[i].outputPath)
+		if from == "" {
+			result.NotFound++
+			continue
+		}
+		// Converted files keep their format
+		to := strings.TrimSuffix(toTasks[i].outputPath, filepath.Ext(toTasks[i].outputPath)) + filepath.Ext(from)
+		if from == to {
+			result.Unchanged++
+			continue
+		}
+		moves = append(moves, ReorganizeMove{From: from, To: to})
+	}
+	result.Moves, result.Conflicts = resolveReorganizeConflicts(moves)
+	if req.DryRun || len(result.Moves) == 0 {
+		return result, nil
+	}
+
+	journal := ReorganizeJournal{
+		ID:           NewQueueID(username),
+		Username:     username,
+		FromTemplate: req.FromTemplate,
+		ToTemplate:   req.ToTemplate,
+		CreatedAt:    time.Now(),
+		Moves:        result.Moves,
+	}
+	if err := saveReorganizeJournal(journal); err != nil {
+		return nil, err
+	}
+	result.JournalID = journal.ID
+	done, failed := applyReorganizeMoves(result.Moves)
+	result.Moves, result.Failed = done, failed
+	return result, nil
+}
+
+// storedArchiveItems returns the media of an account's stored timelines and its output folder
+func storedArchiveItems(username, outputDir string) ([]MediaItem, string, error) {
+	accounts, err := GetAllAccounts()
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to list accounts: %v", err)
+	}
+	var responses []*TwitterResponse
+	archivePath := ""
+	for _, acc := range accounts {
+		if !strings.EqualFold(acc.Username, username) {
+			continue
+		}
+		stored, err := GetAccountByID(acc.ID)
+		if err != nil {
+			return nil, "", err
+		}
+		var response TwitterResponse
+		if err := json.Unmarshal([]byte(stored.ResponseJSON), &response); err != nil {
+			return nil, "", fmt.Errorf("failed to parse stored timeline: %v", err)
+		}
+		responses = append(responses, &response)
+		if acc.ArchivePath != "" {
+			archivePath = acc.ArchivePath
+		}
+	}
+	if len(responses) == 0 {
+		return nil, "", fmt.Errorf("@%s is not saved, its stored timeline is needed to rename its files", username)
+	}
+	if outputDir == "" {
+		if archivePath == "" {
+			return nil, "", fmt.Errorf("archive folder of @%s is unknown", username)
+		}
+		outputDir = filepath.Dir(archivePath)
+	}
+	merged := mergeResponses(username, responses)
+	return timelineMediaItems(merged.Timeline, username), outputDir, nil
+}
+
+// existingMediaFile returns the file saved for a planned path, with any extension, "" if there's none
+func existingMediaFile(path string) string {
+	if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+		return path
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	matches, _ := filepath.Glob(escapeGlob(base) + ".*")
+	for _, match := range matches {
+		ext := filepath.Ext(match)
+		if strings.TrimSuffix(match, ext) != base || ext == ".part" || ext == ".tmp" || ext == reorganizeTempSuffix {
+			continue
+		}
+		if info, err := os.Stat(match); err == nil && info.Mode().IsRegular() {
+			return match
+		}
+	}
+	return ""
+}
+
+// escapeGlob escapes the pattern characters of a path for filepath.Glob
+func escapeGlob(path string) string {
+	return strings.NewReplacer("[", "[[]", "*", "[*]", "?", "[?]").Replace(path)
+}
+
+// resolveReorganizeConflicts splits moves into safe ones and ones whose target is taken: by a file
+// that isn't moving away, or by an earlier move
+func resolveReorganizeConflicts(moves []ReorganizeMove) (safe, conflicts []ReorganizeMove) {
+	targets := make(map[string]bool)
+	for _, move := range moves {
+		if targets[move.To] {
+			conflicts = append(conflicts, move)
+			continue
+		}
+		targets[move.To] = true
+		safe = append(safe, move)
+	}
+
+	// Skipping a move keeps its file in place, which can block another move
+	for changed := true; changed; {
+		changed = false
+		moving := make(map[string]bool, len(safe))
+		for _, move := range safe {
+			moving[move.From] = true
+		}
+		kept := safe[:0]
+		for _, move := range safe {
+			_, err := os.Lstat(move.To)
+			if err == nil && !moving[move.To] && !strings.EqualFold(move.To, move.From) {
+				conflicts = append(conflicts, move)
+				changed = true
+				continue
+			}
+			kept = append(kept, move)
+		}
+		safe = kept
+	}
+	if safe == nil {
+		safe = []ReorganizeMove{}
+	}
+	if conflicts == nil {
+		conflicts = []ReorganizeMove{}
+	}
+	return safe, conflicts
+}
+
+// applyReorganizeMoves renames files in two steps, so moves can swap names: every file is moved to
+// a temporary name first, then to its target. Returns the moves that were done
+func applyReorganizeMoves(moves []ReorganizeMove) ([]ReorganizeMove, int) {
+	failed := 0
+	staged := make([]ReorganizeMove, 0, len(moves))
+	for _, move := range moves {
+		if err := os.Rename(move.From, move.From+reorganizeTempSuffix); err != nil {
+			fmt.Printf("Warning: failed to rename %s: %v\n", move.From, err)
+			failed++
+			continue
+		}
+		staged = append(staged, move)
+	}
+	done := make([]ReorganizeMove, 0, len(staged))
+	for _, move := range staged {
+		err := os.MkdirAll(filepath.Dir(move.To), 0755)
+		if err == nil {
+			err = os.Rename(move.From+reorganizeTempSuffix, move.To)
+		}
+		if err != nil {
+			fmt.Printf("Warning: failed to rename %s: %v\n", move.From, err)
+			os.Rename(move.From+reorganizeTempSuffix, move.From)
+			failed++
+			continue
+		}
+		done = append(done, move)
+	}
+	return done, failed
+}
+
+// UndoReorganize renames the files of a reorganization back
+// Works on interrupted runs too: every file is looked for under its new and its temporary name
+func UndoReorganize(journalID string) (*ReorganizeResult, error) {
+	journal, err := loadReorganizeJournal(journalID)
+	if err != nil {
+		return nil, err
+	}
+	if journal.UndoneAt != nil {
+		return nil, fmt.Errorf("reorganization %s was undone already", journalID)
+	}
+
+	var back []ReorganizeMove
+	result := &ReorganizeResult{JournalID: journal.ID, Moves: []ReorganizeMove{}, Conflicts: []ReorganizeMove{}}
+	for _, move := range journal.Moves {
+		if _, err := os.Lstat(move.From + reorganizeTempSuffix); err == nil {
+			if err := os.Rename(move.From+reorganizeTempSuffix, move.From); err != nil {
+				result.Failed++
+			} else {
+				result.Moves = append(result.Moves, ReorganizeMove{From: move.From + reorganizeTempSuffix, To: move.From})
+			}
+			continue
+		}
+		if _, err := os.Lstat(move.To); err != nil {
+			result.NotFound++ // Never renamed, or removed since
+			continue
+		}
+		back = append(back, ReorganizeMove{From: move.To, To: move.From})
+	}
+	safe, conflicts := resolveReorganizeConflicts(back)
+	done, failed := applyReorganizeMoves(safe)
+	result.Moves = append(result.Moves, done...)
+	result.Conflicts = conflicts
+	result.Failed += failed
+
+	now := time.Now()
+	journal.UndoneAt = &now
+	if err := saveReorganizeJournal(journal); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// ListReorganizeJournals returns the reorganization journals, newest first
+func ListReorganizeJournals() ([]ReorganizeJournal, error) {
+	matches, err := filepath.Glob(filepath.Join(GetReorganizeDir(), "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	journals := make([]ReorganizeJournal, 0, len(matches))
+	for _, path := range matches {
+		journal, err := loadReorganizeJournal(strings.TrimSuffix(filepath.Base(path), ".json"))
+		if err != nil {
+			continue
+		}
+		journals = append(journals, journal)
+	}
+	sort.Slice(journals, func(i, j int) bool {
+		return journals[i].CreatedAt.After(journals[j].CreatedAt)
+	})
+	return journals, nil
+}
+
+// reorganizeJournalPath returns the path of a journal
+func reorganizeJournalPath(id string) (string, error) {
+	if !queueIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid journal id: %s", id)
+	}
+	return filepath.Join(GetReorganizeDir(), id+".json"), nil
+}
+
+// saveReorganizeJournal writes a journal
+func saveReorganizeJournal(journal ReorganizeJournal) error {
+	path, err := reorganizeJournalPath(journal.ID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(GetReorganizeDir(), 0755); err != nil {
+		return fmt.Errorf("failed to create journal folder: %v", err)
+	}
+	data, err := json.MarshalIndent(journal, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return fmt.Errorf("failed to write journal: %v", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return fmt.Errorf("failed to write journal: %v", err)
+	}
+	return nil
+}
+
+// loadReorganizeJournal reads a journal
+func loadReorganizeJournal(id string) (ReorganizeJournal, error) {
+	var journal ReorganizeJournal
+	path, err := reorganizeJournalPath(id)
+	if err != nil {
+		return journal, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return journal, fmt.Errorf("journal %s not found", id)
+	}
+	if err := json.Unmarshal(data, &journal); err != nil {
+		return journal, fmt.Errorf("invalid journal %s: %v", id, err)
+	}
+	return journal, nil
+}
@@ -0,0 +1,89 @@
+package backend
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Age-restricted media
+//
+// Tweets marked as sensitive still come back in the metadata the extractor returns, but for
+// sessions that can't see sensitive media (guests, accounts that aren't age-verified) they come
+// without their media, so they used to vanish from the timeline without a trace. A fetch now
+// counts sensitive tweets that returned no media and lists their IDs. If the request names a
+// second auth token of an account that can see them, each of those tweets is fetched again with
+// it and the media it returns join the timeline; whatever is still hidden is reported.
+
+// maxRestrictedRetries caps the tweets fetched again with the restricted auth token per fetch
+const maxRestrictedRetries = 200
+
+// restricted returns the sensitive tweets that came back without media
+func (c *timelineCollector) restricted() []TweetMetadata {
+	var restricted []TweetMetadata
+	seen := make(map[int64]bool)
+	for _, meta := range c.sensitive {
+		id := int64(meta.TweetID)
+		if c.mediaTweetIDs[id] > 0 || seen[id] {
+			continue
+		}
+		seen[id] = true
+		restricted = append(restricted, meta)
+	}
+	return restricted
+}
+
+// matchMediaType reports whether a media item matches a fetch media type (all, image, video, gif)
+func matchMediaType(mediaType string, media CLIMediaItem) bool {
+	mediaKind := strings.ToLower(media.Type)
+	switch strings.ToLower(strings.TrimSpace(mediaType)) {
+	case "image", "images", "photo", "photos":
+		return mediaKind == "photo"
+	case "video", "videos":
+		return mediaKind == "video"
+	case "gif", "gifs":
+		return mediaKind == "gif" || mediaKind == "animated_gif"
+	default:
+		return true
+	}
+}
+
+// applyRestricted reports the restricted tweets of a fetch and, with authToken set, adds the media
+// that token can see to the response
+func applyRestricted(response *TwitterResponse, restricted []TweetMetadata, authToken, fetchToken, mediaType string, filter TimelineFilter, dateZone string) {
+	response.Restricted = len(restricted)
+	if len(restricted) == 0 {
+		return
+	}
+
+	var missing []string
+	retry := authToken != "" && authToken != fetchToken
+	for i, meta := range restricted {
+		id := int64(meta.TweetID)
+		if !retry || i >= maxRestrictedRetries {
+			missing = append(missing, strconv.FormatInt(id, 10))
+			continue
+		}
+		media, err := ExtractTweetMedia(id, authToken)
+		if err != nil && !RetryAtFromMessage(err.Error()).IsZero() {
+			// Rate limited, leave the rest for the next fetch
+			retry = false
+		}
+		var entries []TimelineEntry
+		for _, item := range media {
+			if matchMediaType(mediaType, item) && filter.MatchMedia(item) {
+				entries = append(entries, convertToTimelineEntry(item))
+			}
+		}
+		if err != nil || len(media) == 0 {
+			missing = append(missing, strconv.FormatInt(id, 10))
+			continue
+		}
+		NormalizeTimelineDates(entries, dateZone)
+		response.Timeline = append(response.Timeline, entries...)
+		response.RestrictedRecovered++
+	}
+
+	response.RestrictedTweetIDs = missing
+	response.TotalURLs = len(response.Timeline)
+	response.Metadata.NewEntries = len(response.Timeline)
+}
@@ -0,0 +1,149 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// Resumable downloads
+//
+// Downloads to the local filesystem and SFTP go through a .part file next to the target, which is
+// only renamed once the file is complete. When a download is interrupted (connection lost, job
+// stopped or paused with abort, app closed) the .part file stays, and the next attempt asks for
+// the rest with a range request instead of fetching a large video from zero. The ETag of the
+// first response is kept in a .part.etag file and sent as If-Range, so a file that changed on the
+// server in the meantime is downloaded again whole instead of being stitched from two versions.
+// The finished file must have the size the server announced, otherwise it's kept as a .part file
+// to be continued and the download fails.
+
+// partETagSuffix is added to the .part file name for the ETag of the partial download
+const partETagSuffix = ".etag"
+
+// resumeDownload downloads a file through a .part file, continuing where an interrupted download stopped
+// The part file is kept on failure so the next attempt only fetches the rest
+func resumeDownload(ctx context.Context, client *http.Client, store ResumableStorage, url, outputPath string, retry bool) error {
+	partPath := outputPath + ".part"
+	etagPath := partPath + partETagSuffix
+	out, offset, err := store.Append(partPath)
+	if err != nil {
+		return err
+	}
+	// discard removes a part file that holds nothing worth resuming
+	discard := func() {
+		out.Close()
+		store.Remove(partPath)
+		store.Remove(etagPath)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		out.Close()
+		return err
+	}
+	etag := ""
+	if offset > 0 {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		if etag = readPartETag(store, etagPath); etag != "" {
+			// The server sends the whole file if it changed since the part was written
+			req.Header.Set("If-Range", etag)
+		}
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		if offset == 0 {
+			discard()
+		} else {
+			out.Close()
+		}
+		return err
+	}
+	defer resp.Body.Close()
+
+	expected := int64(-1) // Size of the finished file, -1 if the server didn't say
+	switch {
+	case resp.StatusCode == http.StatusPartialContent && offset > 0:
+		if current := resp.Header.Get("ETag"); etag != "" && current != "" && current != etag && retry {
+			// Not the file the part was written from - start over
+			discard()
+			return resumeDownload(ctx, client, store, url, outputPath, false)
+		}
+		if total := contentRangeTotal(resp.Header.Get("Content-Range")); total > 0 {
+			expected = total
+		}
+	case resp.StatusCode == http.StatusOK:
+		if offset > 0 {
+			// The server ignored the range or the file changed - start over
+			out.Close()
+			if out, err = store.Create(partPath); err != nil {
+				return err
+			}
+			offset = 0
+		}
+		store.Remove(etagPath)
+		if current := resp.Header.Get("ETag"); isStrongETag(current) {
+			writeStorageFile(store, etagPath, strings.NewReader(current))
+		}
+		expected = resp.ContentLength
+	case resp.StatusCode == http.StatusRequestedRangeNotSatisfiable && retry:
+		// The part file doesn't match the file anymore - start over
+		discard()
+		return resumeDownload(ctx, client, store, url, outputPath, false)
+	default:
+		if offset == 0 {
+			discard()
+		} else {
+			out.Close()
+		}
+		return &HTTPStatusError{Code: resp.StatusCode, Status: resp.Status}
+	}
+
+	if _, err := io.Copy(out, resp.Body); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Close(); err != nil {
+		return err
+	}
+	if expected >= 0 {
+		info, err := store.Stat(partPath)
+		if err != nil {
+			return err
+		}
+		if info.Size() != expected {
+			if info.Size() > expected {
+				store.Remove(partPath)
+				store.Remove(etagPath)
+			}
+			return fmt.Errorf("incomplete download: %d of %d bytes", info.Size(), expected)
+		}
+	}
+	store.Remove(etagPath)
+	if err := store.Rename(partPath, outputPath); err != nil {
+		return err
+	}
+	rememberValidators(url, resp.Header)
+	return nil
+}
+
+// readPartETag returns the ETag a part file was written from, "" if unknown
+func readPartETag(store Storage, etagPath string) string {
+	r, err := store.Open(etagPath)
+	if err != nil {
+		return ""
+	}
+	defer r.Close()
+	data, err := io.ReadAll(io.LimitReader(r, 1024))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(data))
+}
+
+// isStrongETag reports whether an ETag can be used with If-Range (weak ones can't)
+func isStrongETag(etag string) bool {
+	return strings.HasPrefix(etag, `"`) && len(etag) > 2
+}
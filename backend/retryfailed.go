@@ -0,0 +1,194 @@
+package backend
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Failed items
+//
+// When a download job ends with failed files, the failed items are saved with the job's settings
+// as <app dir>/failed/<job id>.json, so RetryFailed can download just those again instead of
+// re-running the whole job. Every failure records why it failed: files the server refused (403
+// and other 4xx responses) are permanent and left out of retries, server errors (5xx), rate limits
+// (429), timeouts, broken connections and files that failed validation are retried. Dead links
+// (404) aren't failures, they're listed in missing.csv (see missing.go). A retry runs under the same job ID, so its failures replace the retried entries and the
+// file is removed once nothing has failed.
+
+// FailedItem is a media item that failed to download
+type FailedItem struct {
+	Item      MediaItem `json:"item"`
+	Error     string    `json:"error"`
+	Status    int       `json:"status,omitempty"` // HTTP status, 0 if the request didn't get a response
+	Retryable bool      `json:"retryable"`
+}
+
+// FailedJob holds the failed items of a download job
+type FailedJob struct {
+	Job        QueueJob     `json:"job"`
+	Items      []FailedItem `json:"items"`
+	FinishedAt time.Time    `json:"finished_at"`
+}
+
+// HTTPStatusError is a download answered with an unexpected HTTP status
+type HTTPStatusError struct {
+	Code   int
+	Status string
+}
+
+func (e *HTTPStatusError) Error() string {
+	return "bad status: " + e.Status
+}
+
+// GetFailedDir returns the directory holding the failed items of download jobs
+func GetFailedDir() string {
+	return filepath.Join(GetAppDataDir(), "failed")
+}
+
+// getFailedPath returns the path of a job's failed items
+func getFailedPath(id string) (string, error) {
+	if !queueIDPattern.MatchString(id) {
+		return "", fmt.Errorf("invalid job id: %s", id)
+	}
+	return filepath.Join(GetFailedDir(), id+".json"), nil
+}
+
+// isRetryableFailure reports whether a failed download may succeed when tried again
+func isRetryableFailure(err error) (status int, retryable bool) {
+	var statusErr *HTTPStatusError
+	if !errors.As(err, &statusErr) {
+		return 0, true // Timeouts, connection errors, invalid files
+	}
+	switch {
+	case statusErr.Code == http.StatusRequestTimeout, statusErr.Code == http.StatusTooManyRequests:
+		return statusErr.Code, true
+	case statusErr.Code >= 400 && statusErr.Code < 500:
+		return statusErr.Code, false
+	}
+	return statusErr.Code, true
+}
+
+// failureLog collects the failures of a running job
+type failureLog struct {
+	mu    sync.Mutex
+	items []FailedItem
+}
+
+// add records a failed item
+func (l *failureLog) add(item MediaItem, err error) {
+	if err == nil {
+		err = errors.New("download failed")
+	}
+	status, retryable := isRetryableFailure(err)
+	l.mu.Lock()
+	l.items = append(l.items, FailedItem{Item: item, Error: err.Error(), Status: status, Retryable: retryable})
+	l.mu.Unlock()
+}
+
+// save writes the failures of a job, keeping earlier failures of items this run didn't attempt
+func (l *failureLog) save(job QueueJob, attempted []MediaItem) {
+	path, err := getFailedPath(job.ID)
+	if err != nil {
+		return
+	}
+	ran := make(map[string]bool, len(attempted))
+	for _, item := range attempted {
+		ran[failedItemKey(item)] = true
+	}
+
+	l.mu.Lock()
+	items := append([]FailedItem(nil), l.items...)
+	l.mu.Unlock()
+	if previous, err := LoadFailedJob(job.ID); err == nil {
+		for _, failed := range previous.Items {
+			if !ran[failedItemKey(failed.Item)] {
+				items = append(items, failed)
+			}
+		}
+	}
+
+	if len(items) == 0 {
+		os.Remove(path)
+		return
+	}
+	job.Version = queueFormatVersion
+	job.Options = stashStorageSecrets(pinQueueOptions(job.Options))
+	job.Pending = len(items)
+	data, err := json.MarshalIndent(FailedJob{Job: job, Items: items, FinishedAt: time.Now()}, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(GetFailedDir(), 0755); err != nil {
+		fmt.Printf("Warning: failed to create failed items directory: %v\n", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		fmt.Printf("Warning: failed to save failed items: %v\n", err)
+	}
+}
+
+// failedItemKey identifies a media item across runs of a job
+func failedItemKey(item MediaItem) string {
+	return fmt.Sprintf("%d|%s|%s", item.TweetID, item.Type, item.URL)
+}
+
+// LoadFailedJob reads the failed items of a download job
+func LoadFailedJob(id string) (FailedJob, error) {
+	path, err := getFailedPath(id)
+	if err != nil {
+		return FailedJob{}, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return FailedJob{}, fmt.Errorf("no failed items for job %s", id)
+		}
+		return FailedJob{}, fmt.Errorf("failed to read failed items: %v", err)
+	}
+	var raw struct {
+		Job        json.RawMessage `json:"job"`
+		Items      []FailedItem    `json:"items"`
+		FinishedAt time.Time       `json:"finished_at"`
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return FailedJob{}, fmt.Errorf("invalid failed items file: %v", err)
+	}
+	job, err := decodeQueueJob(raw.Job)
+	if err != nil {
+		return FailedJob{}, err
+	}
+	return FailedJob{Job: job, Items: raw.Items, FinishedAt: raw.FinishedAt}, nil
+}
+
+// RetryFailed returns the job settings and the retryable failed items of a download job, and how
+// many failures are permanent and left out
+func RetryFailed(id string) (QueueJob, []MediaItem, int, error) {
+	failed, err := LoadFailedJob(id)
+	if err != nil {
+		return QueueJob{}, nil, 0, err
+	}
+	if _, running := runningQueues.Load(id); running {
+		return QueueJob{}, nil, 0, fmt.Errorf("job %s is still downloading", id)
+	}
+	var items []MediaItem
+	excluded := 0
+	for _, f := range failed.Items {
+		if !f.Retryable {
+			excluded++
+			continue
+		}
+		if msg := validateQueueItem(f.Item); msg != "" {
+			excluded++
+			continue
+		}
+		items = append(items, f.Item)
+	}
+	failed.Job.ID = id
+	return failed.Job, items, excluded, nil
+}
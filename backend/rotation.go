@@ -0,0 +1,195 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Token rotation: a rate limited guest fetch is continued from its cursor with a fresh guest token
+// (up to maxGuestRotations runs), and with several auth tokens a limited one is set aside until its
+// reset while the fetch continues with the next.
+
+// maxGuestRotations is how many times a guest fetch is continued with a new guest token
+const maxGuestRotations = 5
+
+// tokenCooldown is how long a rate-limited auth token is set aside if the reset time is unknown
+const tokenCooldown = 15 * time.Minute
+
+// limitedTokens holds when the rate limit of each limited auth token resets
+var limitedTokens = struct {
+	sync.Mutex
+	until map[string]time.Time
+}{until: map[string]time.Time{}}
+
+// isRotatable reports whether a failed run may get further with another token
+func isRotatable(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "[hint="+HintRateLimited) || // Also rate_limited_until
+		strings.Contains(msg, "[hint="+HintTimelineEnd+"]") ||
+		strings.HasPrefix(msg, ErrCodeExtractorStalled+":")
+}
+
+// splitAuthTokens splits a list of auth tokens separated by commas, semicolons or whitespace
+func splitAuthTokens(tokens string) []string {
+	var result []string
+	for _, token := range strings.FieldsFunc(tokens, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' ' || r == '\t' || r == '\n' || r == '\r'
+	}) {
+		if !containsString(result, token) {
+			result = append(result, token)
+		}
+	}
+	return result
+}
+
+// containsString reports whether list contains s
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// pickAuthTokens splits a list of auth tokens, the ones not known to be rate limited first
+// Limited tokens follow, the one that resets first first
+func pickAuthTokens(tokens string) []string {
+	result := splitAuthTokens(tokens)
+	if len(result) < 2 {
+		return result
+	}
+	now := time.Now()
+	limitedTokens.Lock()
+	defer limitedTokens.Unlock()
+	resetAt := func(token string) time.Time {
+		if until, ok := limitedTokens.until[token]; ok && until.After(now) {
+			return until
+		}
+		delete(limitedTokens.until, token)
+		return time.Time{}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return resetAt(result[i]).Before(resetAt(result[j]))
+	})
+	return result
+}
+
+// firstAuthToken returns the auth token a single request of a list uses, "" for none
+func firstAuthToken(tokens string) string {
+	if picked := pickAuthTokens(tokens); len(picked) > 0 {
+		return picked[0]
+	}
+	return ""
+}
+
+// markTokenLimited sets a rate-limited auth token aside until its limit resets
+func markTokenLimited(token string, err error) {
+	until := RetryAtFromMessage(err.Error())
+	if until.IsZero() {
+		until = time.Now().Add(tokenCooldown)
+	}
+	limitedTokens.Lock()
+	limitedTokens.until[token] = until
+	limitedTokens.Unlock()
+}
+
+// rotateGuestToken continues a rate-limited guest fetch with new guest tokens from its cursor
+func rotateGuestToken(exePath string, args []string, username string, batchSize int, resp CLIResponse, err error, h cliStreamHandler) (CLIResponse, error) {
+	return continueFetch(exePath, args, username, batchSize, resp, err, h, true, func(run int, err error) ([]string, bool) {
+		if run > maxGuestRotations {
+			return nil, false
+		}
+		fmt.Printf("Guest fetch of %s rate limited, continuing with a new guest token (%d/%d)\n", username, run, maxGuestRotations)
+		return args, true
+	})
+}
+
+// rotateAuthTokens continues a rate-limited fetch from its cursor with the next auth tokens
+// tokens are in the order of pickAuthTokens, the first one made the failed run
+func rotateAuthTokens(exePath string, args []string, username string, batchSize int, resp CLIResponse, err error, h cliStreamHandler, tokens []string) (CLIResponse, error) {
+	return continueFetch(exePath, args, username, batchSize, resp, err, h, false, func(run int, err error) ([]string, bool) {
+		if strings.Contains(err.Error(), "[hint="+HintRateLimited) {
+			markTokenLimited(tokens[run-1], err)
+		}
+		if run >= len(tokens) {
+			return nil, false
+		}
+		fmt.Printf("Fetch of %s rate limited, continuing with auth token %d of %d\n", username, run+1, len(tokens))
+		return withExtractorArg(args, "--auth-token", tokens[run]), true
+	})
+}
+
+// continueFetch runs the extractor again from the cursor of a rate-limited run, with the arguments
+// nextRun returns for each further run (false to stop). The entries of every run go to h; returns
+// the furthest cursor and the error of the last run, as a partial result if any run received
+// entries. With stopWhenEmpty a run that brings nothing new ends the rotation.
+func continueFetch(exePath string, args []string, username string, batchSize int, resp CLIResponse, err error, h cliStreamHandler, stopWhenEmpty bool, nextRun func(run int, err error) ([]string, bool)) (CLIResponse, error) {
+	received := receivedBefore(err)
+	for run := 1; err != nil && isRotatable(err); run++ {
+		if resp.Cursor == "" && received > 0 {
+			break // Nowhere to continue from
+		}
+		if batchSize > 0 && received >= batchSize {
+			break
+		}
+		runArgs, ok := nextRun(run, err)
+		if !ok {
+			break
+		}
+		if resp.Cursor != "" {
+			runArgs = withExtractorArg(runArgs, "--cursor", resp.Cursor)
+		}
+		if batchSize > 0 {
+			runArgs = withExtractorArg(runArgs, "--limit", fmt.Sprintf("%d", batchSize-received))
+		}
+
+		next, nextErr := runExtractorStream(exePath, runArgs, username, h)
+		if nextErr != nil && receivedBefore(nextErr) == 0 {
+			// Nothing new, the cursor stays where the last run got
+			err = nextErr
+			if stopWhenEmpty {
+				break
+			}
+			continue
+		}
+		received += receivedBefore(nextErr)
+		if next.Cursor == "" && !next.Completed {
+			next.Cursor = resp.Cursor
+		}
+		resp, err = next, nextErr
+	}
+
+	var partial *PartialResultError
+	if errors.As(err, &partial) {
+		partial.Received = received
+	} else if err != nil && received > 0 {
+		err = &PartialResultError{Received: received, Message: err.Error()}
+	}
+	return resp, err
+}
+
+// receivedBefore returns the entries a failed run received before it failed
+func receivedBefore(err error) int {
+	var partial *PartialResultError
+	if errors.As(err, &partial) {
+		return partial.Received
+	}
+	return 0
+}
+
+// withExtractorArg returns the arguments with a flag set to value, replacing it if it's there
+func withExtractorArg(args []string, flag, value string) []string {
+	result := append([]string(nil), args...)
+	for i := 0; i+1 < len(result); i++ {
+		if result[i] == flag {
+			result[i+1] = value
+			return result
+		}
+	}
+	return append(result, flag, value)
+}
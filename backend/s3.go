@@ -0,0 +1,441 @@
+package backend
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// S3 storage: uploads to an S3-compatible bucket with Signature V4. Files over s3PartSize are sent
+// as multipart uploads while they download; MkdirAll does nothing and Rename is copy + delete.
+
+// s3PartSize is the part size of multipart uploads (at least 5 MB except for the last part)
+const s3PartSize = 8 << 20
+
+// emptySHA256 is the SHA256 of an empty payload
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Config is an S3-compatible bucket to upload to
+type S3Config struct {
+	Endpoint  string `json:"endpoint,omitempty"` // e.g. https://s3.eu-central-1.amazonaws.com or https://minio.local:9000, empty = AWS
+	Region    string `json:"region,omitempty"`   // Empty = us-east-1
+	Bucket    string `json:"bucket"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	Prefix    string `json:"prefix,omitempty"`     // Key prefix mirroring the local output folder
+	PathStyle bool   `json:"path_style,omitempty"` // endpoint/bucket/key instead of bucket.endpoint/key (MinIO and most self-hosted servers)
+}
+
+// S3Storage saves files in an S3-compatible bucket
+type S3Storage struct {
+	cfg       S3Config
+	endpoint  *url.URL
+	localRoot string
+	client    *http.Client
+}
+
+// NewS3Storage returns the storage of a bucket; localRoot is the local output folder the engine plans paths in
+func NewS3Storage(cfg S3Config, localRoot string) (*S3Storage, error) {
+	if cfg.Bucket == "" {
+		return nil, fmt.Errorf("no S3 bucket configured")
+	}
+	if cfg.AccessKey == "" || cfg.SecretKey == "" {
+		return nil, fmt.Errorf("S3 access key and secret key are required (saved jobs whose secret key isn't in the secret store need it entered again)")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://s3.%s.amazonaws.com", cfg.Region)
+	}
+	if !strings.Contains(cfg.Endpoint, "://") {
+		cfg.Endpoint = "https://" + cfg.Endpoint
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3 endpoint: %s", cfg.Endpoint)
+	}
+	// No overall timeout, uploads of long videos take a while
+	return &S3Storage{cfg: cfg, endpoint: endpoint, localRoot: localRoot, client: &http.Client{Transport: audited(nil, AuditPurposeUpload)}}, nil
+}
+
+// key maps a path planned below the local output folder to an object key
+func (s *S3Storage) key(localPath string) string {
+	return remoteKey(s.localRoot, s.cfg.Prefix, localPath)
+}
+
+// objectURL returns the URL of an object and its escaped path for signing
+func (s *S3Storage) objectURL(key string, query url.Values) *url.URL {
+	u := *s.endpoint
+	escaped := s.endpoint.EscapedPath()
+	if s.cfg.PathStyle {
+		escaped += "/" + s3Escape(s.cfg.Bucket, false)
+	} else {
+		u.Host = s.cfg.Bucket + "." + u.Host
+	}
+	escaped += "/" + s3Escape(key, true)
+	u.Path, _ = url.PathUnescape(escaped)
+	u.RawPath = escaped
+	u.RawQuery = s3CanonicalQuery(query)
+	return &u
+}
+
+// request builds a signed request; body may be nil
+func (s *S3Storage) request(method, key string, query url.Values, header http.Header, body []byte) (*http.Request, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	req, err := http.NewRequest(method, s.objectURL(key, query).String(), reader)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	payloadHash := emptySHA256
+	if body != nil {
+		sum := sha256.Sum256(body)
+		payloadHash = hex.EncodeToString(sum[:])
+	}
+	s.sign(req, payloadHash, time.Now().UTC())
+	return req, nil
+}
+
+// do sends a signed request with retries and returns the response of a 2xx status
+func (s *S3Storage) do(method, key string, query url.Values, header http.Header, body []byte) (*http.Response, error) {
+	resp, err := doRemote(s.client, func() (*http.Request, error) {
+		return s.request(method, key, query, header, body)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusNotFound {
+		resp.Body.Close()
+		return nil, &fs.PathError{Op: strings.ToLower(method), Path: key, Err: fs.ErrNotExist}
+	}
+	if resp.StatusCode/100 != 2 {
+		defer resp.Body.Close()
+		return nil, s3Error(resp, method, key)
+	}
+	return resp, nil
+}
+
+// sign adds a Signature V4 Authorization header
+func (s *S3Storage) sign(req *http.Request, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	// host and every x-amz-* header are signed
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		lower := strings.ToLower(name)
+		if strings.HasPrefix(lower, "x-amz-") || lower == "content-type" || lower == "content-md5" {
+			headers[lower] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := day + "/" + s.cfg.Region + "/s3/aws4_request"
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + hex.EncodeToString(requestHash[:])
+
+	key := hmacSHA256([]byte("AWS4"+s.cfg.SecretKey), day)
+	key = hmacSHA256(key, s.cfg.Region)
+	key = hmacSHA256(key, "s3")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.cfg.AccessKey, scope, signedHeaders, signature))
+}
+
+// Stat implements Storage
+func (s *S3Storage) Stat(localPath string) (os.FileInfo, error) {
+	key := s.key(localPath)
+	resp, err := s.do(http.MethodHead, key, nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	modTime, _ := http.ParseTime(resp.Header.Get("Last-Modified"))
+	return &sftpFileInfo{name: path.Base(key), size: resp.ContentLength, mode: 0100644, modTime: modTime}, nil
+}
+
+// MkdirAll implements Storage, buckets have no folders
+func (s *S3Storage) MkdirAll(localPath string) error {
+	return nil
+}
+
+// Create implements Storage, the object is uploaded while it's written
+func (s *S3Storage) Create(localPath string) (io.WriteCloser, error) {
+	return &s3Upload{s: s, key: s.key(localPath)}, nil
+}
+
+// Open implements Storage
+func (s *S3Storage) Open(localPath string) (io.ReadCloser, error) {
+	resp, err := s.do(http.MethodGet, s.key(localPath), nil, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// Rename implements Storage with a server-side copy and a delete
+func (s *S3Storage) Rename(oldPath, newPath string) error {
+	oldKey, newKey := s.key(oldPath), s.key(newPath)
+	header := http.Header{}
+	header.Set("x-amz-copy-source", "/"+s3Escape(s.cfg.Bucket, false)+"/"+s3Escape(oldKey, true))
+	resp, err := s.do(http.MethodPut, newKey, nil, header, nil)
+	if err != nil {
+		return fmt.Errorf("failed to copy %s: %v", oldKey, err)
+	}
+	// A copy can fail after the 200 status, the error is in the body
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if bytes.Contains(body, []byte("<Error>")) {
+		return fmt.Errorf("failed to copy %s: %s", oldKey, s3ErrorMessage(body))
+	}
+	return s.Remove(oldPath)
+}
+
+// Remove implements Storage
+func (s *S3Storage) Remove(localPath string) error {
+	resp, err := s.do(http.MethodDelete, s.key(localPath), nil, nil, nil)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// TestS3Connection checks that the bucket is reachable and writable
+func TestS3Connection(cfg S3Config) error {
+	s, err := NewS3Storage(cfg, "")
+	if err != nil {
+		return err
+	}
+	key := remoteKey("", cfg.Prefix, fmt.Sprintf(".write-test-%d", time.Now().UnixNano()))
+	resp, err := s.do(http.MethodPut, key, nil, nil, []byte{})
+	if err != nil {
+		return fmt.Errorf("bucket is not writable: %v", err)
+	}
+	resp.Body.Close()
+	if resp, err := s.do(http.MethodDelete, key, nil, nil, nil); err == nil {
+		resp.Body.Close()
+	}
+	return nil
+}
+
+// s3Upload uploads an object while it's written: one PUT if it stays small, a multipart upload otherwise
+type s3Upload struct {
+	s        *S3Storage
+	key      string
+	buf      []byte
+	uploadID string
+	parts    []s3Part
+	err      error
+}
+
+// s3Part is an uploaded part of a multipart upload
+type s3Part struct {
+	Number int    `xml:"PartNumber"`
+	ETag   string `xml:"ETag"`
+}
+
+func (u *s3Upload) Write(p []byte) (int, error) {
+	if u.err != nil {
+		return 0, u.err
+	}
+	u.buf = append(u.buf, p...)
+	for len(u.buf) >= s3PartSize {
+		if err := u.uploadPart(u.buf[:s3PartSize]); err != nil {
+			u.abort(err)
+			return 0, err
+		}
+		u.buf = append(u.buf[:0], u.buf[s3PartSize:]...)
+	}
+	return len(p), nil
+}
+
+func (u *s3Upload) Close() error {
+	if u.err != nil {
+		return u.err
+	}
+	if u.uploadID == "" {
+		resp, err := u.s.do(http.MethodPut, u.key, nil, nil, u.buf)
+		if err != nil {
+			return fmt.Errorf("failed to upload %s: %v", u.key, err)
+		}
+		resp.Body.Close()
+		return nil
+	}
+
+	if len(u.buf) > 0 {
+		if err := u.uploadPart(u.buf); err != nil {
+			u.abort(err)
+			return err
+		}
+	}
+	body, err := xml.Marshal(struct {
+		XMLName xml.Name `xml:"CompleteMultipartUpload"`
+		Parts   []s3Part `xml:"Part"`
+	}{Parts: u.parts})
+	if err != nil {
+		u.abort(err)
+		return err
+	}
+	resp, err := u.s.do(http.MethodPost, u.key, url.Values{"uploadId": {u.uploadID}}, nil, body)
+	if err != nil {
+		u.abort(err)
+		return fmt.Errorf("failed to complete upload of %s: %v", u.key, err)
+	}
+	result, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if bytes.Contains(result, []byte("<Error>")) {
+		err := fmt.Errorf("failed to complete upload of %s: %s", u.key, s3ErrorMessage(result))
+		u.abort(err)
+		return err
+	}
+	return nil
+}
+
+// uploadPart uploads the next part, starting the multipart upload first if needed
+func (u *s3Upload) uploadPart(data []byte) error {
+	if u.uploadID == "" {
+		resp, err := u.s.do(http.MethodPost, u.key, url.Values{"uploads": {""}}, nil, nil)
+		if err != nil {
+			return fmt.Errorf("failed to start upload of %s: %v", u.key, err)
+		}
+		var result struct {
+			UploadID string `xml:"UploadId"`
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil || result.UploadID == "" {
+			return fmt.Errorf("failed to start upload of %s: no upload ID", u.key)
+		}
+		u.uploadID = result.UploadID
+	}
+
+	number := len(u.parts) + 1
+	query := url.Values{"partNumber": {strconv.Itoa(number)}, "uploadId": {u.uploadID}}
+	resp, err := u.s.do(http.MethodPut, u.key, query, nil, data)
+	if err != nil {
+		return fmt.Errorf("failed to upload part %d of %s: %v", number, u.key, err)
+	}
+	resp.Body.Close()
+	u.parts = append(u.parts, s3Part{Number: number, ETag: resp.Header.Get("ETag")})
+	return nil
+}
+
+// abort cancels the multipart upload so its parts don't stay in the bucket
+func (u *s3Upload) abort(err error) {
+	u.err = err
+	u.buf = nil
+	if u.uploadID == "" {
+		return
+	}
+	if resp, err := u.s.do(http.MethodDelete, u.key, url.Values{"uploadId": {u.uploadID}}, nil, nil); err == nil {
+		resp.Body.Close()
+	}
+	u.uploadID = ""
+}
+
+// s3Escape URI-encodes a key the way Signature V4 expects, keeping slashes if keepSlash is set
+func s3Escape(s string, keepSlash bool) string {
+	var b strings.Builder
+	for _, c := range []byte(s) {
+		switch {
+		case c >= 'A' && c <= 'Z', c >= 'a' && c <= 'z', c >= '0' && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && keepSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+// s3CanonicalQuery encodes a query sorted by key, as Signature V4 expects
+func s3CanonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for k := range query {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, k := range keys {
+		for _, v := range query[k] {
+			parts = append(parts, s3Escape(k, false)+"="+s3Escape(v, false))
+		}
+	}
+	return strings.Join(parts, "&")
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data with key
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+// s3Error builds an error from a failed response
+func s3Error(resp *http.Response, method, key string) error {
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if resp.StatusCode == http.StatusForbidden {
+		return &fs.PathError{Op: strings.ToLower(method), Path: key, Err: fmt.Errorf("%w: %s", fs.ErrPermission, s3ErrorMessage(body))}
+	}
+	if msg := s3ErrorMessage(body); msg != "" {
+		return fmt.Errorf("s3 %s %s: %s (%s)", strings.ToLower(method), key, msg, resp.Status)
+	}
+	return fmt.Errorf("s3 %s %s: %s", strings.ToLower(method), key, resp.Status)
+}
+
+// s3ErrorMessage extracts the code and message of an S3 error document
+func s3ErrorMessage(body []byte) string {
+	var doc struct {
+		Code    string `xml:"Code"`
+		Message string `xml:"Message"`
+	}
+	if xml.Unmarshal(body, &doc) != nil || doc.Code == "" {
+		return ""
+	}
+	if doc.Message == "" {
+		return doc.Code
+	}
+	return doc.Code + ": " + doc.Message
+}
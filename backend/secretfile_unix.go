@@ -0,0 +1,65 @@
+//go:build !windows
+
+package backend
+
+import (
+	"crypto/rand"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// secretFileName describes the fallback secret storage
+// The key is stored next to the file with the same permissions, so it only keeps secrets out of
+// casual view: anyone who can read the app data folder can read them
+const secretFileName = "obfuscated file"
+
+// getSecretKeyPath returns the path of the key of the fallback secret file
+func getSecretKeyPath() string {
+	return filepath.Join(GetAppDataDir(), "secrets.key")
+}
+
+// secretFileKey returns the key of the fallback secret file, generating it on first use
+// A new key is never generated while secrets.json exists, it would make the stored secrets unreadable
+func secretFileKey() ([]byte, error) {
+	key, err := os.ReadFile(getSecretKeyPath())
+	if err == nil && len(key) == 32 {
+		return key, nil
+	}
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read key: %v", err)
+	}
+	if _, statErr := os.Stat(getSecretFilePath()); statErr == nil {
+		return nil, fmt.Errorf("%s is missing or damaged, so the secrets in %s can't be read - remove %s and enter them again",
+			filepath.Base(getSecretKeyPath()), filepath.Base(getSecretFilePath()), filepath.Base(getSecretFilePath()))
+	}
+	key = make([]byte, 32)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("failed to generate key: %v", err)
+	}
+	if err := os.MkdirAll(GetAppDataDir(), 0755); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(getSecretKeyPath(), key, 0600); err != nil {
+		return nil, fmt.Errorf("failed to save key: %v", err)
+	}
+	return key, nil
+}
+
+// protectSecret encrypts data with the machine-local key
+func protectSecret(data []byte) ([]byte, error) {
+	key, err := secretFileKey()
+	if err != nil {
+		return nil, err
+	}
+	return sealBytes(key, data)
+}
+
+// unprotectSecret decrypts data encrypted by protectSecret
+func unprotectSecret(data []byte) ([]byte, error) {
+	key, err := secretFileKey()
+	if err != nil {
+		return nil, err
+	}
+	return openBytes(key, data)
+}
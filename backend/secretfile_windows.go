@@ -0,0 +1,41 @@
+//go:build windows
+
+package backend
+
+import (
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// secretFileName describes the fallback secret storage
+const secretFileName = "DPAPI-encrypted file"
+
+// protectSecret encrypts data for the current Windows user with DPAPI
+func protectSecret(data []byte) ([]byte, error) {
+	return dpapi(data, true)
+}
+
+// unprotectSecret decrypts data encrypted by protectSecret
+func unprotectSecret(data []byte) ([]byte, error) {
+	return dpapi(data, false)
+}
+
+func dpapi(data []byte, protect bool) ([]byte, error) {
+	if len(data) == 0 {
+		return data, nil
+	}
+	in := windows.DataBlob{Size: uint32(len(data)), Data: &data[0]}
+	var out windows.DataBlob
+	var err error
+	if protect {
+		err = windows.CryptProtectData(&in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	} else {
+		err = windows.CryptUnprotectData(&in, nil, nil, 0, nil, windows.CRYPTPROTECT_UI_FORBIDDEN, &out)
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer windows.LocalFree(windows.Handle(unsafe.Pointer(out.Data)))
+	return append([]byte(nil), unsafe.Slice(out.Data, out.Size)...), nil
+}
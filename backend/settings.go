@@ -0,0 +1,232 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+// Settings
+//
+// The frontend keeps its settings in the webview's local storage, which the backend can't read
+// and which is lost with the webview profile. The settings the backend itself depends on live in
+// settings.json in the data folder instead: download concurrency, the default file name template
+// and collision suffix, the default proxy, a bandwidth limit shared by all downloads, the time
+// limits of extractor runs, what happens to the folder of a renamed account, the network audit
+// log and the paths of external tools. Like queue files (see stateversion.go) the file carries a format
+// version and is upgraded step by step when loaded; a file written by a newer version is left
+// alone and the defaults are used until it's saved again. Options sent with a request (a job's
+// proxy or template) still win over these defaults.
+
+// settingsFile holds the settings in the data folder
+const settingsFile = "settings.json"
+
+// settingsFormatVersion is the format version of settings files written by this version
+const settingsFormatVersion = 1
+
+// settingsUpgrades upgrade a raw settings file from the version at its index + 1 to the next one
+var settingsUpgrades = []func(settings map[string]any) error{}
+
+// Limits of the download concurrency
+const (
+	minConcurrentDownloads = 1
+	maxConcurrentDownloads = 32
+)
+
+// Settings are the app-wide settings of the backend
+type Settings struct {
+	Version int `json:"version"`
+
+	MaxConcurrentDownloads int    `json:"max_concurrent_downloads"` // Parallel downloads per job
+	FilenameTemplate       string `json:"filename_template"`        // Default file name template, "" = DefaultFilenameTemplate
+	CollisionSuffix        string `json:"collision_suffix"`         // Default collision suffix: counter or hash
+	Proxy                  string `json:"proxy"`                    // Default proxy URL, "" = system proxy or none
+	BandwidthKBps          int    `json:"bandwidth_kbps"`           // Download speed limit shared by all jobs in KB/s, 0 = unlimited
+
+	// Limits of extractor runs in minutes, see watchdog.go, 0 = none
+	ExtractorTimeoutMinutes int `json:"extractor_timeout_minutes"` // Total run time
+	ExtractorIdleMinutes    int `json:"extractor_idle_minutes"`    // Time without any output
+
+	RenamedFolders string `json:"renamed_folders"` // Archive folder of a renamed account: rename, symlink or keep, see renames.go
+
+	AuditLog bool `json:"audit_log"` // Log every network request to audit.log, see audit.go
+
+	// External tools, "" = the bundled or system-installed one
+	FFmpegPath   string `json:"ffmpeg_path"`
+	FFprobePath  string `json:"ffprobe_path"`
+	ExifToolPath string `json:"exiftool_path"`
+}
+
+var (
+	settingsMu     sync.Mutex
+	settingsLoaded bool
+	settings       Settings
+)
+
+// DefaultSettings returns the settings used before any are saved
+func DefaultSettings() Settings {
+	return Settings{
+		Version:                settingsFormatVersion,
+		MaxConcurrentDownloads: MaxConcurrentDownloads,
+		CollisionSuffix:        CollisionCounter,
+		ExtractorIdleMinutes:   defaultExtractorIdleMinutes,
+		RenamedFolders:         RenamedFoldersRename,
+	}
+}
+
+// getSettingsPath returns the path of the settings file
+func getSettingsPath() string {
+	return filepath.Join(GetAppDataDir(), settingsFile)
+}
+
+// GetSettings returns the current settings
+func GetSettings() Settings {
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	if !settingsLoaded {
+		loaded, err := loadSettings(getSettingsPath())
+		if err != nil {
+			fmt.Printf("Warning: %v - using default settings\n", err)
+			loaded = DefaultSettings()
+		}
+		settings = loaded
+		settingsLoaded = true
+		bandwidth.setRate(int64(settings.BandwidthKBps) * 1024)
+	}
+	return settings
+}
+
+// SetSettings validates and saves the settings, returning them as saved
+func SetSettings(s Settings) (Settings, error) {
+	s, err := normalizeSettings(s)
+	if err != nil {
+		return s, err
+	}
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return s, err
+	}
+
+	settingsMu.Lock()
+	defer settingsMu.Unlock()
+	if err := os.MkdirAll(GetAppDataDir(), 0755); err != nil {
+		return s, fmt.Errorf("failed to create data folder: %v", err)
+	}
+	// Write next to the file and swap, so an interrupted write keeps the old settings
+	path := getSettingsPath()
+	if err := os.WriteFile(path+".tmp", data, 0644); err != nil {
+		return s, fmt.Errorf("failed to save settings: %v", err)
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		os.Remove(path + ".tmp")
+		return s, fmt.Errorf("failed to save settings: %v", err)
+	}
+	settings = s
+	settingsLoaded = true
+	bandwidth.setRate(int64(s.BandwidthKBps) * 1024)
+	return s, nil
+}
+
+// loadSettings reads a settings file of any supported version, the defaults if there is none
+func loadSettings(path string) (Settings, error) {
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return DefaultSettings(), nil
+	}
+	if err != nil {
+		return Settings{}, fmt.Errorf("failed to read settings: %v", err)
+	}
+
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return Settings{}, fmt.Errorf("invalid settings file: %v", err)
+	}
+	version := 1
+	if v, ok := raw["version"].(float64); ok && v >= 1 {
+		version = int(v)
+	}
+	if version > settingsFormatVersion {
+		return Settings{}, fmt.Errorf("settings were saved by a newer version of the app (format %d, this version reads up to %d)", version, settingsFormatVersion)
+	}
+	for ; version < settingsFormatVersion; version++ {
+		if err := settingsUpgrades[version-1](raw); err != nil {
+			return Settings{}, fmt.Errorf("failed to upgrade settings from format %d: %v", version, err)
+		}
+	}
+	raw["version"] = settingsFormatVersion
+
+	// Settings the file doesn't have keep their defaults
+	upgraded, _ := json.Marshal(raw)
+	s := DefaultSettings()
+	if err := json.Unmarshal(upgraded, &s); err != nil {
+		return Settings{}, fmt.Errorf("invalid settings file: %v", err)
+	}
+	if s, err = normalizeSettings(s); err != nil {
+		return Settings{}, err
+	}
+	return s, nil
+}
+
+// normalizeSettings fills in defaults and rejects invalid values
+func normalizeSettings(s Settings) (Settings, error) {
+	s.Version = settingsFormatVersion
+	if s.MaxConcurrentDownloads == 0 {
+		s.MaxConcurrentDownloads = MaxConcurrentDownloads
+	}
+	if s.MaxConcurrentDownloads < minConcurrentDownloads || s.MaxConcurrentDownloads > maxConcurrentDownloads {
+		return s, fmt.Errorf("concurrent downloads must be between %d and %d", minConcurrentDownloads, maxConcurrentDownloads)
+	}
+	s.FilenameTemplate = strings.TrimSpace(s.FilenameTemplate)
+	switch s.CollisionSuffix {
+	case "":
+		s.CollisionSuffix = CollisionCounter
+	case CollisionCounter, CollisionHash:
+	default:
+		return s, fmt.Errorf("unknown collision suffix: %s", s.CollisionSuffix)
+	}
+	s.Proxy = strings.TrimSpace(s.Proxy)
+	if s.Proxy != "" {
+		if u, err := url.Parse(s.Proxy); err != nil || u.Scheme == "" || u.Host == "" {
+			return s, fmt.Errorf("invalid proxy URL: %s", s.Proxy)
+		}
+	}
+	if s.BandwidthKBps < 0 {
+		s.BandwidthKBps = 0
+	}
+	if s.ExtractorTimeoutMinutes < 0 || s.ExtractorIdleMinutes < 0 {
+		return s, fmt.Errorf("extractor time limits can't be negative")
+	}
+	switch s.RenamedFolders {
+	case "":
+		s.RenamedFolders = RenamedFoldersRename
+	case RenamedFoldersRename, RenamedFoldersSymlink, RenamedFoldersKeep:
+	default:
+		return s, fmt.Errorf("unknown renamed folder handling: %s", s.RenamedFolders)
+	}
+	for name, path := range map[string]*string{"ffmpeg": &s.FFmpegPath, "ffprobe": &s.FFprobePath, "exiftool": &s.ExifToolPath} {
+		*path = strings.TrimSpace(*path)
+		if *path == "" {
+			continue
+		}
+		if info, err := os.Stat(*path); err != nil || info.IsDir() {
+			return s, fmt.Errorf("%s not found at %s", name, *path)
+		}
+	}
+	return s, nil
+}
+
+// applySettingsDefaults fills in the job options the request left to the settings
+func applySettingsDefaults(opts DownloadOptions) DownloadOptions {
+	s := GetSettings()
+	if strings.TrimSpace(opts.FilenameTemplate) == "" {
+		opts.FilenameTemplate = s.FilenameTemplate
+	}
+	if opts.CollisionSuffix == "" {
+		opts.CollisionSuffix = s.CollisionSuffix
+	}
+	return opts
+}
@@ -0,0 +1,735 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// SFTP storage: streams downloads to a remote server through the system's OpenSSH client, so
+// host keys, ~/.ssh/config and the SSH agent work as usual. Authentication can't prompt.
+
+// SFTP packet types (draft-ietf-secsh-filexfer-02)
+const (
+	sftpInit          = 1
+	sftpVersion       = 2
+	sftpOpen          = 3
+	sftpClose         = 4
+	sftpRead          = 5
+	sftpWrite         = 6
+	sftpRemove        = 13
+	sftpMkdir         = 14
+	sftpStat          = 17
+	sftpRename        = 18
+	sftpStatus        = 101
+	sftpHandle        = 102
+	sftpData          = 103
+	sftpAttrs         = 105
+	sftpExtended      = 200
+	sftpExtendedReply = 201
+)
+
+// SFTP open flags
+const (
+	sftpFlagRead   = 0x01
+	sftpFlagWrite  = 0x02
+	sftpFlagCreate = 0x08
+	sftpFlagTrunc  = 0x10
+)
+
+// SFTP status codes
+const (
+	sftpStatusOK               = 0
+	sftpStatusEOF              = 1
+	sftpStatusNoSuchFile       = 2
+	sftpStatusPermissionDenied = 3
+)
+
+// SFTP attribute flags
+const (
+	sftpAttrSize        = 0x01
+	sftpAttrUIDGID      = 0x02
+	sftpAttrPermissions = 0x04
+	sftpAttrACModTime   = 0x08
+	sftpAttrExtended    = 0x80000000
+)
+
+const (
+	sftpChunkSize      = 32 * 1024 // Largest read/write every server accepts
+	sftpMaxInflight    = 16        // Pipelined writes per file before waiting for acknowledgements
+	sftpPosixRenameExt = "posix-rename@openssh.com"
+)
+
+// SFTPConfig is a remote output target reachable over SSH
+type SFTPConfig struct {
+	Host         string `json:"host"`
+	Port         int    `json:"port,omitempty"`          // 0 = 22 or the port from ~/.ssh/config
+	User         string `json:"user,omitempty"`          // Empty = the user from ~/.ssh/config or the local user
+	IdentityFile string `json:"identity_file,omitempty"` // Private key, empty = agent/default keys
+	RemoteDir    string `json:"remote_dir"`              // Remote folder mirroring the local output folder
+}
+
+// SFTPStorage saves files on a remote server over SFTP
+// Paths below the local output folder are mapped to the same relative path below RemoteDir
+type SFTPStorage struct {
+	cfg       SFTPConfig
+	localRoot string
+
+	cmd    *exec.Cmd
+	stdin  *countingWriter
+	stdout *countingReader
+	stderr syncBuffer // ssh's error output, e.g. why the login failed
+
+	writeMu     sync.Mutex
+	mu          sync.Mutex
+	nextID      uint32
+	pending     map[uint32]chan sftpPacket
+	connErr     error // Set once the connection is gone
+	posixRename bool
+
+	dirsMu sync.Mutex
+	dirs   map[string]bool // Remote folders known to exist
+}
+
+// sftpPacket is a response from the server
+type sftpPacket struct {
+	typ  byte
+	data []byte // Payload after the request ID
+}
+
+// NewSFTPStorage connects to the server; localRoot is the local output folder the engine plans paths in
+func NewSFTPStorage(cfg SFTPConfig, localRoot string) (*SFTPStorage, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("no SFTP host configured")
+	}
+	if cfg.RemoteDir == "" {
+		cfg.RemoteDir = "."
+	}
+	sshPath, err := exec.LookPath("ssh")
+	if err != nil {
+		return nil, fmt.Errorf("ssh client not found: %v", err)
+	}
+
+	args := []string{
+		"-o", "BatchMode=yes", // Never prompt - there's nobody to answer
+		"-o", "StrictHostKeyChecking=accept-new",
+		"-o", "ServerAliveInterval=30",
+	}
+	if cfg.Port > 0 {
+		args = append(args, "-p", strconv.Itoa(cfg.Port))
+	}
+	if cfg.IdentityFile != "" {
+		args = append(args, "-i", cfg.IdentityFile)
+	}
+	dest := cfg.Host
+	if cfg.User != "" {
+		dest = cfg.User + "@" + cfg.Host
+	}
+	args = append(args, "-s", dest, "sftp")
+
+	s := &SFTPStorage{
+		cfg:       cfg,
+		localRoot: localRoot,
+		pending:   make(map[uint32]chan sftpPacket),
+		dirs:      make(map[string]bool),
+	}
+	s.cmd = exec.Command(sshPath, args...)
+	hideWindow(s.cmd)
+	s.cmd.Stderr = &s.stderr
+	stdin, err := s.cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := s.cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	s.stdin = &countingWriter{WriteCloser: stdin}
+	s.stdout = &countingReader{ReadCloser: stdout}
+	if err := s.cmd.Start(); err != nil {
+		return nil, fmt.Errorf("failed to start ssh: %v", err)
+	}
+
+	reader := bufio.NewReaderSize(s.stdout, 64*1024)
+	if err := s.handshake(reader); err != nil {
+		s.stdin.Close()
+		s.cmd.Wait()
+		auditProcess(cfg.Host, AuditPurposeUpload, s.stdin.n.Load(), s.stdout.n.Load(), err)
+		if msg := strings.TrimSpace(s.stderr.String()); msg != "" {
+			return nil, fmt.Errorf("failed to connect to %s: %s", cfg.Host, msg)
+		}
+		return nil, fmt.Errorf("failed to connect to %s: %v", cfg.Host, err)
+	}
+	go s.readLoop(reader)
+	return s, nil
+}
+
+// handshake exchanges protocol versions and records the server's extensions
+func (s *SFTPStorage) handshake(r io.Reader) error {
+	if err := s.writePacket(sftpInit, binary.BigEndian.AppendUint32(nil, 3)); err != nil {
+		return err
+	}
+	typ, data, err := readSFTPPacket(r)
+	if err != nil {
+		return err
+	}
+	if typ != sftpVersion {
+		return fmt.Errorf("unexpected SFTP packet %d", typ)
+	}
+	d := sftpDecoder{b: data}
+	d.u32() // Version
+	for len(d.b) > 0 && d.err == nil {
+		name, _ := d.str(), d.str()
+		if name == sftpPosixRenameExt {
+			s.posixRename = true
+		}
+	}
+	return nil
+}
+
+// readLoop dispatches responses to the waiting requests until the connection ends
+func (s *SFTPStorage) readLoop(r io.Reader) {
+	for {
+		typ, data, err := readSFTPPacket(r)
+		if err == nil && len(data) < 4 {
+			err = fmt.Errorf("malformed SFTP packet")
+		}
+		if err != nil {
+			s.mu.Lock()
+			s.connErr = fmt.Errorf("SFTP connection lost: %v", err)
+			if msg := strings.TrimSpace(s.stderr.String()); msg != "" {
+				s.connErr = fmt.Errorf("SFTP connection lost: %s", msg)
+			}
+			for id, ch := range s.pending {
+				close(ch)
+				delete(s.pending, id)
+			}
+			s.mu.Unlock()
+			return
+		}
+
+		id := binary.BigEndian.Uint32(data)
+		s.mu.Lock()
+		ch := s.pending[id]
+		delete(s.pending, id)
+		s.mu.Unlock()
+		if ch != nil {
+			ch <- sftpPacket{typ: typ, data: data[4:]}
+		}
+	}
+}
+
+// readSFTPPacket reads a single length-prefixed packet
+func readSFTPPacket(r io.Reader) (byte, []byte, error) {
+	var header [5]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return 0, nil, err
+	}
+	length := binary.BigEndian.Uint32(header[:4])
+	if length < 1 || length > 256*1024 {
+		return 0, nil, fmt.Errorf("invalid SFTP packet length %d", length)
+	}
+	data := make([]byte, length-1)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, nil, err
+	}
+	return header[4], data, nil
+}
+
+// writePacket sends a single packet
+func (s *SFTPStorage) writePacket(typ byte, payload []byte) error {
+	packet := binary.BigEndian.AppendUint32(make([]byte, 0, 5+len(payload)), uint32(1+len(payload)))
+	packet = append(packet, typ)
+	packet = append(packet, payload...)
+	s.writeMu.Lock()
+	defer s.writeMu.Unlock()
+	_, err := s.stdin.Write(packet)
+	return err
+}
+
+// send sends a request and returns the channel its response arrives on
+func (s *SFTPStorage) send(typ byte, body []byte) (chan sftpPacket, error) {
+	ch := make(chan sftpPacket, 1)
+	s.mu.Lock()
+	if s.connErr != nil {
+		s.mu.Unlock()
+		return nil, s.connErr
+	}
+	s.nextID++
+	id := s.nextID
+	s.pending[id] = ch
+	s.mu.Unlock()
+
+	payload := binary.BigEndian.AppendUint32(make([]byte, 0, 4+len(body)), id)
+	if err := s.writePacket(typ, append(payload, body...)); err != nil {
+		s.mu.Lock()
+		delete(s.pending, id)
+		s.mu.Unlock()
+		return nil, fmt.Errorf("SFTP connection lost: %v", err)
+	}
+	return ch, nil
+}
+
+// wait returns the response to a request
+func (s *SFTPStorage) wait(ch chan sftpPacket) (sftpPacket, error) {
+	p, ok := <-ch
+	if !ok {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return p, s.connErr
+	}
+	return p, nil
+}
+
+// call sends a request and waits for its response
+func (s *SFTPStorage) call(typ byte, body []byte) (sftpPacket, error) {
+	ch, err := s.send(typ, body)
+	if err != nil {
+		return sftpPacket{}, err
+	}
+	return s.wait(ch)
+}
+
+// callStatus sends a request that is answered with a status
+func (s *SFTPStorage) callStatus(op, remote string, typ byte, body []byte) error {
+	p, err := s.call(typ, body)
+	if err != nil {
+		return err
+	}
+	return sftpStatusError(p, op, remote)
+}
+
+// sftpStatusError converts a status response to an error (nil for OK)
+// Missing files satisfy os.IsNotExist, like local errors
+func sftpStatusError(p sftpPacket, op, remote string) error {
+	if p.typ != sftpStatus {
+		return fmt.Errorf("sftp %s %s: unexpected response %d", op, remote, p.typ)
+	}
+	d := sftpDecoder{b: p.data}
+	code := d.u32()
+	msg := d.str()
+	switch code {
+	case sftpStatusOK:
+		return nil
+	case sftpStatusEOF:
+		return io.EOF
+	case sftpStatusNoSuchFile:
+		return &fs.PathError{Op: op, Path: remote, Err: fs.ErrNotExist}
+	case sftpStatusPermissionDenied:
+		return &fs.PathError{Op: op, Path: remote, Err: fs.ErrPermission}
+	default:
+		return fmt.Errorf("sftp %s %s: %s (code %d)", op, remote, msg, code)
+	}
+}
+
+// remotePath maps a path planned below the local output folder to the remote folder
+func (s *SFTPStorage) remotePath(localPath string) string {
+	rel, err := filepath.Rel(s.localRoot, localPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return filepath.ToSlash(localPath)
+	}
+	return path.Join(s.cfg.RemoteDir, filepath.ToSlash(rel))
+}
+
+// Stat implements Storage
+func (s *SFTPStorage) Stat(localPath string) (os.FileInfo, error) {
+	return s.stat(s.remotePath(localPath))
+}
+
+// stat returns the file info of a remote path
+func (s *SFTPStorage) stat(remote string) (os.FileInfo, error) {
+	p, err := s.call(sftpStat, appendSFTPString(nil, remote))
+	if err != nil {
+		return nil, err
+	}
+	if p.typ != sftpAttrs {
+		return nil, sftpStatusError(p, "stat", remote)
+	}
+	d := sftpDecoder{b: p.data}
+	info := d.attrs(path.Base(remote))
+	if d.err != nil {
+		return nil, fmt.Errorf("sftp stat %s: %v", remote, d.err)
+	}
+	return info, nil
+}
+
+// MkdirAll implements Storage
+func (s *SFTPStorage) MkdirAll(localPath string) error {
+	return s.mkdirRemote(s.remotePath(localPath))
+}
+
+// mkdirRemote creates a remote folder and its parents
+func (s *SFTPStorage) mkdirRemote(remote string) error {
+	s.dirsMu.Lock()
+	known := s.dirs[remote]
+	s.dirsMu.Unlock()
+	if known {
+		return nil
+	}
+
+	info, err := s.stat(remote)
+	switch {
+	case err == nil:
+		if !info.IsDir() {
+			return fmt.Errorf("sftp mkdir %s: not a directory", remote)
+		}
+	case !os.IsNotExist(err):
+		return err
+	default:
+		if parent := path.Dir(remote); parent != remote && parent != "." && parent != "/" {
+			if err := s.mkdirRemote(parent); err != nil {
+				return err
+			}
+		}
+		body := appendSFTPString(nil, remote)
+		body = binary.BigEndian.AppendUint32(body, 0) // No attributes
+		if err := s.callStatus("mkdir", remote, sftpMkdir, body); err != nil {
+			// Another worker may have created it in the meantime
+			if info, statErr := s.stat(remote); statErr != nil || !info.IsDir() {
+				return err
+			}
+		}
+	}
+
+	s.dirsMu.Lock()
+	s.dirs[remote] = true
+	s.dirsMu.Unlock()
+	return nil
+}
+
+// Create implements Storage
+func (s *SFTPStorage) Create(localPath string) (io.WriteCloser, error) {
+	remote := s.remotePath(localPath)
+	return s.open(remote, sftpFlagWrite|sftpFlagCreate|sftpFlagTrunc, 0)
+}
+
+// Append implements ResumableStorage
+func (s *SFTPStorage) Append(localPath string) (io.WriteCloser, int64, error) {
+	remote := s.remotePath(localPath)
+	var size int64
+	if info, err := s.stat(remote); err == nil {
+		size = info.Size()
+	} else if !os.IsNotExist(err) {
+		return nil, 0, err
+	}
+	f, err := s.open(remote, sftpFlagWrite|sftpFlagCreate, size)
+	if err != nil {
+		return nil, 0, err
+	}
+	return f, size, nil
+}
+
+// Open implements Storage
+func (s *SFTPStorage) Open(localPath string) (io.ReadCloser, error) {
+	return s.open(s.remotePath(localPath), sftpFlagRead, 0)
+}
+
+// open opens a remote file positioned at offset
+func (s *SFTPStorage) open(remote string, flags uint32, offset int64) (*sftpFile, error) {
+	body := appendSFTPString(nil, remote)
+	body = binary.BigEndian.AppendUint32(body, flags)
+	if flags&sftpFlagCreate != 0 {
+		body = binary.BigEndian.AppendUint32(body, sftpAttrPermissions)
+		body = binary.BigEndian.AppendUint32(body, 0644)
+	} else {
+		body = binary.BigEndian.AppendUint32(body, 0)
+	}
+	p, err := s.call(sftpOpen, body)
+	if err != nil {
+		return nil, err
+	}
+	if p.typ != sftpHandle {
+		return nil, sftpStatusError(p, "open", remote)
+	}
+	d := sftpDecoder{b: p.data}
+	handle := d.str()
+	if d.err != nil {
+		return nil, fmt.Errorf("sftp open %s: %v", remote, d.err)
+	}
+	return &sftpFile{s: s, remote: remote, handle: handle, offset: uint64(offset)}, nil
+}
+
+// Rename implements Storage
+func (s *SFTPStorage) Rename(oldPath, newPath string) error {
+	oldRemote, newRemote := s.remotePath(oldPath), s.remotePath(newPath)
+	if s.posixRename {
+		body := appendSFTPString(nil, sftpPosixRenameExt)
+		body = appendSFTPString(body, oldRemote)
+		body = appendSFTPString(body, newRemote)
+		return s.callStatus("rename", oldRemote, sftpExtended, body)
+	}
+	// Plain SFTP v3 rename fails if the target exists
+	if err := s.callStatus("remove", newRemote, sftpRemove, appendSFTPString(nil, newRemote)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	body := appendSFTPString(nil, oldRemote)
+	body = appendSFTPString(body, newRemote)
+	return s.callStatus("rename", oldRemote, sftpRename, body)
+}
+
+// Remove implements Storage
+func (s *SFTPStorage) Remove(localPath string) error {
+	remote := s.remotePath(localPath)
+	return s.callStatus("remove", remote, sftpRemove, appendSFTPString(nil, remote))
+}
+
+// Close ends the SSH session
+func (s *SFTPStorage) Close() error {
+	s.stdin.Close()
+	done := make(chan error, 1)
+	go func() { done <- s.cmd.Wait() }()
+	var err error
+	select {
+	case err = <-done:
+	case <-time.After(10 * time.Second):
+		s.cmd.Process.Kill()
+		err = <-done
+	}
+	auditProcess(s.cfg.Host, AuditPurposeUpload, s.stdin.n.Load(), s.stdout.n.Load(), err)
+	return err
+}
+
+// TestSFTPConnection connects to the server and checks that the remote folder is writable
+func TestSFTPConnection(cfg SFTPConfig) error {
+	s, err := NewSFTPStorage(cfg, "")
+	if err != nil {
+		return err
+	}
+	defer s.Close()
+
+	remote := cfg.RemoteDir
+	if remote == "" {
+		remote = "."
+	}
+	info, err := s.stat(remote)
+	if err != nil {
+		return fmt.Errorf("remote folder not found: %s", remote)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a folder: %s", remote)
+	}
+
+	probe := path.Join(remote, fmt.Sprintf(".write-test-%d", time.Now().UnixNano()))
+	f, err := s.open(probe, sftpFlagWrite|sftpFlagCreate|sftpFlagTrunc, 0)
+	if err != nil {
+		return fmt.Errorf("remote folder is not writable: %v", err)
+	}
+	f.Close()
+	return s.callStatus("remove", probe, sftpRemove, appendSFTPString(nil, probe))
+}
+
+// sftpFile is an open remote file
+type sftpFile struct {
+	s        *SFTPStorage
+	remote   string
+	handle   string
+	offset   uint64
+	inflight []chan sftpPacket // Pipelined writes waiting for their status
+	err      error
+}
+
+// Write sends data in pipelined chunks, only waiting once too many are unacknowledged
+func (f *sftpFile) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 && f.err == nil {
+		n := len(p)
+		if n > sftpChunkSize {
+			n = sftpChunkSize
+		}
+		body := appendSFTPString(nil, f.handle)
+		body = binary.BigEndian.AppendUint64(body, f.offset)
+		body = appendSFTPString(body, string(p[:n]))
+		ch, err := f.s.send(sftpWrite, body)
+		if err != nil {
+			f.err = err
+			break
+		}
+		f.inflight = append(f.inflight, ch)
+		f.offset += uint64(n)
+		written += n
+		p = p[n:]
+
+		if len(f.inflight) >= sftpMaxInflight {
+			f.waitOne()
+		}
+	}
+	return written, f.err
+}
+
+// waitOne waits for the oldest pipelined write
+func (f *sftpFile) waitOne() {
+	ch := f.inflight[0]
+	f.inflight = f.inflight[1:]
+	p, err := f.s.wait(ch)
+	if err == nil {
+		err = sftpStatusError(p, "write", f.remote)
+	}
+	if err != nil && f.err == nil {
+		f.err = err
+	}
+}
+
+// Read reads the next chunk of the file
+func (f *sftpFile) Read(p []byte) (int, error) {
+	if len(p) > sftpChunkSize {
+		p = p[:sftpChunkSize]
+	}
+	body := appendSFTPString(nil, f.handle)
+	body = binary.BigEndian.AppendUint64(body, f.offset)
+	body = binary.BigEndian.AppendUint32(body, uint32(len(p)))
+	resp, err := f.s.call(sftpRead, body)
+	if err != nil {
+		return 0, err
+	}
+	if resp.typ != sftpData {
+		return 0, sftpStatusError(resp, "read", f.remote)
+	}
+	d := sftpDecoder{b: resp.data}
+	data := d.str()
+	if d.err != nil {
+		return 0, fmt.Errorf("sftp read %s: %v", f.remote, d.err)
+	}
+	n := copy(p, data)
+	f.offset += uint64(n)
+	return n, nil
+}
+
+// Close waits for all pending writes and closes the handle
+func (f *sftpFile) Close() error {
+	for len(f.inflight) > 0 {
+		f.waitOne()
+	}
+	err := f.s.callStatus("close", f.remote, sftpClose, appendSFTPString(nil, f.handle))
+	if f.err != nil {
+		return f.err
+	}
+	return err
+}
+
+// syncBuffer is a bytes.Buffer safe for a concurrent writer and reader
+type syncBuffer struct {
+	mu sync.Mutex
+	b  bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.b.String()
+}
+
+// appendSFTPString appends a length-prefixed string
+func appendSFTPString(b []byte, s string) []byte {
+	b = binary.BigEndian.AppendUint32(b, uint32(len(s)))
+	return append(b, s...)
+}
+
+// sftpDecoder reads fields from a packet payload, remembering the first error
+type sftpDecoder struct {
+	b   []byte
+	err error
+}
+
+func (d *sftpDecoder) u32() uint32 {
+	if len(d.b) < 4 {
+		d.fail()
+		return 0
+	}
+	v := binary.BigEndian.Uint32(d.b)
+	d.b = d.b[4:]
+	return v
+}
+
+func (d *sftpDecoder) u64() uint64 {
+	if len(d.b) < 8 {
+		d.fail()
+		return 0
+	}
+	v := binary.BigEndian.Uint64(d.b)
+	d.b = d.b[8:]
+	return v
+}
+
+func (d *sftpDecoder) str() string {
+	n := d.u32()
+	if uint32(len(d.b)) < n {
+		d.fail()
+		return ""
+	}
+	v := string(d.b[:n])
+	d.b = d.b[n:]
+	return v
+}
+
+func (d *sftpDecoder) fail() {
+	if d.err == nil {
+		d.err = fmt.Errorf("truncated SFTP packet")
+	}
+	d.b = nil
+}
+
+// attrs decodes a file attribute block
+func (d *sftpDecoder) attrs(name string) *sftpFileInfo {
+	info := &sftpFileInfo{name: name}
+	flags := d.u32()
+	if flags&sftpAttrSize != 0 {
+		info.size = int64(d.u64())
+	}
+	if flags&sftpAttrUIDGID != 0 {
+		d.u32()
+		d.u32()
+	}
+	if flags&sftpAttrPermissions != 0 {
+		info.mode = d.u32()
+	}
+	if flags&sftpAttrACModTime != 0 {
+		d.u32() // atime
+		info.modTime = time.Unix(int64(d.u32()), 0)
+	}
+	if flags&sftpAttrExtended != 0 {
+		for n := d.u32(); n > 0 && d.err == nil; n-- {
+			d.str()
+			d.str()
+		}
+	}
+	return info
+}
+
+// sftpFileInfo implements os.FileInfo for remote files
+type sftpFileInfo struct {
+	name    string
+	size    int64
+	mode    uint32 // POSIX mode bits
+	modTime time.Time
+}
+
+func (i *sftpFileInfo) Name() string       { return i.name }
+func (i *sftpFileInfo) Size() int64        { return i.size }
+func (i *sftpFileInfo) ModTime() time.Time { return i.modTime }
+func (i *sftpFileInfo) IsDir() bool        { return i.mode&0170000 == 0040000 }
+func (i *sftpFileInfo) Sys() any           { return nil }
+
+func (i *sftpFileInfo) Mode() fs.FileMode {
+	mode := fs.FileMode(i.mode & 0777)
+	if i.IsDir() {
+		mode |= fs.ModeDir
+	}
+	return mode
+}
@@ -0,0 +1,130 @@
+package backend
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+)
+
+// File size limits and job quota
+//
+// A batch of long videos can quietly take hundreds of gigabytes. MaxFileBytes skips files larger
+// than the limit: the size is taken from the response headers before anything is written, and a
+// response without a size is cut off once it passes the limit, so no oversized file is ever
+// saved. They count as skipped, not failed, so retries don't fetch them again. MaxJobBytes stops
+// the job once the files it saved add up to the quota: like a stopped job, files that weren't
+// started stay in the queue to be resumed later. Files already in flight still finish, so a job
+// can end slightly above its quota.
+
+// ErrFileTooLarge is wrapped by the error of downloads above the per-file size limit
+var ErrFileTooLarge = errors.New("file_too_large")
+
+// ErrJobQuotaReached is wrapped by the error of jobs stopped at their download quota
+var ErrJobQuotaReached = errors.New("download_quota_reached")
+
+// QuotaError is returned when a job was stopped because it downloaded its quota
+type QuotaError struct {
+	Quota      int64
+	Downloaded int64
+}
+
+func (e *QuotaError) Error() string {
+	return fmt.Sprintf("%v: %s downloaded, the quota of %s per download was reached - the rest stays in the queue",
+		ErrJobQuotaReached, formatBytes(e.Downloaded), formatBytes(e.Quota))
+}
+
+func (e *QuotaError) Unwrap() error {
+	return ErrJobQuotaReached
+}
+
+// sizeLimits enforces the file size limit and the quota of a download job
+// A nil *sizeLimits means no limits
+type sizeLimits struct {
+	maxFile    int64
+	quota      int64
+	stop       context.CancelCauseFunc
+	downloaded atomic.Int64
+}
+
+// newSizeLimits returns the limits of a job, or nil if none is set
+func newSizeLimits(opts DownloadOptions, stop context.CancelCauseFunc) *sizeLimits {
+	if opts.MaxFileBytes <= 0 && opts.MaxJobBytes <= 0 {
+		return nil
+	}
+	return &sizeLimits{maxFile: opts.MaxFileBytes, quota: opts.MaxJobBytes, stop: stop}
+}
+
+// wrap returns a client that refuses responses above the file size limit
+func (l *sizeLimits) wrap(client *http.Client) *http.Client {
+	if l == nil || l.maxFile <= 0 {
+		return client
+	}
+	wrapped := *client
+	transport := client.Transport
+	if transport == nil {
+		transport = http.DefaultTransport
+	}
+	wrapped.Transport = &sizeLimitTransport{next: transport, maxFile: l.maxFile}
+	return &wrapped
+}
+
+// added records a saved file and stops the job once the quota is reached
+func (l *sizeLimits) added(size int64) {
+	if l == nil || l.quota <= 0 {
+		return
+	}
+	if total := l.downloaded.Add(size); total >= l.quota {
+		l.stop(&QuotaError{Quota: l.quota, Downloaded: total})
+	}
+}
+
+// sizeLimitTransport fails responses larger than maxFile
+type sizeLimitTransport struct {
+	next    http.RoundTripper
+	maxFile int64
+}
+
+func (t *sizeLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.next.RoundTrip(req)
+	if err != nil || req.Method == http.MethodHead {
+		return resp, err
+	}
+	// Content-Length of a range response is the rest, the full size is in Content-Range
+	size := resp.ContentLength
+	if total := contentRangeTotal(resp.Header.Get("Content-Range")); total > 0 {
+		size = total
+	}
+	if size > t.maxFile {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s is above the limit of %s", ErrFileTooLarge, formatBytes(size), formatBytes(t.maxFile))
+	}
+	resp.Body = &limitedBody{ReadCloser: resp.Body, remaining: t.maxFile}
+	return resp, nil
+}
+
+// contentRangeTotal returns the full size from a Content-Range header, 0 if unknown
+func contentRangeTotal(header string) int64 {
+	var start, end, total int64
+	if _, err := fmt.Sscanf(header, "bytes %d-%d/%d", &start, &end, &total); err != nil {
+		return 0
+	}
+	return total
+}
+
+// limitedBody fails reading past the size limit of a response that didn't announce its size
+type limitedBody struct {
+	io.ReadCloser
+	remaining int64
+}
+
+func (b *limitedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	b.remaining -= int64(n)
+	if b.remaining < 0 {
+		return n, fmt.Errorf("%w: the file is above the size limit", ErrFileTooLarge)
+	}
+	return n, err
+}
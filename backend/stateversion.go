@@ -0,0 +1,190 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// State format versions
+//
+// Stopped jobs are resumed from their queue files, which may have been written by an older
+// version of the app. Job files carry a format version (files without one are version 1) and are
+// upgraded step by step when loaded; a job written by a newer version is refused rather than
+// resumed with settings this version doesn't understand. Since version 2 a job pins the settings
+// that decide file names, so an update that changes a default can't rename the files of a
+// resumed job - which would download everything again and orphan its partial .part files.
+// The database is only ever extended (new columns and tables with defaults), so older versions
+// keep working with it; its schema version is recorded in user_version for the diagnostics.
+//
+// CheckStateCompatibility resumes a job in the oldest format and is part of the diagnostics.
+
+// queueFormatVersion is the format version of queue job files written by this version
+const queueFormatVersion = 2
+
+// dbSchemaVersion is the database schema version written by this version
+const dbSchemaVersion = 1
+
+// legacyFilenameTemplate and legacyCollisionSuffix are the defaults version 1 jobs were planned with
+const (
+	legacyFilenameTemplate = "{username}_{timestamp}_{tweet_id}_{index}"
+	legacyCollisionSuffix  = CollisionCounter
+)
+
+// queueUpgrades upgrade a raw job file from the version at its index + 1 to the next one
+var queueUpgrades = []func(job map[string]any) error{
+	upgradeQueueV1,
+}
+
+// upgradeQueueV1 pins the file name settings version 1 used when they weren't set
+func upgradeQueueV1(job map[string]any) error {
+	options, _ := job["options"].(map[string]any)
+	if options == nil {
+		options = make(map[string]any)
+		job["options"] = options
+	}
+	if template, _ := options["filename_template"].(string); template == "" {
+		options["filename_template"] = legacyFilenameTemplate
+	}
+	if suffix, _ := options["collision_suffix"].(string); suffix == "" {
+		options["collision_suffix"] = legacyCollisionSuffix
+	}
+	return nil
+}
+
+// pinQueueOptions fills in the defaults that decide file names, so later versions plan the same paths
+func pinQueueOptions(opts DownloadOptions) DownloadOptions {
+	if opts.FilenameTemplate == "" {
+		opts.FilenameTemplate = DefaultFilenameTemplate
+	}
+	if opts.CollisionSuffix == "" {
+		opts.CollisionSuffix = CollisionCounter
+	}
+	return opts
+}
+
+// decodeQueueJob reads a job file of any supported version and upgrades it to the current one
+func decodeQueueJob(data []byte) (QueueJob, error) {
+	var job QueueJob
+	var raw map[string]any
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return job, fmt.Errorf("invalid queue job file: %v", err)
+	}
+
+	version := 1
+	if v, ok := raw["version"].(float64); ok && v >= 1 {
+		version = int(v)
+	}
+	if version > queueFormatVersion {
+		return job, fmt.Errorf("queue was saved by a newer version of the app (format %d, this version reads up to %d), update the app to resume it", version, queueFormatVersion)
+	}
+	for ; version < queueFormatVersion; version++ {
+		if err := queueUpgrades[version-1](raw); err != nil {
+			return job, fmt.Errorf("failed to upgrade queue from format %d: %v", version, err)
+		}
+	}
+	raw["version"] = queueFormatVersion
+
+	upgraded, err := json.Marshal(raw)
+	if err != nil {
+		return job, err
+	}
+	if err := json.Unmarshal(upgraded, &job); err != nil {
+		return job, fmt.Errorf("invalid queue job file: %v", err)
+	}
+	job.Options = restoreStorageSecrets(job.Options)
+	return job, nil
+}
+
+// Version 1 queue as written before format versions, used by CheckStateCompatibility
+const (
+	compatQueueV1Job = `{
+  "id": "compat_1700000000000000000",
+  "username": "compat",
+  "output_dir": "",
+  "options": {
+    "filter": {"orientation": "", "min_aspect_ratio": 0},
+    "sanitize_paths": false,
+    "queue_id": "compat_1700000000000000000",
+    "conflict": "skip",
+    "order": "newest_first",
+    "grace_minutes": 0,
+    "video_preview": "",
+    "min_free_bytes": 1073741824,
+    "max_archive_bytes": 0,
+    "archive_cap_policy": ""
+  },
+  "created_at": "2024-01-01T12:00:00Z",
+  "updated_at": "2024-01-01T12:30:00Z",
+  "pending": 2
+}`
+	compatQueueV1Items = `{"url":"https://pbs.twimg.com/media/Compat1.jpg?format=jpg&name=orig","date":"2024-01-01T12:00:00","tweet_id":1741832460000000000,"type":"photo","content":"first"}
+{"url":"https://video.twimg.com/ext_tw_video/1/pu/vid/1280x720/compat.mp4","date":"2024-01-01T11:00:00","tweet_id":1741817360000000000,"type":"video"}
+`
+)
+
+// compatQueueV1Files are the files the version 1 queue must resume with
+var compatQueueV1Files = []string{
+	"compat_20240101_120000_1741832460000000000_01.jpg",
+	"compat_20240101_110000_1741817360000000000_01.mp4",
+}
+
+// CheckStateCompatibility resumes a version 1 queue in a temporary folder with this version and
+// checks that it plans the same files
+func CheckStateCompatibility() error {
+	dir, err := os.MkdirTemp("", "txmbd-compat-*")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	jobPath := filepath.Join(dir, "compat.json")
+	itemsPath := filepath.Join(dir, "compat.jsonl")
+	if err := os.WriteFile(jobPath, []byte(compatQueueV1Job), 0644); err != nil {
+		return err
+	}
+	if err := os.WriteFile(itemsPath, []byte(compatQueueV1Items), 0644); err != nil {
+		return err
+	}
+
+	job, items, lineErrors, err := loadQueueFiles(jobPath, itemsPath)
+	if err != nil {
+		return fmt.Errorf("version 1 queue doesn't load: %v", err)
+	}
+	if len(lineErrors) > 0 {
+		return fmt.Errorf("version 1 queue line %d rejected: %s", lineErrors[0].Line, lineErrors[0].Message)
+	}
+	if job.Version != queueFormatVersion || job.Options.MinFreeBytes != 1<<30 || job.Options.Order != "newest_first" {
+		return fmt.Errorf("version 1 queue settings changed when upgraded")
+	}
+	if len(items) != len(compatQueueV1Files) {
+		return fmt.Errorf("version 1 queue resumed with %d of %d items", len(items), len(compatQueueV1Files))
+	}
+
+	tasks, _ := planDownloadTasks(items, dir, job.Username, job.Options)
+	for i, task := range tasks {
+		if name := filepath.Base(task.outputPath); name != compatQueueV1Files[i] {
+			return fmt.Errorf("version 1 queue resumes to %s instead of %s", name, compatQueueV1Files[i])
+		}
+		if !strings.HasPrefix(task.outputPath, filepath.Join(dir, job.Username)) {
+			return fmt.Errorf("version 1 queue resumes outside the account folder: %s", task.outputPath)
+		}
+	}
+	return nil
+}
+
+// checkStateCompat reports whether queues of older versions still resume
+func checkStateCompat() DiagnosticCheck {
+	check := DiagnosticCheck{Name: "state_compat"}
+	if err := CheckStateCompatibility(); err != nil {
+		check.Status = DiagnosticError
+		check.Detail = err.Error()
+		check.Hint = "Stopped downloads saved by an older version may not resume correctly, please report this"
+		return check
+	}
+	check.Status = DiagnosticOK
+	check.Detail = fmt.Sprintf("queue format %d, database schema %d, older queues resume", queueFormatVersion, dbSchemaVersion)
+	return check
+}
@@ -0,0 +1,141 @@
+package backend
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// useQueueFixture copies a queue from testdata/queue into a temporary data folder
+func useQueueFixture(t *testing.T, id string) {
+	t.Helper()
+	t.Setenv(dataDirEnv, t.TempDir())
+	if err := os.MkdirAll(GetQueueDir(), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for _, ext := range []string{".json", ".jsonl"} {
+		data, err := os.ReadFile(filepath.Join("testdata", "queue", id+ext))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(GetQueueDir(), id+ext), data, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestLoadQueueUpgradesOlderFormats(t *testing.T) {
+	tests := []struct {
+		id        string
+		template  string
+		collision string
+		order     string
+		minFree   int64
+		files     []string
+	}{
+		{
+			// Written before format versions
+			id:        "acct_v0",
+			template:  legacyFilenameTemplate,
+			collision: legacyCollisionSuffix,
+			order:     "newest_first",
+			minFree:   1 << 30,
+			files: []string{
+				"acct_20240101_120000_1741832460000000000_01.jpg",
+				"acct_20240101_110000_1741817360000000000_01.mp4",
+			},
+		},
+		{
+			// Version 1 with a custom template, which the upgrade must keep
+			id:        "acct_v1",
+			template:  "{tweet_id}_{index}",
+			collision: legacyCollisionSuffix,
+			order:     "oldest_first",
+			files: []string{
+				"1796817360000000000_01.jpg",
+				"1796817360000000000_02.png",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			useQueueFixture(t, tt.id)
+
+			job, items, lineErrors, err := LoadQueue(tt.id)
+			if err != nil {
+				t.Fatalf("LoadQueue: %v", err)
+			}
+			if len(lineErrors) > 0 {
+				t.Fatalf("line %d rejected: %s", lineErrors[0].Line, lineErrors[0].Message)
+			}
+			if job.Version != queueFormatVersion {
+				t.Errorf("version = %d, want %d", job.Version, queueFormatVersion)
+			}
+			if job.Options.FilenameTemplate != tt.template {
+				t.Errorf("filename template = %q, want %q", job.Options.FilenameTemplate, tt.template)
+			}
+			if job.Options.CollisionSuffix != tt.collision {
+				t.Errorf("collision suffix = %q, want %q", job.Options.CollisionSuffix, tt.collision)
+			}
+			if job.Options.Order != tt.order || job.Options.MinFreeBytes != tt.minFree {
+				t.Errorf("options changed: order %q, min free %d", job.Options.Order, job.Options.MinFreeBytes)
+			}
+			if job.Pending != len(items) || len(items) != len(tt.files) {
+				t.Fatalf("resumed with %d items (pending %d), want %d", len(items), job.Pending, len(tt.files))
+			}
+
+			// The resumed job must plan the files the old version was downloading
+			outputDir := t.TempDir()
+			tasks, _ := planDownloadTasks(items, outputDir, job.Username, job.Options)
+			var names []string
+			for _, task := range tasks {
+				names = append(names, filepath.Base(task.outputPath))
+				if !strings.HasPrefix(task.outputPath, filepath.Join(outputDir, job.Username)) {
+					t.Errorf("%s is outside the account folder", task.outputPath)
+				}
+			}
+			if strings.Join(names, ",") != strings.Join(tt.files, ",") {
+				t.Errorf("planned %v, want %v", names, tt.files)
+			}
+		})
+	}
+}
+
+func TestLoadQueueResavesCurrentFormat(t *testing.T) {
+	useQueueFixture(t, "acct_v0")
+
+	job, items, _, err := LoadQueue("acct_v0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveQueue(job, items); err != nil {
+		t.Fatal(err)
+	}
+	again, againItems, _, err := LoadQueue("acct_v0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if again.Version != queueFormatVersion || again.Options.FilenameTemplate != job.Options.FilenameTemplate {
+		t.Errorf("resaved job changed: version %d, template %q", again.Version, again.Options.FilenameTemplate)
+	}
+	if len(againItems) != len(items) {
+		t.Errorf("resaved job has %d items, want %d", len(againItems), len(items))
+	}
+}
+
+func TestLoadQueueRefusesNewerFormat(t *testing.T) {
+	useQueueFixture(t, "acct_v99")
+
+	_, _, _, err := LoadQueue("acct_v99")
+	if err == nil || !strings.Contains(err.Error(), "newer version") {
+		t.Fatalf("err = %v, want a newer version error", err)
+	}
+}
+
+func TestCheckStateCompatibility(t *testing.T) {
+	if err := CheckStateCompatibility(); err != nil {
+		t.Fatal(err)
+	}
+}
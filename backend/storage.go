@@ -0,0 +1,266 @@
+package backend
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Storage backends: the download engine writes through a Storage, so other targets can be plugged
+// in. Post-processing that needs a real file only runs when the backend is local.
+
+// Storage is where the download engine saves files
+type Storage interface {
+	// Stat returns the file info of path, or an error satisfying os.IsNotExist if it doesn't exist
+	Stat(path string) (os.FileInfo, error)
+	// MkdirAll creates a directory and its parents
+	MkdirAll(path string) error
+	// Create creates or truncates a file for writing
+	Create(path string) (io.WriteCloser, error)
+	// Open opens a file for reading
+	Open(path string) (io.ReadCloser, error)
+	// Rename moves a file, replacing the target if it exists
+	Rename(oldPath, newPath string) error
+	// Remove deletes a file
+	Remove(path string) error
+}
+
+// ResumableStorage is a Storage that can continue partially written files
+// Downloads to it go through a .part file that is resumed with a range request after an interruption
+type ResumableStorage interface {
+	Storage
+	// Append opens a file for writing at its end, creating it if needed, and returns its current size
+	Append(path string) (io.WriteCloser, int64, error)
+}
+
+// LocalStorage saves files on the local filesystem
+type LocalStorage struct{}
+
+// Stat implements Storage
+func (LocalStorage) Stat(path string) (os.FileInfo, error) {
+	return os.Stat(path)
+}
+
+// MkdirAll implements Storage
+func (LocalStorage) MkdirAll(path string) error {
+	return os.MkdirAll(path, 0755)
+}
+
+// Create implements Storage
+func (LocalStorage) Create(path string) (io.WriteCloser, error) {
+	return os.Create(path)
+}
+
+// Append implements ResumableStorage
+func (LocalStorage) Append(path string) (io.WriteCloser, int64, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0644)
+	if err != nil {
+		return nil, 0, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, 0, err
+	}
+	return f, info.Size(), nil
+}
+
+// Open implements Storage
+func (LocalStorage) Open(path string) (io.ReadCloser, error) {
+	return os.Open(path)
+}
+
+// Rename implements Storage
+func (LocalStorage) Rename(oldPath, newPath string) error {
+	return os.Rename(oldPath, newPath)
+}
+
+// Remove implements Storage
+func (LocalStorage) Remove(path string) error {
+	return os.Remove(path)
+}
+
+// storage returns the batch's storage backend, the local filesystem by default
+func (o DownloadOptions) storage() Storage {
+	if o.Storage == nil {
+		return LocalStorage{}
+	}
+	return o.Storage
+}
+
+// localOutput reports whether the batch saves to the local filesystem
+func (o DownloadOptions) localOutput() bool {
+	if o.Archive != "" {
+		return false
+	}
+	if o.Storage != nil {
+		return isLocalStorage(o.Storage)
+	}
+	return !o.RemoteOutput()
+}
+
+// RemoteOutput reports whether the batch saves to a remote target instead of the output folder
+func (o DownloadOptions) RemoteOutput() bool {
+	return (o.SFTP != nil && o.SFTP.Host != "") || (o.S3 != nil && o.S3.Bucket != "") || (o.WebDAV != nil && o.WebDAV.URL != "")
+}
+
+// openStorage connects the remote target configured in opts (SFTP, S3 or WebDAV), if any, and wraps it in archives
+// The returned options use the connection; close finishes the archives and releases it when the job is done
+func openStorage(opts DownloadOptions, outputDir string) (DownloadOptions, func(), error) {
+	closeRemote := func() {}
+	switch {
+	case opts.Storage != nil:
+	case opts.SFTP != nil && opts.SFTP.Host != "":
+		remote, err := NewSFTPStorage(*opts.SFTP, outputDir)
+		if err != nil {
+			return opts, nil, err
+		}
+		opts.Storage = remote
+		closeRemote = func() { remote.Close() }
+	case opts.S3 != nil && opts.S3.Bucket != "":
+		remote, err := NewS3Storage(*opts.S3, outputDir)
+		if err != nil {
+			return opts, nil, err
+		}
+		opts.Storage = remote
+	case opts.WebDAV != nil && opts.WebDAV.URL != "":
+		remote, err := NewWebDAVStorage(*opts.WebDAV, outputDir)
+		if err != nil {
+			return opts, nil, err
+		}
+		opts.Storage = remote
+	}
+	if opts.Archive == "" {
+		return opts, closeRemote, nil
+	}
+
+	archive, err := NewArchiveStorage(opts.storage(), outputDir, opts.Archive)
+	if err != nil {
+		closeRemote()
+		return opts, nil, err
+	}
+	opts.Storage = archive
+	return opts, func() {
+		if err := archive.Close(); err != nil {
+			fmt.Printf("Warning: %v\n", err)
+		}
+		closeRemote()
+	}, nil
+}
+
+// isLocalStorage reports whether files saved to store are regular local files
+func isLocalStorage(store Storage) bool {
+	_, ok := store.(LocalStorage)
+	return ok
+}
+
+// writeStorageFile writes data to path in store, removing the partial file on failure
+func writeStorageFile(store Storage, path string, r io.Reader) error {
+	out, err := store.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, r); err != nil {
+		out.Close()
+		store.Remove(path)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		store.Remove(path)
+		return fmt.Errorf("failed to save %s: %v", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// storageExists reports whether path exists in store
+func storageExists(store Storage, path string) bool {
+	_, err := store.Stat(path)
+	return err == nil
+}
+
+// storageSHA256 calculates the SHA256 hash of a file in store
+func storageSHA256(store Storage, path string) (string, error) {
+	file, err := store.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// freeStoragePath appends _2, _3, ... before the extension until no file exists at the path in store
+func freeStoragePath(store Storage, path string) string {
+	ext := filepath.Ext(path)
+	base := strings.TrimSuffix(path, ext)
+	for n := 2; ; n++ {
+		candidate := fmt.Sprintf("%s_%d%s", base, n, ext)
+		if _, err := store.Stat(candidate); os.IsNotExist(err) {
+			return candidate
+		}
+	}
+}
+
+// Retries of requests to remote HTTP targets (S3, WebDAV)
+const (
+	remoteRetries    = 3
+	remoteRetryDelay = 2 * time.Second
+)
+
+// doRemote sends the request built by newRequest, retrying network errors, 429 and 5xx responses
+// newRequest is called for every attempt so the body can be sent again
+func doRemote(client *http.Client, newRequest func() (*http.Request, error)) (*http.Response, error) {
+	var lastErr error
+	for attempt := 0; attempt <= remoteRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * remoteRetryDelay)
+		}
+		req, err := newRequest()
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			lastErr = fmt.Errorf("%s %s: %s", req.Method, req.URL.Redacted(), resp.Status)
+			continue
+		}
+		return resp, nil
+	}
+	return nil, lastErr
+}
+
+// remoteKey maps a path planned below the local output folder to a slash-separated path below root
+func remoteKey(localRoot, root, localPath string) string {
+	rel, err := filepath.Rel(localRoot, localPath)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		rel = filepath.Base(localPath)
+	}
+	rel = filepath.ToSlash(rel)
+	if rel == "." {
+		rel = ""
+	}
+	root = strings.Trim(root, "/")
+	if root == "" {
+		return rel
+	}
+	if rel == "" {
+		return root
+	}
+	return root + "/" + rel
+}
@@ -0,0 +1,298 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// The extractor is run with --ndjson and writes one record per line as soon as an entry is fetched:
+//
+//	{"type": "media", "data": {...}}
+//	{"type": "metadata", "data": {...}}
+//	{"type": "cursor", "data": "..."}
+//	{"type": "end", "data": {"total": n, "completed": b, "cursor": "..."}}
+//
+// Account lists (following, List members) have a user record per account instead of media and
+// metadata:
+//
+//	{"type": "user", "data": {...}}
+//
+// Records are decoded from the process stdout as a stream and converted immediately, so large
+// accounts never have to be held in memory as raw JSON. If the extractor dies mid-run, everything
+// received before the crash is still returned as a partial result with the last cursor seen.
+
+// errNoJSON is returned when the extractor output contains no records
+var errNoJSON = errors.New("no JSON records in output")
+
+// cliRecord is a single NDJSON record from the extractor
+type cliRecord struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+// cliEndRecord is the data of the final "end" record
+type cliEndRecord struct {
+	Total     int     `json:"total"`
+	Completed bool    `json:"completed"`
+	Cursor    *string `json:"cursor"`
+}
+
+// PartialResultError is returned with a partial response when the extractor failed mid-run
+type PartialResultError struct {
+	Received int    // Media and metadata entries received before the failure
+	Message  string // Extractor error
+}
+
+func (e *PartialResultError) Error() string {
+	return e.Message
+}
+
+// cliStreamHandler receives entries as they're decoded from the extractor output
+type cliStreamHandler struct {
+	onMedia    func(CLIMediaItem)
+	onMetadata func(TweetMetadata)
+	onCursor   func(string) // Cursor of the next page, after each page
+	onUser     func(UserInfo)
+}
+
+// cliStreamResult is the outcome of decoding an extractor output stream
+type cliStreamResult struct {
+	response CLIResponse // Media and Metadata are left empty - entries go to the handler instead
+	received int         // Media, metadata and user entries received
+	ended    bool        // The "end" record was received
+}
+
+// decodeCLIStream decodes extractor NDJSON output line by line
+// Lines that aren't valid records (info messages, a line cut off by a crash) are skipped
+func decodeCLIStream(r io.Reader, h cliStreamHandler) (cliStreamResult, error) {
+	var result cliStreamResult
+	br := bufio.NewReaderSize(r, 64*1024)
+	records := 0
+
+	for {
+		line, readErr := br.ReadBytes('\n')
+		line = bytes.TrimSpace(line)
+
+		var rec cliRecord
+		if len(line) > 0 && line[0] == '{' && json.Unmarshal(line, &rec) == nil {
+			records++
+			switch rec.Type {
+			case "media":
+				var item CLIMediaItem
+				if err := json.Unmarshal(rec.Data, &item); err == nil {
+					result.received++
+					if h.onMedia != nil {
+						h.onMedia(item)
+					}
+				}
+			case "metadata":
+				var meta TweetMetadata
+				if err := json.Unmarshal(rec.Data, &meta); err == nil {
+					result.received++
+					if h.onMetadata != nil {
+						h.onMetadata(meta)
+					}
+				}
+			case "user":
+				var user UserInfo
+				if err := json.Unmarshal(rec.Data, &user); err == nil {
+					result.received++
+					if h.onUser != nil {
+						h.onUser(user)
+					}
+				}
+			case "cursor":
+				var cursor string
+				if err := json.Unmarshal(rec.Data, &cursor); err == nil && cursor != "" {
+					result.response.Cursor = cursor
+					if h.onCursor != nil {
+						h.onCursor(cursor)
+					}
+				}
+			case "end":
+				var end cliEndRecord
+				if err := json.Unmarshal(rec.Data, &end); err == nil {
+					result.ended = true
+					result.response.Total = end.Total
+					result.response.Completed = end.Completed
+					if end.Cursor != nil && *end.Cursor != "" {
+						result.response.Cursor = *end.Cursor
+					}
+				}
+			}
+		}
+
+		if readErr == io.EOF {
+			break
+		}
+		if readErr != nil {
+			return result, readErr
+		}
+	}
+
+	if records == 0 {
+		return result, errNoJSON
+	}
+	return result, nil
+}
+
+// runExtractorStream runs the extractor and streams its JSON output to the handler
+func runExtractorStream(exePath string, args []string, username string, h cliStreamHandler) (CLIResponse, error) {
+	// Execute command with UTF-8 encoding
+	cmd := exec.Command(exePath, args...)
+	cmd.Env = append(os.Environ(),
+		"PYTHONIOENCODING=utf-8",
+		"PYTHONUTF8=1",
+	)
+	hideWindow(cmd) // Hide console window on Windows
+
+	var stderr bytes.Buffer
+	watchdog := newExtractorWatchdog(GetSettings())
+	cmd.Stderr = watchdog.writer(&stderr)
+	cmd.WaitDelay = extractorWaitDelay
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return CLIResponse{}, fmt.Errorf("failed to start extractor: %v", err)
+	}
+	if err := cmd.Start(); err != nil {
+		return CLIResponse{}, fmt.Errorf("failed to start extractor: %v", err)
+	}
+
+	watchdog.start(cmd, stdout)
+	output := &countingReader{ReadCloser: stdout}
+	result, decodeErr := decodeCLIStream(watchdog.reader(output), h)
+	// Drain the rest so the process never blocks on a full pipe
+	io.Copy(io.Discard, output)
+	waitErr := cmd.Wait()
+	watchdog.finish()
+	rememberExtractorRun(args, stderr.String())
+	auditProcess("x.com", AuditPurposeTwitter, 0, output.n.Load(), waitErr)
+	resp := result.response
+
+	if waitErr != nil || (decodeErr == nil && !result.ended) {
+		errorMsg := parseExtractorError(stderr.String(), username)
+		if code := watchdog.trippedCode(); code != "" {
+			errorMsg = fmt.Sprintf("%s: %s (%s)", code, messageCatalog["en"][code], watchdog.limit(code))
+		} else if waitErr == nil {
+			errorMsg = ErrCodeIncompleteOutput + ": " + messageCatalog["en"][ErrCodeIncompleteOutput]
+		}
+		// A run cut short while rate limited keeps the reset time, see ratelimit.go
+		if RetryAtFromMessage(errorMsg).IsZero() {
+			if retryAt := ParseRetryAt(stderr.String(), time.Now()); !retryAt.IsZero() {
+				errorMsg += rateLimitHint(retryAt)
+			}
+		}
+		if result.received > 0 {
+			// Keep what was fetched - the caller can resume from the last cursor
+			resp.Completed = false
+			return resp, &PartialResultError{Received: result.received, Message: errorMsg}
+		}
+		return resp, fmt.Errorf("%s", errorMsg)
+	}
+
+	if decodeErr == errNoJSON {
+		outputStr := stderr.String()
+		if strings.TrimSpace(outputStr) == "" {
+			return resp, fmt.Errorf("%s: %s", ErrCodeEmptyResponse, messageCatalog["en"][ErrCodeEmptyResponse])
+		}
+		return resp, fmt.Errorf("%s: %s. Raw output: %s", ErrCodeParseError, messageCatalog["en"][ErrCodeParseError], outputStr)
+	}
+	if decodeErr != nil {
+		return resp, fmt.Errorf("%s: %s: %v", ErrCodeJSONError, messageCatalog["en"][ErrCodeJSONError], decodeErr)
+	}
+
+	return resp, nil
+}
+
+// timelineCollector converts streamed extractor entries into timeline entries
+// Metadata arrives before the media of the same tweet, so text entries are only decided in finish()
+type timelineCollector struct {
+	filter       TimelineFilter
+	includeMedia bool // Add media entries
+	includeText  bool // Add metadata of tweets without media as text entries
+	textFallback bool // Add all metadata as text entries if the response has no media at all
+
+	timeline      []TimelineEntry
+	textCandidate []TweetMetadata
+	mediaTweetIDs map[int64]int // Media received per tweet
+	mediaCount    int
+	firstUser     *UserInfo
+	firstMeta     *TweetMetadata
+	sensitive     []TweetMetadata // Tweets flagged sensitive, see restricted
+	tweets        int             // Metadata entries received, for the latency history
+}
+
+// newTimelineCollector returns an empty collector
+func newTimelineCollector(filter TimelineFilter) *timelineCollector {
+	return &timelineCollector{
+		filter:        filter,
+		includeMedia:  true,
+		timeline:      make([]TimelineEntry, 0),
+		mediaTweetIDs: make(map[int64]int),
+	}
+}
+
+// handler returns the stream handler feeding this collector
+func (c *timelineCollector) handler() cliStreamHandler {
+	return cliStreamHandler{onMedia: c.addMedia, onMetadata: c.addMetadata}
+}
+
+// addMedia handles a single media entry
+func (c *timelineCollector) addMedia(media CLIMediaItem) {
+	c.mediaCount++
+	c.mediaTweetIDs[int64(media.TweetID)]++
+	if media.Num == 0 {
+		media.Num = c.mediaTweetIDs[int64(media.TweetID)]
+	}
+	if c.firstUser == nil {
+		user := media.User
+		c.firstUser = &user
+	}
+	if !c.includeText {
+		c.textCandidate = nil // Fallback no longer applies once there's media
+	}
+	if c.includeMedia && c.filter.MatchMedia(media) {
+		c.timeline = append(c.timeline, convertToTimelineEntry(media))
+	}
+}
+
+// addMetadata handles a single tweet metadata entry
+func (c *timelineCollector) addMetadata(meta TweetMetadata) {
+	c.tweets++
+	if c.firstMeta == nil {
+		m := meta
+		c.firstMeta = &m
+	}
+	if !c.filter.MatchMetadata(meta) {
+		return
+	}
+	if meta.Sensitive {
+		c.sensitive = append(c.sensitive, meta)
+	}
+	if c.includeText || (c.textFallback && c.mediaCount == 0) {
+		c.textCandidate = append(c.textCandidate, meta)
+	}
+}
+
+// finish adds the text entries and returns the timeline (media entries first)
+func (c *timelineCollector) finish() []TimelineEntry {
+	for _, meta := range c.textCandidate {
+		if c.includeText && c.mediaTweetIDs[int64(meta.TweetID)] > 0 {
+			continue
+		}
+		if !c.includeText && c.mediaCount > 0 {
+			break
+		}
+		c.timeline = append(c.timeline, convertMetadataToTimelineEntry(meta))
+	}
+	c.textCandidate = nil
+	return c.timeline
+}
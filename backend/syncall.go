@@ -0,0 +1,342 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Sync all accounts
+//
+// Brings the whole library up to date in one call: every account in the database is fetched
+// again, the new timeline is merged into the stored one and whatever isn't in the account's
+// archive folder yet is downloaded (existing files are skipped by the download manager).
+// Accounts whose last fetch didn't complete continue from their stored cursor. Accounts run one
+// after another so only one extractor talks to the API at a time; when the rate limit is hit the
+// run waits for the reset (up to maxRateLimitWait) and retries the account once, and otherwise
+// stops and leaves the remaining accounts for the next run. A run can cap the media fetched per
+// account, and add accounts that aren't saved yet (see following.go).
+
+// Status of an account in a sync run
+const (
+	SyncStatusSynced      = "synced"       // Fetched completely and downloaded
+	SyncStatusPartial     = "partial"      // The fetch failed midway, what was fetched is saved and downloaded
+	SyncStatusFailed      = "failed"       // Nothing fetched or the download failed
+	SyncStatusRateLimited = "rate_limited" // Stopped by a rate limit that resets too late to wait for
+	SyncStatusStopped     = "stopped"      // The run was stopped (or paused for disk space) during this account
+	SyncStatusPending     = "pending"      // Not reached before the run stopped
+)
+
+// SyncAllRequest holds the settings of a sync run
+type SyncAllRequest struct {
+	AuthToken string          `json:"auth_token"`
+	OutputDir string          `json:"output_dir"` // For accounts without a known archive folder
+	Proxy     string          `json:"proxy,omitempty"`
+	Usernames []string        `json:"usernames,omitempty"` // Only sync these accounts, all if empty
+	AddNew    bool            `json:"add_new,omitempty"`   // Fetch usernames that aren't saved yet as new accounts
+	MaxMedia  int             `json:"max_media,omitempty"` // Media fetched per account, 0 = no cap; the rest follows on the next runs
+	Options   DownloadOptions `json:"options"`
+}
+
+// SyncAccountResult is the outcome of syncing one account
+type SyncAccountResult struct {
+	Username    string `json:"username"`
+	RenamedFrom string `json:"renamed_from,omitempty"` // Username the account was saved under, if it was renamed
+	MediaType   string `json:"media_type"`
+	Status      string `json:"status"`
+	Fetched     int    `json:"fetched"`   // Media in the fetched timeline
+	NewMedia    int    `json:"new_media"` // Media that weren't in the stored timeline
+	Downloaded  int    `json:"downloaded"`
+	Skipped     int    `json:"skipped"`
+	Failed      int    `json:"failed"`
+	OutputDir   string `json:"output_dir,omitempty"`
+	Error       string `json:"error,omitempty"`
+	RetryAt     string `json:"retry_at,omitempty"` // RFC 3339 time the rate limit resets
+}
+
+// SyncAllReport is the consolidated report of a sync run
+type SyncAllReport struct {
+	StartedAt   string              `json:"started_at"`
+	FinishedAt  string              `json:"finished_at"`
+	Accounts    []SyncAccountResult `json:"accounts"`
+	Synced      int                 `json:"synced"`
+	Partial     int                 `json:"partial"`
+	Failed      int                 `json:"failed"`
+	Pending     int                 `json:"pending"` // Accounts left for the next run
+	NewMedia    int                 `json:"new_media"`
+	Downloaded  int                 `json:"downloaded"`
+	Skipped     int                 `json:"skipped"`
+	FailedFiles int                 `json:"failed_files"`
+	RetryAt     string              `json:"retry_at,omitempty"` // Set if the run stopped at a rate limit
+}
+
+// SyncAccountCallback is called when an account is done (index is 0-based)
+type SyncAccountCallback func(index, total int, result SyncAccountResult)
+
+// SyncAll fetches every stored account again and downloads what's missing from its archive
+func SyncAll(ctx context.Context, req SyncAllRequest, onAccount SyncAccountCallback, progress ProgressCallback, itemStatus ItemStatusCallback) (*SyncAllReport, error) {
+	if req.AuthToken == "" {
+		return nil, fmt.Errorf("auth token is required")
+	}
+	accounts, err := GetAllAccounts()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list accounts: %v", err)
+	}
+	if len(req.Usernames) > 0 {
+		wanted := make(map[string]string) // Lowercase name to name
+		var order []string
+		for _, username := range req.Usernames {
+			username = strings.TrimPrefix(strings.TrimSpace(username), "@")
+			if key := strings.ToLower(username); username != "" && wanted[key] == "" {
+				wanted[key] = username
+				order = append(order, key)
+			}
+		}
+		var selected []AccountListItem
+		saved := make(map[string]bool)
+		for _, acc := range accounts {
+			if key := strings.ToLower(acc.Username); wanted[key] != "" {
+				selected = append(selected, acc)
+				saved[key] = true
+			}
+		}
+		if req.AddNew {
+			for _, key := range order {
+				if !saved[key] {
+					selected = append(selected, AccountListItem{Username: wanted[key], MediaType: "all"})
+				}
+			}
+		}
+		accounts = selected
+	}
+	if len(accounts) == 0 {
+		return nil, fmt.Errorf("no accounts to sync")
+	}
+
+	report := &SyncAllReport{
+		StartedAt: time.Now().Format(time.RFC3339),
+		Accounts:  make([]SyncAccountResult, 0, len(accounts)),
+	}
+	stopped := false
+	for i, acc := range accounts {
+		var result SyncAccountResult
+		if stopped {
+			result = SyncAccountResult{Username: acc.Username, MediaType: acc.MediaType, Status: SyncStatusPending}
+		} else {
+			result = syncAccount(ctx, acc, req, progress, itemStatus)
+			switch result.Status {
+			case SyncStatusRateLimited:
+				report.RetryAt = result.RetryAt
+				stopped = true
+			case SyncStatusStopped:
+				stopped = true
+			}
+		}
+
+		switch result.Status {
+		case SyncStatusSynced:
+			report.Synced++
+		case SyncStatusPartial:
+			report.Partial++
+		case SyncStatusFailed:
+			report.Failed++
+		default:
+			report.Pending++
+		}
+		report.NewMedia += result.NewMedia
+		report.Downloaded += result.Downloaded
+		report.Skipped += result.Skipped
+		report.FailedFiles += result.Failed
+		report.Accounts = append(report.Accounts, result)
+		if onAccount != nil {
+			onAccount(i, len(accounts), result)
+		}
+	}
+	report.FinishedAt = time.Now().Format(time.RFC3339)
+	return report, nil
+}
+
+// syncAccount fetches one account, saves the merged timeline and downloads its media
+func syncAccount(ctx context.Context, acc AccountListItem, req SyncAllRequest, progress ProgressCallback, itemStatus ItemStatusCallback) SyncAccountResult {
+	result := SyncAccountResult{Username: acc.Username, MediaType: acc.MediaType}
+	fail := func(err error) SyncAccountResult {
+		result.Status = SyncStatusFailed
+		result.Error = err.Error()
+		return result
+	}
+	if ctx.Err() != nil {
+		result.Status = SyncStatusStopped
+		return result
+	}
+
+	// New accounts (see SyncAllRequest.AddNew) have no ID and start from an empty timeline
+	var previous TwitterResponse
+	var cursor string
+	if acc.ID != 0 {
+		stored, err := GetAccountByID(acc.ID)
+		if err != nil {
+			return fail(err)
+		}
+		if err := json.Unmarshal([]byte(stored.ResponseJSON), &previous); err != nil {
+			return fail(fmt.Errorf("failed to parse stored timeline: %v", err))
+		}
+		if !stored.Completed {
+			cursor = stored.Cursor
+		}
+	}
+
+	timelineType := "timeline"
+	if acc.Username == "bookmarks" || acc.Username == "likes" {
+		timelineType = acc.Username
+	}
+	fetchReq := TimelineRequest{
+		Username:     acc.Username,
+		AuthToken:    req.AuthToken,
+		TimelineType: timelineType,
+		MediaType:    acc.MediaType,
+		BatchSize:    req.MaxMedia,
+		DateZone:     req.Options.DateZone,
+		Cursor:       cursor,
+	}
+
+	started := time.Now()
+	fetched, err := ExtractTimeline(fetchReq)
+	// Rate limited: wait for the reset if it's soon and retry once
+	if retryAt := shardRetryAt(fetched, err); !retryAt.IsZero() {
+		wait, ok := rateLimitWait(retryAt)
+		if !ok {
+			RecordExtraction(acc.Username, started, fetched, err)
+			result.Status = SyncStatusRateLimited
+			result.RetryAt = retryAt.UTC().Format(time.RFC3339)
+			if err != nil {
+				result.Error = err.Error()
+			} else {
+				result.Error = fetched.Error
+			}
+			return result
+		}
+		select {
+		case <-ctx.Done():
+			result.Status = SyncStatusStopped
+			return result
+		case <-time.After(wait):
+		}
+		fetched, err = ExtractTimeline(fetchReq)
+	}
+	// The old name is gone: follow the user ID to the new one, see renames.go
+	if err != nil && strings.Contains(err.Error(), "[hint="+HintAccountNotFound) {
+		if rename, _ := ResolveRename(acc.Username, req.AuthToken); rename != nil {
+			result.RenamedFrom = acc.Username
+			acc.Username, result.Username, fetchReq.Username = rename.NewUsername, rename.NewUsername, rename.NewUsername
+			if rename.ArchivePath != "" {
+				acc.ArchivePath = rename.ArchivePath
+			}
+			fetched, err = ExtractTimeline(fetchReq)
+		}
+	}
+	RecordExtraction(acc.Username, started, fetched, err)
+	if err != nil {
+		return fail(err)
+	}
+
+	merged := mergeResponses(acc.Username, []*TwitterResponse{fetched, &previous})
+	merged.Cursor = fetched.Cursor
+	merged.Completed = fetched.Completed
+	merged.Metadata.Cursor = merged.Cursor
+	merged.Metadata.Completed = merged.Completed
+	result.Fetched = len(fetched.Timeline)
+	result.NewMedia = len(merged.Timeline) - len(previous.Timeline)
+	if result.NewMedia < 0 {
+		result.NewMedia = 0
+	}
+
+	responseJSON, err := json.Marshal(merged)
+	if err != nil {
+		return fail(fmt.Errorf("failed to encode timeline: %v", err))
+	}
+	name := merged.AccountInfo.Nick
+	if name == "" {
+		name = acc.Name
+	}
+	if err := SaveAccountWithStatus(acc.Username, name, merged.AccountInfo.ProfileImage, merged.TotalURLs, string(responseJSON), acc.MediaType, merged.Cursor, merged.Completed); err != nil {
+		return fail(fmt.Errorf("failed to save timeline: %v", err))
+	}
+
+	// Download next to the existing archive if it's known
+	outputDir := req.OutputDir
+	if acc.ArchivePath != "" && !req.Options.RemoteOutput() {
+		outputDir = filepath.Dir(acc.ArchivePath)
+	}
+	if outputDir == "" {
+		outputDir = GetDefaultDownloadPath()
+	}
+	result.OutputDir = outputDir
+	if !req.Options.RemoteOutput() {
+		if err := CheckOutputWritable(outputDir); err != nil {
+			return fail(err)
+		}
+	}
+
+	opts := req.Options
+	opts.AuthToken = req.AuthToken
+	opts.QueueID = NewQueueID(acc.Username)
+	items := timelineMediaItems(merged.Timeline, acc.Username)
+	result.Downloaded, result.Skipped, result.Failed, err = DownloadMediaWithMetadataProgressAndStatus(items, outputDir, acc.Username, progress, itemStatus, ctx, req.Proxy, opts)
+	if err != nil {
+		if ctx.Err() != nil || errors.Is(err, ErrLowDiskSpace) || errors.Is(err, ErrJobQuotaReached) || errors.Is(err, ErrArchivePruneFailed) {
+			result.Status = SyncStatusStopped
+			result.Error = err.Error()
+			return result
+		}
+		return fail(err)
+	}
+	if !req.Options.RemoteOutput() {
+		SetArchivePath(acc.Username, filepath.Join(outputDir, acc.Username))
+	}
+
+	result.Status = SyncStatusSynced
+	if fetched.Partial {
+		result.Status = SyncStatusPartial
+		result.Error = fetched.Error
+	}
+	return result
+}
+
+// timelineMediaItems converts timeline entries to download items
+// For bookmarks and likes, each item is filed under its author
+func timelineMediaItems(timeline []TimelineEntry, username string) []MediaItem {
+	items := make([]MediaItem, 0, len(timeline))
+	for _, entry := range timeline {
+		author := username
+		if entry.AuthorUsername != "" {
+			author = entry.AuthorUsername
+		}
+		originalFilename := entry.OriginalFilename
+		if originalFilename == "" {
+			originalFilename = ExtractOriginalFilename(entry.URL)
+		}
+		items = append(items, MediaItem{
+			URL:              entry.URL,
+			Date:             entry.Date,
+			TweetID:          int64(entry.TweetID),
+			Type:             entry.Type,
+			Username:         author,
+			Content:          entry.Content,
+			OriginalFilename: originalFilename,
+			Width:            entry.Width,
+			Height:           entry.Height,
+			Engagement:       entry.FavoriteCount + entry.RetweetCount,
+			FavoriteCount:    entry.FavoriteCount,
+			RetweetCount:     entry.RetweetCount,
+			ReplyCount:       entry.ReplyCount,
+			ViewCount:        entry.ViewCount,
+			BookmarkCount:    entry.BookmarkCount,
+			MediaIndex:       entry.MediaIndex,
+		})
+	}
+	return items
+}
@@ -0,0 +1,371 @@
+package backend
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Tags and favorites
+//
+// Media of the library can be starred and tagged, to find and export a selection later instead of
+// digging through account folders. Labels are stored per media, keyed by the media's file name on
+// X (the original file name), which stays the same whatever file name template, folder or
+// username the archive has since. A media that loses its star and its last tag is forgotten.
+// Exports copy (or hard link) the downloaded files of the matching media into a folder, found in
+// the account folders like the metadata backfill finds them (see backfill.go).
+
+// maxTagLength keeps tags short enough for lists and folder names
+const maxTagLength = 64
+
+// MediaRef identifies a media of the library to label
+type MediaRef struct {
+	Username string `json:"username"` // Library account the media was fetched with (also bookmarks and likes)
+	Author   string `json:"author"`   // Author of the tweet if it isn't Username
+	TweetID  string `json:"tweet_id"`
+	URL      string `json:"url"`
+}
+
+// MediaLabels is the star and the tags of a media
+type MediaLabels struct {
+	MediaKey string   `json:"media_key"`
+	Username string   `json:"username"`
+	Author   string   `json:"author,omitempty"`
+	TweetID  string   `json:"tweet_id"`
+	URL      string   `json:"url"`
+	Starred  bool     `json:"starred"`
+	Tags     []string `json:"tags"`
+}
+
+// TagCount is a tag and the number of media that have it
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// LabelFilter selects labeled media; all set fields must match
+type LabelFilter struct {
+	Username string `json:"username"` // "" = every account
+	Tag      string `json:"tag"`      // "" = any tag
+	Starred  bool   `json:"starred"`  // Only starred media
+}
+
+// LabelExportResult describes an export of labeled media
+type LabelExportResult struct {
+	Folder   string `json:"folder"`
+	Exported int    `json:"exported"`
+	Skipped  int    `json:"skipped"`   // Files exported before
+	NotFound int    `json:"not_found"` // Media without a downloaded file
+}
+
+// mediaKey returns the key labels of a media are stored under
+func mediaKey(url string) string {
+	if name := ExtractOriginalFilename(url); name != "" {
+		return name
+	}
+	// Media without a recognizable file name on X, the URL without its query
+	if i := strings.Index(url, "?"); i >= 0 {
+		return url[:i]
+	}
+	return url
+}
+
+// normalizeTag trims a tag and folds its case and spaces, "" if nothing is left
+func normalizeTag(tag string) string {
+	tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+	if len([]rune(tag)) > maxTagLength {
+		tag = string([]rune(tag)[:maxTagLength])
+	}
+	return tag
+}
+
+// SetMediaStarred stars or unstars a media
+func SetMediaStarred(ref MediaRef, starred bool) error {
+	key, err := upsertMediaLabels(ref)
+	if err != nil {
+		return err
+	}
+	if _, err := db.Exec("UPDATE media_labels SET starred = ? WHERE media_key = ?", boolToInt(starred), key); err != nil {
+		return fmt.Errorf("failed to star media: %v", err)
+	}
+	forgetUnlabeledMedia(key)
+	return nil
+}
+
+// SetMediaTags replaces the tags of a media
+func SetMediaTags(ref MediaRef, tags []string) error {
+	key, err := upsertMediaLabels(ref)
+	if err != nil {
+		return err
+	}
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to tag media: %v", err)
+	}
+	if _, err := tx.Exec("DELETE FROM media_tags WHERE media_key = ?", key); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to tag media: %v", err)
+	}
+	for _, tag := range tags {
+		if tag = normalizeTag(tag); tag == "" {
+			continue
+		}
+		if _, err := tx.Exec("INSERT OR IGNORE INTO media_tags (media_key, tag) VALUES (?, ?)", key, tag); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to tag media: %v", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to tag media: %v", err)
+	}
+	forgetUnlabeledMedia(key)
+	return nil
+}
+
+// upsertMediaLabels makes sure a media has a row to label, returning its key
+func upsertMediaLabels(ref MediaRef) (string, error) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return "", err
+		}
+	}
+	if ref.URL == "" || cleanUsername(ref.Username) == "" {
+		return "", fmt.Errorf("media URL and username are required")
+	}
+	author := cleanUsername(ref.Author)
+	if strings.EqualFold(author, ref.Username) {
+		author = ""
+	}
+	key := mediaKey(ref.URL)
+	_, err := db.Exec(`
+		INSERT INTO media_labels (media_key, username, author, tweet_id, url, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+		ON CONFLICT(media_key) DO UPDATE SET updated_at = excluded.updated_at
+	`, key, cleanUsername(ref.Username), author, ref.TweetID, ref.URL, time.Now().Format(time.RFC3339))
+	if err != nil {
+		return "", fmt.Errorf("failed to label media: %v", err)
+	}
+	return key, nil
+}
+
+// forgetUnlabeledMedia removes a media without star and tags
+func forgetUnlabeledMedia(key string) {
+	db.Exec(`
+		DELETE FROM media_labels WHERE media_key = ? AND starred = 0
+		  AND NOT EXISTS (SELECT 1 FROM media_tags WHERE media_tags.media_key = media_labels.media_key)
+	`, key)
+}
+
+// GetMediaLabels returns the labeled media of an account
+func GetMediaLabels(username string) ([]MediaLabels, error) {
+	return FindLabeledMedia(LabelFilter{Username: username})
+}
+
+// FindLabeledMedia returns the labeled media matching a filter, newest tweets first
+func FindLabeledMedia(filter LabelFilter) ([]MediaLabels, error) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return nil, err
+		}
+	}
+	query := `SELECT media_key, username, COALESCE(author, ''), tweet_id, url, starred FROM media_labels WHERE 1 = 1`
+	var args []interface{}
+	if filter.Username != "" {
+		query += " AND LOWER(username) = LOWER(?)"
+		args = append(args, cleanUsername(filter.Username))
+	}
+	if filter.Starred {
+		query += " AND starred = 1"
+	}
+	if tag := normalizeTag(filter.Tag); tag != "" {
+		query += " AND media_key IN (SELECT media_key FROM media_tags WHERE tag = ?)"
+		args = append(args, tag)
+	}
+	query += " ORDER BY CAST(tweet_id AS INTEGER) DESC, media_key"
+	rows, err := db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list labeled media: %v", err)
+	}
+	labels := make([]MediaLabels, 0)
+	index := make(map[string]int)
+	for rows.Next() {
+		var l MediaLabels
+		var starred int
+		if err := rows.Scan(&l.MediaKey, &l.Username, &l.Author, &l.TweetID, &l.URL, &starred); err != nil {
+			continue
+		}
+		l.Starred = starred == 1
+		l.Tags = []string{}
+		index[l.MediaKey] = len(labels)
+		labels = append(labels, l)
+	}
+	rows.Close()
+	if len(labels) == 0 {
+		return labels, nil
+	}
+
+	tagRows, err := db.Query("SELECT media_key, tag FROM media_tags ORDER BY tag")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %v", err)
+	}
+	defer tagRows.Close()
+	for tagRows.Next() {
+		var key, tag string
+		if tagRows.Scan(&key, &tag) != nil {
+			continue
+		}
+		if i, ok := index[key]; ok {
+			labels[i].Tags = append(labels[i].Tags, tag)
+		}
+	}
+	return labels, nil
+}
+
+// ListMediaTags returns the tags in use with their number of media, most used first
+// username "" counts every account
+func ListMediaTags(username string) ([]TagCount, error) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return nil, err
+		}
+	}
+	rows, err := db.Query(`
+		SELECT t.tag, COUNT(*) FROM media_tags t JOIN media_labels l ON l.media_key = t.media_key
+		WHERE ? = '' OR LOWER(l.username) = LOWER(?)
+		GROUP BY t.tag ORDER BY COUNT(*) DESC, t.tag
+	`, username, username)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags: %v", err)
+	}
+	defer rows.Close()
+	tags := make([]TagCount, 0)
+	for rows.Next() {
+		var t TagCount
+		if rows.Scan(&t.Tag, &t.Count) == nil {
+			tags = append(tags, t)
+		}
+	}
+	return tags, nil
+}
+
+// ExportLabeledMedia exports the downloaded files of the media matching a filter to
+// exportDir/<username>. downloadDir is where account folders without a known archive folder are
+func ExportLabeledMedia(filter LabelFilter, downloadDir, exportDir string) (LabelExportResult, error) {
+	if exportDir == "" {
+		return LabelExportResult{}, fmt.Errorf("export folder is required")
+	}
+	labels, err := FindLabeledMedia(filter)
+	if err != nil {
+		return LabelExportResult{Folder: exportDir}, err
+	}
+
+	refs := make([]exportRef, 0, len(labels))
+	for _, l := range labels {
+		author := l.Author
+		if author == "" {
+			author = l.Username
+		}
+		refs = append(refs, exportRef{author: author, key: l.MediaKey})
+	}
+	return exportMediaFiles(refs, downloadDir, exportDir)
+}
+
+// exportRef is a media to export: its author, whose folder has the file, and its media key
+type exportRef struct {
+	author string
+	key    string
+}
+
+// exportMediaFiles exports the downloaded files of media to exportDir/<username>
+func exportMediaFiles(refs []exportRef, downloadDir, exportDir string) (LabelExportResult, error) {
+	result := LabelExportResult{Folder: exportDir}
+
+	// Media are looked up by their file name on X in the folders of their authors
+	byFolder := make(map[string]map[string]bool)
+	for _, ref := range refs {
+		if IsUsernameSensitive(ref.author) && IsLocked() {
+			continue
+		}
+		folder := accountArchiveFolder(ref.author, downloadDir)
+		if byFolder[folder] == nil {
+			byFolder[folder] = make(map[string]bool)
+		}
+		byFolder[folder][ref.key] = true
+	}
+	folders := make([]string, 0, len(byFolder))
+	for folder := range byFolder {
+		folders = append(folders, folder)
+	}
+	sort.Strings(folders)
+
+	for _, folder := range folders {
+		wanted := byFolder[folder]
+		files := labeledFiles(folder, wanted)
+		outDir := filepath.Join(exportDir, filepath.Base(folder))
+		for key := range wanted {
+			path, ok := files[key]
+			if !ok {
+				result.NotFound++
+				continue
+			}
+			if err := os.MkdirAll(outDir, 0755); err != nil {
+				return result, fmt.Errorf("failed to create export folder: %v", err)
+			}
+			target := filepath.Join(outDir, filepath.Base(path))
+			if _, err := os.Stat(target); err == nil {
+				result.Skipped++
+				continue
+			}
+			if err := linkOrCopyFile(path, target); err != nil {
+				return result, fmt.Errorf("failed to export %s: %v", filepath.Base(path), err)
+			}
+			result.Exported++
+		}
+	}
+	return result, nil
+}
+
+// accountArchiveFolder returns the folder an account was downloaded to, or its folder in downloadDir
+func accountArchiveFolder(username, downloadDir string) string {
+	if db != nil {
+		var archivePath string
+		err := db.QueryRow(`SELECT archive_path FROM accounts WHERE LOWER(username) = LOWER(?) AND COALESCE(archive_path, '') != '' LIMIT 1`,
+			username).Scan(&archivePath)
+		if err == nil {
+			return archivePath
+		}
+		if err != sql.ErrNoRows {
+			fmt.Printf("Warning: failed to look up archive of @%s: %v\n", username, err)
+		}
+	}
+	return filepath.Join(downloadDir, username)
+}
+
+// labeledFiles returns the downloaded files of an account folder by media key, for the wanted keys
+func labeledFiles(folder string, wanted map[string]bool) map[string]string {
+	files := make(map[string]string)
+	page, err := ListGallery(folder, 0, 0)
+	if err != nil {
+		return files
+	}
+	tweets := archivedTweets(folder, filepath.Base(folder))
+	byOriginal := make(map[string]string)
+	for tweetID, tweet := range tweets {
+		for _, url := range tweet.MediaURLs {
+			if name := ExtractOriginalFilename(url); name != "" {
+				byOriginal[name] = tweetID
+			}
+		}
+	}
+	for _, item := range page.Items {
+		_, original := matchBackfillFile(item, tweets, byOriginal)
+		if original != "" && wanted[original] {
+			files[original] = item.Path
+		}
+	}
+	return files
+}
@@ -0,0 +1,208 @@
+package backend
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Multiple timelines
+//
+// ExtractTimelines archives a list of accounts in one call. Instead of fetching each account to
+// the end before starting the next, the accounts take turns: a turn fetches one batch of an
+// account, which continues from its cursor on its next turn. Every account gets some of its
+// media even when the run is cut short, and one huge account doesn't hold up the others. All
+// accounts share one rate limit budget. Before each turn the run checks the estimated requests
+// left in the window (see extractprogress.go) and waits for the reset when it's used up. When a
+// batch hits the limit, the run waits for the reported reset (up to maxRateLimitWait) and
+// retries that account first. A limit that resets too late stops the run, and the accounts
+// left are reported as pending with the cursor to resume from.
+
+const (
+	// defaultTimelinesBatchSize is the batch fetched per turn for requests without a batch size
+	defaultTimelinesBatchSize = 200
+	// maxTimelinesRateRetries is how often in a row a turn may hit the rate limit before the run stops
+	maxTimelinesRateRetries = 3
+)
+
+// TimelinesResult is the outcome of one request of ExtractTimelines
+type TimelinesResult struct {
+	Index     int              `json:"index"` // Position of the request
+	Username  string           `json:"username"`
+	Fetched   int              `json:"fetched"` // Entries fetched so far
+	Batches   int              `json:"batches"`
+	Completed bool             `json:"completed"`         // Fetched to the end
+	Pending   bool             `json:"pending,omitempty"` // Not finished when the run stopped, resume from Cursor
+	Cursor    string           `json:"cursor,omitempty"`
+	Error     string           `json:"error,omitempty"`
+	Response  *TwitterResponse `json:"response,omitempty"` // Batches merged, nil if nothing was fetched
+}
+
+// TimelinesResponse is the outcome of ExtractTimelines
+type TimelinesResponse struct {
+	Results   []TimelinesResult `json:"results"`
+	Completed int               `json:"completed"`
+	Failed    int               `json:"failed"`
+	Pending   int               `json:"pending"`
+	RetryAt   string            `json:"retry_at,omitempty"` // Set if the run stopped at a rate limit
+}
+
+// TimelinesCallback is called after every turn with the state of its request (without Response)
+type TimelinesCallback func(result TimelinesResult)
+
+// ExtractTimelines fetches the timelines of several requests, taking turns batch by batch
+func ExtractTimelines(ctx context.Context, reqs []TimelineRequest, onTurn TimelinesCallback) (*TimelinesResponse, error) {
+	if len(reqs) == 0 {
+		return nil, fmt.Errorf("no timelines to fetch")
+	}
+	// Make sure the binary is extracted before the first turn
+	if _, err := ensureExtractor(); err != nil {
+		return nil, err
+	}
+
+	results := make([]TimelinesResult, len(reqs))
+	batches := make([][]*TwitterResponse, len(reqs))
+	started := make([]time.Time, len(reqs))
+	var queue []int
+	for i, req := range reqs {
+		results[i] = TimelinesResult{Index: i, Username: req.Username, Cursor: req.Cursor}
+		if req.Username == "" && req.TimelineType != "bookmarks" {
+			results[i].Error = "username is required"
+			continue
+		}
+		queue = append(queue, i)
+	}
+
+	report := &TimelinesResponse{}
+	rateRetries := 0
+	for len(queue) > 0 {
+		i := queue[0]
+		req := reqs[i]
+		req.Cursor = results[i].Cursor
+		req.Page = results[i].Batches
+		if req.BatchSize <= 0 {
+			req.BatchSize = defaultTimelinesBatchSize
+		}
+
+		// Wait for the next window if the estimated budget is used up
+		kind := fetchStatsKind(resolveTimelineType(req))
+		if remaining, resetAt := countRateRequests(kind, 0); remaining == 0 {
+			wait, ok := rateLimitWait(resetAt)
+			if !ok {
+				report.RetryAt = resetAt.UTC().Format(time.RFC3339)
+				break
+			}
+			if !sleepUnlessDone(ctx, wait) {
+				break
+			}
+		}
+		if ctx.Err() != nil {
+			break
+		}
+
+		if started[i].IsZero() {
+			started[i] = time.Now()
+		}
+		resp, err := ExtractTimeline(req)
+		if resp != nil && len(resp.Timeline) > 0 {
+			batches[i] = append(batches[i], resp)
+			results[i].Fetched += len(resp.Timeline)
+		}
+		results[i].Batches++
+
+		// Rate limited: keep what was fetched and retry this account first after the reset
+		if retryAt := shardRetryAt(resp, err); !retryAt.IsZero() {
+			if resp != nil && resp.Cursor != "" {
+				results[i].Cursor = resp.Cursor
+			}
+			notifyTurn(onTurn, results[i])
+			rateRetries++
+			wait, ok := rateLimitWait(retryAt)
+			if !ok || rateRetries > maxTimelinesRateRetries {
+				report.RetryAt = retryAt.UTC().Format(time.RFC3339)
+				break
+			}
+			if !sleepUnlessDone(ctx, wait) {
+				break
+			}
+			continue
+		}
+
+		rateRetries = 0
+		queue = queue[1:]
+		switch {
+		case err != nil:
+			// Accounts that got some batches can be resumed from their cursor
+			results[i].Error = err.Error()
+			results[i].Pending = results[i].Fetched > 0
+		case resp.Partial:
+			// The extractor failed midway, the account can be resumed from its cursor
+			results[i].Error = resp.Error
+			results[i].Cursor = resp.Cursor
+			results[i].Pending = true
+		case !resp.Metadata.HasMore || resp.Cursor == results[i].Cursor:
+			results[i].Completed = true
+			results[i].Cursor = resp.Cursor
+		default:
+			results[i].Cursor = resp.Cursor
+			queue = append(queue, i)
+		}
+		notifyTurn(onTurn, results[i])
+	}
+
+	// Accounts the run didn't get to finish
+	for _, i := range queue {
+		results[i].Pending = true
+	}
+
+	for i := range results {
+		result := &results[i]
+		if len(batches[i]) > 0 {
+			merged := mergeResponses(reqs[i].Username, batches[i])
+			merged.Cursor = result.Cursor
+			merged.Completed = result.Completed
+			merged.Partial = result.Pending
+			merged.Error = result.Error
+			merged.Metadata.Cursor = merged.Cursor
+			merged.Metadata.Completed = merged.Completed
+			merged.Metadata.HasMore = !merged.Completed && merged.Cursor != ""
+			result.Response = merged
+		}
+		if !started[i].IsZero() {
+			var err error
+			if result.Error != "" && result.Response == nil {
+				err = fmt.Errorf("%s", result.Error)
+			}
+			RecordExtraction(result.Username, started[i], result.Response, err)
+		}
+
+		switch {
+		case result.Completed:
+			report.Completed++
+		case result.Pending:
+			report.Pending++
+		default:
+			report.Failed++
+		}
+	}
+	report.Results = results
+	return report, nil
+}
+
+// notifyTurn reports the state of a request after a turn
+func notifyTurn(onTurn TimelinesCallback, result TimelinesResult) {
+	if onTurn != nil {
+		result.Response = nil
+		onTurn(result)
+	}
+}
+
+// sleepUnlessDone waits for d, false if ctx is done first
+func sleepUnlessDone(ctx context.Context, d time.Duration) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-time.After(d):
+		return true
+	}
+}
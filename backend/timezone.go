@@ -0,0 +1,98 @@
+package backend
+
+import (
+	"fmt"
+	"time"
+)
+
+// Posting time zones
+//
+// The extractor reports tweet dates as UTC without a zone ("2024-03-31T01:30:00"), which the
+// UI then reads as local time while filenames use UTC. With a date zone set, every date is
+// converted once - when a timeline is fetched and when files are named or tagged - so stored
+// dates, the UI, filenames, EXIF and exports all show the same wall-clock time. Stored dates
+// carry their offset ("2024-03-31T03:30:00+02:00"), so they stay unambiguous across DST changes.
+
+// Date zones
+const (
+	DateZoneUTC   = "utc"   // Coordinated Universal Time
+	DateZoneLocal = "local" // The computer's time zone, including its DST rules
+)
+
+// tweetDateLayouts are the date formats seen in extractor output and stored timelines
+var tweetDateLayouts = []string{
+	"2006-01-02T15:04:05",       // ISO 8601 without timezone (from extractor)
+	"2006-01-02T15:04:05+00:00", // ISO 8601 with timezone
+	"2006-01-02T15:04:05-07:00", // ISO 8601 with timezone offset
+	time.RFC3339,                // Standard RFC3339
+	"2006-01-02T15:04:05.000Z",
+	"2006-01-02T15:04:05Z",
+	"2006-01-02 15:04:05",
+	"Mon Jan 02 15:04:05 -0700 2006",
+}
+
+// ParseTweetDate parses a tweet date; dates without a zone are UTC, as the extractor reports them
+func ParseTweetDate(dateStr string) (time.Time, error) {
+	for _, layout := range tweetDateLayouts {
+		if t, err := time.Parse(layout, dateStr); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("unrecognized date: %s", dateStr)
+}
+
+// inDateZone converts t to the date zone ("" keeps t's own zone)
+func inDateZone(t time.Time, zone string) time.Time {
+	switch zone {
+	case DateZoneUTC:
+		return t.UTC()
+	case DateZoneLocal:
+		return t.Local()
+	}
+	return t
+}
+
+// NormalizeTweetDate converts a tweet date to the date zone as RFC 3339 with offset
+// Dates are returned unchanged without a zone or if they can't be parsed
+func NormalizeTweetDate(dateStr, zone string) string {
+	if zone == "" || dateStr == "" {
+		return dateStr
+	}
+	t, err := ParseTweetDate(dateStr)
+	if err != nil {
+		return dateStr
+	}
+	return inDateZone(t, zone).Format(time.RFC3339)
+}
+
+// NormalizeTimelineDates converts the dates of timeline entries to the date zone
+func NormalizeTimelineDates(timeline []TimelineEntry, zone string) {
+	if zone == "" {
+		return
+	}
+	for i := range timeline {
+		timeline[i].Date = NormalizeTweetDate(timeline[i].Date, zone)
+	}
+}
+
+// formatTimestamp converts a date string to the filename timestamp format in the date zone
+func formatTimestamp(dateStr, zone string) string {
+	if t, err := ParseTweetDate(dateStr); err == nil {
+		return inDateZone(t, zone).Format("20060102_150405")
+	}
+
+	// Fallback: use empty string to indicate parsing failed
+	return "00000000_000000"
+}
+
+// postedTime returns the posting time of an item in the date zone, zero without a zone
+func postedTime(dateStr, zone string) time.Time {
+	if zone == "" {
+		return time.Time{}
+	}
+	t, err := ParseTweetDate(dateStr)
+	if err != nil {
+		return time.Time{}
+	}
+	return inDateZone(t, zone)
+}
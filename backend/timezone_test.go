@@ -0,0 +1,81 @@
+package backend
+
+import (
+	"testing"
+	"time"
+	_ "time/tzdata" // The DST rules shouldn't depend on the machine's zoneinfo
+)
+
+// useLocalZone makes name the local time zone for the test
+func useLocalZone(t *testing.T, name string) {
+	t.Helper()
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	previous := time.Local
+	time.Local = loc
+	t.Cleanup(func() { time.Local = previous })
+}
+
+// New York springs forward at 2024-03-10 07:00 UTC (02:00 EST -> 03:00 EDT)
+// and falls back at 2024-11-03 06:00 UTC (02:00 EDT -> 01:00 EST)
+func TestRenderFilenameAcrossDST(t *testing.T) {
+	useLocalZone(t, "America/New_York")
+
+	tests := []struct {
+		name   string
+		date   string
+		zone   string
+		file   string // {timestamp}_{tweet_id}
+		posted string // postedTime as RFC 3339, "" for zero
+	}{
+		{"spring before, local", "2024-03-10T06:59:59", DateZoneLocal, "20240310_015959_1", "2024-03-10T01:59:59-05:00"},
+		{"spring after, local", "2024-03-10T07:00:00", DateZoneLocal, "20240310_030000_1", "2024-03-10T03:00:00-04:00"},
+		{"spring before, utc", "2024-03-10T06:59:59", DateZoneUTC, "20240310_065959_1", "2024-03-10T06:59:59Z"},
+		{"spring after, utc", "2024-03-10T07:00:00", DateZoneUTC, "20240310_070000_1", "2024-03-10T07:00:00Z"},
+		{"fall before, local", "2024-11-03T05:59:59", DateZoneLocal, "20241103_015959_1", "2024-11-03T01:59:59-04:00"},
+		{"fall after, local", "2024-11-03T06:00:00", DateZoneLocal, "20241103_010000_1", "2024-11-03T01:00:00-05:00"},
+		{"fall before, utc", "2024-11-03T05:59:59", DateZoneUTC, "20241103_055959_1", "2024-11-03T05:59:59Z"},
+		{"fall after, utc", "2024-11-03T06:00:00", DateZoneUTC, "20241103_060000_1", "2024-11-03T06:00:00Z"},
+		{"offset date, local", "2024-03-10T03:00:00-04:00", DateZoneLocal, "20240310_030000_1", "2024-03-10T03:00:00-04:00"},
+		{"offset date, utc", "2024-03-10T03:00:00-04:00", DateZoneUTC, "20240310_070000_1", "2024-03-10T07:00:00Z"},
+		{"no zone", "2024-11-03T06:00:00", "", "20241103_060000_1", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			item := MediaItem{Date: tt.date, TweetID: 1}
+			if got := renderFilename("{timestamp}_{tweet_id}", item, "acct", 1, tt.zone); got != tt.file {
+				t.Errorf("renderFilename = %q, want %q", got, tt.file)
+			}
+
+			posted := postedTime(tt.date, tt.zone)
+			got := ""
+			if !posted.IsZero() {
+				got = posted.Format(time.RFC3339)
+			}
+			if got != tt.posted {
+				t.Errorf("postedTime = %q, want %q", got, tt.posted)
+			}
+		})
+	}
+}
+
+// The repeated hour after falling back gives two tweets the same local timestamp, but their
+// stored dates keep them apart
+func TestNormalizeTweetDateRepeatedHour(t *testing.T) {
+	useLocalZone(t, "America/New_York")
+
+	first := NormalizeTweetDate("2024-11-03T05:30:00", DateZoneLocal)
+	second := NormalizeTweetDate("2024-11-03T06:30:00", DateZoneLocal)
+	if first != "2024-11-03T01:30:00-04:00" || second != "2024-11-03T01:30:00-05:00" {
+		t.Fatalf("normalized to %q and %q", first, second)
+	}
+
+	a, _ := ParseTweetDate(first)
+	b, _ := ParseTweetDate(second)
+	if b.Sub(a) != time.Hour {
+		t.Errorf("normalized dates are %v apart, want 1h", b.Sub(a))
+	}
+}
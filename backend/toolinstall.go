@@ -0,0 +1,208 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// Atomic tool installation
+//
+// Tools are never extracted in place. A new binary goes into a staging folder next to the
+// installed tools, is checked against the manifest hash and run once with its version flag, and
+// only then renamed over the installed one - a rename within the same folder, so a crash leaves
+// either the old or the new binary, never half of one. The replaced binary is kept as <name>.bak
+// (the Unix exiftool folders in exiftool.bak) and RollbackTool puts it back if the new version
+// turns out not to work.
+
+// toolBackupSuffix is appended to the previous version of a replaced tool
+const toolBackupSuffix = ".bak"
+
+// newToolStaging creates an empty staging folder next to the installed tools
+func newToolStaging(name string) (string, error) {
+	staging := filepath.Join(filepath.Dir(GetFFmpegPath()), name)
+	os.RemoveAll(staging)
+	if err := os.MkdirAll(staging, 0755); err != nil {
+		return "", fmt.Errorf("failed to create directory: %v", err)
+	}
+	return staging, nil
+}
+
+// checkStagedHash checks a staged binary against the manifest, if it lists the binary
+func checkStagedHash(staged string, entry ToolManifestEntry) error {
+	expected, ok := entry.Files[filepath.Base(staged)]
+	if !ok {
+		return nil
+	}
+	hash, err := calculateSHA256(staged)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %v", filepath.Base(staged), err)
+	}
+	if !strings.EqualFold(expected, hash) {
+		return fmt.Errorf("%s checksum mismatch: expected %s, got %s", filepath.Base(staged), expected, hash)
+	}
+	return nil
+}
+
+// installStaged verifies a staged binary, moves it into place keeping the previous one as .bak
+// and records its checksum. versionFlag runs it once first, "" if the caller already did
+func installStaged(staged, target string, entry ToolManifestEntry, versionFlag string) error {
+	if err := checkStagedHash(staged, entry); err != nil {
+		return err
+	}
+	if versionFlag != "" {
+		if err := checkToolRuns(staged, versionFlag); err != nil {
+			return err
+		}
+	}
+	if err := replaceWithBackup(staged, target); err != nil {
+		return err
+	}
+	return recordToolBinary(target, entry)
+}
+
+// replaceWithBackup renames staged over target, keeping target as .bak and restoring it if the rename fails
+func replaceWithBackup(staged, target string) error {
+	backup := target + toolBackupSuffix
+	hadPrevious := false
+	if _, err := os.Lstat(target); err == nil {
+		os.RemoveAll(backup)
+		if err := os.Rename(target, backup); err != nil {
+			return fmt.Errorf("failed to back up %s: %v", filepath.Base(target), err)
+		}
+		hadPrevious = true
+	}
+	if err := os.Rename(staged, target); err != nil {
+		if hadPrevious {
+			os.Rename(backup, target)
+		}
+		return fmt.Errorf("failed to install %s: %v", filepath.Base(target), err)
+	}
+	return nil
+}
+
+// installStagedExifTool installs exiftool extracted into staging: the Windows executable with its
+// exiftool_files folder, or the Image-ExifTool-VERSION folder of the Perl distribution
+func installStagedExifTool(staging string, entry ToolManifestEntry) error {
+	baseDir := filepath.Dir(GetFFmpegPath())
+
+	if runtime.GOOS == "windows" {
+		staged := filepath.Join(staging, "exiftool.exe")
+		if err := checkStagedHash(staged, entry); err != nil {
+			return err
+		}
+		// Run from staging, the executable needs its exiftool_files folder next to it
+		if err := checkToolRuns(staged, "-ver"); err != nil {
+			return err
+		}
+		if _, err := os.Stat(filepath.Join(staging, "exiftool_files")); err == nil {
+			if err := replaceWithBackup(filepath.Join(staging, "exiftool_files"), filepath.Join(baseDir, "exiftool_files")); err != nil {
+				return err
+			}
+		}
+		return installStaged(staged, filepath.Join(baseDir, "exiftool.exe"), entry, "")
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(staging, "Image-ExifTool-*"))
+	if len(matches) == 0 {
+		return fmt.Errorf("exiftool script not found in archive")
+	}
+	stagedDir := matches[0]
+	if err := checkStagedHash(filepath.Join(stagedDir, "exiftool"), entry); err != nil {
+		return err
+	}
+	if err := checkToolRuns(filepath.Join(stagedDir, "exiftool"), "-ver"); err != nil {
+		return err
+	}
+
+	// GetExifToolPath uses the first Image-ExifTool-* folder, so every other version moves to the backup
+	backup := filepath.Join(baseDir, "exiftool"+toolBackupSuffix)
+	previous, _ := filepath.Glob(filepath.Join(baseDir, "Image-ExifTool-*"))
+	if len(previous) > 0 {
+		os.RemoveAll(backup)
+		if err := os.MkdirAll(backup, 0755); err != nil {
+			return fmt.Errorf("failed to back up exiftool: %v", err)
+		}
+		for _, dir := range previous {
+			if err := os.Rename(dir, filepath.Join(backup, filepath.Base(dir))); err != nil {
+				restoreExifToolFolders(backup, baseDir)
+				return fmt.Errorf("failed to back up exiftool: %v", err)
+			}
+		}
+	}
+	target := filepath.Join(baseDir, filepath.Base(stagedDir))
+	if err := os.Rename(stagedDir, target); err != nil {
+		restoreExifToolFolders(backup, baseDir)
+		return fmt.Errorf("failed to install exiftool: %v", err)
+	}
+	return recordToolBinary(filepath.Join(target, "exiftool"), entry)
+}
+
+// restoreExifToolFolders moves the backed up Image-ExifTool-* folders back into baseDir
+func restoreExifToolFolders(backup, baseDir string) error {
+	saved, _ := filepath.Glob(filepath.Join(backup, "Image-ExifTool-*"))
+	if len(saved) == 0 {
+		return fmt.Errorf("no previous exiftool version to restore")
+	}
+	for _, dir := range saved {
+		if err := os.Rename(dir, filepath.Join(baseDir, filepath.Base(dir))); err != nil {
+			return fmt.Errorf("failed to restore exiftool: %v", err)
+		}
+	}
+	os.RemoveAll(backup)
+	return nil
+}
+
+// restoreBackup puts the .bak of a tool back in place and records its checksum
+func restoreBackup(target string) error {
+	backup := target + toolBackupSuffix
+	if _, err := os.Lstat(backup); err != nil {
+		return fmt.Errorf("no previous version of %s to restore", filepath.Base(target))
+	}
+	os.RemoveAll(target)
+	if err := os.Rename(backup, target); err != nil {
+		return fmt.Errorf("failed to restore %s: %v", filepath.Base(target), err)
+	}
+	if info, err := os.Stat(target); err == nil && info.IsDir() {
+		return nil
+	}
+	return recordToolBinary(target, ToolManifestEntry{})
+}
+
+// RollbackTool restores the version of ffmpeg or exiftool that the last update or import replaced
+func RollbackTool(tool string) error {
+	switch tool {
+	case ToolFFmpeg:
+		if err := restoreBackup(GetFFmpegPath()); err != nil {
+			return err
+		}
+		// ffprobe is optional, it may not have been replaced
+		restoreBackup(bundledFFprobePath())
+		return nil
+	case ToolExifTool:
+		baseDir := filepath.Dir(GetFFmpegPath())
+		if runtime.GOOS == "windows" {
+			if err := restoreBackup(filepath.Join(baseDir, "exiftool.exe")); err != nil {
+				return err
+			}
+			restoreBackup(filepath.Join(baseDir, "exiftool_files"))
+			return nil
+		}
+		backup := filepath.Join(baseDir, "exiftool"+toolBackupSuffix)
+		if saved, _ := filepath.Glob(filepath.Join(backup, "Image-ExifTool-*")); len(saved) == 0 {
+			return fmt.Errorf("no previous exiftool version to restore")
+		}
+		current, _ := filepath.Glob(filepath.Join(baseDir, "Image-ExifTool-*"))
+		for _, dir := range current {
+			os.RemoveAll(dir)
+		}
+		if err := restoreExifToolFolders(backup, baseDir); err != nil {
+			return err
+		}
+		return recordToolBinary(GetExifToolPath(), ToolManifestEntry{})
+	default:
+		return fmt.Errorf("unknown tool: %s", tool)
+	}
+}
@@ -0,0 +1,213 @@
+package backend
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Tool checksums
+//
+// ffmpeg, ffprobe and exiftool are downloaded from third-party hosts. The release workflow copies
+// every tool archive to the release assets and publishes a manifest with their URL and SHA-256 per
+// platform (and optionally of the binaries inside), signed with the project's ed25519 key. Downloads are checked against it
+// before anything is extracted, the hashes of the installed binaries are recorded, and every
+// bundled binary is checked against that record before it's run. The embedded extractor is
+// checked against the hash of the copy compiled into the app.
+
+// Tool manifest location (latest release assets), the signature is at the same URL + ".sig"
+const (
+	toolManifestName = "tools-manifest.json"
+	toolManifestURL  = "https://github.com/missuo/Twitter-X-Media-Batch-Downloader/releases/latest/download/" + toolManifestName
+)
+
+// toolManifestKey is the base64 ed25519 public key the manifest is signed with
+// Set at build time: -ldflags "-X twitterxmediabatchdownloader/backend.toolManifestKey=..."
+// Development builds without a key only rely on HTTPS for the manifest
+var toolManifestKey = ""
+
+// toolRecordFile lists the SHA-256 of every installed tool binary, by file name
+const toolRecordFile = "tools.sha256.json"
+
+// ToolManifest lists the pinned downloads of the external tools
+type ToolManifest struct {
+	Version int                                     `json:"version"`
+	Tools   map[string]map[string]ToolManifestEntry `json:"tools"` // Tool ("ffmpeg", "ffprobe", "exiftool") -> platform ("linux/amd64", or "linux" for any arch) -> entry
+}
+
+// ToolManifestEntry is the pinned download of a tool on one platform
+type ToolManifestEntry struct {
+	Version string            `json:"version,omitempty"` // Version of the tool, replaces the built-in version in the built-in URLs
+	URL     string            `json:"url"`               // Archive to download, the built-in URLs if empty
+	Name    string            `json:"name,omitempty"`    // Archive file name on custom mirrors, the built-in name if empty
+	Mirrors []string          `json:"mirrors,omitempty"` // Other URLs serving the same archive
+	SHA256  string            `json:"sha256"`            // SHA-256 of the archive
+	Files   map[string]string `json:"files,omitempty"`   // SHA-256 of the binaries in the archive, by file name
+}
+
+var (
+	toolRecordMu  sync.Mutex
+	verifiedTools sync.Map // path -> "hash:size:modtime" of the last successful verification
+)
+
+// fetchToolManifest downloads the tool manifest (from the custom mirror if it has one) and checks its signature
+func fetchToolManifest(mirror string) (*ToolManifest, error) {
+	if mirror != "" {
+		manifest, err := fetchToolManifestFrom(mirrorURL(mirror, toolManifestName))
+		if err == nil {
+			return manifest, nil
+		}
+		fmt.Printf("Warning: no tool manifest on mirror: %v\n", err)
+	}
+	return fetchToolManifestFrom(toolManifestURL)
+}
+
+// fetchToolManifestFrom downloads a tool manifest and its signature (url + ".sig")
+func fetchToolManifestFrom(manifestURL string) (*ToolManifest, error) {
+	client := &http.Client{Transport: audited(nil, AuditPurposeTool), Timeout: 30 * time.Second}
+	data, err := fetchSmall(client, manifestURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch tool manifest: %v", err)
+	}
+
+	if toolManifestKey != "" {
+		key, err := base64.StdEncoding.DecodeString(toolManifestKey)
+		if err != nil || len(key) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid tool manifest key")
+		}
+		sigData, err := fetchSmall(client, manifestURL+".sig")
+		if err != nil {
+			return nil, fmt.Errorf("failed to fetch tool manifest signature: %v", err)
+		}
+		sig, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(sigData)))
+		if err != nil || !ed25519.Verify(ed25519.PublicKey(key), data, sig) {
+			return nil, fmt.Errorf("tool manifest signature is invalid")
+		}
+	}
+
+	var manifest ToolManifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse tool manifest: %v", err)
+	}
+	return &manifest, nil
+}
+
+// fetchSmall downloads a small file (at most 1 MB) into memory
+func fetchSmall(client *http.Client, url string) ([]byte, error) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status %d", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
+
+// entry returns the manifest entry of a tool for the current platform
+func (m *ToolManifest) entry(tool string) (ToolManifestEntry, error) {
+	platforms := m.Tools[tool]
+	if entry, ok := platforms[runtime.GOOS+"/"+runtime.GOARCH]; ok {
+		return entry, nil
+	}
+	if entry, ok := platforms[runtime.GOOS]; ok {
+		return entry, nil
+	}
+	return ToolManifestEntry{}, fmt.Errorf("no verified %s download for %s/%s", tool, runtime.GOOS, runtime.GOARCH)
+}
+
+// recordToolBinary checks an extracted binary against the manifest (if it lists it) and records its hash
+func recordToolBinary(path string, entry ToolManifestEntry) error {
+	hash, err := calculateSHA256(path)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %v", filepath.Base(path), err)
+	}
+	if expected, ok := entry.Files[filepath.Base(path)]; ok && !strings.EqualFold(expected, hash) {
+		os.Remove(path)
+		return fmt.Errorf("%s checksum mismatch: expected %s, got %s", filepath.Base(path), expected, hash)
+	}
+	return saveToolHash(path, hash)
+}
+
+// verifyToolBinary checks a bundled binary against its recorded hash before it's run
+// Binaries installed before hashes were recorded are trusted once and recorded
+func verifyToolBinary(path string) error {
+	expected, recorded := loadToolHashes()[filepath.Base(path)]
+	if !recorded {
+		if _, err := os.Stat(path); err != nil {
+			return err
+		}
+		hash, err := calculateSHA256(path)
+		if err != nil {
+			return fmt.Errorf("failed to hash %s: %v", filepath.Base(path), err)
+		}
+		return saveToolHash(path, hash)
+	}
+	if err := verifyFileHash(path, expected); err != nil {
+		return fmt.Errorf("%s was modified since it was installed (%v) - reinstall it", filepath.Base(path), err)
+	}
+	return nil
+}
+
+// verifyFileHash checks a file's SHA-256, remembering the result until the file changes
+func verifyFileHash(path, expected string) error {
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	stamp := fmt.Sprintf("%s:%d:%d", strings.ToLower(expected), info.Size(), info.ModTime().UnixNano())
+	if v, ok := verifiedTools.Load(path); ok && v == stamp {
+		return nil
+	}
+	hash, err := calculateSHA256(path)
+	if err != nil {
+		return fmt.Errorf("failed to hash %s: %v", filepath.Base(path), err)
+	}
+	if !strings.EqualFold(hash, expected) {
+		return fmt.Errorf("checksum mismatch")
+	}
+	verifiedTools.Store(path, stamp)
+	return nil
+}
+
+// loadToolHashes reads the recorded tool hashes
+func loadToolHashes() map[string]string {
+	hashes := make(map[string]string)
+	data, err := os.ReadFile(toolRecordPath())
+	if err == nil {
+		json.Unmarshal(data, &hashes)
+	}
+	return hashes
+}
+
+// saveToolHash records the hash of a tool binary
+func saveToolHash(path, hash string) error {
+	toolRecordMu.Lock()
+	defer toolRecordMu.Unlock()
+
+	hashes := loadToolHashes()
+	hashes[filepath.Base(path)] = hash
+	data, err := json.MarshalIndent(hashes, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(toolRecordPath(), data, 0644); err != nil {
+		return fmt.Errorf("failed to record tool checksum: %v", err)
+	}
+	return nil
+}
+
+// toolRecordPath returns where the tool hashes are recorded, next to the bundled tools
+func toolRecordPath() string {
+	return filepath.Join(filepath.Dir(GetFFmpegPath()), toolRecordFile)
+}
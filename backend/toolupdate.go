@@ -0,0 +1,100 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Tool updates
+//
+// The tool manifest names the current version of every tool next to its pinned archive. The
+// bundled ffmpeg and exiftool are compared against it: a tool is out of date when the manifest
+// lists a different hash for its binary or, without binary hashes, a different version. Updating
+// is the same verified download as the first install. Tools installed on the system (PATH,
+// Homebrew, ...) are reported but left to the system's package manager.
+
+// ToolUpdate is the update status of an external tool
+type ToolUpdate struct {
+	Tool            string `json:"tool"`             // ffmpeg or exiftool
+	Installed       string `json:"installed"`        // Version of the tool in use, "" if not installed
+	Latest          string `json:"latest"`           // Version in the tool manifest, "" if it doesn't name one
+	Bundled         bool   `json:"bundled"`          // Downloaded by the app (only these are updated)
+	UpdateAvailable bool   `json:"update_available"` // The bundled tool differs from the manifest
+}
+
+// CheckToolUpdates compares the bundled ffmpeg and exiftool against the tool manifest
+// mirror is an optional custom mirror, checked for a manifest before the release assets
+func CheckToolUpdates(mirror string) ([]ToolUpdate, error) {
+	manifest, err := fetchToolManifest(mirror)
+	if err != nil {
+		return nil, err
+	}
+
+	updates := []ToolUpdate{
+		checkToolUpdate(manifest, ToolFFmpeg, GetFFmpegPath(), "-version"),
+		checkToolUpdate(manifest, ToolExifTool, GetExifToolPath(), "-ver"),
+	}
+	return updates, nil
+}
+
+// checkToolUpdate compares one tool against its manifest entry
+func checkToolUpdate(manifest *ToolManifest, tool, bundledPath, versionFlag string) ToolUpdate {
+	update := ToolUpdate{Tool: tool}
+	entry, err := manifest.entry(tool)
+	if err == nil {
+		update.Latest = entry.Version
+	}
+
+	if _, statErr := os.Stat(bundledPath); statErr != nil {
+		// Not downloaded by the app, report the system tool's version if there is one
+		if path, err := exec.LookPath(tool); err == nil {
+			update.Installed = toolVersion(path, versionFlag)
+		}
+		return update
+	}
+
+	update.Bundled = true
+	update.Installed = toolVersion(bundledPath, versionFlag)
+	if err != nil {
+		return update
+	}
+	if expected, ok := entry.Files[filepath.Base(bundledPath)]; ok {
+		recorded := loadToolHashes()[filepath.Base(bundledPath)]
+		update.UpdateAvailable = !strings.EqualFold(recorded, expected)
+	} else if entry.Version != "" {
+		update.UpdateAvailable = update.Installed != entry.Version
+	}
+	return update
+}
+
+// toolVersion runs a tool with its version flag and returns the version, "" if it doesn't run
+// ffmpeg prints "ffmpeg version N-118362-g... Copyright ...", exiftool just the version
+func toolVersion(path, versionFlag string) string {
+	cmd := exec.Command(path, versionFlag)
+	hideWindow(cmd)
+	output, err := cmd.Output()
+	if err != nil {
+		return ""
+	}
+	line := strings.TrimSpace(strings.SplitN(string(output), "\n", 2)[0])
+	fields := strings.Fields(line)
+	if len(fields) >= 3 && fields[1] == "version" {
+		return fields[2]
+	}
+	return line
+}
+
+// UpdateTool downloads the version of a bundled tool listed in the tool manifest
+func UpdateTool(tool string, progressCallback func(downloaded, total int64), mirror string) error {
+	switch tool {
+	case ToolFFmpeg:
+		return DownloadFFmpeg(progressCallback, mirror)
+	case ToolExifTool:
+		return DownloadExifTool(progressCallback, mirror)
+	default:
+		return fmt.Errorf("unknown tool: %s", tool)
+	}
+}
@@ -0,0 +1,122 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+)
+
+// Video presets for ConvertVideo
+const (
+	VideoPresetHEVC       = "hevc"        // Re-encode to H.265/HEVC, about half the size at similar quality
+	VideoPresetWebM       = "webm"        // Convert to WebM (VP9 + Opus)
+	VideoPresetStripAudio = "strip_audio" // Drop the audio track, video is copied as is
+	VideoPresetRemux      = "remux"       // Copy all streams into a fresh MP4 container (fixes broken/unseekable files)
+)
+
+// videoPresetArgs returns the ffmpeg arguments between input and output and the output extension of a preset
+func videoPresetArgs(preset string) ([]string, string, error) {
+	// Keep the tweet metadata embedded after download
+	switch preset {
+	case VideoPresetHEVC:
+		// hvc1 tag so QuickTime/Apple devices play the file
+		return []string{"-map", "0", "-map_metadata", "0", "-c:v", "libx265", "-crf", "28", "-preset", "medium", "-tag:v", "hvc1", "-c:a", "copy", "-movflags", "+faststart"}, ".mp4", nil
+	case VideoPresetWebM:
+		return []string{"-map_metadata", "0", "-c:v", "libvpx-vp9", "-crf", "32", "-b:v", "0", "-row-mt", "1", "-c:a", "libopus", "-b:a", "96k"}, ".webm", nil
+	case VideoPresetStripAudio:
+		return []string{"-map_metadata", "0", "-c:v", "copy", "-an", "-movflags", "+faststart"}, ".mp4", nil
+	case VideoPresetRemux:
+		return []string{"-map", "0", "-map_metadata", "0", "-c", "copy", "-movflags", "+faststart"}, ".mp4", nil
+	default:
+		return nil, "", fmt.Errorf("unknown video preset: %s", preset)
+	}
+}
+
+// ConvertVideo converts a video with a preset and returns the path of the result
+// With replaceOriginal the result takes the original's place (same name for MP4 results),
+// otherwise it's saved next to it as {name}_{preset}.{ext}
+func ConvertVideo(inputPath, preset string, replaceOriginal bool) (string, error) {
+	if !IsFFmpegInstalled() {
+		return "", fmt.Errorf("ffmpeg not installed")
+	}
+	args, ext, err := videoPresetArgs(preset)
+	if err != nil {
+		return "", err
+	}
+
+	base := strings.TrimSuffix(inputPath, filepath.Ext(inputPath))
+	outputPath := base + "_" + preset + ext
+	if replaceOriginal {
+		outputPath = base + ext
+	}
+	// Write to a temporary file first - the output may replace the input
+	tmpPath := base + ".converting" + ext
+
+	cmdArgs := append([]string{"-i", inputPath}, args...)
+	cmdArgs = append(cmdArgs, "-y", tmpPath)
+	cmd := exec.Command(ffmpegBinary(), cmdArgs...)
+	hideWindow(cmd) // Hide console window on Windows
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("ffmpeg error: %v, output: %s", err, string(output))
+	}
+
+	if err := os.Rename(tmpPath, outputPath); err != nil {
+		os.Remove(tmpPath)
+		return "", fmt.Errorf("failed to save converted video: %v", err)
+	}
+	if replaceOriginal && outputPath != inputPath {
+		os.Remove(inputPath)
+	}
+	return outputPath, nil
+}
+
+// ConvertVideos converts MP4 files (folders are searched recursively) with a preset
+// workers is the number of ffmpeg processes run at once, 0 = one per CPU core.
+// savedBytes is the total size difference between the originals and the results (negative if they grew)
+func ConvertVideos(paths []string, preset string, replaceOriginal bool, workers int) (converted int, failed int, savedBytes int64, err error) {
+	if !IsFFmpegInstalled() {
+		return 0, 0, 0, fmt.Errorf("ffmpeg not installed")
+	}
+	if _, _, err := videoPresetArgs(preset); err != nil {
+		return 0, 0, 0, err
+	}
+
+	inputs, err := collectMP4Files(paths)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	// Skip results of earlier runs when a folder is converted again
+	pending := inputs[:0]
+	for _, inputPath := range inputs {
+		name := strings.TrimSuffix(filepath.Base(inputPath), filepath.Ext(inputPath))
+		if strings.HasSuffix(name, "_"+preset) || strings.HasSuffix(name, ".converting") {
+			continue
+		}
+		pending = append(pending, inputPath)
+	}
+	inputs = pending
+	if len(inputs) == 0 {
+		return 0, 0, 0, fmt.Errorf("no MP4 files found")
+	}
+
+	var saved int64
+	converted, failed = runFFmpegPool(inputs, workers, func(inputPath string) error {
+		before, err := os.Stat(inputPath)
+		if err != nil {
+			return err
+		}
+		outputPath, err := ConvertVideo(inputPath, preset, replaceOriginal)
+		if err != nil {
+			return err
+		}
+		if after, err := os.Stat(outputPath); err == nil {
+			atomic.AddInt64(&saved, before.Size()-after.Size())
+		}
+		return nil
+	})
+	return converted, failed, saved, nil
+}
@@ -0,0 +1,81 @@
+package backend
+
+import (
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Connection pooling
+//
+// Every client made by CreateHTTPClient shares one tuned transport per proxy, so connections stay
+// open between files and between jobs instead of being set up again for each client. Requests to
+// the media hosts go through a transport with an idle pool large enough for all download workers,
+// which matters most for accounts with thousands of small images where the TLS handshake would
+// otherwise cost more than the file itself. HTTP/2 is used where the server offers it. The media
+// is already compressed, so it's requested as is and Content-Length stays the size of the file.
+
+// mediaHosts are the hosts the media files are downloaded from
+var mediaHosts = map[string]bool{
+	"pbs.twimg.com":   true,
+	"video.twimg.com": true,
+}
+
+// pooledTransports are the shared transports, by proxy URL
+var pooledTransports = struct {
+	sync.Mutex
+	byProxy map[string]*pooledTransport
+}{byProxy: map[string]*pooledTransport{}}
+
+// pooledTransport sends media host requests through a transport with a larger idle pool
+type pooledTransport struct {
+	media *http.Transport
+	other *http.Transport
+}
+
+func (t *pooledTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if mediaHosts[req.URL.Hostname()] {
+		return t.media.RoundTrip(req)
+	}
+	return t.other.RoundTrip(req)
+}
+
+// sharedTransport returns the shared transport for a proxy (nil for none)
+func sharedTransport(proxyURL *url.URL) *pooledTransport {
+	key := ""
+	if proxyURL != nil {
+		key = proxyURL.String()
+	}
+	pooledTransports.Lock()
+	defer pooledTransports.Unlock()
+	if t, ok := pooledTransports.byProxy[key]; ok {
+		return t
+	}
+
+	t := &pooledTransport{
+		media: newTunedTransport(proxyURL, maxConcurrentDownloads*2),
+		other: newTunedTransport(proxyURL, 8),
+	}
+	t.media.DisableCompression = true
+	pooledTransports.byProxy[key] = t
+	return t
+}
+
+// newTunedTransport returns a keep-alive transport with HTTP/2 and the given idle connections per host
+func newTunedTransport(proxyURL *url.URL, idlePerHost int) *http.Transport {
+	return &http.Transport{
+		Proxy: http.ProxyURL(proxyURL),
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          256,
+		MaxIdleConnsPerHost:   idlePerHost,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+}
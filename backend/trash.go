@@ -0,0 +1,95 @@
+package backend
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// MoveToTrash moves a file to the system trash / recycle bin so it can still be restored
+// Files on another volume than the trash (archives on external drives) are copied to it and removed
+func MoveToTrash(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return err
+	}
+
+	switch runtime.GOOS {
+	case "windows":
+		script := fmt.Sprintf("Add-Type -AssemblyName Microsoft.VisualBasic; [Microsoft.VisualBasic.FileIO.FileSystem]::DeleteFile('%s', 'OnlyErrorDialogs', 'SendToRecycleBin')", escapePowerShell(absPath))
+		cmd := exec.Command("powershell", "-NoProfile", "-NonInteractive", "-Command", script)
+		hideWindow(cmd)
+		if output, err := cmd.CombinedOutput(); err != nil {
+			return fmt.Errorf("failed to move to recycle bin: %v: %s", err, output)
+		}
+		return nil
+	case "darwin":
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		return moveFile(absPath, freeFilePath(filepath.Join(homeDir, ".Trash", filepath.Base(absPath))))
+	default:
+		return moveToFreedesktopTrash(absPath)
+	}
+}
+
+// moveToFreedesktopTrash implements the freedesktop.org trash spec (home trash only)
+func moveToFreedesktopTrash(absPath string) error {
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		dataHome = filepath.Join(homeDir, ".local", "share")
+	}
+	filesDir := filepath.Join(dataHome, "Trash", "files")
+	infoDir := filepath.Join(dataHome, "Trash", "info")
+	if err := os.MkdirAll(filesDir, 0700); err != nil {
+		return fmt.Errorf("failed to create trash directory: %v", err)
+	}
+	if err := os.MkdirAll(infoDir, 0700); err != nil {
+		return fmt.Errorf("failed to create trash directory: %v", err)
+	}
+
+	target := filepath.Join(filesDir, filepath.Base(absPath))
+	if _, err := os.Stat(target); err == nil {
+		target = freeFilePath(target)
+	}
+	name := filepath.Base(target)
+
+	info := fmt.Sprintf("[Trash Info]\nPath=%s\nDeletionDate=%s\n",
+		(&url.URL{Path: absPath}).EscapedPath(), time.Now().Format("2006-01-02T15:04:05"))
+	infoPath := filepath.Join(infoDir, name+".trashinfo")
+	if err := os.WriteFile(infoPath, []byte(info), 0600); err != nil {
+		return fmt.Errorf("failed to write trash info: %v", err)
+	}
+
+	if err := moveFile(absPath, target); err != nil {
+		os.Remove(infoPath)
+		return fmt.Errorf("failed to move to trash: %v", err)
+	}
+	return nil
+}
+
+// moveFile renames a file, or copies it and removes the original if the target is on another volume
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil || !errors.Is(err, syscall.EXDEV) {
+		return err
+	}
+	if _, err := copyVerified(src, dst); err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
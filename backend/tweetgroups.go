@@ -0,0 +1,128 @@
+package backend
+
+import "sort"
+
+// Tweets grouped by ID
+//
+// A timeline has one entry per media file, so a tweet with four photos shows up as four entries
+// that repeat the tweet's text and counts. Requests can ask for the timeline grouped by tweet
+// instead: one object per tweet with its media nested in the order they were posted, each with
+// its 1-based index, so the UI can show a multi-photo tweet as one card. Text tweets have no
+// media. Tweets keep the order in which they first appear in the timeline.
+
+// TimelineTweet is a tweet with its media
+type TimelineTweet struct {
+	TweetID        TweetIDString `json:"tweet_id"`
+	Date           string        `json:"date"`
+	IsRetweet      bool          `json:"is_retweet"`
+	QuoteID        TweetIDString `json:"quote_id,omitempty"`
+	ReplyID        TweetIDString `json:"reply_id,omitempty"`
+	ConversationID TweetIDString `json:"conversation_id,omitempty"`
+	Content        string        `json:"content,omitempty"`
+	ViewCount      int           `json:"view_count,omitempty"`
+	BookmarkCount  int           `json:"bookmark_count,omitempty"`
+	FavoriteCount  int           `json:"favorite_count,omitempty"`
+	RetweetCount   int           `json:"retweet_count,omitempty"`
+	ReplyCount     int           `json:"reply_count,omitempty"`
+	Source         string        `json:"source,omitempty"`
+	Verified       bool          `json:"verified,omitempty"`
+	AuthorUsername string        `json:"author_username,omitempty"`
+	Card           *TweetCard    `json:"card,omitempty"`
+	Media          []TweetMedia  `json:"media"` // Empty for text tweets
+}
+
+// TweetMedia is one media file of a tweet
+type TweetMedia struct {
+	Index            int    `json:"index"` // 1-based position in the tweet
+	URL              string `json:"url"`
+	Type             string `json:"type"`
+	Extension        string `json:"extension"`
+	Width            int    `json:"width"`
+	Height           int    `json:"height"`
+	OriginalFilename string `json:"original_filename,omitempty"`
+	EstimatedSize    int64  `json:"estimated_size,omitempty"`
+}
+
+// GroupTimelineByTweet groups timeline entries by tweet
+func GroupTimelineByTweet(timeline []TimelineEntry) []TimelineTweet {
+	tweets := make([]TimelineTweet, 0)
+	byID := make(map[int64]int) // Index in tweets
+	for _, entry := range timeline {
+		i, ok := byID[int64(entry.TweetID)]
+		if !ok {
+			i = len(tweets)
+			byID[int64(entry.TweetID)] = i
+			tweets = append(tweets, TimelineTweet{
+				TweetID:        entry.TweetID,
+				Date:           entry.Date,
+				IsRetweet:      entry.IsRetweet,
+				QuoteID:        entry.QuoteID,
+				ReplyID:        entry.ReplyID,
+				ConversationID: entry.ConversationID,
+				Content:        entry.Content,
+				ViewCount:      entry.ViewCount,
+				BookmarkCount:  entry.BookmarkCount,
+				FavoriteCount:  entry.FavoriteCount,
+				RetweetCount:   entry.RetweetCount,
+				ReplyCount:     entry.ReplyCount,
+				Source:         entry.Source,
+				Verified:       entry.Verified,
+				AuthorUsername: entry.AuthorUsername,
+				Card:           entry.Card,
+				Media:          make([]TweetMedia, 0),
+			})
+		}
+		if entry.Type == "text" {
+			continue
+		}
+		tweet := &tweets[i]
+		if hasTweetMedia(tweet.Media, entry.URL) {
+			continue // Same media from merged fetches
+		}
+		tweet.Media = append(tweet.Media, TweetMedia{
+			Index:            entry.MediaIndex,
+			URL:              entry.URL,
+			Type:             entry.Type,
+			Extension:        entry.Extension,
+			Width:            entry.Width,
+			Height:           entry.Height,
+			OriginalFilename: entry.OriginalFilename,
+			EstimatedSize:    entry.EstimatedSize,
+		})
+	}
+
+	for i := range tweets {
+		sortTweetMedia(tweets[i].Media)
+	}
+	return tweets
+}
+
+// hasTweetMedia reports whether media holds a file
+func hasTweetMedia(media []TweetMedia, url string) bool {
+	for _, m := range media {
+		if m.URL == url {
+			return true
+		}
+	}
+	return false
+}
+
+// sortTweetMedia puts the media of a tweet in posted order. Entries stored before media were
+// numbered keep their order in the timeline and are numbered by it.
+func sortTweetMedia(media []TweetMedia) {
+	for _, m := range media {
+		if m.Index == 0 {
+			for j := range media {
+				media[j].Index = j + 1
+			}
+			return
+		}
+	}
+	sort.SliceStable(media, func(a, b int) bool { return media[a].Index < media[b].Index })
+}
+
+// GroupByTweet replaces the timeline of the response with its tweets
+func (r *TwitterResponse) GroupByTweet() {
+	r.Tweets = GroupTimelineByTweet(r.Timeline)
+	r.Timeline = make([]TimelineEntry, 0)
+}
@@ -0,0 +1,204 @@
+package backend
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Tweet archive
+//
+// Media files only carry the tweet text in their embedded comment. With TweetsJSONL set, every
+// media job also writes the tweets its media came from to tweets.jsonl in the account folder, one
+// JSON object per line with the text, date and counts - no separate text-mode run needed. The
+// records come from the items of the job, which the timeline already filled in. An existing file
+// is merged: tweets seen again get their current counts, tweets that aren't in this job are kept.
+// With TweetsTXT set, each tweet is also written to tweets/<tweet id>.txt in the account folder,
+// readable without any tools; a tweet downloaded again gets its file rewritten with current counts.
+
+// tweetsJSONLFile is the tweet archive in each account folder
+const tweetsJSONLFile = "tweets.jsonl"
+
+// tweetTextsFolder holds the .txt file of each tweet in each account folder
+const tweetTextsFolder = "tweets"
+
+// TweetRecord is one line of tweets.jsonl
+type TweetRecord struct {
+	TweetID       string     `json:"tweet_id"`
+	Username      string     `json:"username"`
+	Date          string     `json:"date"`
+	URL           string     `json:"url"`
+	Content       string     `json:"content,omitempty"`
+	Media         []string   `json:"media"` // Media URLs in the tweet
+	FavoriteCount int        `json:"favorite_count"`
+	RetweetCount  int        `json:"retweet_count"`
+	ReplyCount    int        `json:"reply_count"`
+	ViewCount     int        `json:"view_count"`
+	BookmarkCount int        `json:"bookmark_count"`
+	Card          *TweetCard `json:"card,omitempty"` // Poll or link preview
+}
+
+// tweetRecords groups the items of a job into tweets per account folder
+func tweetRecords(tasks []downloadTask, outputDir string) map[string][]TweetRecord {
+	byDir := make(map[string][]TweetRecord)
+	index := make(map[string]map[int64]int) // account folder -> tweet ID -> position
+	for _, task := range tasks {
+		item := task.item
+		// outputDir/username/type/file
+		dir := filepath.Dir(filepath.Dir(task.outputPath))
+		if rel, err := filepath.Rel(outputDir, dir); err != nil || rel == "." {
+			continue
+		}
+		if index[dir] == nil {
+			index[dir] = make(map[int64]int)
+		}
+		pos, ok := index[dir][item.TweetID]
+		if !ok {
+			pos = len(byDir[dir])
+			index[dir][item.TweetID] = pos
+			byDir[dir] = append(byDir[dir], TweetRecord{
+				TweetID:  strconv.FormatInt(item.TweetID, 10),
+				Username: item.Username,
+				Date:     item.Date,
+				URL:      fmt.Sprintf("https://x.com/%s/status/%d", item.Username, item.TweetID),
+				Media:    []string{},
+			})
+		}
+		record := &byDir[dir][pos]
+		if record.Content == "" {
+			record.Content = item.Content
+		}
+		if record.Card == nil {
+			record.Card = item.Card
+		}
+		if item.Type != "text" && !slices.Contains(record.Media, item.URL) {
+			record.Media = append(record.Media, item.URL)
+		}
+		record.FavoriteCount = max(record.FavoriteCount, item.FavoriteCount)
+		record.RetweetCount = max(record.RetweetCount, item.RetweetCount)
+		record.ReplyCount = max(record.ReplyCount, item.ReplyCount)
+		record.ViewCount = max(record.ViewCount, item.ViewCount)
+		record.BookmarkCount = max(record.BookmarkCount, item.BookmarkCount)
+	}
+	return byDir
+}
+
+// writeTweetsJSONL writes the tweets of a job's media to tweets.jsonl in each account folder
+func writeTweetsJSONL(store Storage, tasks []downloadTask, outputDir string) error {
+	var firstErr error
+	for dir, records := range tweetRecords(tasks, outputDir) {
+		if err := store.MkdirAll(dir); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to write %s: %v", tweetsJSONLFile, err)
+			}
+			continue
+		}
+		if err := mergeTweetsJSONL(store, filepath.Join(dir, tweetsJSONLFile), records); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// mergeTweetsJSONL merges records into a tweets.jsonl file, newest tweets first
+func mergeTweetsJSONL(store Storage, path string, records []TweetRecord) error {
+	merged := make(map[string]TweetRecord)
+	if r, err := store.Open(path); err == nil {
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 4*1024*1024)
+		for scanner.Scan() {
+			var record TweetRecord
+			if json.Unmarshal(scanner.Bytes(), &record) == nil && record.TweetID != "" {
+				merged[record.TweetID] = record
+			}
+		}
+		r.Close()
+	}
+	for _, record := range records {
+		// Media of the tweet that weren't part of this job stay listed
+		if previous, ok := merged[record.TweetID]; ok {
+			for _, url := range previous.Media {
+				if !slices.Contains(record.Media, url) {
+					record.Media = append(record.Media, url)
+				}
+			}
+		}
+		merged[record.TweetID] = record
+	}
+
+	ids := make([]string, 0, len(merged))
+	for id := range merged {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool {
+		return tweetIDLess(ids[j], ids[i])
+	})
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	for _, id := range ids {
+		if err := encoder.Encode(merged[id]); err != nil {
+			return fmt.Errorf("failed to encode tweet %s: %v", id, err)
+		}
+	}
+
+	// Write next to the file and swap, so an interrupted write keeps the old archive
+	tmp := path + ".tmp"
+	if err := writeStorageFile(store, tmp, &buf); err != nil {
+		return fmt.Errorf("failed to write %s: %v", tweetsJSONLFile, err)
+	}
+	if err := store.Rename(tmp, path); err != nil {
+		store.Remove(tmp)
+		return fmt.Errorf("failed to write %s: %v", tweetsJSONLFile, err)
+	}
+	return nil
+}
+
+// writeTweetTexts writes each tweet of a job's media to a .txt file in the tweets folder of its account
+func writeTweetTexts(store Storage, tasks []downloadTask, outputDir string) error {
+	var firstErr error
+	for dir, records := range tweetRecords(tasks, outputDir) {
+		textDir := filepath.Join(dir, tweetTextsFolder)
+		if err := store.MkdirAll(textDir); err != nil {
+			if firstErr == nil {
+				firstErr = fmt.Errorf("failed to write tweet text files: %v", err)
+			}
+			continue
+		}
+		for _, record := range records {
+			path := filepath.Join(textDir, record.TweetID+".txt")
+			if err := writeStorageFile(store, path, strings.NewReader(formatTweetText(record))); err != nil && firstErr == nil {
+				firstErr = fmt.Errorf("failed to write tweet %s: %v", record.TweetID, err)
+			}
+		}
+	}
+	return firstErr
+}
+
+// formatTweetText formats a tweet for its .txt file: the text, then the date, counts, link and media
+func formatTweetText(record TweetRecord) string {
+	var b strings.Builder
+	if record.Content != "" {
+		b.WriteString(record.Content)
+		b.WriteString("\n\n")
+	}
+	if card := formatCard(record.Card); card != "" {
+		b.WriteString(card)
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "Date: %s\n", record.Date)
+	fmt.Fprintf(&b, "Likes: %d, Retweets: %d, Replies: %d, Views: %d, Bookmarks: %d\n",
+		record.FavoriteCount, record.RetweetCount, record.ReplyCount, record.ViewCount, record.BookmarkCount)
+	fmt.Fprintf(&b, "URL: %s\n", record.URL)
+	for _, url := range record.Media {
+		fmt.Fprintf(&b, "Media: %s\n", url)
+	}
+	return b.String()
+}
@@ -4,6 +4,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/url"
 	"os"
@@ -11,6 +12,7 @@ import (
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 )
 
 // getExecutableName returns the appropriate executable name for the current OS
@@ -63,18 +65,20 @@ func parseExtractorError(output string, username string) string {
 		errorLine = errorLine[:300] + "..."
 	}
 
-	// Add context hint based on error type, but keep original message
+	// Add context hint based on error type, but keep original message (see messages.go)
 	var hint string
-	if strings.Contains(outputLower, "unable to retrieve tweets from this timeline") {
-		hint = " [Hint: End of timeline reached or rate limited - data already fetched has been saved]"
+	if retryAt := ParseRetryAt(output, time.Now()); !retryAt.IsZero() {
+		hint = rateLimitHint(retryAt)
+	} else if strings.Contains(outputLower, "unable to retrieve tweets from this timeline") {
+		hint = hintText(HintTimelineEnd)
 	} else if strings.Contains(outputLower, "rate limit") || strings.Contains(output, "429") {
-		hint = " [Hint: Wait 5-15 minutes before retrying]"
+		hint = hintText(HintRateLimited)
 	} else if strings.Contains(output, "401") || strings.Contains(outputLower, "unauthorized") {
-		hint = " [Hint: Auth token may be invalid or expired]"
+		hint = hintText(HintAuthInvalid)
 	} else if strings.Contains(output, "404") {
-		hint = fmt.Sprintf(" [Hint: @%s may not exist or is suspended]", username)
+		hint = hintText(HintAccountNotFound, "username", username)
 	} else if strings.Contains(outputLower, "protected") || strings.Contains(output, "403") {
-		hint = " [Hint: Protected account - need to follow and use auth token]"
+		hint = hintText(HintProtected)
 	}
 
 	return errorLine + hint
@@ -111,9 +115,10 @@ func (t *TweetIDString) UnmarshalJSON(data []byte) error {
 
 // Author represents tweet author information from extractor
 type Author struct {
-	ID   int64  `json:"id"`
-	Name string `json:"name"`
-	Nick string `json:"nick"`
+	ID       int64  `json:"id"`
+	Name     string `json:"name"`
+	Nick     string `json:"nick"`
+	Verified bool   `json:"verified,omitempty"`
 }
 
 // UserInfo represents full user information from extractor
@@ -139,51 +144,55 @@ type UserInfo struct {
 
 // CLIMediaItem represents a single media entry from extractor CLI
 type CLIMediaItem struct {
-	URL            string        `json:"url"`
-	TweetID        TweetIDString `json:"tweet_id"`
-	RetweetID      TweetIDString `json:"retweet_id"`
-	QuoteID        TweetIDString `json:"quote_id"`
-	ReplyID        TweetIDString `json:"reply_id"`
-	ConversationID TweetIDString `json:"conversation_id"`
-	Date           string        `json:"date"`
-	Extension      string        `json:"extension"`
-	Width          int           `json:"width"`
-	Height         int           `json:"height"`
-	Type           string        `json:"type"`
-	Bitrate        int           `json:"bitrate"`
-	Duration       float64       `json:"duration"`
-	Author         UserInfo      `json:"author"`
-	User           UserInfo      `json:"user"`
-	Content        string        `json:"content"`
-	FavoriteCount  int           `json:"favorite_count"`
-	RetweetCount   int           `json:"retweet_count"`
-	ReplyCount     int           `json:"reply_count"`
-	QuoteCount     int           `json:"quote_count"`
-	BookmarkCount  int           `json:"bookmark_count"`
-	ViewCount      int           `json:"view_count"`
-	Source         string        `json:"source"`
-	Sensitive      bool          `json:"sensitive"`
+	URL            string          `json:"url"`
+	TweetID        TweetIDString   `json:"tweet_id"`
+	RetweetID      TweetIDString   `json:"retweet_id"`
+	QuoteID        TweetIDString   `json:"quote_id"`
+	ReplyID        TweetIDString   `json:"reply_id"`
+	ConversationID TweetIDString   `json:"conversation_id"`
+	Date           string          `json:"date"`
+	Extension      string          `json:"extension"`
+	Width          int             `json:"width"`
+	Height         int             `json:"height"`
+	Type           string          `json:"type"`
+	Bitrate        int             `json:"bitrate"`
+	Duration       float64         `json:"duration"`
+	Author         UserInfo        `json:"author"`
+	User           UserInfo        `json:"user"`
+	Content        string          `json:"content"`
+	FavoriteCount  int             `json:"favorite_count"`
+	RetweetCount   int             `json:"retweet_count"`
+	ReplyCount     int             `json:"reply_count"`
+	QuoteCount     int             `json:"quote_count"`
+	BookmarkCount  int             `json:"bookmark_count"`
+	ViewCount      int             `json:"view_count"`
+	Source         string          `json:"source"`
+	Sensitive      bool            `json:"sensitive"`
+	Card           json.RawMessage `json:"card,omitempty"` // Poll or link preview, see parseCard
+	Num            int             `json:"num"`            // 1-based position of the media in its tweet
 }
 
 // TweetMetadata represents tweet metadata from extractor
 type TweetMetadata struct {
-	TweetID        TweetIDString `json:"tweet_id"`
-	RetweetID      TweetIDString `json:"retweet_id,omitempty"`
-	QuoteID        TweetIDString `json:"quote_id,omitempty"`
-	ReplyID        TweetIDString `json:"reply_id,omitempty"`
-	ConversationID TweetIDString `json:"conversation_id,omitempty"`
-	Date           string        `json:"date"`
-	Author         Author        `json:"author"`
-	Content        string        `json:"content"`
-	Lang           string        `json:"lang,omitempty"`
-	Hashtags       []string      `json:"hashtags,omitempty"`
-	FavoriteCount  int           `json:"favorite_count"`
-	RetweetCount   int           `json:"retweet_count"`
-	QuoteCount     int           `json:"quote_count,omitempty"`
-	ReplyCount     int           `json:"reply_count,omitempty"`
-	BookmarkCount  int           `json:"bookmark_count,omitempty"`
-	ViewCount      int           `json:"view_count,omitempty"`
-	Sensitive      bool          `json:"sensitive,omitempty"`
+	TweetID        TweetIDString   `json:"tweet_id"`
+	RetweetID      TweetIDString   `json:"retweet_id,omitempty"`
+	QuoteID        TweetIDString   `json:"quote_id,omitempty"`
+	ReplyID        TweetIDString   `json:"reply_id,omitempty"`
+	ConversationID TweetIDString   `json:"conversation_id,omitempty"`
+	Date           string          `json:"date"`
+	Author         Author          `json:"author"`
+	Content        string          `json:"content"`
+	Lang           string          `json:"lang,omitempty"`
+	Hashtags       []string        `json:"hashtags,omitempty"`
+	FavoriteCount  int             `json:"favorite_count"`
+	RetweetCount   int             `json:"retweet_count"`
+	QuoteCount     int             `json:"quote_count,omitempty"`
+	ReplyCount     int             `json:"reply_count,omitempty"`
+	BookmarkCount  int             `json:"bookmark_count,omitempty"`
+	ViewCount      int             `json:"view_count,omitempty"`
+	Sensitive      bool            `json:"sensitive,omitempty"`
+	Source         string          `json:"source,omitempty"`
+	Card           json.RawMessage `json:"card,omitempty"` // Poll or link preview, see parseCard
 }
 
 // CLIResponse represents the raw response from extractor CLI
@@ -202,6 +211,9 @@ type TimelineEntry struct {
 	TweetID          TweetIDString `json:"tweet_id"`
 	Type             string        `json:"type"`
 	IsRetweet        bool          `json:"is_retweet"`
+	QuoteID          TweetIDString `json:"quote_id,omitempty"`        // Tweet quoted by this one
+	ReplyID          TweetIDString `json:"reply_id,omitempty"`        // Tweet this one replies to
+	ConversationID   TweetIDString `json:"conversation_id,omitempty"` // First tweet of the thread
 	Extension        string        `json:"extension"`
 	Width            int           `json:"width"`
 	Height           int           `json:"height"`
@@ -215,17 +227,26 @@ type TimelineEntry struct {
 	Verified         bool          `json:"verified,omitempty"`
 	OriginalFilename string        `json:"original_filename,omitempty"` // Original filename from API
 	AuthorUsername   string        `json:"author_username,omitempty"`   // Username of tweet author (for bookmarks and likes)
+	Card             *TweetCard    `json:"card,omitempty"`              // Poll or link preview of the tweet
+	EstimatedSize    int64         `json:"estimated_size,omitempty"`    // Approximate file size in bytes, see mediastats.go
+	MediaIndex       int           `json:"media_index,omitempty"`       // 1-based position of the media in its tweet as posted
 }
 
 // AccountInfo represents Twitter account information (derived from metadata)
 type AccountInfo struct {
+	ID             string `json:"id,omitempty"` // Numeric user ID, stays the same when the account is renamed
 	Name           string `json:"name"`
 	Nick           string `json:"nick"`
 	Date           string `json:"date"`
 	FollowersCount int    `json:"followers_count"`
 	FriendsCount   int    `json:"friends_count"`
-	ProfileImage   string `json:"profile_image"`
+	ProfileImage   string `json:"profile_image"` // Small avatar as reported, see profileimages.go
 	StatusesCount  int    `json:"statuses_count"`
+	MediaCount     int    `json:"media_count,omitempty"`
+
+	ProfileImageOriginal  string `json:"profile_image_original,omitempty"`  // Avatar at its original size
+	ProfileBanner         string `json:"profile_banner,omitempty"`          // Banner as reported
+	ProfileBannerOriginal string `json:"profile_banner_original,omitempty"` // Banner at its original size
 }
 
 // ExtractMetadata represents extraction metadata
@@ -236,6 +257,8 @@ type ExtractMetadata struct {
 	HasMore    bool   `json:"has_more"`
 	Cursor     string `json:"cursor,omitempty"`    // Cursor for resume capability
 	Completed  bool   `json:"completed,omitempty"` // True if all media fetched
+
+	Stats *TimelineStats `json:"stats,omitempty"` // Breakdown of the timeline by media type
 }
 
 // TwitterResponse represents the full response for frontend
@@ -246,56 +269,104 @@ type TwitterResponse struct {
 	Metadata    ExtractMetadata `json:"metadata"`
 	Cursor      string          `json:"cursor,omitempty"`    // Cursor for next fetch
 	Completed   bool            `json:"completed,omitempty"` // True if fetch completed
+	Partial     bool            `json:"partial,omitempty"`   // True if the extractor failed mid-run and only the entries fetched so far are returned
+	Error       string          `json:"error,omitempty"`     // Extractor error for partial results
+	RetryAt     string          `json:"retry_at,omitempty"`  // RFC 3339 time a rate limit resets, if the partial result was rate limited
+
+	// Sensitive tweets that came back without media (age-restricted for this session), see applyRestricted
+	Restricted          int      `json:"restricted,omitempty"`
+	RestrictedRecovered int      `json:"restricted_recovered,omitempty"` // Fetched with the restricted auth token
+	RestrictedTweetIDs  []string `json:"restricted_tweet_ids,omitempty"` // Still without media
+
+	// Timeline grouped by tweet instead of Timeline, see GroupByTweet
+	Tweets []TimelineTweet `json:"tweets,omitempty"`
 }
 
 // TimelineRequest represents request parameters for timeline extraction
 type TimelineRequest struct {
-	Username     string `json:"username"`
-	AuthToken    string `json:"auth_token"`
-	TimelineType string `json:"timeline_type"` // media, timeline, tweets, with_replies, likes, bookmarks
-	BatchSize    int    `json:"batch_size"`    // 0 = all
-	Page         int    `json:"page"`
-	MediaType    string `json:"media_type"` // all, image, video, gif
-	Retweets     bool   `json:"retweets"`
-	Cursor       string `json:"cursor,omitempty"` // Resume from this cursor position
+	Username     string         `json:"username"`
+	AuthToken    string         `json:"auth_token"`
+	TimelineType string         `json:"timeline_type"` // media, timeline, tweets, with_replies, highlights, likes, bookmarks
+	BatchSize    int            `json:"batch_size"`    // 0 = all
+	Page         int            `json:"page"`
+	MediaType    string         `json:"media_type"` // all, image, video, gif
+	Retweets     bool           `json:"retweets"`
+	Cursor       string         `json:"cursor,omitempty"`    // Resume from this cursor position
+	Filter       TimelineFilter `json:"filter,omitempty"`    // Optional filters applied during conversion
+	DateZone     string         `json:"date_zone,omitempty"` // Convert dates to utc or local ("" = as reported)
+
+	// Auth token of an account that can see age-restricted media, used to fetch them again
+	RestrictedAuthToken string `json:"restricted_auth_token,omitempty"`
+
+	// OnProgress receives progress reports while the extractor runs, see extractprogress.go (not persisted)
+	OnProgress func(ExtractProgress) `json:"-"`
 }
 
 // DateRangeRequest represents request parameters for date range extraction
 type DateRangeRequest struct {
-	Username    string `json:"username"`
-	AuthToken   string `json:"auth_token"`
-	StartDate   string `json:"start_date"` // YYYY-MM-DD
-	EndDate     string `json:"end_date"`   // YYYY-MM-DD
-	MediaFilter string `json:"media_filter"`
-	Retweets    bool   `json:"retweets"`
+	Username    string         `json:"username"`
+	AuthToken   string         `json:"auth_token"`
+	StartDate   string         `json:"start_date"` // YYYY-MM-DD
+	EndDate     string         `json:"end_date"`   // YYYY-MM-DD
+	MediaFilter string         `json:"media_filter"`
+	Retweets    bool           `json:"retweets"`
+	Filter      TimelineFilter `json:"filter,omitempty"`    // Optional filters applied during conversion
+	DateZone    string         `json:"date_zone,omitempty"` // Convert dates to utc or local ("" = as reported)
+
+	// Auth token of an account that can see age-restricted media, used to fetch them again
+	RestrictedAuthToken string `json:"restricted_auth_token,omitempty"`
+
+	// OnProgress receives progress reports while the extractor runs, see extractprogress.go (not persisted)
+	OnProgress func(ExtractProgress) `json:"-"`
+}
+
+// TimelineTypeInfo describes a timeline that can be fetched
+type TimelineTypeInfo struct {
+	ID            string `json:"id"`
+	Label         string `json:"label"`
+	Path          string `json:"path"`           // URL path after x.com/<user>, or the full path for own timelines
+	NeedsUsername bool   `json:"needs_username"` // False for the timelines of the auth token's own account
+	Premium       bool   `json:"premium"`        // Only exists for X Premium accounts, empty otherwise
+}
+
+// timelineTypes lists the supported timeline types, the first one is the default
+var timelineTypes = []TimelineTypeInfo{
+	{ID: "timeline", Label: "Timeline", Path: "/timeline", NeedsUsername: true}, // Best for cursor support
+	{ID: "media", Label: "Media", Path: "/media", NeedsUsername: true},
+	{ID: "tweets", Label: "Tweets", Path: "/tweets", NeedsUsername: true},
+	{ID: "with_replies", Label: "Replies", Path: "/with_replies", NeedsUsername: true},
+	{ID: "highlights", Label: "Highlights", Path: "/highlights", NeedsUsername: true, Premium: true},
+	{ID: "likes", Label: "Likes", Path: "/likes", NeedsUsername: true},
+	{ID: "bookmarks", Label: "Bookmarks", Path: "/i/bookmarks"},
+}
+
+// GetTimelineTypes returns the supported timeline types
+func GetTimelineTypes() []TimelineTypeInfo {
+	types := make([]TimelineTypeInfo, len(timelineTypes))
+	copy(types, timelineTypes)
+	return types
+}
+
+// getTimelineType returns the timeline type with the given ID, the default one if it's unknown
+func getTimelineType(id string) TimelineTypeInfo {
+	for _, t := range timelineTypes {
+		if t.ID == id {
+			return t
+		}
+	}
+	return timelineTypes[0] // Default to timeline for reliable cursor
 }
 
 // buildTwitterURL constructs the Twitter URL based on username and timeline type
 func buildTwitterURL(username, timelineType string) string {
+	t := getTimelineType(timelineType)
 	// Special case: bookmarks don't need username
-	if timelineType == "bookmarks" {
-		return "https://x.com/i/bookmarks"
+	if !t.NeedsUsername {
+		return "https://x.com" + t.Path
 	}
 
 	// Clean username - extract handle from URL if needed
-	username = cleanUsername(username)
-
-	// Build URL based on timeline type
-	baseURL := "https://x.com/" + username
-	switch timelineType {
-	case "media":
-		return baseURL + "/media"
-	case "timeline":
-		return baseURL + "/timeline" // Best for cursor support
-	case "tweets":
-		return baseURL + "/tweets"
-	case "with_replies":
-		return baseURL + "/with_replies"
-	case "likes":
-		return baseURL + "/likes"
-	default:
-		return baseURL + "/timeline" // Default to timeline for reliable cursor
-	}
+	return "https://x.com/" + cleanUsername(username) + t.Path
 }
 
 // cleanUsername extracts the handle from different input formats
@@ -388,6 +459,9 @@ func convertMetadataToTimelineEntry(meta TweetMetadata) TimelineEntry {
 		TweetID:        meta.TweetID,
 		Type:           "text",
 		IsRetweet:      meta.RetweetID != 0,
+		QuoteID:        meta.QuoteID,
+		ReplyID:        meta.ReplyID,
+		ConversationID: meta.ConversationID,
 		Extension:      "txt",
 		Width:          0,
 		Height:         0,
@@ -397,7 +471,9 @@ func convertMetadataToTimelineEntry(meta TweetMetadata) TimelineEntry {
 		FavoriteCount:  meta.FavoriteCount,
 		RetweetCount:   meta.RetweetCount,
 		ReplyCount:     meta.ReplyCount,
+		Source:         meta.Source,
 		AuthorUsername: meta.Author.Name,
+		Card:           parseCard(meta.Card),
 	}
 }
 
@@ -420,6 +496,9 @@ func convertToTimelineEntry(media CLIMediaItem) TimelineEntry {
 		Width:          media.Width,
 		Height:         media.Height,
 		IsRetweet:      media.RetweetID != 0,
+		QuoteID:        media.QuoteID,
+		ReplyID:        media.ReplyID,
+		ConversationID: media.ConversationID,
 		Content:        media.Content,
 		ViewCount:      media.ViewCount,
 		BookmarkCount:  media.BookmarkCount,
@@ -429,6 +508,8 @@ func convertToTimelineEntry(media CLIMediaItem) TimelineEntry {
 		Source:         media.Source,
 		Verified:       media.Author.Verified,
 		AuthorUsername: authorUsername,
+		Card:           parseCard(media.Card),
+		MediaIndex:     media.Num,
 		// OriginalFilename will be extracted from URL in download.go
 	}
 
@@ -445,23 +526,20 @@ func convertToTimelineEntry(media CLIMediaItem) TimelineEntry {
 			entry.Type = "photo"
 		}
 	}
+	entry.EstimatedSize = estimateMediaSize(entry.Type, media)
 
 	return entry
 }
 
 // getExtractorPath returns the path to extractor binary
-// Binary is stored in ~/.twitterxmediabatchdownloader/ (same as ffmpeg and database)
+// Binary is stored in the app data folder (same as ffmpeg and database)
 func getExtractorPath() string {
-	homeDir, _ := os.UserHomeDir()
-	baseDir := filepath.Join(homeDir, ".twitterxmediabatchdownloader")
-	return filepath.Join(baseDir, getExecutableName())
+	return filepath.Join(GetAppDataDir(), getExecutableName())
 }
 
 // getHashFilePath returns the path to the hash file for version checking
 func getHashFilePath() string {
-	homeDir, _ := os.UserHomeDir()
-	baseDir := filepath.Join(homeDir, ".twitterxmediabatchdownloader")
-	return filepath.Join(baseDir, "extractor.sha256")
+	return filepath.Join(GetAppDataDir(), "extractor.sha256")
 }
 
 // calculateHash calculates SHA256 hash of data
@@ -489,18 +567,28 @@ func ensureExtractor() (string, error) {
 	if _, err := os.Stat(exePath); err == nil {
 		// Binary exists - check hash
 		if storedHash, err := os.ReadFile(hashPath); err == nil {
-			if string(storedHash) == embeddedHash {
+			// The copy on disk must still be the embedded binary before it's run
+			if string(storedHash) == embeddedHash && verifyFileHash(exePath, embeddedHash) == nil {
 				return exePath, nil // Already extracted and up to date
 			}
 		}
-		// Hash differs or missing - need to update
-		os.Remove(exePath)
+		// Hash differs, missing or the binary was modified - need to update
 	}
 
-	// Extract binary
-	if err := os.WriteFile(exePath, extractorBin, 0755); err != nil {
+	// Write next to the old binary and swap it in once complete, a crash mid-write must not leave half a binary
+	staged := exePath + ".new"
+	if err := os.WriteFile(staged, extractorBin, 0755); err != nil {
+		os.Remove(staged)
 		return "", fmt.Errorf("failed to write extractor: %v", err)
 	}
+	if hash, err := calculateSHA256(staged); err != nil || hash != embeddedHash {
+		os.Remove(staged)
+		return "", fmt.Errorf("failed to write extractor: written file doesn't match the embedded binary")
+	}
+	if err := replaceWithBackup(staged, exePath); err != nil {
+		os.Remove(staged)
+		return "", err
+	}
 
 	// Save hash for future comparison
 	if err := os.WriteFile(hashPath, []byte(embeddedHash), 0644); err != nil {
@@ -511,34 +599,36 @@ func ensureExtractor() (string, error) {
 	return exePath, nil
 }
 
+// resolveTimelineType returns the timeline a request is fetched from
+func resolveTimelineType(req TimelineRequest) string {
+	if req.TimelineType != "" {
+		return req.TimelineType
+	}
+	// Determine the right endpoint based on what user wants:
+	// - Media (all/image/video/gif): Use /media endpoint - fastest and most reliable
+	// - Text tweets: Use /tweets endpoint with --text-tweets
+	// - With retweets: Use /tweets endpoint (retweets not available on /media)
+	if req.MediaType == "text" || req.Retweets {
+		return "tweets"
+	}
+	return "media"
+}
+
 // ExtractTimeline extracts media from user timeline using the new CLI
 func ExtractTimeline(req TimelineRequest) (*TwitterResponse, error) {
+	// Sensitive accounts can't be fetched while the content lock is locked
+	if err := checkFetchAllowed(req.Username); err != nil {
+		return nil, err
+	}
+
 	// Get or extract extractor binary (persistent, not temp)
 	exePath, err := ensureExtractor()
 	if err != nil {
 		return nil, err
 	}
 
-	// Determine the right endpoint based on what user wants:
-	// - Media (all/image/video/gif): Use /media endpoint - fastest and most reliable
-	// - Text tweets: Use /tweets endpoint with --text-tweets
-	// - With retweets: Use /tweets endpoint (retweets not available on /media)
 	isTextOnly := req.MediaType == "text"
-	wantsRetweets := req.Retweets
-
-	timelineType := req.TimelineType
-	if timelineType == "" {
-		if isTextOnly {
-			// Text-only tweets need /tweets endpoint
-			timelineType = "tweets"
-		} else if wantsRetweets {
-			// Retweets need /tweets endpoint (not available on /media)
-			timelineType = "tweets"
-		} else {
-			// Default: /media endpoint - fastest for media-only fetch
-			timelineType = "media"
-		}
-	}
+	timelineType := resolveTimelineType(req)
 
 	url := buildTwitterURL(req.Username, timelineType)
 
@@ -546,15 +636,16 @@ func ExtractTimeline(req TimelineRequest) (*TwitterResponse, error) {
 	// Format: extractor.exe URL --auth-token TOKEN --json [options]
 	args := []string{url}
 
-	// Add auth token
-	if req.AuthToken != "" {
-		args = append(args, "--auth-token", req.AuthToken)
+	// Add auth token, the first usable one of a list (see rotation.go)
+	tokens := pickAuthTokens(req.AuthToken)
+	if len(tokens) > 0 {
+		args = append(args, "--auth-token", tokens[0])
 	} else {
 		args = append(args, "--guest")
 	}
 
-	// Always request JSON output with metadata
-	args = append(args, "--json", "--metadata")
+	// Always request streamed JSON output with metadata
+	args = append(args, "--ndjson", "--metadata")
 
 	// Add limit if specified
 	if req.BatchSize > 0 {
@@ -592,55 +683,41 @@ func ExtractTimeline(req TimelineRequest) (*TwitterResponse, error) {
 		args = append(args, "--cursor", req.Cursor)
 	}
 
-	// Execute command with UTF-8 encoding
-	cmd := exec.Command(exePath, args...)
-	cmd.Env = append(os.Environ(),
-		"PYTHONIOENCODING=utf-8",
-		"PYTHONUTF8=1",
-	)
-	hideWindow(cmd) // Hide console window on Windows
-	output, err := cmd.CombinedOutput()
-
-	// Ensure process is killed after completion
-	if cmd.Process != nil {
-		cmd.Process.Kill()
-	}
-
-	if err != nil {
-		outputStr := string(output)
-		errorMsg := parseExtractorError(outputStr, req.Username)
-		return nil, fmt.Errorf("%s", errorMsg)
-	}
-
-	// Find JSON in output (skip any info messages)
-	jsonStr := extractJSON(string(output))
-	if jsonStr == "" {
-		outputStr := string(output)
-		if strings.TrimSpace(outputStr) == "" {
-			return nil, fmt.Errorf("empty_response: Extractor returned no data. The timeline may be empty or inaccessible")
-		}
-		return nil, fmt.Errorf("parse_error: Could not parse extractor output. Raw output: %s", outputStr)
+	// Stream and convert the extractor output
+	collector := newTimelineCollector(req.Filter)
+	collector.includeMedia = !isTextOnly
+	collector.includeText = isTextOnly
+	collector.textFallback = !isTextOnly // Text-only tweets (no media) when the timeline has no media at all
+
+	progress := newExtractProgress(req.Username, fetchStatsKind(timelineType), req.OnProgress)
+	progress.sizeCount = timelineSizeCount(timelineType)
+	handler := progress.handler(collector.handler())
+
+	started := time.Now()
+	cliResponse, err := runExtractorStream(exePath, args, req.Username, handler)
+	if err != nil && len(tokens) == 0 {
+		// Rate-limited guests continue with a new guest token, see rotation.go
+		cliResponse, err = rotateGuestToken(exePath, args, req.Username, req.BatchSize, cliResponse, err, handler)
+	} else if err != nil && len(tokens) > 1 {
+		// and rate-limited tokens with the next token
+		cliResponse, err = rotateAuthTokens(exePath, args, req.Username, req.BatchSize, cliResponse, err, handler, tokens)
+	}
+	progress.finish(err)
+	var partial *PartialResultError
+	if err != nil && !errors.As(err, &partial) {
+		return nil, err
 	}
-
-	// Parse CLI response
-	var cliResponse CLIResponse
-	if err := json.Unmarshal([]byte(jsonStr), &cliResponse); err != nil {
-		return nil, fmt.Errorf("json_error: Failed to parse JSON response: %v", err)
+	if partial == nil {
+		recordFetchLatency(timelineType, time.Since(started), collector.tweets)
 	}
+	timeline := collector.finish()
+	NormalizeTimelineDates(timeline, req.DateZone)
 
-	// Convert to frontend format
-	var timeline []TimelineEntry
 	accountInfo := AccountInfo{
 		Name: req.Username,
 		Nick: req.Username,
 	}
 
-	// Build a set of tweet IDs that have media
-	mediaTweetIDs := make(map[int64]bool)
-	for _, media := range cliResponse.Media {
-		mediaTweetIDs[int64(media.TweetID)] = true
-	}
-
 	// For bookmarks and likes, keep name as "bookmarks"/"likes" (not from author tweet)
 	isBookmarks := req.TimelineType == "bookmarks"
 	isLikes := req.TimelineType == "likes"
@@ -652,91 +729,23 @@ func ExtractTimeline(req TimelineRequest) (*TwitterResponse, error) {
 		accountInfo.Nick = "My Likes"
 	}
 
-	if isTextOnly {
-		// Text-only mode: get tweets from metadata that don't have media
-		timeline = make([]TimelineEntry, 0)
-		for _, meta := range cliResponse.Metadata {
-			if !mediaTweetIDs[int64(meta.TweetID)] {
-				timeline = append(timeline, convertMetadataToTimelineEntry(meta))
-			}
-		}
-
-		// Get account info from first media item if available, otherwise from metadata
-		if !isBookmarks && !isLikes {
-			if len(cliResponse.Media) > 0 {
-				user := cliResponse.Media[0].User
-				accountInfo.Name = user.Name
-				accountInfo.Nick = user.Nick
-				accountInfo.Date = user.Date
-				accountInfo.FollowersCount = user.FollowersCount
-				accountInfo.FriendsCount = user.FriendsCount
-				accountInfo.ProfileImage = user.ProfileImage
-				accountInfo.StatusesCount = user.StatusesCount
-			} else if len(cliResponse.Metadata) > 0 {
-				firstMeta := cliResponse.Metadata[0]
-				accountInfo.Name = firstMeta.Author.Name
-				accountInfo.Nick = firstMeta.Author.Nick
-			}
-		} else {
-			// For bookmarks and likes, get other info from first media item if available
-			if len(cliResponse.Media) > 0 {
-				user := cliResponse.Media[0].User
-				accountInfo.Date = user.Date
-				accountInfo.FollowersCount = user.FollowersCount
-				accountInfo.FriendsCount = user.FriendsCount
-				accountInfo.ProfileImage = user.ProfileImage
-				accountInfo.StatusesCount = user.StatusesCount
-			}
-		}
-	} else if len(cliResponse.Media) > 0 {
-		// Normal media items only (text tweets handled separately with "text" media type)
-		timeline = make([]TimelineEntry, 0, len(cliResponse.Media))
-
-		// Add media items
-		for _, media := range cliResponse.Media {
-			timeline = append(timeline, convertToTimelineEntry(media))
-		}
-
-		// Get account info from first media item
-		user := cliResponse.Media[0].User
+	// Get account info from first media item if available, otherwise from metadata
+	if user := collector.firstUser; user != nil {
 		if !isBookmarks && !isLikes {
+			accountInfo.ID = formatUserID(user.ID)
 			accountInfo.Name = user.Name
 			accountInfo.Nick = user.Nick
 		}
 		accountInfo.Date = user.Date
 		accountInfo.FollowersCount = user.FollowersCount
 		accountInfo.FriendsCount = user.FriendsCount
-		accountInfo.ProfileImage = user.ProfileImage
+		accountInfo.setProfileImages(*user)
 		accountInfo.StatusesCount = user.StatusesCount
-	} else if len(cliResponse.Metadata) > 0 {
-		// Fallback: Text-only tweets (no media) - convert metadata to timeline entries
-		timeline = make([]TimelineEntry, 0, len(cliResponse.Metadata))
-		for _, meta := range cliResponse.Metadata {
-			entry := TimelineEntry{
-				URL:            "", // No media URL for text tweets
-				TweetID:        meta.TweetID,
-				Date:           meta.Date,
-				Type:           "text",
-				IsRetweet:      meta.RetweetID != 0,
-				Extension:      "txt",
-				Width:          0,
-				Height:         0,
-				Content:        meta.Content,
-				ViewCount:      meta.ViewCount,
-				BookmarkCount:  meta.BookmarkCount,
-				FavoriteCount:  meta.FavoriteCount,
-				RetweetCount:   meta.RetweetCount,
-				ReplyCount:     meta.ReplyCount,
-				AuthorUsername: meta.Author.Name,
-			}
-			timeline = append(timeline, entry)
-		}
-		// Get account info from first metadata
-		if !isBookmarks && !isLikes {
-			firstMeta := cliResponse.Metadata[0]
-			accountInfo.Name = firstMeta.Author.Name
-			accountInfo.Nick = firstMeta.Author.Nick
-		}
+		accountInfo.MediaCount = user.MediaCount
+	} else if meta := collector.firstMeta; meta != nil && !isBookmarks && !isLikes {
+		accountInfo.ID = formatUserID(meta.Author.ID)
+		accountInfo.Name = meta.Author.Name
+		accountInfo.Nick = meta.Author.Nick
 	}
 
 	// Determine if there's more data to fetch
@@ -757,12 +766,28 @@ func ExtractTimeline(req TimelineRequest) (*TwitterResponse, error) {
 		Cursor:    cliResponse.Cursor,
 		Completed: cliResponse.Completed,
 	}
+	if !isTextOnly {
+		applyRestricted(response, collector.restricted(), req.RestrictedAuthToken, firstAuthToken(req.AuthToken), req.MediaType, req.Filter, req.DateZone)
+	}
+	response.Metadata.Stats = computeTimelineStats(response.Timeline)
+	if partial != nil {
+		response.Partial = true
+		response.Error = partial.Message
+		if retryAt := RetryAtFromMessage(partial.Message); !retryAt.IsZero() {
+			response.RetryAt = retryAt.UTC().Format(time.RFC3339)
+		}
+	}
 
 	return response, nil
 }
 
 // ExtractDateRange extracts media based on date range using the new CLI
 func ExtractDateRange(req DateRangeRequest) (*TwitterResponse, error) {
+	// Sensitive accounts can't be fetched while the content lock is locked
+	if err := checkFetchAllowed(req.Username); err != nil {
+		return nil, err
+	}
+
 	// Get or extract extractor binary (persistent, not temp)
 	exePath, err := ensureExtractor()
 	if err != nil {
@@ -775,15 +800,16 @@ func ExtractDateRange(req DateRangeRequest) (*TwitterResponse, error) {
 	// Build command arguments
 	args := []string{url}
 
-	// Add auth token
-	if req.AuthToken != "" {
-		args = append(args, "--auth-token", req.AuthToken)
+	// Add auth token, the first usable one of a list (see rotation.go)
+	tokens := pickAuthTokens(req.AuthToken)
+	if len(tokens) > 0 {
+		args = append(args, "--auth-token", tokens[0])
 	} else {
 		args = append(args, "--guest")
 	}
 
-	// Always request JSON output with metadata
-	args = append(args, "--json", "--metadata")
+	// Always request streamed JSON output with metadata
+	args = append(args, "--ndjson", "--metadata")
 
 	if req.Retweets {
 		args = append(args, "--retweets", "include")
@@ -796,79 +822,52 @@ func ExtractDateRange(req DateRangeRequest) (*TwitterResponse, error) {
 		args = append(args, "--text-tweets")
 	}
 
-	// Execute command with UTF-8 encoding
-	cmd := exec.Command(exePath, args...)
-	cmd.Env = append(os.Environ(),
-		"PYTHONIOENCODING=utf-8",
-		"PYTHONUTF8=1",
-	)
-	hideWindow(cmd)
-	output, err := cmd.CombinedOutput()
-
-	// Ensure process is killed after completion
-	if cmd.Process != nil {
-		cmd.Process.Kill()
-	}
-
-	if err != nil {
-		outputStr := string(output)
-		errorMsg := parseExtractorError(outputStr, req.Username)
-		return nil, fmt.Errorf("%s", errorMsg)
-	}
+	// Stream and convert the extractor output
+	collector := newTimelineCollector(req.Filter)
+	collector.includeText = isTextOnly
 
-	// Find JSON in output (skip any info messages)
-	jsonStr := extractJSON(string(output))
-	if jsonStr == "" {
-		outputStr := string(output)
-		if strings.TrimSpace(outputStr) == "" {
-			return nil, fmt.Errorf("empty_response: Extractor returned no data. The timeline may be empty or inaccessible")
-		}
-		return nil, fmt.Errorf("parse_error: Could not parse extractor output. Raw output: %s", outputStr)
-	}
+	progress := newExtractProgress(req.Username, fetchStatsSearchKind, req.OnProgress)
+	handler := progress.handler(collector.handler())
 
-	// Parse CLI response
-	var cliResponse CLIResponse
-	if err := json.Unmarshal([]byte(jsonStr), &cliResponse); err != nil {
-		return nil, fmt.Errorf("json_error: Failed to parse JSON response: %v", err)
+	started := time.Now()
+	cliResponse, err := runExtractorStream(exePath, args, req.Username, handler)
+	if err != nil && len(tokens) == 0 {
+		// Rate-limited guests continue with a new guest token, see rotation.go
+		cliResponse, err = rotateGuestToken(exePath, args, req.Username, 0, cliResponse, err, handler)
+	} else if err != nil && len(tokens) > 1 {
+		// and rate-limited tokens with the next token
+		cliResponse, err = rotateAuthTokens(exePath, args, req.Username, 0, cliResponse, err, handler, tokens)
 	}
-
-	// Convert to frontend format
-	mediaTweetIDs := make(map[int64]bool)
-	for _, media := range cliResponse.Media {
-		mediaTweetIDs[int64(media.TweetID)] = true
+	progress.finish(err)
+	var partial *PartialResultError
+	if err != nil && !errors.As(err, &partial) {
+		return nil, err
 	}
-
-	timeline := make([]TimelineEntry, 0, len(cliResponse.Media)+len(cliResponse.Metadata))
-	for _, media := range cliResponse.Media {
-		timeline = append(timeline, convertToTimelineEntry(media))
-	}
-
-	if isTextOnly {
-		for _, meta := range cliResponse.Metadata {
-			if !mediaTweetIDs[int64(meta.TweetID)] {
-				timeline = append(timeline, convertMetadataToTimelineEntry(meta))
-			}
-		}
+	if partial == nil {
+		recordFetchLatency(fetchStatsSearchKind, time.Since(started), collector.tweets)
 	}
+	timeline := collector.finish()
+	NormalizeTimelineDates(timeline, req.DateZone)
 
 	// Build account info from first media item (has full user info)
 	accountInfo := AccountInfo{
 		Name: req.Username,
 		Nick: req.Username,
 	}
-	if len(cliResponse.Media) > 0 {
-		user := cliResponse.Media[0].User
+	if user := collector.firstUser; user != nil {
+		accountInfo.ID = formatUserID(user.ID)
 		accountInfo.Name = user.Name
 		accountInfo.Nick = user.Nick
 		accountInfo.Date = user.Date
 		accountInfo.FollowersCount = user.FollowersCount
 		accountInfo.FriendsCount = user.FriendsCount
-		accountInfo.ProfileImage = user.ProfileImage
+		accountInfo.setProfileImages(*user)
 		accountInfo.StatusesCount = user.StatusesCount
-	} else if len(cliResponse.Metadata) > 0 {
-		firstMeta := cliResponse.Metadata[0]
-		accountInfo.Name = firstMeta.Author.Name
-		accountInfo.Nick = firstMeta.Author.Nick
+		accountInfo.MediaCount = user.MediaCount
+	} else if meta := collector.firstMeta; meta != nil {
+		accountInfo.ID = formatUserID(meta.Author.ID)
+		accountInfo.Name = meta.Author.Name
+		accountInfo.Nick = meta.Author.Nick
 	}
 
 	// Determine if there's more data to fetch
@@ -889,31 +888,17 @@ func ExtractDateRange(req DateRangeRequest) (*TwitterResponse, error) {
 		Cursor:    cliResponse.Cursor,
 		Completed: cliResponse.Completed,
 	}
-
-	return response, nil
-}
-
-// extractJSON finds and extracts JSON object from output string
-func extractJSON(output string) string {
-	// Find the start of JSON object
-	start := strings.Index(output, "{")
-	if start == -1 {
-		return ""
-	}
-
-	// Find the matching closing brace
-	depth := 0
-	for i := start; i < len(output); i++ {
-		switch output[i] {
-		case '{':
-			depth++
-		case '}':
-			depth--
-			if depth == 0 {
-				return output[start : i+1]
-			}
+	if !isTextOnly {
+		applyRestricted(response, collector.restricted(), req.RestrictedAuthToken, firstAuthToken(req.AuthToken), mediaFilter, req.Filter, req.DateZone)
+	}
+	response.Metadata.Stats = computeTimelineStats(response.Timeline)
+	if partial != nil {
+		response.Partial = true
+		response.Error = partial.Message
+		if retryAt := RetryAtFromMessage(partial.Message); !retryAt.IsZero() {
+			response.RetryAt = retryAt.UTC().Format(time.RFC3339)
 		}
 	}
 
-	return ""
+	return response, nil
 }
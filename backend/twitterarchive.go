@@ -0,0 +1,403 @@
+package backend
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/url"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Twitter data export import
+//
+// The archive X lets users download from their settings (a ZIP with data/tweets.js, data/like.js,
+// ...) lists every tweet and like of the account, also those far beyond what the timelines reach.
+// Tweets carry their media, so they become timeline entries directly. Likes only carry the tweet
+// ID and text: each liked tweet is looked up with the extractor to find its media, liked tweets
+// without media are kept as text entries. Lookups stop at a rate limit or after
+// maxArchiveLikeLookups; importing the archive again continues with the likes not looked up yet.
+// Everything is merged into the saved accounts (the account's own name and "likes"), from where
+// it's downloaded like any fetched timeline.
+
+// maxArchiveLikeLookups caps the liked tweets looked up per import
+const maxArchiveLikeLookups = 1000
+
+// twitterArchiveFilePattern matches the data files of an archive, split ones have -partN
+var twitterArchiveFilePattern = regexp.MustCompile(`(?:^|/)data/(account|tweets?|like)(?:-part\d+)?\.js$`)
+
+// TwitterArchiveImport describes an imported archive
+type TwitterArchiveImport struct {
+	Username      string   `json:"username"`
+	Tweets        int      `json:"tweets"`         // Tweets in the archive
+	Media         int      `json:"media"`          // Media entries of the tweets
+	Likes         int      `json:"likes"`          // Likes in the archive
+	LikesResolved int      `json:"likes_resolved"` // Liked tweets looked up in this import
+	LikeMedia     int      `json:"like_media"`     // Media found in liked tweets
+	LikesPending  int      `json:"likes_pending"`  // Liked tweets still to look up, import again to continue
+	RateLimited   bool     `json:"rate_limited"`
+	Warnings      []string `json:"warnings,omitempty"`
+}
+
+// archiveAccount is an entry of data/account.js
+type archiveAccount struct {
+	Account struct {
+		Username           string `json:"username"`
+		AccountDisplayName string `json:"accountDisplayName"`
+	} `json:"account"`
+}
+
+// archiveTweet is an entry of data/tweets.js
+type archiveTweet struct {
+	Tweet struct {
+		IDStr            string `json:"id_str"`
+		InReplyToIDStr   string `json:"in_reply_to_status_id_str"`
+		CreatedAt        string `json:"created_at"`
+		FullText         string `json:"full_text"`
+		FavoriteCount    string `json:"favorite_count"`
+		RetweetCount     string `json:"retweet_count"`
+		Source           string `json:"source"`
+		ExtendedEntities struct {
+			Media []archiveMedia `json:"media"`
+		} `json:"extended_entities"`
+	} `json:"tweet"`
+}
+
+// archiveMedia is a media entity of an archived tweet
+type archiveMedia struct {
+	MediaURLHTTPS string `json:"media_url_https"`
+	Type          string `json:"type"` // photo, video, animated_gif
+	OriginalInfo  struct {
+		Width  int `json:"width"`
+		Height int `json:"height"`
+	} `json:"original_info"`
+	Sizes struct {
+		Large struct {
+			W string `json:"w"`
+			H string `json:"h"`
+		} `json:"large"`
+	} `json:"sizes"`
+	VideoInfo struct {
+		Variants []struct {
+			Bitrate     string `json:"bitrate"`
+			ContentType string `json:"content_type"`
+			URL         string `json:"url"`
+		} `json:"variants"`
+	} `json:"video_info"`
+}
+
+// archiveLike is an entry of data/like.js
+type archiveLike struct {
+	Like struct {
+		TweetID  string `json:"tweetId"`
+		FullText string `json:"fullText"`
+	} `json:"like"`
+}
+
+// ImportTwitterArchive imports the tweets and likes of an X data export ZIP into the saved accounts
+// authToken is used to look up the media of liked tweets ("" = guest)
+func ImportTwitterArchive(zipPath, authToken string) (*TwitterArchiveImport, error) {
+	zr, err := zip.OpenReader(zipPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open archive: %v", err)
+	}
+	defer zr.Close()
+
+	var accounts []archiveAccount
+	var tweets []archiveTweet
+	var likes []archiveLike
+	for _, f := range zr.File {
+		m := twitterArchiveFilePattern.FindStringSubmatch(f.Name)
+		if m == nil {
+			continue
+		}
+		data, err := readArchiveDataFile(f)
+		if err == nil {
+			// Split files are appended to what earlier parts decoded
+			switch m[1] {
+			case "account":
+				err = appendArchivePart(data, &accounts)
+			case "tweet", "tweets":
+				err = appendArchivePart(data, &tweets)
+			case "like":
+				err = appendArchivePart(data, &likes)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s: %v", f.Name, err)
+		}
+	}
+	if len(accounts) == 0 || accounts[0].Account.Username == "" {
+		return nil, fmt.Errorf("not an X data export: data/account.js is missing")
+	}
+
+	account := accounts[0].Account
+	result := &TwitterArchiveImport{Username: account.Username, Tweets: len(tweets), Likes: len(likes)}
+	if err := checkFetchAllowed(account.Username); err != nil {
+		return nil, err
+	}
+
+	var entries []TimelineEntry
+	for _, t := range tweets {
+		entries = append(entries, archiveTweetEntries(t, account.Username)...)
+	}
+	result.Media = len(entries)
+	if len(entries) > 0 {
+		nick := account.AccountDisplayName
+		if nick == "" {
+			nick = account.Username
+		}
+		if err := mergeAccountTimeline(account.Username, nick, entries); err != nil {
+			return nil, fmt.Errorf("failed to save tweets: %v", err)
+		}
+	}
+
+	if len(likes) > 0 {
+		if err := importArchiveLikes(likes, authToken, result); err != nil {
+			return nil, fmt.Errorf("failed to save likes: %v", err)
+		}
+	}
+	return result, nil
+}
+
+// readArchiveDataFile returns the JSON array of a data file ("window.YTD.<name>.partN = [...]")
+func readArchiveDataFile(f *zip.File) ([]byte, error) {
+	r, err := f.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if i := bytes.IndexByte(data, '='); i >= 0 {
+		data = data[i+1:]
+	}
+	return bytes.TrimRight(bytes.TrimSpace(data), ";"), nil
+}
+
+// appendArchivePart decodes the JSON array of a data file and appends its items to items
+func appendArchivePart[T any](data []byte, items *[]T) error {
+	var part []T
+	if err := json.Unmarshal(data, &part); err != nil {
+		return err
+	}
+	*items = append(*items, part...)
+	return nil
+}
+
+// archiveTweetEntries converts the media of an archived tweet to timeline entries
+func archiveTweetEntries(t archiveTweet, username string) []TimelineEntry {
+	tweet := t.Tweet
+	id, err := strconv.ParseInt(tweet.IDStr, 10, 64)
+	if err != nil || len(tweet.ExtendedEntities.Media) == 0 {
+		return nil
+	}
+	date := ""
+	if created, err := time.Parse(time.RubyDate, tweet.CreatedAt); err == nil {
+		date = created.UTC().Format("2006-01-02T15:04:05")
+	}
+	likes, _ := strconv.Atoi(tweet.FavoriteCount)
+	retweets, _ := strconv.Atoi(tweet.RetweetCount)
+	isRetweet := strings.HasPrefix(tweet.FullText, "RT @")
+	replyID, _ := strconv.ParseInt(tweet.InReplyToIDStr, 10, 64)
+
+	var entries []TimelineEntry
+	for i, media := range tweet.ExtendedEntities.Media {
+		entry := TimelineEntry{
+			Date:           date,
+			TweetID:        TweetIDString(id),
+			IsRetweet:      isRetweet,
+			ReplyID:        TweetIDString(replyID),
+			Width:          media.OriginalInfo.Width,
+			Height:         media.OriginalInfo.Height,
+			Content:        tweet.FullText,
+			FavoriteCount:  likes,
+			RetweetCount:   retweets,
+			Source:         archiveSourceName(tweet.Source),
+			AuthorUsername: username,
+			MediaIndex:     i + 1,
+		}
+		if entry.Width == 0 {
+			entry.Width, _ = strconv.Atoi(media.Sizes.Large.W)
+			entry.Height, _ = strconv.Atoi(media.Sizes.Large.H)
+		}
+
+		switch media.Type {
+		case "video", "animated_gif":
+			// Highest bitrate MP4
+			best := -1
+			for _, v := range media.VideoInfo.Variants {
+				bitrate, _ := strconv.Atoi(v.Bitrate)
+				if v.ContentType == "video/mp4" && bitrate > best {
+					best = bitrate
+					entry.URL = v.URL
+				}
+			}
+			entry.Type = "video"
+			if media.Type == "animated_gif" {
+				entry.Type = "gif"
+			}
+			entry.Extension = "mp4"
+		default:
+			// https://pbs.twimg.com/media/<id>.jpg -> the original size
+			u, err := url.Parse(media.MediaURLHTTPS)
+			if err != nil || u.Host == "" {
+				continue
+			}
+			ext := strings.TrimPrefix(path.Ext(u.Path), ".")
+			u.Path = strings.TrimSuffix(u.Path, path.Ext(u.Path))
+			u.RawQuery = "format=" + ext + "&name=orig"
+			entry.URL = u.String()
+			entry.Type = "photo"
+			entry.Extension = ext
+		}
+		if entry.URL != "" {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// archiveSourceName returns the client name of a source link (<a href="...">Twitter Web App</a>)
+func archiveSourceName(source string) string {
+	if start := strings.Index(source, ">"); start >= 0 {
+		if end := strings.Index(source[start:], "<"); end >= 0 {
+			return source[start+1 : start+end]
+		}
+	}
+	return source
+}
+
+// importArchiveLikes looks up the media of liked tweets that aren't saved yet and merges them into "likes"
+func importArchiveLikes(likes []archiveLike, authToken string, result *TwitterArchiveImport) error {
+	known := make(map[int64]bool)
+	if response, err := savedTimeline("likes"); err == nil {
+		for _, entry := range response.Timeline {
+			known[int64(entry.TweetID)] = true
+		}
+	}
+
+	var entries []TimelineEntry
+	for _, like := range likes {
+		id, err := strconv.ParseInt(like.Like.TweetID, 10, 64)
+		if err != nil || known[id] {
+			continue
+		}
+		known[id] = true
+		if result.RateLimited || result.LikesResolved >= maxArchiveLikeLookups {
+			result.LikesPending++
+			continue
+		}
+
+		media, err := ExtractTweetMedia(id, authToken)
+		if err != nil {
+			if !RetryAtFromMessage(err.Error()).IsZero() {
+				result.RateLimited = true
+			} else if len(result.Warnings) < 10 {
+				result.Warnings = append(result.Warnings, fmt.Sprintf("tweet %d: %v", id, err))
+			}
+			// Looked up again by the next import
+			result.LikesPending++
+			continue
+		}
+		result.LikesResolved++
+		if len(media) == 0 {
+			// Text-only (or no longer visible): keep the text so it isn't looked up again
+			entries = append(entries, TimelineEntry{
+				TweetID:   TweetIDString(id),
+				Type:      "text",
+				Extension: "txt",
+				Content:   like.Like.FullText,
+			})
+			continue
+		}
+		for _, item := range media {
+			entries = append(entries, convertToTimelineEntry(item))
+			result.LikeMedia++
+		}
+	}
+	if len(entries) == 0 {
+		return nil
+	}
+	return mergeAccountTimeline("likes", "My Likes", entries)
+}
+
+// savedTimeline returns the saved "all media" timeline of an account
+func savedTimeline(username string) (*TwitterResponse, error) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return nil, err
+		}
+	}
+	var responseJSON string
+	err := db.QueryRow(`SELECT response_json FROM accounts WHERE LOWER(username) = LOWER(?) AND media_type = 'all'`, username).Scan(&responseJSON)
+	if err != nil {
+		return nil, err
+	}
+	if responseJSON, err = openResponseJSON(responseJSON); err != nil {
+		return nil, err
+	}
+	if converted, err := ConvertLegacyToNewFormat(responseJSON); err == nil {
+		responseJSON = converted
+	}
+	var response TwitterResponse
+	if err := json.Unmarshal([]byte(responseJSON), &response); err != nil {
+		return nil, err
+	}
+	return &response, nil
+}
+
+// mergeAccountTimeline adds entries that aren't in the saved timeline of an account, newest first
+func mergeAccountTimeline(username, nick string, entries []TimelineEntry) error {
+	response, err := savedTimeline(username)
+	if err != nil {
+		response = &TwitterResponse{
+			AccountInfo: AccountInfo{Name: username, Nick: nick},
+			Completed:   true,
+			Metadata:    ExtractMetadata{Completed: true},
+		}
+	}
+
+	// Media are keyed by URL, text entries by tweet
+	key := func(entry TimelineEntry) string {
+		if entry.Type == "text" {
+			return "text:" + strconv.FormatInt(int64(entry.TweetID), 10)
+		}
+		return entry.URL
+	}
+	seen := make(map[string]bool)
+	for _, entry := range response.Timeline {
+		seen[key(entry)] = true
+	}
+	added := 0
+	for _, entry := range entries {
+		if !seen[key(entry)] {
+			seen[key(entry)] = true
+			response.Timeline = append(response.Timeline, entry)
+			added++
+		}
+	}
+	if added == 0 && err == nil {
+		return nil
+	}
+	sort.SliceStable(response.Timeline, func(i, j int) bool {
+		return response.Timeline[i].TweetID > response.Timeline[j].TweetID
+	})
+	response.TotalURLs = len(response.Timeline)
+	response.Metadata.NewEntries = added
+	response.Metadata.Stats = computeTimelineStats(response.Timeline)
+
+	data, err := json.Marshal(response)
+	if err != nil {
+		return err
+	}
+	return SaveAccountWithStatus(username, response.AccountInfo.Nick, response.AccountInfo.ProfileImage, response.TotalURLs, string(data), "all", response.Cursor, response.Completed)
+}
@@ -0,0 +1,151 @@
+package backend
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// Video previews
+//
+// A poster frame or a 3x3 contact sheet per video, so an archive can be browsed in any file
+// manager without playing every file. Previews are saved in a .thumbs folder next to the
+// videos (videos/.thumbs/{name}.jpg) or alongside them ({name}.jpg / {name}_sheet.jpg).
+
+// Video preview kinds
+const (
+	PreviewPoster       = "poster"        // A single representative frame
+	PreviewContactSheet = "contact_sheet" // 9 frames spread over the video in a 3x3 grid
+)
+
+// Video preview locations
+const (
+	PreviewInThumbsFolder = "thumbs"    // {folder}/.thumbs/{name}.jpg (default)
+	PreviewAlongside      = "alongside" // {folder}/{name}.jpg
+)
+
+const (
+	previewPosterWidth = 640 // Poster width in pixels
+	previewTileWidth   = 320 // Width of each contact sheet tile in pixels
+)
+
+// ffmpegDurationPattern matches the duration ffmpeg logs for its input
+var ffmpegDurationPattern = regexp.MustCompile(`Duration: (\d+):(\d{2}):(\d{2}(?:\.\d+)?)`)
+
+// VideoPreviewPath returns where the preview of a video is saved
+func VideoPreviewPath(videoPath, kind, location string) string {
+	dir := filepath.Dir(videoPath)
+	name := strings.TrimSuffix(filepath.Base(videoPath), filepath.Ext(videoPath))
+	if kind == PreviewContactSheet {
+		name += "_sheet"
+	}
+	if location == PreviewAlongside {
+		return filepath.Join(dir, name+".jpg")
+	}
+	return filepath.Join(dir, ".thumbs", name+".jpg")
+}
+
+// GenerateVideoPreview saves a poster frame or contact sheet of a video and returns its path
+// Existing previews are kept unless overwrite is set
+func GenerateVideoPreview(videoPath, kind, location string, overwrite bool) (string, error) {
+	if !IsFFmpegInstalled() {
+		return "", fmt.Errorf("ffmpeg not installed")
+	}
+	if kind != PreviewPoster && kind != PreviewContactSheet {
+		return "", fmt.Errorf("unknown preview kind: %s", kind)
+	}
+
+	outputPath := VideoPreviewPath(videoPath, kind, location)
+	if _, err := os.Stat(outputPath); err == nil && !overwrite {
+		return outputPath, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
+		return "", fmt.Errorf("failed to create preview folder: %v", err)
+	}
+
+	var filter string
+	if kind == PreviewPoster {
+		// thumbnail picks the most representative frame instead of a black first frame
+		filter = fmt.Sprintf("thumbnail,scale=%d:-2", previewPosterWidth)
+	} else {
+		duration, err := videoDuration(videoPath)
+		if err != nil {
+			return "", err
+		}
+		// 9 frames evenly spread over the video
+		filter = fmt.Sprintf("fps=9/%.3f,scale=%d:-2,tile=3x3:padding=4:margin=4", duration, previewTileWidth)
+	}
+
+	args := []string{
+		"-i", videoPath,
+		"-vf", filter,
+		"-frames:v", "1",
+		"-q:v", "3",
+		"-f", "image2",
+		"-y",
+		outputPath,
+	}
+	cmd := exec.Command(ffmpegBinary(), args...)
+	hideWindow(cmd)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		os.Remove(outputPath)
+		return "", fmt.Errorf("ffmpeg error: %v, output: %s", err, string(output))
+	}
+	return outputPath, nil
+}
+
+// GenerateVideoPreviews saves previews for MP4 files (folders are searched recursively)
+// workers is the number of ffmpeg processes run at once, 0 = one per CPU core.
+// Videos that already have a preview are counted as skipped unless overwrite is set
+func GenerateVideoPreviews(paths []string, kind, location string, overwrite bool, workers int) (generated int, skipped int, failed int, err error) {
+	if !IsFFmpegInstalled() {
+		return 0, 0, 0, fmt.Errorf("ffmpeg not installed")
+	}
+	if kind != PreviewPoster && kind != PreviewContactSheet {
+		return 0, 0, 0, fmt.Errorf("unknown preview kind: %s", kind)
+	}
+
+	inputs, err := collectMP4Files(paths)
+	if err != nil {
+		return 0, 0, 0, err
+	}
+	if len(inputs) == 0 {
+		return 0, 0, 0, fmt.Errorf("no MP4 files found")
+	}
+
+	var skippedCount int64
+	done, failed := runFFmpegPool(inputs, workers, func(videoPath string) error {
+		if _, err := os.Stat(VideoPreviewPath(videoPath, kind, location)); err == nil && !overwrite {
+			atomic.AddInt64(&skippedCount, 1)
+			return nil
+		}
+		_, err := GenerateVideoPreview(videoPath, kind, location, true)
+		return err
+	})
+	return done - int(skippedCount), int(skippedCount), failed, nil
+}
+
+// videoDuration returns the duration of a video in seconds from ffmpeg's input log
+func videoDuration(videoPath string) (float64, error) {
+	cmd := exec.Command(ffmpegBinary(), "-hide_banner", "-i", videoPath)
+	hideWindow(cmd)
+	// ffmpeg exits with an error without an output file, the log is what matters
+	output, _ := cmd.CombinedOutput()
+	m := ffmpegDurationPattern.FindStringSubmatch(string(output))
+	if m == nil {
+		return 0, fmt.Errorf("failed to read video duration: %s", filepath.Base(videoPath))
+	}
+	hours, _ := strconv.Atoi(m[1])
+	minutes, _ := strconv.Atoi(m[2])
+	seconds, _ := strconv.ParseFloat(m[3], 64)
+	duration := float64(hours*3600+minutes*60) + seconds
+	if duration <= 0 {
+		return 0, fmt.Errorf("video has no duration: %s", filepath.Base(videoPath))
+	}
+	return duration, nil
+}
@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"io"
+	"os/exec"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Extractor watchdog
+//
+// An extractor run that hangs (a stalled connection, a stuck login) would otherwise block its
+// fetch forever. Each run is watched: it's killed when it takes longer than the time limit of the
+// settings, or when it writes nothing to stdout or stderr for the inactivity limit. A killed run
+// fails like a crashed one, so the entries received so far come back as a partial result with
+// the last cursor and the fetch can be resumed. Both limits can be turned off with 0.
+
+// Default inactivity limit of extractor runs
+const defaultExtractorIdleMinutes = 10
+
+// watchdogInterval is how often the watchdog checks a run
+const watchdogInterval = 5 * time.Second
+
+// extractorWaitDelay is how long a killed run may keep its output pipes open, e.g. through a
+// child process of the extractor that outlived it
+const extractorWaitDelay = 5 * time.Second
+
+// extractorWatchdog kills an extractor run that takes too long or stops writing output
+type extractorWatchdog struct {
+	timeout    time.Duration // 0 = no limit
+	idle       time.Duration // 0 = no limit
+	lastOutput atomic.Int64  // Unix nanoseconds of the last output
+	stop       chan struct{}
+	stopOnce   sync.Once
+	mu         sync.Mutex
+	tripped    string // Error code of the limit that killed the run, "" if none
+}
+
+// newExtractorWatchdog returns a watchdog with the limits of the settings
+func newExtractorWatchdog(s Settings) *extractorWatchdog {
+	w := &extractorWatchdog{
+		timeout: time.Duration(s.ExtractorTimeoutMinutes) * time.Minute,
+		idle:    time.Duration(s.ExtractorIdleMinutes) * time.Minute,
+		stop:    make(chan struct{}),
+	}
+	w.lastOutput.Store(time.Now().UnixNano())
+	return w
+}
+
+// activityReader notes every read with output
+type activityReader struct {
+	r io.Reader
+	w *extractorWatchdog
+}
+
+func (a activityReader) Read(p []byte) (int, error) {
+	n, err := a.r.Read(p)
+	if n > 0 {
+		a.w.lastOutput.Store(time.Now().UnixNano())
+	}
+	return n, err
+}
+
+// activityWriter notes every write
+type activityWriter struct {
+	wr io.Writer
+	w  *extractorWatchdog
+}
+
+func (a activityWriter) Write(p []byte) (int, error) {
+	a.w.lastOutput.Store(time.Now().UnixNano())
+	return a.wr.Write(p)
+}
+
+// reader returns r, counting what's read from it as output of the run
+func (w *extractorWatchdog) reader(r io.Reader) io.Reader {
+	return activityReader{r: r, w: w}
+}
+
+// writer returns wr, counting what's written to it as output of the run
+func (w *extractorWatchdog) writer(wr io.Writer) io.Writer {
+	return activityWriter{wr: wr, w: w}
+}
+
+// start watches a started run until finish; a run over a limit is killed and its stdout closed
+func (w *extractorWatchdog) start(cmd *exec.Cmd, stdout io.Closer) {
+	if w.timeout <= 0 && w.idle <= 0 {
+		return
+	}
+	started := time.Now()
+	go func() {
+		ticker := time.NewTicker(watchdogInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-w.stop:
+				return
+			case now := <-ticker.C:
+				code := ""
+				if w.timeout > 0 && now.Sub(started) > w.timeout {
+					code = ErrCodeExtractorTimeout
+				} else if w.idle > 0 && now.Sub(time.Unix(0, w.lastOutput.Load())) > w.idle {
+					code = ErrCodeExtractorStalled
+				}
+				if code == "" {
+					continue
+				}
+				w.mu.Lock()
+				w.tripped = code
+				w.mu.Unlock()
+				cmd.Process.Kill()
+				// Unblocks the decoder if a child process still holds the pipe
+				stdout.Close()
+				return
+			}
+		}
+	}()
+}
+
+// finish stops watching the run
+func (w *extractorWatchdog) finish() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+// trippedCode returns the error code of the limit that killed the run, "" if it wasn't killed
+func (w *extractorWatchdog) trippedCode() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.tripped
+}
+
+// limit returns the limit behind an error code, for the error message
+func (w *extractorWatchdog) limit(code string) time.Duration {
+	if code == ErrCodeExtractorTimeout {
+		return w.timeout
+	}
+	return w.idle
+}
@@ -0,0 +1,150 @@
+package backend
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// Wayback Machine fallback
+//
+// With WaybackFallback set, media that answers 404 (deleted tweet, suspended account) is looked
+// up in the Internet Archive's availability API before it's given up on. If the Wayback Machine
+// has a copy, the original bytes of the snapshot (the id_ form, without the archive's toolbar or
+// rewriting) are saved in place of the file, the item status is "recovered" and the file is
+// recorded in the recovered_media table of the library with the snapshot it came from. Twitter
+// image URLs were archived in several forms, so the legacy forms (.jpg, .jpg:orig) are tried
+// too. Media the archive doesn't have goes to missing.csv as usual.
+
+const waybackAvailableAPI = "https://archive.org/wayback/available"
+
+// WaybackSnapshot is an archived copy of a URL
+type WaybackSnapshot struct {
+	URL       string `json:"url"`       // Original bytes of the snapshot
+	Timestamp string `json:"timestamp"` // YYYYMMDDhhmmss
+}
+
+// RecoveredMedia is a file recovered from the Wayback Machine
+type RecoveredMedia struct {
+	MediaURL    string `json:"media_url"`
+	TweetID     string `json:"tweet_id"`
+	Username    string `json:"username"`
+	Path        string `json:"path"`
+	SnapshotURL string `json:"snapshot_url"`
+	RecoveredAt string `json:"recovered_at"`
+}
+
+// waybackCandidates returns the forms a media URL may have been archived under
+func waybackCandidates(mediaURL string) []string {
+	candidates := []string{mediaURL}
+	u, err := url.Parse(mediaURL)
+	if err != nil || u.Host != "pbs.twimg.com" || !strings.HasPrefix(u.Path, "/media/") {
+		return candidates
+	}
+	// https://pbs.twimg.com/media/<id>?format=jpg&name=orig -> <id>.jpg, <id>.jpg:orig
+	base := strings.TrimSuffix(u.Path, path.Ext(u.Path))
+	ext := u.Query().Get("format")
+	if ext == "" {
+		ext = strings.TrimPrefix(path.Ext(u.Path), ".")
+	}
+	if ext == "" {
+		return candidates
+	}
+	legacy := "https://pbs.twimg.com" + base + "." + ext
+	return append(candidates, legacy, legacy+":orig", legacy+":large")
+}
+
+// findWaybackSnapshot asks the availability API for the closest snapshot of a media URL
+func findWaybackSnapshot(ctx context.Context, client *http.Client, mediaURL string) (WaybackSnapshot, error) {
+	for _, candidate := range waybackCandidates(mediaURL) {
+		req, err := http.NewRequestWithContext(ctx, "GET", waybackAvailableAPI+"?"+url.Values{"url": {candidate}}.Encode(), nil)
+		if err != nil {
+			return WaybackSnapshot{}, err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return WaybackSnapshot{}, err
+		}
+		var result struct {
+			ArchivedSnapshots struct {
+				Closest struct {
+					Available bool   `json:"available"`
+					Status    string `json:"status"`
+					Timestamp string `json:"timestamp"`
+				} `json:"closest"`
+			} `json:"archived_snapshots"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&result)
+		resp.Body.Close()
+		if err != nil {
+			return WaybackSnapshot{}, fmt.Errorf("invalid availability response: %v", err)
+		}
+		closest := result.ArchivedSnapshots.Closest
+		if closest.Available && closest.Status == "200" && closest.Timestamp != "" {
+			return WaybackSnapshot{
+				URL:       "https://web.archive.org/web/" + closest.Timestamp + "id_/" + candidate,
+				Timestamp: closest.Timestamp,
+			}, nil
+		}
+	}
+	return WaybackSnapshot{}, fmt.Errorf("not archived: %s", mediaURL)
+}
+
+// recoverFromWayback downloads the archived copy of a dead media link, returning the saved path
+func recoverFromWayback(ctx context.Context, client *http.Client, store Storage, task downloadTask, webpQuality int) (string, WaybackSnapshot, error) {
+	snapshot, err := findWaybackSnapshot(ctx, client, task.item.URL)
+	if err != nil {
+		return "", snapshot, err
+	}
+	task.item.URL = snapshot.URL
+	if task.webp {
+		savedPath, err := downloadWebP(ctx, client, task, webpQuality)
+		return savedPath, snapshot, err
+	}
+	return task.outputPath, snapshot, downloadFileWithContext(ctx, client, store, snapshot.URL, task.outputPath)
+}
+
+// markRecovered records a recovered file in the library
+func markRecovered(item MediaItem, savedPath string, snapshot WaybackSnapshot) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return
+		}
+	}
+	db.Exec(`
+		INSERT OR REPLACE INTO recovered_media (media_url, tweet_id, username, path, snapshot_url, recovered_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`, item.URL, fmt.Sprint(item.TweetID), item.Username, savedPath, snapshot.URL, time.Now().UTC().Format(time.RFC3339))
+}
+
+// GetRecoveredMedia returns the files of an account recovered from the Wayback Machine ("" = all accounts)
+func GetRecoveredMedia(username string) ([]RecoveredMedia, error) {
+	if db == nil {
+		if err := InitDB(); err != nil {
+			return nil, err
+		}
+	}
+	rows, err := db.Query(`
+		SELECT media_url, tweet_id, username, path, snapshot_url, recovered_at FROM recovered_media
+		WHERE ? = '' OR username = ? COLLATE NOCASE
+		ORDER BY recovered_at DESC
+	`, username, username)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	media := []RecoveredMedia{}
+	for rows.Next() {
+		var m RecoveredMedia
+		if err := rows.Scan(&m.MediaURL, &m.TweetID, &m.Username, &m.Path, &m.SnapshotURL, &m.RecoveredAt); err != nil {
+			return nil, err
+		}
+		media = append(media, m)
+	}
+	return media, rows.Err()
+}
@@ -0,0 +1,141 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Wayback Machine saves
+//
+// With SaveToWayback set on a fetch, the URL of every fetched tweet is submitted to the Wayback
+// Machine's Save Page Now (web.archive.org/save), so a public copy exists beside the local
+// archive. Submissions run in the background one at a time, a few seconds apart, so they never
+// slow down the fetch and stay under the anonymous rate limit; when the archive answers 429 the
+// queue waits a minute before going on. Each tweet is submitted once per session. The queue only
+// lives in memory: tweets still queued when the app closes aren't saved.
+
+const (
+	waybackSaveEndpoint  = "https://web.archive.org/save/"
+	waybackSaveInterval  = 8 * time.Second
+	waybackSaveBackoff   = time.Minute
+	waybackSaveQueueSize = 10000
+	waybackSaveAttempts  = 3
+)
+
+// WaybackSaveStatus reports the progress of the save queue
+type WaybackSaveStatus struct {
+	Queued int `json:"queued"`
+	Saved  int `json:"saved"`
+	Failed int `json:"failed"`
+}
+
+// waybackSaver submits URLs to Save Page Now in the background
+type waybackSaver struct {
+	mu      sync.Mutex
+	queue   chan string
+	seen    map[string]bool
+	status  WaybackSaveStatus
+	started bool
+}
+
+var waybackSaves = &waybackSaver{}
+
+// QueueWaybackSaves submits the tweets of a timeline to the Wayback Machine in the background
+func QueueWaybackSaves(timeline []TimelineEntry, username string) int {
+	var urls []string
+	for _, entry := range timeline {
+		author := entry.AuthorUsername
+		if author == "" {
+			author = username
+		}
+		if author == "" {
+			author = "i" // x.com/i/status/<id> redirects to the tweet
+		}
+		if entry.TweetID == 0 {
+			continue
+		}
+		urls = append(urls, fmt.Sprintf("https://x.com/%s/status/%d", author, int64(entry.TweetID)))
+	}
+	return waybackSaves.add(urls)
+}
+
+// GetWaybackSaveStatus returns the progress of the Wayback Machine save queue
+func GetWaybackSaveStatus() WaybackSaveStatus {
+	waybackSaves.mu.Lock()
+	defer waybackSaves.mu.Unlock()
+	return waybackSaves.status
+}
+
+// add queues URLs that weren't submitted this session, returning how many were queued
+func (s *waybackSaver) add(urls []string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.started {
+		s.queue = make(chan string, waybackSaveQueueSize)
+		s.seen = make(map[string]bool)
+		s.started = true
+		go s.run()
+	}
+	added := 0
+	for _, u := range urls {
+		if s.seen[u] {
+			continue
+		}
+		select {
+		case s.queue <- u:
+			s.seen[u] = true
+			s.status.Queued++
+			added++
+		default:
+			// Queue full, the rest can be submitted by a later fetch
+			return added
+		}
+	}
+	return added
+}
+
+// run submits queued URLs one at a time
+func (s *waybackSaver) run() {
+	client := &http.Client{Transport: audited(nil, AuditPurposeWayback), Timeout: 2 * time.Minute}
+	for u := range s.queue {
+		saved := false
+		for attempt := 0; attempt < waybackSaveAttempts && !saved; attempt++ {
+			status, err := submitWaybackSave(client, u)
+			switch {
+			case err == nil && status < 400:
+				saved = true
+			case status == http.StatusTooManyRequests:
+				time.Sleep(waybackSaveBackoff)
+			default:
+				time.Sleep(waybackSaveInterval)
+			}
+		}
+
+		s.mu.Lock()
+		s.status.Queued--
+		if saved {
+			s.status.Saved++
+		} else {
+			s.status.Failed++
+		}
+		s.mu.Unlock()
+		time.Sleep(waybackSaveInterval)
+	}
+}
+
+// submitWaybackSave asks Save Page Now to capture a URL
+func submitWaybackSave(client *http.Client, pageURL string) (int, error) {
+	req, err := http.NewRequest("GET", waybackSaveEndpoint+pageURL, nil)
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("User-Agent", "Mozilla/5.0 (compatible; TwitterXMediaBatchDownloader)")
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	return resp.StatusCode, nil
+}
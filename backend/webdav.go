@@ -0,0 +1,311 @@
+package backend
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WebDAV storage
+//
+// Uploads downloads to a WebDAV server (Nextcloud, ownCloud, a NAS, rclone serve webdav, ...)
+// below the configured folder URL, with the same layout as the output folder. WebDAV has no
+// resumable uploads, so each file is spooled to a temporary file and PUT once it's complete;
+// that way a failed upload can simply be sent again. Every request is retried on network errors
+// and server errors. Folders are created with MKCOL, files renamed with MOVE.
+
+// WebDAVConfig is a WebDAV folder to upload to
+type WebDAVConfig struct {
+	URL      string `json:"url"` // Folder mirroring the local output folder, e.g. https://cloud.example.com/remote.php/dav/files/me/twitter
+	User     string `json:"user,omitempty"`
+	Password string `json:"password,omitempty"`
+}
+
+// WebDAVStorage saves files on a WebDAV server
+type WebDAVStorage struct {
+	cfg       WebDAVConfig
+	base      *url.URL
+	localRoot string
+	client    *http.Client
+
+	dirsMu sync.Mutex
+	dirs   map[string]bool // Remote folders known to exist
+}
+
+// NewWebDAVStorage returns the storage of a WebDAV folder; localRoot is the local output folder the engine plans paths in
+func NewWebDAVStorage(cfg WebDAVConfig, localRoot string) (*WebDAVStorage, error) {
+	base, err := url.Parse(strings.TrimSuffix(cfg.URL, "/"))
+	if err != nil || base.Host == "" || (base.Scheme != "http" && base.Scheme != "https") {
+		return nil, fmt.Errorf("invalid WebDAV URL: %s", cfg.URL)
+	}
+	return &WebDAVStorage{
+		cfg:       cfg,
+		base:      base,
+		localRoot: localRoot,
+		client:    &http.Client{Transport: audited(nil, AuditPurposeUpload)}, // No overall timeout, uploads of long videos take a while
+		dirs:      make(map[string]bool),
+	}, nil
+}
+
+// remoteURL returns the URL of a path below the WebDAV folder
+func (s *WebDAVStorage) remoteURL(rel string) string {
+	u := *s.base
+	if rel != "" {
+		u.Path = u.Path + "/" + rel
+		u.RawPath = ""
+	}
+	return u.String()
+}
+
+// rel maps a path planned below the local output folder to a path below the WebDAV folder
+func (s *WebDAVStorage) rel(localPath string) string {
+	return remoteKey(s.localRoot, "", localPath)
+}
+
+// do sends a request with retries
+func (s *WebDAVStorage) do(method, rel string, header http.Header, body func() (io.Reader, int64, error)) (*http.Response, error) {
+	return doRemote(s.client, func() (*http.Request, error) {
+		var reader io.Reader
+		var size int64
+		if body != nil {
+			var err error
+			if reader, size, err = body(); err != nil {
+				return nil, err
+			}
+		}
+		req, err := http.NewRequest(method, s.remoteURL(rel), reader)
+		if err != nil {
+			return nil, err
+		}
+		if body != nil {
+			req.ContentLength = size
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		if s.cfg.User != "" || s.cfg.Password != "" {
+			req.SetBasicAuth(s.cfg.User, s.cfg.Password)
+		}
+		return req, nil
+	})
+}
+
+// webdavError turns an unsuccessful response into an error and closes it
+func webdavError(resp *http.Response, op, rel string) error {
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusNotFound:
+		return &fs.PathError{Op: op, Path: rel, Err: fs.ErrNotExist}
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return &fs.PathError{Op: op, Path: rel, Err: fs.ErrPermission}
+	default:
+		return fmt.Errorf("webdav %s %s: %s", op, rel, resp.Status)
+	}
+}
+
+// Stat implements Storage
+func (s *WebDAVStorage) Stat(localPath string) (os.FileInfo, error) {
+	rel := s.rel(localPath)
+	header := http.Header{}
+	header.Set("Depth", "0")
+	header.Set("Content-Type", "application/xml")
+	propfind := `<?xml version="1.0" encoding="utf-8"?><d:propfind xmlns:d="DAV:"><d:prop><d:getcontentlength/><d:getlastmodified/><d:resourcetype/></d:prop></d:propfind>`
+	resp, err := s.do("PROPFIND", rel, header, func() (io.Reader, int64, error) {
+		return strings.NewReader(propfind), int64(len(propfind)), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusMultiStatus && resp.StatusCode != http.StatusOK {
+		return nil, webdavError(resp, "stat", rel)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		Responses []struct {
+			Props []struct {
+				Status string `xml:"status"`
+				Prop   struct {
+					Length       string    `xml:"getcontentlength"`
+					LastModified string    `xml:"getlastmodified"`
+					ResourceType *struct{} `xml:"resourcetype>collection"`
+				} `xml:"prop"`
+			} `xml:"propstat"`
+		} `xml:"response"`
+	}
+	if err := xml.NewDecoder(resp.Body).Decode(&result); err != nil || len(result.Responses) == 0 {
+		return nil, fmt.Errorf("webdav stat %s: invalid response", rel)
+	}
+	info := &sftpFileInfo{name: path.Base(rel), mode: 0100644}
+	for _, propstat := range result.Responses[0].Props {
+		if !strings.Contains(propstat.Status, " 200 ") {
+			continue
+		}
+		prop := propstat.Prop
+		if prop.ResourceType != nil {
+			info.mode = 0040755
+		}
+		if size, err := strconv.ParseInt(prop.Length, 10, 64); err == nil {
+			info.size = size
+		}
+		if modTime, err := http.ParseTime(prop.LastModified); err == nil {
+			info.modTime = modTime
+		}
+	}
+	return info, nil
+}
+
+// MkdirAll implements Storage
+func (s *WebDAVStorage) MkdirAll(localPath string) error {
+	rel := s.rel(localPath)
+	if rel == "" {
+		return nil
+	}
+	current := ""
+	for _, part := range strings.Split(rel, "/") {
+		if current == "" {
+			current = part
+		} else {
+			current += "/" + part
+		}
+		s.dirsMu.Lock()
+		known := s.dirs[current]
+		s.dirsMu.Unlock()
+		if known {
+			continue
+		}
+		resp, err := s.do("MKCOL", current, nil, nil)
+		if err != nil {
+			return err
+		}
+		// 405: the folder already exists
+		if resp.StatusCode/100 != 2 && resp.StatusCode != http.StatusMethodNotAllowed {
+			return webdavError(resp, "mkdir", current)
+		}
+		resp.Body.Close()
+		s.dirsMu.Lock()
+		s.dirs[current] = true
+		s.dirsMu.Unlock()
+	}
+	return nil
+}
+
+// Create implements Storage, the file is uploaded when it's closed
+func (s *WebDAVStorage) Create(localPath string) (io.WriteCloser, error) {
+	spool, err := os.CreateTemp("", "txmbd-webdav-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temporary file: %v", err)
+	}
+	return &webdavUpload{s: s, rel: s.rel(localPath), spool: spool}, nil
+}
+
+// Open implements Storage
+func (s *WebDAVStorage) Open(localPath string) (io.ReadCloser, error) {
+	rel := s.rel(localPath)
+	resp, err := s.do(http.MethodGet, rel, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, webdavError(resp, "open", rel)
+	}
+	return resp.Body, nil
+}
+
+// Rename implements Storage
+func (s *WebDAVStorage) Rename(oldPath, newPath string) error {
+	oldRel, newRel := s.rel(oldPath), s.rel(newPath)
+	header := http.Header{}
+	header.Set("Destination", s.remoteURL(newRel))
+	header.Set("Overwrite", "T")
+	resp, err := s.do("MOVE", oldRel, header, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return webdavError(resp, "rename", oldRel)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// Remove implements Storage
+func (s *WebDAVStorage) Remove(localPath string) error {
+	rel := s.rel(localPath)
+	resp, err := s.do(http.MethodDelete, rel, nil, nil)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode/100 != 2 {
+		return webdavError(resp, "remove", rel)
+	}
+	resp.Body.Close()
+	return nil
+}
+
+// TestWebDAVConnection checks that the WebDAV folder exists and is writable
+func TestWebDAVConnection(cfg WebDAVConfig) error {
+	s, err := NewWebDAVStorage(cfg, "")
+	if err != nil {
+		return err
+	}
+	info, err := s.Stat("")
+	if err != nil {
+		return fmt.Errorf("remote folder not found: %v", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("not a folder: %s", cfg.URL)
+	}
+	probe := fmt.Sprintf(".write-test-%d", time.Now().UnixNano())
+	w, err := s.Create(probe)
+	if err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("remote folder is not writable: %v", err)
+	}
+	return s.Remove(probe)
+}
+
+// webdavUpload spools a file and uploads it when closed
+type webdavUpload struct {
+	s     *WebDAVStorage
+	rel   string
+	spool *os.File
+}
+
+func (u *webdavUpload) Write(p []byte) (int, error) {
+	return u.spool.Write(p)
+}
+
+func (u *webdavUpload) Close() error {
+	defer os.Remove(u.spool.Name())
+	defer u.spool.Close()
+	size, err := u.spool.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return err
+	}
+	resp, err := u.s.do(http.MethodPut, u.rel, nil, func() (io.Reader, int64, error) {
+		if _, err := u.spool.Seek(0, io.SeekStart); err != nil {
+			return nil, 0, err
+		}
+		return io.NopCloser(u.spool), size, nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to upload %s: %v", u.rel, err)
+	}
+	if resp.StatusCode/100 != 2 {
+		return webdavError(resp, "upload", u.rel)
+	}
+	resp.Body.Close()
+	return nil
+}
@@ -1,9 +1,12 @@
 import { useState, useEffect, useRef, useCallback } from "react";
 import { TooltipProvider } from "@/components/ui/tooltip";
-import { getSettings, applyThemeMode, applyFont } from "@/lib/settings";
+import { getSettings, saveSettings, applyThemeMode, applyFont, getDateZone } from "@/lib/settings";
 import { applyTheme } from "@/lib/themes";
 import { toastWithSound as toast } from "@/lib/toast-with-sound";
 import { logger } from "@/lib/logger";
+import { parseRetryAt, formatRetryAt, waitUntil } from "@/lib/rate-limit";
+import { loadMessageCatalog, localizeMessage } from "@/lib/messages";
+import { loadAuthTokens, getAuthToken } from "@/lib/auth-tokens";
 import {
   saveFetchState,
   getFetchState,
@@ -11,6 +14,8 @@ import {
   getResumableInfo,
   stateToResponse,
   mergeTimelines,
+  mergeTimelineStats,
+  estimateFetchProgress,
   saveCursor,
   getCursor,
   clearCursor,
@@ -21,16 +26,20 @@ import {
 import { TitleBar } from "@/components/TitleBar";
 import { Sidebar, type PageType } from "@/components/Sidebar";
 import { Header } from "@/components/Header";
-import { SearchBar, type FetchMode, type PrivateType, type FetchType, type MultipleAccount } from "@/components/SearchBar";
+import { SearchBar, type FetchMode, type PrivateType, type FetchType, type MultipleAccount, type FetchEstimate } from "@/components/SearchBar";
 import { MediaList } from "@/components/MediaList";
 import { DatabaseView } from "@/components/DatabaseView";
 import { SettingsPage } from "@/components/SettingsPage";
 import { DebugLoggerPage } from "@/components/DebugLoggerPage";
+import { ConflictDialog } from "@/components/ConflictDialog";
+import { DownloadConfirmDialog } from "@/components/DownloadConfirmDialog";
 import type { HistoryItem } from "@/components/FetchHistory";
-import type { TwitterResponse } from "@/types/api";
+import type { TwitterResponse, TimelineStats, ExtractProgress } from "@/types/api";
+import { backend } from "../wailsjs/go/models";
 
 // Wails bindings
-import { ExtractTimeline, ExtractDateRange, SaveAccountToDBWithStatus, CleanupExtractorProcesses, GetAllAccountsFromDB } from "../wailsjs/go/main/App";
+import { ExtractTimeline, ExtractDateRange, EstimateJob, SaveAccountToDBWithStatus, CleanupExtractorProcesses, GetAllAccountsFromDB, BatchImport, FetchBatchEntry, SetClipboardWatch, SetAccountWatch, SetMediaServer } from "../wailsjs/go/main/App";
+import { EventsOn, EventsOff } from "../wailsjs/runtime/runtime";
 
 const HISTORY_KEY = "twitter_media_fetch_history";
 const MAX_HISTORY = 10;
@@ -41,6 +50,18 @@ function formatNumberWithComma(num: number): string {
   return num.toLocaleString();
 }
 
+// Log sensitive tweets that came back without media (age-restricted for the auth token used)
+function reportRestricted(data: TwitterResponse, username: string) {
+  if (!data.restricted) return;
+  const missing = data.restricted_tweet_ids?.length || 0;
+  if (data.restricted_recovered) {
+    logger.info(`@${username}: fetched ${data.restricted_recovered} age-restricted tweet${data.restricted_recovered !== 1 ? "s" : ""} with the age-restricted auth token`);
+  }
+  if (missing > 0) {
+    logger.warning(`@${username}: ${missing} age-restricted tweet${missing !== 1 ? "s" : ""} skipped, their media isn't visible to this auth token`);
+  }
+}
+
 function App() {
   const [currentPage, setCurrentPage] = useState<PageType>("main");
   const [username, setUsername] = useState("");
@@ -54,6 +75,10 @@ function App() {
   const [elapsedTime, setElapsedTime] = useState(0);
   const [remainingTime, setRemainingTime] = useState<number | null>(null);
   const [newMediaCount, setNewMediaCount] = useState<number | null>(null);
+  const [extractProgress, setExtractProgress] = useState<ExtractProgress | null>(null);
+  const [fetchEstimate, setFetchEstimate] = useState<FetchEstimate | null>(null);
+  // Totals of the finished batches, and the tweets a resumed fetch started with
+  const extractProgressBaseRef = useRef({ pages: 0, items: 0, media: 0, tweets: 0, startTweets: 0 });
   const stopFetchRef = useRef(false);
   const fetchStartTimeRef = useRef<number | null>(null);
   const timeoutIntervalRef = useRef<number | null>(null);
@@ -112,12 +137,143 @@ function App() {
     mediaQuery.addEventListener("change", handleChange);
     checkForUpdates();
     loadHistory();
+    loadMessageCatalog();
 
     return () => {
       mediaQuery.removeEventListener("change", handleChange);
     };
   }, []);
 
+  // Offer tweet links copied while the clipboard watcher is on
+  useEffect(() => {
+    SetClipboardWatch(getSettings().watchClipboard).catch(() => {});
+
+    EventsOn("clipboard-tweets", (entries: backend.BatchImportEntry[]) => {
+      const label = entries.length === 1 ? "Copied tweet link" : `Copied ${entries.length} tweet links`;
+      toast.info(label, {
+        description: entries.map((entry) => entry.input).join("\n"),
+        action: {
+          label: "Add",
+          onClick: () => {
+            const accounts: MultipleAccount[] = entries.map((entry) => ({
+              id: crypto.randomUUID(),
+              username: entry.username || entry.tweet_id || entry.input,
+              status: "pending",
+              mediaCount: 0,
+              previousMediaCount: 0,
+              elapsedTime: 0,
+              remainingTime: null,
+              showDiff: false,
+              entry,
+            }));
+            // Skip tweets already in the list
+            setMultipleAccounts((prev) => {
+              const queued = new Set(prev.map((acc) => acc.entry?.tweet_id).filter(Boolean));
+              return [...prev, ...accounts.filter((acc) => !queued.has(acc.entry?.tweet_id))];
+            });
+            setFetchType("multiple");
+            setCurrentPage("main");
+          },
+        },
+      });
+    });
+
+    return () => {
+      EventsOff("clipboard-tweets");
+    };
+  }, []);
+
+  // Alert about saved accounts that changed while the account watcher is on
+  useEffect(() => {
+    const settings = getSettings();
+    loadAuthTokens().finally(() => {
+      saveSettings(getSettings()); // Moves a restricted token left in the settings
+      SetAccountWatch(settings.watchAccounts, settings.watchAccountsHours, getAuthToken("public")).catch(() => {});
+    });
+    if (settings.mediaServer) {
+      SetMediaServer(true, settings.mediaServerPort, settings.downloadPath).catch((err) => toast.error(`Media server failed to start: ${err}`));
+    }
+
+    EventsOn("notification-failed", (error: string) => {
+      logger.error(`Desktop notification failed: ${error}`);
+      toast.warning("Desktop notifications aren't working on this system, see the debug log");
+    });
+
+    EventsOn("account-watch", (result: backend.AccountWatchResult) => {
+      if (!result.urgent_archive) {
+        toast.warning(result.message, { duration: Infinity });
+        return;
+      }
+      toast.warning(result.message, {
+        description: "Archive it now, before more is lost",
+        duration: Infinity,
+        action: {
+          label: "Archive",
+          onClick: () => {
+            setMultipleAccounts((prev) => {
+              if (prev.some((acc) => acc.username.toLowerCase() === result.username.toLowerCase())) return prev;
+              return [...prev, {
+                id: crypto.randomUUID(),
+                username: result.username,
+                status: "pending",
+                mediaCount: 0,
+                previousMediaCount: 0,
+                elapsedTime: 0,
+                remainingTime: null,
+                showDiff: false,
+              }];
+            });
+            setFetchType("multiple");
+            setCurrentPage("main");
+          },
+        },
+      });
+    });
+
+    return () => {
+      EventsOff("notification-failed");
+      EventsOff("account-watch");
+    };
+  }, []);
+
+  // Progress of the running extraction, added up over the batches of a fetch
+  useEffect(() => {
+    EventsOn("extract-progress", (progress: ExtractProgress) => {
+      const base = extractProgressBaseRef.current;
+      const total = {
+        ...progress,
+        pages: base.pages + progress.pages,
+        items: base.items + progress.items,
+        media: base.media + progress.media,
+        tweets: base.tweets + progress.tweets,
+      };
+      if (progress.done) {
+        extractProgressBaseRef.current = { ...base, pages: total.pages, items: total.items, media: total.media, tweets: total.tweets };
+      }
+      setExtractProgress(total);
+      const elapsed = fetchStartTimeRef.current !== null ? (Date.now() - fetchStartTimeRef.current) / 1000 : progress.elapsed_seconds;
+      setFetchEstimate(estimateFetchProgress(total.tweets, total.expected, base.startTweets, elapsed));
+    });
+
+    return () => {
+      EventsOff("extract-progress");
+    };
+  }, []);
+
+  // Report new matches of smart collections with automatic actions
+  useEffect(() => {
+    EventsOn("collection-matches", (result: { name: string; new: number; exported: number; downloaded: number }) => {
+      const details = [];
+      if (result.downloaded > 0) details.push(`${result.downloaded} downloaded`);
+      if (result.exported > 0) details.push(`${result.exported} exported`);
+      toast.info(`${result.new} new in ${result.name}`, { description: details.join(", ") || undefined });
+    });
+
+    return () => {
+      EventsOff("collection-matches");
+    };
+  }, []);
+
   const checkForUpdates = async () => {
     try {
       const response = await fetch(
@@ -302,6 +458,9 @@ function App() {
     // Reset state for new fetch
     setLoading(true);
     setFetchedMediaType(mediaType || "all");
+    extractProgressBaseRef.current = { pages: 0, items: 0, media: 0, tweets: 0, startTweets: 0 };
+    setExtractProgress(null);
+    setFetchEstimate(null);
     stopFetchRef.current = false;
     fetchStartTimeRef.current = Date.now();
     setElapsedTime(0);
@@ -332,6 +491,7 @@ function App() {
     let cursor: string | undefined;
     let allTimeline: TwitterResponse["timeline"] = [];
     let accountInfo: TwitterResponse["account_info"] | null = null;
+    let allStats: TimelineStats | undefined; // Unknown when resuming from a saved state
 
     if (isResume) {
       existingState = getFetchState(cleanUsername);
@@ -339,6 +499,8 @@ function App() {
         cursor = existingState.cursor;
         allTimeline = existingState.timeline;
         accountInfo = existingState.accountInfo;
+        const resumedTweets = new Set(allTimeline.map((entry) => entry.tweet_id)).size;
+        extractProgressBaseRef.current = { ...extractProgressBaseRef.current, tweets: resumedTweets, startTweets: resumedTweets };
         logger.info(`Resuming ${fetchTarget} from ${allTimeline.length} items...`);
         
         // Show existing data immediately
@@ -390,8 +552,12 @@ function App() {
           end_date: endDate,
           media_filter: mediaType || "all",
           retweets: retweets || false,
+          date_zone: getDateZone(getSettings()),
+          save_to_wayback: getSettings().waybackSave,
+          restricted_auth_token: getSettings().restrictedAuthToken || "",
         });
         finalData = JSON.parse(response);
+        if (finalData) reportRestricted(finalData, username.trim());
         
         // Save to database immediately for date range mode
         if (finalData && finalData.account_info) {
@@ -434,13 +600,29 @@ function App() {
         const isSingleMode = settings.fetchMode === "single";
         const batchSize = isSingleMode ? 0 : BATCH_SIZE;
         
-        let timelineType = "timeline";
+        let timelineType: string = settings.timelineType || "timeline";
         if (isBookmarks) {
           timelineType = "bookmarks";
         } else if (isLikes) {
           timelineType = "likes";
         }
 
+        // Rough cost of a fresh fetch of a known account (no extra API call without a stored size)
+        if (!isResume && !isBookmarks) {
+          EstimateJob(new backend.JobEstimateRequest({
+            username: username.trim(),
+            timeline_type: timelineType,
+            media_type: mediaType || "all",
+            batch_size: batchSize,
+          }))
+            .then((estimate) => {
+              const minutes = Math.max(1, Math.round(estimate.duration_seconds / 60));
+              const waits = estimate.exceeds_rate_limit ? `, waits for the rate limit ${estimate.rate_windows - 1}x` : "";
+              logger.info(`Estimate: ~${formatNumberWithComma(estimate.items)} items, ~${formatNumberWithComma(estimate.requests)} requests, ~${minutes} min${waits}`);
+            })
+            .catch(() => {});
+        }
+
         let hasMore = true;
         let page = 0;
 
@@ -521,9 +703,20 @@ function App() {
             media_type: mediaType || "all",
             retweets: retweets || false,
             cursor: cursor,
+            date_zone: getDateZone(getSettings()),
+            save_to_wayback: getSettings().waybackSave,
+            restricted_auth_token: getSettings().restrictedAuthToken || "",
           });
 
           const data: TwitterResponse = JSON.parse(response);
+          reportRestricted(data, cleanUsername);
+          if (data.partial) {
+            logger.warning(`Extractor stopped early, kept ${data.timeline.length} items: ${localizeMessage(data.error || "")}`);
+            const retryAt = data.retry_at ? new Date(data.retry_at) : null;
+            if (retryAt) {
+              toast.warning(`Rate limited - continue after ${formatRetryAt(retryAt)}`);
+            }
+          }
 
           // Set account info from first response
           if (!accountInfo && data.account_info) {
@@ -542,6 +735,7 @@ function App() {
           // Merge new entries (deduplicate)
           const previousCount = allTimeline.length;
           allTimeline = mergeTimelines(allTimeline, data.timeline);
+          allStats = page === 0 && !isResume ? data.metadata.stats : mergeTimelineStats(allStats, data.metadata.stats);
           const newCount = allTimeline.length - previousCount;
           
           // Update new media count for display
@@ -574,6 +768,7 @@ function App() {
                 has_more: hasMore,
                 cursor: cursor,
                 completed: !hasMore,
+                stats: allStats,
               },
               cursor: cursor,
               completed: !hasMore,
@@ -611,6 +806,7 @@ function App() {
                 has_more: hasMore,
                 cursor: cursor,
                 completed: !hasMore,
+                stats: allStats,
               },
               cursor: cursor,
               completed: !hasMore,
@@ -658,6 +854,7 @@ function App() {
                 has_more: false,
                 cursor: cursor,
                 completed: !stopFetchRef.current,
+                stats: allStats,
               },
               cursor: cursor,
               completed: !stopFetchRef.current,
@@ -704,8 +901,9 @@ function App() {
     } catch (error) {
       const errorMsg = error instanceof Error ? error.message : String(error);
       const elapsedSecs = fetchStartTimeRef.current ? Math.floor((Date.now() - fetchStartTimeRef.current) / 1000) : 0;
-      logger.error(`Failed to fetch: ${errorMsg} (${elapsedSecs}s)`);
-      toast.error("Failed to fetch media");
+      logger.error(`Failed to fetch: ${localizeMessage(errorMsg)} (${elapsedSecs}s)`);
+      const retryAt = parseRetryAt(errorMsg);
+      toast.error(retryAt ? `Rate limited - retry at ${formatRetryAt(retryAt)}` : "Failed to fetch media");
 
       // On error, check if we have partial data saved
       const savedState = getFetchState(cleanUsername);
@@ -838,45 +1036,66 @@ function App() {
     toast.success(`Added ${formatNumberWithComma(accounts.length)} account(s) to multiple fetch`);
   };
 
-  // Handle import txt file
-  const handleImportFile = () => {
-    const input = document.createElement("input");
-    input.type = "file";
-    input.accept = ".txt";
-    input.onchange = async (e) => {
-      const file = (e.target as HTMLInputElement).files?.[0];
-      if (!file) return;
-
-      try {
-        const text = await file.text();
-        const lines = text.split("\n").map((line) => line.trim()).filter((line) => line.length > 0);
-        
-        if (lines.length === 0) {
-          toast.error("File is empty");
-          return;
-        }
+  // Handle import of a TXT/CSV file of usernames, profile, tweet and search URLs
+  const handleImportFile = async () => {
+    try {
+      const result = await BatchImport();
+      if (!result) return;
 
-        const accounts: MultipleAccount[] = lines.map((line) => {
-          const username = parseUsername(line);
-          return {
-            id: crypto.randomUUID(),
-            username,
-            status: "pending",
-            mediaCount: 0,
-            previousMediaCount: 0,
-            elapsedTime: 0,
-            remainingTime: null,
-            showDiff: false,
-          };
-        });
+      for (const lineError of result.errors) {
+        logger.warning(`Import line ${lineError.line}: ${lineError.message}`);
+      }
+      if (result.entries.length === 0) {
+        toast.error(result.errors.length > 0 ? `No valid lines (${result.errors.length} skipped)` : "File is empty");
+        return;
+      }
 
-        setMultipleAccounts(accounts);
-        toast.success(`Imported ${formatNumberWithComma(accounts.length)} account(s)`);
-      } catch (error) {
-        toast.error("Failed to read file");
+      const accounts: MultipleAccount[] = result.entries.map((entry) => ({
+        id: crypto.randomUUID(),
+        username: entry.username || entry.tweet_id || entry.input,
+        status: "pending",
+        mediaCount: 0,
+        previousMediaCount: 0,
+        elapsedTime: 0,
+        remainingTime: null,
+        showDiff: false,
+        timelineType: entry.mode === "profile" ? entry.timeline_type : undefined,
+        entry: entry.mode === "profile" ? undefined : entry,
+      }));
+
+      setMultipleAccounts(accounts);
+      let message = `Imported ${formatNumberWithComma(accounts.length)} entries`;
+      if (result.errors.length > 0) {
+        message += `, ${formatNumberWithComma(result.errors.length)} invalid lines skipped (see Debug Logs)`;
       }
-    };
-    input.click();
+      toast.success(message);
+    } catch (error) {
+      toast.error(`Failed to read file: ${error}`);
+    }
+  };
+
+  // Fetch a single tweet or search from a batch import, returns the error message if it failed
+  const fetchBatchEntry = async (account: MultipleAccount, authToken: string): Promise<string | null> => {
+    const label = account.entry?.input || account.username;
+    setMultipleAccounts((prev) =>
+      prev.map((acc) => (acc.id === account.id ? { ...acc, status: "fetching" as const, error: undefined } : acc))
+    );
+    try {
+      const result = await FetchBatchEntry(account.entry!, authToken, getDateZone(getSettings()));
+      const status = result.partial ? ("incomplete" as const) : ("completed" as const);
+      setMultipleAccounts((prev) =>
+        prev.map((acc) => (acc.id === account.id ? { ...acc, status, mediaCount: result.media, error: result.error || undefined } : acc))
+      );
+      logger.success(`${label}: ${result.media} media saved to ${result.accounts.map((name) => "@" + name).join(", ") || "no account"}`);
+      return result.partial ? result.error || null : null;
+    } catch (error) {
+      const errorMsg = String(error);
+      setMultipleAccounts((prev) =>
+        prev.map((acc) => (acc.id === account.id ? { ...acc, status: "failed" as const, error: errorMsg } : acc))
+      );
+      logger.error(`${label}: failed - ${localizeMessage(errorMsg)}`);
+      return errorMsg;
+    }
   };
 
   // Handle fetch all accounts
@@ -889,9 +1108,8 @@ function App() {
     setIsFetchingAll(true);
     stopAllRef.current = false;
 
-    // Get auth token from localStorage (same as SearchBar)
-    const PUBLIC_AUTH_TOKEN_KEY = "twitter_public_auth_token";
-    const authToken = localStorage.getItem(PUBLIC_AUTH_TOKEN_KEY) || "";
+    // Same auth token as SearchBar
+    const authToken = getAuthToken("public");
     if (!authToken.trim()) {
       toast.error("Please enter your auth token");
       setIsFetchingAll(false);
@@ -919,16 +1137,36 @@ function App() {
     // Get current accounts list (use state getter function)
     let currentAccounts = [...multipleAccounts];
 
+    // Set when an account hits a rate limit with a known reset time
+    let rateLimitedUntil: Date | null = null;
+
     // Fetch each account sequentially
     for (let i = 0; i < currentAccounts.length; i++) {
       if (stopAllRef.current) {
         break;
       }
 
+      // Back off until the rate limit resets instead of failing the remaining accounts too
+      if (rateLimitedUntil && rateLimitedUntil.getTime() > Date.now()) {
+        logger.warning(`Rate limited - waiting until ${formatRetryAt(rateLimitedUntil)} before @${currentAccounts[i].username}`);
+        await waitUntil(rateLimitedUntil, () => stopAllRef.current);
+        if (stopAllRef.current) {
+          break;
+        }
+      }
+      rateLimitedUntil = null;
+
       const account = currentAccounts[i];
       const accountId = account.id;
       accountStopFlagsRef.current.set(accountId, false);
 
+      // Single tweets and searches are fetched in one go
+      if (account.entry) {
+        const errorMsg = await fetchBatchEntry(account, authToken.trim());
+        rateLimitedUntil = errorMsg ? parseRetryAt(errorMsg) : null;
+        continue;
+      }
+
       // Update status to fetching
       setMultipleAccounts((prev) =>
         prev.map((acc) =>
@@ -1042,7 +1280,7 @@ function App() {
                 authToken: authToken.trim(),
                 mediaType: fetchedMediaType || "all",
                 retweets: false,
-                timelineType: "timeline",
+                timelineType: account.timelineType || "timeline",
               });
               
               // Also save to database
@@ -1081,15 +1319,19 @@ function App() {
           const response = await ExtractTimeline({
             username: cleanUsername,
             auth_token: authToken.trim(),
-            timeline_type: "timeline",
+            timeline_type: account.timelineType || "timeline",
             batch_size: batchSizeMultiple,
             page: page,
             media_type: fetchedMediaType || "all",
             retweets: false,
             cursor: cursor,
+            date_zone: getDateZone(getSettings()),
+            save_to_wayback: getSettings().waybackSave,
+            restricted_auth_token: getSettings().restrictedAuthToken || "",
           });
 
           const data: TwitterResponse = JSON.parse(response);
+          reportRestricted(data, cleanUsername);
 
           if (!accountInfo && data.account_info) {
             accountInfo = data.account_info;
@@ -1153,7 +1395,7 @@ function App() {
               authToken: authToken.trim(),
               mediaType: fetchedMediaType || "all",
               retweets: false,
-              timelineType: "timeline",
+              timelineType: account.timelineType || "timeline",
             });
           }
 
@@ -1294,7 +1536,8 @@ function App() {
               : acc
           )
         );
-        logger.error(`@${account.username}: failed - ${errorMsg} (${elapsedSecs}s)`);
+        logger.error(`@${account.username}: failed - ${localizeMessage(errorMsg)} (${elapsedSecs}s)`);
+        rateLimitedUntil = parseRetryAt(errorMsg);
       }
     }
 
@@ -1371,14 +1614,17 @@ function App() {
     const account = multipleAccounts.find((acc) => acc.id === accountId);
     if (!account) return;
 
-    // Get auth token from localStorage
-    const PUBLIC_AUTH_TOKEN_KEY = "twitter_public_auth_token";
-    const authToken = localStorage.getItem(PUBLIC_AUTH_TOKEN_KEY) || "";
+    const authToken = getAuthToken("public");
     if (!authToken.trim()) {
       toast.error("Please enter your auth token");
       return;
     }
 
+    if (account.entry) {
+      await fetchBatchEntry(account, authToken.trim());
+      return;
+    }
+
     // Get fetch mode from settings
     const retrySettings = getSettings();
     const isSingleModeRetry = retrySettings.fetchMode === "single";
@@ -1526,15 +1772,19 @@ function App() {
         const response = await ExtractTimeline({
           username: cleanUsername,
           auth_token: authToken.trim(),
-          timeline_type: "timeline",
+          timeline_type: account.timelineType || "timeline",
           batch_size: batchSizeRetry,
           page: page,
           media_type: fetchedMediaType || "all",
           retweets: false,
           cursor: cursor,
+          date_zone: getDateZone(getSettings()),
+          save_to_wayback: getSettings().waybackSave,
+          restricted_auth_token: getSettings().restrictedAuthToken || "",
         });
 
         const data: TwitterResponse = JSON.parse(response);
+        reportRestricted(data, cleanUsername);
 
         if (!accountInfo && data.account_info) {
           accountInfo = data.account_info;
@@ -1584,7 +1834,7 @@ function App() {
             authToken: authToken.trim(),
             mediaType: fetchedMediaType || "all",
             retweets: false,
-            timelineType: "timeline",
+            timelineType: account.timelineType || "timeline",
           });
         }
 
@@ -1731,7 +1981,7 @@ function App() {
             : acc
         )
       );
-      logger.error(`@${account.username}: failed - ${errorMsg} (${elapsedSecs}s)`);
+      logger.error(`@${account.username}: failed - ${localizeMessage(errorMsg)} (${elapsedSecs}s)`);
     }
   };
 
@@ -1773,6 +2023,8 @@ function App() {
               hasResult={!!result}
               elapsedTime={elapsedTime}
               remainingTime={remainingTime}
+              extractProgress={extractProgress}
+              fetchEstimate={fetchEstimate}
               fetchType={fetchType}
               onFetchTypeChange={handleFetchTypeChange}
               multipleAccounts={multipleAccounts}
@@ -1800,6 +2052,7 @@ function App() {
                   totalUrls={result.total_urls}
                   fetchedMediaType={fetchedMediaType}
                   newMediaCount={newMediaCount}
+                  stats={result.metadata?.stats}
                 />
               </div>
             )}
@@ -1820,6 +2073,8 @@ function App() {
             {renderPage()}
           </div>
         </div>
+        <ConflictDialog />
+        <DownloadConfirmDialog />
       </div>
     </TooltipProvider>
   );
@@ -0,0 +1,375 @@
+import { useEffect, useState } from "react";
+import { Button } from "@/components/ui/button";
+import { Badge } from "@/components/ui/badge";
+import { Checkbox } from "@/components/ui/checkbox";
+import { Input } from "@/components/ui/input";
+import { Label } from "@/components/ui/label";
+import { Switch } from "@/components/ui/switch";
+import {
+  Select,
+  SelectContent,
+  SelectItem,
+  SelectTrigger,
+  SelectValue,
+} from "@/components/ui/select";
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+} from "@/components/ui/dialog";
+import { Pencil, Play, Plus, Trash2, FolderOpen } from "lucide-react";
+import { toastWithSound as toast } from "@/lib/toast-with-sound";
+import { getSettings } from "@/lib/settings";
+import { openExternal } from "@/lib/utils";
+import { SaveCollection, DeleteCollection, ListCollections, EvaluateCollection, SelectFolder } from "../../wailsjs/go/main/App";
+import { backend } from "../../wailsjs/go/models";
+
+const MEDIA_TYPES = [
+  { value: "photo", label: "Images" },
+  { value: "video", label: "Videos" },
+  { value: "gif", label: "GIFs" },
+  { value: "text", label: "Text" },
+];
+
+// Matches shown when a collection is opened
+const MAX_SHOWN_MATCHES = 200;
+
+interface CollectionForm {
+  id: string;
+  name: string;
+  usernames: string;
+  types: string[];
+  minLikes: string;
+  minRetweets: string;
+  minViews: string;
+  since: string;
+  until: string;
+  text: string;
+  retweets: string;
+  tag: string;
+  starred: boolean;
+  autoExport: string;
+  autoDownload: boolean;
+}
+
+const emptyForm: CollectionForm = {
+  id: "",
+  name: "",
+  usernames: "",
+  types: [],
+  minLikes: "",
+  minRetweets: "",
+  minViews: "",
+  since: "",
+  until: "",
+  text: "",
+  retweets: "any",
+  tag: "",
+  starred: false,
+  autoExport: "",
+  autoDownload: false,
+};
+
+function toForm(c: backend.SmartCollection): CollectionForm {
+  const q = c.query;
+  return {
+    id: c.id,
+    name: c.name,
+    usernames: (q.usernames || []).join(", "),
+    types: q.types || [],
+    minLikes: q.min_likes ? String(q.min_likes) : "",
+    minRetweets: q.min_retweets ? String(q.min_retweets) : "",
+    minViews: q.min_views ? String(q.min_views) : "",
+    since: q.since || "",
+    until: q.until || "",
+    text: q.text || "",
+    retweets: q.retweets || "any",
+    tag: q.tag || "",
+    starred: !!q.starred,
+    autoExport: c.auto_export,
+    autoDownload: c.auto_download,
+  };
+}
+
+// describeQuery summarizes a collection's query, e.g. "videos from @user, 10,000+ likes, 2024-01-01 to 2024-12-31"
+function describeQuery(q: backend.CollectionQuery): string {
+  const parts: string[] = [];
+  const types = (q.types || []).map((t) => MEDIA_TYPES.find((m) => m.value === t)?.label.toLowerCase() || t);
+  parts.push(types.length > 0 ? types.join(", ") : "all media");
+  if (q.usernames && q.usernames.length > 0) parts.push(`from ${q.usernames.map((u) => `@${u}`).join(", ")}`);
+  if (q.min_likes) parts.push(`${q.min_likes.toLocaleString()}+ likes`);
+  if (q.min_retweets) parts.push(`${q.min_retweets.toLocaleString()}+ retweets`);
+  if (q.min_views) parts.push(`${q.min_views.toLocaleString()}+ views`);
+  if (q.since || q.until) parts.push(`${q.since || "start"} to ${q.until || "now"}`);
+  if (q.text) parts.push(`"${q.text}"`);
+  if (q.retweets === "only") parts.push("retweets only");
+  if (q.retweets === "exclude") parts.push("no retweets");
+  if (q.tag) parts.push(`tagged ${q.tag}`);
+  if (q.starred) parts.push("starred");
+  return parts.join(", ");
+}
+
+interface CollectionsDialogProps {
+  open: boolean;
+  onOpenChange: (open: boolean) => void;
+}
+
+// Lists, edits and evaluates smart collections (saved searches over the library)
+export function CollectionsDialog({ open, onOpenChange }: CollectionsDialogProps) {
+  const [collections, setCollections] = useState<backend.SmartCollection[]>([]);
+  const [form, setForm] = useState<CollectionForm | null>(null);
+  const [result, setResult] = useState<backend.CollectionResult | null>(null);
+
+  const loadCollections = async () => {
+    try {
+      setCollections((await ListCollections()) || []);
+    } catch (error) {
+      toast.error(`Failed to load collections: ${error}`);
+    }
+  };
+
+  useEffect(() => {
+    if (open) {
+      loadCollections();
+      setForm(null);
+      setResult(null);
+    }
+  }, [open]);
+
+  const updateForm = (change: Partial<CollectionForm>) => {
+    setForm((prev) => (prev ? { ...prev, ...change } : prev));
+  };
+
+  const handleSave = async () => {
+    if (!form) return;
+    const settings = getSettings();
+    const collection = new backend.SmartCollection({
+      id: form.id,
+      name: form.name,
+      query: new backend.CollectionQuery({
+        usernames: form.usernames.split(",").map((u) => u.trim().replace(/^@/, "")).filter(Boolean),
+        types: form.types,
+        min_likes: parseInt(form.minLikes) || 0,
+        min_retweets: parseInt(form.minRetweets) || 0,
+        min_views: parseInt(form.minViews) || 0,
+        since: form.since,
+        until: form.until,
+        text: form.text,
+        retweets: form.retweets === "any" ? "" : form.retweets,
+        tag: form.tag,
+        starred: form.starred,
+      }),
+      auto_export: form.autoExport,
+      auto_download: form.autoDownload,
+      download_dir: settings.downloadPath,
+      created_at: "",
+      last_matches: 0,
+    });
+    try {
+      const saved = await SaveCollection(collection);
+      setForm(null);
+      await loadCollections();
+      handleEvaluate(saved);
+    } catch (error) {
+      toast.error(`Failed to save collection: ${error}`);
+    }
+  };
+
+  const handleDelete = async (c: backend.SmartCollection) => {
+    try {
+      await DeleteCollection(c.id);
+      if (result?.collection.id === c.id) setResult(null);
+      loadCollections();
+    } catch (error) {
+      toast.error(`Failed to delete collection: ${error}`);
+    }
+  };
+
+  const handleEvaluate = async (c: backend.SmartCollection) => {
+    try {
+      setResult(await EvaluateCollection(c.id));
+      loadCollections();
+    } catch (error) {
+      toast.error(`Failed to evaluate collection: ${error}`);
+    }
+  };
+
+  const handleSelectExportFolder = async () => {
+    const folder = await SelectFolder(form?.autoExport || getSettings().downloadPath);
+    if (folder) updateForm({ autoExport: folder });
+  };
+
+  const toggleType = (type: string) => {
+    if (!form) return;
+    updateForm({ types: form.types.includes(type) ? form.types.filter((t) => t !== type) : [...form.types, type] });
+  };
+
+  return (
+    <Dialog open={open} onOpenChange={onOpenChange}>
+      <DialogContent className="max-w-2xl max-h-[85vh] overflow-y-auto">
+        <DialogHeader>
+          <DialogTitle>Smart Collections</DialogTitle>
+          <DialogDescription>
+            Saved searches over the library. Collections can export or download new matches by themselves whenever an account is saved.
+          </DialogDescription>
+        </DialogHeader>
+
+        {form ? (
+          <div className="space-y-3">
+            <div className="space-y-1">
+              <Label htmlFor="collectionName">Name</Label>
+              <Input id="collectionName" value={form.name} onChange={(e) => updateForm({ name: e.target.value })} placeholder="e.g. Best videos of 2024" autoFocus />
+            </div>
+            <div className="space-y-1">
+              <Label htmlFor="collectionUsers">Accounts</Label>
+              <Input id="collectionUsers" value={form.usernames} onChange={(e) => updateForm({ usernames: e.target.value })} placeholder="All accounts, or e.g. user1, user2" />
+            </div>
+            <div className="flex items-center gap-4">
+              {MEDIA_TYPES.map((type) => (
+                <label key={type.value} className="flex items-center gap-2 text-sm">
+                  <Checkbox checked={form.types.includes(type.value)} onCheckedChange={() => toggleType(type.value)} />
+                  {type.label}
+                </label>
+              ))}
+            </div>
+            <div className="grid grid-cols-3 gap-2">
+              <div className="space-y-1">
+                <Label htmlFor="collectionLikes">Min Likes</Label>
+                <Input id="collectionLikes" type="number" min={0} value={form.minLikes} onChange={(e) => updateForm({ minLikes: e.target.value })} />
+              </div>
+              <div className="space-y-1">
+                <Label htmlFor="collectionRetweets">Min Retweets</Label>
+                <Input id="collectionRetweets" type="number" min={0} value={form.minRetweets} onChange={(e) => updateForm({ minRetweets: e.target.value })} />
+              </div>
+              <div className="space-y-1">
+                <Label htmlFor="collectionViews">Min Views</Label>
+                <Input id="collectionViews" type="number" min={0} value={form.minViews} onChange={(e) => updateForm({ minViews: e.target.value })} />
+              </div>
+            </div>
+            <div className="grid grid-cols-2 gap-2">
+              <div className="space-y-1">
+                <Label htmlFor="collectionSince">From</Label>
+                <Input id="collectionSince" type="date" value={form.since} onChange={(e) => updateForm({ since: e.target.value })} />
+              </div>
+              <div className="space-y-1">
+                <Label htmlFor="collectionUntil">To</Label>
+                <Input id="collectionUntil" type="date" value={form.until} onChange={(e) => updateForm({ until: e.target.value })} />
+              </div>
+            </div>
+            <div className="grid grid-cols-2 gap-2">
+              <div className="space-y-1">
+                <Label htmlFor="collectionText">Tweet Contains</Label>
+                <Input id="collectionText" value={form.text} onChange={(e) => updateForm({ text: e.target.value })} />
+              </div>
+              <div className="space-y-1">
+                <Label htmlFor="collectionTag">Tag</Label>
+                <Input id="collectionTag" value={form.tag} onChange={(e) => updateForm({ tag: e.target.value })} />
+              </div>
+            </div>
+            <div className="flex items-center gap-4">
+              <Select value={form.retweets} onValueChange={(value) => updateForm({ retweets: value })}>
+                <SelectTrigger className="w-auto">
+                  <SelectValue />
+                </SelectTrigger>
+                <SelectContent>
+                  <SelectItem value="any">With Retweets</SelectItem>
+                  <SelectItem value="exclude">Without Retweets</SelectItem>
+                  <SelectItem value="only">Only Retweets</SelectItem>
+                </SelectContent>
+              </Select>
+              <label className="flex items-center gap-2 text-sm">
+                <Checkbox checked={form.starred} onCheckedChange={(checked) => updateForm({ starred: checked === true })} />
+                Starred only
+              </label>
+            </div>
+            <div className="space-y-1">
+              <Label>Export New Matches To</Label>
+              <div className="flex items-center gap-2">
+                <Input value={form.autoExport} onChange={(e) => updateForm({ autoExport: e.target.value })} placeholder="Off" />
+                <Button variant="outline" size="icon" onClick={handleSelectExportFolder}>
+                  <FolderOpen className="h-4 w-4" />
+                </Button>
+              </div>
+            </div>
+            <div className="flex items-center gap-2">
+              <Switch id="collectionDownload" checked={form.autoDownload} onCheckedChange={(checked) => updateForm({ autoDownload: checked })} />
+              <Label htmlFor="collectionDownload">Download new matches</Label>
+            </div>
+            <DialogFooter>
+              <Button variant="outline" onClick={() => setForm(null)}>Cancel</Button>
+              <Button onClick={handleSave} disabled={!form.name.trim()}>Save</Button>
+            </DialogFooter>
+          </div>
+        ) : (
+          <div className="space-y-3">
+            {collections.length === 0 && (
+              <p className="text-sm text-muted-foreground">No collections yet.</p>
+            )}
+            {collections.map((c) => (
+              <div key={c.id} className="flex items-center gap-3 rounded-lg border p-3">
+                <div className="flex-1 min-w-0">
+                  <div className="flex items-center gap-2">
+                    <p className="font-medium truncate">{c.name}</p>
+                    {c.last_run && <Badge variant="secondary">{c.last_matches.toLocaleString()}</Badge>}
+                    {c.auto_export && <Badge variant="outline">Export</Badge>}
+                    {c.auto_download && <Badge variant="outline">Download</Badge>}
+                  </div>
+                  <p className="text-xs text-muted-foreground truncate">{describeQuery(c.query)}</p>
+                </div>
+                <Button variant="outline" size="icon" onClick={() => handleEvaluate(c)}>
+                  <Play className="h-4 w-4" />
+                </Button>
+                <Button variant="outline" size="icon" onClick={() => setForm(toForm(c))}>
+                  <Pencil className="h-4 w-4" />
+                </Button>
+                <Button variant="outline" size="icon" onClick={() => handleDelete(c)}>
+                  <Trash2 className="h-4 w-4" />
+                </Button>
+              </div>
+            ))}
+
+            {result && (
+              <div className="space-y-2">
+                <p className="text-sm font-medium">
+                  {result.collection.name}: {result.matches.length.toLocaleString()} matches, {result.new.toLocaleString()} new
+                </p>
+                <div className="max-h-64 overflow-y-auto rounded border divide-y">
+                  {result.matches.slice(0, MAX_SHOWN_MATCHES).map((m) => (
+                    <button
+                      key={m.media_key}
+                      type="button"
+                      className="flex w-full items-center gap-2 px-2 py-1 text-left text-xs hover:bg-muted/50"
+                      onClick={() => openExternal(`https://x.com/${m.author || m.username}/status/${m.tweet_id}`)}
+                    >
+                      <span className="w-20 shrink-0 text-muted-foreground">{m.date.slice(0, 10)}</span>
+                      <span className="w-28 shrink-0 truncate">@{m.author || m.username}</span>
+                      <span className="w-12 shrink-0">{m.type}</span>
+                      <span className="flex-1 truncate text-muted-foreground">{m.content}</span>
+                      <span className="shrink-0">{m.likes.toLocaleString()} likes</span>
+                      {m.new && <Badge variant="secondary">New</Badge>}
+                    </button>
+                  ))}
+                  {result.matches.length > MAX_SHOWN_MATCHES && (
+                    <p className="px-2 py-1 text-xs text-muted-foreground">
+                      and {(result.matches.length - MAX_SHOWN_MATCHES).toLocaleString()} more
+                    </p>
+                  )}
+                </div>
+              </div>
+            )}
+
+            <DialogFooter>
+              <Button variant="outline" onClick={() => setForm({ ...emptyForm })}>
+                <Plus className="h-4 w-4" />
+                New Collection
+              </Button>
+            </DialogFooter>
+          </div>
+        )}
+      </DialogContent>
+    </Dialog>
+  );
+}
@@ -0,0 +1,80 @@
+import { useEffect, useState } from "react";
+import { Button } from "@/components/ui/button";
+import { Checkbox } from "@/components/ui/checkbox";
+import { Label } from "@/components/ui/label";
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+} from "@/components/ui/dialog";
+import { EventsOn, EventsOff } from "../../wailsjs/runtime/runtime";
+import { ResolveConflict } from "../../wailsjs/go/main/App";
+
+interface FileConflict {
+  id: string;
+  tweet_id: number;
+  index: number;
+  path: string;
+  existing_size: number;
+  existing_mod_time: string;
+  new_size: number;
+}
+
+function formatSize(bytes: number): string {
+  if (bytes < 1024) return `${bytes} B`;
+  if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`;
+  return `${(bytes / (1024 * 1024)).toFixed(1)} MB`;
+}
+
+// Asks the user what to do when a download would replace an existing file with different content
+export function ConflictDialog() {
+  const [conflict, setConflict] = useState<FileConflict | null>(null);
+  const [applyToAll, setApplyToAll] = useState(false);
+
+  useEffect(() => {
+    EventsOn("download-conflict", (data: FileConflict) => {
+      setConflict(data);
+    });
+    return () => {
+      EventsOff("download-conflict");
+    };
+  }, []);
+
+  const resolve = async (action: "skip" | "overwrite" | "keep_both") => {
+    if (!conflict) return;
+    await ResolveConflict(conflict.id, action, applyToAll);
+    setConflict(null);
+    setApplyToAll(false);
+  };
+
+  return (
+    <Dialog open={conflict !== null} onOpenChange={(open) => { if (!open) resolve("skip"); }}>
+      <DialogContent>
+        <DialogHeader>
+          <DialogTitle>File Already Exists</DialogTitle>
+          <DialogDescription className="break-all">
+            {conflict?.path}
+          </DialogDescription>
+        </DialogHeader>
+        {conflict && (
+          <div className="text-sm text-muted-foreground space-y-1">
+            <p>Existing: {formatSize(conflict.existing_size)}, modified {new Date(conflict.existing_mod_time).toLocaleString()}</p>
+            <p>Downloaded: {formatSize(conflict.new_size)}</p>
+          </div>
+        )}
+        <div className="flex items-center gap-2">
+          <Checkbox id="conflict-apply-all" checked={applyToAll} onCheckedChange={(checked) => setApplyToAll(checked === true)} />
+          <Label htmlFor="conflict-apply-all">Apply to all remaining conflicts</Label>
+        </div>
+        <DialogFooter>
+          <Button variant="outline" onClick={() => resolve("skip")}>Keep Existing</Button>
+          <Button variant="outline" onClick={() => resolve("keep_both")}>Keep Both</Button>
+          <Button onClick={() => resolve("overwrite")}>Overwrite</Button>
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  );
+}
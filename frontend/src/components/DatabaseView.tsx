@@ -34,10 +34,12 @@ import {
   DropdownMenuItem,
   DropdownMenuTrigger,
 } from "@/components/ui/dropdown-menu";
-import { Trash2, FileInput, FileOutput, Pencil, Tag, Shuffle, X, XCircle, Download, StopCircle, Globe, Lock, Bookmark, Heart, Image, Images, Video, Film, FileText, Filter, AlertCircle, MoreVertical, FileBraces, CloudBackup, Search, LayoutGrid, Grid3X3, List, ArrowUpDown, ArrowUp, FolderOpen, Users, MessageSquare } from "lucide-react";
+import { Trash2, FileInput, FileOutput, Pencil, Tag, Shuffle, X, XCircle, Download, StopCircle, Globe, Lock, Bookmark, Heart, Image, Images, Video, Film, FileText, Filter, AlertCircle, MoreVertical, FileBraces, CloudBackup, Search, LayoutGrid, Grid3X3, List, ArrowUpDown, ArrowUp, FolderOpen, Users, MessageSquare, LockOpen, ShieldAlert, FolderInput, FileArchive, Merge, FilePen, Stamp, ListFilter, FileLock, FileKey } from "lucide-react";
 import { toastWithSound as toast } from "@/lib/toast-with-sound";
-import { getSettings } from "@/lib/settings";
+import { getSettings, getSFTPTarget, getS3Target, getWebDAVTarget, getDateZone } from "@/lib/settings";
 import { openExternal } from "@/lib/utils";
+import { retryFailedAction } from "@/lib/retry-failed";
+import { getAuthToken } from "@/lib/auth-tokens";
 import {
   GetAllAccountsFromDB,
   GetAccountFromDB,
@@ -45,6 +47,10 @@ import {
   SaveAccountToDB,
   ExportAccountJSON,
   ExportAccountsTXT,
+  ExportHTMLGallery,
+  ExportMarkdownNotes,
+  ExportHydrus,
+  ImportTwitterArchive,
   UpdateAccountGroup,
   GetAllGroups,
   DownloadMediaWithMetadata,
@@ -52,9 +58,22 @@ import {
   CheckFolderExists,
   OpenFolder,
   GetFolderPath,
+  SetAccountSensitive,
+  GetLockStatus,
+  UnlockContent,
+  LockContent,
+  SelectFolder,
+  MoveArchive,
+  MergeArchives,
+  ReorganizeArchive,
+  UndoReorganize,
+  BackfillMetadata,
+  EncryptArchive,
+  DecryptArchive,
 } from "../../wailsjs/go/main/App";
 import { EventsOn, EventsOff } from "../../wailsjs/runtime/runtime";
-import { main } from "../../wailsjs/go/models";
+import { main, backend } from "../../wailsjs/go/models";
+import { CollectionsDialog } from "@/components/CollectionsDialog";
 
 interface DownloadProgress {
   current: number;
@@ -129,10 +148,19 @@ export function DatabaseView({ onLoadAccount, onUpdateSelected }: DatabaseViewPr
   const [editGroupName, setEditGroupName] = useState("");
   const [editGroupColor, setEditGroupColor] = useState("");
   const [clearAllDialogOpen, setClearAllDialogOpen] = useState(false);
+  const [lockStatus, setLockStatus] = useState<{ enabled: boolean; locked: boolean }>({ enabled: false, locked: false });
+  const [unlockDialogOpen, setUnlockDialogOpen] = useState(false);
+  const [unlockPassphrase, setUnlockPassphrase] = useState("");
+  const [collectionsOpen, setCollectionsOpen] = useState(false);
+  const [reorganizeAccount, setReorganizeAccount] = useState<AccountListItem | null>(null);
+  const [reorganizeFrom, setReorganizeFrom] = useState("");
+  const [reorganizeTo, setReorganizeTo] = useState("");
+  const [reorganizePlan, setReorganizePlan] = useState<backend.ReorganizeResult | null>(null);
   const [isDownloading, setIsDownloading] = useState(false);
   const [downloadingAccountId, setDownloadingAccountId] = useState<number | null>(null);
   const [downloadProgress, setDownloadProgress] = useState<DownloadProgress | null>(null);
   const [isBulkDownloading, setIsBulkDownloading] = useState(false);
+  const [importingArchive, setImportingArchive] = useState(false);
   const [bulkDownloadCurrent, setBulkDownloadCurrent] = useState(0);
   const [bulkDownloadTotal, setBulkDownloadTotal] = useState(0);
   const stopBulkDownloadRef = useRef(false);
@@ -166,6 +194,7 @@ export function DatabaseView({ onLoadAccount, onUpdateSelected }: DatabaseViewPr
   const loadAccounts = async () => {
     setLoading(true);
     try {
+      setLockStatus(await GetLockStatus());
       const data = await GetAllAccountsFromDB();
       setAccounts(data || []);
       const groupsData = await GetAllGroups();
@@ -326,6 +355,207 @@ export function DatabaseView({ onLoadAccount, onUpdateSelected }: DatabaseViewPr
     }
   };
 
+  const handleToggleSensitive = async (account: AccountListItem) => {
+    try {
+      await SetAccountSensitive(account.id, !account.sensitive);
+      toast.success(account.sensitive ? `@${account.username} is no longer sensitive` : `@${account.username} marked as sensitive`);
+      loadAccounts();
+    } catch (error) {
+      toast.error("Failed to update account");
+    }
+  };
+
+  const handleMoveArchive = async (account: AccountListItem) => {
+    const settings = getSettings();
+    // The archive's current download folder: where it was last downloaded to, or the current one
+    const archivePath = account.archive_path;
+    const oldRoot = archivePath
+      ? archivePath.slice(0, Math.max(archivePath.lastIndexOf("/"), archivePath.lastIndexOf("\\")))
+      : settings.downloadPath;
+    const newRoot = await SelectFolder(oldRoot);
+    if (!newRoot || newRoot === oldRoot) {
+      return;
+    }
+
+    toast.info(`Moving @${account.username}, verifying every file...`);
+    try {
+      const result = await MoveArchive(account.username, oldRoot, newRoot);
+      toast.success(`Moved ${result.files} files to ${result.target}`);
+      loadAccounts();
+    } catch (error) {
+      toast.error(`Failed to move archive: ${error}`);
+    }
+  };
+
+  const handleMergeArchive = async (account: AccountListItem) => {
+    const settings = getSettings();
+    const folder = account.archive_path || (await GetFolderPath(settings.downloadPath, account.username));
+    // Another copy of the account's folder, e.g. from another machine
+    const source = await SelectFolder(settings.downloadPath);
+    if (!source || source === folder) {
+      return;
+    }
+
+    toast.info(`Merging into @${account.username}, verifying every copied file...`);
+    try {
+      const result = await MergeArchives(source, folder);
+      const details = [`${result.duplicates} already there`];
+      if (result.kept_both > 0) details.push(`${result.kept_both} kept next to a different file`);
+      if (result.failed_copy > 0) details.push(`${result.failed_copy} failed`);
+      toast.success(`Merged ${result.copied} files into ${result.target}`, { description: details.join(", ") });
+    } catch (error) {
+      toast.error(`Failed to merge archive: ${error}`);
+    }
+  };
+
+  const handleOpenReorganize = (account: AccountListItem) => {
+    // Usually the files were saved with an older template, and the settings have the new one
+    setReorganizeAccount(account);
+    setReorganizeFrom("{username}_{timestamp}_{tweet_id}_{index}");
+    setReorganizeTo(getSettings().filenameTemplate || "");
+    setReorganizePlan(null);
+  };
+
+  const reorganizeRequest = (dryRun: boolean) => {
+    const settings = getSettings();
+    return new backend.ReorganizeRequest({
+      username: reorganizeAccount?.username || "",
+      output_dir: "",
+      from_template: reorganizeFrom,
+      to_template: reorganizeTo,
+      from_date_zone: getDateZone(settings),
+      to_date_zone: getDateZone(settings),
+      dry_run: dryRun,
+    });
+  };
+
+  const handlePreviewReorganize = async () => {
+    try {
+      setReorganizePlan(await ReorganizeArchive(reorganizeRequest(true)));
+    } catch (error) {
+      toast.error(`Failed to plan renames: ${error}`);
+    }
+  };
+
+  const handleReorganize = async () => {
+    if (!reorganizeAccount) return;
+    const username = reorganizeAccount.username;
+    try {
+      const result = await ReorganizeArchive(reorganizeRequest(false));
+      setReorganizeAccount(null);
+      const details = [`${result.unchanged} unchanged`];
+      if (result.conflicts.length > 0) details.push(`${result.conflicts.length} skipped, name taken`);
+      if (result.failed > 0) details.push(`${result.failed} failed`);
+      toast.success(`Renamed ${result.moves.length} files of @${username}`, {
+        description: details.join(", "),
+        action: result.journal_id ? {
+          label: "Undo",
+          onClick: async () => {
+            try {
+              const undone = await UndoReorganize(result.journal_id || "");
+              toast.success(`Renamed ${undone.moves.length} files of @${username} back`);
+            } catch (error) {
+              toast.error(`Failed to undo: ${error}`);
+            }
+          },
+        } : undefined,
+      });
+    } catch (error) {
+      toast.error(`Failed to rename files: ${error}`);
+    }
+  };
+
+  const handleBackfillMetadata = async (account: AccountListItem) => {
+    const settings = getSettings();
+    const folder = account.archive_path || (await GetFolderPath(settings.downloadPath, account.username));
+    toast.info(`Embedding metadata into the files of @${account.username}...`);
+    try {
+      const result = await BackfillMetadata(folder, getDateZone(settings));
+      const details = [`${result.skipped} already had it`];
+      if (result.unmatched > 0) details.push(`${result.unmatched} without a stored tweet`);
+      if (result.failed > 0) details.push(`${result.failed} failed`);
+      toast.success(`Embedded metadata into ${result.embedded} files`, { description: details.join(", ") });
+    } catch (error) {
+      toast.error(`Failed to embed metadata: ${error}`);
+    }
+  };
+
+  const handleEncryptArchive = async (account: AccountListItem, encrypt: boolean) => {
+    const settings = getSettings();
+    const folder = account.archive_path || (await GetFolderPath(settings.downloadPath, account.username));
+    try {
+      const result = encrypt ? await EncryptArchive(folder) : await DecryptArchive(folder);
+      const verb = encrypt ? "Encrypted" : "Decrypted";
+      if (result.failed > 0) {
+        toast.warning(`${verb} ${result.files} files of @${account.username}`, { description: `${result.failed} failed` });
+      } else {
+        toast.success(`${verb} ${result.files} files of @${account.username}`);
+      }
+    } catch (error) {
+      toast.error(`Failed to ${encrypt ? "encrypt" : "decrypt"} files: ${error}`);
+    }
+  };
+
+  const handleExportGallery = async (account: AccountListItem) => {
+    const settings = getSettings();
+    const folder = account.archive_path || (await GetFolderPath(settings.downloadPath, account.username));
+    toast.info(`Exporting gallery of @${account.username}...`);
+    try {
+      const result = await ExportHTMLGallery(folder);
+      toast.success(`Exported ${result.media} media to ${result.path}`);
+    } catch (error) {
+      toast.error(`Failed to export gallery: ${error}`);
+    }
+  };
+
+  const handleExportMarkdown = async (account: AccountListItem) => {
+    const settings = getSettings();
+    const folder = account.archive_path || (await GetFolderPath(settings.downloadPath, account.username));
+    const vaultDir = await SelectFolder(settings.downloadPath);
+    if (!vaultDir) {
+      return;
+    }
+    try {
+      const result = await ExportMarkdownNotes(folder, vaultDir);
+      toast.success(`Wrote ${result.written} notes to ${result.folder}` + (result.skipped > 0 ? ` (${result.skipped} already there)` : ""));
+    } catch (error) {
+      toast.error(`Failed to export notes: ${error}`);
+    }
+  };
+
+  const handleExportHydrus = async (account: AccountListItem) => {
+    const settings = getSettings();
+    const folder = account.archive_path || (await GetFolderPath(settings.downloadPath, account.username));
+    const exportDir = await SelectFolder(settings.downloadPath);
+    if (!exportDir) {
+      return;
+    }
+    try {
+      const result = await ExportHydrus(folder, exportDir);
+      toast.success(`Exported ${result.exported} files with tags to ${result.folder}` + (result.skipped > 0 ? ` (${result.skipped} already there)` : ""));
+    } catch (error) {
+      toast.error(`Failed to export for Hydrus: ${error}`);
+    }
+  };
+
+  const handleUnlock = async () => {
+    try {
+      await UnlockContent(unlockPassphrase);
+      setUnlockDialogOpen(false);
+      setUnlockPassphrase("");
+      toast.success("Unlocked");
+      loadAccounts();
+    } catch (error) {
+      toast.error("Wrong passphrase");
+    }
+  };
+
+  const handleLock = async () => {
+    await LockContent();
+    toast.info("Sensitive accounts hidden");
+    loadAccounts();
+  };
+
   const handleDelete = async (id: number, username: string) => {
     try {
       await DeleteAccountFromDB(id);
@@ -392,21 +622,61 @@ export function DatabaseView({ onLoadAccount, onUpdateSelected }: DatabaseViewPr
       setDownloadProgress({ current: 0, total: timeline.length, percent: 0 });
 
       const request = new main.DownloadMediaWithMetadataRequest({
-        items: timeline.map((item: { url: string; date: string; tweet_id: string; type: string; original_filename?: string; author_username?: string }) => new main.MediaItemRequest({
+        items: timeline.map((item: { url: string; date: string; tweet_id: string; type: string; original_filename?: string; author_username?: string; content?: string; favorite_count?: number; retweet_count?: number; reply_count?: number; view_count?: number; bookmark_count?: number; media_index?: number }) => new main.MediaItemRequest({
           url: item.url,
           date: item.date,
           tweet_id: item.tweet_id,
           type: item.type,
           original_filename: item.original_filename || "",
           author_username: item.author_username || "",
+          content: item.content || "",
+          favorite_count: item.favorite_count || 0,
+          retweet_count: item.retweet_count || 0,
+          reply_count: item.reply_count || 0,
+          view_count: item.view_count || 0,
+          bookmark_count: item.bookmark_count || 0,
+          media_index: item.media_index || 0,
         })),
         output_dir: outputDir,
         username: actualUsername,
         proxy: settings.proxy || "",
+        notify: settings.notificationsEnabled,
+        conflict_policy: settings.conflictPolicy,
+        max_archive_gb: settings.archiveLimitGB || 0,
+        archive_cap: settings.archiveLimitPolicy,
+        max_file_mb: settings.maxFileMB || 0,
+        max_job_gb: settings.maxJobGB || 0,
+        archive: settings.archiveOutput === "off" ? "" : settings.archiveOutput,
+        order: settings.newestFirst ? "newest_first" : "",
+        grace_minutes: settings.graceMinutes || 0,
+        confirm_above_gb: settings.confirmAboveGB || 0,
+        min_free_gb: settings.minFreeGB ?? 1,
+        video_preview: settings.videoPreview === "off" ? "" : settings.videoPreview,
+        sftp: getSFTPTarget(settings),
+        s3: getS3Target(settings),
+        webdav: getWebDAVTarget(settings),
+        file_hook: settings.fileHook || "",
+        batch_hook: settings.batchHook || "",
+        convert_webp: settings.convertWebP === "off" ? "" : settings.convertWebP,
+        webp_quality: settings.webpQuality || 0,
+        validate_media: settings.validateMedia,
+        wayback_fallback: settings.waybackFallback,
+        tweets_jsonl: settings.tweetsJsonl,
+        tweets_txt: settings.tweetsTxt,
+        date_zone: getDateZone(settings),
+        filename_template: settings.filenameTemplate || "",
+        collision_suffix: settings.collisionSuffix || "counter",
+        protected_fallback: settings.protectedFolderFallback,
+        auth_token: getAuthToken("public"),
       });
 
       const response = await DownloadMediaWithMetadata(request);
 
+      if (response.declined) {
+        toast.info(`Download cancelled for @${username}`);
+        return;
+      }
+
       if (response.success) {
         const parts: string[] = [];
         if (response.downloaded > 0) {
@@ -418,11 +688,19 @@ export function DatabaseView({ onLoadAccount, onUpdateSelected }: DatabaseViewPr
         if (response.failed > 0) {
           parts.push(`${response.failed} failed`);
         }
+        if (response.recovered) {
+          parts.push(`${response.recovered} recovered from the Wayback Machine`);
+        }
+        if (response.missing) {
+          parts.push(`${response.missing} gone (listed in missing.csv)`);
+        }
         const message = parts.length > 0 ? `${parts.join(', ')} for @${username}` : `Download completed for @${username}`;
         
         // Use info toast if only skipped files (no downloaded, no failed)
         if (response.downloaded === 0 && response.failed === 0 && response.skipped > 0) {
           toast.info(message);
+        } else if (response.failed > 0 && response.queue_id) {
+          toast.success(message, { action: retryFailedAction(response.queue_id) });
         } else {
           toast.success(message);
         }
@@ -511,17 +789,51 @@ export function DatabaseView({ onLoadAccount, onUpdateSelected }: DatabaseViewPr
         const actualUsername = data.account_info?.name || account.username;
 
         const request = new main.DownloadMediaWithMetadataRequest({
-          items: timeline.map((item: { url: string; date: string; tweet_id: string; type: string; author_username?: string; original_filename?: string }) => new main.MediaItemRequest({
+          items: timeline.map((item: { url: string; date: string; tweet_id: string; type: string; author_username?: string; original_filename?: string; content?: string; favorite_count?: number; retweet_count?: number; reply_count?: number; view_count?: number; bookmark_count?: number; media_index?: number }) => new main.MediaItemRequest({
             url: item.url,
             date: item.date,
             tweet_id: item.tweet_id,
             type: item.type,
             author_username: item.author_username || "",
             original_filename: item.original_filename || "",
+            content: item.content || "",
+            favorite_count: item.favorite_count || 0,
+            retweet_count: item.retweet_count || 0,
+            reply_count: item.reply_count || 0,
+            view_count: item.view_count || 0,
+            bookmark_count: item.bookmark_count || 0,
+            media_index: item.media_index || 0,
           })),
           output_dir: outputDir,
           username: actualUsername,
           proxy: settings.proxy || "",
+          conflict_policy: settings.conflictPolicy,
+          max_archive_gb: settings.archiveLimitGB || 0,
+          archive_cap: settings.archiveLimitPolicy,
+          max_file_mb: settings.maxFileMB || 0,
+          max_job_gb: settings.maxJobGB || 0,
+          archive: settings.archiveOutput === "off" ? "" : settings.archiveOutput,
+          order: settings.newestFirst ? "newest_first" : "",
+          grace_minutes: settings.graceMinutes || 0,
+          confirm_above_gb: settings.confirmAboveGB || 0,
+          min_free_gb: settings.minFreeGB ?? 1,
+          video_preview: settings.videoPreview === "off" ? "" : settings.videoPreview,
+          sftp: getSFTPTarget(settings),
+          s3: getS3Target(settings),
+          webdav: getWebDAVTarget(settings),
+          file_hook: settings.fileHook || "",
+          batch_hook: settings.batchHook || "",
+          convert_webp: settings.convertWebP === "off" ? "" : settings.convertWebP,
+          webp_quality: settings.webpQuality || 0,
+          validate_media: settings.validateMedia,
+          wayback_fallback: settings.waybackFallback,
+          tweets_jsonl: settings.tweetsJsonl,
+          tweets_txt: settings.tweetsTxt,
+          date_zone: getDateZone(settings),
+          filename_template: settings.filenameTemplate || "",
+          collision_suffix: settings.collisionSuffix || "counter",
+          protected_fallback: settings.protectedFolderFallback,
+          auth_token: getAuthToken("public"),
         });
 
         const response = await DownloadMediaWithMetadata(request);
@@ -636,6 +948,29 @@ export function DatabaseView({ onLoadAccount, onUpdateSelected }: DatabaseViewPr
     });
   };
 
+  const handleImportArchive = async () => {
+    setImportingArchive(true);
+    try {
+      const result = await ImportTwitterArchive(getAuthToken("public"));
+      if (!result) {
+        return;
+      }
+      let message = `Imported @${result.username}: ${result.media} media from ${result.tweets} tweets`;
+      if (result.likes > 0) {
+        message += `, ${result.like_media} media from ${result.likes_resolved} likes`;
+      }
+      toast.success(message);
+      if (result.likes_pending > 0) {
+        toast.info(`${result.likes_pending} likes still to look up${result.rate_limited ? " (rate limited)" : ""}, import the archive again later to continue`);
+      }
+      loadAccounts();
+    } catch (error) {
+      toast.error(`Failed to import archive: ${error}`);
+    } finally {
+      setImportingArchive(false);
+    }
+  };
+
   const handleImport = () => {
     const input = document.createElement("input");
     input.type = "file";
@@ -788,6 +1123,24 @@ export function DatabaseView({ onLoadAccount, onUpdateSelected }: DatabaseViewPr
           </div>
         </div>
         <div className="flex items-center gap-2">
+          {lockStatus.enabled && (
+            <Tooltip>
+              <TooltipTrigger asChild>
+                <Button variant="outline" size="icon" onClick={lockStatus.locked ? () => setUnlockDialogOpen(true) : handleLock}>
+                  {lockStatus.locked ? <Lock className="h-4 w-4" /> : <LockOpen className="h-4 w-4" />}
+                </Button>
+              </TooltipTrigger>
+              <TooltipContent>{lockStatus.locked ? "Unlock Sensitive Accounts" : "Hide Sensitive Accounts"}</TooltipContent>
+            </Tooltip>
+          )}
+          <Tooltip>
+            <TooltipTrigger asChild>
+              <Button variant="outline" size="icon" onClick={() => setCollectionsOpen(true)}>
+                <ListFilter className="h-4 w-4" />
+              </Button>
+            </TooltipTrigger>
+            <TooltipContent>Smart Collections</TooltipContent>
+          </Tooltip>
           <Tooltip>
             <TooltipTrigger asChild>
               <Button variant="outline" size="icon" onClick={handleImport}>
@@ -796,6 +1149,14 @@ export function DatabaseView({ onLoadAccount, onUpdateSelected }: DatabaseViewPr
             </TooltipTrigger>
             <TooltipContent>Import JSON</TooltipContent>
           </Tooltip>
+          <Tooltip>
+            <TooltipTrigger asChild>
+              <Button variant="outline" size="icon" onClick={handleImportArchive} disabled={importingArchive}>
+                {importingArchive ? <Spinner /> : <FileArchive className="h-4 w-4" />}
+              </Button>
+            </TooltipTrigger>
+            <TooltipContent>Import X Data Export (ZIP)</TooltipContent>
+          </Tooltip>
           <DropdownMenu>
             <Tooltip>
               <TooltipTrigger asChild>
@@ -1249,6 +1610,50 @@ export function DatabaseView({ onLoadAccount, onUpdateSelected }: DatabaseViewPr
                             <FileOutput className="h-4 w-4 mr-2" />
                             Export JSON
                           </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleExportGallery(account)}>
+                            <Images className="h-4 w-4 mr-2" />
+                            Export HTML Gallery
+                          </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleExportMarkdown(account)}>
+                            <FileText className="h-4 w-4 mr-2" />
+                            Export Markdown Notes
+                          </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleExportHydrus(account)}>
+                            <Tag className="h-4 w-4 mr-2" />
+                            Export for Hydrus
+                          </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleBackfillMetadata(account)}>
+                            <Stamp className="h-4 w-4 mr-2" />
+                            Embed Metadata
+                          </DropdownMenuItem>
+                          {account.sensitive && (
+                            <>
+                              <DropdownMenuItem onClick={() => handleEncryptArchive(account, true)}>
+                                <FileLock className="h-4 w-4 mr-2" />
+                                Encrypt Files
+                              </DropdownMenuItem>
+                              <DropdownMenuItem onClick={() => handleEncryptArchive(account, false)}>
+                                <FileKey className="h-4 w-4 mr-2" />
+                                Decrypt Files
+                              </DropdownMenuItem>
+                            </>
+                          )}
+                          <DropdownMenuItem onClick={() => handleMoveArchive(account)}>
+                            <FolderInput className="h-4 w-4 mr-2" />
+                            Move Archive
+                          </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleMergeArchive(account)}>
+                            <Merge className="h-4 w-4 mr-2" />
+                            Merge Archive Copy
+                          </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleOpenReorganize(account)}>
+                            <FilePen className="h-4 w-4 mr-2" />
+                            Reorganize Files
+                          </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleToggleSensitive(account)}>
+                            <ShieldAlert className="h-4 w-4 mr-2" />
+                            {account.sensitive ? "Unmark Sensitive" : "Mark Sensitive"}
+                          </DropdownMenuItem>
                           <DropdownMenuItem
                             onClick={() => handleDelete(account.id, account.username)}
                             className="text-destructive focus:text-destructive"
@@ -1413,6 +1818,50 @@ export function DatabaseView({ onLoadAccount, onUpdateSelected }: DatabaseViewPr
                             <FileOutput className="h-4 w-4 mr-2" />
                             Export JSON
                           </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleExportGallery(account)}>
+                            <Images className="h-4 w-4 mr-2" />
+                            Export HTML Gallery
+                          </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleExportMarkdown(account)}>
+                            <FileText className="h-4 w-4 mr-2" />
+                            Export Markdown Notes
+                          </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleExportHydrus(account)}>
+                            <Tag className="h-4 w-4 mr-2" />
+                            Export for Hydrus
+                          </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleBackfillMetadata(account)}>
+                            <Stamp className="h-4 w-4 mr-2" />
+                            Embed Metadata
+                          </DropdownMenuItem>
+                          {account.sensitive && (
+                            <>
+                              <DropdownMenuItem onClick={() => handleEncryptArchive(account, true)}>
+                                <FileLock className="h-4 w-4 mr-2" />
+                                Encrypt Files
+                              </DropdownMenuItem>
+                              <DropdownMenuItem onClick={() => handleEncryptArchive(account, false)}>
+                                <FileKey className="h-4 w-4 mr-2" />
+                                Decrypt Files
+                              </DropdownMenuItem>
+                            </>
+                          )}
+                          <DropdownMenuItem onClick={() => handleMoveArchive(account)}>
+                            <FolderInput className="h-4 w-4 mr-2" />
+                            Move Archive
+                          </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleMergeArchive(account)}>
+                            <Merge className="h-4 w-4 mr-2" />
+                            Merge Archive Copy
+                          </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleOpenReorganize(account)}>
+                            <FilePen className="h-4 w-4 mr-2" />
+                            Reorganize Files
+                          </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleToggleSensitive(account)}>
+                            <ShieldAlert className="h-4 w-4 mr-2" />
+                            {account.sensitive ? "Unmark Sensitive" : "Mark Sensitive"}
+                          </DropdownMenuItem>
                           <DropdownMenuItem
                             onClick={() => handleDelete(account.id, account.username)}
                             className="text-destructive focus:text-destructive"
@@ -1627,6 +2076,50 @@ export function DatabaseView({ onLoadAccount, onUpdateSelected }: DatabaseViewPr
                         <FileOutput className="h-4 w-4 mr-2" />
                         Export JSON
                       </DropdownMenuItem>
+                      <DropdownMenuItem onClick={() => handleExportGallery(account)}>
+                        <Images className="h-4 w-4 mr-2" />
+                        Export HTML Gallery
+                      </DropdownMenuItem>
+                      <DropdownMenuItem onClick={() => handleExportMarkdown(account)}>
+                        <FileText className="h-4 w-4 mr-2" />
+                        Export Markdown Notes
+                      </DropdownMenuItem>
+                      <DropdownMenuItem onClick={() => handleExportHydrus(account)}>
+                        <Tag className="h-4 w-4 mr-2" />
+                        Export for Hydrus
+                      </DropdownMenuItem>
+                      <DropdownMenuItem onClick={() => handleBackfillMetadata(account)}>
+                        <Stamp className="h-4 w-4 mr-2" />
+                        Embed Metadata
+                      </DropdownMenuItem>
+                      {account.sensitive && (
+                        <>
+                          <DropdownMenuItem onClick={() => handleEncryptArchive(account, true)}>
+                            <FileLock className="h-4 w-4 mr-2" />
+                            Encrypt Files
+                          </DropdownMenuItem>
+                          <DropdownMenuItem onClick={() => handleEncryptArchive(account, false)}>
+                            <FileKey className="h-4 w-4 mr-2" />
+                            Decrypt Files
+                          </DropdownMenuItem>
+                        </>
+                      )}
+                      <DropdownMenuItem onClick={() => handleMoveArchive(account)}>
+                        <FolderInput className="h-4 w-4 mr-2" />
+                        Move Archive
+                      </DropdownMenuItem>
+                      <DropdownMenuItem onClick={() => handleMergeArchive(account)}>
+                        <Merge className="h-4 w-4 mr-2" />
+                        Merge Archive Copy
+                      </DropdownMenuItem>
+                      <DropdownMenuItem onClick={() => handleOpenReorganize(account)}>
+                        <FilePen className="h-4 w-4 mr-2" />
+                        Reorganize Files
+                      </DropdownMenuItem>
+                      <DropdownMenuItem onClick={() => handleToggleSensitive(account)}>
+                        <ShieldAlert className="h-4 w-4 mr-2" />
+                        {account.sensitive ? "Unmark Sensitive" : "Mark Sensitive"}
+                      </DropdownMenuItem>
                       <Dialog>
                         <DialogTrigger asChild>
                           <DropdownMenuItem
@@ -1705,6 +2198,83 @@ export function DatabaseView({ onLoadAccount, onUpdateSelected }: DatabaseViewPr
       )}
 
       {/* Edit Group Dialog */}
+      <Dialog open={unlockDialogOpen} onOpenChange={(open) => { setUnlockDialogOpen(open); if (!open) setUnlockPassphrase(""); }}>
+        <DialogContent>
+          <DialogHeader>
+            <DialogTitle>Unlock Sensitive Accounts</DialogTitle>
+            <DialogDescription>
+              Enter the content lock passphrase to show accounts marked as sensitive.
+            </DialogDescription>
+          </DialogHeader>
+          <Input
+            type="password"
+            placeholder="Passphrase"
+            value={unlockPassphrase}
+            onChange={(e) => setUnlockPassphrase(e.target.value)}
+            onKeyDown={(e) => { if (e.key === "Enter") handleUnlock(); }}
+            autoFocus
+          />
+          <DialogFooter>
+            <Button variant="outline" onClick={() => setUnlockDialogOpen(false)}>Cancel</Button>
+            <Button onClick={handleUnlock}>Unlock</Button>
+          </DialogFooter>
+        </DialogContent>
+      </Dialog>
+
+      <CollectionsDialog open={collectionsOpen} onOpenChange={setCollectionsOpen} />
+
+      <Dialog open={!!reorganizeAccount} onOpenChange={(open) => !open && setReorganizeAccount(null)}>
+        <DialogContent>
+          <DialogHeader>
+            <DialogTitle>Reorganize Files of @{reorganizeAccount?.username}</DialogTitle>
+            <DialogDescription>
+              Rename the saved files to a new file name template. Files whose new name is taken are skipped, and the renames can be undone.
+            </DialogDescription>
+          </DialogHeader>
+          <div className="space-y-4 py-2">
+            <div className="space-y-2">
+              <Label htmlFor="reorganizeFrom">Current Template</Label>
+              <Input
+                id="reorganizeFrom"
+                value={reorganizeFrom}
+                onChange={(e) => { setReorganizeFrom(e.target.value); setReorganizePlan(null); }}
+              />
+            </div>
+            <div className="space-y-2">
+              <Label htmlFor="reorganizeTo">New Template</Label>
+              <Input
+                id="reorganizeTo"
+                value={reorganizeTo}
+                onChange={(e) => { setReorganizeTo(e.target.value); setReorganizePlan(null); }}
+              />
+            </div>
+            {reorganizePlan && (
+              <div className="space-y-1 text-sm">
+                <p>
+                  {formatNumberWithComma(reorganizePlan.moves.length)} to rename, {formatNumberWithComma(reorganizePlan.unchanged)} unchanged, {formatNumberWithComma(reorganizePlan.conflicts.length)} skipped, {formatNumberWithComma(reorganizePlan.not_found)} not found
+                </p>
+                <div className="max-h-40 overflow-y-auto rounded border p-2 font-mono text-xs text-muted-foreground">
+                  {reorganizePlan.moves.slice(0, 50).map((move) => (
+                    <div key={move.from} className="truncate" title={`${move.from} -> ${move.to}`}>
+                      {move.to.split(/[\\/]/).pop()}
+                    </div>
+                  ))}
+                  {reorganizePlan.moves.length > 50 && <div>and {formatNumberWithComma(reorganizePlan.moves.length - 50)} more</div>}
+                </div>
+              </div>
+            )}
+          </div>
+          <DialogFooter>
+            <Button variant="outline" onClick={() => setReorganizeAccount(null)}>Cancel</Button>
+            {reorganizePlan && reorganizePlan.moves.length > 0 ? (
+              <Button onClick={handleReorganize}>Rename {formatNumberWithComma(reorganizePlan.moves.length)} Files</Button>
+            ) : (
+              <Button onClick={handlePreviewReorganize} disabled={!reorganizeTo.trim()}>Preview</Button>
+            )}
+          </DialogFooter>
+        </DialogContent>
+      </Dialog>
+
       <Dialog open={!!editingAccount} onOpenChange={(open) => !open && setEditingAccount(null)}>
         <DialogContent className="[&>button]:hidden">
           <div className="absolute right-4 top-4">
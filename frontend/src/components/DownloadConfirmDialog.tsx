@@ -0,0 +1,100 @@
+import { useEffect, useState } from "react";
+import { Button } from "@/components/ui/button";
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+} from "@/components/ui/dialog";
+import { EventsOn, EventsOff } from "../../wailsjs/runtime/runtime";
+import { ConfirmDownload } from "../../wailsjs/go/main/App";
+
+interface SyncAuthor {
+  username: string;
+  new_files: number;
+}
+
+interface SyncDiff {
+  id: string;
+  username: string;
+  output_dir: string;
+  new_tweets: number;
+  new_files: number;
+  existing_files: number;
+  filtered_files: number;
+  new_files_by_type: Record<string, number>;
+  estimated_bytes: number;
+  measured_files: number;
+  authors?: SyncAuthor[];
+  threshold_bytes?: number;
+}
+
+const MAX_AUTHORS_SHOWN = 10;
+
+function formatSize(bytes: number): string {
+  if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`;
+  if (bytes < 1024 * 1024 * 1024) return `${(bytes / (1024 * 1024)).toFixed(1)} MB`;
+  return `${(bytes / (1024 * 1024 * 1024)).toFixed(2)} GB`;
+}
+
+// Shows the dry-run diff of a download above the confirmation threshold and asks whether to go ahead
+export function DownloadConfirmDialog() {
+  const [diff, setDiff] = useState<SyncDiff | null>(null);
+
+  useEffect(() => {
+    EventsOn("download-confirm", (data: SyncDiff) => {
+      setDiff(data);
+    });
+    return () => {
+      EventsOff("download-confirm");
+    };
+  }, []);
+
+  const answer = async (proceed: boolean) => {
+    if (!diff) return;
+    await ConfirmDownload(diff.id, proceed);
+    setDiff(null);
+  };
+
+  const types = diff ? Object.entries(diff.new_files_by_type || {}) : [];
+  const authors = diff?.authors || [];
+
+  return (
+    <Dialog open={diff !== null} onOpenChange={(open) => { if (!open) answer(false); }}>
+      <DialogContent>
+        <DialogHeader>
+          <DialogTitle>Large Download</DialogTitle>
+          <DialogDescription>
+            {diff && `@${diff.username} would add about ${formatSize(diff.estimated_bytes)}${diff.threshold_bytes ? ` (limit ${formatSize(diff.threshold_bytes)})` : ""}`}
+          </DialogDescription>
+        </DialogHeader>
+        {diff && (
+          <div className="text-sm text-muted-foreground space-y-1">
+            <p>{diff.new_tweets} new tweets, {diff.new_files} new files, {diff.existing_files} already downloaded</p>
+            {types.length > 0 && (
+              <p>{types.map(([type, count]) => `${count} ${type}`).join(", ")}</p>
+            )}
+            <p>Size measured for {diff.measured_files} of {diff.new_files} files, the rest is estimated</p>
+            {authors.length > 0 && (
+              <div>
+                <p>{authors.length} authors:</p>
+                <ul className="list-disc pl-5">
+                  {authors.slice(0, MAX_AUTHORS_SHOWN).map((author) => (
+                    <li key={author.username}>@{author.username} ({author.new_files} files)</li>
+                  ))}
+                  {authors.length > MAX_AUTHORS_SHOWN && <li>and {authors.length - MAX_AUTHORS_SHOWN} more</li>}
+                </ul>
+              </div>
+            )}
+          </div>
+        )}
+        <DialogFooter>
+          <Button variant="outline" onClick={() => answer(false)}>Cancel</Button>
+          <Button onClick={() => answer(true)}>Download</Button>
+        </DialogFooter>
+      </DialogContent>
+    </Dialog>
+  );
+}
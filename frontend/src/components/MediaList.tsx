@@ -15,6 +15,15 @@ import {
   TooltipContent,
   TooltipTrigger,
 } from "@/components/ui/tooltip";
+import {
+  Dialog,
+  DialogContent,
+  DialogDescription,
+  DialogFooter,
+  DialogHeader,
+  DialogTitle,
+} from "@/components/ui/dialog";
+import { Input } from "@/components/ui/input";
 
 import {
   Image,
@@ -29,6 +38,8 @@ import {
   Grid3X3,
   List,
   StopCircle,
+  Pause,
+  Play,
   Users,
   UserPlus,
   MessageSquare,
@@ -44,16 +55,23 @@ import {
   CheckCircle,
   XCircle,
   FileCheck,
+  Star,
+  Tags,
+  FileOutput,
+  Quote,
+  Reply,
 } from "lucide-react";
 import { Spinner } from "@/components/ui/spinner";
-import type { TimelineEntry, AccountInfo } from "@/types/api";
+import type { TimelineEntry, AccountInfo, TimelineStats } from "@/types/api";
 import { logger } from "@/lib/logger";
 import { toastWithSound as toast } from "@/lib/toast-with-sound";
-import { getSettings } from "@/lib/settings";
+import { getSettings, getSFTPTarget, getS3Target, getWebDAVTarget, getDateZone } from "@/lib/settings";
 import { openExternal } from "@/lib/utils";
-import { DownloadMediaWithMetadata, OpenFolder, IsFFmpegInstalled, ConvertGIFs, StopDownload, CheckFolderExists, CheckGifsFolderHasMP4 } from "../../wailsjs/go/main/App";
+import { retryFailedAction } from "@/lib/retry-failed";
+import { getAuthToken } from "@/lib/auth-tokens";
+import { DownloadMediaWithMetadata, DownloadSelection, OpenFolder, IsFFmpegInstalled, ConvertGIFs, ConvertFilesToGIF, SelectVideoFiles, StopDownload, PauseDownload, ResumeDownload, CheckFolderExists, CheckGifsFolderHasMP4, GetMediaLabels, SetMediaStarred, SetMediaTags, ExportLabeledMedia, SelectFolder } from "../../wailsjs/go/main/App";
 import { EventsOn, EventsOff } from "../../wailsjs/runtime/runtime";
-import { main } from "../../wailsjs/go/models";
+import { main, backend } from "../../wailsjs/go/models";
 
 interface DownloadProgress {
   current: number;
@@ -64,7 +82,7 @@ interface DownloadProgress {
 interface DownloadItemStatus {
   tweet_id: number;
   index: number;
-  status: "success" | "failed" | "skipped";
+  status: "success" | "failed" | "skipped" | "missing" | "recovered";
 }
 
 interface MediaListProps {
@@ -73,6 +91,7 @@ interface MediaListProps {
   totalUrls: number;
   fetchedMediaType?: string;
   newMediaCount?: number | null;
+  stats?: TimelineStats; // Breakdown computed by the backend, unknown for resumed fetches
 }
 
 function getThumbnailUrl(url: string): string {
@@ -142,8 +161,8 @@ function formatDate(dateStr: string): string {
     // Handle ISO format: 2022-12-30T03:21:36 -> 2022-12-30 • 03:21:36
     if (dateStr.includes("T")) {
       const [datePart, timePart] = dateStr.split("T");
-      // Remove timezone if present
-      const timeClean = timePart.split("+")[0].split("Z")[0];
+      // Remove timezone if present (Z, +02:00 or -05:00)
+      const timeClean = timePart.replace(/(Z|[+-]\d{2}:?\d{2})$/, "");
       return `${datePart} • ${timeClean}`;
     }
     return dateStr;
@@ -200,6 +219,19 @@ function formatNumberWithComma(num: number): string {
   return num.toLocaleString();
 }
 
+function formatSize(bytes: number): string {
+  if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(0)} KB`;
+  if (bytes < 1024 * 1024 * 1024) return `${(bytes / (1024 * 1024)).toFixed(0)} MB`;
+  return `${(bytes / (1024 * 1024 * 1024)).toFixed(1)} GB`;
+}
+
+// Tweet IDs don't fit in a number, so they're compared as BigInts
+function compareTweetIds(a: string, b: string): number {
+  const x = BigInt(a || 0);
+  const y = BigInt(b || 0);
+  return x > y ? 1 : x < y ? -1 : 0;
+}
+
 function getItemKey(item: TimelineEntry): string {
   // Use tweet_id + url hash for unique identification
   // This ensures each media item is uniquely identified even for same tweet_id
@@ -222,12 +254,14 @@ export function MediaList({
   totalUrls,
   fetchedMediaType = "all",
   newMediaCount = null,
+  stats,
 }: MediaListProps) {
   const [selectedItems, setSelectedItems] = useState<Set<number>>(new Set());
   const [sortBy, setSortBy] = useState<string>("date-desc");
   const [filterType, setFilterType] = useState<string>("all");
   const [viewMode, setViewMode] = useState<"large" | "small" | "list">("list");
   const [isDownloading, setIsDownloading] = useState(false);
+  const [isPaused, setIsPaused] = useState(false);
   const [downloadProgress, setDownloadProgress] = useState<DownloadProgress | null>(null);
   const [hasDownloaded, setHasDownloaded] = useState(false);
   const [isConverting, setIsConverting] = useState(false);
@@ -241,6 +275,11 @@ export function MediaList({
   const [failedItems, setFailedItems] = useState<Set<string>>(new Set());
   const [skippedItems, setSkippedItems] = useState<Set<string>>(new Set());
   const [downloadingItem, setDownloadingItem] = useState<string | null>(null);
+  // Stars and tags by media URL, and the label filter ("all", "starred" or "tag:<tag>")
+  const [labels, setLabels] = useState<Record<string, backend.MediaLabels>>({});
+  const [labelFilter, setLabelFilter] = useState<string>("all");
+  const [taggingItem, setTaggingItem] = useState<TimelineEntry | null>(null);
+  const [tagInput, setTagInput] = useState("");
   // Lazy loading: start with 10 thumbnails, load more on scroll
   const [visibleCount, setVisibleCount] = useState<number>(10);
   const loadMoreRef = useRef<HTMLDivElement>(null);
@@ -269,6 +308,14 @@ export function MediaList({
       });
     }
 
+    // Filter by star or tag
+    if (labelFilter === "starred") {
+      filtered = filtered.filter((item) => labels[item.url]?.starred);
+    } else if (labelFilter.startsWith("tag:")) {
+      const tag = labelFilter.slice(4);
+      filtered = filtered.filter((item) => labels[item.url]?.tags.includes(tag));
+    }
+
     // Sort
     filtered.sort((a, b) => {
       if (sortBy === "date-desc") {
@@ -279,12 +326,37 @@ export function MediaList({
         return Number(b.tweet_id) - Number(a.tweet_id);
       } else if (sortBy === "tweet-id-asc") {
         return Number(a.tweet_id) - Number(b.tweet_id);
+      } else if (sortBy === "thread") {
+        // Newest threads first, each in reading order
+        return compareTweetIds(b.conversation_id || b.tweet_id, a.conversation_id || a.tweet_id) || compareTweetIds(a.tweet_id, b.tweet_id);
       }
       return 0;
     });
 
     return filtered;
-  }, [timeline, filterType, sortBy]);
+  }, [timeline, filterType, sortBy, labels, labelFilter]);
+
+  // Load the stars and tags of the account
+  useEffect(() => {
+    GetMediaLabels(accountInfo.name)
+      .then((list) => {
+        const byURL: Record<string, backend.MediaLabels> = {};
+        for (const l of list || []) byURL[l.url] = l;
+        setLabels(byURL);
+      })
+      .catch(() => setLabels({}));
+    setLabelFilter("all");
+  }, [accountInfo.name]);
+
+  // Tags in use in this account, most used first
+  const accountTags = useMemo(() => {
+    const counts: Record<string, number> = {};
+    for (const l of Object.values(labels)) {
+      for (const tag of l.tags) counts[tag] = (counts[tag] || 0) + 1;
+    }
+    return Object.entries(counts).sort((a, b) => b[1] - a[1] || a[0].localeCompare(b[0]));
+  }, [labels]);
+  const starredCount = useMemo(() => Object.values(labels).filter((l) => l.starred).length, [labels]);
 
   // Reset visible count when filtered timeline changes
   useEffect(() => {
@@ -386,6 +458,17 @@ export function MediaList({
     };
   }, []);
 
+  // Log files moved to the trash to keep the archive under its size limit
+  useEffect(() => {
+    const unsubscribe = EventsOn("archive-pruned", (path: string) => {
+      logger.info(`Archive size limit: moved to trash ${path}`);
+    });
+    return () => {
+      EventsOff("archive-pruned");
+      unsubscribe();
+    };
+  }, []);
+
   // Listen for per-item download status events
   // Store current downloading item key for event listener (single download)
   const currentDownloadingItemKeyRef = useRef<string | null>(null);
@@ -411,17 +494,23 @@ export function MediaList({
         itemKey = `${String(status.tweet_id)}-${status.index}`;
       }
       
-      if (status.status === "success") {
+      if (status.status === "success" || status.status === "recovered") {
         setDownloadedItems((prev) => new Set(prev).add(itemKey));
         // Show toast for single download
         if (currentKey) {
-          toast.success("Downloaded");
+          toast.success(status.status === "recovered" ? "Recovered from the Wayback Machine" : "Downloaded");
         }
       } else if (status.status === "failed") {
         setFailedItems((prev) => new Set(prev).add(itemKey));
         if (currentKey) {
           toast.error("Download failed");
         }
+      } else if (status.status === "missing") {
+        // Dead link, listed in missing.csv
+        setFailedItems((prev) => new Set(prev).add(itemKey));
+        if (currentKey) {
+          toast.warning("Media is gone (deleted tweet or suspended account)");
+        }
       } else if (status.status === "skipped") {
         // Remove from downloaded if it was added, then add to skipped
         setDownloadedItems((prev) => {
@@ -534,21 +623,51 @@ export function MediaList({
 
     try {
       const settings = getSettings();
+      // The selected entries are sent as they are, the backend converts them to download items
+      const entries = itemsWithIndices.map(({ item }) => new backend.TimelineEntry(item));
       const request = new main.DownloadMediaWithMetadataRequest({
-        items: itemsWithIndices.map(({ item }) => new main.MediaItemRequest({
-          url: item.url,
-          date: item.date,
-          tweet_id: item.tweet_id,
-          type: item.type,
-          content: item.content || "",
-          original_filename: item.original_filename || "",
-          author_username: item.author_username || "",
-        })),
+        items: [],
         output_dir: getOutputDir(),
         username: accountInfo.name,
         proxy: settings.proxy || "",
+        orientation: settings.orientation === "all" ? "" : settings.orientation,
+        min_aspect_ratio: settings.minAspectRatio || 0,
+        notify: settings.notificationsEnabled,
+        conflict_policy: settings.conflictPolicy,
+        max_archive_gb: settings.archiveLimitGB || 0,
+        archive_cap: settings.archiveLimitPolicy,
+        max_file_mb: settings.maxFileMB || 0,
+        max_job_gb: settings.maxJobGB || 0,
+        archive: settings.archiveOutput === "off" ? "" : settings.archiveOutput,
+        order: settings.newestFirst ? "newest_first" : "",
+        grace_minutes: settings.graceMinutes || 0,
+        confirm_above_gb: settings.confirmAboveGB || 0,
+        min_free_gb: settings.minFreeGB ?? 1,
+        video_preview: settings.videoPreview === "off" ? "" : settings.videoPreview,
+        sftp: getSFTPTarget(settings),
+        s3: getS3Target(settings),
+        webdav: getWebDAVTarget(settings),
+        file_hook: settings.fileHook || "",
+        batch_hook: settings.batchHook || "",
+        convert_webp: settings.convertWebP === "off" ? "" : settings.convertWebP,
+        webp_quality: settings.webpQuality || 0,
+        validate_media: settings.validateMedia,
+        wayback_fallback: settings.waybackFallback,
+        tweets_jsonl: settings.tweetsJsonl,
+        tweets_txt: settings.tweetsTxt,
+        date_zone: getDateZone(settings),
+        filename_template: settings.filenameTemplate || "",
+        collision_suffix: settings.collisionSuffix || "counter",
+        protected_fallback: settings.protectedFolderFallback,
+        auth_token: getAuthToken("public"),
       });
-      const response = await DownloadMediaWithMetadata(request);
+      const response = await DownloadSelection(entries, request);
+
+      if (response.declined) {
+        logger.info("Download cancelled after reviewing the dry run");
+        toast.info("Download cancelled");
+        return;
+      }
 
       if (response.success) {
         const parts: string[] = [];
@@ -561,6 +680,12 @@ export function MediaList({
         if (response.failed > 0) {
           parts.push(`${response.failed} failed`);
         }
+        if (response.recovered) {
+          parts.push(`${response.recovered} recovered from the Wayback Machine`);
+        }
+        if (response.missing) {
+          parts.push(`${response.missing} gone (listed in missing.csv)`);
+        }
         const message = parts.length > 0 ? parts.join(', ') : 'Download completed';
         
         // Use info toast if only skipped files (no downloaded, no failed)
@@ -569,9 +694,12 @@ export function MediaList({
           toast.info(message);
         } else {
           logger.success(message);
-          toast.success(message);
+          toast.success(message, response.failed > 0 && response.queue_id ? { action: retryFailedAction(response.queue_id) } : undefined);
         }
         setHasDownloaded(true);
+        if (response.output_dir) {
+          toast.warning(`Download folder is protected by Controlled Folder Access, saved to ${response.output_dir}`);
+        }
         
         // Check if FFmpeg is installed for GIF conversion
         const installed = await IsFFmpegInstalled();
@@ -583,9 +711,16 @@ export function MediaList({
     } catch (error) {
       const errorMsg = error instanceof Error ? error.message : String(error);
       logger.error(`Download failed: ${errorMsg}`);
-      toast.error("Download failed");
+      // Errors with a code prefix explain what to do, show them in full
+      const coded = errorMsg.match(/^(folder_protected|disk_space_low|download_quota_reached|archive_prune_failed): (.*)$/s);
+      if (coded) {
+        toast.error(coded[2]);
+      } else {
+        toast.error("Download failed");
+      }
     } finally {
       setIsDownloading(false);
+      setIsPaused(false);
       setDownloadProgress(null);
       bulkDownloadKeyMapRef.current = new Map();
     }
@@ -603,6 +738,22 @@ export function MediaList({
     }
   };
 
+  const handleTogglePause = async () => {
+    try {
+      if (isPaused) {
+        await ResumeDownload("");
+        logger.info("Download resumed");
+      } else {
+        // Running transfers are cut off and continue where they stopped on resume
+        await PauseDownload("", true);
+        logger.info("Download paused");
+      }
+      setIsPaused(!isPaused);
+    } catch (error) {
+      console.error("Failed to pause download:", error);
+    }
+  };
+
   const handleOpenFolder = async () => {
     const settings = getSettings();
     const isBookmarks = accountInfo.nick === "My Bookmarks";
@@ -633,10 +784,79 @@ export function MediaList({
     }
   };
 
+  const mediaRef = (item: TimelineEntry) =>
+    new backend.MediaRef({
+      username: accountInfo.name,
+      author: item.author_username || "",
+      tweet_id: item.tweet_id,
+      url: item.url,
+    });
+
+  const updateLabels = (item: TimelineEntry, change: Partial<backend.MediaLabels>) => {
+    setLabels((prev) => {
+      const current = prev[item.url] || new backend.MediaLabels({ media_key: "", username: accountInfo.name, tweet_id: item.tweet_id, url: item.url, starred: false, tags: [] });
+      const next = { ...prev, [item.url]: new backend.MediaLabels({ ...current, ...change }) };
+      if (!next[item.url].starred && next[item.url].tags.length === 0) delete next[item.url];
+      return next;
+    });
+  };
+
+  const handleToggleStar = async (item: TimelineEntry) => {
+    const starred = !labels[item.url]?.starred;
+    try {
+      await SetMediaStarred(mediaRef(item), starred);
+      updateLabels(item, { starred });
+    } catch (error) {
+      toast.error(`Failed to star media: ${error}`);
+    }
+  };
+
+  const handleEditTags = (item: TimelineEntry) => {
+    setTaggingItem(item);
+    setTagInput((labels[item.url]?.tags || []).join(", "));
+  };
+
+  const handleSaveTags = async () => {
+    if (!taggingItem) return;
+    // Same folding as the backend, so the filter finds the tags right away
+    const tags = Array.from(new Set(tagInput.split(",").map((t) => t.trim().split(/\s+/).join(" ").toLowerCase()).filter(Boolean)));
+    try {
+      await SetMediaTags(mediaRef(taggingItem), tags);
+      updateLabels(taggingItem, { tags });
+      setTaggingItem(null);
+    } catch (error) {
+      toast.error(`Failed to tag media: ${error}`);
+    }
+  };
+
+  const handleExportLabeled = async () => {
+    const settings = getSettings();
+    const exportDir = await SelectFolder(settings.downloadPath);
+    if (!exportDir) return;
+    const filter = new backend.LabelFilter({
+      username: accountInfo.name,
+      tag: labelFilter.startsWith("tag:") ? labelFilter.slice(4) : "",
+      starred: labelFilter === "starred",
+    });
+    try {
+      const result = await ExportLabeledMedia(filter, getOutputDir(), exportDir);
+      const details = [`${result.skipped} exported before`];
+      if (result.not_found > 0) details.push(`${result.not_found} not downloaded`);
+      toast.success(`Exported ${result.exported} files to ${result.folder}`, { description: details.join(", ") });
+    } catch (error) {
+      toast.error(`Failed to export: ${error}`);
+    }
+  };
+
   const handleOpenTweet = (tweetId: string) => {
     openExternal(`https://x.com/${accountInfo.name}/status/${tweetId}`);
   };
 
+  // Quoted and replied-to tweets can be by anyone
+  const handleOpenOtherTweet = (tweetId: string) => {
+    openExternal(`https://x.com/i/status/${tweetId}`);
+  };
+
   const handleConvertGifs = async () => {
     const settings = getSettings();
     const isBookmarks = accountInfo.nick === "My Bookmarks";
@@ -661,6 +881,7 @@ export function MediaList({
         quality: settings.gifQuality || "fast",
         resolution: settings.gifResolution || "high",
         delete_original: false, // Keep MP4 original
+        workers: settings.gifWorkers || 0,
       });
 
       if (response.success) {
@@ -689,6 +910,39 @@ export function MediaList({
     }
   };
 
+  const handleConvertVideos = async () => {
+    const settings = getSettings();
+    const paths = await SelectVideoFiles(getOutputDir());
+    if (!paths || paths.length === 0) return;
+
+    setIsConverting(true);
+    logger.info(`Converting ${paths.length} video${paths.length !== 1 ? "s" : ""} to GIF...`);
+
+    try {
+      const response = await ConvertFilesToGIF({
+        paths,
+        quality: settings.gifQuality || "fast",
+        resolution: settings.gifResolution || "high",
+        delete_original: false, // Keep MP4 original
+        workers: settings.gifWorkers || 0,
+      });
+
+      if (response.success) {
+        logger.success(`Converted ${response.converted} GIFs${response.failed > 0 ? `, ${response.failed} failed` : ""}`);
+        toast.success(`${response.converted} GIFs converted`);
+      } else {
+        logger.error(response.message);
+        toast.error("Convert failed");
+      }
+    } catch (error) {
+      const errorMsg = error instanceof Error ? error.message : String(error);
+      logger.error(`Convert failed: ${errorMsg}`);
+      toast.error("Convert failed");
+    } finally {
+      setIsConverting(false);
+    }
+  };
+
   return (
     <div className="space-y-4">
       {/* Account Info Card */}
@@ -715,52 +969,74 @@ export function MediaList({
               <div className="text-2xl font-bold text-primary">{formatNumberWithComma(totalUrls)}</div>
             </div>
             <div className="text-sm text-muted-foreground">items found</div>
+            {stats && stats.estimated_bytes > 0 && (
+              <div className="text-xs text-muted-foreground" title="Estimated from the dimensions, bitrate and length of the media">
+                ~{formatSize(stats.estimated_bytes)}
+                {stats.first_date && stats.last_date && ` · ${formatJoinDate(stats.first_date)} – ${formatJoinDate(stats.last_date)}`}
+              </div>
+            )}
           </div>
         </div>
       ) : (
         // Normal account info card
-        <div className="flex items-center gap-4 p-4 bg-muted/50 rounded-lg">
-          <img
-            src={accountInfo.profile_image}
-            alt={accountInfo.nick}
-            className="w-16 h-16 rounded-full"
-          />
-          <div className="flex-1">
-            <div className="flex items-center gap-2">
-              <h2 className="text-xl">{accountInfo.nick}</h2>
-              <span className="text-muted-foreground">@{accountInfo.name}</span>
-            </div>
-            <div className="flex items-center gap-4 text-sm text-muted-foreground mt-1">
-              <span className="flex items-center gap-1">
-                <Users className="h-3.5 w-3.5" />
-                {formatNumber(accountInfo.followers_count)} followers
-              </span>
-              <span className="flex items-center gap-1">
-                <UserPlus className="h-3.5 w-3.5" />
-                {formatNumber(accountInfo.friends_count)} following
-              </span>
-              <span className="flex items-center gap-1">
-                <MessageSquare className="h-3.5 w-3.5" />
-                {formatNumber(accountInfo.statuses_count)} posts
-              </span>
-              {accountInfo.date && (
+        <div className="bg-muted/50 rounded-lg overflow-hidden">
+          {(accountInfo.profile_banner_original || accountInfo.profile_banner) && (
+            <img
+              src={accountInfo.profile_banner_original || accountInfo.profile_banner}
+              alt=""
+              className="w-full h-28 object-cover"
+              onError={(e) => { e.currentTarget.style.display = "none"; }}
+            />
+          )}
+          <div className="flex items-center gap-4 p-4">
+            <img
+              src={accountInfo.profile_image_original || accountInfo.profile_image}
+              alt={accountInfo.nick}
+              className="w-16 h-16 rounded-full"
+            />
+            <div className="flex-1">
+              <div className="flex items-center gap-2">
+                <h2 className="text-xl">{accountInfo.nick}</h2>
+                <span className="text-muted-foreground">@{accountInfo.name}</span>
+              </div>
+              <div className="flex items-center gap-4 text-sm text-muted-foreground mt-1">
                 <span className="flex items-center gap-1">
-                  <Calendar className="h-3.5 w-3.5" />
-                  Joined {formatJoinDate(accountInfo.date)}
+                  <Users className="h-3.5 w-3.5" />
+                  {formatNumber(accountInfo.followers_count)} followers
                 </span>
-              )}
+                <span className="flex items-center gap-1">
+                  <UserPlus className="h-3.5 w-3.5" />
+                  {formatNumber(accountInfo.friends_count)} following
+                </span>
+                <span className="flex items-center gap-1">
+                  <MessageSquare className="h-3.5 w-3.5" />
+                  {formatNumber(accountInfo.statuses_count)} posts
+                </span>
+                {accountInfo.date && (
+                  <span className="flex items-center gap-1">
+                    <Calendar className="h-3.5 w-3.5" />
+                    Joined {formatJoinDate(accountInfo.date)}
+                  </span>
+                )}
+              </div>
             </div>
-          </div>
-          <div className="text-right">
-            <div className="flex items-center justify-end gap-2">
-              {newMediaCount !== null && newMediaCount > 0 && (
-                <div className="text-lg font-semibold text-green-600 dark:text-green-400 animate-in fade-in slide-in-from-left-2 duration-300">
-                  {formatNumberWithComma(newMediaCount)}+
+            <div className="text-right">
+              <div className="flex items-center justify-end gap-2">
+                {newMediaCount !== null && newMediaCount > 0 && (
+                  <div className="text-lg font-semibold text-green-600 dark:text-green-400 animate-in fade-in slide-in-from-left-2 duration-300">
+                    {formatNumberWithComma(newMediaCount)}+
+                  </div>
+                )}
+                <div className="text-2xl font-bold text-primary">{formatNumberWithComma(totalUrls)}</div>
+              </div>
+              <div className="text-sm text-muted-foreground">items found</div>
+              {stats && stats.estimated_bytes > 0 && (
+                <div className="text-xs text-muted-foreground" title="Estimated from the dimensions, bitrate and length of the media">
+                  ~{formatSize(stats.estimated_bytes)}
+                  {stats.first_date && stats.last_date && ` · ${formatJoinDate(stats.first_date)} – ${formatJoinDate(stats.last_date)}`}
                 </div>
               )}
-              <div className="text-2xl font-bold text-primary">{formatNumberWithComma(totalUrls)}</div>
             </div>
-            <div className="text-sm text-muted-foreground">items found</div>
           </div>
         </div>
       )}
@@ -774,6 +1050,7 @@ export function MediaList({
           <SelectContent>
             <SelectItem value="date-desc">Newest</SelectItem>
             <SelectItem value="date-asc">Oldest</SelectItem>
+            <SelectItem value="thread">Threads</SelectItem>
           </SelectContent>
         </Select>
 
@@ -813,6 +1090,40 @@ export function MediaList({
           </Select>
         )}
 
+        {/* Star and tag filter - only show when the account has labels */}
+        {(starredCount > 0 || accountTags.length > 0) && (
+          <Select value={labelFilter} onValueChange={setLabelFilter}>
+            <SelectTrigger className="w-auto">
+              <SelectValue placeholder="Labels" />
+            </SelectTrigger>
+            <SelectContent>
+              <SelectItem value="all">Any Label</SelectItem>
+              {starredCount > 0 && (
+                <SelectItem value="starred">
+                  <span className="flex items-center gap-2">
+                    <Star className="h-4 w-4 text-yellow-500" />
+                    Starred ({formatNumberWithComma(starredCount)})
+                  </span>
+                </SelectItem>
+              )}
+              {accountTags.map(([tag, count]) => (
+                <SelectItem key={tag} value={`tag:${tag}`}>
+                  <span className="flex items-center gap-2">
+                    <Tags className="h-4 w-4" />
+                    {tag} ({formatNumberWithComma(count)})
+                  </span>
+                </SelectItem>
+              ))}
+            </SelectContent>
+          </Select>
+        )}
+        {labelFilter !== "all" && (
+          <Button variant="outline" onClick={handleExportLabeled}>
+            <FileOutput className="h-4 w-4" />
+            Export
+          </Button>
+        )}
+
         {/* View Mode Toggle */}
         <div className="flex items-center border rounded-md">
           <Button
@@ -859,7 +1170,17 @@ export function MediaList({
             </>
           )}
         </Button>
+        <Button variant="outline" onClick={handleConvertVideos} disabled={isConverting || !ffmpegInstalled}>
+          <Film className="h-4 w-4" />
+          Videos to GIF
+        </Button>
         <div className="flex items-center gap-2">
+          {isDownloading && (
+            <Button variant="outline" onClick={handleTogglePause}>
+              {isPaused ? <Play className="h-4 w-4" /> : <Pause className="h-4 w-4" />}
+              {isPaused ? "Resume" : "Pause"}
+            </Button>
+          )}
           {isDownloading && (
             <Button variant="destructive" onClick={handleStopDownload}>
               <StopCircle className="h-4 w-4" />
@@ -987,8 +1308,21 @@ export function MediaList({
                   <p className="text-sm text-muted-foreground mt-1">
                     {formatDate(item.date)} {getRelativeTime(item.date)}
                   </p>
+                  {(labels[item.url]?.tags.length ?? 0) > 0 && (
+                    <div className="flex flex-wrap gap-1 mt-1">
+                      {labels[item.url].tags.map((tag) => (
+                        <Badge key={tag} variant="outline" className="text-xs">{tag}</Badge>
+                      ))}
+                    </div>
+                  )}
                 </div>
                 <div className="flex items-center gap-2 shrink-0">
+                  <Button size="icon" variant="ghost" onClick={() => handleToggleStar(item)}>
+                    <Star className={`h-4 w-4 ${labels[item.url]?.starred ? "fill-yellow-500 text-yellow-500" : ""}`} />
+                  </Button>
+                  <Button size="icon" variant="ghost" onClick={() => handleEditTags(item)}>
+                    <Tags className="h-4 w-4" />
+                  </Button>
                   <Tooltip>
                     <TooltipTrigger asChild>
                       <Button
@@ -1008,6 +1342,7 @@ export function MediaList({
                                 content: item.content || "",
                                 original_filename: item.original_filename || "",
                                 author_username: item.author_username || "",
+                                media_index: item.media_index || 0,
                               })],
                               output_dir: getOutputDir(),
                               username: accountInfo.name,
@@ -1059,6 +1394,30 @@ export function MediaList({
                       )}
                     </TooltipContent>
                   </Tooltip>
+                  {item.quote_id && (
+                    <Tooltip>
+                      <TooltipTrigger asChild>
+                        <Button size="icon" variant="outline" onClick={() => handleOpenOtherTweet(item.quote_id!)}>
+                          <Quote className="h-4 w-4" />
+                        </Button>
+                      </TooltipTrigger>
+                      <TooltipContent>
+                        <p>Open quoted tweet</p>
+                      </TooltipContent>
+                    </Tooltip>
+                  )}
+                  {item.reply_id && (
+                    <Tooltip>
+                      <TooltipTrigger asChild>
+                        <Button size="icon" variant="outline" onClick={() => handleOpenOtherTweet(item.reply_id!)}>
+                          <Reply className="h-4 w-4" />
+                        </Button>
+                      </TooltipTrigger>
+                      <TooltipContent>
+                        <p>Open replied-to tweet</p>
+                      </TooltipContent>
+                    </Tooltip>
+                  )}
                   <Button
                     size="icon"
                     variant="outline"
@@ -1128,6 +1487,7 @@ export function MediaList({
                                   type: item.type,
                                   content: item.content || "",
                                   author_username: item.author_username || "",
+                                  media_index: item.media_index || 0,
                                 })],
                                 output_dir: getOutputDir(),
                                 username: accountInfo.name,
@@ -1190,8 +1550,30 @@ export function MediaList({
                     >
                       <ExternalLink className="h-4 w-4" />
                     </Button>
+                    <Button
+                      size="icon"
+                      variant="outline"
+                      className="h-8 w-8"
+                      onClick={(e) => {
+                        e.stopPropagation();
+                        handleEditTags(item);
+                      }}
+                    >
+                      <Tags className="h-4 w-4" />
+                    </Button>
                   </div>
 
+                  {/* Star - stays visible once set */}
+                  <button
+                    className={`absolute top-9 left-2 rounded p-0.5 bg-background/80 ${labels[item.url]?.starred ? "" : "opacity-0 group-hover:opacity-100"}`}
+                    onClick={(e) => {
+                      e.stopPropagation();
+                      handleToggleStar(item);
+                    }}
+                  >
+                    <Star className={`h-4 w-4 ${labels[item.url]?.starred ? "fill-yellow-500 text-yellow-500" : ""}`} />
+                  </button>
+
                   {/* Checkbox */}
                   <div className="absolute top-2 left-2" onClick={(e) => e.stopPropagation()}>
                     <Checkbox
@@ -1385,6 +1767,7 @@ export function MediaList({
                           content: item.content || "",
                           original_filename: item.original_filename || "",
                           author_username: item.author_username || "",
+                          media_index: item.media_index || 0,
                         })],
                         output_dir: getOutputDir(),
                         username: accountInfo.name,
@@ -1448,6 +1831,29 @@ export function MediaList({
         </div>
       )}
 
+      {/* Tag Editor */}
+      <Dialog open={!!taggingItem} onOpenChange={(open) => !open && setTaggingItem(null)}>
+        <DialogContent>
+          <DialogHeader>
+            <DialogTitle>Tags</DialogTitle>
+            <DialogDescription>
+              Separate tags with commas. Filter and export tagged media from the labels menu.
+            </DialogDescription>
+          </DialogHeader>
+          <Input
+            placeholder="e.g. art, reference"
+            value={tagInput}
+            onChange={(e) => setTagInput(e.target.value)}
+            onKeyDown={(e) => { if (e.key === "Enter") handleSaveTags(); }}
+            autoFocus
+          />
+          <DialogFooter>
+            <Button variant="outline" onClick={() => setTaggingItem(null)}>Cancel</Button>
+            <Button onClick={handleSaveTags}>Save</Button>
+          </DialogFooter>
+        </DialogContent>
+      </Dialog>
+
       {/* Scroll to Top Button - hide when preview is open */}
       {showScrollTop && previewIndex === null && (
         <Button
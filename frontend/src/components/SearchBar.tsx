@@ -49,16 +49,60 @@ import { Settings as SettingsIcon } from "lucide-react";
 import { FetchHistory } from "@/components/FetchHistory";
 import type { HistoryItem } from "@/components/FetchHistory";
 import { cn } from "@/lib/utils";
-import { getSettings, updateSettings, type FetchMode as SettingsFetchMode, type MediaType as SettingsMediaType } from "@/lib/settings";
+import { loadAuthTokens, getAuthToken, setAuthToken } from "@/lib/auth-tokens";
+import { getSettings, updateSettings, type FetchMode as SettingsFetchMode, type MediaType as SettingsMediaType, type TimelineType } from "@/lib/settings";
+import { GetTimelineTypes } from "../../wailsjs/go/main/App";
+import type { backend } from "../../wailsjs/go/models";
+import type { ExtractProgress } from "@/types/api";
+
+export interface FetchEstimate {
+  percent: number;
+  etaSeconds: number | null;
+}
+
+function formatEta(seconds: number): string {
+  if (seconds >= 3600) {
+    return `${Math.floor(seconds / 3600)}h ${Math.floor((seconds % 3600) / 60)}m`;
+  }
+  return `${Math.floor(seconds / 60)}:${String(seconds % 60).padStart(2, "0")}`;
+}
+
+// Pages, items, percentage and the estimated rate limit budget of the running extraction
+function ExtractProgressBadge({ progress, estimate }: { progress: ExtractProgress; estimate: FetchEstimate | null }) {
+  const low = progress.rate_remaining < progress.rate_budget * 0.1;
+  const resetAt = progress.rate_reset_at ? new Date(progress.rate_reset_at) : null;
+  return (
+    <Tooltip>
+      <TooltipTrigger asChild>
+        <div className="flex items-center gap-1.5 px-3 py-1.5 border rounded-md bg-muted/50 text-sm whitespace-nowrap">
+          {estimate && (
+            <span className="font-mono">
+              {estimate.percent}%{estimate.etaSeconds !== null && ` · ~${formatEta(estimate.etaSeconds)} left`}
+            </span>
+          )}
+          <span className="font-mono">{formatNumberWithComma(progress.items)}</span>
+          <span className="text-muted-foreground">items · {formatNumberWithComma(progress.pages)} pages</span>
+          <span className={cn("font-mono", low ? "text-destructive" : "text-muted-foreground")}>
+            ~{progress.rate_remaining}/{progress.rate_budget}
+          </span>
+        </div>
+      </TooltipTrigger>
+      <TooltipContent className="max-w-xs">
+        <p>{formatNumberWithComma(progress.media)} media from {formatNumberWithComma(progress.items - progress.media)} tweets</p>
+        <p>About {progress.rate_remaining} of {progress.rate_budget} requests left in this rate limit window{resetAt ? `, resets at ${resetAt.toLocaleTimeString()}` : ""}</p>
+        {progress.expected > 0 && (
+          <p>{formatNumberWithComma(progress.tweets)} of about {formatNumberWithComma(progress.expected)} tweets, by the account's counts</p>
+        )}
+        {progress.cursor && <p className="mt-1 text-xs break-all opacity-80">Resumes from cursor {progress.cursor}</p>}
+      </TooltipContent>
+    </Tooltip>
+  );
+}
 
 export type FetchMode = "public" | "private";
 export type PrivateType = "bookmarks" | "likes";
 export type FetchType = "single" | "multiple";
 
-// Local storage keys for auth tokens
-const PUBLIC_AUTH_TOKEN_KEY = "twitter_public_auth_token";
-const PRIVATE_AUTH_TOKEN_KEY = "twitter_private_auth_token";
-
 export interface MultipleAccount {
   id: string;
   username: string;
@@ -75,6 +119,8 @@ export interface MultipleAccount {
   error?: string;
   showDiff?: boolean;
   cursor?: string;
+  timelineType?: string; // Profile timeline from a batch import ("" = timeline)
+  entry?: backend.BatchImportEntry; // Single tweet or search from a batch import, fetched with FetchBatchEntry
 }
 
 interface SearchBarProps {
@@ -102,6 +148,8 @@ interface SearchBarProps {
   hasResult: boolean;
   elapsedTime?: number;
   remainingTime?: number | null;
+  extractProgress?: ExtractProgress | null;
+  fetchEstimate?: FetchEstimate | null;
   // Multiple mode props
   fetchType?: FetchType;
   onFetchTypeChange?: (type: FetchType) => void;
@@ -133,6 +181,8 @@ export function SearchBar({
   hasResult,
   elapsedTime = 0,
   remainingTime = null,
+  extractProgress = null,
+  fetchEstimate = null,
   fetchType = "single",
   onFetchTypeChange,
   multipleAccounts = [],
@@ -150,6 +200,8 @@ export function SearchBar({
   const [startDate, setStartDate] = useState("");
   const [endDate, setEndDate] = useState("");
   const [mediaType, setMediaType] = useState<SettingsMediaType>(getSettings().mediaType);
+  const [timelineType, setTimelineType] = useState<TimelineType>(getSettings().timelineType);
+  const [timelineTypes, setTimelineTypes] = useState<backend.TimelineTypeInfo[]>([]);
   const [retweets, setRetweets] = useState(getSettings().includeRetweets);
   const [mode, setMode] = useState<FetchMode>(externalMode || "public");
   const [privateType, setPrivateType] = useState<PrivateType>(externalPrivateType || "bookmarks");
@@ -176,23 +228,30 @@ export function SearchBar({
   const [showPublicToken, setShowPublicToken] = useState(false);
   const [showPrivateToken, setShowPrivateToken] = useState(false);
 
+  // Timelines of other accounts (bookmarks and likes are in private mode)
+  useEffect(() => {
+    GetTimelineTypes()
+      .then((types) => setTimelineTypes(types.filter((t) => t.needs_username && t.id !== "likes")))
+      .catch(() => setTimelineTypes([]));
+  }, []);
+
   // Load saved auth tokens on mount
   useEffect(() => {
-    const savedPublicToken = localStorage.getItem(PUBLIC_AUTH_TOKEN_KEY) || "";
-    const savedPrivateToken = localStorage.getItem(PRIVATE_AUTH_TOKEN_KEY) || "";
-    setPublicAuthToken(savedPublicToken);
-    setPrivateAuthToken(savedPrivateToken);
+    loadAuthTokens().then(() => {
+      setPublicAuthToken(getAuthToken("public"));
+      setPrivateAuthToken(getAuthToken("private"));
+    });
   }, []);
 
   // Save auth tokens when they change
   const handlePublicTokenChange = (value: string) => {
     setPublicAuthToken(value);
-    localStorage.setItem(PUBLIC_AUTH_TOKEN_KEY, value);
+    setAuthToken("public", value);
   };
 
   const handlePrivateTokenChange = (value: string) => {
     setPrivateAuthToken(value);
-    localStorage.setItem(PRIVATE_AUTH_TOKEN_KEY, value);
+    setAuthToken("private", value);
   };
 
   const handleFetch = () => {
@@ -302,13 +361,15 @@ export function SearchBar({
               </TooltipTrigger>
               <TooltipContent className="max-w-xs">
                 <p className="text-sm">
-                  One username per line, example:
+                  One username or URL per line (TXT) or per row (CSV), example:
                   <br />
                   <span className="font-mono text-xs">masteraoko</span>
                   <br />
-                  <span className="font-mono text-xs">xbatchdemo</span>
+                  <span className="font-mono text-xs">https://x.com/xbatchdemo/media</span>
                   <br />
-                  <span className="font-mono text-xs">takomayuyi</span>
+                  <span className="font-mono text-xs">https://x.com/takomayuyi/status/1234567890123456789</span>
+                  <br />
+                  <span className="font-mono text-xs">https://x.com/search?q=%23art</span>
                 </p>
               </TooltipContent>
             </Tooltip>
@@ -321,7 +382,7 @@ export function SearchBar({
               className="flex items-center gap-2 flex-1"
             >
               <FileText className="h-4 w-4" />
-              Import TXT File
+              Import TXT/CSV File
             </Button>
             <Button
               variant="default"
@@ -389,6 +450,7 @@ export function SearchBar({
             <div className="flex items-center gap-2">
               {loading && (
                 <>
+                  {extractProgress && <ExtractProgressBadge progress={extractProgress} estimate={fetchEstimate} />}
                   {/* Timer Display */}
                   {(remainingTime !== null || elapsedTime > 0) && (
                     <div className="flex items-center gap-1.5 px-3 py-1.5 border rounded-md bg-muted/50 text-sm w-[85px]">
@@ -508,6 +570,7 @@ export function SearchBar({
           <div className="flex items-center gap-2 ml-auto">
             {loading && (
               <>
+                {extractProgress && <ExtractProgressBadge progress={extractProgress} estimate={fetchEstimate} />}
                 {/* Timer Display */}
                 {(remainingTime !== null || elapsedTime > 0) && (
                   <div className="flex items-center gap-1.5 px-3 py-1.5 border rounded-md bg-muted/50 text-sm w-[85px]">
@@ -558,7 +621,7 @@ export function SearchBar({
                   {mode === "private" ? (
                     <p>Use auth token from the account whose bookmarks/likes you want to fetch</p>
                   ) : (
-                    <p>Recommended to use a dummy account, not your main account.<br />Excessive usage may cause suspension</p>
+                    <p>Recommended to use a dummy account, not your main account.<br />Excessive usage may cause suspension<br />Separate several tokens with commas to switch to the next one when rate limited</p>
                   )}
                 </TooltipContent>
               </Tooltip>
@@ -650,6 +713,30 @@ export function SearchBar({
               </Select>
             </div>
 
+            {/* Timeline - only for public mode */}
+            {mode === "public" && timelineTypes.length > 0 && (
+              <div className="flex items-center gap-2">
+                <Label htmlFor="timeline-type" className="text-sm">
+                  Timeline
+                </Label>
+                <Select value={timelineType} onValueChange={(value: TimelineType) => {
+                  updateSettings({ timelineType: value });
+                  setTimelineType(value);
+                }}>
+                  <SelectTrigger id="timeline-type" className="w-auto h-8 bg-background">
+                    <SelectValue />
+                  </SelectTrigger>
+                  <SelectContent>
+                    {timelineTypes.map((t) => (
+                      <SelectItem key={t.id} value={t.id}>
+                        {t.premium ? `${t.label} (Premium)` : t.label}
+                      </SelectItem>
+                    ))}
+                  </SelectContent>
+                </Select>
+              </div>
+            )}
+
             {/* Include Retweets */}
             <div className="flex items-center gap-2">
               <Checkbox
@@ -791,7 +878,7 @@ export function SearchBar({
                       </>
                     ) : (
                       <div className="flex items-center gap-2">
-                        <span className="font-medium">@{account.username}</span>
+                        <span className="font-medium">{account.entry ? account.entry.input : `@${account.username}`}</span>
                       </div>
                     )}
                   </div>
@@ -10,7 +10,7 @@ import {
   SelectValue,
 } from "@/components/ui/select";
 import { Tooltip, TooltipContent, TooltipTrigger } from "@/components/ui/tooltip";
-import { FolderOpen, Save, RotateCcw, Info, Download, Check, RefreshCw } from "lucide-react";
+import { FolderOpen, Save, RotateCcw, Info, Download, Check, RefreshCw, FileInput, Stethoscope, Copy, X, TriangleAlert, Bug, ScrollText } from "lucide-react";
 import {
   Dialog,
   DialogContent,
@@ -21,10 +21,21 @@ import {
 } from "@/components/ui/dialog";
 import { Spinner } from "@/components/ui/spinner";
 import { Switch } from "@/components/ui/switch";
-import { getSettings, getSettingsWithDefaults, saveSettings, resetToDefaultSettings, applyThemeMode, applyFont, FONT_OPTIONS, type Settings as SettingsType, type FontFamily, type GifQuality, type GifResolution } from "@/lib/settings";
+import { getSettings, getSettingsWithDefaults, saveSettings, resetToDefaultSettings, applyThemeMode, applyFont, FONT_OPTIONS, type Settings as SettingsType, type FontFamily, type GifQuality, type GifResolution, type Orientation, type ConflictPolicy, type ArchiveCapPolicy, type VideoPreview, type OutputTarget, type WebPConversion, type DateZone, type CollisionSuffix, type ArchiveOutput, type MessageLanguage } from "@/lib/settings";
 import { themes, applyTheme } from "@/lib/themes";
-import { SelectFolder, IsFFmpegInstalled, DownloadFFmpeg, IsExifToolInstalled, DownloadExifTool } from "../../wailsjs/go/main/App";
+import { SelectFolder, IsFFmpegInstalled, DownloadFFmpeg, IsExifToolInstalled, GetExifToolStatus, DownloadExifTool, ImportTool, CheckToolUpdates, UpdateTool, Diagnostics, GetDataDir, SetDataDir, GetLockStatus, SetLockPassphrase, GetEncryptionStatus, EnableEncryption, GetSecretStorage, DisableEncryption, TestSFTPConnection, TestS3Connection, TestWebDAVConnection, SetClipboardWatch, SetAccountWatch, SetMediaServer, GetSettings as GetBackendSettings, SetSettings as SetBackendSettings, ExportDebugBundle, OpenFolder, GetAuditSummary, GetAuditLogPath, ClearAuditLog } from "../../wailsjs/go/main/App";
+import { EventsOn, EventsOff } from "../../wailsjs/runtime/runtime";
+import { backend } from "../../wailsjs/go/models";
 import { toastWithSound as toast } from "@/lib/toast-with-sound";
+import { logger } from "@/lib/logger";
+import { getAuthToken } from "@/lib/auth-tokens";
+
+function formatSize(bytes: number): string {
+  if (bytes < 1024) return `${bytes} B`;
+  if (bytes < 1024 * 1024) return `${(bytes / 1024).toFixed(1)} KB`;
+  if (bytes < 1024 * 1024 * 1024) return `${(bytes / (1024 * 1024)).toFixed(1)} MB`;
+  return `${(bytes / (1024 * 1024 * 1024)).toFixed(1)} GB`;
+}
 
 export function SettingsPage() {
   const [savedSettings, setSavedSettings] = useState<SettingsType>(getSettings());
@@ -34,7 +45,27 @@ export function SettingsPage() {
   const [downloadingFFmpeg, setDownloadingFFmpeg] = useState(false);
   const [exiftoolInstalled, setExiftoolInstalled] = useState(false);
   const [downloadingExifTool, setDownloadingExifTool] = useState(false);
+  const [exiftoolStatus, setExiftoolStatus] = useState<backend.ExifToolStatus | null>(null);
   const [showResetConfirm, setShowResetConfirm] = useState(false);
+  const [lockEnabled, setLockEnabled] = useState(false);
+  const [currentPassphrase, setCurrentPassphrase] = useState("");
+  const [newPassphrase, setNewPassphrase] = useState("");
+  const [encryptionEnabled, setEncryptionEnabled] = useState(false);
+  const [secretStorage, setSecretStorage] = useState("");
+  const [encryptionPassphrase, setEncryptionPassphrase] = useState("");
+  const [encryptionBusy, setEncryptionBusy] = useState(false);
+  const [testingSFTP, setTestingSFTP] = useState(false);
+  const [testingRemote, setTestingRemote] = useState(false);
+  const [toolUpdates, setToolUpdates] = useState<Record<string, backend.ToolUpdate>>({});
+  const [checkingUpdates, setCheckingUpdates] = useState(false);
+  const [updatingTool, setUpdatingTool] = useState<string | null>(null);
+  const [updatePercent, setUpdatePercent] = useState<number | null>(null);
+  const [diagnostics, setDiagnostics] = useState<backend.DiagnosticsReport | null>(null);
+  const [runningDiagnostics, setRunningDiagnostics] = useState(false);
+  const [exportingBundle, setExportingBundle] = useState(false);
+  const [dataDir, setDataDir] = useState<backend.DataDirInfo | null>(null);
+  const [movingDataDir, setMovingDataDir] = useState(false);
+  const [backendSettings, setBackendSettings] = useState<backend.Settings | null>(null);
 
   useEffect(() => {
     applyThemeMode(savedSettings.themeMode);
@@ -63,12 +94,14 @@ export function SettingsPage() {
 
   const checkDependencies = async (showToast = false) => {
     try {
-      const [ffmpeg, exiftool] = await Promise.all([
+      const [ffmpeg, exiftool, status] = await Promise.all([
         IsFFmpegInstalled(),
-        IsExifToolInstalled()
+        IsExifToolInstalled(),
+        GetExifToolStatus()
       ]);
       setFfmpegInstalled(ffmpeg);
       setExiftoolInstalled(exiftool);
+      setExiftoolStatus(status);
       if (showToast) {
         toast.success("Dependencies status updated");
       }
@@ -89,11 +122,121 @@ export function SettingsPage() {
     
     // Initial check
     checkDependencies();
+    GetDataDir().then(setDataDir).catch((error) => console.error("Failed to get data folder:", error));
+    GetBackendSettings().then(setBackendSettings).catch((error) => console.error("Failed to load backend settings:", error));
+    GetLockStatus().then((status) => setLockEnabled(status.enabled)).catch(() => {});
+    GetEncryptionStatus().then((status) => setEncryptionEnabled(status.enabled)).catch(() => {});
+    GetSecretStorage().then(setSecretStorage).catch(() => {});
   }, []);
 
-  const handleSave = () => {
+  const handleSetPassphrase = async () => {
+    try {
+      await SetLockPassphrase(currentPassphrase, newPassphrase);
+      toast.success(newPassphrase ? "Content lock passphrase saved" : "Content lock removed");
+      setLockEnabled(newPassphrase !== "");
+      setCurrentPassphrase("");
+      setNewPassphrase("");
+    } catch (error) {
+      toast.error(String(error).includes("encryption") ? String(error) : "Wrong passphrase");
+    }
+  };
+
+  const handleToggleEncryption = async () => {
+    setEncryptionBusy(true);
+    try {
+      if (encryptionEnabled) {
+        const results = await DisableEncryption(encryptionPassphrase, tempSettings.downloadPath);
+        const files = (results || []).reduce((sum, r) => sum + r.files, 0);
+        toast.success("Encryption turned off", { description: `${files} files decrypted` });
+        setEncryptionEnabled(false);
+      } else {
+        await EnableEncryption(encryptionPassphrase);
+        toast.success("Encryption turned on", { description: "New downloads of sensitive accounts are encrypted. Encrypt existing ones from the account menu" });
+        setEncryptionEnabled(true);
+      }
+      setEncryptionPassphrase("");
+    } catch (error) {
+      toast.error(`${error}`);
+    } finally {
+      setEncryptionBusy(false);
+    }
+  };
+
+  const handleTestSFTP = async () => {
+    setTestingSFTP(true);
+    try {
+      await TestSFTPConnection(new backend.SFTPConfig({
+        host: tempSettings.sftpHost,
+        port: tempSettings.sftpPort || 0,
+        user: tempSettings.sftpUser,
+        identity_file: tempSettings.sftpKeyFile,
+        remote_dir: tempSettings.sftpRemoteDir,
+      }));
+      toast.success("SFTP connection works");
+    } catch (error) {
+      toast.error(`SFTP connection failed: ${error}`);
+    } finally {
+      setTestingSFTP(false);
+    }
+  };
+
+  const handleTestRemote = async () => {
+    setTestingRemote(true);
+    const name = tempSettings.outputTarget === "s3" ? "S3" : "WebDAV";
+    try {
+      if (tempSettings.outputTarget === "s3") {
+        await TestS3Connection(new backend.S3Config({
+          endpoint: tempSettings.s3Endpoint,
+          region: tempSettings.s3Region,
+          bucket: tempSettings.s3Bucket,
+          access_key: tempSettings.s3AccessKey,
+          secret_key: tempSettings.s3SecretKey,
+          prefix: tempSettings.s3Prefix,
+          path_style: tempSettings.s3PathStyle,
+        }));
+      } else {
+        await TestWebDAVConnection(new backend.WebDAVConfig({
+          url: tempSettings.webdavUrl,
+          user: tempSettings.webdavUser,
+          password: tempSettings.webdavPassword,
+        }));
+      }
+      toast.success(`${name} connection works`);
+    } catch (error) {
+      toast.error(`${name} connection failed: ${error}`);
+    } finally {
+      setTestingRemote(false);
+    }
+  };
+
+  const handleSave = async () => {
+    // The backend keeps its own copy of the settings it needs without a request (settings.json)
+    try {
+      const saved = await SetBackendSettings(new backend.Settings({
+        ...backendSettings,
+        proxy: tempSettings.proxy || "",
+        filename_template: tempSettings.filenameTemplate || "",
+        collision_suffix: tempSettings.collisionSuffix,
+      }));
+      setBackendSettings(saved);
+    } catch (error) {
+      toast.error(`Failed to save settings: ${error}`);
+      return;
+    }
     saveSettings(tempSettings);
     setSavedSettings(tempSettings);
+    SetClipboardWatch(tempSettings.watchClipboard).catch(() => {});
+    SetAccountWatch(tempSettings.watchAccounts, tempSettings.watchAccountsHours, getAuthToken("public")).catch(() => {});
+    if (tempSettings.mediaServer !== savedSettings.mediaServer || tempSettings.mediaServerPort !== savedSettings.mediaServerPort || (tempSettings.mediaServer && tempSettings.downloadPath !== savedSettings.downloadPath)) {
+      try {
+        const info = await SetMediaServer(tempSettings.mediaServer, tempSettings.mediaServerPort, tempSettings.downloadPath);
+        if (info) {
+          toast.success("Media server running", { description: info.urls.join("\n"), duration: 15000 });
+        }
+      } catch (error) {
+        toast.error(`Media server failed to start: ${error}`);
+      }
+    }
     toast.success("Settings saved");
   };
 
@@ -104,6 +247,10 @@ export function SettingsPage() {
     applyThemeMode(defaultSettings.themeMode);
     applyTheme(defaultSettings.theme);
     applyFont(defaultSettings.fontFamily);
+    SetClipboardWatch(defaultSettings.watchClipboard).catch(() => {});
+    SetAccountWatch(defaultSettings.watchAccounts, defaultSettings.watchAccountsHours, "").catch(() => {});
+    SetMediaServer(defaultSettings.mediaServer, defaultSettings.mediaServerPort, defaultSettings.downloadPath).catch(() => {});
+    SetBackendSettings(new backend.Settings({})).then(setBackendSettings).catch(() => {});
     setShowResetConfirm(false);
     toast.success("Settings reset to default");
   };
@@ -120,10 +267,31 @@ export function SettingsPage() {
     }
   };
 
+  const handleMoveDataDir = async (reset = false) => {
+    try {
+      let target = "";
+      if (!reset) {
+        target = await SelectFolder(dataDir?.path || "");
+        if (!target || target.trim() === "") {
+          return;
+        }
+      }
+      setMovingDataDir(true);
+      const info = await SetDataDir(target);
+      setDataDir(info);
+      toast.success(`Data folder moved to ${info.path}`);
+      await checkDependencies();
+    } catch (error) {
+      toast.error(`Failed to move data folder: ${error}`);
+    } finally {
+      setMovingDataDir(false);
+    }
+  };
+
   const handleDownloadFFmpeg = async () => {
     setDownloadingFFmpeg(true);
     try {
-      await DownloadFFmpeg();
+      await DownloadFFmpeg(tempSettings.toolMirror || "");
       await checkDependencies(); // Re-check after download
       toast.success("FFmpeg downloaded successfully");
     } catch (error) {
@@ -137,7 +305,7 @@ export function SettingsPage() {
   const handleDownloadExifTool = async () => {
     setDownloadingExifTool(true);
     try {
-      await DownloadExifTool();
+      await DownloadExifTool(tempSettings.toolMirror || "");
       await checkDependencies(); // Re-check after download
       toast.success("ExifTool downloaded successfully");
     } catch (error) {
@@ -148,6 +316,176 @@ export function SettingsPage() {
     }
   };
 
+  const handleCheckToolUpdates = async () => {
+    setCheckingUpdates(true);
+    try {
+      const updates = await CheckToolUpdates(tempSettings.toolMirror || "");
+      const byTool: Record<string, backend.ToolUpdate> = {};
+      for (const update of updates || []) {
+        byTool[update.tool] = update;
+      }
+      setToolUpdates(byTool);
+      const available = (updates || []).filter((u) => u.update_available).length;
+      toast.success(available > 0 ? `${available} tool update${available > 1 ? "s" : ""} available` : "Tools are up to date");
+    } catch (error) {
+      toast.error(`Failed to check for tool updates: ${error}`);
+    } finally {
+      setCheckingUpdates(false);
+    }
+  };
+
+  const handleUpdateTool = async (tool: "ffmpeg" | "exiftool") => {
+    setUpdatingTool(tool);
+    setUpdatePercent(null);
+    EventsOn("tool-update-progress", (progress: { tool: string; downloaded: number; total: number }) => {
+      if (progress.total > 0) {
+        setUpdatePercent(Math.round((progress.downloaded / progress.total) * 100));
+      }
+    });
+    try {
+      await UpdateTool(tool, tempSettings.toolMirror || "");
+      await checkDependencies();
+      setToolUpdates((prev) => ({ ...prev, [tool]: { ...prev[tool], update_available: false, installed: prev[tool]?.latest || "" } as backend.ToolUpdate }));
+      toast.success(`${tool === "ffmpeg" ? "FFmpeg" : "ExifTool"} updated`);
+    } catch (error) {
+      toast.error(`Failed to update ${tool === "ffmpeg" ? "FFmpeg" : "ExifTool"}: ${error}`);
+    } finally {
+      EventsOff("tool-update-progress");
+      setUpdatingTool(null);
+      setUpdatePercent(null);
+    }
+  };
+
+  // Update status of a bundled tool, shown next to "Detected & Installed"
+  const renderToolUpdate = (tool: "ffmpeg" | "exiftool") => {
+    const update = toolUpdates[tool];
+    if (updatingTool === tool) {
+      return (
+        <Button variant="outline" size="sm" className="h-9 ml-3" disabled>
+          <Spinner />
+          Updating{updatePercent !== null ? ` ${updatePercent}%` : "..."}
+        </Button>
+      );
+    }
+    if (update?.update_available) {
+      return (
+        <Button variant="outline" size="sm" className="h-9 ml-3" onClick={() => handleUpdateTool(tool)} disabled={updatingTool !== null}>
+          <Download className="h-4 w-4" />
+          Update{update.latest ? ` to ${update.latest}` : ""}
+        </Button>
+      );
+    }
+    if (update) {
+      return (
+        <span className="ml-3 text-xs text-muted-foreground">
+          {update.bundled ? `Up to date${update.installed ? ` (${update.installed})` : ""}` : "System installed"}
+        </span>
+      );
+    }
+    return (
+      <Button variant="ghost" size="sm" className="h-9 ml-2" onClick={handleCheckToolUpdates} disabled={checkingUpdates}>
+        {checkingUpdates ? <Spinner /> : <RefreshCw className="h-4 w-4" />}
+        Check for Updates
+      </Button>
+    );
+  };
+
+  const handleRunDiagnostics = async () => {
+    setRunningDiagnostics(true);
+    try {
+      setDiagnostics(await Diagnostics(tempSettings.downloadPath || "", tempSettings.proxy || ""));
+    } catch (error) {
+      toast.error(`Failed to run diagnostics: ${error}`);
+    } finally {
+      setRunningDiagnostics(false);
+    }
+  };
+
+  const handleShowAuditSummary = async () => {
+    try {
+      const summary = await GetAuditSummary();
+      if (summary.length === 0) {
+        toast.info("The network audit log is empty");
+        return;
+      }
+      const path = await GetAuditLogPath();
+      toast.info(`Network audit log: ${summary.length} host${summary.length === 1 ? "" : "s"}`, {
+        description: (
+          <div className="space-y-0.5">
+            {summary.map((host) => (
+              <div key={host.host}>
+                {host.host} ({host.purposes.join(", ")}): {host.requests} request{host.requests === 1 ? "" : "s"}, {formatSize(host.sent)} sent, {formatSize(host.received)} received
+              </div>
+            ))}
+          </div>
+        ),
+        duration: 15000,
+        action: {
+          label: "Open Folder",
+          onClick: () => OpenFolder(path.replace(/[\\/][^\\/]*$/, "")).catch(() => {}),
+        },
+      });
+    } catch (error) {
+      toast.error(`Failed to read audit log: ${error}`);
+    }
+  };
+
+  const handleClearAuditLog = async () => {
+    try {
+      await ClearAuditLog();
+      toast.success("Network audit log cleared");
+    } catch (error) {
+      toast.error(`${error}`);
+    }
+  };
+
+  const handleExportDebugBundle = async () => {
+    setExportingBundle(true);
+    try {
+      // Credentials in the settings are redacted by the backend
+      const path = await ExportDebugBundle(new backend.DebugBundleRequest({
+        logs: logger.getLogs().map((entry) => `${entry.timestamp.toISOString()} [${entry.level}] ${entry.message}`),
+        settings: savedSettings,
+        output_dir: savedSettings.downloadPath || "",
+        proxy: savedSettings.proxy || "",
+      }));
+      toast.success("Debug bundle saved", {
+        description: path,
+        action: {
+          label: "Open Folder",
+          onClick: () => OpenFolder(path.replace(/[\\/][^\\/]*$/, "")).catch(() => {}),
+        },
+      });
+    } catch (error) {
+      toast.error(`Failed to export debug bundle: ${error}`);
+    } finally {
+      setExportingBundle(false);
+    }
+  };
+
+  const handleCopyDiagnostics = async () => {
+    if (!diagnostics) return;
+    try {
+      await navigator.clipboard.writeText(JSON.stringify(diagnostics, null, 2));
+      toast.success("Diagnostics report copied");
+    } catch (error) {
+      toast.error("Failed to copy diagnostics report");
+    }
+  };
+
+  // Offline install from an archive downloaded elsewhere
+  const handleImportTool = async (tool: "ffmpeg" | "exiftool") => {
+    try {
+      const response = await ImportTool(tool);
+      if (response.success) {
+        await checkDependencies();
+        toast.success(response.message);
+      }
+    } catch (error) {
+      toast.error(`Failed to import ${tool === "ffmpeg" ? "FFmpeg" : "ExifTool"}: ${error}`);
+    }
+  };
+
   return (
     <div className="space-y-6">
       <h1 className="text-2xl font-bold">Settings</h1>
@@ -172,6 +510,48 @@ export function SettingsPage() {
             </div>
           </div>
 
+          {/* Data Folder */}
+          <div className="space-y-2">
+            <Label htmlFor="data-folder" className="flex items-center gap-2">
+              Data Folder
+              {dataDir?.source === "portable" && <span className="text-xs text-muted-foreground">(portable)</span>}
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Where tools, the account database and caches are kept. Moving it takes effect immediately, without saving</p>
+                  <p className="mt-1 text-xs text-muted-foreground">The TXMBD_DATA_DIR environment variable overrides this</p>
+                  <p className="mt-1 text-xs text-muted-foreground">Put a portable.txt file next to the app (or start it with --portable) to keep everything in the app folder</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <div className="flex gap-2">
+              <InputWithContext id="data-folder" value={dataDir?.path || ""} readOnly />
+              <Button
+                type="button"
+                variant="outline"
+                onClick={() => handleMoveDataDir()}
+                disabled={movingDataDir || dataDir?.source === "env" || dataDir?.source === "portable"}
+                className="gap-1.5"
+              >
+                {movingDataDir ? <Spinner /> : <FolderOpen className="h-4 w-4" />}
+                Move
+              </Button>
+              {dataDir?.source === "setting" && (
+                <Button
+                  type="button"
+                  variant="ghost"
+                  onClick={() => handleMoveDataDir(true)}
+                  disabled={movingDataDir}
+                  title={`Move back to ${dataDir.default}`}
+                >
+                  <RotateCcw className="h-4 w-4" />
+                </Button>
+              )}
+            </div>
+          </div>
+
           {/* Theme Mode */}
           <div className="space-y-2">
             <Label htmlFor="theme-mode">Mode</Label>
@@ -247,6 +627,26 @@ export function SettingsPage() {
               onCheckedChange={(checked) => setTempSettings(prev => ({ ...prev, sfxEnabled: checked }))}
             />
           </div>
+
+          {/* Desktop Notifications */}
+          <div className="flex items-center gap-3">
+            <Label htmlFor="notifications-enabled" className="cursor-pointer text-sm">Desktop Notifications</Label>
+            <Switch
+              id="notifications-enabled"
+              checked={tempSettings.notificationsEnabled}
+              onCheckedChange={(checked) => setTempSettings(prev => ({ ...prev, notificationsEnabled: checked }))}
+            />
+          </div>
+
+          {/* Newest First */}
+          <div className="flex items-center gap-3">
+            <Label htmlFor="newest-first" className="cursor-pointer text-sm">Download Newest First</Label>
+            <Switch
+              id="newest-first"
+              checked={tempSettings.newestFirst}
+              onCheckedChange={(checked) => setTempSettings(prev => ({ ...prev, newestFirst: checked }))}
+            />
+          </div>
         </div>
 
         {/* Right Column */}
@@ -278,10 +678,25 @@ export function SettingsPage() {
             </div>
             <div className="h-9 flex items-center">
               {exiftoolInstalled ? (
-                <div className="flex items-center gap-2 text-sm text-green-600 dark:text-green-400 font-medium">
-                  <Check className="h-4 w-4" />
-                  Detected & Installed
-                </div>
+                <>
+                  <div className="flex items-center gap-2 text-sm text-green-600 dark:text-green-400 font-medium">
+                    <Check className="h-4 w-4" />
+                    Detected & Installed
+                  </div>
+                  {renderToolUpdate("exiftool")}
+                </>
+              ) : exiftoolStatus?.perl_missing ? (
+                <Tooltip>
+                  <TooltipTrigger asChild>
+                    <div className="flex items-center gap-2 text-sm text-yellow-600 dark:text-yellow-400 font-medium">
+                      <TriangleAlert className="h-4 w-4" />
+                      Perl not installed
+                    </div>
+                  </TooltipTrigger>
+                  <TooltipContent className="max-w-xs">
+                    <p>{exiftoolStatus.message}</p>
+                  </TooltipContent>
+                </Tooltip>
               ) : (
                 <Button
                   variant="outline"
@@ -303,6 +718,19 @@ export function SettingsPage() {
                   )}
                 </Button>
               )}
+              {!exiftoolInstalled && !exiftoolStatus?.perl_missing && (
+                <Button
+                  variant="ghost"
+                  size="sm"
+                  className="h-9 ml-2"
+                  onClick={() => handleImportTool("exiftool")}
+                  disabled={downloadingExifTool}
+                  title="Install from a downloaded archive (offline)"
+                >
+                  <FileInput className="h-4 w-4" />
+                  Import
+                </Button>
+              )}
             </div>
           </div>
 
@@ -333,10 +761,13 @@ export function SettingsPage() {
             </div>
             <div className="h-9 flex items-center">
               {ffmpegInstalled ? (
-                <div className="flex items-center gap-2 text-sm text-green-600 dark:text-green-400 font-medium">
-                  <Check className="h-4 w-4" />
-                  Detected & Installed
-                </div>
+                <>
+                  <div className="flex items-center gap-2 text-sm text-green-600 dark:text-green-400 font-medium">
+                    <Check className="h-4 w-4" />
+                    Detected & Installed
+                  </div>
+                  {renderToolUpdate("ffmpeg")}
+                </>
               ) : (
                 <Button
                   variant="outline"
@@ -358,6 +789,19 @@ export function SettingsPage() {
                   )}
                 </Button>
               )}
+              {!ffmpegInstalled && (
+                <Button
+                  variant="ghost"
+                  size="sm"
+                  className="h-9 ml-2"
+                  onClick={() => handleImportTool("ffmpeg")}
+                  disabled={downloadingFFmpeg}
+                  title="Install from a downloaded archive (offline)"
+                >
+                  <FileInput className="h-4 w-4" />
+                  Import
+                </Button>
+              )}
             </div>
           </div>
 
@@ -401,6 +845,36 @@ export function SettingsPage() {
             </div>
           )}
 
+          {/* GIF Conversion Workers - only show if FFmpeg installed */}
+          {ffmpegInstalled && (
+            <div className="space-y-2">
+              <Label htmlFor="gif-workers" className="flex items-center gap-2">
+                GIF Conversion Workers
+                <Tooltip>
+                  <TooltipTrigger asChild>
+                    <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                  </TooltipTrigger>
+                  <TooltipContent side="top">
+                    <p>Number of GIFs converted at the same time (0 = one per CPU core)</p>
+                  </TooltipContent>
+                </Tooltip>
+              </Label>
+              <InputWithContext
+                id="gif-workers"
+                type="number"
+                min="0"
+                max="32"
+                value={tempSettings.gifWorkers || 0}
+                onChange={(e) => {
+                  const value = parseInt(e.target.value, 10);
+                  setTempSettings((prev) => ({ ...prev, gifWorkers: isNaN(value) || value < 0 ? 0 : Math.min(value, 32) }));
+                }}
+                placeholder="0"
+                className="w-[20%]"
+              />
+            </div>
+          )}
+
           {/* Proxy */}
           <div className="space-y-2">
             <Label htmlFor="proxy" className="flex items-center gap-2">
@@ -423,6 +897,54 @@ export function SettingsPage() {
             />
           </div>
 
+          {/* Age-Restricted Media */}
+          <div className="space-y-2">
+            <Label htmlFor="restricted-auth-token" className="flex items-center gap-2">
+              Auth Token for Age-Restricted Media
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Sensitive tweets come back without media for accounts that can't see them and are reported after each fetch</p>
+                  <p className="mt-1 text-xs text-muted-foreground">With the auth token of an age-verified account they are fetched again with it</p>
+                  {secretStorage && <p className="mt-1 text-xs text-muted-foreground">Auth tokens are stored in: {secretStorage}</p>}
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <InputWithContext
+              id="restricted-auth-token"
+              type="password"
+              value={tempSettings.restrictedAuthToken || ""}
+              onChange={(e) => setTempSettings((prev) => ({ ...prev, restrictedAuthToken: e.target.value }))}
+              placeholder="Auth token (optional)"
+              className="w-[90%]"
+            />
+          </div>
+
+          {/* Tool Mirror */}
+          <div className="space-y-2">
+            <Label htmlFor="tool-mirror" className="flex items-center gap-2">
+              Tool Download Mirror
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Server holding the FFmpeg/ExifTool archives under their usual file names, tried before the built-in mirrors</p>
+                  <p className="mt-1 text-xs text-muted-foreground">Downloads are always checked against the published checksums</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <InputWithContext
+              id="tool-mirror"
+              value={tempSettings.toolMirror || ""}
+              onChange={(e) => setTempSettings((prev) => ({ ...prev, toolMirror: e.target.value }))}
+              placeholder="https://artifacts.example.com/tools (optional)"
+              className="w-[90%]"
+            />
+          </div>
+
           {/* Fetch Timeout */}
           <div className="space-y-2">
             <Label htmlFor="fetch-timeout" className="flex items-center gap-2">
@@ -468,20 +990,1200 @@ export function SettingsPage() {
             />
           </div>
 
-        </div>
-      </div>
+          {/* Orientation Filter */}
+          <div className="space-y-2">
+            <Label htmlFor="orientation" className="flex items-center gap-2">
+              Orientation Filter
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Only download media with this orientation and minimum aspect ratio (long side / short side, 0 = any)</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <div className="flex items-center gap-2">
+              <Select
+                value={tempSettings.orientation}
+                onValueChange={(value: Orientation) => setTempSettings((prev) => ({ ...prev, orientation: value }))}
+              >
+                <SelectTrigger id="orientation" className="w-auto">
+                  <SelectValue placeholder="Orientation" />
+                </SelectTrigger>
+                <SelectContent>
+                  <SelectItem value="all">All</SelectItem>
+                  <SelectItem value="landscape">Landscape</SelectItem>
+                  <SelectItem value="portrait">Portrait</SelectItem>
+                  <SelectItem value="square">Square</SelectItem>
+                </SelectContent>
+              </Select>
+              <InputWithContext
+                id="min-aspect-ratio"
+                type="number"
+                step="0.01"
+                value={tempSettings.minAspectRatio || 0}
+                onChange={(e) => {
+                  const value = parseFloat(e.target.value);
+                  setTempSettings((prev) => ({ ...prev, minAspectRatio: isNaN(value) || value < 0 ? 0 : value }));
+                }}
+                placeholder="0"
+                className="w-[20%]"
+              />
+            </div>
+          </div>
 
-      {/* Actions */}
-      <div className="flex gap-2 justify-between pt-4 border-t">
-        <Button variant="outline" onClick={() => setShowResetConfirm(true)} className="gap-1.5">
-          <RotateCcw className="h-4 w-4" />
-          Reset to Default
-        </Button>
-        <Button onClick={handleSave} className="gap-1.5">
-          <Save className="h-4 w-4" />
-          Save Changes
-        </Button>
-      </div>
+          {/* Existing Files */}
+          <div className="space-y-2">
+            <Label htmlFor="conflict-policy" className="flex items-center gap-2">
+              Existing Files
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>What to do when a file with the same name already exists but its content differs</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Select
+              value={tempSettings.conflictPolicy}
+              onValueChange={(value: ConflictPolicy) => setTempSettings((prev) => ({ ...prev, conflictPolicy: value }))}
+            >
+              <SelectTrigger id="conflict-policy" className="w-auto">
+                <SelectValue placeholder="Existing Files" />
+              </SelectTrigger>
+              <SelectContent>
+                <SelectItem value="skip">Skip (don't download)</SelectItem>
+                <SelectItem value="overwrite">Overwrite if different</SelectItem>
+                <SelectItem value="keep_both">Keep both if different</SelectItem>
+                <SelectItem value="ask">Ask me</SelectItem>
+              </SelectContent>
+            </Select>
+          </div>
+
+          {/* Output Target */}
+          <div className="space-y-2">
+            <Label htmlFor="output-target" className="flex items-center gap-2">
+              Save Downloads To
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Stream downloads straight to a server over SSH (e.g. a NAS) with the same folder layout. Uses your SSH agent or key - password logins aren't supported</p>
+                  <p>S3-compatible buckets and WebDAV folders (e.g. Nextcloud) get the same layout below the prefix or folder URL</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Select
+              value={tempSettings.outputTarget}
+              onValueChange={(value: OutputTarget) => setTempSettings((prev) => ({ ...prev, outputTarget: value }))}
+            >
+              <SelectTrigger id="output-target" className="w-auto">
+                <SelectValue placeholder="Save Downloads To" />
+              </SelectTrigger>
+              <SelectContent>
+                <SelectItem value="local">Download folder</SelectItem>
+                <SelectItem value="sftp">SFTP server</SelectItem>
+                <SelectItem value="s3">S3 bucket</SelectItem>
+                <SelectItem value="webdav">WebDAV folder</SelectItem>
+              </SelectContent>
+            </Select>
+            {tempSettings.outputTarget === "sftp" && (
+              <div className="space-y-2">
+                <div className="flex gap-2">
+                  <InputWithContext
+                    id="sftp-host"
+                    value={tempSettings.sftpHost}
+                    onChange={(e) => setTempSettings((prev) => ({ ...prev, sftpHost: e.target.value.trim() }))}
+                    placeholder="Host"
+                    className="w-[40%]"
+                  />
+                  <InputWithContext
+                    id="sftp-port"
+                    type="number"
+                    min="0"
+                    value={tempSettings.sftpPort || ""}
+                    onChange={(e) => {
+                      const value = parseInt(e.target.value);
+                      setTempSettings((prev) => ({ ...prev, sftpPort: isNaN(value) || value < 0 ? 0 : value }));
+                    }}
+                    placeholder="22"
+                    className="w-[15%]"
+                  />
+                  <InputWithContext
+                    id="sftp-user"
+                    value={tempSettings.sftpUser}
+                    onChange={(e) => setTempSettings((prev) => ({ ...prev, sftpUser: e.target.value.trim() }))}
+                    placeholder="User"
+                    className="w-[25%]"
+                  />
+                </div>
+                <div className="flex gap-2">
+                  <InputWithContext
+                    id="sftp-remote-dir"
+                    value={tempSettings.sftpRemoteDir}
+                    onChange={(e) => setTempSettings((prev) => ({ ...prev, sftpRemoteDir: e.target.value }))}
+                    placeholder="Remote folder, e.g. /volume1/twitter"
+                    className="w-[40%]"
+                  />
+                  <InputWithContext
+                    id="sftp-key-file"
+                    value={tempSettings.sftpKeyFile}
+                    onChange={(e) => setTempSettings((prev) => ({ ...prev, sftpKeyFile: e.target.value }))}
+                    placeholder="Private key (optional)"
+                    className="w-[40%]"
+                  />
+                  <Button variant="outline" onClick={handleTestSFTP} disabled={!tempSettings.sftpHost || testingSFTP}>
+                    {testingSFTP ? <Spinner /> : "Test"}
+                  </Button>
+                </div>
+              </div>
+            )}
+            {tempSettings.outputTarget === "s3" && (
+              <div className="space-y-2">
+                <div className="flex gap-2">
+                  <InputWithContext
+                    id="s3-endpoint"
+                    value={tempSettings.s3Endpoint}
+                    onChange={(e) => setTempSettings((prev) => ({ ...prev, s3Endpoint: e.target.value.trim() }))}
+                    placeholder="Endpoint (empty = AWS)"
+                    className="w-[40%]"
+                  />
+                  <InputWithContext
+                    id="s3-region"
+                    value={tempSettings.s3Region}
+                    onChange={(e) => setTempSettings((prev) => ({ ...prev, s3Region: e.target.value.trim() }))}
+                    placeholder="us-east-1"
+                    className="w-[20%]"
+                  />
+                  <InputWithContext
+                    id="s3-bucket"
+                    value={tempSettings.s3Bucket}
+                    onChange={(e) => setTempSettings((prev) => ({ ...prev, s3Bucket: e.target.value.trim() }))}
+                    placeholder="Bucket"
+                    className="w-[20%]"
+                  />
+                </div>
+                <div className="flex gap-2">
+                  <InputWithContext
+                    id="s3-access-key"
+                    value={tempSettings.s3AccessKey}
+                    onChange={(e) => setTempSettings((prev) => ({ ...prev, s3AccessKey: e.target.value.trim() }))}
+                    placeholder="Access key"
+                    className="w-[30%]"
+                  />
+                  <InputWithContext
+                    id="s3-secret-key"
+                    type="password"
+                    value={tempSettings.s3SecretKey}
+                    onChange={(e) => setTempSettings((prev) => ({ ...prev, s3SecretKey: e.target.value.trim() }))}
+                    placeholder="Secret key"
+                    className="w-[30%]"
+                  />
+                  <InputWithContext
+                    id="s3-prefix"
+                    value={tempSettings.s3Prefix}
+                    onChange={(e) => setTempSettings((prev) => ({ ...prev, s3Prefix: e.target.value }))}
+                    placeholder="Prefix (optional)"
+                    className="w-[20%]"
+                  />
+                </div>
+                <div className="flex items-center gap-2">
+                  <Switch
+                    id="s3-path-style"
+                    checked={tempSettings.s3PathStyle}
+                    onCheckedChange={(checked) => setTempSettings((prev) => ({ ...prev, s3PathStyle: checked }))}
+                  />
+                  <Label htmlFor="s3-path-style" className="text-sm font-normal">Path-style URLs (MinIO, self-hosted)</Label>
+                  <Button variant="outline" onClick={handleTestRemote} disabled={!tempSettings.s3Bucket || testingRemote}>
+                    {testingRemote ? <Spinner /> : "Test"}
+                  </Button>
+                </div>
+              </div>
+            )}
+            {tempSettings.outputTarget === "webdav" && (
+              <div className="space-y-2">
+                <InputWithContext
+                  id="webdav-url"
+                  value={tempSettings.webdavUrl}
+                  onChange={(e) => setTempSettings((prev) => ({ ...prev, webdavUrl: e.target.value.trim() }))}
+                  placeholder="Folder URL, e.g. https://cloud.example.com/remote.php/dav/files/me/twitter"
+                  className="w-[80%]"
+                />
+                <div className="flex gap-2">
+                  <InputWithContext
+                    id="webdav-user"
+                    value={tempSettings.webdavUser}
+                    onChange={(e) => setTempSettings((prev) => ({ ...prev, webdavUser: e.target.value.trim() }))}
+                    placeholder="User"
+                    className="w-[30%]"
+                  />
+                  <InputWithContext
+                    id="webdav-password"
+                    type="password"
+                    value={tempSettings.webdavPassword}
+                    onChange={(e) => setTempSettings((prev) => ({ ...prev, webdavPassword: e.target.value }))}
+                    placeholder="Password"
+                    className="w-[30%]"
+                  />
+                  <Button variant="outline" onClick={handleTestRemote} disabled={!tempSettings.webdavUrl || testingRemote}>
+                    {testingRemote ? <Spinner /> : "Test"}
+                  </Button>
+                </div>
+              </div>
+            )}
+          </div>
+
+          {/* Post-download Hooks */}
+          <div className="space-y-2">
+            <Label htmlFor="file-hook" className="flex items-center gap-2">
+              Post-download Hooks
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top" className="max-w-xs">
+                  <p>Shell commands run after each downloaded file and after each job, e.g. to import into Hydrus or rclone sync</p>
+                  <p className="mt-1">Per file: TXMBD_PATH, TXMBD_TWEET_ID, TXMBD_AUTHOR, TXMBD_TYPE, TXMBD_DATE, TXMBD_URL</p>
+                  <p className="mt-1">Per job: TXMBD_STATUS, TXMBD_DOWNLOADED, TXMBD_FAILED, TXMBD_FILES (list of saved paths)</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <InputWithContext
+              id="file-hook"
+              value={tempSettings.fileHook}
+              onChange={(e) => setTempSettings((prev) => ({ ...prev, fileHook: e.target.value }))}
+              placeholder={'Per file, e.g. hydrus-import "$TXMBD_PATH"'}
+            />
+            <InputWithContext
+              id="batch-hook"
+              value={tempSettings.batchHook}
+              onChange={(e) => setTempSettings((prev) => ({ ...prev, batchHook: e.target.value }))}
+              placeholder={'Per job, e.g. rclone copy "$TXMBD_OUTPUT_DIR" remote:twitter'}
+            />
+          </div>
+
+          {/* WebP Conversion */}
+          <div className="space-y-2">
+            <Label htmlFor="convert-webp" className="flex items-center gap-2">
+              Convert WebP Images
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Convert images that arrive as WebP to JPEG or PNG for older viewers (requires FFmpeg)</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <div className="flex gap-2">
+              <Select
+                value={tempSettings.convertWebP}
+                onValueChange={(value: WebPConversion) => setTempSettings((prev) => ({ ...prev, convertWebP: value }))}
+              >
+                <SelectTrigger id="convert-webp" className="w-auto">
+                  <SelectValue placeholder="Convert WebP Images" />
+                </SelectTrigger>
+                <SelectContent>
+                  <SelectItem value="off">Keep WebP</SelectItem>
+                  <SelectItem value="jpg">JPEG</SelectItem>
+                  <SelectItem value="png">PNG</SelectItem>
+                </SelectContent>
+              </Select>
+              {tempSettings.convertWebP === "jpg" && (
+                <InputWithContext
+                  id="webp-quality"
+                  type="number"
+                  min="1"
+                  max="100"
+                  value={tempSettings.webpQuality}
+                  onChange={(e) => {
+                    const value = parseInt(e.target.value);
+                    setTempSettings((prev) => ({ ...prev, webpQuality: isNaN(value) ? 90 : Math.min(100, Math.max(1, value)) }));
+                  }}
+                  placeholder="90"
+                  className="w-[20%]"
+                />
+              )}
+            </div>
+          </div>
+
+          {/* Message Language */}
+          <div className="space-y-2">
+            <Label htmlFor="message-language" className="flex items-center gap-2">
+              Error Hint Language
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Language of fetch error hints in the log, like rate limit and auth token hints</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Select
+              value={tempSettings.messageLanguage}
+              onValueChange={(value: MessageLanguage) => setTempSettings((prev) => ({ ...prev, messageLanguage: value }))}
+            >
+              <SelectTrigger id="message-language" className="w-auto">
+                <SelectValue placeholder="Error Hint Language" />
+              </SelectTrigger>
+              <SelectContent>
+                <SelectItem value="auto">System Language</SelectItem>
+                <SelectItem value="en">English</SelectItem>
+                <SelectItem value="zh-CN">简体中文</SelectItem>
+                <SelectItem value="ja">日本語</SelectItem>
+                <SelectItem value="es">Español</SelectItem>
+              </SelectContent>
+            </Select>
+          </div>
+
+          {/* Date Time Zone */}
+          <div className="space-y-2">
+            <Label htmlFor="date-zone" className="flex items-center gap-2">
+              Date Time Zone
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Time zone used for tweet dates in fetched lists, filenames and embedded metadata</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Select
+              value={tempSettings.dateZone}
+              onValueChange={(value: DateZone) => setTempSettings((prev) => ({ ...prev, dateZone: value }))}
+            >
+              <SelectTrigger id="date-zone" className="w-auto">
+                <SelectValue placeholder="Date Time Zone" />
+              </SelectTrigger>
+              <SelectContent>
+                <SelectItem value="original">As Reported (UTC)</SelectItem>
+                <SelectItem value="local">Local Time</SelectItem>
+                <SelectItem value="utc">UTC (with offset)</SelectItem>
+              </SelectContent>
+            </Select>
+          </div>
+
+          {/* Filename Template */}
+          <div className="space-y-2">
+            <Label htmlFor="filename-template" className="flex items-center gap-2">
+              Filename Template
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Variables: {"{username}"}, {"{timestamp}"}, {"{date}"}, {"{tweet_id}"}, {"{index}"}, {"{num}"}, {"{type}"}, {"{sort_index}"}</p>
+                  <p className="mt-1 text-xs text-muted-foreground">{"{sort_index}"} is derived from the tweet ID and sorts files strictly by posting time, even within the same second. {"{index}"} ({"{num}"} without padding) is the position of the media in its tweet as posted</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <InputWithContext
+              id="filename-template"
+              value={tempSettings.filenameTemplate || ""}
+              onChange={(e) => setTempSettings((prev) => ({ ...prev, filenameTemplate: e.target.value }))}
+              placeholder="{username}_{timestamp}_{tweet_id}_{index}"
+              className="w-[90%]"
+            />
+          </div>
+
+          {/* Name Collisions */}
+          <div className="space-y-2">
+            <Label htmlFor="collision-suffix" className="flex items-center gap-2">
+              Name Collisions
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Suffix for files that would get a name already in use. A content hash gives the same names on every run, whatever the download order (useful for rsync backups)</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Select
+              value={tempSettings.collisionSuffix || "counter"}
+              onValueChange={(value: CollisionSuffix) => setTempSettings((prev) => ({ ...prev, collisionSuffix: value }))}
+            >
+              <SelectTrigger id="collision-suffix" className="w-auto">
+                <SelectValue placeholder="Name Collisions" />
+              </SelectTrigger>
+              <SelectContent>
+                <SelectItem value="counter">Counter (_2, _3, ...)</SelectItem>
+                <SelectItem value="hash">Content hash (_1a2b3c4d)</SelectItem>
+              </SelectContent>
+            </Select>
+          </div>
+
+          {/* Download Validation */}
+          <div className="flex items-center gap-3">
+            <Label htmlFor="validate-media" className="flex items-center gap-2 cursor-pointer text-sm">
+              Check Downloads for Corruption
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Probe each download with FFprobe; truncated or unreadable files are removed and counted as failed</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Switch
+              id="validate-media"
+              checked={tempSettings.validateMedia}
+              onCheckedChange={(checked) => setTempSettings((prev) => ({ ...prev, validateMedia: checked }))}
+            />
+          </div>
+
+          {/* Wayback Machine Fallback */}
+          <div className="flex items-center gap-3">
+            <Label htmlFor="wayback-fallback" className="flex items-center gap-2 cursor-pointer text-sm">
+              Recover Deleted Media from the Wayback Machine
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>When a file is gone (404), download the Internet Archive's copy if it has one</p>
+                  <p className="mt-1 text-xs text-muted-foreground">Files it doesn't have are listed in missing.csv</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Switch
+              id="wayback-fallback"
+              checked={tempSettings.waybackFallback}
+              onCheckedChange={(checked) => setTempSettings((prev) => ({ ...prev, waybackFallback: checked }))}
+            />
+          </div>
+
+          {/* Wayback Machine Saves */}
+          <div className="flex items-center gap-3">
+            <Label htmlFor="wayback-save" className="flex items-center gap-2 cursor-pointer text-sm">
+              Save Fetched Tweets to the Wayback Machine
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Submit every fetched tweet to web.archive.org/save for a public backup beside the local one</p>
+                  <p className="mt-1 text-xs text-muted-foreground">Runs in the background, one tweet every few seconds while the app is open</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Switch
+              id="wayback-save"
+              checked={tempSettings.waybackSave}
+              onCheckedChange={(checked) => setTempSettings((prev) => ({ ...prev, waybackSave: checked }))}
+            />
+          </div>
+
+          {/* Tweet Archive */}
+          <div className="flex items-center gap-3">
+            <Label htmlFor="tweets-jsonl" className="flex items-center gap-2 cursor-pointer text-sm">
+              Save Tweets with Media
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Write the text, date and counts of each downloaded tweet to tweets.jsonl in the account folder</p>
+                  <p className="mt-1 text-xs text-muted-foreground">No separate Text (No Media) fetch needed</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Switch
+              id="tweets-jsonl"
+              checked={tempSettings.tweetsJsonl}
+              onCheckedChange={(checked) => setTempSettings((prev) => ({ ...prev, tweetsJsonl: checked }))}
+            />
+          </div>
+
+          {/* Tweet Text Files */}
+          <div className="flex items-center gap-3">
+            <Label htmlFor="tweets-txt" className="flex items-center gap-2 cursor-pointer text-sm">
+              Save Tweet Text Files
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Write each downloaded tweet to tweets/&lt;tweet id&gt;.txt in the account folder</p>
+                  <p className="mt-1 text-xs text-muted-foreground">Text, date, counts, link and media of the tweet</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Switch
+              id="tweets-txt"
+              checked={tempSettings.tweetsTxt}
+              onCheckedChange={(checked) => setTempSettings((prev) => ({ ...prev, tweetsTxt: checked }))}
+            />
+          </div>
+
+          {/* Clipboard Watcher */}
+          <div className="flex items-center gap-3">
+            <Label htmlFor="watch-clipboard" className="flex items-center gap-2 cursor-pointer text-sm">
+              Watch Clipboard for Tweet Links
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Copied x.com/status links pop up with an Add button that queues them for multiple fetch</p>
+                  <p className="mt-1 text-xs text-muted-foreground">Each tweet is offered once per session</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Switch
+              id="watch-clipboard"
+              checked={tempSettings.watchClipboard}
+              onCheckedChange={(checked) => setTempSettings((prev) => ({ ...prev, watchClipboard: checked }))}
+            />
+          </div>
+
+          {/* Account Watcher */}
+          <div className="flex items-center gap-3">
+            <Label htmlFor="watch-accounts" className="flex items-center gap-2 cursor-pointer text-sm">
+              Watch Saved Accounts
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Checks saved accounts for suspensions, protection, renames and deleted tweets</p>
+                  <p className="mt-1 text-xs text-muted-foreground">Accounts at risk pop up with an Archive button. Uses the public auth token</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Switch
+              id="watch-accounts"
+              checked={tempSettings.watchAccounts}
+              onCheckedChange={(checked) => setTempSettings((prev) => ({ ...prev, watchAccounts: checked }))}
+            />
+            {tempSettings.watchAccounts && (
+              <div className="flex items-center gap-2 text-sm">
+                <span className="text-muted-foreground">every</span>
+                <InputWithContext
+                  id="watch-accounts-hours"
+                  type="number"
+                  min={1}
+                  className="w-20 h-8"
+                  value={tempSettings.watchAccountsHours || 24}
+                  onChange={(e) => {
+                    const value = parseInt(e.target.value, 10);
+                    setTempSettings((prev) => ({ ...prev, watchAccountsHours: isNaN(value) || value < 1 ? 1 : value }));
+                  }}
+                />
+                <span className="text-muted-foreground">hours</span>
+              </div>
+            )}
+          </div>
+
+          {/* Media Server */}
+          <div className="flex items-center gap-3">
+            <Label htmlFor="media-server" className="flex items-center gap-2 cursor-pointer text-sm">
+              Media Server
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Browse the download folder from a phone or TV on the same network</p>
+                  <p className="mt-1 text-xs text-muted-foreground">The link with its access key is shown when you save. Sensitive accounts stay hidden while locked</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Switch
+              id="media-server"
+              checked={tempSettings.mediaServer}
+              onCheckedChange={(checked) => setTempSettings((prev) => ({ ...prev, mediaServer: checked }))}
+            />
+            {tempSettings.mediaServer && (
+              <div className="flex items-center gap-2 text-sm">
+                <span className="text-muted-foreground">port</span>
+                <InputWithContext
+                  id="media-server-port"
+                  type="number"
+                  min={1024}
+                  max={65535}
+                  className="w-24 h-8"
+                  value={tempSettings.mediaServerPort || 8642}
+                  onChange={(e) => {
+                    const value = parseInt(e.target.value, 10);
+                    setTempSettings((prev) => ({ ...prev, mediaServerPort: isNaN(value) || value < 1 || value > 65535 ? 8642 : value }));
+                  }}
+                />
+              </div>
+            )}
+          </div>
+
+          {/* Controlled Folder Access Fallback */}
+          <div className="flex items-center gap-3">
+            <Label htmlFor="protected-fallback" className="flex items-center gap-2 cursor-pointer text-sm">
+              Fall Back to Downloads if Folder Is Protected
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Windows Controlled Folder Access can block writing to Pictures, Documents and Videos</p>
+                  <p className="mt-1 text-xs text-muted-foreground">When it does, save to your Downloads folder instead of failing</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Switch
+              id="protected-fallback"
+              checked={tempSettings.protectedFolderFallback}
+              onCheckedChange={(checked) => setTempSettings((prev) => ({ ...prev, protectedFolderFallback: checked }))}
+            />
+          </div>
+
+          {/* Video Previews */}
+          <div className="space-y-2">
+            <Label htmlFor="video-preview" className="flex items-center gap-2">
+              Video Previews
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Save a poster frame or a 3x3 contact sheet of each downloaded video in a .thumbs folder (requires FFmpeg)</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Select
+              value={tempSettings.videoPreview}
+              onValueChange={(value: VideoPreview) => setTempSettings((prev) => ({ ...prev, videoPreview: value }))}
+            >
+              <SelectTrigger id="video-preview" className="w-auto">
+                <SelectValue placeholder="Video Previews" />
+              </SelectTrigger>
+              <SelectContent>
+                <SelectItem value="off">Off</SelectItem>
+                <SelectItem value="poster">Poster frame</SelectItem>
+                <SelectItem value="contact_sheet">Contact sheet (3x3)</SelectItem>
+              </SelectContent>
+            </Select>
+          </div>
+
+          {/* Archive Output */}
+          <div className="space-y-2">
+            <Label htmlFor="archive-output" className="flex items-center gap-2">
+              Save As Archive
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Save one ZIP or tar archive per account (with a manifest.json) instead of loose files. Metadata is not embedded in archived files</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <Select
+              value={tempSettings.archiveOutput}
+              onValueChange={(value: ArchiveOutput) => setTempSettings((prev) => ({ ...prev, archiveOutput: value }))}
+            >
+              <SelectTrigger id="archive-output" className="w-auto">
+                <SelectValue placeholder="Save As Archive" />
+              </SelectTrigger>
+              <SelectContent>
+                <SelectItem value="off">Off (loose files)</SelectItem>
+                <SelectItem value="zip">ZIP</SelectItem>
+                <SelectItem value="tar">tar</SelectItem>
+              </SelectContent>
+            </Select>
+          </div>
+
+          {/* Archive Size Limit */}
+          <div className="space-y-2">
+            <Label htmlFor="archive-limit" className="flex items-center gap-2">
+              Archive Size Limit (GB)
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Maximum size of each account folder (0 = no limit). Pruned files are moved to the trash</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <div className="flex items-center gap-2">
+              <InputWithContext
+                id="archive-limit"
+                type="number"
+                step="1"
+                value={tempSettings.archiveLimitGB || 0}
+                onChange={(e) => {
+                  const value = parseFloat(e.target.value);
+                  setTempSettings((prev) => ({ ...prev, archiveLimitGB: isNaN(value) || value < 0 ? 0 : value }));
+                }}
+                placeholder="0"
+                className="w-[20%]"
+              />
+              <Select
+                value={tempSettings.archiveLimitPolicy}
+                onValueChange={(value: ArchiveCapPolicy) => setTempSettings((prev) => ({ ...prev, archiveLimitPolicy: value }))}
+              >
+                <SelectTrigger id="archive-limit-policy" className="w-auto">
+                  <SelectValue placeholder="When full" />
+                </SelectTrigger>
+                <SelectContent>
+                  <SelectItem value="stop">Stop downloading</SelectItem>
+                  <SelectItem value="prune_oldest">Remove oldest files</SelectItem>
+                  <SelectItem value="prune_engagement">Remove least popular files</SelectItem>
+                </SelectContent>
+              </Select>
+            </div>
+          </div>
+
+          {/* File Size Limit and Download Quota */}
+          <div className="space-y-2">
+            <Label htmlFor="max-file-mb" className="flex items-center gap-2">
+              Max File Size (MB) / Download Quota (GB)
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Skip files larger than the max file size, and stop a download once it saved the quota (0 = no limit)</p>
+                  <p>Files not downloaded because of the quota stay in the queue and can be resumed later</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <div className="flex items-center gap-2">
+              <InputWithContext
+                id="max-file-mb"
+                type="number"
+                step="10"
+                value={tempSettings.maxFileMB || 0}
+                onChange={(e) => {
+                  const value = parseFloat(e.target.value);
+                  setTempSettings((prev) => ({ ...prev, maxFileMB: isNaN(value) || value < 0 ? 0 : value }));
+                }}
+                placeholder="0"
+                className="w-[20%]"
+              />
+              <InputWithContext
+                id="max-job-gb"
+                type="number"
+                step="1"
+                value={tempSettings.maxJobGB || 0}
+                onChange={(e) => {
+                  const value = parseFloat(e.target.value);
+                  setTempSettings((prev) => ({ ...prev, maxJobGB: isNaN(value) || value < 0 ? 0 : value }));
+                }}
+                placeholder="0"
+                className="w-[20%]"
+              />
+            </div>
+          </div>
+
+          {/* Concurrency / Bandwidth */}
+          {backendSettings && (
+            <div className="space-y-2">
+              <Label htmlFor="max-concurrent-downloads" className="flex items-center gap-2">
+                Parallel Downloads / Bandwidth Limit (KB/s)
+                <Tooltip>
+                  <TooltipTrigger asChild>
+                    <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                  </TooltipTrigger>
+                  <TooltipContent side="top">
+                    <p>Files downloaded at the same time per download (1-32), and a speed limit shared by all downloads (0 = no limit)</p>
+                  </TooltipContent>
+                </Tooltip>
+              </Label>
+              <div className="flex items-center gap-2">
+                <InputWithContext
+                  id="max-concurrent-downloads"
+                  type="number"
+                  min="1"
+                  max="32"
+                  value={backendSettings.max_concurrent_downloads || 10}
+                  onChange={(e) => {
+                    const value = parseInt(e.target.value, 10);
+                    setBackendSettings((prev) => prev && new backend.Settings({ ...prev, max_concurrent_downloads: isNaN(value) ? 10 : Math.min(32, Math.max(1, value)) }));
+                  }}
+                  placeholder="10"
+                  className="w-[20%]"
+                />
+                <InputWithContext
+                  id="bandwidth-kbps"
+                  type="number"
+                  step="100"
+                  value={backendSettings.bandwidth_kbps || 0}
+                  onChange={(e) => {
+                    const value = parseInt(e.target.value, 10);
+                    setBackendSettings((prev) => prev && new backend.Settings({ ...prev, bandwidth_kbps: isNaN(value) || value < 0 ? 0 : value }));
+                  }}
+                  placeholder="0"
+                  className="w-[20%]"
+                />
+              </div>
+            </div>
+          )}
+
+          {/* Extractor Time Limits */}
+          {backendSettings && (
+            <div className="space-y-2">
+              <Label htmlFor="extractor-timeout" className="flex items-center gap-2">
+                Fetch Time Limit / Inactivity Limit (min)
+                <Tooltip>
+                  <TooltipTrigger asChild>
+                    <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                  </TooltipTrigger>
+                  <TooltipContent side="top">
+                    <p>Stop a fetch that runs longer than this, or that gets no response for this long (0 = no limit)</p>
+                    <p className="mt-1 text-xs text-muted-foreground">What was fetched so far is kept and can be resumed</p>
+                  </TooltipContent>
+                </Tooltip>
+              </Label>
+              <div className="flex items-center gap-2">
+                <InputWithContext
+                  id="extractor-timeout"
+                  type="number"
+                  min="0"
+                  value={backendSettings.extractor_timeout_minutes ?? 0}
+                  onChange={(e) => {
+                    const value = parseInt(e.target.value, 10);
+                    setBackendSettings((prev) => prev && new backend.Settings({ ...prev, extractor_timeout_minutes: isNaN(value) || value < 0 ? 0 : value }));
+                  }}
+                  placeholder="0"
+                  className="w-[20%]"
+                />
+                <InputWithContext
+                  id="extractor-idle"
+                  type="number"
+                  min="0"
+                  value={backendSettings.extractor_idle_minutes ?? 10}
+                  onChange={(e) => {
+                    const value = parseInt(e.target.value, 10);
+                    setBackendSettings((prev) => prev && new backend.Settings({ ...prev, extractor_idle_minutes: isNaN(value) || value < 0 ? 0 : value }));
+                  }}
+                  placeholder="10"
+                  className="w-[20%]"
+                />
+              </div>
+            </div>
+          )}
+
+          {/* Renamed Accounts */}
+          {backendSettings && (
+            <div className="space-y-2">
+              <Label htmlFor="renamed-folders" className="flex items-center gap-2">
+                Renamed Accounts
+                <Tooltip>
+                  <TooltipTrigger asChild>
+                    <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                  </TooltipTrigger>
+                  <TooltipContent side="top">
+                    <p>When a saved account changes its username, it keeps its archive and sync state under the new name</p>
+                    <p className="mt-1 text-xs text-muted-foreground">Choose what happens to its folder and to files named after the old username</p>
+                  </TooltipContent>
+                </Tooltip>
+              </Label>
+              <Select
+                value={backendSettings.renamed_folders || "rename"}
+                onValueChange={(value) => setBackendSettings((prev) => prev && new backend.Settings({ ...prev, renamed_folders: value }))}
+              >
+                <SelectTrigger id="renamed-folders">
+                  <SelectValue />
+                </SelectTrigger>
+                <SelectContent>
+                  <SelectItem value="rename">Rename folder and files</SelectItem>
+                  <SelectItem value="symlink">Rename and link the old folder name</SelectItem>
+                  <SelectItem value="keep">Keep the old folder</SelectItem>
+                </SelectContent>
+              </Select>
+            </div>
+          )}
+
+          {/* Network Audit Log */}
+          {backendSettings && (
+            <div className="flex items-center gap-3">
+              <Label htmlFor="audit-log" className="flex items-center gap-2 cursor-pointer text-sm">
+                Network Audit Log
+                <Tooltip>
+                  <TooltipTrigger asChild>
+                    <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                  </TooltipTrigger>
+                  <TooltipContent side="top">
+                    <p>Log every request the app makes (host, purpose and bytes) to audit.log in the data folder</p>
+                    <p className="mt-1 text-xs text-muted-foreground">Check that it only talks to X, its media servers, the tool mirrors and the services you set up</p>
+                  </TooltipContent>
+                </Tooltip>
+              </Label>
+              <Switch
+                id="audit-log"
+                checked={backendSettings.audit_log}
+                onCheckedChange={(checked) => setBackendSettings((prev) => prev && new backend.Settings({ ...prev, audit_log: checked }))}
+              />
+              <Button variant="outline" size="sm" className="h-9" onClick={handleShowAuditSummary}>
+                <ScrollText className="h-4 w-4" />
+                Summary
+              </Button>
+              <Button variant="ghost" size="sm" className="h-9" onClick={handleClearAuditLog}>
+                Clear
+              </Button>
+            </div>
+          )}
+
+          {/* Tool Paths */}
+          {backendSettings && (
+            <div className="space-y-2">
+              <Label htmlFor="ffmpeg-path" className="flex items-center gap-2">
+                FFmpeg / FFprobe / ExifTool Paths
+                <Tooltip>
+                  <TooltipTrigger asChild>
+                    <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                  </TooltipTrigger>
+                  <TooltipContent side="top">
+                    <p>Use these programs instead of the downloaded or system-installed ones (empty = automatic)</p>
+                  </TooltipContent>
+                </Tooltip>
+              </Label>
+              <div className="flex flex-col gap-2">
+                <InputWithContext
+                  id="ffmpeg-path"
+                  value={backendSettings.ffmpeg_path || ""}
+                  onChange={(e) => setBackendSettings((prev) => prev && new backend.Settings({ ...prev, ffmpeg_path: e.target.value }))}
+                  placeholder="ffmpeg (optional)"
+                  className="w-[90%]"
+                />
+                <InputWithContext
+                  id="ffprobe-path"
+                  value={backendSettings.ffprobe_path || ""}
+                  onChange={(e) => setBackendSettings((prev) => prev && new backend.Settings({ ...prev, ffprobe_path: e.target.value }))}
+                  placeholder="ffprobe (optional)"
+                  className="w-[90%]"
+                />
+                <InputWithContext
+                  id="exiftool-path"
+                  value={backendSettings.exiftool_path || ""}
+                  onChange={(e) => setBackendSettings((prev) => prev && new backend.Settings({ ...prev, exiftool_path: e.target.value }))}
+                  placeholder="exiftool (optional)"
+                  className="w-[90%]"
+                />
+              </div>
+            </div>
+          )}
+
+          {/* New Tweet Grace Period */}
+          <div className="space-y-2">
+            <Label htmlFor="grace-minutes" className="flex items-center gap-2">
+              New Tweet Grace Period (minutes)
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Tweets younger than this are downloaded last, with media re-fetched to get the final quality (0 = off)</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <InputWithContext
+              id="grace-minutes"
+              type="number"
+              min="0"
+              max="60"
+              value={tempSettings.graceMinutes || 0}
+              onChange={(e) => {
+                const value = parseInt(e.target.value, 10);
+                setTempSettings((prev) => ({ ...prev, graceMinutes: isNaN(value) || value < 0 ? 0 : Math.min(value, 60) }));
+              }}
+              placeholder="0"
+              className="w-[20%]"
+            />
+          </div>
+
+          {/* Content Lock */}
+          <div className="space-y-2">
+            <Label htmlFor="lock-passphrase" className="flex items-center gap-2">
+              Content Lock
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Accounts marked as sensitive are hidden and can't be fetched until unlocked with this passphrase. Leave the new passphrase empty to remove the lock</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <div className="flex items-center gap-2">
+              {lockEnabled && (
+                <InputWithContext
+                  id="lock-current"
+                  type="password"
+                  value={currentPassphrase}
+                  onChange={(e) => setCurrentPassphrase(e.target.value)}
+                  placeholder="Current passphrase"
+                  className="w-[30%]"
+                />
+              )}
+              <InputWithContext
+                id="lock-passphrase"
+                type="password"
+                value={newPassphrase}
+                onChange={(e) => setNewPassphrase(e.target.value)}
+                placeholder={lockEnabled ? "New passphrase" : "Passphrase"}
+                className="w-[30%]"
+              />
+              <Button variant="outline" onClick={handleSetPassphrase} disabled={!lockEnabled && !newPassphrase}>
+                {lockEnabled ? (newPassphrase ? "Change" : "Remove") : "Set"}
+              </Button>
+            </div>
+          </div>
+
+          {/* Encryption at Rest */}
+          {lockEnabled && (
+            <div className="space-y-2">
+              <Label htmlFor="encryption-passphrase" className="flex items-center gap-2">
+                Encrypt Sensitive Archives {encryptionEnabled && <span className="text-xs text-muted-foreground">(on)</span>}
+                <Tooltip>
+                  <TooltipTrigger asChild>
+                    <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                  </TooltipTrigger>
+                  <TooltipContent side="top">
+                    <p>Encrypts the downloads and stored timelines of accounts marked as sensitive with a key protected by the lock passphrase</p>
+                    <p className="mt-1 text-xs text-muted-foreground">Encrypted files end in .xenc. They stream through the media server while unlocked and can be decrypted from the account menu. Turning it off decrypts everything</p>
+                  </TooltipContent>
+                </Tooltip>
+              </Label>
+              <div className="flex items-center gap-2">
+                <InputWithContext
+                  id="encryption-passphrase"
+                  type="password"
+                  value={encryptionPassphrase}
+                  onChange={(e) => setEncryptionPassphrase(e.target.value)}
+                  placeholder="Lock passphrase"
+                  className="w-[30%]"
+                />
+                <Button variant="outline" onClick={handleToggleEncryption} disabled={!encryptionPassphrase || encryptionBusy}>
+                  {encryptionBusy ? <Spinner /> : null}
+                  {encryptionEnabled ? "Turn Off" : "Turn On"}
+                </Button>
+              </div>
+            </div>
+          )}
+
+          {/* Minimum Free Space */}
+          <div className="space-y-2">
+            <Label htmlFor="min-free" className="flex items-center gap-2">
+              Pause When Free Space Below (GB)
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Pause a running download when the download drive gets this full; the rest stays in the queue to resume later (0 = only when the drive is full)</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <InputWithContext
+              id="min-free"
+              type="number"
+              step="0.5"
+              min="0"
+              value={tempSettings.minFreeGB ?? 1}
+              onChange={(e) => {
+                const value = parseFloat(e.target.value);
+                setTempSettings((prev) => ({ ...prev, minFreeGB: isNaN(value) || value < 0 ? 0 : value }));
+              }}
+              placeholder="1"
+              className="w-[20%]"
+            />
+          </div>
+
+          {/* Large Download Confirmation */}
+          <div className="space-y-2">
+            <Label htmlFor="confirm-above" className="flex items-center gap-2">
+              Confirm Downloads Above (GB)
+              <Tooltip>
+                <TooltipTrigger asChild>
+                  <Info className="h-3.5 w-3.5 text-muted-foreground cursor-help" />
+                </TooltipTrigger>
+                <TooltipContent side="top">
+                  <p>Show what a download would add (new tweets, files, estimated size) and ask first when it's larger than this (0 = never)</p>
+                </TooltipContent>
+              </Tooltip>
+            </Label>
+            <InputWithContext
+              id="confirm-above"
+              type="number"
+              step="1"
+              min="0"
+              value={tempSettings.confirmAboveGB || 0}
+              onChange={(e) => {
+                const value = parseFloat(e.target.value);
+                setTempSettings((prev) => ({ ...prev, confirmAboveGB: isNaN(value) || value < 0 ? 0 : value }));
+              }}
+              placeholder="0"
+              className="w-[20%]"
+            />
+          </div>
+
+        </div>
+      </div>
+
+      {/* Actions */}
+      <div className="flex gap-2 justify-between pt-4 border-t">
+        <div className="flex gap-2">
+          <Button variant="outline" onClick={() => setShowResetConfirm(true)} className="gap-1.5">
+            <RotateCcw className="h-4 w-4" />
+            Reset to Default
+          </Button>
+          <Button variant="outline" onClick={handleRunDiagnostics} disabled={runningDiagnostics} className="gap-1.5">
+            {runningDiagnostics ? <Spinner /> : <Stethoscope className="h-4 w-4" />}
+            Diagnostics
+          </Button>
+          <Button variant="outline" onClick={handleExportDebugBundle} disabled={exportingBundle} className="gap-1.5">
+            {exportingBundle ? <Spinner /> : <Bug className="h-4 w-4" />}
+            Debug Bundle
+          </Button>
+        </div>
+        <Button onClick={handleSave} className="gap-1.5">
+          <Save className="h-4 w-4" />
+          Save Changes
+        </Button>
+      </div>
+
+      {/* Diagnostics Dialog */}
+      <Dialog open={diagnostics !== null} onOpenChange={(open) => !open && setDiagnostics(null)}>
+        <DialogContent className="max-w-2xl">
+          <DialogHeader>
+            <DialogTitle>Diagnostics</DialogTitle>
+            <DialogDescription>
+              {diagnostics ? `${diagnostics.os}/${diagnostics.arch} - ${diagnostics.output_dir}` : ""}
+            </DialogDescription>
+          </DialogHeader>
+          <div className="space-y-2 max-h-[60vh] overflow-y-auto">
+            {diagnostics?.checks.map((check, i) => (
+              <div key={i} className="flex items-start gap-2 text-sm">
+                {check.status === "ok" ? (
+                  <Check className="h-4 w-4 mt-0.5 text-green-600 dark:text-green-400 shrink-0" />
+                ) : check.status === "warning" ? (
+                  <TriangleAlert className="h-4 w-4 mt-0.5 text-yellow-600 dark:text-yellow-400 shrink-0" />
+                ) : (
+                  <X className="h-4 w-4 mt-0.5 text-destructive shrink-0" />
+                )}
+                <div className="min-w-0">
+                  <span className="font-medium">{check.name}</span>{" "}
+                  <span className="text-muted-foreground break-all">{check.detail}</span>
+                  {check.hint && <p className="text-xs text-muted-foreground">{check.hint}</p>}
+                </div>
+              </div>
+            ))}
+          </div>
+          <DialogFooter>
+            <Button variant="outline" onClick={handleCopyDiagnostics} className="gap-1.5">
+              <Copy className="h-4 w-4" />
+              Copy Report
+            </Button>
+            <Button onClick={() => setDiagnostics(null)}>Close</Button>
+          </DialogFooter>
+        </DialogContent>
+      </Dialog>
 
       {/* Reset Confirmation Dialog */}
       <Dialog open={showResetConfirm} onOpenChange={setShowResetConfirm}>
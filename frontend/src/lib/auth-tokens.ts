@@ -0,0 +1,89 @@
+import { GetSecret, SetSecret } from "../../wailsjs/go/main/App";
+
+/**
+ * Auth tokens are kept by the backend in the OS keychain (or an encrypted file) and cached here,
+ * so they can be read synchronously once loadAuthTokens has run
+ */
+export type AuthTokenKind = "public" | "private" | "restricted";
+
+const KINDS: AuthTokenKind[] = ["public", "private", "restricted"];
+
+// Where tokens were kept before they moved to the keychain
+const LEGACY_KEYS: Partial<Record<AuthTokenKind, string>> = {
+  public: "twitter_public_auth_token",
+  private: "twitter_private_auth_token",
+};
+
+const SAVE_DELAY_MS = 500;
+
+const tokens: Record<AuthTokenKind, string> = { public: "", private: "", restricted: "" };
+const pendingSaves: Partial<Record<AuthTokenKind, ReturnType<typeof setTimeout>>> = {};
+let loading: Promise<void> | null = null;
+
+function secretName(kind: AuthTokenKind): string {
+  return `${kind}_auth_token`;
+}
+
+/**
+ * Load the stored tokens once, moving tokens left in local storage by older versions
+ */
+export function loadAuthTokens(): Promise<void> {
+  if (!loading) {
+    loading = Promise.all(
+      KINDS.map(async (kind) => {
+        try {
+          tokens[kind] = await GetSecret(secretName(kind));
+        } catch (error) {
+          console.error(`Failed to load ${kind} auth token:`, error);
+        }
+        const legacyKey = LEGACY_KEYS[kind];
+        const legacy = legacyKey ? localStorage.getItem(legacyKey) : null;
+        if (legacyKey && legacy !== null) {
+          try {
+            if (!tokens[kind] && legacy) {
+              await SetSecret(secretName(kind), legacy);
+              tokens[kind] = legacy;
+            }
+            localStorage.removeItem(legacyKey);
+          } catch (error) {
+            // Keep using it from local storage until it can be moved
+            tokens[kind] = tokens[kind] || legacy;
+            console.error(`Failed to move ${kind} auth token to the keychain:`, error);
+          }
+        }
+      })
+    ).then(() => undefined);
+  }
+  return loading;
+}
+
+export function getAuthToken(kind: AuthTokenKind): string {
+  return tokens[kind];
+}
+
+/**
+ * Update a token, stored after typing pauses
+ */
+export function setAuthToken(kind: AuthTokenKind, value: string): void {
+  tokens[kind] = value;
+  clearTimeout(pendingSaves[kind]);
+  pendingSaves[kind] = setTimeout(() => {
+    delete pendingSaves[kind];
+    SetSecret(secretName(kind), tokens[kind]).catch((error) => console.error(`Failed to save ${kind} auth token:`, error));
+  }, SAVE_DELAY_MS);
+}
+
+/**
+ * Mask the stored tokens in text shown in logs
+ */
+export function scrubAuthTokens(text: string): string {
+  let result = text;
+  for (const kind of KINDS) {
+    for (const token of tokens[kind].split(/[\s,;]+/)) {
+      if (token.length >= 8) {
+        result = result.split(token).join("[redacted]");
+      }
+    }
+  }
+  return result;
+}
@@ -3,7 +3,7 @@
  * Manages resumable fetch state for large accounts
  */
 
-import type { TwitterResponse, TimelineEntry, AccountInfo } from "@/types/api";
+import type { TwitterResponse, TimelineEntry, AccountInfo, TimelineStats } from "@/types/api";
 
 const FETCH_STATE_KEY = "twitter_fetch_state";
 const CURSOR_STATE_KEY = "twitter_cursor_state"; // Lightweight cursor-only storage
@@ -235,3 +235,49 @@ export function mergeTimelines(
   const unique = newEntries.filter((e) => !seenIds.has(e.tweet_id));
   return [...existing, ...unique];
 }
+
+/**
+ * Add up the stats of two batches of a fetch (undefined if either is unknown)
+ */
+export function mergeTimelineStats(
+  a: TimelineStats | undefined,
+  b: TimelineStats | undefined
+): TimelineStats | undefined {
+  if (!a || !b) {
+    return undefined;
+  }
+  const earlier = (x?: string, y?: string) => (!x || (y && new Date(y) < new Date(x)) ? y : x);
+  const later = (x?: string, y?: string) => (!x || (y && new Date(y) > new Date(x)) ? y : x);
+  return {
+    photos: a.photos + b.photos,
+    videos: a.videos + b.videos,
+    gifs: a.gifs + b.gifs,
+    text: a.text + b.text,
+    estimated_bytes: a.estimated_bytes + b.estimated_bytes,
+    first_date: earlier(a.first_date, b.first_date),
+    last_date: later(a.last_date, b.last_date),
+  };
+}
+
+/**
+ * Percentage and remaining seconds of a fetch from the tweets received and the size the account
+ * reports (null while the size is unknown). The startTweets of a resumed fetch were received
+ * before it started, so they don't count towards the speed
+ */
+export function estimateFetchProgress(
+  tweets: number,
+  expected: number,
+  startTweets: number,
+  elapsedSeconds: number
+): { percent: number; etaSeconds: number | null } | null {
+  if (expected <= 0) {
+    return null;
+  }
+  // The account's counts include tweets that are never fetched (deleted, withheld, skipped retweets)
+  const percent = Math.min(99, Math.floor((tweets / expected) * 100));
+  const fetched = tweets - startTweets;
+  const etaSeconds = fetched > 0 && elapsedSeconds > 0
+    ? Math.max(0, Math.round(((expected - tweets) * elapsedSeconds) / fetched))
+    : null;
+  return { percent, etaSeconds };
+}
@@ -1,3 +1,5 @@
+import { scrubAuthTokens } from "./auth-tokens";
+
 export type LogLevel = "info" | "success" | "warning" | "error" | "debug";
 
 export interface LogEntry {
@@ -15,7 +17,7 @@ class Logger {
     const entry: LogEntry = {
       timestamp: new Date(),
       level,
-      message: message.toLowerCase(),
+      message: scrubAuthTokens(message).toLowerCase(),
     };
     this.logs.push(entry);
     if (this.logs.length > this.maxLogs) {
@@ -0,0 +1,71 @@
+import { GetMessageCatalog } from "../../wailsjs/go/main/App";
+import { getSettings, type MessageLanguage } from "@/lib/settings";
+import { parseRetryAt, formatRetryAt } from "@/lib/rate-limit";
+
+/**
+ * Backend errors keep their English text and carry a code: hints end in a
+ * "[hint=<code> key=value ...]" token and extractor errors start with "<code>: ".
+ * The translations come from the backend's message catalog.
+ */
+let catalog: Record<string, Record<string, string>> | null = null;
+
+/**
+ * Load the message catalog once, before the first localizeMessage call
+ */
+export async function loadMessageCatalog(): Promise<void> {
+  if (catalog) return;
+  try {
+    catalog = await GetMessageCatalog();
+  } catch (error) {
+    console.error("Failed to load message catalog:", error);
+  }
+}
+
+/**
+ * The catalog language for a setting, e.g. "zh-TW" -> "zh-CN", "es-MX" -> "es"
+ */
+function resolveLanguage(language: MessageLanguage): string | null {
+  if (!catalog) return null;
+  const wanted = language === "auto" ? navigator.language : language;
+  if (catalog[wanted]) return wanted;
+  const base = wanted.split("-")[0].toLowerCase();
+  return Object.keys(catalog).find((lang) => lang.split("-")[0].toLowerCase() === base) ?? null;
+}
+
+/**
+ * Fill in the {name} placeholders of a template
+ */
+function fillTemplate(template: string, params: Record<string, string>): string {
+  return template.replace(/\{(\w+)\}/g, (match, name) => params[name] ?? match);
+}
+
+/**
+ * Translate the hint and error code of a backend message to the language of the settings.
+ * Messages without a code, or in a language without translations, are returned as is.
+ */
+export function localizeMessage(message: string, language: MessageLanguage = getSettings().messageLanguage): string {
+  const lang = resolveLanguage(language);
+  if (!lang || lang === "en") return message;
+  const messages = catalog![lang];
+
+  let result = message;
+  const token = result.match(/\[hint=(\w+)((?: \w+=\S+?)*)\]/);
+  if (token && messages[token[1]]) {
+    const params: Record<string, string> = {};
+    for (const pair of token[2].trim().split(" ").filter(Boolean)) {
+      const [key, ...value] = pair.split("=");
+      params[key] = value.join("=");
+    }
+    const retryAt = parseRetryAt(result);
+    if (retryAt) params.time = formatRetryAt(retryAt);
+    result = result.replace(/\[Hint: [^\]]*\]/, `[${fillTemplate(messages[token[1]], params)}]`);
+  }
+
+  // Only the English text after the code is replaced, details like the raw output stay
+  const prefix = result.match(/^(\w+): /);
+  const english = prefix ? catalog!.en?.[prefix[1]] : undefined;
+  if (prefix && english && messages[prefix[1]] && result.startsWith(prefix[0] + english)) {
+    result = messages[prefix[1]] + result.slice(prefix[0].length + english.length);
+  }
+  return result;
+}
@@ -0,0 +1,27 @@
+/**
+ * Extractor errors caused by a rate limit carry the reset time as a
+ * "retry_at=<RFC 3339>" token, e.g. "... [Hint: Rate limited - retry at 15:04] [retry_at=2024-05-01T13:04:00Z]"
+ */
+export function parseRetryAt(message: string | undefined): Date | null {
+  if (!message) return null;
+  const match = message.match(/retry_at=([0-9T:+\-Z]+)/);
+  if (!match) return null;
+  const date = new Date(match[1]);
+  return isNaN(date.getTime()) ? null : date;
+}
+
+/**
+ * Format a retry time as local HH:MM
+ */
+export function formatRetryAt(date: Date): string {
+  return date.toLocaleTimeString([], { hour: "2-digit", minute: "2-digit" });
+}
+
+/**
+ * Wait until the given time, checking shouldStop every second
+ */
+export async function waitUntil(date: Date, shouldStop: () => boolean): Promise<void> {
+  while (Date.now() < date.getTime() && !shouldStop()) {
+    await new Promise((resolve) => setTimeout(resolve, Math.min(1000, date.getTime() - Date.now())));
+  }
+}
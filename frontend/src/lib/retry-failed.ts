@@ -0,0 +1,31 @@
+import { RetryFailed } from "../../wailsjs/go/main/App";
+import { toastWithSound as toast } from "@/lib/toast-with-sound";
+
+/**
+ * Toast action that downloads the failed files of a job again.
+ * Files the server refused (403 and other client errors) aren't retried.
+ */
+export function retryFailedAction(jobID: string) {
+  return {
+    label: "Retry failed",
+    onClick: async () => {
+      try {
+        const response = await RetryFailed(jobID);
+        const excluded = response.excluded > 0 ? ` (${response.excluded} refused by the server, not retried)` : "";
+        if (!response.success) {
+          toast.error(response.message || "Retry failed");
+        } else if (response.downloaded === 0 && response.failed === 0 && response.skipped === 0) {
+          toast.info(`Nothing to retry${excluded}`);
+        } else if (response.failed > 0) {
+          toast.warning(`Retried: ${response.downloaded} downloaded, ${response.failed} failed again${excluded}`, {
+            action: retryFailedAction(jobID),
+          });
+        } else {
+          toast.success(`Retried: ${response.downloaded} downloaded${excluded}`);
+        }
+      } catch (error) {
+        toast.error(`Retry failed: ${error}`);
+      }
+    },
+  };
+}
@@ -1,10 +1,22 @@
 import { GetDefaults } from "../../wailsjs/go/main/App";
+import { getAuthToken, setAuthToken } from "./auth-tokens";
 
 export type FontFamily = "google-sans" | "inter" | "poppins" | "roboto" | "dm-sans" | "plus-jakarta-sans" | "manrope" | "space-grotesk" | "noto-sans" | "nunito-sans" | "figtree" | "raleway" | "public-sans" | "outfit" | "jetbrains-mono" | "geist-sans";
 export type GifQuality = "fast" | "better";
 export type GifResolution = "original" | "high" | "medium" | "low";
 export type FetchMode = "single" | "batch";
 export type MediaType = "all" | "image" | "video" | "gif" | "text";
+export type TimelineType = "timeline" | "media" | "tweets" | "with_replies" | "highlights";
+export type Orientation = "all" | "portrait" | "landscape" | "square";
+export type ConflictPolicy = "skip" | "overwrite" | "keep_both" | "ask";
+export type ArchiveCapPolicy = "stop" | "prune_oldest" | "prune_engagement";
+export type VideoPreview = "off" | "poster" | "contact_sheet";
+export type OutputTarget = "local" | "sftp" | "s3" | "webdav";
+export type WebPConversion = "off" | "jpg" | "png";
+export type DateZone = "original" | "local" | "utc";
+export type CollisionSuffix = "counter" | "hash";
+export type ArchiveOutput = "off" | "zip" | "tar";
+export type MessageLanguage = "auto" | "en" | "zh-CN" | "ja" | "es";
 
 export interface Settings {
   downloadPath: string;
@@ -12,13 +24,66 @@ export interface Settings {
   themeMode: "auto" | "light" | "dark";
   fontFamily: FontFamily;
   sfxEnabled: boolean;
+  notificationsEnabled: boolean; // Show desktop notification when a download batch completes or fails. Default: true.
   gifQuality: GifQuality;
   gifResolution: GifResolution;
+  gifWorkers: number; // GIF conversions run at once, 0 = one per CPU core. Default: 0.
   proxy: string; // Proxy URL (e.g., http://proxy:port or socks5://proxy:port). Empty to use system proxy or no proxy.
+  restrictedAuthToken: string; // Auth token of an age-verified account, used to fetch age-restricted tweets again. Empty = report them only.
+  toolMirror: string; // Base URL of a mirror holding the FFmpeg/ExifTool archives, tried first. Empty = built-in mirrors only.
   fetchTimeout: number; // Fetch timeout in seconds. Default: 60 seconds.
   fetchMode: FetchMode; // Fetch mode: single (all at once) or batch (200 per request). Default: batch.
   mediaType: MediaType; // Media type filter. Default: all.
+  timelineType: TimelineType; // Timeline fetched for public accounts (highlights need X Premium). Default: timeline.
   includeRetweets: boolean; // Include retweets in fetch. Default: false.
+  orientation: Orientation; // Only download media with this orientation. Default: all.
+  minAspectRatio: number; // Minimum aspect ratio (long side / short side), 0 = no limit. Default: 0.
+  conflictPolicy: ConflictPolicy; // What to do when an existing file differs from the downloaded one. Default: skip.
+  archiveLimitGB: number; // Maximum size of an account archive in GB, 0 = no limit. Default: 0.
+  archiveLimitPolicy: ArchiveCapPolicy; // What to do when an account archive exceeds the limit. Default: stop.
+  maxFileMB: number; // Skip files larger than this in MB, 0 = no limit. Default: 0.
+  maxJobGB: number; // Stop a download after saving this much in GB (the rest stays queued), 0 = no limit. Default: 0.
+  archiveOutput: ArchiveOutput; // Save one ZIP or tar archive per account instead of loose files. Default: off.
+  newestFirst: boolean; // Download the newest media first (most likely to be deleted soon), then older items. Default: true.
+  graceMinutes: number; // Tweets younger than this are downloaded last with freshly resolved media URLs, 0 = off. Default: 0.
+  minFreeGB: number; // Pause downloads when the download drive has less free space than this in GB, 0 = only when full. Default: 1.
+  confirmAboveGB: number; // Ask for confirmation when a download is estimated above this size in GB, 0 = never. Default: 0.
+  videoPreview: VideoPreview; // Save a poster frame or contact sheet of each downloaded video in a .thumbs folder. Default: off.
+  convertWebP: WebPConversion; // Convert WebP images to JPEG/PNG after download (needs FFmpeg). Default: off.
+  webpQuality: number; // JPEG quality (1-100) for converted WebP images. Default: 90.
+  validateMedia: boolean; // Check downloads with ffprobe and count corrupt files as failed. Default: false.
+  waybackFallback: boolean; // Download deleted media (404) from the Wayback Machine if archived. Default: false.
+  waybackSave: boolean; // Submit fetched tweets to the Wayback Machine in the background. Default: false.
+  tweetsJsonl: boolean; // Also save the tweets of downloaded media to tweets.jsonl in each account folder. Default: false.
+  tweetsTxt: boolean; // Also save each tweet of downloaded media to a .txt file in the tweets folder of its account. Default: false.
+  messageLanguage: MessageLanguage; // Language of error hints from the backend (auto = system language, English if there's no translation). Default: auto.
+  dateZone: DateZone; // Time zone of fetched dates, filenames and embedded dates (original = as reported, UTC). Default: original.
+  protectedFolderFallback: boolean; // Download to the Downloads folder if Windows Controlled Folder Access blocks the download folder. Default: true.
+  filenameTemplate: string; // File name without extension, e.g. {sort_index}_{index}. Empty = {username}_{timestamp}_{tweet_id}_{index}.
+  collisionSuffix: CollisionSuffix; // Suffix for files that would take a used name: counter (_2, _3) or hash (short content hash, same on every run). Default: counter.
+  outputTarget: OutputTarget; // Save downloads locally or upload them to an SFTP server, S3 bucket or WebDAV folder. Default: local.
+  sftpHost: string; // SFTP server host name or ~/.ssh/config alias
+  sftpPort: number; // SFTP port, 0 = 22 or the port from ~/.ssh/config
+  sftpUser: string; // SFTP user, empty = from ~/.ssh/config or the local user
+  sftpKeyFile: string; // Private key file, empty = SSH agent or default keys
+  sftpRemoteDir: string; // Remote folder that mirrors the download folder
+  s3Endpoint: string; // S3-compatible endpoint URL, empty = AWS
+  s3Region: string; // Bucket region, empty = us-east-1
+  s3Bucket: string; // Bucket name
+  s3AccessKey: string; // Access key ID
+  s3SecretKey: string; // Secret access key
+  s3Prefix: string; // Key prefix that mirrors the download folder
+  s3PathStyle: boolean; // Path-style bucket URLs (MinIO and most self-hosted servers)
+  webdavUrl: string; // WebDAV folder URL that mirrors the download folder
+  webdavUser: string; // WebDAV user
+  webdavPassword: string; // WebDAV password or app password
+  fileHook: string; // Shell command run for every downloaded file (details in TXMBD_* variables). Default: none.
+  batchHook: string; // Shell command run once a download job ends. Default: none.
+  watchClipboard: boolean; // Offer tweet links copied to the clipboard for the download queue. Default: false.
+  watchAccounts: boolean; // Check saved accounts for suspensions, protection, renames and deletions. Default: false.
+  watchAccountsHours: number; // Hours between account watcher checks. Default: 24.
+  mediaServer: boolean; // Serve the download folder on the LAN for browsing from a phone or TV. Default: false.
+  mediaServerPort: number; // Port of the media server. Default: 8642.
 }
 
 export const DEFAULT_SETTINGS: Settings = {
@@ -27,15 +92,110 @@ export const DEFAULT_SETTINGS: Settings = {
   themeMode: "auto",
   fontFamily: "google-sans",
   sfxEnabled: true,
+  notificationsEnabled: true,
   gifQuality: "fast",
   gifResolution: "original",
+  gifWorkers: 0, // Default: one per CPU core
   proxy: "",
+  restrictedAuthToken: "", // Default: only report age-restricted tweets
+  toolMirror: "", // Default: built-in mirrors only
   fetchTimeout: 60, // Default: 60 seconds
   fetchMode: "batch", // Default: batch mode (200 per request)
   mediaType: "all", // Default: all media
+  timelineType: "timeline", // Default: full timeline
   includeRetweets: false, // Default: don't include retweets
+  orientation: "all", // Default: any orientation
+  minAspectRatio: 0, // Default: no aspect ratio limit
+  conflictPolicy: "skip", // Default: keep existing files
+  archiveLimitGB: 0, // Default: no archive size limit
+  archiveLimitPolicy: "stop", // Default: stop downloading when the limit is reached
+  maxFileMB: 0, // Default: no file size limit
+  maxJobGB: 0, // Default: no download quota
+  archiveOutput: "off", // Default: loose files
+  newestFirst: true, // Default: newest media first
+  graceMinutes: 0, // Default: no grace period
+  confirmAboveGB: 0, // Default: never ask
+  minFreeGB: 1, // Default: keep 1 GB free
+  videoPreview: "off", // Default: no video previews
+  convertWebP: "off", // Default: keep WebP images
+  webpQuality: 90, // Default: high quality
+  validateMedia: false, // Default: don't probe downloads
+  waybackFallback: false, // Default: list deleted media in missing.csv only
+  waybackSave: false, // Default: local archive only
+  tweetsJsonl: false, // Default: media only
+  tweetsTxt: false, // Default: media only
+  messageLanguage: "auto", // Default: system language
+  dateZone: "original", // Default: dates as reported by the extractor
+  protectedFolderFallback: true, // Default: keep downloading, to Downloads
+  filenameTemplate: "", // Default: {username}_{timestamp}_{tweet_id}_{index}
+  collisionSuffix: "counter", // Default: _2, _3, ...
+  outputTarget: "local", // Default: save locally
+  sftpHost: "",
+  sftpPort: 0,
+  sftpUser: "",
+  sftpKeyFile: "",
+  sftpRemoteDir: "",
+  s3Endpoint: "",
+  s3Region: "",
+  s3Bucket: "",
+  s3AccessKey: "",
+  s3SecretKey: "",
+  s3Prefix: "",
+  s3PathStyle: false,
+  webdavUrl: "",
+  webdavUser: "",
+  webdavPassword: "",
+  fileHook: "",
+  batchHook: "",
+  watchClipboard: false,
+  watchAccounts: false,
+  watchAccountsHours: 24,
+  mediaServer: false,
+  mediaServerPort: 8642,
 };
 
+// getSFTPTarget returns the SFTP target for download requests, or undefined to save locally
+export function getSFTPTarget(settings: Settings) {
+  if (settings.outputTarget !== "sftp" || !settings.sftpHost) {
+    return undefined;
+  }
+  return {
+    host: settings.sftpHost,
+    port: settings.sftpPort || 0,
+    user: settings.sftpUser,
+    identity_file: settings.sftpKeyFile,
+    remote_dir: settings.sftpRemoteDir,
+  };
+}
+
+// getS3Target returns the S3 bucket for download requests, or undefined to save elsewhere
+export function getS3Target(settings: Settings) {
+  if (settings.outputTarget !== "s3" || !settings.s3Bucket) {
+    return undefined;
+  }
+  return {
+    endpoint: settings.s3Endpoint,
+    region: settings.s3Region,
+    bucket: settings.s3Bucket,
+    access_key: settings.s3AccessKey,
+    secret_key: settings.s3SecretKey,
+    prefix: settings.s3Prefix,
+    path_style: settings.s3PathStyle,
+  };
+}
+
+// getWebDAVTarget returns the WebDAV folder for download requests, or undefined to save elsewhere
+export function getWebDAVTarget(settings: Settings) {
+  if (settings.outputTarget !== "webdav" || !settings.webdavUrl) {
+    return undefined;
+  }
+  return {
+    url: settings.webdavUrl,
+    user: settings.webdavUser,
+    password: settings.webdavPassword,
+  };
+}
+
 export const FONT_OPTIONS: { value: FontFamily; label: string; fontFamily: string }[] = [
   { value: "dm-sans", label: "DM Sans", fontFamily: '"DM Sans", system-ui, sans-serif' },
   { value: "figtree", label: "Figtree", fontFamily: '"Figtree", system-ui, sans-serif' },
@@ -80,12 +240,13 @@ export function getSettings(): Settings {
     const stored = localStorage.getItem(SETTINGS_KEY);
     if (stored) {
       const parsed = JSON.parse(stored);
-      return { ...DEFAULT_SETTINGS, ...parsed };
+      // The restricted token is kept with the other auth tokens, older versions stored it here
+      return { ...DEFAULT_SETTINGS, ...parsed, restrictedAuthToken: getAuthToken("restricted") || parsed.restrictedAuthToken || "" };
     }
   } catch (error) {
     console.error("Failed to load settings:", error);
   }
-  return DEFAULT_SETTINGS;
+  return { ...DEFAULT_SETTINGS, restrictedAuthToken: getAuthToken("restricted") };
 }
 
 export async function getSettingsWithDefaults(): Promise<Settings> {
@@ -100,8 +261,11 @@ export async function getSettingsWithDefaults(): Promise<Settings> {
 }
 
 export function saveSettings(settings: Settings): void {
+  if (settings.restrictedAuthToken !== getAuthToken("restricted")) {
+    setAuthToken("restricted", settings.restrictedAuthToken);
+  }
   try {
-    localStorage.setItem(SETTINGS_KEY, JSON.stringify(settings));
+    localStorage.setItem(SETTINGS_KEY, JSON.stringify({ ...settings, restrictedAuthToken: "" }));
   } catch (error) {
     console.error("Failed to save settings:", error);
   }
@@ -138,3 +302,8 @@ export function applyThemeMode(mode: "auto" | "light" | "dark"): void {
     document.documentElement.classList.remove("dark");
   }
 }
+
+// getDateZone returns the date zone to send to the backend ("" = as reported)
+export function getDateZone(settings: Settings): string {
+  return settings.dateZone === "original" ? "" : settings.dateZone;
+}
@@ -6,8 +6,11 @@ export interface AccountInfo {
   date: string;
   followers_count: number;
   friends_count: number;
-  profile_image: string;
+  profile_image: string;  // Small avatar (48x48)
   statuses_count: number;
+  profile_image_original?: string;  // Avatar at its original size
+  profile_banner?: string;
+  profile_banner_original?: string; // Banner at its original size
 }
 
 export interface TimelineEntry {
@@ -16,6 +19,9 @@ export interface TimelineEntry {
   tweet_id: string;
   type: string; // photo, video, gif, text
   is_retweet: boolean;
+  quote_id?: string;        // Tweet quoted by this one
+  reply_id?: string;        // Tweet this one replies to
+  conversation_id?: string; // First tweet of the thread
   extension: string;
   width: number;
   height: number;
@@ -29,6 +35,39 @@ export interface TimelineEntry {
   verified?: boolean;
   original_filename?: string; // Original filename from API
   author_username?: string; // Username of tweet author (for bookmarks and likes)
+  estimated_size?: number; // Approximate file size in bytes
+  media_index?: number;    // 1-based position of the media in its tweet
+}
+
+// Timeline grouped by tweet (requested with grouped: true)
+export interface TimelineTweet {
+  tweet_id: string;
+  date: string;
+  is_retweet: boolean;
+  quote_id?: string;
+  reply_id?: string;
+  conversation_id?: string;
+  content?: string;
+  view_count?: number;
+  bookmark_count?: number;
+  favorite_count?: number;
+  retweet_count?: number;
+  reply_count?: number;
+  source?: string;
+  verified?: boolean;
+  author_username?: string;
+  media: TweetMedia[]; // Empty for text tweets
+}
+
+export interface TweetMedia {
+  index: number; // 1-based position in the tweet
+  url: string;
+  type: string;
+  extension: string;
+  width: number;
+  height: number;
+  original_filename?: string;
+  estimated_size?: number;
 }
 
 export interface ExtractMetadata {
@@ -38,6 +77,17 @@ export interface ExtractMetadata {
   has_more: boolean;
   cursor?: string;      // Cursor for resume capability
   completed?: boolean;  // True if all media fetched
+  stats?: TimelineStats; // Breakdown of the timeline by media type
+}
+
+export interface TimelineStats {
+  photos: number;
+  videos: number;
+  gifs: number;
+  text: number;
+  estimated_bytes: number; // Approximate size of the media files
+  first_date?: string;     // Date of the oldest entry
+  last_date?: string;      // Date of the newest entry
 }
 
 export interface TwitterResponse {
@@ -47,17 +97,62 @@ export interface TwitterResponse {
   metadata: ExtractMetadata;
   cursor?: string;      // Cursor for next fetch (from CLI)
   completed?: boolean;  // True if fetch completed
+  partial?: boolean;    // True if the extractor failed mid-run (results so far are kept)
+  error?: string;       // Extractor error for partial results
+  retry_at?: string;    // RFC 3339 time the rate limit resets, if the partial result was rate limited
+  restricted?: number;  // Sensitive tweets that came back without media (age-restricted)
+  restricted_recovered?: number; // Of those, fetched with the age-restricted auth token
+  restricted_tweet_ids?: string[]; // Still without media
+  tweets?: TimelineTweet[]; // Timeline grouped by tweet, instead of timeline
+}
+
+// Progress of a running extraction ("extract-progress" events)
+export interface ExtractProgress {
+  username: string;
+  pages: number;           // Pages (API requests) fetched
+  items: number;           // Media and tweets received
+  media: number;
+  tweets: number;
+  expected: number;        // Tweets the timeline holds according to the account's counts, 0 = unknown
+  cursor?: string;         // Cursor to resume from if the fetch is stopped now
+  rate_budget: number;     // Requests allowed per rate limit window
+  rate_remaining: number;  // Estimated requests left in the current window
+  rate_reset_at?: string;  // RFC 3339
+  elapsed_seconds: number;
+  done: boolean;
+}
+
+// Outcome of one account of ExtractTimelines ("timelines-progress" events carry it without response)
+export interface TimelinesResult {
+  index: number;           // Position of the request
+  username: string;
+  fetched: number;         // Entries fetched so far
+  batches: number;
+  completed: boolean;      // Fetched to the end
+  pending?: boolean;       // Not finished when the run stopped, resume from cursor
+  cursor?: string;
+  error?: string;
+  response?: TwitterResponse;
+}
+
+export interface TimelinesResponse {
+  results: TimelinesResult[];
+  completed: number;
+  failed: number;
+  pending: number;
+  retry_at?: string;       // Set if the run stopped at a rate limit
 }
 
 export interface TimelineRequest {
   username: string;
   auth_token: string;
-  timeline_type: string; // media, timeline, tweets, with_replies, likes
+  timeline_type: string; // media, timeline, tweets, with_replies, highlights, likes
   batch_size: number;
   page: number;
   media_type: string; // all, image, video, gif
   retweets: boolean;
   cursor?: string; // Resume from this cursor position
+  grouped?: boolean; // Return tweets instead of timeline
 }
 
 export interface DateRangeRequest {
@@ -67,6 +162,7 @@ export interface DateRangeRequest {
   end_date: string; // YYYY-MM-DD
   media_filter: string;
   retweets: boolean;
+  grouped?: boolean; // Return tweets instead of timeline
 }
 
 // Settings types
@@ -3,64 +3,262 @@
 import {main} from '../models';
 import {backend} from '../models';
 
+export function ArchiveFollowing(arg1:main.ArchiveFollowingRequest):Promise<backend.SyncAllReport>;
+
+export function BackfillMetadata(arg1:string,arg2:string):Promise<backend.BackfillMetadataResult>;
+
+export function BatchImport():Promise<backend.BatchImportResult>;
+
+export function CheckAccount(arg1:string,arg2:string):Promise<backend.AccountWatchResult>;
+
 export function CheckFolderExists(arg1:string,arg2:string):Promise<boolean>;
 
 export function CheckGifsFolderExists(arg1:string,arg2:string):Promise<boolean>;
 
 export function CheckGifsFolderHasMP4(arg1:string,arg2:string):Promise<boolean>;
 
+export function CheckToolUpdates(arg1:string):Promise<Array<backend.ToolUpdate>>;
+
 export function CleanupExtractorProcesses():Promise<void>;
 
 export function ClearAllAccountsFromDB():Promise<void>;
 
+export function ClearAuditLog():Promise<void>;
+
+export function ClearGalleryCache():Promise<void>;
+
+export function CompareArchives(arg1:string,arg2:string,arg3:string):Promise<backend.ArchiveDiff>;
+
+export function ConfirmDownload(arg1:string,arg2:boolean):Promise<boolean>;
+
+export function ConvertFilesToGIF(arg1:main.ConvertFilesToGIFRequest):Promise<main.ConvertGIFsResponse>;
+
 export function ConvertGIFs(arg1:main.ConvertGIFsRequest):Promise<main.ConvertGIFsResponse>;
 
+export function ConvertVideos(arg1:main.ConvertVideosRequest):Promise<main.ConvertVideosResponse>;
+
+export function ConvertWebPImages(arg1:main.ConvertWebPImagesRequest):Promise<main.ConvertGIFsResponse>;
+
+export function DecryptArchive(arg1:string):Promise<backend.EncryptionResult>;
+
 export function DeleteAccountFromDB(arg1:number):Promise<void>;
 
-export function DownloadExifTool():Promise<void>;
+export function DeleteCollection(arg1:string):Promise<void>;
 
-export function DownloadFFmpeg():Promise<void>;
+export function DeleteQueue(arg1:string):Promise<void>;
+
+export function Diagnostics(arg1:string,arg2:string):Promise<backend.DiagnosticsReport>;
+
+export function DisableEncryption(arg1:string,arg2:string):Promise<Array<backend.EncryptionResult>>;
+
+export function DownloadExifTool(arg1:string):Promise<void>;
+
+export function DownloadFFmpeg(arg1:string):Promise<void>;
 
 export function DownloadMedia(arg1:main.DownloadMediaRequest):Promise<main.DownloadMediaResponse>;
 
 export function DownloadMediaWithMetadata(arg1:main.DownloadMediaWithMetadataRequest):Promise<main.DownloadMediaResponse>;
 
+export function DownloadSelection(arg1:Array<backend.TimelineEntry>,arg2:main.DownloadMediaWithMetadataRequest):Promise<main.DownloadMediaResponse>;
+
+export function DryRunDownload(arg1:main.DownloadMediaWithMetadataRequest):Promise<backend.SyncDiff>;
+
+export function EnableEncryption(arg1:string):Promise<void>;
+
+export function EncryptArchive(arg1:string):Promise<backend.EncryptionResult>;
+
+export function EstimateJob(arg1:backend.JobEstimateRequest):Promise<backend.JobEstimate>;
+
+export function EvaluateCollection(arg1:string):Promise<backend.CollectionResult>;
+
 export function ExportAccountJSON(arg1:number,arg2:string):Promise<string>;
 
 export function ExportAccountsTXT(arg1:Array<number>,arg2:string):Promise<string>;
 
+export function ExportDebugBundle(arg1:backend.DebugBundleRequest):Promise<string>;
+
+export function ExportHTMLGallery(arg1:string):Promise<backend.HTMLGalleryResult>;
+
+export function ExportHydrus(arg1:string,arg2:string):Promise<backend.HydrusExportResult>;
+
+export function ExportLabeledMedia(arg1:backend.LabelFilter,arg2:string,arg3:string):Promise<backend.LabelExportResult>;
+
+export function ExportMarkdownNotes(arg1:string,arg2:string):Promise<backend.MarkdownExportResult>;
+
 export function ExtractDateRange(arg1:main.DateRangeRequest):Promise<string>;
 
+export function ExtractParallel(arg1:main.ParallelRequest):Promise<string>;
+
 export function ExtractTimeline(arg1:main.TimelineRequest):Promise<string>;
 
+export function ExtractTimelines(arg1:Array<main.TimelineRequest>):Promise<string>;
+
+export function FetchBatchEntry(arg1:backend.BatchImportEntry,arg2:string,arg3:string):Promise<backend.BatchFetchResult>;
+
+export function FindLabeledMedia(arg1:backend.LabelFilter):Promise<Array<backend.MediaLabels>>;
+
+export function GenerateVideoPreviews(arg1:main.GenerateVideoPreviewsRequest):Promise<main.GenerateVideoPreviewsResponse>;
+
 export function GetAccountFromDB(arg1:number):Promise<string>;
 
+export function GetAccountRenames():Promise<Array<backend.AccountRename>>;
+
 export function GetAllAccountsFromDB():Promise<Array<backend.AccountListItem>>;
 
 export function GetAllGroups():Promise<Array<Record<string, string>>>;
 
+export function GetAuditLogPath():Promise<string>;
+
+export function GetAuditSummary():Promise<Array<backend.AuditHostSummary>>;
+
+export function GetDataDir():Promise<backend.DataDirInfo>;
+
 export function GetDefaults():Promise<Record<string, string>>;
 
+export function GetEncryptionStatus():Promise<backend.EncryptionStatus>;
+
+export function GetExifToolStatus():Promise<backend.ExifToolStatus>;
+
 export function GetFolderPath(arg1:string,arg2:string):Promise<string>;
 
+export function GetGalleryCacheStats():Promise<backend.GalleryCacheStats>;
+
 export function GetGifsFolderPath(arg1:string,arg2:string):Promise<string>;
 
+export function GetJobHistory(arg1:number):Promise<Array<backend.JobRecord>>;
+
+export function GetLockStatus():Promise<backend.LockStatus>;
+
+export function GetMediaLabels(arg1:string):Promise<Array<backend.MediaLabels>>;
+
+export function GetMessageCatalog():Promise<Record<string, Record<string, string>>>;
+
+export function GetQueueItems(arg1:string,arg2:number,arg3:number):Promise<backend.QueuePage>;
+
+export function GetQueuePath(arg1:string):Promise<string>;
+
+export function GetRecoveredMedia(arg1:string):Promise<Array<backend.RecoveredMedia>>;
+
+export function GetSecret(arg1:string):Promise<string>;
+
+export function GetSecretStorage():Promise<string>;
+
+export function GetSettings():Promise<backend.Settings>;
+
+export function GetStats():Promise<backend.JobStats>;
+
+export function GetThumbnail(arg1:string,arg2:number):Promise<string>;
+
+export function GetTimelineTypes():Promise<Array<backend.TimelineTypeInfo>>;
+
+export function GetWaybackSaveStatus():Promise<backend.WaybackSaveStatus>;
+
 export function ImportAccountFromJSON():Promise<main.ImportAccountResponse>;
 
+export function ImportTool(arg1:string):Promise<main.ImportToolResponse>;
+
+export function ImportTwitterArchive(arg1:string):Promise<backend.TwitterArchiveImport>;
+
 export function IsExifToolInstalled():Promise<boolean>;
 
 export function IsFFmpegInstalled():Promise<boolean>;
 
+export function IsFFprobeInstalled():Promise<boolean>;
+
+export function ListCollections():Promise<Array<backend.SmartCollection>>;
+
+export function ListGallery(arg1:string,arg2:number,arg3:number):Promise<backend.GalleryPage>;
+
+export function ListMediaTags(arg1:string):Promise<Array<backend.TagCount>>;
+
+export function ListQueues():Promise<Array<backend.QueueJob>>;
+
+export function ListReorganizeJournals():Promise<Array<backend.ReorganizeJournal>>;
+
+export function LockContent():Promise<void>;
+
+export function MergeArchives(arg1:string,arg2:string):Promise<backend.MergeArchivesResult>;
+
+export function MoveArchive(arg1:string,arg2:string,arg3:string):Promise<backend.MoveArchiveResult>;
+
+export function MoveQueueAuthors(arg1:string,arg2:Array<string>,arg3:boolean):Promise<void>;
+
+export function MoveQueueItems(arg1:string,arg2:Array<number>,arg3:number):Promise<void>;
+
 export function OpenFolder(arg1:string):Promise<void>;
 
+export function PauseDownload(arg1:string,arg2:boolean):Promise<void>;
+
+export function PreflightDownload(arg1:main.DownloadMediaWithMetadataRequest):Promise<backend.PreflightReport>;
+
+export function ProbeMedia(arg1:string):Promise<backend.MediaProbe>;
+
 export function Quit():Promise<void>;
 
+export function RemoveQueueAuthors(arg1:string,arg2:Array<string>):Promise<void>;
+
+export function RemoveQueueItems(arg1:string,arg2:Array<number>):Promise<void>;
+
+export function ReorganizeArchive(arg1:backend.ReorganizeRequest):Promise<backend.ReorganizeResult>;
+
+export function ResolveAccountRename(arg1:string,arg2:string):Promise<backend.AccountRename>;
+
+export function ResolveConflict(arg1:string,arg2:string,arg3:boolean):Promise<boolean>;
+
+export function ResumeDownload(arg1:string):Promise<void>;
+
+export function ResumeQueue(arg1:string):Promise<main.ResumeQueueResponse>;
+
+export function RetryFailed(arg1:string):Promise<main.RetryFailedResponse>;
+
+export function RollbackTool(arg1:string):Promise<void>;
+
 export function SaveAccountToDB(arg1:string,arg2:string,arg3:string,arg4:number,arg5:string,arg6:string):Promise<void>;
 
 export function SaveAccountToDBWithStatus(arg1:string,arg2:string,arg3:string,arg4:number,arg5:string,arg6:string,arg7:string,arg8:boolean):Promise<void>;
 
+export function SaveCollection(arg1:backend.SmartCollection):Promise<backend.SmartCollection>;
+
 export function SelectFolder(arg1:string):Promise<string>;
 
+export function SelectVideoFiles(arg1:string):Promise<Array<string>>;
+
+export function SetAccountSensitive(arg1:number,arg2:boolean):Promise<void>;
+
+export function SetAccountWatch(arg1:boolean,arg2:number,arg3:string):Promise<void>;
+
+export function SetClipboardWatch(arg1:boolean):Promise<void>;
+
+export function SetDataDir(arg1:string):Promise<backend.DataDirInfo>;
+
+export function SetLockPassphrase(arg1:string,arg2:string):Promise<void>;
+
+export function SetMediaServer(arg1:boolean,arg2:number,arg3:string):Promise<backend.MediaServerInfo>;
+
+export function SetMediaStarred(arg1:backend.MediaRef,arg2:boolean):Promise<void>;
+
+export function SetMediaTags(arg1:backend.MediaRef,arg2:Array<string>):Promise<void>;
+
+export function SetSecret(arg1:string,arg2:string):Promise<void>;
+
+export function SetSettings(arg1:backend.Settings):Promise<backend.Settings>;
+
 export function StopDownload():Promise<boolean>;
 
+export function StopTimelines():Promise<boolean>;
+
+export function SyncAll(arg1:main.SyncAllRequest):Promise<backend.SyncAllReport>;
+
+export function TestS3Connection(arg1:backend.S3Config):Promise<void>;
+
+export function TestSFTPConnection(arg1:backend.SFTPConfig):Promise<void>;
+
+export function TestWebDAVConnection(arg1:backend.WebDAVConfig):Promise<void>;
+
+export function UndoReorganize(arg1:string):Promise<backend.ReorganizeResult>;
+
+export function UnlockContent(arg1:string):Promise<void>;
+
 export function UpdateAccountGroup(arg1:number,arg2:string,arg3:string):Promise<void>;
+
+export function UpdateTool(arg1:string,arg2:string):Promise<void>;
@@ -2,6 +2,22 @@
 // Cynhyrchwyd y ffeil hon yn awtomatig. PEIDIWCH Â MODIWL
 // This file is automatically generated. DO NOT EDIT
 
+export function ArchiveFollowing(arg1) {
+  return window['go']['main']['App']['ArchiveFollowing'](arg1);
+}
+
+export function BackfillMetadata(arg1, arg2) {
+  return window['go']['main']['App']['BackfillMetadata'](arg1, arg2);
+}
+
+export function BatchImport() {
+  return window['go']['main']['App']['BatchImport']();
+}
+
+export function CheckAccount(arg1, arg2) {
+  return window['go']['main']['App']['CheckAccount'](arg1, arg2);
+}
+
 export function CheckFolderExists(arg1, arg2) {
   return window['go']['main']['App']['CheckFolderExists'](arg1, arg2);
 }
@@ -14,6 +30,10 @@ export function CheckGifsFolderHasMP4(arg1, arg2) {
   return window['go']['main']['App']['CheckGifsFolderHasMP4'](arg1, arg2);
 }
 
+export function CheckToolUpdates(arg1) {
+  return window['go']['main']['App']['CheckToolUpdates'](arg1);
+}
+
 export function CleanupExtractorProcesses() {
   return window['go']['main']['App']['CleanupExtractorProcesses']();
 }
@@ -22,20 +42,68 @@ export function ClearAllAccountsFromDB() {
   return window['go']['main']['App']['ClearAllAccountsFromDB']();
 }
 
+export function ClearAuditLog() {
+  return window['go']['main']['App']['ClearAuditLog']();
+}
+
+export function ClearGalleryCache() {
+  return window['go']['main']['App']['ClearGalleryCache']();
+}
+
+export function CompareArchives(arg1, arg2, arg3) {
+  return window['go']['main']['App']['CompareArchives'](arg1, arg2, arg3);
+}
+
+export function ConfirmDownload(arg1, arg2) {
+  return window['go']['main']['App']['ConfirmDownload'](arg1, arg2);
+}
+
+export function ConvertFilesToGIF(arg1) {
+  return window['go']['main']['App']['ConvertFilesToGIF'](arg1);
+}
+
 export function ConvertGIFs(arg1) {
   return window['go']['main']['App']['ConvertGIFs'](arg1);
 }
 
+export function ConvertVideos(arg1) {
+  return window['go']['main']['App']['ConvertVideos'](arg1);
+}
+
+export function ConvertWebPImages(arg1) {
+  return window['go']['main']['App']['ConvertWebPImages'](arg1);
+}
+
+export function DecryptArchive(arg1) {
+  return window['go']['main']['App']['DecryptArchive'](arg1);
+}
+
 export function DeleteAccountFromDB(arg1) {
   return window['go']['main']['App']['DeleteAccountFromDB'](arg1);
 }
 
-export function DownloadExifTool() {
-  return window['go']['main']['App']['DownloadExifTool']();
+export function DeleteCollection(arg1) {
+  return window['go']['main']['App']['DeleteCollection'](arg1);
+}
+
+export function DeleteQueue(arg1) {
+  return window['go']['main']['App']['DeleteQueue'](arg1);
+}
+
+export function Diagnostics(arg1, arg2) {
+  return window['go']['main']['App']['Diagnostics'](arg1, arg2);
+}
+
+export function DisableEncryption(arg1, arg2) {
+  return window['go']['main']['App']['DisableEncryption'](arg1, arg2);
 }
 
-export function DownloadFFmpeg() {
-  return window['go']['main']['App']['DownloadFFmpeg']();
+export function DownloadExifTool(arg1) {
+  return window['go']['main']['App']['DownloadExifTool'](arg1);
+}
+
+export function DownloadFFmpeg(arg1) {
+  return window['go']['main']['App']['DownloadFFmpeg'](arg1);
 }
 
 export function DownloadMedia(arg1) {
@@ -46,6 +114,30 @@ export function DownloadMediaWithMetadata(arg1) {
   return window['go']['main']['App']['DownloadMediaWithMetadata'](arg1);
 }
 
+export function DownloadSelection(arg1, arg2) {
+  return window['go']['main']['App']['DownloadSelection'](arg1, arg2);
+}
+
+export function DryRunDownload(arg1) {
+  return window['go']['main']['App']['DryRunDownload'](arg1);
+}
+
+export function EnableEncryption(arg1) {
+  return window['go']['main']['App']['EnableEncryption'](arg1);
+}
+
+export function EncryptArchive(arg1) {
+  return window['go']['main']['App']['EncryptArchive'](arg1);
+}
+
+export function EstimateJob(arg1) {
+  return window['go']['main']['App']['EstimateJob'](arg1);
+}
+
+export function EvaluateCollection(arg1) {
+  return window['go']['main']['App']['EvaluateCollection'](arg1);
+}
+
 export function ExportAccountJSON(arg1, arg2) {
   return window['go']['main']['App']['ExportAccountJSON'](arg1, arg2);
 }
@@ -54,18 +146,62 @@ export function ExportAccountsTXT(arg1, arg2) {
   return window['go']['main']['App']['ExportAccountsTXT'](arg1, arg2);
 }
 
+export function ExportDebugBundle(arg1) {
+  return window['go']['main']['App']['ExportDebugBundle'](arg1);
+}
+
+export function ExportHTMLGallery(arg1) {
+  return window['go']['main']['App']['ExportHTMLGallery'](arg1);
+}
+
+export function ExportHydrus(arg1, arg2) {
+  return window['go']['main']['App']['ExportHydrus'](arg1, arg2);
+}
+
+export function ExportLabeledMedia(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ExportLabeledMedia'](arg1, arg2, arg3);
+}
+
+export function ExportMarkdownNotes(arg1, arg2) {
+  return window['go']['main']['App']['ExportMarkdownNotes'](arg1, arg2);
+}
+
 export function ExtractDateRange(arg1) {
   return window['go']['main']['App']['ExtractDateRange'](arg1);
 }
 
+export function ExtractParallel(arg1) {
+  return window['go']['main']['App']['ExtractParallel'](arg1);
+}
+
 export function ExtractTimeline(arg1) {
   return window['go']['main']['App']['ExtractTimeline'](arg1);
 }
 
+export function ExtractTimelines(arg1) {
+  return window['go']['main']['App']['ExtractTimelines'](arg1);
+}
+
+export function FetchBatchEntry(arg1, arg2, arg3) {
+  return window['go']['main']['App']['FetchBatchEntry'](arg1, arg2, arg3);
+}
+
+export function FindLabeledMedia(arg1) {
+  return window['go']['main']['App']['FindLabeledMedia'](arg1);
+}
+
+export function GenerateVideoPreviews(arg1) {
+  return window['go']['main']['App']['GenerateVideoPreviews'](arg1);
+}
+
 export function GetAccountFromDB(arg1) {
   return window['go']['main']['App']['GetAccountFromDB'](arg1);
 }
 
+export function GetAccountRenames() {
+  return window['go']['main']['App']['GetAccountRenames']();
+}
+
 export function GetAllAccountsFromDB() {
   return window['go']['main']['App']['GetAllAccountsFromDB']();
 }
@@ -74,22 +210,110 @@ export function GetAllGroups() {
   return window['go']['main']['App']['GetAllGroups']();
 }
 
+export function GetAuditLogPath() {
+  return window['go']['main']['App']['GetAuditLogPath']();
+}
+
+export function GetAuditSummary() {
+  return window['go']['main']['App']['GetAuditSummary']();
+}
+
+export function GetDataDir() {
+  return window['go']['main']['App']['GetDataDir']();
+}
+
 export function GetDefaults() {
   return window['go']['main']['App']['GetDefaults']();
 }
 
+export function GetEncryptionStatus() {
+  return window['go']['main']['App']['GetEncryptionStatus']();
+}
+
+export function GetExifToolStatus() {
+  return window['go']['main']['App']['GetExifToolStatus']();
+}
+
 export function GetFolderPath(arg1, arg2) {
   return window['go']['main']['App']['GetFolderPath'](arg1, arg2);
 }
 
+export function GetGalleryCacheStats() {
+  return window['go']['main']['App']['GetGalleryCacheStats']();
+}
+
 export function GetGifsFolderPath(arg1, arg2) {
   return window['go']['main']['App']['GetGifsFolderPath'](arg1, arg2);
 }
 
+export function GetJobHistory(arg1) {
+  return window['go']['main']['App']['GetJobHistory'](arg1);
+}
+
+export function GetLockStatus() {
+  return window['go']['main']['App']['GetLockStatus']();
+}
+
+export function GetMediaLabels(arg1) {
+  return window['go']['main']['App']['GetMediaLabels'](arg1);
+}
+
+export function GetMessageCatalog() {
+  return window['go']['main']['App']['GetMessageCatalog']();
+}
+
+export function GetQueueItems(arg1, arg2, arg3) {
+  return window['go']['main']['App']['GetQueueItems'](arg1, arg2, arg3);
+}
+
+export function GetQueuePath(arg1) {
+  return window['go']['main']['App']['GetQueuePath'](arg1);
+}
+
+export function GetRecoveredMedia(arg1) {
+  return window['go']['main']['App']['GetRecoveredMedia'](arg1);
+}
+
+export function GetSecret(arg1) {
+  return window['go']['main']['App']['GetSecret'](arg1);
+}
+
+export function GetSecretStorage() {
+  return window['go']['main']['App']['GetSecretStorage']();
+}
+
+export function GetSettings() {
+  return window['go']['main']['App']['GetSettings']();
+}
+
+export function GetStats() {
+  return window['go']['main']['App']['GetStats']();
+}
+
+export function GetThumbnail(arg1, arg2) {
+  return window['go']['main']['App']['GetThumbnail'](arg1, arg2);
+}
+
+export function GetTimelineTypes() {
+  return window['go']['main']['App']['GetTimelineTypes']();
+}
+
+export function GetWaybackSaveStatus() {
+  return window['go']['main']['App']['GetWaybackSaveStatus']();
+}
+
 export function ImportAccountFromJSON() {
   return window['go']['main']['App']['ImportAccountFromJSON']();
 }
 
+export function ImportTool(arg1) {
+  return window['go']['main']['App']['ImportTool'](arg1);
+}
+
+export function ImportTwitterArchive(arg1) {
+  return window['go']['main']['App']['ImportTwitterArchive'](arg1);
+}
+
 export function IsExifToolInstalled() {
   return window['go']['main']['App']['IsExifToolInstalled']();
 }
@@ -98,14 +322,106 @@ export function IsFFmpegInstalled() {
   return window['go']['main']['App']['IsFFmpegInstalled']();
 }
 
+export function IsFFprobeInstalled() {
+  return window['go']['main']['App']['IsFFprobeInstalled']();
+}
+
+export function ListCollections() {
+  return window['go']['main']['App']['ListCollections']();
+}
+
+export function ListGallery(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ListGallery'](arg1, arg2, arg3);
+}
+
+export function ListMediaTags(arg1) {
+  return window['go']['main']['App']['ListMediaTags'](arg1);
+}
+
+export function ListQueues() {
+  return window['go']['main']['App']['ListQueues']();
+}
+
+export function ListReorganizeJournals() {
+  return window['go']['main']['App']['ListReorganizeJournals']();
+}
+
+export function LockContent() {
+  return window['go']['main']['App']['LockContent']();
+}
+
+export function MergeArchives(arg1, arg2) {
+  return window['go']['main']['App']['MergeArchives'](arg1, arg2);
+}
+
+export function MoveArchive(arg1, arg2, arg3) {
+  return window['go']['main']['App']['MoveArchive'](arg1, arg2, arg3);
+}
+
+export function MoveQueueAuthors(arg1, arg2, arg3) {
+  return window['go']['main']['App']['MoveQueueAuthors'](arg1, arg2, arg3);
+}
+
+export function MoveQueueItems(arg1, arg2, arg3) {
+  return window['go']['main']['App']['MoveQueueItems'](arg1, arg2, arg3);
+}
+
 export function OpenFolder(arg1) {
   return window['go']['main']['App']['OpenFolder'](arg1);
 }
 
+export function PauseDownload(arg1, arg2) {
+  return window['go']['main']['App']['PauseDownload'](arg1, arg2);
+}
+
+export function PreflightDownload(arg1) {
+  return window['go']['main']['App']['PreflightDownload'](arg1);
+}
+
+export function ProbeMedia(arg1) {
+  return window['go']['main']['App']['ProbeMedia'](arg1);
+}
+
 export function Quit() {
   return window['go']['main']['App']['Quit']();
 }
 
+export function RemoveQueueAuthors(arg1, arg2) {
+  return window['go']['main']['App']['RemoveQueueAuthors'](arg1, arg2);
+}
+
+export function RemoveQueueItems(arg1, arg2) {
+  return window['go']['main']['App']['RemoveQueueItems'](arg1, arg2);
+}
+
+export function ReorganizeArchive(arg1) {
+  return window['go']['main']['App']['ReorganizeArchive'](arg1);
+}
+
+export function ResolveAccountRename(arg1, arg2) {
+  return window['go']['main']['App']['ResolveAccountRename'](arg1, arg2);
+}
+
+export function ResolveConflict(arg1, arg2, arg3) {
+  return window['go']['main']['App']['ResolveConflict'](arg1, arg2, arg3);
+}
+
+export function ResumeDownload(arg1) {
+  return window['go']['main']['App']['ResumeDownload'](arg1);
+}
+
+export function ResumeQueue(arg1) {
+  return window['go']['main']['App']['ResumeQueue'](arg1);
+}
+
+export function RetryFailed(arg1) {
+  return window['go']['main']['App']['RetryFailed'](arg1);
+}
+
+export function RollbackTool(arg1) {
+  return window['go']['main']['App']['RollbackTool'](arg1);
+}
+
 export function SaveAccountToDB(arg1, arg2, arg3, arg4, arg5, arg6) {
   return window['go']['main']['App']['SaveAccountToDB'](arg1, arg2, arg3, arg4, arg5, arg6);
 }
@@ -114,14 +430,94 @@ export function SaveAccountToDBWithStatus(arg1, arg2, arg3, arg4, arg5, arg6, ar
   return window['go']['main']['App']['SaveAccountToDBWithStatus'](arg1, arg2, arg3, arg4, arg5, arg6, arg7, arg8);
 }
 
+export function SaveCollection(arg1) {
+  return window['go']['main']['App']['SaveCollection'](arg1);
+}
+
 export function SelectFolder(arg1) {
   return window['go']['main']['App']['SelectFolder'](arg1);
 }
 
+export function SelectVideoFiles(arg1) {
+  return window['go']['main']['App']['SelectVideoFiles'](arg1);
+}
+
+export function SetAccountSensitive(arg1, arg2) {
+  return window['go']['main']['App']['SetAccountSensitive'](arg1, arg2);
+}
+
+export function SetAccountWatch(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetAccountWatch'](arg1, arg2, arg3);
+}
+
+export function SetClipboardWatch(arg1) {
+  return window['go']['main']['App']['SetClipboardWatch'](arg1);
+}
+
+export function SetDataDir(arg1) {
+  return window['go']['main']['App']['SetDataDir'](arg1);
+}
+
+export function SetLockPassphrase(arg1, arg2) {
+  return window['go']['main']['App']['SetLockPassphrase'](arg1, arg2);
+}
+
+export function SetMediaServer(arg1, arg2, arg3) {
+  return window['go']['main']['App']['SetMediaServer'](arg1, arg2, arg3);
+}
+
+export function SetMediaStarred(arg1, arg2) {
+  return window['go']['main']['App']['SetMediaStarred'](arg1, arg2);
+}
+
+export function SetMediaTags(arg1, arg2) {
+  return window['go']['main']['App']['SetMediaTags'](arg1, arg2);
+}
+
+export function SetSecret(arg1, arg2) {
+  return window['go']['main']['App']['SetSecret'](arg1, arg2);
+}
+
+export function SetSettings(arg1) {
+  return window['go']['main']['App']['SetSettings'](arg1);
+}
+
 export function StopDownload() {
   return window['go']['main']['App']['StopDownload']();
 }
 
+export function StopTimelines() {
+  return window['go']['main']['App']['StopTimelines']();
+}
+
+export function SyncAll(arg1) {
+  return window['go']['main']['App']['SyncAll'](arg1);
+}
+
+export function TestS3Connection(arg1) {
+  return window['go']['main']['App']['TestS3Connection'](arg1);
+}
+
+export function TestSFTPConnection(arg1) {
+  return window['go']['main']['App']['TestSFTPConnection'](arg1);
+}
+
+export function TestWebDAVConnection(arg1) {
+  return window['go']['main']['App']['TestWebDAVConnection'](arg1);
+}
+
+export function UndoReorganize(arg1) {
+  return window['go']['main']['App']['UndoReorganize'](arg1);
+}
+
+export function UnlockContent(arg1) {
+  return window['go']['main']['App']['UnlockContent'](arg1);
+}
+
 export function UpdateAccountGroup(arg1, arg2, arg3) {
   return window['go']['main']['App']['UpdateAccountGroup'](arg1, arg2, arg3);
 }
+
+export function UpdateTool(arg1, arg2) {
+  return window['go']['main']['App']['UpdateTool'](arg1, arg2);
+}
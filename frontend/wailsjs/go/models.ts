@@ -14,6 +14,9 @@ export namespace backend {
 	    completed: boolean;
 	    followers_count: number;
 	    statuses_count: number;
+	    sensitive: boolean;
+	    archive_path: string;
+	    user_id: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new AccountListItem(source);
@@ -34,6 +37,2126 @@ export namespace backend {
 	        this.completed = source["completed"];
 	        this.followers_count = source["followers_count"];
 	        this.statuses_count = source["statuses_count"];
+	        this.sensitive = source["sensitive"];
+	        this.archive_path = source["archive_path"];
+	        this.user_id = source["user_id"];
+	    }
+	}
+	export class AccountRename {
+	    user_id: string;
+	    old_username: string;
+	    new_username: string;
+	    archive_path?: string;
+	    renamed_files: number;
+	    renamed_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AccountRename(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.user_id = source["user_id"];
+	        this.old_username = source["old_username"];
+	        this.new_username = source["new_username"];
+	        this.archive_path = source["archive_path"];
+	        this.renamed_files = source["renamed_files"];
+	        this.renamed_at = source["renamed_at"];
+	    }
+	}
+	export class AccountStats {
+	    username: string;
+	    media: number;
+	    bytes: number;
+	    last_run: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AccountStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.media = source["media"];
+	        this.bytes = source["bytes"];
+	        this.last_run = source["last_run"];
+	    }
+	}
+	export class AccountWatchResult {
+	    username: string;
+	    renamed_from?: string;
+	    status: string;
+	    previous_status: string;
+	    nick?: string;
+	    previous_nick?: string;
+	    deleted_tweets?: number;
+	    deleted_media?: number;
+	    message: string;
+	    urgent_archive: boolean;
+	    checked_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AccountWatchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.renamed_from = source["renamed_from"];
+	        this.status = source["status"];
+	        this.previous_status = source["previous_status"];
+	        this.nick = source["nick"];
+	        this.previous_nick = source["previous_nick"];
+	        this.deleted_tweets = source["deleted_tweets"];
+	        this.deleted_media = source["deleted_media"];
+	        this.message = source["message"];
+	        this.urgent_archive = source["urgent_archive"];
+	        this.checked_at = source["checked_at"];
+	    }
+	}
+	export class Engagement {
+	    views: number;
+	    likes: number;
+	    retweets: number;
+	    replies: number;
+	    bookmarks: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new Engagement(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.views = source["views"];
+	        this.likes = source["likes"];
+	        this.retweets = source["retweets"];
+	        this.replies = source["replies"];
+	        this.bookmarks = source["bookmarks"];
+	    }
+	}
+	export class EngagementChange {
+	    tweet_id: string;
+	    date?: string;
+	    before: Engagement;
+	    after: Engagement;
+	
+	    static createFrom(source: any = {}) {
+	        return new EngagementChange(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tweet_id = source["tweet_id"];
+	        this.date = source["date"];
+	        this.before = this.convertValues(source["before"], Engagement);
+	        this.after = this.convertValues(source["after"], Engagement);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ArchiveDiffMedia {
+	    tweet_id: string;
+	    index: number;
+	    date?: string;
+	    type?: string;
+	    url?: string;
+	    path?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ArchiveDiffMedia(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tweet_id = source["tweet_id"];
+	        this.index = source["index"];
+	        this.date = source["date"];
+	        this.type = source["type"];
+	        this.url = source["url"];
+	        this.path = source["path"];
+	    }
+	}
+	export class ArchiveSnapshotInfo {
+	    source: string;
+	    kind: string;
+	    media: number;
+	    tweets: number;
+	    followers_count?: number;
+	    statuses_count?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ArchiveSnapshotInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.kind = source["kind"];
+	        this.media = source["media"];
+	        this.tweets = source["tweets"];
+	        this.followers_count = source["followers_count"];
+	        this.statuses_count = source["statuses_count"];
+	    }
+	}
+	export class ArchiveDiff {
+	    username: string;
+	    old: ArchiveSnapshotInfo;
+	    new: ArchiveSnapshotInfo;
+	    added: ArchiveDiffMedia[];
+	    removed: ArchiveDiffMedia[];
+	    engagement_changes: EngagementChange[];
+	    engagement_compared: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ArchiveDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.old = this.convertValues(source["old"], ArchiveSnapshotInfo);
+	        this.new = this.convertValues(source["new"], ArchiveSnapshotInfo);
+	        this.added = this.convertValues(source["added"], ArchiveDiffMedia);
+	        this.removed = this.convertValues(source["removed"], ArchiveDiffMedia);
+	        this.engagement_changes = this.convertValues(source["engagement_changes"], EngagementChange);
+	        this.engagement_compared = source["engagement_compared"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class AuditHostSummary {
+	    host: string;
+	    purposes: string[];
+	    requests: number;
+	    errors: number;
+	    sent: number;
+	    received: number;
+	    first_seen: string;
+	    last_seen: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new AuditHostSummary(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.purposes = source["purposes"];
+	        this.requests = source["requests"];
+	        this.errors = source["errors"];
+	        this.sent = source["sent"];
+	        this.received = source["received"];
+	        this.first_seen = source["first_seen"];
+	        this.last_seen = source["last_seen"];
+	    }
+	}
+	export class BackfillMetadataResult {
+	    folder: string;
+	    files: number;
+	    embedded: number;
+	    skipped: number;
+	    unmatched: number;
+	    failed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BackfillMetadataResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.folder = source["folder"];
+	        this.files = source["files"];
+	        this.embedded = source["embedded"];
+	        this.skipped = source["skipped"];
+	        this.unmatched = source["unmatched"];
+	        this.failed = source["failed"];
+	    }
+	}
+	export class BatchFetchResult {
+	    accounts: string[];
+	    media: number;
+	    partial?: boolean;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BatchFetchResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.accounts = source["accounts"];
+	        this.media = source["media"];
+	        this.partial = source["partial"];
+	        this.error = source["error"];
+	    }
+	}
+	export class BatchImportEntry {
+	    line: number;
+	    input: string;
+	    mode: string;
+	    username?: string;
+	    timeline_type?: string;
+	    tweet_id?: string;
+	    url?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new BatchImportEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.input = source["input"];
+	        this.mode = source["mode"];
+	        this.username = source["username"];
+	        this.timeline_type = source["timeline_type"];
+	        this.tweet_id = source["tweet_id"];
+	        this.url = source["url"];
+	    }
+	}
+	export class QueueLineError {
+	    line: number;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueLineError(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.line = source["line"];
+	        this.message = source["message"];
+	    }
+	}
+	export class BatchImportResult {
+	    entries: BatchImportEntry[];
+	    errors: QueueLineError[];
+	    duplicates: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new BatchImportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.entries = this.convertValues(source["entries"], BatchImportEntry);
+	        this.errors = this.convertValues(source["errors"], QueueLineError);
+	        this.duplicates = source["duplicates"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CollectionMatch {
+	    media_key: string;
+	    username: string;
+	    author?: string;
+	    tweet_id: string;
+	    url: string;
+	    type: string;
+	    date: string;
+	    content?: string;
+	    likes: number;
+	    retweets: number;
+	    views: number;
+	    is_retweet: boolean;
+	    new: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new CollectionMatch(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.media_key = source["media_key"];
+	        this.username = source["username"];
+	        this.author = source["author"];
+	        this.tweet_id = source["tweet_id"];
+	        this.url = source["url"];
+	        this.type = source["type"];
+	        this.date = source["date"];
+	        this.content = source["content"];
+	        this.likes = source["likes"];
+	        this.retweets = source["retweets"];
+	        this.views = source["views"];
+	        this.is_retweet = source["is_retweet"];
+	        this.new = source["new"];
+	    }
+	}
+	export class CollectionQuery {
+	    usernames?: string[];
+	    types?: string[];
+	    min_likes?: number;
+	    min_retweets?: number;
+	    min_views?: number;
+	    since?: string;
+	    until?: string;
+	    text?: string;
+	    retweets?: string;
+	    tag?: string;
+	    starred?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new CollectionQuery(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.usernames = source["usernames"];
+	        this.types = source["types"];
+	        this.min_likes = source["min_likes"];
+	        this.min_retweets = source["min_retweets"];
+	        this.min_views = source["min_views"];
+	        this.since = source["since"];
+	        this.until = source["until"];
+	        this.text = source["text"];
+	        this.retweets = source["retweets"];
+	        this.tag = source["tag"];
+	        this.starred = source["starred"];
+	    }
+	}
+	export class SmartCollection {
+	    id: string;
+	    name: string;
+	    query: CollectionQuery;
+	    auto_export: string;
+	    auto_download: boolean;
+	    download_dir: string;
+	    created_at: string;
+	    last_run?: string;
+	    last_matches: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SmartCollection(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.name = source["name"];
+	        this.query = this.convertValues(source["query"], CollectionQuery);
+	        this.auto_export = source["auto_export"];
+	        this.auto_download = source["auto_download"];
+	        this.download_dir = source["download_dir"];
+	        this.created_at = source["created_at"];
+	        this.last_run = source["last_run"];
+	        this.last_matches = source["last_matches"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class CollectionResult {
+	    collection: SmartCollection;
+	    matches: CollectionMatch[];
+	    new: number;
+	    exported: number;
+	    downloaded: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new CollectionResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.collection = this.convertValues(source["collection"], SmartCollection);
+	        this.matches = this.convertValues(source["matches"], CollectionMatch);
+	        this.new = source["new"];
+	        this.exported = source["exported"];
+	        this.downloaded = source["downloaded"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DataDirInfo {
+	    path: string;
+	    default: string;
+	    source: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DataDirInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.default = source["default"];
+	        this.source = source["source"];
+	    }
+	}
+	export class DebugBundleRequest {
+	    logs: string[];
+	    settings: Record<string, any>;
+	    output_dir: string;
+	    proxy: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DebugBundleRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.logs = source["logs"];
+	        this.settings = source["settings"];
+	        this.output_dir = source["output_dir"];
+	        this.proxy = source["proxy"];
+	    }
+	}
+	export class DiagnosticCheck {
+	    name: string;
+	    status: string;
+	    detail: string;
+	    hint?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DiagnosticCheck(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.status = source["status"];
+	        this.detail = source["detail"];
+	        this.hint = source["hint"];
+	    }
+	}
+	export class DiagnosticsReport {
+	    os: string;
+	    arch: string;
+	    app_data_dir: string;
+	    output_dir: string;
+	    generated_at: string;
+	    checks: DiagnosticCheck[];
+	
+	    static createFrom(source: any = {}) {
+	        return new DiagnosticsReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.os = source["os"];
+	        this.arch = source["arch"];
+	        this.app_data_dir = source["app_data_dir"];
+	        this.output_dir = source["output_dir"];
+	        this.generated_at = source["generated_at"];
+	        this.checks = this.convertValues(source["checks"], DiagnosticCheck);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class WebDAVConfig {
+	    url: string;
+	    user?: string;
+	    password?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new WebDAVConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.user = source["user"];
+	        this.password = source["password"];
+	    }
+	}
+	export class S3Config {
+	    endpoint?: string;
+	    region?: string;
+	    bucket: string;
+	    access_key: string;
+	    secret_key: string;
+	    prefix?: string;
+	    path_style?: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new S3Config(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.endpoint = source["endpoint"];
+	        this.region = source["region"];
+	        this.bucket = source["bucket"];
+	        this.access_key = source["access_key"];
+	        this.secret_key = source["secret_key"];
+	        this.prefix = source["prefix"];
+	        this.path_style = source["path_style"];
+	    }
+	}
+	export class SFTPConfig {
+	    host: string;
+	    port?: number;
+	    user?: string;
+	    identity_file?: string;
+	    remote_dir: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SFTPConfig(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.host = source["host"];
+	        this.port = source["port"];
+	        this.user = source["user"];
+	        this.identity_file = source["identity_file"];
+	        this.remote_dir = source["remote_dir"];
+	    }
+	}
+	export class MediaFilter {
+	    orientation?: string;
+	    min_aspect_ratio?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MediaFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.orientation = source["orientation"];
+	        this.min_aspect_ratio = source["min_aspect_ratio"];
+	    }
+	}
+	export class DownloadOptions {
+	    filter: MediaFilter;
+	    sanitize_paths: boolean;
+	    queue_id: string;
+	    conflict: string;
+	    order: string;
+	    grace_minutes: number;
+	    video_preview: string;
+	    convert_webp: string;
+	    webp_quality: number;
+	    validate_media: boolean;
+	    date_zone: string;
+	    wayback_fallback: boolean;
+	    min_free_bytes: number;
+	    protected_fallback: boolean;
+	    collision_suffix: string;
+	    tweets_jsonl: boolean;
+	    tweets_txt: boolean;
+	    filename_template: string;
+	    confirm_above_bytes: number;
+	    sftp?: SFTPConfig;
+	    s3?: S3Config;
+	    webdav?: WebDAVConfig;
+	    archive: string;
+	    file_hook: string;
+	    batch_hook: string;
+	    max_archive_bytes: number;
+	    archive_cap_policy: string;
+	    max_file_bytes: number;
+	    max_job_bytes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new DownloadOptions(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.filter = this.convertValues(source["filter"], MediaFilter);
+	        this.sanitize_paths = source["sanitize_paths"];
+	        this.queue_id = source["queue_id"];
+	        this.conflict = source["conflict"];
+	        this.order = source["order"];
+	        this.grace_minutes = source["grace_minutes"];
+	        this.video_preview = source["video_preview"];
+	        this.convert_webp = source["convert_webp"];
+	        this.webp_quality = source["webp_quality"];
+	        this.validate_media = source["validate_media"];
+	        this.date_zone = source["date_zone"];
+	        this.wayback_fallback = source["wayback_fallback"];
+	        this.min_free_bytes = source["min_free_bytes"];
+	        this.protected_fallback = source["protected_fallback"];
+	        this.collision_suffix = source["collision_suffix"];
+	        this.tweets_jsonl = source["tweets_jsonl"];
+	        this.tweets_txt = source["tweets_txt"];
+	        this.filename_template = source["filename_template"];
+	        this.confirm_above_bytes = source["confirm_above_bytes"];
+	        this.sftp = this.convertValues(source["sftp"], SFTPConfig);
+	        this.s3 = this.convertValues(source["s3"], S3Config);
+	        this.webdav = this.convertValues(source["webdav"], WebDAVConfig);
+	        this.archive = source["archive"];
+	        this.file_hook = source["file_hook"];
+	        this.batch_hook = source["batch_hook"];
+	        this.max_archive_bytes = source["max_archive_bytes"];
+	        this.archive_cap_policy = source["archive_cap_policy"];
+	        this.max_file_bytes = source["max_file_bytes"];
+	        this.max_job_bytes = source["max_job_bytes"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class EncryptionResult {
+	    folder: string;
+	    files: number;
+	    failed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new EncryptionResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.folder = source["folder"];
+	        this.files = source["files"];
+	        this.failed = source["failed"];
+	    }
+	}
+	export class EncryptionStatus {
+	    enabled: boolean;
+	    unlocked: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new EncryptionStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.unlocked = source["unlocked"];
+	    }
+	}
+	
+	
+	export class ExifToolStatus {
+	    installed: boolean;
+	    perl_missing: boolean;
+	    fallback: boolean;
+	    message?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ExifToolStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.installed = source["installed"];
+	        this.perl_missing = source["perl_missing"];
+	        this.fallback = source["fallback"];
+	        this.message = source["message"];
+	    }
+	}
+	export class GalleryCacheStats {
+	    thumbnails: number;
+	    thumbnail_bytes: number;
+	    listings: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new GalleryCacheStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.thumbnails = source["thumbnails"];
+	        this.thumbnail_bytes = source["thumbnail_bytes"];
+	        this.listings = source["listings"];
+	    }
+	}
+	export class GalleryItem {
+	    path: string;
+	    name: string;
+	    type: string;
+	    tweet_id?: string;
+	    date?: string;
+	    size: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new GalleryItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.name = source["name"];
+	        this.type = source["type"];
+	        this.tweet_id = source["tweet_id"];
+	        this.date = source["date"];
+	        this.size = source["size"];
+	    }
+	}
+	export class GalleryPage {
+	    folder: string;
+	    total: number;
+	    items: GalleryItem[];
+	
+	    static createFrom(source: any = {}) {
+	        return new GalleryPage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.folder = source["folder"];
+	        this.total = source["total"];
+	        this.items = this.convertValues(source["items"], GalleryItem);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class HTMLGalleryResult {
+	    path: string;
+	    media: number;
+	    tweets: number;
+	    thumbnails: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HTMLGalleryResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.media = source["media"];
+	        this.tweets = source["tweets"];
+	        this.thumbnails = source["thumbnails"];
+	    }
+	}
+	export class HydrusExportResult {
+	    folder: string;
+	    exported: number;
+	    skipped: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new HydrusExportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.folder = source["folder"];
+	        this.exported = source["exported"];
+	        this.skipped = source["skipped"];
+	    }
+	}
+	export class JobEstimate {
+	    username: string;
+	    items: number;
+	    items_source: string;
+	    pages: number;
+	    requests: number;
+	    seconds_per_request: number;
+	    latency_source: string;
+	    rate_budget: number;
+	    rate_windows: number;
+	    duration_seconds: number;
+	    exceeds_rate_limit: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new JobEstimate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.items = source["items"];
+	        this.items_source = source["items_source"];
+	        this.pages = source["pages"];
+	        this.requests = source["requests"];
+	        this.seconds_per_request = source["seconds_per_request"];
+	        this.latency_source = source["latency_source"];
+	        this.rate_budget = source["rate_budget"];
+	        this.rate_windows = source["rate_windows"];
+	        this.duration_seconds = source["duration_seconds"];
+	        this.exceeds_rate_limit = source["exceeds_rate_limit"];
+	    }
+	}
+	export class JobEstimateRequest {
+	    username: string;
+	    auth_token?: string;
+	    timeline_type: string;
+	    media_type: string;
+	    batch_size: number;
+	    media_count?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new JobEstimateRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.auth_token = source["auth_token"];
+	        this.timeline_type = source["timeline_type"];
+	        this.media_type = source["media_type"];
+	        this.batch_size = source["batch_size"];
+	        this.media_count = source["media_count"];
+	    }
+	}
+	export class JobRecord {
+	    id: number;
+	    kind: string;
+	    username: string;
+	    started_at: string;
+	    duration_ms: number;
+	    items: number;
+	    bytes: number;
+	    failed: number;
+	    error?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new JobRecord(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.kind = source["kind"];
+	        this.username = source["username"];
+	        this.started_at = source["started_at"];
+	        this.duration_ms = source["duration_ms"];
+	        this.items = source["items"];
+	        this.bytes = source["bytes"];
+	        this.failed = source["failed"];
+	        this.error = source["error"];
+	    }
+	}
+	export class MonthStats {
+	    month: string;
+	    media: number;
+	    bytes: number;
+	    runs: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MonthStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.month = source["month"];
+	        this.media = source["media"];
+	        this.bytes = source["bytes"];
+	        this.runs = source["runs"];
+	    }
+	}
+	export class JobStats {
+	    total_media: number;
+	    total_bytes: number;
+	    extractions: number;
+	    downloads: number;
+	    failed_files: number;
+	    failed_runs: number;
+	    months: MonthStats[];
+	    top_accounts: AccountStats[];
+	
+	    static createFrom(source: any = {}) {
+	        return new JobStats(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.total_media = source["total_media"];
+	        this.total_bytes = source["total_bytes"];
+	        this.extractions = source["extractions"];
+	        this.downloads = source["downloads"];
+	        this.failed_files = source["failed_files"];
+	        this.failed_runs = source["failed_runs"];
+	        this.months = this.convertValues(source["months"], MonthStats);
+	        this.top_accounts = this.convertValues(source["top_accounts"], AccountStats);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class LabelExportResult {
+	    folder: string;
+	    exported: number;
+	    skipped: number;
+	    not_found: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new LabelExportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.folder = source["folder"];
+	        this.exported = source["exported"];
+	        this.skipped = source["skipped"];
+	        this.not_found = source["not_found"];
+	    }
+	}
+	export class LabelFilter {
+	    username: string;
+	    tag: string;
+	    starred: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LabelFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.tag = source["tag"];
+	        this.starred = source["starred"];
+	    }
+	}
+	export class LockStatus {
+	    enabled: boolean;
+	    locked: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new LockStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.enabled = source["enabled"];
+	        this.locked = source["locked"];
+	    }
+	}
+	export class MarkdownExportResult {
+	    folder: string;
+	    written: number;
+	    skipped: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MarkdownExportResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.folder = source["folder"];
+	        this.written = source["written"];
+	        this.skipped = source["skipped"];
+	    }
+	}
+	
+	export class MediaLabels {
+	    media_key: string;
+	    username: string;
+	    author?: string;
+	    tweet_id: string;
+	    url: string;
+	    starred: boolean;
+	    tags: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MediaLabels(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.media_key = source["media_key"];
+	        this.username = source["username"];
+	        this.author = source["author"];
+	        this.tweet_id = source["tweet_id"];
+	        this.url = source["url"];
+	        this.starred = source["starred"];
+	        this.tags = source["tags"];
+	    }
+	}
+	export class MediaProbe {
+	    path: string;
+	    size: number;
+	    format: string;
+	    duration: number;
+	    video_codec?: string;
+	    audio_codec?: string;
+	    width: number;
+	    height: number;
+	    corrupt: boolean;
+	    problem?: string;
+	    probed_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MediaProbe(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.path = source["path"];
+	        this.size = source["size"];
+	        this.format = source["format"];
+	        this.duration = source["duration"];
+	        this.video_codec = source["video_codec"];
+	        this.audio_codec = source["audio_codec"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.corrupt = source["corrupt"];
+	        this.problem = source["problem"];
+	        this.probed_at = source["probed_at"];
+	    }
+	}
+	export class MediaRef {
+	    username: string;
+	    author: string;
+	    tweet_id: string;
+	    url: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new MediaRef(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.author = source["author"];
+	        this.tweet_id = source["tweet_id"];
+	        this.url = source["url"];
+	    }
+	}
+	export class MediaServerInfo {
+	    port: number;
+	    key: string;
+	    urls: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new MediaServerInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.port = source["port"];
+	        this.key = source["key"];
+	        this.urls = source["urls"];
+	    }
+	}
+	export class MergeArchivesResult {
+	    source: string;
+	    target: string;
+	    copied: number;
+	    bytes: number;
+	    duplicates: number;
+	    kept_both: number;
+	    tweets: number;
+	    dead_links: number;
+	    failed_copy: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MergeArchivesResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.target = source["target"];
+	        this.copied = source["copied"];
+	        this.bytes = source["bytes"];
+	        this.duplicates = source["duplicates"];
+	        this.kept_both = source["kept_both"];
+	        this.tweets = source["tweets"];
+	        this.dead_links = source["dead_links"];
+	        this.failed_copy = source["failed_copy"];
+	    }
+	}
+	
+	export class MoveArchiveResult {
+	    source: string;
+	    target: string;
+	    files: number;
+	    bytes: number;
+	    renamed: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new MoveArchiveResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source = source["source"];
+	        this.target = source["target"];
+	        this.files = source["files"];
+	        this.bytes = source["bytes"];
+	        this.renamed = source["renamed"];
+	    }
+	}
+	export class PathIssue {
+	    index: number;
+	    tweet_id: string;
+	    path: string;
+	    problems: string[];
+	    message: string;
+	    fixed_path: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new PathIssue(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.index = source["index"];
+	        this.tweet_id = source["tweet_id"];
+	        this.path = source["path"];
+	        this.problems = source["problems"];
+	        this.message = source["message"];
+	        this.fixed_path = source["fixed_path"];
+	    }
+	}
+	export class PollChoice {
+	    label: string;
+	    votes: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new PollChoice(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.label = source["label"];
+	        this.votes = source["votes"];
+	    }
+	}
+	export class PreflightReport {
+	    output_dir: string;
+	    total_paths: number;
+	    max_path_length: number;
+	    case_insensitive: boolean;
+	    issues: PathIssue[];
+	
+	    static createFrom(source: any = {}) {
+	        return new PreflightReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.output_dir = source["output_dir"];
+	        this.total_paths = source["total_paths"];
+	        this.max_path_length = source["max_path_length"];
+	        this.case_insensitive = source["case_insensitive"];
+	        this.issues = this.convertValues(source["issues"], PathIssue);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QueueAuthor {
+	    username: string;
+	    items: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueAuthor(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.items = source["items"];
+	    }
+	}
+	export class TweetPoll {
+	    choices: PollChoice[];
+	    total_votes: number;
+	    ends_at?: string;
+	    final: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TweetPoll(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.choices = this.convertValues(source["choices"], PollChoice);
+	        this.total_votes = source["total_votes"];
+	        this.ends_at = source["ends_at"];
+	        this.final = source["final"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TweetCard {
+	    name: string;
+	    url?: string;
+	    title?: string;
+	    description?: string;
+	    domain?: string;
+	    image?: string;
+	    poll?: TweetPoll;
+	
+	    static createFrom(source: any = {}) {
+	        return new TweetCard(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.name = source["name"];
+	        this.url = source["url"];
+	        this.title = source["title"];
+	        this.description = source["description"];
+	        this.domain = source["domain"];
+	        this.image = source["image"];
+	        this.poll = this.convertValues(source["poll"], TweetPoll);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QueueItem {
+	    position: number;
+	    url: string;
+	    date: string;
+	    tweet_id: number;
+	    type: string;
+	    username: string;
+	    content?: string;
+	    original_filename?: string;
+	    width?: number;
+	    height?: number;
+	    engagement?: number;
+	    favorite_count?: number;
+	    retweet_count?: number;
+	    reply_count?: number;
+	    view_count?: number;
+	    bookmark_count?: number;
+	    card?: TweetCard;
+	    media_index?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueItem(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.position = source["position"];
+	        this.url = source["url"];
+	        this.date = source["date"];
+	        this.tweet_id = source["tweet_id"];
+	        this.type = source["type"];
+	        this.username = source["username"];
+	        this.content = source["content"];
+	        this.original_filename = source["original_filename"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.engagement = source["engagement"];
+	        this.favorite_count = source["favorite_count"];
+	        this.retweet_count = source["retweet_count"];
+	        this.reply_count = source["reply_count"];
+	        this.view_count = source["view_count"];
+	        this.bookmark_count = source["bookmark_count"];
+	        this.card = this.convertValues(source["card"], TweetCard);
+	        this.media_index = source["media_index"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class QueueJob {
+	    version: number;
+	    id: string;
+	    username: string;
+	    output_dir: string;
+	    proxy?: string;
+	    options: DownloadOptions;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    updated_at: any;
+	    pending: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new QueueJob(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.id = source["id"];
+	        this.username = source["username"];
+	        this.output_dir = source["output_dir"];
+	        this.proxy = source["proxy"];
+	        this.options = this.convertValues(source["options"], DownloadOptions);
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.updated_at = this.convertValues(source["updated_at"], null);
+	        this.pending = source["pending"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class QueuePage {
+	    job: QueueJob;
+	    items: QueueItem[];
+	    total: number;
+	    offset: number;
+	    authors: QueueAuthor[];
+	    running: boolean;
+	    invalid_lines?: QueueLineError[];
+	
+	    static createFrom(source: any = {}) {
+	        return new QueuePage(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.job = this.convertValues(source["job"], QueueJob);
+	        this.items = this.convertValues(source["items"], QueueItem);
+	        this.total = source["total"];
+	        this.offset = source["offset"];
+	        this.authors = this.convertValues(source["authors"], QueueAuthor);
+	        this.running = source["running"];
+	        this.invalid_lines = this.convertValues(source["invalid_lines"], QueueLineError);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RecoveredMedia {
+	    media_url: string;
+	    tweet_id: string;
+	    username: string;
+	    path: string;
+	    snapshot_url: string;
+	    recovered_at: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new RecoveredMedia(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.media_url = source["media_url"];
+	        this.tweet_id = source["tweet_id"];
+	        this.username = source["username"];
+	        this.path = source["path"];
+	        this.snapshot_url = source["snapshot_url"];
+	        this.recovered_at = source["recovered_at"];
+	    }
+	}
+	export class ReorganizeMove {
+	    from: string;
+	    to: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReorganizeMove(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.from = source["from"];
+	        this.to = source["to"];
+	    }
+	}
+	export class ReorganizeJournal {
+	    id: string;
+	    username: string;
+	    from_template: string;
+	    to_template: string;
+	    // Go type: time
+	    created_at: any;
+	    // Go type: time
+	    undone_at?: any;
+	    moves: ReorganizeMove[];
+	
+	    static createFrom(source: any = {}) {
+	        return new ReorganizeJournal(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.username = source["username"];
+	        this.from_template = source["from_template"];
+	        this.to_template = source["to_template"];
+	        this.created_at = this.convertValues(source["created_at"], null);
+	        this.undone_at = this.convertValues(source["undone_at"], null);
+	        this.moves = this.convertValues(source["moves"], ReorganizeMove);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	export class ReorganizeRequest {
+	    username: string;
+	    output_dir: string;
+	    from_template: string;
+	    to_template: string;
+	    from_date_zone: string;
+	    to_date_zone: string;
+	    dry_run: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReorganizeRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.output_dir = source["output_dir"];
+	        this.from_template = source["from_template"];
+	        this.to_template = source["to_template"];
+	        this.from_date_zone = source["from_date_zone"];
+	        this.to_date_zone = source["to_date_zone"];
+	        this.dry_run = source["dry_run"];
+	    }
+	}
+	export class ReorganizeResult {
+	    journal_id?: string;
+	    moves: ReorganizeMove[];
+	    conflicts: ReorganizeMove[];
+	    unchanged: number;
+	    not_found: number;
+	    failed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ReorganizeResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.journal_id = source["journal_id"];
+	        this.moves = this.convertValues(source["moves"], ReorganizeMove);
+	        this.conflicts = this.convertValues(source["conflicts"], ReorganizeMove);
+	        this.unchanged = source["unchanged"];
+	        this.not_found = source["not_found"];
+	        this.failed = source["failed"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	
+	
+	export class Settings {
+	    version: number;
+	    max_concurrent_downloads: number;
+	    filename_template: string;
+	    collision_suffix: string;
+	    proxy: string;
+	    bandwidth_kbps: number;
+	    extractor_timeout_minutes: number;
+	    extractor_idle_minutes: number;
+	    renamed_folders: string;
+	    audit_log: boolean;
+	    ffmpeg_path: string;
+	    ffprobe_path: string;
+	    exiftool_path: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new Settings(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.version = source["version"];
+	        this.max_concurrent_downloads = source["max_concurrent_downloads"];
+	        this.filename_template = source["filename_template"];
+	        this.collision_suffix = source["collision_suffix"];
+	        this.proxy = source["proxy"];
+	        this.bandwidth_kbps = source["bandwidth_kbps"];
+	        this.extractor_timeout_minutes = source["extractor_timeout_minutes"];
+	        this.extractor_idle_minutes = source["extractor_idle_minutes"];
+	        this.renamed_folders = source["renamed_folders"];
+	        this.audit_log = source["audit_log"];
+	        this.ffmpeg_path = source["ffmpeg_path"];
+	        this.ffprobe_path = source["ffprobe_path"];
+	        this.exiftool_path = source["exiftool_path"];
+	    }
+	}
+	
+	export class SyncAccountResult {
+	    username: string;
+	    renamed_from?: string;
+	    media_type: string;
+	    status: string;
+	    fetched: number;
+	    new_media: number;
+	    downloaded: number;
+	    skipped: number;
+	    failed: number;
+	    output_dir?: string;
+	    error?: string;
+	    retry_at?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncAccountResult(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.renamed_from = source["renamed_from"];
+	        this.media_type = source["media_type"];
+	        this.status = source["status"];
+	        this.fetched = source["fetched"];
+	        this.new_media = source["new_media"];
+	        this.downloaded = source["downloaded"];
+	        this.skipped = source["skipped"];
+	        this.failed = source["failed"];
+	        this.output_dir = source["output_dir"];
+	        this.error = source["error"];
+	        this.retry_at = source["retry_at"];
+	    }
+	}
+	export class SyncAllReport {
+	    started_at: string;
+	    finished_at: string;
+	    accounts: SyncAccountResult[];
+	    synced: number;
+	    partial: number;
+	    failed: number;
+	    pending: number;
+	    new_media: number;
+	    downloaded: number;
+	    skipped: number;
+	    failed_files: number;
+	    retry_at?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncAllReport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.started_at = source["started_at"];
+	        this.finished_at = source["finished_at"];
+	        this.accounts = this.convertValues(source["accounts"], SyncAccountResult);
+	        this.synced = source["synced"];
+	        this.partial = source["partial"];
+	        this.failed = source["failed"];
+	        this.pending = source["pending"];
+	        this.new_media = source["new_media"];
+	        this.downloaded = source["downloaded"];
+	        this.skipped = source["skipped"];
+	        this.failed_files = source["failed_files"];
+	        this.retry_at = source["retry_at"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class SyncAuthor {
+	    username: string;
+	    new_files: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncAuthor(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.new_files = source["new_files"];
+	    }
+	}
+	export class SyncDiff {
+	    id: string;
+	    username: string;
+	    output_dir: string;
+	    new_tweets: number;
+	    new_files: number;
+	    existing_files: number;
+	    filtered_files: number;
+	    new_files_by_type: Record<string, number>;
+	    estimated_bytes: number;
+	    measured_files: number;
+	    authors?: SyncAuthor[];
+	    threshold_bytes?: number;
+	    needs_confirmation: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncDiff(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.username = source["username"];
+	        this.output_dir = source["output_dir"];
+	        this.new_tweets = source["new_tweets"];
+	        this.new_files = source["new_files"];
+	        this.existing_files = source["existing_files"];
+	        this.filtered_files = source["filtered_files"];
+	        this.new_files_by_type = source["new_files_by_type"];
+	        this.estimated_bytes = source["estimated_bytes"];
+	        this.measured_files = source["measured_files"];
+	        this.authors = this.convertValues(source["authors"], SyncAuthor);
+	        this.threshold_bytes = source["threshold_bytes"];
+	        this.needs_confirmation = source["needs_confirmation"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TagCount {
+	    tag: string;
+	    count: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TagCount(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tag = source["tag"];
+	        this.count = source["count"];
+	    }
+	}
+	export class TimelineEntry {
+	    url: string;
+	    date: string;
+	    tweet_id: number;
+	    type: string;
+	    is_retweet: boolean;
+	    quote_id?: number;
+	    reply_id?: number;
+	    conversation_id?: number;
+	    extension: string;
+	    width: number;
+	    height: number;
+	    content?: string;
+	    view_count?: number;
+	    bookmark_count?: number;
+	    favorite_count?: number;
+	    retweet_count?: number;
+	    reply_count?: number;
+	    source?: string;
+	    verified?: boolean;
+	    original_filename?: string;
+	    author_username?: string;
+	    card?: TweetCard;
+	    estimated_size?: number;
+	    media_index?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimelineEntry(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.date = source["date"];
+	        this.tweet_id = source["tweet_id"];
+	        this.type = source["type"];
+	        this.is_retweet = source["is_retweet"];
+	        this.quote_id = source["quote_id"];
+	        this.reply_id = source["reply_id"];
+	        this.conversation_id = source["conversation_id"];
+	        this.extension = source["extension"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.content = source["content"];
+	        this.view_count = source["view_count"];
+	        this.bookmark_count = source["bookmark_count"];
+	        this.favorite_count = source["favorite_count"];
+	        this.retweet_count = source["retweet_count"];
+	        this.reply_count = source["reply_count"];
+	        this.source = source["source"];
+	        this.verified = source["verified"];
+	        this.original_filename = source["original_filename"];
+	        this.author_username = source["author_username"];
+	        this.card = this.convertValues(source["card"], TweetCard);
+	        this.estimated_size = source["estimated_size"];
+	        this.media_index = source["media_index"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class TimelineFilter {
+	    source_include?: string[];
+	    source_exclude?: string[];
+	    verified?: string;
+	    author_include?: string[];
+	    author_exclude?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TimelineFilter(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.source_include = source["source_include"];
+	        this.source_exclude = source["source_exclude"];
+	        this.verified = source["verified"];
+	        this.author_include = source["author_include"];
+	        this.author_exclude = source["author_exclude"];
+	    }
+	}
+	export class TimelineTypeInfo {
+	    id: string;
+	    label: string;
+	    path: string;
+	    needs_username: boolean;
+	    premium: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new TimelineTypeInfo(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.id = source["id"];
+	        this.label = source["label"];
+	        this.path = source["path"];
+	        this.needs_username = source["needs_username"];
+	        this.premium = source["premium"];
+	    }
+	}
+	export class ToolUpdate {
+	    tool: string;
+	    installed: string;
+	    latest: string;
+	    bundled: boolean;
+	    update_available: boolean;
+	
+	    static createFrom(source: any = {}) {
+	        return new ToolUpdate(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.tool = source["tool"];
+	        this.installed = source["installed"];
+	        this.latest = source["latest"];
+	        this.bundled = source["bundled"];
+	        this.update_available = source["update_available"];
+	    }
+	}
+	
+	
+	export class TwitterArchiveImport {
+	    username: string;
+	    tweets: number;
+	    media: number;
+	    likes: number;
+	    likes_resolved: number;
+	    like_media: number;
+	    likes_pending: number;
+	    rate_limited: boolean;
+	    warnings?: string[];
+	
+	    static createFrom(source: any = {}) {
+	        return new TwitterArchiveImport(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.tweets = source["tweets"];
+	        this.media = source["media"];
+	        this.likes = source["likes"];
+	        this.likes_resolved = source["likes_resolved"];
+	        this.like_media = source["like_media"];
+	        this.likes_pending = source["likes_pending"];
+	        this.rate_limited = source["rate_limited"];
+	        this.warnings = source["warnings"];
+	    }
+	}
+	export class WaybackSaveStatus {
+	    queued: number;
+	    saved: number;
+	    failed: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new WaybackSaveStatus(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.queued = source["queued"];
+	        this.saved = source["saved"];
+	        this.failed = source["failed"];
 	    }
 	}
 
@@ -41,11 +2164,232 @@ export namespace backend {
 
 export namespace main {
 	
+	export class MediaItemRequest {
+	    url: string;
+	    date: string;
+	    tweet_id: number;
+	    type: string;
+	    content?: string;
+	    original_filename?: string;
+	    author_username?: string;
+	    width?: number;
+	    height?: number;
+	    engagement?: number;
+	    favorite_count?: number;
+	    retweet_count?: number;
+	    reply_count?: number;
+	    view_count?: number;
+	    bookmark_count?: number;
+	    card?: backend.TweetCard;
+	    media_index?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new MediaItemRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.url = source["url"];
+	        this.date = source["date"];
+	        this.tweet_id = source["tweet_id"];
+	        this.type = source["type"];
+	        this.content = source["content"];
+	        this.original_filename = source["original_filename"];
+	        this.author_username = source["author_username"];
+	        this.width = source["width"];
+	        this.height = source["height"];
+	        this.engagement = source["engagement"];
+	        this.favorite_count = source["favorite_count"];
+	        this.retweet_count = source["retweet_count"];
+	        this.reply_count = source["reply_count"];
+	        this.view_count = source["view_count"];
+	        this.bookmark_count = source["bookmark_count"];
+	        this.card = this.convertValues(source["card"], backend.TweetCard);
+	        this.media_index = source["media_index"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class DownloadMediaWithMetadataRequest {
+	    items: MediaItemRequest[];
+	    output_dir: string;
+	    username: string;
+	    proxy?: string;
+	    orientation?: string;
+	    min_aspect_ratio?: number;
+	    notify?: boolean;
+	    sanitize_paths?: boolean;
+	    conflict_policy?: string;
+	    max_archive_gb?: number;
+	    archive_cap?: string;
+	    order?: string;
+	    grace_minutes?: number;
+	    auth_token?: string;
+	    confirm_above_gb?: number;
+	    min_free_gb?: number;
+	    video_preview?: string;
+	    sftp?: backend.SFTPConfig;
+	    convert_webp?: string;
+	    webp_quality?: number;
+	    validate_media?: boolean;
+	    wayback_fallback?: boolean;
+	    date_zone?: string;
+	    filename_template?: string;
+	    collision_suffix?: string;
+	    protected_fallback?: boolean;
+	    tweets_jsonl?: boolean;
+	    tweets_txt?: boolean;
+	    max_file_mb?: number;
+	    max_job_gb?: number;
+	    archive?: string;
+	    s3?: backend.S3Config;
+	    webdav?: backend.WebDAVConfig;
+	    file_hook?: string;
+	    batch_hook?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new DownloadMediaWithMetadataRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.items = this.convertValues(source["items"], MediaItemRequest);
+	        this.output_dir = source["output_dir"];
+	        this.username = source["username"];
+	        this.proxy = source["proxy"];
+	        this.orientation = source["orientation"];
+	        this.min_aspect_ratio = source["min_aspect_ratio"];
+	        this.notify = source["notify"];
+	        this.sanitize_paths = source["sanitize_paths"];
+	        this.conflict_policy = source["conflict_policy"];
+	        this.max_archive_gb = source["max_archive_gb"];
+	        this.archive_cap = source["archive_cap"];
+	        this.order = source["order"];
+	        this.grace_minutes = source["grace_minutes"];
+	        this.auth_token = source["auth_token"];
+	        this.confirm_above_gb = source["confirm_above_gb"];
+	        this.min_free_gb = source["min_free_gb"];
+	        this.video_preview = source["video_preview"];
+	        this.sftp = this.convertValues(source["sftp"], backend.SFTPConfig);
+	        this.convert_webp = source["convert_webp"];
+	        this.webp_quality = source["webp_quality"];
+	        this.validate_media = source["validate_media"];
+	        this.wayback_fallback = source["wayback_fallback"];
+	        this.date_zone = source["date_zone"];
+	        this.filename_template = source["filename_template"];
+	        this.collision_suffix = source["collision_suffix"];
+	        this.protected_fallback = source["protected_fallback"];
+	        this.tweets_jsonl = source["tweets_jsonl"];
+	        this.tweets_txt = source["tweets_txt"];
+	        this.max_file_mb = source["max_file_mb"];
+	        this.max_job_gb = source["max_job_gb"];
+	        this.archive = source["archive"];
+	        this.s3 = this.convertValues(source["s3"], backend.S3Config);
+	        this.webdav = this.convertValues(source["webdav"], backend.WebDAVConfig);
+	        this.file_hook = source["file_hook"];
+	        this.batch_hook = source["batch_hook"];
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ArchiveFollowingRequest {
+	    auth_token: string;
+	    username?: string;
+	    list_id?: string;
+	    limit?: number;
+	    max_media_per_account?: number;
+	    download: DownloadMediaWithMetadataRequest;
+	
+	    static createFrom(source: any = {}) {
+	        return new ArchiveFollowingRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.auth_token = source["auth_token"];
+	        this.username = source["username"];
+	        this.list_id = source["list_id"];
+	        this.limit = source["limit"];
+	        this.max_media_per_account = source["max_media_per_account"];
+	        this.download = this.convertValues(source["download"], DownloadMediaWithMetadataRequest);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class ConvertFilesToGIFRequest {
+	    paths: string[];
+	    quality: string;
+	    resolution: string;
+	    delete_original: boolean;
+	    workers?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConvertFilesToGIFRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.paths = source["paths"];
+	        this.quality = source["quality"];
+	        this.resolution = source["resolution"];
+	        this.delete_original = source["delete_original"];
+	        this.workers = source["workers"];
+	    }
+	}
 	export class ConvertGIFsRequest {
 	    folder_path: string;
 	    quality: string;
 	    resolution: string;
 	    delete_original: boolean;
+	    workers?: number;
 	
 	    static createFrom(source: any = {}) {
 	        return new ConvertGIFsRequest(source);
@@ -57,6 +2401,7 @@ export namespace main {
 	        this.quality = source["quality"];
 	        this.resolution = source["resolution"];
 	        this.delete_original = source["delete_original"];
+	        this.workers = source["workers"];
 	    }
 	}
 	export class ConvertGIFsResponse {
@@ -77,6 +2422,64 @@ export namespace main {
 	        this.message = source["message"];
 	    }
 	}
+	export class ConvertVideosRequest {
+	    paths: string[];
+	    preset: string;
+	    replace_original: boolean;
+	    workers?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConvertVideosRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.paths = source["paths"];
+	        this.preset = source["preset"];
+	        this.replace_original = source["replace_original"];
+	        this.workers = source["workers"];
+	    }
+	}
+	export class ConvertVideosResponse {
+	    success: boolean;
+	    converted: number;
+	    failed: number;
+	    saved_bytes: number;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConvertVideosResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.success = source["success"];
+	        this.converted = source["converted"];
+	        this.failed = source["failed"];
+	        this.saved_bytes = source["saved_bytes"];
+	        this.message = source["message"];
+	    }
+	}
+	export class ConvertWebPImagesRequest {
+	    paths: string[];
+	    format: string;
+	    quality: number;
+	    keep_original: boolean;
+	    workers?: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new ConvertWebPImagesRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.paths = source["paths"];
+	        this.format = source["format"];
+	        this.quality = source["quality"];
+	        this.keep_original = source["keep_original"];
+	        this.workers = source["workers"];
+	    }
+	}
 	export class DateRangeRequest {
 	    username: string;
 	    auth_token: string;
@@ -84,6 +2487,11 @@ export namespace main {
 	    end_date: string;
 	    media_filter: string;
 	    retweets: boolean;
+	    filter?: backend.TimelineFilter;
+	    date_zone?: string;
+	    restricted_auth_token?: string;
+	    save_to_wayback?: boolean;
+	    grouped?: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new DateRangeRequest(source);
@@ -97,7 +2505,30 @@ export namespace main {
 	        this.end_date = source["end_date"];
 	        this.media_filter = source["media_filter"];
 	        this.retweets = source["retweets"];
+	        this.filter = this.convertValues(source["filter"], backend.TimelineFilter);
+	        this.date_zone = source["date_zone"];
+	        this.restricted_auth_token = source["restricted_auth_token"];
+	        this.save_to_wayback = source["save_to_wayback"];
+	        this.grouped = source["grouped"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 	export class DownloadMediaRequest {
 	    urls: string[];
@@ -122,7 +2553,12 @@ export namespace main {
 	    downloaded: number;
 	    skipped: number;
 	    failed: number;
+	    missing?: number;
+	    recovered?: number;
 	    message: string;
+	    queue_id?: string;
+	    declined?: boolean;
+	    output_dir?: string;
 	
 	    static createFrom(source: any = {}) {
 	        return new DownloadMediaResponse(source);
@@ -134,49 +2570,116 @@ export namespace main {
 	        this.downloaded = source["downloaded"];
 	        this.skipped = source["skipped"];
 	        this.failed = source["failed"];
+	        this.missing = source["missing"];
+	        this.recovered = source["recovered"];
 	        this.message = source["message"];
+	        this.queue_id = source["queue_id"];
+	        this.declined = source["declined"];
+	        this.output_dir = source["output_dir"];
 	    }
 	}
-	export class MediaItemRequest {
-	    url: string;
-	    date: string;
-	    tweet_id: number;
-	    type: string;
-	    content?: string;
-	    original_filename?: string;
-	    author_username?: string;
+	
+	export class GenerateVideoPreviewsRequest {
+	    paths: string[];
+	    kind: string;
+	    location: string;
+	    overwrite: boolean;
+	    workers?: number;
 	
 	    static createFrom(source: any = {}) {
-	        return new MediaItemRequest(source);
+	        return new GenerateVideoPreviewsRequest(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.url = source["url"];
-	        this.date = source["date"];
-	        this.tweet_id = source["tweet_id"];
-	        this.type = source["type"];
-	        this.content = source["content"];
-	        this.original_filename = source["original_filename"];
-	        this.author_username = source["author_username"];
+	        this.paths = source["paths"];
+	        this.kind = source["kind"];
+	        this.location = source["location"];
+	        this.overwrite = source["overwrite"];
+	        this.workers = source["workers"];
 	    }
 	}
-	export class DownloadMediaWithMetadataRequest {
-	    items: MediaItemRequest[];
-	    output_dir: string;
+	export class GenerateVideoPreviewsResponse {
+	    success: boolean;
+	    generated: number;
+	    skipped: number;
+	    failed: number;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new GenerateVideoPreviewsResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.success = source["success"];
+	        this.generated = source["generated"];
+	        this.skipped = source["skipped"];
+	        this.failed = source["failed"];
+	        this.message = source["message"];
+	    }
+	}
+	export class ImportAccountResponse {
+	    success: boolean;
 	    username: string;
-	    proxy?: string;
+	    message: string;
 	
 	    static createFrom(source: any = {}) {
-	        return new DownloadMediaWithMetadataRequest(source);
+	        return new ImportAccountResponse(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
-	        this.items = this.convertValues(source["items"], MediaItemRequest);
-	        this.output_dir = source["output_dir"];
+	        this.success = source["success"];
 	        this.username = source["username"];
-	        this.proxy = source["proxy"];
+	        this.message = source["message"];
+	    }
+	}
+	export class ImportToolResponse {
+	    success: boolean;
+	    message: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ImportToolResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.success = source["success"];
+	        this.message = source["message"];
+	    }
+	}
+	
+	export class ParallelRequest {
+	    username: string;
+	    auth_token: string;
+	    start_date?: string;
+	    end_date?: string;
+	    media_filter: string;
+	    retweets: boolean;
+	    shards: number;
+	    workers: number;
+	    filter?: backend.TimelineFilter;
+	    date_zone?: string;
+	    restricted_auth_token?: string;
+	
+	    static createFrom(source: any = {}) {
+	        return new ParallelRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.username = source["username"];
+	        this.auth_token = source["auth_token"];
+	        this.start_date = source["start_date"];
+	        this.end_date = source["end_date"];
+	        this.media_filter = source["media_filter"];
+	        this.retweets = source["retweets"];
+	        this.shards = source["shards"];
+	        this.workers = source["workers"];
+	        this.filter = this.convertValues(source["filter"], backend.TimelineFilter);
+	        this.date_zone = source["date_zone"];
+	        this.restricted_auth_token = source["restricted_auth_token"];
 	    }
 	
 		convertValues(a: any, classs: any, asMap: boolean = false): any {
@@ -197,23 +2700,122 @@ export namespace main {
 		    return a;
 		}
 	}
-	export class ImportAccountResponse {
+	export class ResumeQueueResponse {
 	    success: boolean;
-	    username: string;
+	    downloaded: number;
+	    skipped: number;
+	    failed: number;
+	    missing?: number;
+	    recovered?: number;
 	    message: string;
+	    queue_id?: string;
+	    declined?: boolean;
+	    output_dir?: string;
+	    invalid_lines: backend.QueueLineError[];
 	
 	    static createFrom(source: any = {}) {
-	        return new ImportAccountResponse(source);
+	        return new ResumeQueueResponse(source);
 	    }
 	
 	    constructor(source: any = {}) {
 	        if ('string' === typeof source) source = JSON.parse(source);
 	        this.success = source["success"];
-	        this.username = source["username"];
+	        this.downloaded = source["downloaded"];
+	        this.skipped = source["skipped"];
+	        this.failed = source["failed"];
+	        this.missing = source["missing"];
+	        this.recovered = source["recovered"];
+	        this.message = source["message"];
+	        this.queue_id = source["queue_id"];
+	        this.declined = source["declined"];
+	        this.output_dir = source["output_dir"];
+	        this.invalid_lines = this.convertValues(source["invalid_lines"], backend.QueueLineError);
+	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
+	export class RetryFailedResponse {
+	    success: boolean;
+	    downloaded: number;
+	    skipped: number;
+	    failed: number;
+	    missing?: number;
+	    recovered?: number;
+	    message: string;
+	    queue_id?: string;
+	    declined?: boolean;
+	    output_dir?: string;
+	    excluded: number;
+	
+	    static createFrom(source: any = {}) {
+	        return new RetryFailedResponse(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.success = source["success"];
+	        this.downloaded = source["downloaded"];
+	        this.skipped = source["skipped"];
+	        this.failed = source["failed"];
+	        this.missing = source["missing"];
+	        this.recovered = source["recovered"];
 	        this.message = source["message"];
+	        this.queue_id = source["queue_id"];
+	        this.declined = source["declined"];
+	        this.output_dir = source["output_dir"];
+	        this.excluded = source["excluded"];
 	    }
 	}
+	export class SyncAllRequest {
+	    auth_token: string;
+	    usernames?: string[];
+	    download: DownloadMediaWithMetadataRequest;
+	
+	    static createFrom(source: any = {}) {
+	        return new SyncAllRequest(source);
+	    }
+	
+	    constructor(source: any = {}) {
+	        if ('string' === typeof source) source = JSON.parse(source);
+	        this.auth_token = source["auth_token"];
+	        this.usernames = source["usernames"];
+	        this.download = this.convertValues(source["download"], DownloadMediaWithMetadataRequest);
+	    }
 	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
+	}
 	export class TimelineRequest {
 	    username: string;
 	    auth_token: string;
@@ -223,6 +2825,11 @@ export namespace main {
 	    media_type: string;
 	    retweets: boolean;
 	    cursor?: string;
+	    filter?: backend.TimelineFilter;
+	    date_zone?: string;
+	    restricted_auth_token?: string;
+	    save_to_wayback?: boolean;
+	    grouped?: boolean;
 	
 	    static createFrom(source: any = {}) {
 	        return new TimelineRequest(source);
@@ -238,7 +2845,30 @@ export namespace main {
 	        this.media_type = source["media_type"];
 	        this.retweets = source["retweets"];
 	        this.cursor = source["cursor"];
+	        this.filter = this.convertValues(source["filter"], backend.TimelineFilter);
+	        this.date_zone = source["date_zone"];
+	        this.restricted_auth_token = source["restricted_auth_token"];
+	        this.save_to_wayback = source["save_to_wayback"];
+	        this.grouped = source["grouped"];
 	    }
+	
+		convertValues(a: any, classs: any, asMap: boolean = false): any {
+		    if (!a) {
+		        return a;
+		    }
+		    if (a.slice && a.map) {
+		        return (a as any[]).map(elem => this.convertValues(elem, classs));
+		    } else if ("object" === typeof a) {
+		        if (asMap) {
+		            for (const key of Object.keys(a)) {
+		                a[key] = new classs(a[key]);
+		            }
+		            return a;
+		        }
+		        return new classs(a);
+		    }
+		    return a;
+		}
 	}
 
 }
@@ -70,6 +70,11 @@ def parse_args() -> argparse.Namespace:
         action="store_true",
         help="Emit JSON instead of plain URLs",
     )
+    parser.add_argument(
+        "--ndjson",
+        action="store_true",
+        help="Stream one JSON record per line as entries are fetched (survives crashes mid-run)",
+    )
     parser.add_argument(
         "--metadata",
         action="store_true",
@@ -149,6 +154,16 @@ def _progress_callback(count: int, cursor: Optional[str]) -> None:
     print(f"Fetching... {count} media{cursor_info}", file=sys.stderr)
 
 
+def _emit_record(kind: str, data: object) -> None:
+    """Write a single NDJSON record to stdout.
+
+    Records are {"type": "media"|"metadata"|"user"|"cursor"|"end", "data": ...}. Output is
+    flushed after every record so everything fetched so far survives a crash.
+    """
+    sys.stdout.write(json.dumps({"type": kind, "data": data}, default=str) + "\n")
+    sys.stdout.flush()
+
+
 def main() -> None:
     args = parse_args()
     
@@ -229,8 +244,19 @@ def main() -> None:
     # Pass seen_urls for deduplication if no cursor available
     skip_urls = seen_urls if (not resume_cursor and seen_urls) else None
 
+    item_cb = None
+    if args.ndjson:
+        # Records are written as they arrive, so previous results go first and
+        # boundary duplicates must be skipped up front
+        for entry in previous_media:
+            _emit_record("media", entry)
+        for entry in previous_metadata:
+            _emit_record("metadata", entry)
+        skip_urls = seen_urls or None
+        item_cb = _emit_record
+
     try:
-        result = run_request_dict(request, on_progress=progress_cb, skip_urls=skip_urls)
+        result = run_request_dict(request, on_progress=progress_cb, skip_urls=skip_urls, on_item=item_cb)
     except KeyboardInterrupt:
         print("\nInterrupted by user", file=sys.stderr)
         sys.exit(130)
@@ -277,7 +303,13 @@ def main() -> None:
                 file=sys.stderr,
             )
 
-    if args.json:
+    if args.ndjson:
+        _emit_record("end", {
+            "total": len(media),
+            "completed": result.get("completed", True),
+            "cursor": result.get("cursor"),
+        })
+    elif args.json:
         payload: Dict[str, object] = {
             "media": media,
             "total": len(media),
@@ -287,6 +319,8 @@ def main() -> None:
             payload["cursor"] = result["cursor"]
         if args.metadata:
             payload["metadata"] = metadata
+        if result.get("users"):
+            payload["users"] = result["users"]
         print(json.dumps(payload, indent=2, default=str))
     else:
         for item in media:
@@ -8,7 +8,8 @@ Flask) rely on this module to ensure consistent behavior.
 Features:
 - Cursor-based resume: Save progress and continue from where you left off
 - Progress tracking: Monitor fetch progress in real-time
-- Rate limit handling: Automatically handled by gallery-dl
+- Rate limit handling: Automatically handled by gallery-dl, the reset time is
+  reported on stderr as "Rate limit reset: <unix timestamp>"
 """
 
 from __future__ import annotations
@@ -17,8 +18,9 @@ import json
 import os
 import sys
 import threading
+import time
 from dataclasses import dataclass, field
-from datetime import date, datetime
+from datetime import date, datetime, timezone
 from typing import Any, Callable, Dict, Iterable, List, MutableMapping, Optional
 
 REPO_ROOT = os.path.dirname(os.path.abspath(__file__))
@@ -60,6 +62,7 @@ class TwitterResult:
 
     media: List[Dict[str, Any]]
     metadata: List[Dict[str, Any]]
+    users: List[Dict[str, Any]] = field(default_factory=list)  # Accounts of following/List member URLs
     cursor: Optional[str] = None  # Cursor to resume from
     total: int = 0
     completed: bool = True  # False if stopped before completion
@@ -94,6 +97,8 @@ def _extract_tweet_metadata(data: MutableMapping[str, Any]) -> Dict[str, Any]:
         "reply_count",
         "bookmark_count",
         "view_count",
+        "source",
+        "card",
     )
     meta = {key: _serialize_value(data.get(key)) for key in keys}
     if isinstance(meta["author"], dict):
@@ -101,6 +106,7 @@ def _extract_tweet_metadata(data: MutableMapping[str, Any]) -> Dict[str, Any]:
             "id": meta["author"].get("id"),
             "name": meta["author"].get("name"),
             "nick": meta["author"].get("nick"),
+            "verified": meta["author"].get("verified", False),
         }
     elif isinstance(meta["author"], str):
         # Handle case where author is just a string (username)
@@ -119,6 +125,22 @@ def _extract_tweet_metadata(data: MutableMapping[str, Any]) -> Dict[str, Any]:
     return meta
 
 
+def _extract_user(extractor: Any, data: Any) -> Optional[Dict[str, Any]]:
+    """Convert a queued account (following list, List members) into a user record."""
+    if not isinstance(data, dict):
+        return None
+    transform = getattr(extractor, "_transform_user", None)
+    if transform is None:
+        return None
+    try:
+        user = transform(data)
+    except (KeyError, TypeError):
+        return None
+    if not isinstance(user, dict) or not user.get("name"):
+        return None
+    return {key: _serialize_value(value) for key, value in user.items()}
+
+
 def _clean_file_metadata(meta: MutableMapping[str, Any]) -> Dict[str, Any]:
     return {key: _serialize_value(value) for key, value in meta.items()}
 
@@ -141,17 +163,69 @@ def _apply_options(options: Dict[str, Any], cursor: Optional[str] = None) -> Non
     else:
         config.set(("extractor", "twitter"), "cursor", True)
 
+    # Yield tweets with a link preview or poll, their card data is passed through (see _pass_through_cards)
+    config.set(("extractor", "twitter"), "cards", True)
+
     for key, value in options.items():
         if key in _INTERNAL_KEYS or value is None:
             continue
         config.set(("extractor", "twitter"), key, value)
 
 
+def _report_rate_limits(extractor: Any) -> None:
+    """Report the reset time on stderr whenever the extractor waits out a rate limit."""
+    original_wait = getattr(extractor, "wait", None)
+    if original_wait is None:
+        return
+
+    def wait(seconds=None, until=None, *args, **kwargs):
+        try:
+            if until is not None:
+                if isinstance(until, datetime):
+                    # gallery-dl uses naive UTC datetimes
+                    if until.tzinfo is None:
+                        until = until.replace(tzinfo=timezone.utc)
+                    reset = until.timestamp()
+                else:
+                    reset = float(until)
+            elif seconds is not None:
+                reset = time.time() + float(seconds)
+            else:
+                reset = None
+            if reset is not None:
+                print(f"Rate limit reset: {int(reset)}", file=sys.stderr, flush=True)
+        except (TypeError, ValueError):
+            pass
+        return original_wait(seconds, until, *args, **kwargs)
+
+    extractor.wait = wait
+
+
+def _pass_through_cards(extractor: Any) -> None:
+    """Copy the card (poll or link preview) of every tweet into its metadata as "card"."""
+    original_transform = getattr(extractor, "_transform_tweet", None)
+    if original_transform is None:
+        return
+
+    def transform(tweet, *args, **kwargs):
+        tdata = original_transform(tweet, *args, **kwargs)
+        if isinstance(tweet, dict) and isinstance(tdata, dict):
+            card = tweet.get("card")
+            if card is None and isinstance(tweet.get("legacy"), dict):
+                card = tweet["legacy"].get("card")
+            if card is not None:
+                tdata["card"] = card
+        return tdata
+
+    extractor._transform_tweet = transform
+
+
 def run_request(
     request: TwitterRequest,
     on_progress: Optional[Callable[[int, Optional[str]], None]] = None,
     skip_urls: Optional[set] = None,
     ensure_cursor: bool = True,
+    on_item: Optional[Callable[[str, Any], None]] = None,
 ) -> TwitterResult:
     """Run Twitter extractor and return media & metadata results.
     
@@ -160,6 +234,8 @@ def run_request(
         on_progress: Optional callback(count, cursor) called periodically during fetch
         skip_urls: Optional set of URLs to skip (for resume/deduplication)
         ensure_cursor: If True, continue fetching until cursor is available (for reliable resume)
+        on_item: Optional callback(kind, data) called for every "media"/"metadata"/"user" entry as soon
+            as it's fetched, and with kind "cursor" whenever the resume cursor changes
     
     Returns:
         TwitterResult with media, metadata, cursor for resume, and completion status
@@ -171,9 +247,12 @@ def run_request(
         extractor = extractor_mod.find(request.url)
         if extractor is None or extractor.category != "twitter":
             raise ValueError(f"URL not recognized by Twitter extractor: {request.url}")
+        _report_rate_limits(extractor)
+        _pass_through_cards(extractor)
 
         media: List[Dict[str, Any]] = []
         metadata: List[Dict[str, Any]] = []
+        users: List[Dict[str, Any]] = []
         collected = 0
         skipped = 0
         last_cursor: Optional[str] = None
@@ -187,7 +266,22 @@ def run_request(
                 if mtype is Message.Directory and request.metadata:
                     # Only extract metadata if message[1] is a dict
                     if isinstance(message[1], dict):
-                        metadata.append(_extract_tweet_metadata(message[1]))
+                        meta = _extract_tweet_metadata(message[1])
+                        metadata.append(meta)
+                        if on_item:
+                            on_item("metadata", meta)
+                elif mtype is Message.Queue:
+                    # Account lists queue one user timeline per account
+                    user = _extract_user(extractor, message[2] if len(message) > 2 else None)
+                    if user is None:
+                        continue
+                    users.append(user)
+                    collected += 1
+                    if on_item:
+                        on_item("user", user)
+                    if request.limit and collected >= request.limit:
+                        completed = False
+                        break
                 elif mtype is Message.Url:
                     url = message[1]
                     
@@ -216,8 +310,11 @@ def run_request(
                             # If filter evaluation fails, include the item (fail-open)
                             pass
                     
-                    media.append({"url": url, **file_meta})
+                    item = {"url": url, **file_meta}
+                    media.append(item)
                     collected += 1
+                    if on_item:
+                        on_item("media", item)
                     
                     # Track last tweet_id for progress display
                     if "tweet_id" in file_meta:
@@ -225,6 +322,8 @@ def run_request(
                     
                     # Try to get cursor from extractor
                     if hasattr(extractor, '_cursor') and extractor._cursor:
+                        if on_item and extractor._cursor != last_cursor:
+                            on_item("cursor", extractor._cursor)
                         last_cursor = extractor._cursor
                     
                     # Report progress every 10 items
@@ -255,6 +354,7 @@ def run_request(
         return TwitterResult(
             media=media,
             metadata=metadata,
+            users=users,
             cursor=last_cursor,
             total=collected,
             completed=completed,
@@ -266,12 +366,14 @@ def run_request_dict(
     on_progress: Optional[Callable[[int, Optional[str]], None]] = None,
     skip_urls: Optional[set] = None,
     ensure_cursor: bool = True,
+    on_item: Optional[Callable[[str, Any], None]] = None,
 ) -> Dict[str, Any]:
     """Run request and return as dictionary (for JSON serialization)."""
-    result = run_request(request, on_progress, skip_urls, ensure_cursor)
+    result = run_request(request, on_progress, skip_urls, ensure_cursor, on_item)
     return {
         "media": result.media,
         "metadata": result.metadata,
+        "users": result.users,
         "cursor": result.cursor,
         "total": result.total,
         "completed": result.completed,
@@ -2,8 +2,11 @@ package main
 
 import (
 	"embed"
+	"fmt"
 	"log"
+	"os"
 	"runtime"
+	"twitterxmediabatchdownloader/backend"
 
 	"github.com/wailsapp/wails/v2"
 	"github.com/wailsapp/wails/v2/pkg/options"
@@ -16,6 +19,11 @@ import (
 var assets embed.FS
 
 func main() {
+	// Headless JSON-over-stdio mode for third-party frontends
+	if len(os.Args) > 1 && os.Args[1] == "--ipc" {
+		os.Exit(runIPC())
+	}
+
 	// Create an instance of the app structure
 	app := NewApp()
 
@@ -51,6 +59,7 @@ func main() {
 			WindowIsTranslucent:               false,
 			DisableWindowIcon:                 false,
 			DisableFramelessWindowDecorations: false,
+			WebviewUserDataPath:               backend.WebviewDataDir(), // Settings stay in the data folder in portable mode
 		},
 		Mac: &mac.Options{
 			TitleBar: &mac.TitleBar{
@@ -74,3 +83,18 @@ func main() {
 		log.Fatal("Error:", err.Error())
 	}
 }
+
+// runIPC serves the headless interface and returns the exit code
+// stdout only carries protocol messages, so the backend's own output is sent to stderr
+func runIPC() int {
+	out := os.Stdout
+	os.Stdout = os.Stderr
+
+	backend.InitDB()
+	defer backend.CloseDB()
+	if err := backend.ServeIPC(os.Stdin, out); err != nil {
+		fmt.Fprintln(os.Stderr, "Error:", err.Error())
+		return 1
+	}
+	return 0
+}